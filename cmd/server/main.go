@@ -33,29 +33,68 @@ func main() {
 
 	supplyRepo := models.NewSupplyRepository(database.DB)
 	userRepo := models.NewUserRepository(database.DB)
+	refreshTokenRepo := models.NewRefreshTokenRepository(database.DB)
+	loginAttemptRepo := models.NewLoginAttemptRepository(database.DB)
+	passwordResetRepo := models.NewPasswordResetTokenRepository(database.DB)
 	supplyTaskRepo := models.NewSupplyTaskRepository(database.DB)
 	orderRepo := models.NewOrderRepository(database.DB)
 	invoiceMatchRepo := models.NewInvoiceReconciliationRepository(database.DB)
 	vinmesCatalogRepo := models.NewVinmesCatalogRepository(database.DB)
+	vinmesExportLedgerRepo := models.NewVinmesExportLedgerRepository(database.DB)
+	vinmesOverrideRepo := models.NewVinmesMappingOverrideRepository(database.DB)
+	orderEmailTemplateRepo := models.NewOrderEmailTemplateRepository(database.DB)
 	orderUnreadRepo := models.NewOrderUnreadRepository(database.DB)
 	companyContactRepo := models.NewCompanyContactRepository(database.DB)
 	forecastApprovalRepo := models.NewForecastApprovalRepository(database.DB)
+	forecastPeriodRepo := models.NewForecastPeriodRepository(database.DB)
 	schemaMaintenanceRepo := models.NewSchemaMaintenanceRepository(database.DB)
 	hoaDonRepo := models.NewHoaDonRepository(database.DB)
+	backgroundJobRepo := models.NewBackgroundJobRepository(database.DB)
+	internalSupplySyncRunRepo := models.NewInternalSupplySyncRunRepository(database.DB)
+	supplyStockSnapshotRepo := models.NewSupplyStockSnapshotRepository(database.DB)
+	stockAlertRepo := models.NewStockAlertRepository(database.DB)
+	auditEventRepo := models.NewAuditEventRepository(database.DB)
 
 	mustRunStartupStepsParallel(
+		startupStep{name: "user token version schema", run: userRepo.EnsureSchema},
+		startupStep{name: "refresh token schema", run: refreshTokenRepo.EnsureSchema},
+		startupStep{name: "login attempt schema", run: loginAttemptRepo.EnsureSchema},
+		startupStep{name: "password reset token schema", run: passwordResetRepo.EnsureSchema},
 		startupStep{name: "order history schema", run: orderRepo.EnsureSchema},
 		startupStep{name: "invoice reconciliation schema", run: invoiceMatchRepo.EnsureSchema},
 		startupStep{name: "order unread schema", run: orderUnreadRepo.EnsureSchema},
 		startupStep{name: "forecast approval schema", run: forecastApprovalRepo.EnsureSchema},
+		startupStep{name: "forecast period schema", run: forecastPeriodRepo.EnsureSchema},
 		startupStep{name: "supply task schema", run: supplyTaskRepo.EnsureSchema},
 		startupStep{name: "Vinmes catalog schema", run: vinmesCatalogRepo.EnsureSchema},
+		startupStep{name: "Vinmes export ledger schema", run: vinmesExportLedgerRepo.EnsureSchema},
+		startupStep{name: "Vinmes mapping override schema", run: vinmesOverrideRepo.EnsureSchema},
+		startupStep{name: "order email template schema", run: orderEmailTemplateRepo.EnsureSchema},
+		startupStep{name: "background job schema", run: backgroundJobRepo.EnsureSchema},
+		startupStep{name: "internal supply sync run schema", run: internalSupplySyncRunRepo.EnsureSchema},
+		startupStep{name: "supply stock snapshot schema", run: supplyStockSnapshotRepo.EnsureSchema},
+		startupStep{name: "stock alert schema", run: stockAlertRepo.EnsureSchema},
+		startupStep{name: "audit event schema", run: auditEventRepo.EnsureSchema},
 	)
 	mustRunStartupStep("invoice export schema", schemaMaintenanceRepo.EnsureInvoiceExportSchema)
 	mustRunStartupStep("company contacts schema", companyContactRepo.EnsureSchema)
 	mustRunStartupStep("relational schema", schemaMaintenanceRepo.EnsureRelationalIntegrity)
 
 	realtimeHub := realtime.NewHub()
+	jobRunner := services.NewJobRunner(services.JobRunnerConfig{
+		Store:  backgroundJobRepo,
+		Events: realtimeHub,
+	})
+	// Jobs still running belonged to the previous process; nothing will
+	// finish them, so fail them before any handler can start new ones.
+	mustRunStartupStep("interrupted background jobs", func() error {
+		count, err := jobRunner.FailInterrupted()
+		if count > 0 {
+			log.Printf("[startup] marked %d interrupted background jobs as failed", count)
+		}
+		return err
+	})
+	orderEmailTemplates := services.NewOrderEmailTemplateService(orderEmailTemplateRepo)
 	orderMailer := services.NewSMTPOrderMailer(services.SMTPOrderMailerConfig{
 		Host:        config.AppConfig.SMTPHost,
 		Port:        config.AppConfig.SMTPPort,
@@ -63,8 +102,46 @@ func main() {
 		AppPassword: config.AppConfig.SMTPAppPassword,
 		From:        config.AppConfig.SMTPFrom,
 		TLSPolicy:   config.AppConfig.SMTPTLSPolicy,
+		Templates:   orderEmailTemplates,
+	})
+	passwordReset := services.NewPasswordResetService(services.PasswordResetConfig{
+		Store: passwordResetRepo,
+		Users: userRepo,
+		Sender: services.NewSMTPPasswordResetMailer(services.SMTPPasswordResetMailerConfig{
+			Host:        config.AppConfig.SMTPHost,
+			Port:        config.AppConfig.SMTPPort,
+			Username:    config.AppConfig.SMTPUsername,
+			AppPassword: config.AppConfig.SMTPAppPassword,
+			From:        config.AppConfig.SMTPFrom,
+			TLSPolicy:   config.AppConfig.SMTPTLSPolicy,
+		}),
+		Throttle:             loginAttemptRepo,
+		LinkURL:              config.AppConfig.PasswordResetURL,
+		TokenMinutes:         config.AppConfig.PasswordResetTokenMinutes,
+		MaxRequestsPerEmail:  config.AppConfig.PasswordResetMaxPerEmail,
+		MaxRequestsPerIP:     config.AppConfig.PasswordResetMaxPerIP,
+		RequestWindowMinutes: config.AppConfig.PasswordResetWindowMinutes,
+	})
+	orderEmailOutbox := services.NewOrderEmailOutbox(services.OrderEmailOutboxConfig{
+		Store:               orderRepo,
+		Sender:              orderMailer,
+		PollIntervalSeconds: config.AppConfig.OrderEmailPollSeconds,
+		MaxAttempts:         config.AppConfig.OrderEmailMaxAttempts,
+	})
+	invoiceMatcher := services.NewInvoiceMatcher(services.InvoiceMatcherConfig{
+		Store:        invoiceMatchRepo,
+		MinScore:     float64(config.AppConfig.InvoiceMatchMinScore),
+		LookbackDays: config.AppConfig.InvoiceMatchLookbackDays,
+		DueDays:      config.AppConfig.OrderDeliveryDueDays,
 	})
-	internalSupplySyncService := services.NewInternalSupplySyncService(config.AppConfig, supplyRepo, companyContactRepo)
+	ubotInvoiceSync := services.NewUBotInvoiceSync(services.UBotInvoiceSyncConfig{
+		APIBaseURL:     config.AppConfig.UBotAPIBaseURL,
+		Username:       config.AppConfig.UBotUsername,
+		Password:       config.AppConfig.UBotPassword,
+		TimeoutSeconds: config.AppConfig.UBotAPITimeoutSeconds,
+		Store:          hoaDonRepo,
+	})
+	internalSupplySyncService := services.NewInternalSupplySyncService(config.AppConfig, supplyRepo, companyContactRepo, internalSupplySyncRunRepo, supplyStockSnapshotRepo)
 	geminiProxyService := services.NewGeminiProxyService(services.GeminiProxyConfig{
 		APIKey:          config.AppConfig.GeminiAPIKey,
 		Model:           config.AppConfig.GeminiModel,
@@ -73,33 +150,92 @@ func main() {
 		MaxOutputTokens: config.AppConfig.GeminiMaxOutputTokens,
 	})
 	vinmesCatalogService := services.NewVinmesCatalogService(services.VinmesCatalogConfig{
-		APIBaseURL:     config.AppConfig.VinmesAPIBaseURL,
-		APIToken:       config.AppConfig.VinmesAPIToken,
-		TimeoutSeconds: config.AppConfig.VinmesAPITimeoutSeconds,
-		CatalogStore:   vinmesCatalogRepo,
+		APIBaseURL:           config.AppConfig.VinmesAPIBaseURL,
+		APIToken:             config.AppConfig.VinmesAPIToken,
+		TimeoutSeconds:       config.AppConfig.VinmesAPITimeoutSeconds,
+		CatalogStore:         vinmesCatalogRepo,
+		SubmissionStore:      invoiceMatchRepo,
+		ExportLedger:         vinmesExportLedgerRepo,
+		RefreshLog:           vinmesCatalogRepo,
+		OverrideStore:        vinmesOverrideRepo,
+		ScheduleEnabled:      config.AppConfig.VinmesCatalogSyncEnabled,
+		ScheduleHour:         config.AppConfig.VinmesCatalogSyncHour,
+		ScheduleMinute:       config.AppConfig.VinmesCatalogSyncMinute,
+		ScheduleTimezone:     config.AppConfig.VinmesCatalogSyncTimezone,
+		ScheduleRunOnStartup: config.AppConfig.VinmesCatalogSyncRunOnStartup,
+	})
+	orderLifecycleSync := services.NewOrderLifecycleSync(services.OrderLifecycleSyncConfig{
+		Store:           orderRepo,
+		IntervalMinutes: config.AppConfig.OrderLifecycleSyncMinutes,
+	})
+	stockAlertEvaluator := services.NewStockAlertEvaluator(services.StockAlertEvaluatorConfig{
+		Supplies:        supplyRepo,
+		Orders:          orderRepo,
+		Store:           stockAlertRepo,
+		Assignees:       supplyTaskRepo,
+		Notifier:        realtimeHub,
+		IntervalMinutes: config.AppConfig.StockAlertIntervalMinutes,
+	})
+	forecastDeadlineReminder := services.NewForecastDeadlineReminder(services.ForecastDeadlineReminderConfig{
+		Store:     forecastPeriodRepo,
+		Publisher: realtimeHub,
+		LeadHours: config.AppConfig.ForecastDeadlineReminderHours,
 	})
 
-	router := newRouter(config.AppConfig.FrontendURL, apiHandlers{
+	router, err := newRouter(config.AppConfig.FrontendURL, config.AppConfig.TrustedProxies, apiHandlers{
+		authMiddleware: handlers.NewAuthMiddleware(userRepo, config.AppConfig.JWTSecret),
+		auditRecorder:  handlers.NewAuditRecorder(auditEventRepo),
 		auth: handlers.NewAuthHandler(
 			userRepo,
+			refreshTokenRepo,
+			loginAttemptRepo,
 			config.AppConfig.JWTSecret,
 			config.AppConfig.JWTExpiresHours,
 			config.AppConfig.JWTExpiresMinutes,
+			config.AppConfig.RefreshTokenExpiresDays,
+			models.LoginThrottlePolicy{
+				EmailMaxFailures: config.AppConfig.LoginMaxFailedAttempts,
+				IPMaxFailures:    config.AppConfig.LoginIPMaxFailedAttempts,
+				DelayAfter:       config.AppConfig.LoginDelayAfterAttempts,
+				BaseDelay:        time.Duration(config.AppConfig.LoginBaseDelaySeconds) * time.Second,
+				MaxDelay:         time.Duration(config.AppConfig.LoginMaxDelaySeconds) * time.Second,
+				Lockout:          time.Duration(config.AppConfig.LoginLockoutMinutes) * time.Minute,
+				Window:           time.Duration(config.AppConfig.LoginAttemptWindowMinutes) * time.Minute,
+			},
+			handlers.PasswordPolicy{
+				MinLength:        config.AppConfig.PasswordMinLength,
+				RequireMixedCase: config.AppConfig.PasswordRequireMixedCase,
+				RequireDigit:     config.AppConfig.PasswordRequireDigit,
+				RequireSymbol:    config.AppConfig.PasswordRequireSymbol,
+			},
+			passwordReset,
 		),
-		supplies:           handlers.NewSupplyHandler(supplyRepo, userRepo, supplyTaskRepo, config.AppConfig.JWTSecret),
-		supplyTasks:        handlers.NewSupplyTaskHandler(supplyRepo, supplyTaskRepo, userRepo, config.AppConfig.JWTSecret),
-		invoices:           handlers.NewHoaDonHandler(hoaDonRepo, userRepo, config.AppConfig.JWTSecret),
-		invoiceRefresh:     handlers.NewRefreshHandler(hoaDonRepo, userRepo, config.AppConfig.JWTSecret, realtimeHub),
-		internalSupplySync: handlers.NewInternalSupplySyncHandler(internalSupplySyncService, userRepo, config.AppConfig.JWTSecret),
-		orders:             handlers.NewOrderHandler(orderRepo, invoiceMatchRepo, orderUnreadRepo, companyContactRepo, userRepo, config.AppConfig.JWTSecret, orderMailer, realtimeHub, vinmesCatalogService),
-		forecastApprovals:  handlers.NewForecastApprovalHandler(forecastApprovalRepo, userRepo, config.AppConfig.JWTSecret, realtimeHub),
-		reports:            handlers.NewReportHandler(userRepo, config.AppConfig.JWTSecret, geminiProxyService),
+		supplies:           handlers.NewSupplyHandler(supplyRepo, supplyTaskRepo, supplyStockSnapshotRepo, orderRepo, jobRunner),
+		supplyTasks:        handlers.NewSupplyTaskHandler(supplyRepo, supplyTaskRepo, userRepo),
+		invoices:           handlers.NewHoaDonHandler(hoaDonRepo),
+		invoiceRefresh:     handlers.NewRefreshHandler(ubotInvoiceSync, jobRunner, realtimeHub),
+		internalSupplySync: handlers.NewInternalSupplySyncHandler(internalSupplySyncService, jobRunner),
+		orders:             handlers.NewOrderHandler(orderRepo, invoiceMatchRepo, orderUnreadRepo, companyContactRepo, orderMailer, realtimeHub, vinmesCatalogService, vinmesExportLedgerRepo, orderEmailOutbox, orderEmailTemplates, invoiceMatcher, jobRunner),
+		forecastApprovals:  handlers.NewForecastApprovalHandler(forecastApprovalRepo, forecastPeriodRepo, realtimeHub),
+		stockAlerts:        handlers.NewStockAlertHandler(stockAlertRepo, supplyTaskRepo),
+		jobs:               handlers.NewJobHandler(jobRunner),
+		reports:            handlers.NewReportHandler(geminiProxyService),
 		websocket:          handlers.NewWSHandler(userRepo, config.AppConfig.JWTSecret, realtimeHub, config.AppConfig.FrontendURL),
+		audit:              handlers.NewAuditHandler(auditEventRepo),
 	})
+	if err != nil {
+		log.Fatal("Failed to build router:", err)
+	}
 
 	backgroundCtx, cancelBackground := context.WithCancel(context.Background())
 	defer cancelBackground()
 	internalSupplySyncService.Start(backgroundCtx)
+	vinmesCatalogService.Start(backgroundCtx)
+	orderEmailOutbox.Start(backgroundCtx)
+	orderLifecycleSync.Start(backgroundCtx)
+	passwordReset.Start(backgroundCtx)
+	stockAlertEvaluator.Start(backgroundCtx)
+	forecastDeadlineReminder.Start(backgroundCtx)
 
 	go func() {
 		if err := router.Run(":" + config.AppConfig.ServerPort); err != nil {
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"bv108-consumables-management-backend/internal/handlers"
 
 	"github.com/gin-contrib/cors"
@@ -8,6 +10,7 @@ import (
 )
 
 type apiHandlers struct {
+	authMiddleware     *handlers.AuthMiddleware
 	auth               *handlers.AuthHandler
 	supplies           *handlers.SupplyHandler
 	supplyTasks        *handlers.SupplyTaskHandler
@@ -16,17 +19,30 @@ type apiHandlers struct {
 	internalSupplySync *handlers.InternalSupplySyncHandler
 	orders             *handlers.OrderHandler
 	forecastApprovals  *handlers.ForecastApprovalHandler
+	stockAlerts        *handlers.StockAlertHandler
+	jobs               *handlers.JobHandler
 	reports            *handlers.ReportHandler
 	websocket          *handlers.WSHandler
+	auditRecorder      *handlers.AuditRecorder
+	audit              *handlers.AuditHandler
 }
 
-func newRouter(frontendURL string, h apiHandlers) *gin.Engine {
+// newRouter builds the API router. Only the trustedProxies may set
+// X-Forwarded-For; with none, ClientIP is the TCP peer, which keeps login
+// throttling and the audit log from being fed a spoofed address.
+func newRouter(frontendURL string, trustedProxies []string, h apiHandlers) (*gin.Engine, error) {
 	router := gin.Default()
+	if len(trustedProxies) == 0 {
+		trustedProxies = nil
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
 	router.Use(cors.New(corsConfig(frontendURL)))
 	router.GET("/health", handlers.HealthCheck)
 
 	registerAPIRoutes(router.Group("/api"), h)
-	return router
+	return router, nil
 }
 
 func corsConfig(frontendURL string) cors.Config {
@@ -40,29 +56,58 @@ func corsConfig(frontendURL string) cors.Config {
 }
 
 func registerAPIRoutes(api *gin.RouterGroup, h apiHandlers) {
-	api.GET("/ws", h.websocket.Handle)
-	api.GET("/export-to-vinmes", h.orders.GetExportToVinmes)
-	api.GET("/export-to-vinmes/mapping-preview", h.orders.GetExportToVinmesMappingPreview)
-	api.POST("/export-to-vinmes/catalogs/refresh", h.orders.RefreshVinmesCatalogs)
+	api.Use(h.authMiddleware.Enforce(apiRoutePolicies))
+	api.Use(h.auditRecorder.Record(auditExemptRoutes...))
 
+	api.GET("/ws", h.websocket.Handle)
+	registerVinmesExportRoutes(api.Group("/export-to-vinmes"), h.orders)
 	registerAuthRoutes(api.Group("/auth"), h.auth)
 	registerSupplyRoutes(api.Group("/supplies"), h.supplies, h.internalSupplySync)
 	registerSupplyTaskRoutes(api.Group("/supply-tasks"), h.supplyTasks)
 	registerInvoiceRoutes(api.Group("/hoa-don"), h.invoices, h.invoiceRefresh)
 	registerOrderRoutes(api.Group("/orders"), h.orders)
 	registerForecastApprovalRoutes(api.Group("/forecast-approvals"), h.forecastApprovals)
+	registerForecastPeriodRoutes(api.Group("/forecast-periods"), h.forecastApprovals)
+	registerStockAlertRoutes(api.Group("/stock-alerts"), h.stockAlerts)
+	api.GET("/jobs/:id", h.jobs.GetJob)
+	api.POST("/jobs/:id/cancel", h.jobs.CancelJob)
 	api.POST("/reports/gemini-compare", h.reports.GenerateGeminiCompare)
+	api.GET("/audit", h.audit.ListAuditEvents)
+}
+
+func registerVinmesExportRoutes(group *gin.RouterGroup, h *handlers.OrderHandler) {
+	group.GET("", h.GetExportToVinmes)
+	group.GET("/mapping-preview", h.GetExportToVinmesMappingPreview)
+	group.POST("/catalogs/refresh", h.RefreshVinmesCatalogs)
+	group.GET("/catalogs/refreshes", h.ListVinmesCatalogRefreshRuns)
+	group.GET("/catalogs/refreshes/:id", h.GetVinmesCatalogRefreshRun)
+	group.POST("/submit", h.SubmitExportToVinmes)
+	group.GET("/ledger", h.ListVinmesExportLedger)
+	group.GET("/ledger/:id", h.GetVinmesExportLedgerEntry)
+	group.POST("/ledger/:id/retry", h.RetryVinmesExportLedgerEntry)
+	group.GET("/mapping-overrides", h.ListVinmesMappingOverrides)
+	group.POST("/mapping-overrides", h.CreateVinmesMappingOverride)
+	group.PUT("/mapping-overrides/:id", h.UpdateVinmesMappingOverride)
+	group.DELETE("/mapping-overrides/:id", h.DeleteVinmesMappingOverride)
 }
 
 func registerAuthRoutes(group *gin.RouterGroup, h *handlers.AuthHandler) {
 	group.POST("/register", h.Register)
 	group.POST("/login", h.Login)
+	group.POST("/refresh", h.RefreshSession)
+	group.POST("/logout", h.Logout)
+	group.POST("/logout-all", h.LogoutAllSessions)
+	group.POST("/forgot-password", h.ForgotPassword)
+	group.POST("/reset-password", h.ResetPassword)
+	group.PUT("/password", h.ChangePassword)
 	group.GET("/profile", h.GetProfile)
 	group.PUT("/profile", h.UpdateProfile)
 	group.GET("/users", h.ListManagedUsers)
 	group.PUT("/users/:id/role", h.UpdateManagedUserRole)
 	group.PUT("/users/:id/password", h.ResetManagedUserPassword)
 	group.DELETE("/users/:id", h.DeleteManagedUser)
+	group.POST("/users/:id/unlock", h.UnlockManagedUser)
+	group.GET("/login-attempts", h.ListLoginAttempts)
 }
 
 func registerSupplyRoutes(group *gin.RouterGroup, h *handlers.SupplyHandler, syncHandler *handlers.InternalSupplySyncHandler) {
@@ -77,9 +122,13 @@ func registerSupplyRoutes(group *gin.RouterGroup, h *handlers.SupplyHandler, syn
 	group.GET("/compare-export", h.ExportCompareCatalogExcel)
 	group.POST("/compare-import", h.ImportCompareCatalogExcel)
 	group.GET("/forecast-catalog", h.GetForecastCatalog)
+	group.GET("/forecast-suggestions", h.GetForecastSuggestions)
 	group.POST("/internal-sync", syncHandler.SyncNow)
+	group.GET("/internal-sync/runs", syncHandler.ListSyncRuns)
+	group.GET("/internal-sync/runs/:id", syncHandler.GetSyncRun)
 	group.POST("/compare", h.CompareSupplies)
 	group.GET("/:id", h.GetSupplyByID)
+	group.GET("/:id/stock-history", h.GetSupplyStockHistory)
 }
 
 func registerSupplyTaskRoutes(group *gin.RouterGroup, h *handlers.SupplyTaskHandler) {
@@ -97,22 +146,46 @@ func registerInvoiceRoutes(group *gin.RouterGroup, h *handlers.HoaDonHandler, re
 	group.GET("/search", h.SearchHoaDon)
 	group.GET("/:id", h.GetHoaDonByID)
 	group.POST("/refresh", refreshHandler.RefreshInvoices)
+	group.GET("/refresh/status", refreshHandler.GetInvoiceRefreshStatus)
 }
 
 func registerOrderRoutes(group *gin.RouterGroup, h *handlers.OrderHandler) {
 	group.GET("/pending", h.GetPendingOrders)
 	group.GET("/history", h.GetOrderHistory)
+	group.GET("/outstanding", h.GetOutstandingOrderQuantities)
 	group.GET("/invoice-reconciliations", h.GetInvoiceReconciliationHistory)
 	group.GET("/invoice-reconciliations/matched-invoices", h.GetMatchedInvoiceNumbers)
 	group.GET("/invoice-reconciliations/matched-orders", h.GetMatchedOrderReconciliations)
+	group.GET("/invoice-reconciliations/price-variances", h.GetInvoicePriceVarianceReport)
+	group.GET("/invoice-reconciliations/aging", h.GetInvoiceReconciliationAging)
 	group.GET("/company-contacts/search", h.SearchCompanyContacts)
 	group.GET("/unread-snapshot", h.GetUnreadSnapshot)
+	group.GET("/email-outbox", h.ListOrderEmails)
+	group.GET("/email-templates", h.ListOrderEmailTemplates)
+	group.GET("/invoice-matches", h.ListInvoiceMatches)
 	group.POST("/pending/forecast", h.CreateForecastOrders)
 	group.POST("/pending/manual", h.CreateManualOrder)
+	group.POST("/pending/propose", h.ProposePendingOrders)
+	group.POST("/pending/batches/:batchId/approve", h.ApprovePendingOrderBatch)
+	group.POST("/pending/batches/:batchId/reject", h.RejectPendingOrderBatch)
 	group.POST("/place", h.PlaceOrders)
 	group.POST("/history/reorder", h.RepeatOrderHistory)
+	group.GET("/history/:id/amendments", h.ListOrderHistoryAmendments)
+	group.GET("/history/:id/pdf", h.GetOrderBatchPDF)
+	group.GET("/history/:id/xlsx", h.GetOrderBatchWorkbook)
+	group.POST("/history/:id/cancel", h.CancelOrderHistoryLine)
+	group.POST("/history/:id/amend", h.AmendOrderHistoryLine)
+	group.POST("/email-outbox/:id/resend", h.ResendOrderEmail)
+	group.POST("/email-templates", h.CreateOrderEmailTemplate)
+	group.POST("/email-templates/preview", h.PreviewOrderEmailTemplate)
+	group.PUT("/email-templates/:id", h.UpdateOrderEmailTemplate)
+	group.DELETE("/email-templates/:id", h.DeleteOrderEmailTemplate)
 	group.POST("/invoice-reconciliations/upsert", h.UpsertInvoiceReconciliations)
 	group.POST("/invoice-reconciliations/bulk", h.SaveInvoiceReconciliations)
+	group.POST("/invoice-reconciliations/price-variances/recheck", h.RecheckInvoicePriceVariances)
+	group.POST("/invoice-matches/run", h.RunInvoiceMatcher)
+	group.POST("/invoice-matches/:id/accept", h.AcceptInvoiceMatch)
+	group.POST("/invoice-matches/:id/reject", h.RejectInvoiceMatch)
 	group.POST("/alerts/suppliers/seen", h.MarkSupplierAlertSeen)
 	group.POST("/groups/seen", h.MarkGroupsSeen)
 }
@@ -124,3 +197,186 @@ func registerForecastApprovalRoutes(group *gin.RouterGroup, h *handlers.Forecast
 	group.POST("", h.SaveForecastApproval)
 	group.POST("/bulk", h.SaveForecastApprovalsBulk)
 }
+
+func registerForecastPeriodRoutes(group *gin.RouterGroup, h *handlers.ForecastApprovalHandler) {
+	group.GET("", h.ListForecastPeriods)
+	group.GET("/:year/:month", h.GetForecastPeriod)
+	group.PUT("/:year/:month", h.UpdateForecastPeriod)
+	group.POST("/:year/:month/lock", h.LockForecastPeriod)
+	group.POST("/:year/:month/unlock", h.UnlockForecastPeriod)
+}
+
+func registerStockAlertRoutes(group *gin.RouterGroup, h *handlers.StockAlertHandler) {
+	group.GET("", h.ListStockAlerts)
+	group.POST("/:id/acknowledge", h.AcknowledgeStockAlert)
+	group.POST("/:id/snooze", h.SnoozeStockAlert)
+}
+
+var (
+	invoiceWorkflowViewers = []string{
+		handlers.RoleAdmin,
+		handlers.RoleChiHuyKhoa,
+		handlers.RoleNhanVienKho,
+		handlers.RoleThuKho,
+		handlers.RoleNhanVienKeToan,
+		handlers.RoleNhanVienThau,
+	}
+	adminOnly          = []string{handlers.RoleAdmin}
+	managerRoles       = []string{handlers.RoleAdmin, handlers.RoleChiHuyKhoa}
+	accountingRoles    = []string{handlers.RoleAdmin, handlers.RoleChiHuyKhoa, handlers.RoleNhanVienKeToan}
+	orderOperators     = []string{handlers.RoleAdmin, handlers.RoleChiHuyKhoa, handlers.RoleThuKho, handlers.RoleNhanVienThau}
+	orderProposers     = []string{handlers.RoleAdmin, handlers.RoleThuKho}
+	invoiceEditors     = []string{handlers.RoleThuKho}
+	priceVarianceUsers = []string{handlers.RoleAdmin, handlers.RoleThuKho, handlers.RoleNhanVienKeToan}
+	forecastEditors    = []string{handlers.RoleAdmin, handlers.RoleChiHuyKhoa, handlers.RoleThuKho, handlers.RoleNhanVienThau}
+)
+
+// apiRoutePolicies lists who may call every /api route. The auth middleware
+// refuses routes missing from this table, and routes_test.go keeps it in sync
+// with the registered routes.
+var apiRoutePolicies = handlers.RoutePolicies{
+	// The websocket reads its token from the subprotocol header; register
+	// allows the very first account to be created without one.
+	"GET /api/ws":             handlers.PublicRoute(),
+	"POST /api/auth/register": handlers.PublicRoute(),
+	"POST /api/auth/login":    handlers.PublicRoute(),
+	// Refresh and logout authenticate with the refresh token in the body.
+	"POST /api/auth/refresh": handlers.PublicRoute(),
+	"POST /api/auth/logout":  handlers.PublicRoute(),
+	// The reset flow authenticates with the emailed token.
+	"POST /api/auth/forgot-password": handlers.PublicRoute(),
+	"POST /api/auth/reset-password":  handlers.PublicRoute(),
+
+	"GET /api/export-to-vinmes":                          handlers.RolesRoute(invoiceWorkflowViewers...),
+	"GET /api/export-to-vinmes/mapping-preview":          handlers.RolesRoute(invoiceWorkflowViewers...),
+	"POST /api/export-to-vinmes/catalogs/refresh":        handlers.RolesRoute(accountingRoles...),
+	"GET /api/export-to-vinmes/catalogs/refreshes":       handlers.RolesRoute(invoiceWorkflowViewers...),
+	"GET /api/export-to-vinmes/catalogs/refreshes/:id":   handlers.RolesRoute(invoiceWorkflowViewers...),
+	"POST /api/export-to-vinmes/submit":                  handlers.RolesRoute(accountingRoles...),
+	"GET /api/export-to-vinmes/ledger":                   handlers.RolesRoute(invoiceWorkflowViewers...),
+	"GET /api/export-to-vinmes/ledger/:id":               handlers.RolesRoute(invoiceWorkflowViewers...),
+	"POST /api/export-to-vinmes/ledger/:id/retry":        handlers.RolesRoute(accountingRoles...),
+	"GET /api/export-to-vinmes/mapping-overrides":        handlers.RolesRoute(invoiceWorkflowViewers...),
+	"POST /api/export-to-vinmes/mapping-overrides":       handlers.RolesRoute(adminOnly...),
+	"PUT /api/export-to-vinmes/mapping-overrides/:id":    handlers.RolesRoute(adminOnly...),
+	"DELETE /api/export-to-vinmes/mapping-overrides/:id": handlers.RolesRoute(adminOnly...),
+
+	"POST /api/auth/logout-all":        handlers.AuthenticatedRoute(),
+	"PUT /api/auth/password":           handlers.AuthenticatedRoute(),
+	"GET /api/auth/profile":            handlers.AuthenticatedRoute(),
+	"PUT /api/auth/profile":            handlers.AuthenticatedRoute(),
+	"GET /api/auth/users":              handlers.RolesRoute(managerRoles...),
+	"PUT /api/auth/users/:id/role":     handlers.RolesRoute(managerRoles...),
+	"PUT /api/auth/users/:id/password": handlers.RolesRoute(adminOnly...),
+	"DELETE /api/auth/users/:id":       handlers.RolesRoute(managerRoles...),
+	"POST /api/auth/users/:id/unlock":  handlers.RolesRoute(adminOnly...),
+	"GET /api/auth/login-attempts":     handlers.RolesRoute(adminOnly...),
+
+	"GET /api/supplies":                        handlers.AuthenticatedRoute(),
+	"GET /api/supplies/search":                 handlers.AuthenticatedRoute(),
+	"GET /api/supplies/groups":                 handlers.AuthenticatedRoute(),
+	"GET /api/supplies/group":                  handlers.AuthenticatedRoute(),
+	"GET /api/supplies/low-stock":              handlers.AuthenticatedRoute(),
+	"GET /api/supplies/compare-level1":         handlers.AuthenticatedRoute(),
+	"GET /api/supplies/compare-level2":         handlers.AuthenticatedRoute(),
+	"GET /api/supplies/compare-catalog":        handlers.AuthenticatedRoute(),
+	"GET /api/supplies/compare-export":         handlers.AuthenticatedRoute(),
+	"POST /api/supplies/compare-import":        handlers.RolesRoute(managerRoles...),
+	"GET /api/supplies/forecast-catalog":       handlers.AuthenticatedRoute(),
+	"GET /api/supplies/forecast-suggestions":   handlers.AuthenticatedRoute(),
+	"POST /api/supplies/internal-sync":         handlers.RolesRoute(adminOnly...),
+	"GET /api/supplies/internal-sync/runs":     handlers.RolesRoute(adminOnly...),
+	"GET /api/supplies/internal-sync/runs/:id": handlers.RolesRoute(adminOnly...),
+	"POST /api/supplies/compare":               handlers.AuthenticatedRoute(),
+	"GET /api/supplies/:id":                    handlers.AuthenticatedRoute(),
+	"GET /api/supplies/:id/stock-history":      handlers.AuthenticatedRoute(),
+
+	"GET /api/supply-tasks/state":               handlers.RolesRoute(managerRoles...),
+	"GET /api/supply-tasks/catalog":             handlers.RolesRoute(managerRoles...),
+	"GET /api/supply-tasks/assignments":         handlers.RolesRoute(managerRoles...),
+	"GET /api/supply-tasks/assignments/export":  handlers.RolesRoute(managerRoles...),
+	"POST /api/supply-tasks/assignments/import": handlers.RolesRoute(managerRoles...),
+	"PUT /api/supply-tasks/visibility":          handlers.RolesRoute(managerRoles...),
+	"PUT /api/supply-tasks/assignments":         handlers.RolesRoute(managerRoles...),
+
+	"GET /api/hoa-don":                handlers.AuthenticatedRoute(),
+	"GET /api/hoa-don/search":         handlers.AuthenticatedRoute(),
+	"GET /api/hoa-don/:id":            handlers.AuthenticatedRoute(),
+	"POST /api/hoa-don/refresh":       handlers.RolesRoute(accountingRoles...),
+	"GET /api/hoa-don/refresh/status": handlers.RolesRoute(accountingRoles...),
+
+	"GET /api/orders/pending":                                          handlers.AuthenticatedRoute(),
+	"GET /api/orders/history":                                          handlers.AuthenticatedRoute(),
+	"GET /api/orders/outstanding":                                      handlers.AuthenticatedRoute(),
+	"GET /api/orders/invoice-reconciliations":                          handlers.RolesRoute(invoiceWorkflowViewers...),
+	"GET /api/orders/invoice-reconciliations/matched-invoices":         handlers.RolesRoute(invoiceWorkflowViewers...),
+	"GET /api/orders/invoice-reconciliations/matched-orders":           handlers.RolesRoute(invoiceWorkflowViewers...),
+	"GET /api/orders/invoice-reconciliations/price-variances":          handlers.RolesRoute(invoiceWorkflowViewers...),
+	"GET /api/orders/invoice-reconciliations/aging":                    handlers.RolesRoute(invoiceWorkflowViewers...),
+	"GET /api/orders/company-contacts/search":                          handlers.RolesRoute(managerRoles...),
+	"GET /api/orders/unread-snapshot":                                  handlers.AuthenticatedRoute(),
+	"GET /api/orders/email-outbox":                                     handlers.AuthenticatedRoute(),
+	"GET /api/orders/email-templates":                                  handlers.RolesRoute(adminOnly...),
+	"GET /api/orders/invoice-matches":                                  handlers.RolesRoute(invoiceWorkflowViewers...),
+	"POST /api/orders/pending/forecast":                                handlers.RolesRoute(managerRoles...),
+	"POST /api/orders/pending/manual":                                  handlers.RolesRoute(managerRoles...),
+	"POST /api/orders/pending/propose":                                 handlers.RolesRoute(orderProposers...),
+	"POST /api/orders/pending/batches/:batchId/approve":                handlers.RolesRoute(managerRoles...),
+	"POST /api/orders/pending/batches/:batchId/reject":                 handlers.RolesRoute(managerRoles...),
+	"POST /api/orders/place":                                           handlers.RolesRoute(orderOperators...),
+	"POST /api/orders/history/reorder":                                 handlers.RolesRoute(orderOperators...),
+	"GET /api/orders/history/:id/amendments":                           handlers.AuthenticatedRoute(),
+	"GET /api/orders/history/:id/pdf":                                  handlers.AuthenticatedRoute(),
+	"GET /api/orders/history/:id/xlsx":                                 handlers.AuthenticatedRoute(),
+	"POST /api/orders/history/:id/cancel":                              handlers.RolesRoute(orderOperators...),
+	"POST /api/orders/history/:id/amend":                               handlers.RolesRoute(orderOperators...),
+	"POST /api/orders/email-outbox/:id/resend":                         handlers.RolesRoute(orderOperators...),
+	"POST /api/orders/email-templates":                                 handlers.RolesRoute(adminOnly...),
+	"POST /api/orders/email-templates/preview":                         handlers.RolesRoute(adminOnly...),
+	"PUT /api/orders/email-templates/:id":                              handlers.RolesRoute(adminOnly...),
+	"DELETE /api/orders/email-templates/:id":                           handlers.RolesRoute(adminOnly...),
+	"POST /api/orders/invoice-reconciliations/upsert":                  handlers.RolesRoute(invoiceEditors...),
+	"POST /api/orders/invoice-reconciliations/bulk":                    handlers.RolesRoute(invoiceEditors...),
+	"POST /api/orders/invoice-reconciliations/price-variances/recheck": handlers.RolesRoute(priceVarianceUsers...),
+	"POST /api/orders/invoice-matches/run":                             handlers.RolesRoute(invoiceEditors...),
+	"POST /api/orders/invoice-matches/:id/accept":                      handlers.RolesRoute(invoiceEditors...),
+	"POST /api/orders/invoice-matches/:id/reject":                      handlers.RolesRoute(invoiceEditors...),
+	"POST /api/orders/alerts/suppliers/seen":                           handlers.AuthenticatedRoute(),
+	"POST /api/orders/groups/seen":                                     handlers.AuthenticatedRoute(),
+
+	"GET /api/forecast-approvals":                 handlers.AuthenticatedRoute(),
+	"GET /api/forecast-approvals/history":         handlers.AuthenticatedRoute(),
+	"GET /api/forecast-approvals/monthly-history": handlers.AuthenticatedRoute(),
+	"POST /api/forecast-approvals":                handlers.RolesRoute(forecastEditors...),
+	"POST /api/forecast-approvals/bulk":           handlers.RolesRoute(forecastEditors...),
+
+	"GET /api/forecast-periods":                      handlers.AuthenticatedRoute(),
+	"GET /api/forecast-periods/:year/:month":         handlers.AuthenticatedRoute(),
+	"PUT /api/forecast-periods/:year/:month":         handlers.RolesRoute(adminOnly...),
+	"POST /api/forecast-periods/:year/:month/lock":   handlers.RolesRoute(managerRoles...),
+	"POST /api/forecast-periods/:year/:month/unlock": handlers.RolesRoute(adminOnly...),
+
+	"GET /api/stock-alerts":                  handlers.AuthenticatedRoute(),
+	"POST /api/stock-alerts/:id/acknowledge": handlers.AuthenticatedRoute(),
+	"POST /api/stock-alerts/:id/snooze":      handlers.AuthenticatedRoute(),
+
+	"GET /api/jobs/:id":                handlers.AuthenticatedRoute(),
+	"POST /api/jobs/:id/cancel":        handlers.AuthenticatedRoute(),
+	"POST /api/reports/gemini-compare": handlers.AuthenticatedRoute(),
+	"GET /api/audit":                   handlers.RolesRoute(managerRoles...),
+}
+
+// auditExemptRoutes are mutating routes that the audit log skips: sign-in and
+// token traffic (login attempts have their own table), POSTs that only read,
+// and per-user "seen" markers.
+var auditExemptRoutes = []string{
+	"POST /api/auth/login",
+	"POST /api/auth/refresh",
+	"POST /api/auth/logout",
+	"POST /api/auth/forgot-password",
+	"POST /api/supplies/compare",
+	"POST /api/orders/email-templates/preview",
+	"POST /api/orders/alerts/suppliers/seen",
+	"POST /api/orders/groups/seen",
+	"POST /api/reports/gemini-compare",
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"bv108-consumables-management-backend/internal/handlers"
@@ -9,19 +12,7 @@ import (
 )
 
 func TestRouterRegistersExpectedRoutes(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-	router := newRouter("http://localhost:5173", apiHandlers{
-		auth:               &handlers.AuthHandler{},
-		supplies:           &handlers.SupplyHandler{},
-		supplyTasks:        &handlers.SupplyTaskHandler{},
-		invoices:           &handlers.HoaDonHandler{},
-		invoiceRefresh:     &handlers.RefreshHandler{},
-		internalSupplySync: &handlers.InternalSupplySyncHandler{},
-		orders:             &handlers.OrderHandler{},
-		forecastApprovals:  &handlers.ForecastApprovalHandler{},
-		reports:            &handlers.ReportHandler{},
-		websocket:          &handlers.WSHandler{},
-	})
+	router := newTestRouter()
 
 	expected := []string{
 		"GET /health",
@@ -29,14 +20,32 @@ func TestRouterRegistersExpectedRoutes(t *testing.T) {
 		"GET /api/export-to-vinmes",
 		"GET /api/export-to-vinmes/mapping-preview",
 		"POST /api/export-to-vinmes/catalogs/refresh",
+		"GET /api/export-to-vinmes/catalogs/refreshes",
+		"GET /api/export-to-vinmes/catalogs/refreshes/:id",
+		"POST /api/export-to-vinmes/submit",
+		"GET /api/export-to-vinmes/ledger",
+		"GET /api/export-to-vinmes/ledger/:id",
+		"POST /api/export-to-vinmes/ledger/:id/retry",
+		"GET /api/export-to-vinmes/mapping-overrides",
+		"POST /api/export-to-vinmes/mapping-overrides",
+		"PUT /api/export-to-vinmes/mapping-overrides/:id",
+		"DELETE /api/export-to-vinmes/mapping-overrides/:id",
 		"POST /api/auth/register",
 		"POST /api/auth/login",
+		"POST /api/auth/refresh",
+		"POST /api/auth/logout",
+		"POST /api/auth/logout-all",
+		"POST /api/auth/forgot-password",
+		"POST /api/auth/reset-password",
+		"PUT /api/auth/password",
 		"GET /api/auth/profile",
 		"PUT /api/auth/profile",
 		"GET /api/auth/users",
 		"PUT /api/auth/users/:id/role",
 		"PUT /api/auth/users/:id/password",
 		"DELETE /api/auth/users/:id",
+		"POST /api/auth/users/:id/unlock",
+		"GET /api/auth/login-attempts",
 		"GET /api/supplies",
 		"GET /api/supplies/search",
 		"GET /api/supplies/groups",
@@ -48,9 +57,13 @@ func TestRouterRegistersExpectedRoutes(t *testing.T) {
 		"GET /api/supplies/compare-export",
 		"POST /api/supplies/compare-import",
 		"GET /api/supplies/forecast-catalog",
+		"GET /api/supplies/forecast-suggestions",
 		"POST /api/supplies/internal-sync",
+		"GET /api/supplies/internal-sync/runs",
+		"GET /api/supplies/internal-sync/runs/:id",
 		"POST /api/supplies/compare",
 		"GET /api/supplies/:id",
+		"GET /api/supplies/:id/stock-history",
 		"GET /api/supply-tasks/state",
 		"GET /api/supply-tasks/catalog",
 		"GET /api/supply-tasks/assignments",
@@ -62,19 +75,43 @@ func TestRouterRegistersExpectedRoutes(t *testing.T) {
 		"GET /api/hoa-don/search",
 		"GET /api/hoa-don/:id",
 		"POST /api/hoa-don/refresh",
+		"GET /api/hoa-don/refresh/status",
 		"GET /api/orders/pending",
 		"GET /api/orders/history",
+		"GET /api/orders/outstanding",
 		"GET /api/orders/invoice-reconciliations",
 		"GET /api/orders/invoice-reconciliations/matched-invoices",
 		"GET /api/orders/invoice-reconciliations/matched-orders",
+		"GET /api/orders/invoice-reconciliations/price-variances",
+		"GET /api/orders/invoice-reconciliations/aging",
 		"GET /api/orders/company-contacts/search",
 		"GET /api/orders/unread-snapshot",
+		"GET /api/orders/email-outbox",
+		"GET /api/orders/email-templates",
+		"GET /api/orders/invoice-matches",
 		"POST /api/orders/pending/forecast",
 		"POST /api/orders/pending/manual",
+		"POST /api/orders/pending/propose",
+		"POST /api/orders/pending/batches/:batchId/approve",
+		"POST /api/orders/pending/batches/:batchId/reject",
 		"POST /api/orders/place",
 		"POST /api/orders/history/reorder",
+		"GET /api/orders/history/:id/amendments",
+		"GET /api/orders/history/:id/pdf",
+		"GET /api/orders/history/:id/xlsx",
+		"POST /api/orders/history/:id/cancel",
+		"POST /api/orders/history/:id/amend",
+		"POST /api/orders/email-outbox/:id/resend",
+		"POST /api/orders/email-templates",
+		"POST /api/orders/email-templates/preview",
+		"PUT /api/orders/email-templates/:id",
+		"DELETE /api/orders/email-templates/:id",
 		"POST /api/orders/invoice-reconciliations/upsert",
 		"POST /api/orders/invoice-reconciliations/bulk",
+		"POST /api/orders/invoice-reconciliations/price-variances/recheck",
+		"POST /api/orders/invoice-matches/run",
+		"POST /api/orders/invoice-matches/:id/accept",
+		"POST /api/orders/invoice-matches/:id/reject",
 		"POST /api/orders/alerts/suppliers/seen",
 		"POST /api/orders/groups/seen",
 		"GET /api/forecast-approvals",
@@ -82,7 +119,18 @@ func TestRouterRegistersExpectedRoutes(t *testing.T) {
 		"GET /api/forecast-approvals/monthly-history",
 		"POST /api/forecast-approvals",
 		"POST /api/forecast-approvals/bulk",
+		"GET /api/forecast-periods",
+		"GET /api/forecast-periods/:year/:month",
+		"PUT /api/forecast-periods/:year/:month",
+		"POST /api/forecast-periods/:year/:month/lock",
+		"POST /api/forecast-periods/:year/:month/unlock",
+		"GET /api/stock-alerts",
+		"POST /api/stock-alerts/:id/acknowledge",
+		"POST /api/stock-alerts/:id/snooze",
+		"GET /api/jobs/:id",
+		"POST /api/jobs/:id/cancel",
 		"POST /api/reports/gemini-compare",
+		"GET /api/audit",
 	}
 
 	actual := make(map[string]struct{}, len(router.Routes()))
@@ -99,3 +147,196 @@ func TestRouterRegistersExpectedRoutes(t *testing.T) {
 		}
 	}
 }
+
+func TestEveryAPIRouteHasPolicy(t *testing.T) {
+	router := newTestRouter()
+
+	registered := make(map[string]struct{})
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		key := handlers.RoutePolicyKey(route.Method, route.Path)
+		registered[key] = struct{}{}
+		if _, ok := apiRoutePolicies[key]; !ok {
+			t.Errorf("route %s has no access policy", key)
+		}
+	}
+	for key, policy := range apiRoutePolicies {
+		if _, ok := registered[key]; !ok {
+			t.Errorf("policy %s does not match a registered route", key)
+		}
+		if policy.Public && len(policy.Roles) > 0 {
+			t.Errorf("public route %s must not list roles", key)
+		}
+	}
+}
+
+func TestAPIRoutePoliciesAllowRoles(t *testing.T) {
+	testCases := []struct {
+		route string
+		role  string
+		want  bool
+	}{
+		{route: "GET /api/orders/invoice-matches", role: handlers.RoleNhanVienKho, want: true},
+		{route: "GET /api/orders/invoice-matches", role: handlers.RoleNhanVien, want: true},
+		{route: "GET /api/orders/invoice-matches", role: "guest", want: false},
+		{route: "POST /api/orders/invoice-matches/:id/accept", role: handlers.RoleThuKho, want: true},
+		{route: "POST /api/orders/invoice-matches/:id/accept", role: handlers.RoleAdmin, want: false},
+		{route: "POST /api/orders/invoice-matches/:id/accept", role: handlers.RoleNhanVien, want: false},
+		{route: "POST /api/orders/pending/manual", role: handlers.RoleChiHuyKhoa, want: true},
+		{route: "POST /api/orders/pending/manual", role: handlers.RoleThuKho, want: false},
+		{route: "POST /api/supplies/internal-sync", role: " Admin ", want: true},
+		{route: "POST /api/supplies/internal-sync", role: handlers.RoleChiHuyKhoa, want: false},
+		{route: "GET /api/audit", role: handlers.RoleNhanVienKeToan, want: false},
+		{route: "GET /api/stock-alerts", role: handlers.RoleNhanVienThau, want: true},
+	}
+
+	for _, tc := range testCases {
+		policy, ok := apiRoutePolicies[tc.route]
+		if !ok {
+			t.Fatalf("route %s has no access policy", tc.route)
+		}
+		if got := policy.Allows(tc.role); got != tc.want {
+			t.Errorf("%s allows %q = %v, want %v", tc.route, tc.role, got, tc.want)
+		}
+	}
+}
+
+func TestOrderRoutePolicies(t *testing.T) {
+	allRoles := []string{
+		handlers.RoleAdmin,
+		handlers.RoleChiHuyKhoa,
+		handlers.RoleNhanVienKho,
+		handlers.RoleThuKho,
+		handlers.RoleNhanVienKeToan,
+		handlers.RoleNhanVienThau,
+		handlers.RoleNhanVien,
+	}
+	testCases := []struct {
+		name    string
+		routes  []string
+		allowed []string
+	}{
+		{
+			name: "pending order creation",
+			routes: []string{
+				"POST /api/orders/pending/forecast",
+				"POST /api/orders/pending/manual",
+			},
+			allowed: []string{handlers.RoleAdmin, handlers.RoleChiHuyKhoa},
+		},
+		{
+			name:    "pending order proposal",
+			routes:  []string{"POST /api/orders/pending/propose"},
+			allowed: []string{handlers.RoleAdmin, handlers.RoleThuKho},
+		},
+		{
+			name: "reconciliation reads",
+			routes: []string{
+				"GET /api/orders/invoice-reconciliations",
+				"GET /api/orders/invoice-reconciliations/matched-invoices",
+				"GET /api/orders/invoice-reconciliations/matched-orders",
+			},
+			allowed: []string{
+				handlers.RoleAdmin,
+				handlers.RoleChiHuyKhoa,
+				handlers.RoleNhanVienKho,
+				handlers.RoleThuKho,
+				handlers.RoleNhanVienKeToan,
+				handlers.RoleNhanVienThau,
+				handlers.RoleNhanVien,
+			},
+		},
+		{
+			name: "reconciliation writes",
+			routes: []string{
+				"POST /api/orders/invoice-reconciliations/upsert",
+				"POST /api/orders/invoice-reconciliations/bulk",
+			},
+			allowed: []string{handlers.RoleThuKho},
+		},
+		{
+			name:    "price variance recheck",
+			routes:  []string{"POST /api/orders/invoice-reconciliations/price-variances/recheck"},
+			allowed: []string{handlers.RoleAdmin, handlers.RoleThuKho, handlers.RoleNhanVienKeToan},
+		},
+		{
+			name: "accounting exports",
+			routes: []string{
+				"POST /api/export-to-vinmes/submit",
+				"POST /api/export-to-vinmes/ledger/:id/retry",
+				"POST /api/hoa-don/refresh",
+			},
+			allowed: []string{handlers.RoleAdmin, handlers.RoleChiHuyKhoa, handlers.RoleNhanVienKeToan},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed := make(map[string]bool, len(tc.allowed))
+			for _, role := range tc.allowed {
+				allowed[role] = true
+			}
+			for _, route := range tc.routes {
+				policy, ok := apiRoutePolicies[route]
+				if !ok {
+					t.Fatalf("route %s has no access policy", route)
+				}
+				for _, role := range append(allRoles, "guest") {
+					if got := policy.Allows(role); got != allowed[role] {
+						t.Errorf("%s allows %q = %v, want %v", route, role, got, allowed[role])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAuditExemptRoutesAreRegistered(t *testing.T) {
+	for _, key := range auditExemptRoutes {
+		if _, ok := apiRoutePolicies[key]; !ok {
+			t.Errorf("audit exemption %s does not match a registered route", key)
+		}
+	}
+}
+
+func TestRouterIgnoresForwardedForWithoutTrustedProxies(t *testing.T) {
+	router := newTestRouter()
+	var clientIP string
+	router.GET("/client-ip", func(c *gin.Context) { clientIP = c.ClientIP() })
+
+	request := httptest.NewRequest(http.MethodGet, "/client-ip", nil)
+	request.RemoteAddr = "10.0.0.9:51234"
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	if clientIP != "10.0.0.9" {
+		t.Fatalf("ClientIP = %q, want the TCP peer 10.0.0.9", clientIP)
+	}
+}
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router, err := newRouter("http://localhost:5173", nil, apiHandlers{
+		authMiddleware:     &handlers.AuthMiddleware{},
+		auth:               &handlers.AuthHandler{},
+		supplies:           &handlers.SupplyHandler{},
+		supplyTasks:        &handlers.SupplyTaskHandler{},
+		invoices:           &handlers.HoaDonHandler{},
+		invoiceRefresh:     &handlers.RefreshHandler{},
+		internalSupplySync: &handlers.InternalSupplySyncHandler{},
+		orders:             &handlers.OrderHandler{},
+		forecastApprovals:  &handlers.ForecastApprovalHandler{},
+		stockAlerts:        &handlers.StockAlertHandler{},
+		jobs:               &handlers.JobHandler{},
+		reports:            &handlers.ReportHandler{},
+		websocket:          &handlers.WSHandler{},
+		auditRecorder:      &handlers.AuditRecorder{},
+		audit:              &handlers.AuditHandler{},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return router
+}
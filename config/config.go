@@ -27,9 +27,27 @@ type Config struct {
 	ServerPort                      string
 	GinMode                         string
 	FrontendURL                     string
+	TrustedProxies                  []string
 	JWTSecret                       string
 	JWTExpiresHours                 int
 	JWTExpiresMinutes               int
+	RefreshTokenExpiresDays         int
+	LoginMaxFailedAttempts          int
+	LoginIPMaxFailedAttempts        int
+	LoginDelayAfterAttempts         int
+	LoginBaseDelaySeconds           int
+	LoginMaxDelaySeconds            int
+	LoginLockoutMinutes             int
+	LoginAttemptWindowMinutes       int
+	PasswordMinLength               int
+	PasswordRequireMixedCase        bool
+	PasswordRequireDigit            bool
+	PasswordRequireSymbol           bool
+	PasswordResetURL                string
+	PasswordResetTokenMinutes       int
+	PasswordResetMaxPerEmail        int
+	PasswordResetMaxPerIP           int
+	PasswordResetWindowMinutes      int
 	InternalSupplyAPIURL            string
 	InternalSupplyAPIToken          string
 	InternalSupplyAPICookie         string
@@ -40,6 +58,7 @@ type Config struct {
 	InternalSupplySyncMinute        int
 	InternalSupplySyncTimezone      string
 	InternalSupplySyncRunOnStartup  bool
+	InternalSupplySyncMaxRemovalPct int
 	GeminiAPIKey                    string
 	GeminiModel                     string
 	GeminiAPIBaseURL                string
@@ -49,6 +68,24 @@ type Config struct {
 	VinmesAPIBaseURL                string
 	VinmesAPIToken                  string
 	VinmesAPITimeoutSeconds         int
+	VinmesCatalogSyncEnabled        bool
+	VinmesCatalogSyncHour           int
+	VinmesCatalogSyncMinute         int
+	VinmesCatalogSyncTimezone       string
+	VinmesCatalogSyncRunOnStartup   bool
+	OrderDeliveryDueDays            int
+	OrderLifecycleSyncMinutes       int
+	OrderEmailPollSeconds           int
+	OrderEmailMaxAttempts           int
+	StockAlertIntervalMinutes       int
+	ForecastDeadlineReminderHours   int
+	InvoiceMatchMinScore            int
+	InvoiceMatchLookbackDays        int
+	InvoicePriceTolerancePercent    float64
+	UBotAPIBaseURL                  string
+	UBotUsername                    string
+	UBotPassword                    string
+	UBotAPITimeoutSeconds           int
 }
 
 var AppConfig *Config
@@ -65,6 +102,7 @@ func LoadConfig() error {
 	}
 
 	serverPort := getEnv("PORT", getEnv("SERVER_PORT", "8080"))
+	frontendURL := getEnv("FRONTEND_URL", "http://localhost:5173")
 
 	AppConfig = &Config{
 		DBHost:                          getEnv("DB_HOST", "localhost"),
@@ -82,10 +120,28 @@ func LoadConfig() error {
 		DefaultCompanyContactEmail:      getEnv("DEFAULT_COMPANY_CONTACT_EMAIL", getEnv("SMTP_FROM", getEnv("SMTP_USERNAME", ""))),
 		ServerPort:                      serverPort,
 		GinMode:                         getEnv("GIN_MODE", "debug"),
-		FrontendURL:                     getEnv("FRONTEND_URL", "http://localhost:5173"),
+		FrontendURL:                     frontendURL,
+		TrustedProxies:                  getEnvAsList("TRUSTED_PROXIES"),
 		JWTSecret:                       getEnv("JWT_SECRET", ""),
 		JWTExpiresHours:                 getEnvAsInt("JWT_EXPIRES_HOURS", 8),
-		JWTExpiresMinutes:               getEnvAsInt("JWT_EXPIRES_MINUTES", 0),
+		JWTExpiresMinutes:               getEnvAsInt("JWT_EXPIRES_MINUTES", 15),
+		RefreshTokenExpiresDays:         getEnvAsInt("REFRESH_TOKEN_EXPIRES_DAYS", 14),
+		LoginMaxFailedAttempts:          getEnvAsInt("LOGIN_MAX_FAILED_ATTEMPTS", 5),
+		LoginIPMaxFailedAttempts:        getEnvAsInt("LOGIN_IP_MAX_FAILED_ATTEMPTS", 20),
+		LoginDelayAfterAttempts:         getEnvAsInt("LOGIN_DELAY_AFTER_ATTEMPTS", 3),
+		LoginBaseDelaySeconds:           getEnvAsInt("LOGIN_BASE_DELAY_SECONDS", 2),
+		LoginMaxDelaySeconds:            getEnvAsInt("LOGIN_MAX_DELAY_SECONDS", 60),
+		LoginLockoutMinutes:             getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+		LoginAttemptWindowMinutes:       getEnvAsInt("LOGIN_ATTEMPT_WINDOW_MINUTES", 15),
+		PasswordMinLength:               getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireMixedCase:        getEnvAsBool("PASSWORD_REQUIRE_MIXED_CASE", false),
+		PasswordRequireDigit:            getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSymbol:           getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordResetURL:                getEnv("PASSWORD_RESET_URL", strings.TrimRight(frontendURL, "/")+"/reset-password"),
+		PasswordResetTokenMinutes:       getEnvAsInt("PASSWORD_RESET_TOKEN_MINUTES", 30),
+		PasswordResetMaxPerEmail:        getEnvAsInt("PASSWORD_RESET_MAX_PER_EMAIL", 3),
+		PasswordResetMaxPerIP:           getEnvAsInt("PASSWORD_RESET_MAX_PER_IP", 10),
+		PasswordResetWindowMinutes:      getEnvAsInt("PASSWORD_RESET_WINDOW_MINUTES", 60),
 		InternalSupplyAPIURL:            getEnv("INTERNAL_SUPPLY_API_URL", ""),
 		InternalSupplyAPIToken:          getEnv("INTERNAL_SUPPLY_API_TOKEN", ""),
 		InternalSupplyAPICookie:         getEnv("INTERNAL_SUPPLY_API_COOKIE", ""),
@@ -96,6 +152,7 @@ func LoadConfig() error {
 		InternalSupplySyncMinute:        getEnvAsInt("INTERNAL_SUPPLY_SYNC_MINUTE", 0),
 		InternalSupplySyncTimezone:      getEnv("INTERNAL_SUPPLY_SYNC_TIMEZONE", "Asia/Bangkok"),
 		InternalSupplySyncRunOnStartup:  getEnvAsBool("INTERNAL_SUPPLY_SYNC_RUN_ON_STARTUP", false),
+		InternalSupplySyncMaxRemovalPct: getEnvAsInt("INTERNAL_SUPPLY_SYNC_MAX_REMOVAL_PERCENT", 20),
 		GeminiAPIKey:                    getEnv("GEMINI_API_KEY", ""),
 		GeminiModel:                     getEnv("GEMINI_MODEL", "gemini-flash-lite-latest"),
 		GeminiAPIBaseURL:                getEnv("GEMINI_API_BASE_URL", "https://generativelanguage.googleapis.com/v1beta"),
@@ -105,6 +162,24 @@ func LoadConfig() error {
 		VinmesAPIBaseURL:                getEnv("VINMES_API_BASE_URL", ""),
 		VinmesAPIToken:                  getEnv("VINMES_API_TOKEN", ""),
 		VinmesAPITimeoutSeconds:         getEnvAsInt("VINMES_API_TIMEOUT_SECONDS", 60),
+		VinmesCatalogSyncEnabled:        getEnvAsBool("VINMES_CATALOG_SYNC_ENABLED", false),
+		VinmesCatalogSyncHour:           getEnvAsInt("VINMES_CATALOG_SYNC_HOUR", 2),
+		VinmesCatalogSyncMinute:         getEnvAsInt("VINMES_CATALOG_SYNC_MINUTE", 0),
+		VinmesCatalogSyncTimezone:       getEnv("VINMES_CATALOG_SYNC_TIMEZONE", "Asia/Bangkok"),
+		VinmesCatalogSyncRunOnStartup:   getEnvAsBool("VINMES_CATALOG_SYNC_RUN_ON_STARTUP", false),
+		OrderDeliveryDueDays:            getEnvAsInt("ORDER_DELIVERY_DUE_DAYS", 14),
+		OrderLifecycleSyncMinutes:       getEnvAsInt("ORDER_LIFECYCLE_SYNC_INTERVAL_MINUTES", 30),
+		OrderEmailPollSeconds:           getEnvAsInt("ORDER_EMAIL_POLL_SECONDS", 15),
+		OrderEmailMaxAttempts:           getEnvAsInt("ORDER_EMAIL_MAX_ATTEMPTS", 6),
+		StockAlertIntervalMinutes:       getEnvAsInt("STOCK_ALERT_INTERVAL_MINUTES", 60),
+		ForecastDeadlineReminderHours:   getEnvAsInt("FORECAST_DEADLINE_REMINDER_HOURS", 24),
+		InvoiceMatchMinScore:            getEnvAsInt("INVOICE_MATCH_MIN_SCORE", 60),
+		InvoiceMatchLookbackDays:        getEnvAsInt("INVOICE_MATCH_LOOKBACK_DAYS", 90),
+		InvoicePriceTolerancePercent:    getEnvAsFloat("INVOICE_PRICE_TOLERANCE_PERCENT", 1),
+		UBotAPIBaseURL:                  getEnv("UBOT_API_BASE_URL", "https://portal.ubot.vn/api"),
+		UBotUsername:                    getEnv("UBOT_USERNAME", ""),
+		UBotPassword:                    getEnv("UBOT_PASSWORD", ""),
+		UBotAPITimeoutSeconds:           getEnvAsInt("UBOT_API_TIMEOUT_SECONDS", 60),
 	}
 
 	return nil
@@ -150,6 +225,31 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return parsedValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+
+	parsedValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return parsedValue
+}
+
+// getEnvAsList splits a comma-separated value, dropping empty items.
+func getEnvAsList(key string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	value := strings.TrimSpace(strings.ToLower(os.Getenv(key)))
 	if value == "" {
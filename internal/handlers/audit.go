@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const auditChangeContextKey = "auditChange"
+
+// AuditChange lets a handler describe what it changed. Without one the audit
+// event falls back to the route as action and the path parameters as entity.
+type AuditChange struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+	// Actor is only needed on public routes, where the auth middleware did not
+	// resolve the caller.
+	Actor *models.UserProfile
+}
+
+func setAuditChange(c *gin.Context, change AuditChange) {
+	c.Set(auditChangeContextKey, change)
+}
+
+type AuditRecorder struct {
+	repo *models.AuditEventRepository
+}
+
+func NewAuditRecorder(repo *models.AuditEventRepository) *AuditRecorder {
+	return &AuditRecorder{repo: repo}
+}
+
+// Record writes an audit event after every successful POST, PUT, PATCH or
+// DELETE, except for the exempt route keys (see RoutePolicyKey). Failed
+// requests changed nothing and are not recorded.
+func (r *AuditRecorder) Record(exempt ...string) gin.HandlerFunc {
+	skipped := make(map[string]bool, len(exempt))
+	for _, key := range exempt {
+		skipped[key] = true
+	}
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		if r == nil || r.repo == nil || !isAuditedMethod(c.Request.Method) || c.FullPath() == "" {
+			return
+		}
+		if skipped[RoutePolicyKey(c.Request.Method, c.FullPath())] {
+			return
+		}
+		status := c.Writer.Status()
+		if status < http.StatusOK || status >= http.StatusBadRequest {
+			return
+		}
+
+		if err := r.repo.Record(buildAuditEvent(c, status, time.Now())); err != nil {
+			log.Printf("[audit] recording %s %s failed: %v", c.Request.Method, c.FullPath(), err)
+		}
+	}
+}
+
+func isAuditedMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func buildAuditEvent(c *gin.Context, status int, now time.Time) models.AuditEvent {
+	var change AuditChange
+	if value, exists := c.Get(auditChangeContextKey); exists {
+		change, _ = value.(AuditChange)
+	}
+
+	event := models.AuditEvent{
+		Action:     strings.TrimSpace(change.Action),
+		EntityType: strings.TrimSpace(change.EntityType),
+		EntityID:   strings.TrimSpace(change.EntityID),
+		Before:     marshalAuditState(change.Before),
+		After:      marshalAuditState(change.After),
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		StatusCode: status,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		CreatedAt:  now,
+	}
+	if event.Action == "" {
+		event.Action = RoutePolicyKey(c.Request.Method, c.FullPath())
+	}
+	if event.EntityType == "" {
+		event.EntityType = auditEntityTypeFromRoute(c.FullPath())
+	}
+	if event.EntityID == "" {
+		values := make([]string, 0, len(c.Params))
+		for _, param := range c.Params {
+			values = append(values, param.Value)
+		}
+		event.EntityID = strings.Join(values, "/")
+	}
+
+	actor := change.Actor
+	if actor == nil {
+		actor, _ = currentUserFromContext(c)
+	}
+	if actor != nil {
+		actorID := actor.ID
+		event.ActorID = &actorID
+		event.ActorName = actor.Username
+		event.ActorEmail = actor.Email
+		event.ActorRole = actor.Role
+	}
+	return event
+}
+
+// auditEntityTypeFromRoute uses the first path segment below /api, e.g.
+// "orders" for /api/orders/place.
+func auditEntityTypeFromRoute(route string) string {
+	segments := strings.Split(strings.TrimPrefix(route, "/api/"), "/")
+	if len(segments) == 0 {
+		return ""
+	}
+	return segments[0]
+}
+
+func marshalAuditState(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("[audit] encoding change state failed: %v", err)
+		return nil
+	}
+	if string(encoded) == "null" {
+		return nil
+	}
+	return encoded
+}
+
+type AuditHandler struct {
+	repo *models.AuditEventRepository
+}
+
+func NewAuditHandler(repo *models.AuditEventRepository) *AuditHandler {
+	return &AuditHandler{
+		repo: repo,
+	}
+}
+
+// ListAuditEvents returns audit events newest first, filtered by actorId,
+// action, entityType, entityId and a from/to time range.
+func (h *AuditHandler) ListAuditEvents(c *gin.Context) {
+	if _, ok := requireCurrentUser(c); !ok {
+		return
+	}
+
+	if h.repo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Audit log is not configured"})
+		return
+	}
+
+	filter, ok := parseAuditEventFilter(c)
+	if !ok {
+		return
+	}
+
+	events, total, err := h.repo.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginationResponse{
+		Data:       events,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		Total:      total,
+		TotalPages: (total + filter.PageSize - 1) / filter.PageSize,
+	})
+}
+
+func parseAuditEventFilter(c *gin.Context) (models.AuditEventFilter, bool) {
+	page, pageSize := parsePagination(c)
+	filter := models.AuditEventFilter{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entityType"),
+		EntityID:   c.Query("entityId"),
+		Page:       page,
+		PageSize:   pageSize,
+	}
+
+	if rawActorID := strings.TrimSpace(c.Query("actorId")); rawActorID != "" {
+		actorID, err := strconv.ParseInt(rawActorID, 10, 64)
+		if err != nil || actorID <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "actorId must be a positive number"})
+			return filter, false
+		}
+		filter.ActorID = &actorID
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target **time.Time
+	}{
+		{name: "from", target: &filter.From},
+		{name: "to", target: &filter.To},
+	} {
+		raw := strings.TrimSpace(c.Query(bound.name))
+		if raw == "" {
+			continue
+		}
+		parsed, ok := parseOptionalRFC3339(raw)
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: bound.name + " must be a date (YYYY-MM-DD) or an RFC3339 time"})
+			return filter, false
+		}
+		if bound.name == "to" && len(raw) == len("2006-01-02") {
+			// A plain date includes the whole day.
+			endOfDay := parsed.AddDate(0, 0, 1)
+			parsed = &endOfDay
+		}
+		*bound.target = parsed
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "from must be before to"})
+		return filter, false
+	}
+	return filter, true
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func buildTestAuditEvent(t *testing.T, route, target string, handle func(*gin.Context)) models.AuditEvent {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var event models.AuditEvent
+	router := gin.New()
+	router.POST(route, func(c *gin.Context) {
+		c.Set(currentUserContextKey, &models.UserProfile{ID: 4, Username: "Thu Kho", Email: "kho@bv108.vn", Role: RoleThuKho})
+		handle(c)
+		event = buildAuditEvent(c, http.StatusOK, time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC))
+	})
+
+	request := httptest.NewRequest(http.MethodPost, target, nil)
+	request.Header.Set("User-Agent", "audit-test")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+	return event
+}
+
+func TestBuildAuditEventDefaultsToRoute(t *testing.T) {
+	event := buildTestAuditEvent(t, "/api/orders/history/:id/cancel", "/api/orders/history/42/cancel", func(*gin.Context) {})
+
+	if event.Action != "POST /api/orders/history/:id/cancel" || event.EntityType != "orders" || event.EntityID != "42" {
+		t.Fatalf("event = %q %q %q, want route defaults", event.Action, event.EntityType, event.EntityID)
+	}
+	if event.ActorID == nil || *event.ActorID != 4 || event.ActorRole != RoleThuKho {
+		t.Fatalf("actor = %v %q, want the signed-in user", event.ActorID, event.ActorRole)
+	}
+	if event.Path != "/api/orders/history/42/cancel" || event.UserAgent != "audit-test" || event.Before != nil {
+		t.Fatalf("unexpected request details: %+v", event)
+	}
+}
+
+func TestBuildAuditEventUsesHandlerChange(t *testing.T) {
+	event := buildTestAuditEvent(t, "/api/auth/users/:id/role", "/api/auth/users/7/role", func(c *gin.Context) {
+		setAuditChange(c, AuditChange{
+			Action:     "users.role_changed",
+			EntityType: "user",
+			EntityID:   "7",
+			Before:     gin.H{"role": RoleThuKho},
+			After:      gin.H{"role": RoleChiHuyKhoa},
+			Actor:      &models.UserProfile{ID: 1, Username: "Admin", Role: RoleAdmin},
+		})
+	})
+
+	if event.Action != "users.role_changed" || event.EntityType != "user" || event.EntityID != "7" {
+		t.Fatalf("event = %q %q %q, want the handler's change", event.Action, event.EntityType, event.EntityID)
+	}
+	if string(event.Before) != `{"role":"thu_kho"}` || string(event.After) != `{"role":"chi_huy_khoa"}` {
+		t.Fatalf("before/after = %s / %s", event.Before, event.After)
+	}
+	if event.ActorID == nil || *event.ActorID != 1 {
+		t.Fatalf("actor = %v, want the explicit actor", event.ActorID)
+	}
+}
+
+func TestParseAuditEventFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		query  string
+		wantOK bool
+		check  func(*testing.T, models.AuditEventFilter)
+	}{
+		{
+			name:   "date-only to includes the whole day",
+			query:  "actorId=3&entityType=user&from=2026-04-01&to=2026-04-01",
+			wantOK: true,
+			check: func(t *testing.T, filter models.AuditEventFilter) {
+				if filter.ActorID == nil || *filter.ActorID != 3 || filter.EntityType != "user" {
+					t.Fatalf("filter = %+v", filter)
+				}
+				if filter.To.Sub(*filter.From) != 24*time.Hour {
+					t.Fatalf("range = %s..%s, want one day", filter.From, filter.To)
+				}
+			},
+		},
+		{name: "invalid actor", query: "actorId=abc"},
+		{name: "invalid date", query: "from=01/04/2026"},
+		{name: "from after to", query: "from=2026-04-02&to=2026-04-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/audit?"+tt.query, nil)
+
+			filter, ok := parseAuditEventFilter(ctx)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok && recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+			}
+			if tt.check != nil {
+				tt.check(t, filter)
+			}
+		})
+	}
+}
+
+func TestListAuditEventsRequiresAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/audit", nil)
+
+	(&AuditHandler{}).ListAuditEvents(ctx)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+}
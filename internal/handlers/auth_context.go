@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -15,49 +16,85 @@ import (
 const currentUserCacheTTL = 30 * time.Second
 
 type currentUserCacheEntry struct {
-	profile   models.UserProfile
-	expiresAt time.Time
+	profile      models.UserProfile
+	tokenVersion int64
+	expiresAt    time.Time
 }
 
 var currentUserCache sync.Map
 
+var (
+	errTokenRevoked       = errors.New("token has been revoked")
+	errMissingCurrentUser = errors.New("missing authenticated user")
+)
+
+// accessTokenClaims is what the API reads from an access token. Tokens issued
+// before token versions existed carry no "ver" claim and count as version 0.
+type accessTokenClaims struct {
+	UserID       int64
+	TokenVersion int64
+}
+
 func loadActiveUserByID(userRepo *models.UserRepository, userID int64) (*models.User, error) {
 	user, err := userRepo.GetByID(userID)
 	if err != nil {
 		return nil, err
 	}
 	if !user.IsActive {
-		return nil, fmt.Errorf("user account is disabled")
+		return nil, models.ErrUserDisabled
 	}
 	return user, nil
 }
 
-func getCurrentUserFromAuthorizationHeader(c *gin.Context, userRepo *models.UserRepository, jwtSecret []byte) (*models.UserProfile, error) {
-	userID, err := getUserIDFromAuthorizationHeader(c, jwtSecret)
+// loadActiveUserForToken loads the token's user and refuses tokens issued
+// before the last password reset, role change, deactivation or logout-all.
+func loadActiveUserForToken(userRepo *models.UserRepository, claims accessTokenClaims) (*models.User, error) {
+	user, err := loadActiveUserByID(userRepo, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return nil, errTokenRevoked
+	}
+	return user, nil
+}
+
+// authenticateRequest resolves the bearer token of the request to an active
+// user. Only AuthMiddleware.Enforce calls it; handlers read the user it
+// stored with currentUserFromContext.
+func authenticateRequest(c *gin.Context, userRepo *models.UserRepository, jwtSecret []byte) (*models.UserProfile, error) {
+	claims, err := parseAuthorizationHeader(c, jwtSecret)
 	if err != nil {
 		return nil, err
 	}
 
 	now := time.Now()
-	if cached, ok := currentUserCache.Load(userID); ok {
+	if cached, ok := currentUserCache.Load(claims.UserID); ok {
 		entry := cached.(currentUserCacheEntry)
 		if now.Before(entry.expiresAt) {
+			if entry.tokenVersion != claims.TokenVersion {
+				return nil, errTokenRevoked
+			}
 			profile := entry.profile
 			return &profile, nil
 		}
-		currentUserCache.Delete(userID)
+		currentUserCache.Delete(claims.UserID)
 	}
 
-	user, err := loadActiveUserByID(userRepo, userID)
+	user, err := loadActiveUserByID(userRepo, claims.UserID)
 	if err != nil {
 		return nil, err
 	}
 
 	profile := user.ToProfile()
-	currentUserCache.Store(userID, currentUserCacheEntry{
-		profile:   profile,
-		expiresAt: now.Add(currentUserCacheTTL),
+	currentUserCache.Store(claims.UserID, currentUserCacheEntry{
+		profile:      profile,
+		tokenVersion: user.TokenVersion,
+		expiresAt:    now.Add(currentUserCacheTTL),
 	})
+	if user.TokenVersion != claims.TokenVersion {
+		return nil, errTokenRevoked
+	}
 
 	return &profile, nil
 }
@@ -66,22 +103,26 @@ func invalidateCurrentUserCache(userID int64) {
 	currentUserCache.Delete(userID)
 }
 
-func getUserIDFromAuthorizationHeader(c *gin.Context, jwtSecret []byte) (int64, error) {
+func parseAuthorizationHeader(c *gin.Context, jwtSecret []byte) (accessTokenClaims, error) {
 	authHeader := strings.TrimSpace(c.GetHeader("Authorization"))
 	if authHeader == "" {
-		return 0, fmt.Errorf("missing authorization header")
+		return accessTokenClaims{}, fmt.Errorf("missing authorization header")
 	}
 
 	parts := strings.SplitN(authHeader, " ", 2)
 	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return 0, fmt.Errorf("invalid authorization header format")
+		return accessTokenClaims{}, fmt.Errorf("invalid authorization header format")
 	}
 
 	tokenString := strings.TrimSpace(parts[1])
 	if tokenString == "" {
-		return 0, fmt.Errorf("missing bearer token")
+		return accessTokenClaims{}, fmt.Errorf("missing bearer token")
 	}
 
+	return parseAccessToken(tokenString, jwtSecret)
+}
+
+func parseAccessToken(tokenString string, jwtSecret []byte) (accessTokenClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method")
@@ -89,23 +130,32 @@ func getUserIDFromAuthorizationHeader(c *gin.Context, jwtSecret []byte) (int64,
 		return jwtSecret, nil
 	})
 	if err != nil || !token.Valid {
-		return 0, fmt.Errorf("invalid or expired token")
+		return accessTokenClaims{}, fmt.Errorf("invalid or expired token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return 0, fmt.Errorf("invalid token claims")
+		return accessTokenClaims{}, fmt.Errorf("invalid token claims")
 	}
 
 	subValue, exists := claims["sub"]
 	if !exists {
-		return 0, fmt.Errorf("missing subject in token")
+		return accessTokenClaims{}, fmt.Errorf("missing subject in token")
 	}
 
 	userID, err := convertClaimToInt64(subValue)
 	if err != nil {
-		return 0, fmt.Errorf("invalid subject in token")
+		return accessTokenClaims{}, fmt.Errorf("invalid subject in token")
+	}
+
+	parsed := accessTokenClaims{UserID: userID}
+	if versionValue, exists := claims["ver"]; exists {
+		version, err := convertClaimToInt64(versionValue)
+		if err != nil {
+			return accessTokenClaims{}, fmt.Errorf("invalid token version")
+		}
+		parsed.TokenVersion = version
 	}
 
-	return userID, nil
+	return parsed, nil
 }
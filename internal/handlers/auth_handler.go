@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-sql-driver/mysql"
@@ -30,9 +31,15 @@ const (
 
 type AuthHandler struct {
 	userRepo          *models.UserRepository
+	refreshRepo       *models.RefreshTokenRepository
+	loginRepo         *models.LoginAttemptRepository
 	jwtSecret         []byte
 	jwtExpiresHours   int
 	jwtExpiresMinutes int
+	refreshTokenDays  int
+	loginPolicy       models.LoginThrottlePolicy
+	passwordPolicy    PasswordPolicy
+	passwordReset     *services.PasswordResetService
 }
 
 type RegisterRequest struct {
@@ -48,9 +55,11 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token     string             `json:"token"`
-	ExpiresAt string             `json:"expiresAt"`
-	User      models.UserProfile `json:"user"`
+	Token                 string             `json:"token"`
+	ExpiresAt             string             `json:"expiresAt"`
+	RefreshToken          string             `json:"refreshToken,omitempty"`
+	RefreshTokenExpiresAt string             `json:"refreshTokenExpiresAt,omitempty"`
+	User                  models.UserProfile `json:"user"`
 }
 
 type RegisterResponse struct {
@@ -80,12 +89,18 @@ func (e *statusError) Error() string {
 	return e.message
 }
 
-func NewAuthHandler(userRepo *models.UserRepository, jwtSecret string, jwtExpiresHours int, jwtExpiresMinutes int) *AuthHandler {
+func NewAuthHandler(userRepo *models.UserRepository, refreshRepo *models.RefreshTokenRepository, loginRepo *models.LoginAttemptRepository, jwtSecret string, jwtExpiresHours int, jwtExpiresMinutes int, refreshTokenDays int, loginPolicy models.LoginThrottlePolicy, passwordPolicy PasswordPolicy, passwordReset *services.PasswordResetService) *AuthHandler {
 	return &AuthHandler{
 		userRepo:          userRepo,
+		refreshRepo:       refreshRepo,
+		loginRepo:         loginRepo,
 		jwtSecret:         []byte(jwtSecret),
 		jwtExpiresHours:   jwtExpiresHours,
 		jwtExpiresMinutes: jwtExpiresMinutes,
+		refreshTokenDays:  refreshTokenDays,
+		loginPolicy:       loginPolicy,
+		passwordPolicy:    passwordPolicy,
+		passwordReset:     passwordReset,
 	}
 }
 
@@ -113,8 +128,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	if len(req.Password) < 6 {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_PASSWORD", Message: "Password must be at least 6 characters"})
+	if err := h.passwordPolicy.Validate(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_PASSWORD", Message: err.Error()})
 		return
 	}
 
@@ -126,7 +141,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	if err := h.ensureCanCreateUser(c, req.Role); err != nil {
+	creator, err := h.ensureCanCreateUser(c, req.Role)
+	if err != nil {
 		var requestErr *statusError
 		if errors.As(err, &requestErr) {
 			errorCode := "UNAUTHORIZED"
@@ -163,6 +179,18 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	change := AuditChange{
+		Action:     "users.created",
+		EntityType: "user",
+		EntityID:   strconv.FormatInt(createdUser.ID, 10),
+		After:      createdUser.ToProfile(),
+	}
+	if creator != nil {
+		creatorProfile := creator.ToProfile()
+		change.Actor = &creatorProfile
+	}
+	setAuditChange(c, change)
+
 	c.JSON(http.StatusCreated, RegisterResponse{
 		Message: "User created successfully",
 		User:    createdUser.ToProfile(),
@@ -182,41 +210,49 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	now := time.Now()
+	clientIP := c.ClientIP()
+	if !h.checkLoginThrottle(c, req.Email, clientIP, now) {
+		return
+	}
+
 	user, err := h.userRepo.GetByEmail(req.Email)
 	if err != nil {
+		// Unknown emails count like wrong passwords so probing for accounts is
+		// throttled as well.
+		h.registerLoginFailure(c, req.Email, clientIP, nil, now)
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "INVALID_CREDENTIALS", Message: "Email or password is incorrect"})
 		return
 	}
 
 	if !user.IsActive {
+		h.recordLoginAttempt(c, req.Email, &user.ID, models.LoginOutcomeDisabled, now)
 		c.JSON(http.StatusForbidden, ErrorResponse{Error: "ACCOUNT_DISABLED", Message: "User account is disabled"})
 		return
 	}
 
 	if compareErr := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); compareErr != nil {
+		h.registerLoginFailure(c, req.Email, clientIP, &user.ID, now)
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "INVALID_CREDENTIALS", Message: "Email or password is incorrect"})
 		return
 	}
 
-	token, expiresAt, err := h.generateToken(user)
+	response, err := h.issueSession(c, user, "")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "TOKEN_ERROR", Message: "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, AuthResponse{
-		Token:     token,
-		ExpiresAt: expiresAt.Format(time.RFC3339),
-		User:      user.ToProfile(),
-	})
+	h.registerLoginSuccess(c, req.Email, user.ID, now)
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *AuthHandler) UpdateProfile(c *gin.Context) {
-	userID, err := h.getUserIDFromAuthorizationHeader(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return
 	}
+	userID := currentUser.ID
 
 	var req UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -245,7 +281,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 			return
 		}
 
-		if err.Error() == "user not found" {
+		if errors.Is(err, models.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "User not found"})
 			return
 		}
@@ -255,6 +291,16 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	invalidateCurrentUserCache(userID)
+	change := AuditChange{
+		Action:     "users.profile_updated",
+		EntityType: "user",
+		EntityID:   strconv.FormatInt(userID, 10),
+		After:      updatedUser.ToProfile(),
+	}
+	if before, ok := currentUserFromContext(c); ok {
+		change.Before = *before
+	}
+	setAuditChange(c, change)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile updated successfully",
@@ -263,19 +309,19 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 }
 
 func (h *AuthHandler) GetProfile(c *gin.Context) {
-	userID, err := h.getUserIDFromAuthorizationHeader(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return
 	}
+	userID := currentUser.ID
 
 	user, err := loadActiveUserByID(h.userRepo, userID)
 	if err != nil {
-		if err.Error() == "user not found" {
+		if errors.Is(err, models.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "User not found"})
 			return
 		}
-		if err.Error() == "user account is disabled" {
+		if errors.Is(err, models.ErrUserDisabled) {
 			c.JSON(http.StatusForbidden, ErrorResponse{Error: "ACCOUNT_DISABLED", Message: "User account is disabled"})
 			return
 		}
@@ -290,14 +336,8 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 }
 
 func (h *AuthHandler) ListManagedUsers(c *gin.Context) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
-		return
-	}
-
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only admin and chi_huy_khoa can manage accounts"})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return
 	}
 
@@ -315,14 +355,8 @@ func (h *AuthHandler) ListManagedUsers(c *gin.Context) {
 }
 
 func (h *AuthHandler) UpdateManagedUserRole(c *gin.Context) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
-		return
-	}
-
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only admin and chi_huy_khoa can manage accounts"})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return
 	}
 
@@ -351,11 +385,11 @@ func (h *AuthHandler) UpdateManagedUserRole(c *gin.Context) {
 
 	targetUser, err := loadActiveUserByID(h.userRepo, userID)
 	if err != nil {
-		if err.Error() == "user not found" {
+		if errors.Is(err, models.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "User not found"})
 			return
 		}
-		if err.Error() == "user account is disabled" {
+		if errors.Is(err, models.ErrUserDisabled) {
 			c.JSON(http.StatusForbidden, ErrorResponse{Error: "ACCOUNT_DISABLED", Message: "User account is disabled"})
 			return
 		}
@@ -376,7 +410,7 @@ func (h *AuthHandler) UpdateManagedUserRole(c *gin.Context) {
 
 	updatedUser, err := h.userRepo.UpdateRole(userID, req.Role)
 	if err != nil {
-		if err.Error() == "user not found" {
+		if errors.Is(err, models.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "User not found"})
 			return
 		}
@@ -385,7 +419,14 @@ func (h *AuthHandler) UpdateManagedUserRole(c *gin.Context) {
 		return
 	}
 
-	invalidateCurrentUserCache(userID)
+	h.revokeUserSessions(userID)
+	setAuditChange(c, AuditChange{
+		Action:     "users.role_changed",
+		EntityType: "user",
+		EntityID:   strconv.FormatInt(userID, 10),
+		Before:     targetUser.ToProfile(),
+		After:      updatedUser.ToProfile(),
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User role updated successfully",
@@ -394,14 +435,8 @@ func (h *AuthHandler) UpdateManagedUserRole(c *gin.Context) {
 }
 
 func (h *AuthHandler) DeleteManagedUser(c *gin.Context) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
-		return
-	}
-
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only admin and chi_huy_khoa can manage accounts"})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return
 	}
 
@@ -418,11 +453,11 @@ func (h *AuthHandler) DeleteManagedUser(c *gin.Context) {
 
 	targetUser, err := loadActiveUserByID(h.userRepo, userID)
 	if err != nil {
-		if err.Error() == "user not found" {
+		if errors.Is(err, models.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "User not found"})
 			return
 		}
-		if err.Error() == "user account is disabled" {
+		if errors.Is(err, models.ErrUserDisabled) {
 			c.JSON(http.StatusForbidden, ErrorResponse{Error: "ACCOUNT_DISABLED", Message: "User account is disabled"})
 			return
 		}
@@ -437,7 +472,7 @@ func (h *AuthHandler) DeleteManagedUser(c *gin.Context) {
 	}
 
 	if err := h.userRepo.DeactivateByID(userID); err != nil {
-		if err.Error() == "user not found" {
+		if errors.Is(err, models.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "User not found"})
 			return
 		}
@@ -446,7 +481,13 @@ func (h *AuthHandler) DeleteManagedUser(c *gin.Context) {
 		return
 	}
 
-	invalidateCurrentUserCache(userID)
+	h.revokeUserSessions(userID)
+	setAuditChange(c, AuditChange{
+		Action:     "users.deleted",
+		EntityType: "user",
+		EntityID:   strconv.FormatInt(userID, 10),
+		Before:     targetUser.ToProfile(),
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User deleted successfully",
@@ -454,14 +495,8 @@ func (h *AuthHandler) DeleteManagedUser(c *gin.Context) {
 }
 
 func (h *AuthHandler) ResetManagedUserPassword(c *gin.Context) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
-		return
-	}
-
-	if !userHasAnyRole(currentUser, RoleAdmin) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only admin can reset account passwords"})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return
 	}
 
@@ -478,11 +513,11 @@ func (h *AuthHandler) ResetManagedUserPassword(c *gin.Context) {
 
 	targetUser, err := loadActiveUserByID(h.userRepo, userID)
 	if err != nil {
-		if err.Error() == "user not found" {
+		if errors.Is(err, models.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "User not found"})
 			return
 		}
-		if err.Error() == "user account is disabled" {
+		if errors.Is(err, models.ErrUserDisabled) {
 			c.JSON(http.StatusForbidden, ErrorResponse{Error: "ACCOUNT_DISABLED", Message: "User account is disabled"})
 			return
 		}
@@ -503,8 +538,8 @@ func (h *AuthHandler) ResetManagedUserPassword(c *gin.Context) {
 	}
 
 	req.Password = strings.TrimSpace(req.Password)
-	if len(req.Password) < 6 {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_PASSWORD", Message: "Password must be at least 6 characters"})
+	if err := h.passwordPolicy.Validate(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_PASSWORD", Message: err.Error()})
 		return
 	}
 
@@ -515,7 +550,7 @@ func (h *AuthHandler) ResetManagedUserPassword(c *gin.Context) {
 	}
 
 	if _, err := h.userRepo.UpdatePassword(userID, string(passwordHash)); err != nil {
-		if err.Error() == "user not found" {
+		if errors.Is(err, models.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "User not found"})
 			return
 		}
@@ -524,7 +559,12 @@ func (h *AuthHandler) ResetManagedUserPassword(c *gin.Context) {
 		return
 	}
 
-	invalidateCurrentUserCache(userID)
+	h.revokeUserSessions(userID)
+	setAuditChange(c, AuditChange{
+		Action:     "users.password_reset_by_admin",
+		EntityType: "user",
+		EntityID:   strconv.FormatInt(userID, 10),
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User password reset successfully",
@@ -547,6 +587,7 @@ func (h *AuthHandler) generateToken(user *models.User) (string, time.Time, error
 		"email":    user.Email,
 		"username": user.Username,
 		"role":     user.Role,
+		"ver":      user.TokenVersion,
 		"iat":      issuedAt.Unix(),
 		"exp":      expiresAt.Unix(),
 	}
@@ -578,87 +619,45 @@ func isAccountCreatorRole(role string) bool {
 	return role == RoleAdmin || role == RoleChiHuyKhoa
 }
 
-func (h *AuthHandler) ensureCanCreateUser(c *gin.Context, requestedRole string) error {
+// ensureCanCreateUser returns the signed-in account creator, or nil when the
+// very first (admin) account is being bootstrapped.
+func (h *AuthHandler) ensureCanCreateUser(c *gin.Context, requestedRole string) (*models.User, error) {
 	requestingUser, err := h.getCurrentUser(c)
 	if err == nil {
 		if !requestingUser.IsActive {
-			return &statusError{status: http.StatusForbidden, message: "User account is disabled"}
+			return nil, &statusError{status: http.StatusForbidden, message: "User account is disabled"}
 		}
 		if !isAccountCreatorRole(requestingUser.Role) {
-			return &statusError{status: http.StatusForbidden, message: createAccountMessage}
+			return nil, &statusError{status: http.StatusForbidden, message: createAccountMessage}
 		}
 		if !canAssignManagedRole(requestingUser.Role, requestedRole) {
-			return &statusError{status: http.StatusForbidden, message: "You do not have permission to create an account with this role"}
+			return nil, &statusError{status: http.StatusForbidden, message: "You do not have permission to create an account with this role"}
 		}
-		return nil
+		return requestingUser, nil
 	}
 
 	userCount, countErr := h.userRepo.CountUsers()
 	if countErr != nil {
-		return countErr
+		return nil, countErr
 	}
 
 	if userCount == 0 {
 		if requestedRole != RoleAdmin {
-			return &statusError{status: http.StatusBadRequest, message: "First account must use role admin"}
+			return nil, &statusError{status: http.StatusBadRequest, message: "First account must use role admin"}
 		}
-		return nil
+		return nil, nil
 	}
 
-	return &statusError{status: http.StatusUnauthorized, message: createAccountMessage}
+	return nil, &statusError{status: http.StatusUnauthorized, message: createAccountMessage}
 }
 
 func (h *AuthHandler) getCurrentUser(c *gin.Context) (*models.User, error) {
-	userID, err := h.getUserIDFromAuthorizationHeader(c)
+	claims, err := parseAuthorizationHeader(c, h.jwtSecret)
 	if err != nil {
 		return nil, err
 	}
 
-	return loadActiveUserByID(h.userRepo, userID)
-}
-
-func (h *AuthHandler) getUserIDFromAuthorizationHeader(c *gin.Context) (int64, error) {
-	authHeader := strings.TrimSpace(c.GetHeader("Authorization"))
-	if authHeader == "" {
-		return 0, fmt.Errorf("missing authorization header")
-	}
-
-	parts := strings.SplitN(authHeader, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return 0, fmt.Errorf("invalid authorization header format")
-	}
-
-	tokenString := strings.TrimSpace(parts[1])
-	if tokenString == "" {
-		return 0, fmt.Errorf("missing bearer token")
-	}
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
-		}
-		return h.jwtSecret, nil
-	})
-	if err != nil || !token.Valid {
-		return 0, fmt.Errorf("invalid or expired token")
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return 0, fmt.Errorf("invalid token claims")
-	}
-
-	subValue, exists := claims["sub"]
-	if !exists {
-		return 0, fmt.Errorf("missing subject in token")
-	}
-
-	userID, err := convertClaimToInt64(subValue)
-	if err != nil {
-		return 0, fmt.Errorf("invalid subject in token")
-	}
-
-	return userID, nil
+	return loadActiveUserForToken(h.userRepo, claims)
 }
 
 func convertClaimToInt64(value interface{}) (int64, error) {
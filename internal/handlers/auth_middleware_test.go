@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAuthMiddlewareEnforcesRoutePolicies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const secret = "middleware-test-secret"
+	const userID int64 = 900001
+	currentUserCache.Store(userID, currentUserCacheEntry{
+		profile:   models.UserProfile{ID: userID, Username: "thu.kho", Role: RoleThuKho},
+		expiresAt: time.Now().Add(time.Minute),
+	})
+	defer invalidateCurrentUserCache(userID)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": userID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	router := gin.New()
+	api := router.Group("/api")
+	api.Use(NewAuthMiddleware(nil, secret).Enforce(RoutePolicies{
+		"GET /api/public":  PublicRoute(),
+		"GET /api/any":     AuthenticatedRoute(),
+		"GET /api/thu-kho": RolesRoute(RoleThuKho),
+		"GET /api/admin":   RolesRoute(RoleAdmin),
+	}))
+	whoAmI := func(c *gin.Context) {
+		user, ok := currentUserFromContext(c)
+		if !ok {
+			c.Status(http.StatusTeapot)
+			return
+		}
+		c.String(http.StatusOK, user.Username)
+	}
+	for _, path := range []string{"/public", "/any", "/thu-kho", "/admin", "/unlisted"} {
+		api.GET(path, whoAmI)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{name: "public without token", path: "/api/public", wantStatus: http.StatusTeapot},
+		{name: "authenticated route without token", path: "/api/any", wantStatus: http.StatusUnauthorized},
+		{name: "authenticated route", path: "/api/any", token: token, wantStatus: http.StatusOK},
+		{name: "allowed role", path: "/api/thu-kho", token: token, wantStatus: http.StatusOK},
+		{name: "other role", path: "/api/admin", token: token, wantStatus: http.StatusForbidden},
+		{name: "route without policy", path: "/api/unlisted", token: token, wantStatus: http.StatusForbidden},
+		{name: "unknown path", path: "/api/missing", token: token, wantStatus: http.StatusNotFound},
+		{name: "unknown path without token", path: "/api/missing", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+const forgotPasswordMessage = "If the email belongs to an active account, a password reset link has been sent"
+
+// ChangePassword lets a signed-in user replace their own password. Every other
+// session is ended; the caller gets a fresh session in the response.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid change password payload"})
+		return
+	}
+
+	user, err := loadActiveUserByID(h.userRepo, currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+
+	if compareErr := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); compareErr != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_CURRENT_PASSWORD", Message: "Current password is incorrect"})
+		return
+	}
+
+	req.NewPassword = strings.TrimSpace(req.NewPassword)
+	if err := h.passwordPolicy.Validate(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_PASSWORD", Message: err.Error()})
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "PASSWORD_UNCHANGED", Message: "New password must differ from the current password"})
+		return
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "HASH_ERROR", Message: "Failed to hash password"})
+		return
+	}
+
+	updatedUser, err := h.userRepo.UpdatePassword(user.ID, string(passwordHash))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	h.revokeUserSessions(user.ID)
+	setAuditChange(c, AuditChange{
+		Action:     "users.password_changed",
+		EntityType: "user",
+		EntityID:   strconv.FormatInt(user.ID, 10),
+	})
+
+	response, err := h.issueSession(c, updatedUser, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "TOKEN_ERROR", Message: "Password changed but failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ForgotPassword queues a reset link email. The answer is the same whether or
+// not the email exists; requests are limited per email and per client IP.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	if h.passwordReset == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Password reset is not configured"})
+		return
+	}
+
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid forgot password payload"})
+		return
+	}
+
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if !isValidEmail(req.Email) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_EMAIL", Message: "Email is invalid"})
+		return
+	}
+
+	wait, err := h.passwordReset.CheckRequestLimit(req.Email, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: "RESET_THROTTLED", Message: "Too many password reset requests. Please wait before trying again"})
+		return
+	}
+
+	// The email is looked up and sent in the background, so the response time
+	// is the same whether or not the account exists.
+	if !h.passwordReset.Enqueue(req.Email, c.ClientIP()) {
+		log.Printf("[auth] password reset queue is full; dropped request for %s", req.Email)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+}
+
+// ResetPassword sets a new password with a token from the reset email. The
+// token is checked last so a rejected password does not use it up.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	if h.passwordReset == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Password reset is not configured"})
+		return
+	}
+
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid reset password payload"})
+		return
+	}
+
+	req.Password = strings.TrimSpace(req.Password)
+	if err := h.passwordPolicy.Validate(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_PASSWORD", Message: err.Error()})
+		return
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "HASH_ERROR", Message: "Failed to hash password"})
+		return
+	}
+
+	userID, err := h.passwordReset.Redeem(req.Token)
+	if err != nil {
+		if errors.Is(err, models.ErrPasswordResetTokenInvalid) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_RESET_TOKEN", Message: err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	user, err := loadActiveUserByID(h.userRepo, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserDisabled) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "ACCOUNT_DISABLED", Message: "User account is disabled"})
+			return
+		}
+		if errors.Is(err, models.ErrUserNotFound) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_RESET_TOKEN", Message: models.ErrPasswordResetTokenInvalid.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	if _, err := h.userRepo.UpdatePassword(user.ID, string(passwordHash)); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	h.revokeUserSessions(user.ID)
+	// Whoever holds the mailbox proved ownership, so earlier failed logins no
+	// longer need to keep the account locked.
+	if h.loginRepo != nil {
+		if err := h.loginRepo.ClearThrottle(models.LoginThrottleScopeEmail, strings.ToLower(strings.TrimSpace(user.Email))); err != nil {
+			log.Printf("[auth] clearing login throttle after password reset failed: %v", err)
+		}
+	}
+
+	actor := user.ToProfile()
+	setAuditChange(c, AuditChange{
+		Action:     "users.password_reset",
+		EntityType: "user",
+		EntityID:   strconv.FormatInt(user.ID, 10),
+		Actor:      &actor,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
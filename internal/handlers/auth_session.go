@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultRefreshTokenDays = 14
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshSession trades a refresh token for a new access token and a new
+// refresh token. Presenting a token that was already rotated ends the whole
+// session, since it means the token was copied.
+func (h *AuthHandler) RefreshSession(c *gin.Context) {
+	if h.refreshRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Refresh tokens are not configured"})
+		return
+	}
+
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid refresh payload"})
+		return
+	}
+
+	now := time.Now()
+	stored, err := h.refreshRepo.GetByHash(models.HashRefreshToken(strings.TrimSpace(req.RefreshToken)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if stored == nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "INVALID_REFRESH_TOKEN", Message: "Refresh token is invalid"})
+		return
+	}
+	if stored.RevokedAt != nil {
+		h.revokeSessionFamily(stored.FamilyID, now)
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "INVALID_REFRESH_TOKEN", Message: "Refresh token has been revoked"})
+		return
+	}
+	if !now.Before(stored.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "REFRESH_TOKEN_EXPIRED", Message: "Refresh token has expired"})
+		return
+	}
+
+	user, err := loadActiveUserForToken(h.userRepo, accessTokenClaims{UserID: stored.UserID, TokenVersion: stored.TokenVersion})
+	if err != nil {
+		h.revokeSessionFamily(stored.FamilyID, now)
+		if errors.Is(err, models.ErrUserDisabled) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "ACCOUNT_DISABLED", Message: "User account is disabled"})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "INVALID_REFRESH_TOKEN", Message: err.Error()})
+		return
+	}
+
+	response, err := h.rotateSession(c, user, stored, now)
+	if errors.Is(err, models.ErrRefreshTokenReused) {
+		h.revokeSessionFamily(stored.FamilyID, now)
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "INVALID_REFRESH_TOKEN", Message: "Refresh token has been revoked"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "TOKEN_ERROR", Message: "Failed to refresh session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout ends the session of the presented refresh token. It works without a
+// valid access token so an expired client can still sign out.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if h.refreshRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Refresh tokens are not configured"})
+		return
+	}
+
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid logout payload"})
+		return
+	}
+
+	stored, err := h.refreshRepo.GetByHash(models.HashRefreshToken(strings.TrimSpace(req.RefreshToken)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if stored != nil {
+		if err := h.refreshRepo.RevokeFamily(stored.FamilyID, time.Now()); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAllSessions bumps the caller's token version, which invalidates every
+// access and refresh token issued to them so far.
+func (h *AuthHandler) LogoutAllSessions(c *gin.Context) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.BumpTokenVersion(currentUser.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	h.revokeUserSessions(currentUser.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions logged out successfully"})
+}
+
+// issueSession signs an access token and starts a refresh token family, or
+// continues familyID when it is set.
+func (h *AuthHandler) issueSession(c *gin.Context, user *models.User, familyID string) (AuthResponse, error) {
+	token, expiresAt, err := h.generateToken(user)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	response := AuthResponse{
+		Token:     token,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+		User:      user.ToProfile(),
+	}
+	if h.refreshRepo == nil {
+		return response, nil
+	}
+
+	refresh, refreshValue, refreshHash, err := h.newRefreshToken(c, user, familyID, time.Now())
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if err := h.refreshRepo.Create(refresh, refreshHash); err != nil {
+		return AuthResponse{}, err
+	}
+
+	response.RefreshToken = refreshValue
+	response.RefreshTokenExpiresAt = refresh.ExpiresAt.Format(time.RFC3339)
+	return response, nil
+}
+
+func (h *AuthHandler) rotateSession(c *gin.Context, user *models.User, current *models.RefreshToken, now time.Time) (AuthResponse, error) {
+	token, expiresAt, err := h.generateToken(user)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	next, nextValue, nextHash, err := h.newRefreshToken(c, user, current.FamilyID, now)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if err := h.refreshRepo.Rotate(current, next, nextHash, now); err != nil {
+		return AuthResponse{}, err
+	}
+
+	return AuthResponse{
+		Token:                 token,
+		ExpiresAt:             expiresAt.Format(time.RFC3339),
+		RefreshToken:          nextValue,
+		RefreshTokenExpiresAt: next.ExpiresAt.Format(time.RFC3339),
+		User:                  user.ToProfile(),
+	}, nil
+}
+
+func (h *AuthHandler) newRefreshToken(c *gin.Context, user *models.User, familyID string, now time.Time) (*models.RefreshToken, string, string, error) {
+	if familyID == "" {
+		generated, err := models.NewRefreshTokenFamilyID()
+		if err != nil {
+			return nil, "", "", err
+		}
+		familyID = generated
+	}
+
+	value, hash, err := models.NewRefreshTokenValue()
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	days := h.refreshTokenDays
+	if days <= 0 {
+		days = defaultRefreshTokenDays
+	}
+
+	return &models.RefreshToken{
+		UserID:       user.ID,
+		FamilyID:     familyID,
+		TokenVersion: user.TokenVersion,
+		ExpiresAt:    now.AddDate(0, 0, days),
+		CreatedAt:    now,
+		UserAgent:    c.Request.UserAgent(),
+		IPAddress:    c.ClientIP(),
+	}, value, hash, nil
+}
+
+func (h *AuthHandler) revokeSessionFamily(familyID string, now time.Time) {
+	if err := h.refreshRepo.RevokeFamily(familyID, now); err != nil {
+		log.Printf("[auth] revoking refresh token family failed: %v", err)
+	}
+}
+
+// revokeUserSessions drops the cached profile and the refresh tokens of a user
+// whose token version was just bumped. The version alone already rejects the
+// old tokens; revoking the rows keeps the session list accurate.
+func (h *AuthHandler) revokeUserSessions(userID int64) {
+	invalidateCurrentUserCache(userID)
+	if h.refreshRepo == nil {
+		return
+	}
+	if err := h.refreshRepo.RevokeAllForUser(userID, time.Now()); err != nil {
+		log.Printf("[auth] revoking refresh tokens of user %d failed: %v", userID, err)
+	}
+}
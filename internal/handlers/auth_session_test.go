@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAccessTokenCarriesTokenVersion(t *testing.T) {
+	handler := &AuthHandler{jwtSecret: []byte("session-test-secret"), jwtExpiresMinutes: 15}
+
+	token, _, err := handler.generateToken(&models.User{ID: 42, Role: RoleThuKho, TokenVersion: 3})
+	if err != nil {
+		t.Fatalf("generateToken returned error: %v", err)
+	}
+
+	claims, err := parseAccessToken(token, handler.jwtSecret)
+	if err != nil {
+		t.Fatalf("parseAccessToken returned error: %v", err)
+	}
+	if claims.UserID != 42 || claims.TokenVersion != 3 {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestCurrentUserRejectsTokensOfAnOlderVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const userID int64 = 900002
+	handler := &AuthHandler{jwtSecret: []byte("session-test-secret"), jwtExpiresMinutes: 15}
+	currentUserCache.Store(userID, currentUserCacheEntry{
+		profile:      models.UserProfile{ID: userID, Username: "admin", Role: RoleAdmin},
+		tokenVersion: 2,
+		expiresAt:    time.Now().Add(time.Minute),
+	})
+	defer invalidateCurrentUserCache(userID)
+
+	for _, tt := range []struct {
+		version int64
+		wantErr error
+	}{
+		{version: 1, wantErr: errTokenRevoked},
+		{version: 2},
+	} {
+		token, _, err := handler.generateToken(&models.User{ID: userID, TokenVersion: tt.version})
+		if err != nil {
+			t.Fatalf("generateToken returned error: %v", err)
+		}
+
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/api/auth/profile", nil)
+		ctx.Request.Header.Set("Authorization", "Bearer "+token)
+
+		_, err = authenticateRequest(ctx, nil, handler.jwtSecret)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("version %d: error = %v, want %v", tt.version, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRefreshSessionRequiresRefreshStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/auth/refresh", strings.NewReader(`{"refreshToken":"abc"}`))
+
+	(&AuthHandler{}).RefreshSession(ctx)
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestLogoutAllSessionsRequiresAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/auth/logout-all", nil)
+
+	(&AuthHandler{}).LogoutAllSessions(ctx)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestPasswordEndpointsRequireConfiguration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		handler    func(*AuthHandler, *gin.Context)
+		wantStatus int
+	}{
+		{name: "change password without token", path: "/api/auth/password", body: `{"currentPassword":"a","newPassword":"b"}`, handler: (*AuthHandler).ChangePassword, wantStatus: http.StatusUnauthorized},
+		{name: "forgot password without mailer", path: "/api/auth/forgot-password", body: `{"email":"a@bv108.vn"}`, handler: (*AuthHandler).ForgotPassword, wantStatus: http.StatusServiceUnavailable},
+		{name: "reset password without mailer", path: "/api/auth/reset-password", body: `{"token":"abc","password":"matkhau123"}`, handler: (*AuthHandler).ResetPassword, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+
+			tt.handler(&AuthHandler{}, ctx)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+		})
+	}
+}
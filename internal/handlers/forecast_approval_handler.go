@@ -14,10 +14,9 @@ import (
 )
 
 type ForecastApprovalHandler struct {
-	repo      *models.ForecastApprovalRepository
-	userRepo  *models.UserRepository
-	jwtSecret []byte
-	hub       *realtime.Hub
+	repo       *models.ForecastApprovalRepository
+	periodRepo *models.ForecastPeriodRepository
+	hub        *realtime.Hub
 }
 
 type SaveForecastApprovalRequest struct {
@@ -38,6 +37,7 @@ type SaveForecastApprovalsRequest struct {
 
 type forecastTransitionError struct {
 	status  int
+	code    string
 	message string
 }
 
@@ -45,18 +45,16 @@ func (e *forecastTransitionError) Error() string {
 	return e.message
 }
 
-func NewForecastApprovalHandler(repo *models.ForecastApprovalRepository, userRepo *models.UserRepository, jwtSecret string, hub *realtime.Hub) *ForecastApprovalHandler {
+func NewForecastApprovalHandler(repo *models.ForecastApprovalRepository, periodRepo *models.ForecastPeriodRepository, hub *realtime.Hub) *ForecastApprovalHandler {
 	return &ForecastApprovalHandler{
-		repo:      repo,
-		userRepo:  userRepo,
-		jwtSecret: []byte(jwtSecret),
-		hub:       hub,
+		repo:       repo,
+		periodRepo: periodRepo,
+		hub:        hub,
 	}
 }
 
 func (h *ForecastApprovalHandler) GetForecastApprovals(c *gin.Context) {
-	if _, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret); err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+	if _, ok := requireCurrentUser(c); !ok {
 		return
 	}
 
@@ -74,13 +72,17 @@ func (h *ForecastApprovalHandler) GetForecastApprovals(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
 		return
 	}
+	period, err := h.getForecastPeriod(month, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"data": records})
+	c.JSON(http.StatusOK, gin.H{"data": records, "period": period})
 }
 
 func (h *ForecastApprovalHandler) GetForecastChangeHistory(c *gin.Context) {
-	if _, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret); err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+	if _, ok := requireCurrentUser(c); !ok {
 		return
 	}
 
@@ -105,8 +107,7 @@ func (h *ForecastApprovalHandler) GetForecastChangeHistory(c *gin.Context) {
 }
 
 func (h *ForecastApprovalHandler) GetForecastMonthlyHistory(c *gin.Context) {
-	if _, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret); err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+	if _, ok := requireCurrentUser(c); !ok {
 		return
 	}
 
@@ -120,9 +121,8 @@ func (h *ForecastApprovalHandler) GetForecastMonthlyHistory(c *gin.Context) {
 }
 
 func (h *ForecastApprovalHandler) SaveForecastApproval(c *gin.Context) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return
 	}
 
@@ -143,8 +143,13 @@ func (h *ForecastApprovalHandler) SaveForecastApproval(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
 		return
 	}
+	period, err := h.getForecastPeriod(req.ForecastMonth, req.ForecastYear)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
 
-	if err := validateForecastApprovalTransition(req, currentUser, lookupExistingForecastStatus(req, statusByItemKey)); err != nil {
+	if err := validateForecastApprovalTransition(req, currentUser, lookupExistingForecastStatus(req, statusByItemKey), period, time.Now()); err != nil {
 		writeForecastTransitionError(c, err)
 		return
 	}
@@ -165,9 +170,8 @@ func (h *ForecastApprovalHandler) SaveForecastApproval(c *gin.Context) {
 }
 
 func (h *ForecastApprovalHandler) SaveForecastApprovalsBulk(c *gin.Context) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return
 	}
 
@@ -191,6 +195,8 @@ func (h *ForecastApprovalHandler) SaveForecastApprovalsBulk(c *gin.Context) {
 	}
 
 	statusCacheByPeriod := make(map[string]map[string]string)
+	periodCache := make(map[string]*models.ForecastPeriod)
+	now := time.Now()
 	inputs := make([]models.SaveForecastApprovalInput, 0, len(req.Items))
 	for _, item := range req.Items {
 		periodKey := fmt.Sprintf("%04d-%02d", item.ForecastYear, item.ForecastMonth)
@@ -203,9 +209,16 @@ func (h *ForecastApprovalHandler) SaveForecastApprovalsBulk(c *gin.Context) {
 			}
 			statusByItemKey = loaded
 			statusCacheByPeriod[periodKey] = statusByItemKey
+
+			period, err := h.getForecastPeriod(item.ForecastMonth, item.ForecastYear)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+				return
+			}
+			periodCache[periodKey] = period
 		}
 
-		if err := validateForecastApprovalTransition(item, currentUser, lookupExistingForecastStatus(item, statusByItemKey)); err != nil {
+		if err := validateForecastApprovalTransition(item, currentUser, lookupExistingForecastStatus(item, statusByItemKey), periodCache[periodKey], now); err != nil {
 			writeForecastTransitionError(c, err)
 			return
 		}
@@ -338,7 +351,9 @@ func (h *ForecastApprovalHandler) getForecastStatusByPeriod(month, year int) (ma
 func writeForecastTransitionError(c *gin.Context, err error) {
 	if transitionErr, ok := err.(*forecastTransitionError); ok {
 		errorCode := "INVALID_REQUEST"
-		if transitionErr.status == http.StatusForbidden {
+		if transitionErr.code != "" {
+			errorCode = transitionErr.code
+		} else if transitionErr.status == http.StatusForbidden {
 			errorCode = "FORBIDDEN"
 		}
 
@@ -349,12 +364,16 @@ func writeForecastTransitionError(c *gin.Context, err error) {
 	c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: err.Error()})
 }
 
-func validateForecastApprovalTransition(req SaveForecastApprovalRequest, currentUser *models.UserProfile, existingStatus string) error {
+func validateForecastApprovalTransition(req SaveForecastApprovalRequest, currentUser *models.UserProfile, existingStatus string, period *models.ForecastPeriod, now time.Time) error {
 	normalizedStatus := strings.TrimSpace(req.Status)
 	normalizedExistingStatus := strings.TrimSpace(existingStatus)
 
 	isAdmin := userHasAnyRole(currentUser, RoleAdmin)
 
+	if err := validateForecastPeriodRules(period, normalizedStatus, isAdmin, now); err != nil {
+		return err
+	}
+
 	switch normalizedStatus {
 	case models.ForecastApprovalStatusEdited:
 		if userHasAnyRole(currentUser, RoleThuKho) && !isAdmin {
@@ -419,6 +438,53 @@ func validateForecastApprovalTransition(req SaveForecastApprovalRequest, current
 	}
 }
 
+// validateForecastPeriodRules applies the period configuration: nothing
+// changes in a locked period, and once a deadline passes only Admin may still
+// make the changes it covers. Edits and submissions close at the submission
+// deadline, approvals and rejections at the approval deadline.
+func validateForecastPeriodRules(period *models.ForecastPeriod, status string, isAdmin bool, now time.Time) error {
+	if period == nil {
+		return nil
+	}
+	if period.Locked {
+		return &forecastTransitionError{
+			status:  http.StatusForbidden,
+			code:    "FORECAST_PERIOD_LOCKED",
+			message: fmt.Sprintf("Forecast period %d/%d is locked. Admin must unlock it first", period.ForecastMonth, period.ForecastYear),
+		}
+	}
+	if isAdmin {
+		return nil
+	}
+
+	switch status {
+	case models.ForecastApprovalStatusEdited, models.ForecastApprovalStatusSubmitted:
+		if period.SubmissionClosed(now) {
+			return &forecastTransitionError{
+				status:  http.StatusForbidden,
+				code:    "FORECAST_DEADLINE_PASSED",
+				message: fmt.Sprintf("Submission deadline for %d/%d passed at %s", period.ForecastMonth, period.ForecastYear, period.SubmissionDeadline.Format(time.RFC3339)),
+			}
+		}
+	case models.ForecastApprovalStatusApproved, models.ForecastApprovalStatusRejected:
+		if period.ApprovalClosed(now) {
+			return &forecastTransitionError{
+				status:  http.StatusForbidden,
+				code:    "FORECAST_DEADLINE_PASSED",
+				message: fmt.Sprintf("Approval deadline for %d/%d passed at %s", period.ForecastMonth, period.ForecastYear, period.ApprovalDeadline.Format(time.RFC3339)),
+			}
+		}
+	}
+	return nil
+}
+
+func (h *ForecastApprovalHandler) getForecastPeriod(month, year int) (*models.ForecastPeriod, error) {
+	if h.periodRepo == nil {
+		return nil, nil
+	}
+	return h.periodRepo.GetPeriod(month, year)
+}
+
 func lookupExistingForecastStatus(req SaveForecastApprovalRequest, statusByItemKey map[string]string) string {
 	primaryKey := forecastApprovalStatusKey(req.MaQuanLy, req.MaVtytCu)
 	if primaryKey != "" {
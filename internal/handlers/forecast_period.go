@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdateForecastPeriodRequest struct {
+	SubmissionDeadline *time.Time `json:"submissionDeadline"`
+	ApprovalDeadline   *time.Time `json:"approvalDeadline"`
+}
+
+type ForecastPeriodLockRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *ForecastApprovalHandler) ListForecastPeriods(c *gin.Context) {
+	if _, ok := requireCurrentUser(c); !ok {
+		return
+	}
+	if !h.requireForecastPeriodRepo(c) {
+		return
+	}
+
+	year := 0
+	if raw := strings.TrimSpace(c.Query("year")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 2000 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "year is invalid"})
+			return
+		}
+		year = parsed
+	}
+
+	periods, err := h.periodRepo.ListPeriods(year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": periods})
+}
+
+// GetForecastPeriod returns the rules of one month and their audit trail. A
+// month that was never configured comes back unlocked without deadlines.
+func (h *ForecastApprovalHandler) GetForecastPeriod(c *gin.Context) {
+	if _, ok := requireCurrentUser(c); !ok {
+		return
+	}
+	month, year, ok := parseForecastPeriodParams(c)
+	if !ok || !h.requireForecastPeriodRepo(c) {
+		return
+	}
+
+	period, err := h.periodRepo.GetPeriod(month, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if period == nil {
+		period = &models.ForecastPeriod{ForecastMonth: month, ForecastYear: year}
+	}
+	audit, err := h.periodRepo.ListAudit(month, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": period, "audit": audit})
+}
+
+func (h *ForecastApprovalHandler) UpdateForecastPeriod(c *gin.Context) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	month, year, ok := parseForecastPeriodParams(c)
+	if !ok || !h.requireForecastPeriodRepo(c) {
+		return
+	}
+
+	var req UpdateForecastPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid forecast period payload; deadlines use RFC 3339"})
+		return
+	}
+	if req.SubmissionDeadline != nil && req.ApprovalDeadline != nil && req.ApprovalDeadline.Before(*req.SubmissionDeadline) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "approvalDeadline must not be before submissionDeadline"})
+		return
+	}
+
+	period, err := h.periodRepo.SaveDeadlines(models.ForecastPeriodDeadlinesInput{
+		ForecastMonth:      month,
+		ForecastYear:       year,
+		SubmissionDeadline: req.SubmissionDeadline,
+		ApprovalDeadline:   req.ApprovalDeadline,
+		Actor:              forecastPeriodActor(currentUser),
+		Now:                time.Now(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	h.broadcastForecastPeriodUpdated(currentUser, period, models.ForecastPeriodActionConfigure)
+	c.JSON(http.StatusOK, gin.H{"data": period})
+}
+
+// LockForecastPeriod freezes every forecast of a month, typically once Chi
+// huy khoa has approved the whole period.
+func (h *ForecastApprovalHandler) LockForecastPeriod(c *gin.Context) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	h.setForecastPeriodLocked(c, currentUser, true, false)
+}
+
+// UnlockForecastPeriod reopens a locked month. A reason is required and kept
+// in the period audit.
+func (h *ForecastApprovalHandler) UnlockForecastPeriod(c *gin.Context) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	h.setForecastPeriodLocked(c, currentUser, false, true)
+}
+
+func (h *ForecastApprovalHandler) setForecastPeriodLocked(c *gin.Context, currentUser *models.UserProfile, locked bool, reasonRequired bool) {
+	month, year, ok := parseForecastPeriodParams(c)
+	if !ok || !h.requireForecastPeriodRepo(c) {
+		return
+	}
+
+	var req ForecastPeriodLockRequest
+	// The reason is optional when locking, so an empty body is accepted.
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid forecast period lock payload"})
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reasonRequired && reason == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "reason is required"})
+		return
+	}
+
+	period, err := h.periodRepo.SetLocked(month, year, locked, reason, forecastPeriodActor(currentUser), time.Now())
+	if errors.Is(err, models.ErrForecastPeriodLockState) {
+		message := "Forecast period is already unlocked"
+		if locked {
+			message = "Forecast period is already locked"
+		}
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "CONFLICT", Message: message})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	action := models.ForecastPeriodActionUnlock
+	if locked {
+		action = models.ForecastPeriodActionLock
+	}
+	h.broadcastForecastPeriodUpdated(currentUser, period, action)
+	c.JSON(http.StatusOK, gin.H{"data": period})
+}
+
+func (h *ForecastApprovalHandler) broadcastForecastPeriodUpdated(currentUser *models.UserProfile, period *models.ForecastPeriod, action string) {
+	if h.hub == nil || period == nil {
+		return
+	}
+	h.hub.Broadcast("forecast.period_updated", gin.H{
+		"action":    action,
+		"period":    period,
+		"updatedBy": currentUser.Username,
+		"updatedAt": time.Now().UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func (h *ForecastApprovalHandler) requireForecastPeriodRepo(c *gin.Context) bool {
+	if h.periodRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Forecast periods are not configured"})
+		return false
+	}
+	return true
+}
+
+func parseForecastPeriodParams(c *gin.Context) (int, int, bool) {
+	year, yearErr := strconv.Atoi(strings.TrimSpace(c.Param("year")))
+	month, monthErr := strconv.Atoi(strings.TrimSpace(c.Param("month")))
+	if yearErr != nil || monthErr != nil || month < 1 || month > 12 || year < 2000 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "month/year is invalid"})
+		return 0, 0, false
+	}
+	return month, year, true
+}
+
+func forecastPeriodActor(user *models.UserProfile) models.OrderActor {
+	return models.OrderActor{ID: user.ID, Username: user.Username, Email: user.Email}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestForecastPeriodEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &ForecastApprovalHandler{}
+	tests := []struct {
+		name   string
+		method string
+		target string
+		call   func(*gin.Context)
+	}{
+		{name: "list", method: http.MethodGet, target: "/api/forecast-periods", call: handler.ListForecastPeriods},
+		{name: "get", method: http.MethodGet, target: "/api/forecast-periods/2026/6", call: handler.GetForecastPeriod},
+		{name: "update", method: http.MethodPut, target: "/api/forecast-periods/2026/6", call: handler.UpdateForecastPeriod},
+		{name: "lock", method: http.MethodPost, target: "/api/forecast-periods/2026/6/lock", call: handler.LockForecastPeriod},
+		{name: "unlock", method: http.MethodPost, target: "/api/forecast-periods/2026/6/unlock", call: handler.UnlockForecastPeriod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tt.method, tt.target, nil)
+
+			tt.call(ctx)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestValidateForecastPeriodRules(t *testing.T) {
+	now := time.Date(2026, 5, 20, 8, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		period   *models.ForecastPeriod
+		status   string
+		isAdmin  bool
+		wantCode string
+	}{
+		{name: "no period", period: nil, status: models.ForecastApprovalStatusSubmitted},
+		{name: "locked blocks admin", period: &models.ForecastPeriod{Locked: true}, status: models.ForecastApprovalStatusApproved, isAdmin: true, wantCode: "FORECAST_PERIOD_LOCKED"},
+		{name: "submission passed", period: &models.ForecastPeriod{SubmissionDeadline: &past}, status: models.ForecastApprovalStatusSubmitted, wantCode: "FORECAST_DEADLINE_PASSED"},
+		{name: "submission open", period: &models.ForecastPeriod{SubmissionDeadline: &future}, status: models.ForecastApprovalStatusEdited},
+		{name: "approval still open after submission closed", period: &models.ForecastPeriod{SubmissionDeadline: &past, ApprovalDeadline: &future}, status: models.ForecastApprovalStatusApproved},
+		{name: "approval passed", period: &models.ForecastPeriod{ApprovalDeadline: &past}, status: models.ForecastApprovalStatusRejected, wantCode: "FORECAST_DEADLINE_PASSED"},
+		{name: "admin bypasses deadlines", period: &models.ForecastPeriod{SubmissionDeadline: &past, ApprovalDeadline: &past}, status: models.ForecastApprovalStatusApproved, isAdmin: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateForecastPeriodRules(tt.period, tt.status, tt.isAdmin, now)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var transitionErr *forecastTransitionError
+			if !errors.As(err, &transitionErr) {
+				t.Fatalf("expected forecastTransitionError, got %v", err)
+			}
+			if transitionErr.status != http.StatusForbidden || transitionErr.code != tt.wantCode {
+				t.Fatalf("got status %d code %q, want 403 %q", transitionErr.status, transitionErr.code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetForecastSuggestions godoc
+// @Summary Get suggested forecast quantities
+// @Description Suggested DuTruGoc per forecast catalog supply from its consumption history, stock, open orders and packaging unit
+// @Tags supplies
+// @Param month query int false "Forecast month, default current month"
+// @Param year query int false "Forecast year, default current year"
+// @Param keyword query string false "Search keyword"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplies/forecast-suggestions [get]
+func (h *SupplyHandler) GetForecastSuggestions(c *gin.Context) {
+	visibleIDX1, ok := h.getVisibleSupplyIDX1ForRequester(c)
+	if !ok {
+		return
+	}
+	if h.stockRepo == nil || h.orderRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Forecast suggestions are not configured"})
+		return
+	}
+
+	now := time.Now()
+	month, _ := strconv.Atoi(c.DefaultQuery("month", strconv.Itoa(int(now.Month()))))
+	year, _ := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(now.Year())))
+	if month < 1 || month > 12 || year < 2000 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "month/year is invalid"})
+		return
+	}
+
+	supplies, err := h.repo.GetForecastCatalogVisible(c.Query("keyword"), visibleIDX1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	// A year of history plus the month before it, whose last snapshot is the
+	// baseline for the first month's outflow.
+	targetStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	history, err := h.stockRepo.ListMonthlyConsumption(targetStart.AddDate(-1, -1, 0), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	annualUsage, err := h.repo.ListCompareAnnualUsage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	outstanding, err := h.orderRepo.ListOutstandingQuantities(models.OrderOutstandingFilter{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	onOrder := models.OutstandingQuantityByMaterial(outstanding)
+
+	suggestions := make([]models.ForecastSuggestion, 0, len(supplies))
+	for _, supply := range supplies {
+		suggestions = append(suggestions, models.BuildForecastSuggestion(supply, models.ForecastSuggestionInput{
+			ForecastMonth: month,
+			ForecastYear:  year,
+			AsOf:          now,
+			History:       history[supply.IDX1],
+		}, annualUsage, onOrder))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"month": month,
+		"year":  year,
+		"data":  suggestions,
+		"total": len(suggestions),
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetForecastSuggestionsRejectsInvalidPeriod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &SupplyHandler{
+		stockRepo: &models.SupplyStockSnapshotRepository{},
+		orderRepo: &models.OrderRepository{},
+	}
+	for _, query := range []string{"month=0&year=2026", "month=13&year=2026", "month=abc&year=2026", "month=11&year=1999"} {
+		t.Run(query, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/supplies/forecast-suggestions?"+query, nil)
+			ctx.Set(currentUserContextKey, &models.UserProfile{ID: 1, Username: "Admin", Role: RoleAdmin})
+
+			handler.GetForecastSuggestions(ctx)
+
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d (body = %s)", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetForecastSuggestionsRequiresConfiguredRepositories(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/supplies/forecast-suggestions?month=11&year=2026", nil)
+	ctx.Set(currentUserContextKey, &models.UserProfile{ID: 1, Username: "Admin", Role: RoleAdmin})
+
+	(&SupplyHandler{stockRepo: &models.SupplyStockSnapshotRepository{}}).GetForecastSuggestions(ctx)
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+}
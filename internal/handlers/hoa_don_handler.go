@@ -10,26 +10,18 @@ import (
 
 // HoaDonHandler handles HTTP requests for hoa_don
 type HoaDonHandler struct {
-	repo      *models.HoaDonRepository
-	userRepo  *models.UserRepository
-	jwtSecret []byte
+	repo *models.HoaDonRepository
 }
 
 // NewHoaDonHandler creates a new handler instance
-func NewHoaDonHandler(repo *models.HoaDonRepository, userRepo *models.UserRepository, jwtSecret string) *HoaDonHandler {
+func NewHoaDonHandler(repo *models.HoaDonRepository) *HoaDonHandler {
 	return &HoaDonHandler{
-		repo:      repo,
-		userRepo:  userRepo,
-		jwtSecret: []byte(jwtSecret),
+		repo: repo,
 	}
 }
 
 func (h *HoaDonHandler) requireAuthenticatedUser(c *gin.Context) bool {
-	if _, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret); err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "UNAUTHORIZED",
-			Message: err.Error(),
-		})
+	if _, ok := requireCurrentUser(c); !ok {
 		return false
 	}
 
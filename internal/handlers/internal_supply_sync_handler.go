@@ -3,58 +3,112 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type internalSupplySyncRunner interface {
-	RunOnce(ctx context.Context) (int, error)
+	Sync(ctx context.Context, opts services.InternalSupplySyncOptions) (*services.InternalSupplySyncResult, error)
+	ListRuns(limit int) ([]models.InternalSupplySyncRun, error)
+	GetRun(runID int64) (*models.InternalSupplySyncRun, error)
 }
 
 type InternalSupplySyncHandler struct {
-	runner    internalSupplySyncRunner
-	userRepo  *models.UserRepository
-	jwtSecret []byte
+	runner internalSupplySyncRunner
+	jobs   *services.JobRunner
 }
 
-func NewInternalSupplySyncHandler(runner internalSupplySyncRunner, userRepo *models.UserRepository, jwtSecret string) *InternalSupplySyncHandler {
+func NewInternalSupplySyncHandler(runner internalSupplySyncRunner, jobs *services.JobRunner) *InternalSupplySyncHandler {
 	return &InternalSupplySyncHandler{
-		runner:    runner,
-		userRepo:  userRepo,
-		jwtSecret: []byte(jwtSecret),
+		runner: runner,
+		jobs:   jobs,
 	}
 }
 
+// SyncNow starts an internal supply sync as a background job and answers 202
+// with the job. With dryRun=true the job only reports what would change.
 func (h *InternalSupplySyncHandler) SyncNow(c *gin.Context) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return
 	}
 
-	if !canRunInternalSupplySyncRole(currentUser.Role) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Admin can run internal supply sync"})
-		return
+	dryRun := false
+	if raw := strings.TrimSpace(c.Query("dryRun")); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "dryRun must be true or false"})
+			return
+		}
+		dryRun = parsed
 	}
 
-	count, err := h.runner.RunOnce(c.Request.Context())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "INTERNAL_SUPPLY_SYNC_FAILED",
-			"message": err.Error(),
-		})
+	if !requireJobRunner(c, h.jobs) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Internal supply sync completed",
-		"count":   count,
+	opts := services.InternalSupplySyncOptions{
+		Trigger:           models.InternalSupplySyncTriggerManual,
+		DryRun:            dryRun,
+		RequestedByUserID: jobCreatorID(currentUser),
+	}
+	job, err := h.jobs.Start(models.BackgroundJobKindInternalSupplySync, opts.RequestedByUserID, func(ctx context.Context, _ services.JobProgressFunc) (interface{}, error) {
+		return h.runner.Sync(ctx, opts)
 	})
+	message := "Internal supply sync started"
+	if dryRun {
+		message = "Internal supply sync preview started"
+	}
+	respondJobStarted(c, job, err, message)
+}
+
+func (h *InternalSupplySyncHandler) ListSyncRuns(c *gin.Context) {
+	if _, ok := requireCurrentUser(c); !ok {
+		return
+	}
+
+	limit := 30
+	if rawLimit := strings.TrimSpace(c.Query("limit")); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "limit must be greater than 0"})
+			return
+		}
+		limit = parsed
+	}
+
+	runs, err := h.runner.ListRuns(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": runs, "count": len(runs)})
 }
 
-func canRunInternalSupplySyncRole(role string) bool {
-	return normalizeRoleForPermissions(role) == RoleAdmin
+func (h *InternalSupplySyncHandler) GetSyncRun(c *gin.Context) {
+	if _, ok := requireCurrentUser(c); !ok {
+		return
+	}
+
+	runID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || runID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return
+	}
+
+	run, err := h.runner.GetRun(runID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Internal supply sync run not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": run})
 }
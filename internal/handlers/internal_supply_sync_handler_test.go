@@ -1,31 +1,37 @@
 package handlers
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
 
-func TestCanRunInternalSupplySyncRole(t *testing.T) {
-	t.Parallel()
+	"github.com/gin-gonic/gin"
+)
+
+func TestInternalSupplySyncEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
 
 	testCases := []struct {
-		name string
-		role string
-		want bool
+		name    string
+		method  string
+		path    string
+		handler func(*InternalSupplySyncHandler, *gin.Context)
 	}{
-		{name: "admin allowed", role: RoleAdmin, want: true},
-		{name: "admin with spaces and casing allowed", role: " Admin ", want: true},
-		{name: "chi huy khoa denied", role: RoleChiHuyKhoa, want: false},
-		{name: "thu kho denied", role: RoleThuKho, want: false},
-		{name: "nhan vien thau denied", role: RoleNhanVienThau, want: false},
-		{name: "empty denied", role: "", want: false},
+		{name: "sync", method: http.MethodPost, path: "/api/supplies/internal-sync?dryRun=true", handler: (*InternalSupplySyncHandler).SyncNow},
+		{name: "runs", method: http.MethodGet, path: "/api/supplies/internal-sync/runs", handler: (*InternalSupplySyncHandler).ListSyncRuns},
+		{name: "run", method: http.MethodGet, path: "/api/supplies/internal-sync/runs/1", handler: (*InternalSupplySyncHandler).GetSyncRun},
 	}
 
 	for _, tc := range testCases {
-		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tc.method, tc.path, nil)
+
+			tc.handler(&InternalSupplySyncHandler{}, ctx)
 
-			got := canRunInternalSupplySyncRole(tc.role)
-			if got != tc.want {
-				t.Fatalf("canRunInternalSupplySyncRole(%q) = %v, want %v", tc.role, got, tc.want)
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("%s status = %d, want %d", tc.name, recorder.Code, http.StatusUnauthorized)
 			}
 		})
 	}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RejectInvoiceMatchRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RunInvoiceMatcher scores unmatched invoice rows against open order lines
+// and queues the resulting proposals for review.
+func (h *OrderHandler) RunInvoiceMatcher(c *gin.Context) {
+	if _, ok := h.authorizeInvoiceMatchReview(c); !ok {
+		return
+	}
+	if h.invoiceMatcher == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Invoice matcher is not configured"})
+		return
+	}
+
+	result, err := h.invoiceMatcher.Run()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	if h.hub != nil && result.Proposed > 0 {
+		h.hub.Broadcast("invoices.match_proposals_created", gin.H{
+			"count":     result.Proposed,
+			"updatedAt": time.Now().UTC().Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *OrderHandler) ListInvoiceMatches(c *gin.Context) {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+	if h.invoiceMatchRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Invoice reconciliation repository is not configured"})
+		return
+	}
+
+	status := strings.ToLower(strings.TrimSpace(c.DefaultQuery("status", models.InvoiceMatchStatusProposed)))
+	switch status {
+	case "all":
+		status = ""
+	case models.InvoiceMatchStatusProposed, models.InvoiceMatchStatusAccepted, models.InvoiceMatchStatusRejected:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "status must be proposed, accepted, rejected or all"})
+		return
+	}
+	limit, _ := strconv.Atoi(strings.TrimSpace(c.Query("limit")))
+
+	proposals, err := h.invoiceMatchRepo.ListInvoiceMatchProposals(models.InvoiceMatchFilter{Status: status, Limit: limit})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": proposals})
+}
+
+// AcceptInvoiceMatch writes the proposal as an order_invoice_reconciliation
+// row, the same record a manual match creates.
+func (h *OrderHandler) AcceptInvoiceMatch(c *gin.Context) {
+	currentUser, ok := h.authorizeInvoiceMatchReview(c)
+	if !ok {
+		return
+	}
+	proposalID, ok := parseInvoiceMatchID(c)
+	if !ok {
+		return
+	}
+
+	proposal, err := h.invoiceMatchRepo.AcceptInvoiceMatchProposal(proposalID, vinmesOverrideActor(currentUser), time.Now())
+	if err != nil {
+		writeInvoiceMatchError(c, err)
+		return
+	}
+	h.syncOrderLifecycleAfterReconciliation()
+
+	if h.hub != nil {
+		h.hub.Broadcast("invoices.reconciliation_updated", gin.H{
+			"count":     1,
+			"matchId":   proposal.ID,
+			"updatedBy": currentUser.Username,
+			"updatedAt": time.Now().UTC().Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": proposal})
+}
+
+func (h *OrderHandler) RejectInvoiceMatch(c *gin.Context) {
+	currentUser, ok := h.authorizeInvoiceMatchReview(c)
+	if !ok {
+		return
+	}
+	proposalID, ok := parseInvoiceMatchID(c)
+	if !ok {
+		return
+	}
+
+	var req RejectInvoiceMatchRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid reject payload"})
+			return
+		}
+	}
+	if len(strings.TrimSpace(req.Reason)) > 1000 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "reason must be at most 1000 characters"})
+		return
+	}
+
+	proposal, err := h.invoiceMatchRepo.RejectInvoiceMatchProposal(proposalID, vinmesOverrideActor(currentUser), req.Reason, time.Now())
+	if err != nil {
+		writeInvoiceMatchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": proposal})
+}
+
+func (h *OrderHandler) authorizeInvoiceMatchReview(c *gin.Context) (*models.UserProfile, bool) {
+	currentUser, err := h.getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return nil, false
+	}
+	if h.invoiceMatchRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Invoice reconciliation repository is not configured"})
+		return nil, false
+	}
+	return currentUser, true
+}
+
+func writeInvoiceMatchError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvoiceMatchNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: err.Error()})
+	case errors.Is(err, models.ErrInvoiceMatchNotProposed):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "ALREADY_REVIEWED", Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+	}
+}
+
+func parseInvoiceMatchID(c *gin.Context) (int64, bool) {
+	proposalID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || proposalID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return 0, false
+	}
+	return proposalID, true
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInvoiceMatchEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(*OrderHandler, *gin.Context)
+	}{
+		{name: "run", method: http.MethodPost, path: "/api/orders/invoice-matches/run", handler: (*OrderHandler).RunInvoiceMatcher},
+		{name: "list", method: http.MethodGet, path: "/api/orders/invoice-matches", handler: (*OrderHandler).ListInvoiceMatches},
+		{name: "accept", method: http.MethodPost, path: "/api/orders/invoice-matches/1/accept", handler: (*OrderHandler).AcceptInvoiceMatch},
+		{name: "reject", method: http.MethodPost, path: "/api/orders/invoice-matches/1/reject", handler: (*OrderHandler).RejectInvoiceMatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tc.method, tc.path, nil)
+
+			tc.handler(&OrderHandler{}, ctx)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("%s status = %d, want %d", tc.name, recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
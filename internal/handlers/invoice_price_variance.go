@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetInvoicePriceVarianceReport lists checked invoice lines grouped by
+// supplier and invoice month. fromMonth and toMonth are inclusive YYYY-MM
+// bounds; flaggedOnly defaults to true.
+func (h *OrderHandler) GetInvoicePriceVarianceReport(c *gin.Context) {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+	if h.invoiceMatchRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Invoice reconciliation repository is not configured"})
+		return
+	}
+
+	filter, ok := parseInvoicePriceVarianceFilter(c)
+	if !ok {
+		return
+	}
+
+	groups, err := h.invoiceMatchRepo.ListInvoicePriceVariances(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+// RecheckInvoicePriceVariances re-runs the price check on every reconciled
+// invoice line, picking up changed contract prices or tolerance.
+func (h *OrderHandler) RecheckInvoicePriceVariances(c *gin.Context) {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+	if h.invoiceMatchRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Invoice reconciliation repository is not configured"})
+		return
+	}
+
+	checked, err := h.invoiceMatchRepo.RecheckInvoicePriceVariances(time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invoice prices rechecked", "count": checked})
+}
+
+func parseInvoicePriceVarianceFilter(c *gin.Context) (models.InvoicePriceVarianceFilter, bool) {
+	filter := models.InvoicePriceVarianceFilter{FlaggedOnly: true}
+
+	if raw := strings.TrimSpace(c.Query("fromMonth")); raw != "" {
+		from, err := time.ParseInLocation("2006-01", raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "fromMonth must use the YYYY-MM format"})
+			return filter, false
+		}
+		filter.From = &from
+	}
+	if raw := strings.TrimSpace(c.Query("toMonth")); raw != "" {
+		to, err := time.ParseInLocation("2006-01", raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "toMonth must use the YYYY-MM format"})
+			return filter, false
+		}
+		to = to.AddDate(0, 1, 0)
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "fromMonth must not be after toMonth"})
+		return filter, false
+	}
+	if raw := strings.TrimSpace(c.Query("flaggedOnly")); raw != "" {
+		flaggedOnly, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "flaggedOnly must be true or false"})
+			return filter, false
+		}
+		filter.FlaggedOnly = flaggedOnly
+	}
+	return filter, true
+}
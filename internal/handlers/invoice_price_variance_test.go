@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInvoicePriceVarianceEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(*OrderHandler, *gin.Context)
+	}{
+		{name: "report", method: http.MethodGet, path: "/api/orders/invoice-reconciliations/price-variances", handler: (*OrderHandler).GetInvoicePriceVarianceReport},
+		{name: "recheck", method: http.MethodPost, path: "/api/orders/invoice-reconciliations/price-variances/recheck", handler: (*OrderHandler).RecheckInvoicePriceVariances},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tc.method, tc.path, nil)
+
+			tc.handler(&OrderHandler{}, ctx)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("%s status = %d, want %d", tc.name, recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestParseInvoicePriceVarianceFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?fromMonth=2026-05&toMonth=2026-06&flaggedOnly=false", nil)
+
+	filter, ok := parseInvoicePriceVarianceFilter(ctx)
+	if !ok {
+		t.Fatalf("parseInvoicePriceVarianceFilter() rejected a valid query: %s", recorder.Body.String())
+	}
+	if filter.FlaggedOnly || filter.From.Format("2006-01-02") != "2026-05-01" || filter.To.Format("2006-01-02") != "2026-07-01" {
+		t.Fatalf("filter = %+v", filter)
+	}
+
+	recorder = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?fromMonth=2026-07&toMonth=2026-06", nil)
+	if _, ok := parseInvoicePriceVarianceFilter(ctx); ok || recorder.Code != http.StatusBadRequest {
+		t.Fatalf("reversed range accepted, status = %d", recorder.Code)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetInvoiceReconciliationAging returns reconciliation exceptions grouped per
+// supplier and bucketed by age. minAgeDays defaults to the delivery due days
+// and lookbackDays limits how far back unmatched invoices are listed.
+func (h *OrderHandler) GetInvoiceReconciliationAging(c *gin.Context) {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+	if h.invoiceMatchRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Invoice reconciliation repository is not configured"})
+		return
+	}
+
+	filter, ok := parseInvoiceAgingFilter(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.invoiceMatchRepo.GetInvoiceAgingReport(filter, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+func parseInvoiceAgingFilter(c *gin.Context) (models.InvoiceAgingFilter, bool) {
+	filter := models.InvoiceAgingFilter{MinAgeDays: -1}
+
+	if raw := strings.TrimSpace(c.Query("minAgeDays")); raw != "" {
+		minAgeDays, err := strconv.Atoi(raw)
+		if err != nil || minAgeDays < 0 || minAgeDays > 3650 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "minAgeDays must be from 0 to 3650"})
+			return filter, false
+		}
+		filter.MinAgeDays = minAgeDays
+	}
+	if raw := strings.TrimSpace(c.Query("lookbackDays")); raw != "" {
+		lookbackDays, err := strconv.Atoi(raw)
+		if err != nil || lookbackDays < 1 || lookbackDays > 3650 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "lookbackDays must be from 1 to 3650"})
+			return filter, false
+		}
+		filter.LookbackDays = lookbackDays
+	}
+	return filter, true
+}
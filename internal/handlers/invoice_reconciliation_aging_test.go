@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInvoiceReconciliationAgingRequiresAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/orders/invoice-reconciliations/aging", nil)
+
+	(&OrderHandler{}).GetInvoiceReconciliationAging(ctx)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestParseInvoiceAgingFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name       string
+		query      string
+		ok         bool
+		minAgeDays int
+	}{
+		{name: "defaults", query: "/", ok: true, minAgeDays: -1},
+		{name: "explicit", query: "/?minAgeDays=0&lookbackDays=30", ok: true, minAgeDays: 0},
+		{name: "negative", query: "/?minAgeDays=-3", ok: false},
+		{name: "invalid lookback", query: "/?lookbackDays=abc", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, tc.query, nil)
+
+			filter, ok := parseInvoiceAgingFilter(ctx)
+			if ok != tc.ok {
+				t.Fatalf("ok = %v, want %v (status %d)", ok, tc.ok, recorder.Code)
+			}
+			if ok && filter.MinAgeDays != tc.minAgeDays {
+				t.Fatalf("minAgeDays = %d, want %d", filter.MinAgeDays, tc.minAgeDays)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobHandler struct {
+	jobs *services.JobRunner
+}
+
+func NewJobHandler(jobs *services.JobRunner) *JobHandler {
+	return &JobHandler{
+		jobs: jobs,
+	}
+}
+
+// GetJob returns the state of a background job to the user who started it,
+// Admin or Chi huy khoa. Progress also arrives as jobs.progress and
+// jobs.finished events on the creator's websocket.
+func (h *JobHandler) GetJob(c *gin.Context) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	if !requireJobRunner(c, h.jobs) {
+		return
+	}
+	jobID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobs.Get(jobID)
+	if err != nil {
+		writeJobError(c, err)
+		return
+	}
+	if !canReadJob(currentUser, job) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Admin, Chi huy khoa or the user who started the job can view it"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob stops a running job. Only Admin or the user who started it may
+// cancel.
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	if !requireJobRunner(c, h.jobs) {
+		return
+	}
+	jobID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobs.Get(jobID)
+	if err != nil {
+		writeJobError(c, err)
+		return
+	}
+	isOwner := job.CreatedByUserID != nil && *job.CreatedByUserID == currentUser.ID
+	if !isOwner && !userHasAnyRole(currentUser, RoleAdmin) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Admin or the user who started the job can cancel it"})
+		return
+	}
+
+	job, err = h.jobs.Cancel(jobID)
+	if err != nil {
+		writeJobError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Cancellation requested", "job": job})
+}
+
+// canReadJob reports whether user may see job: its creator or a manager.
+func canReadJob(user *models.UserProfile, job models.BackgroundJob) bool {
+	if job.CreatedByUserID != nil && *job.CreatedByUserID == user.ID {
+		return true
+	}
+	return userHasAnyRole(user, RoleAdmin, RoleChiHuyKhoa)
+}
+
+// requireJobRunner answers 503 when the handler was built without a job
+// runner.
+func requireJobRunner(c *gin.Context, jobs *services.JobRunner) bool {
+	if jobs == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Background jobs are not configured"})
+		return false
+	}
+	return true
+}
+
+// respondJobStarted answers a request that handed its work to the job
+// runner: 202 with the new job, or 409 with the job already running.
+func respondJobStarted(c *gin.Context, job models.BackgroundJob, err error, message string) {
+	if errors.Is(err, services.ErrJobAlreadyRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": "JOB_RUNNING", "message": err.Error(), "job": job})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"success": true, "message": message, "job": job})
+}
+
+func writeJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: err.Error()})
+	case errors.Is(err, services.ErrJobNotRunning):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "JOB_NOT_RUNNING", Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+	}
+}
+
+func parseJobID(c *gin.Context) (int64, bool) {
+	jobID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || jobID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return 0, false
+	}
+	return jobID, true
+}
+
+func jobCreatorID(user *models.UserProfile) *int64 {
+	if user == nil {
+		return nil
+	}
+	id := user.ID
+	return &id
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJobEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(*JobHandler, *gin.Context)
+	}{
+		{name: "get", method: http.MethodGet, path: "/api/jobs/1", handler: (*JobHandler).GetJob},
+		{name: "cancel", method: http.MethodPost, path: "/api/jobs/1/cancel", handler: (*JobHandler).CancelJob},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tc.method, tc.path, nil)
+
+			tc.handler(&JobHandler{}, ctx)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("%s status = %d, want %d", tc.name, recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestRespondJobStarted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "started", err: nil, wantStatus: http.StatusAccepted},
+		{name: "already running", err: services.ErrJobAlreadyRunning, wantStatus: http.StatusConflict},
+		{name: "store failure", err: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+
+			respondJobStarted(ctx, models.BackgroundJob{ID: 3, Kind: models.BackgroundJobKindInvoiceRefresh}, tc.err, "started")
+
+			if recorder.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCanReadJob(t *testing.T) {
+	creatorID := int64(7)
+	job := models.BackgroundJob{ID: 1, CreatedByUserID: &creatorID}
+
+	testCases := []struct {
+		name string
+		user *models.UserProfile
+		job  models.BackgroundJob
+		want bool
+	}{
+		{name: "creator", user: &models.UserProfile{ID: 7, Role: RoleNhanVienKho}, job: job, want: true},
+		{name: "admin", user: &models.UserProfile{ID: 1, Role: RoleAdmin}, job: job, want: true},
+		{name: "chi huy khoa", user: &models.UserProfile{ID: 2, Role: RoleChiHuyKhoa}, job: job, want: true},
+		{name: "other staff", user: &models.UserProfile{ID: 8, Role: RoleNhanVienKho}, job: job},
+		{name: "job without creator", user: &models.UserProfile{ID: 8, Role: RoleThuKho}, job: models.BackgroundJob{ID: 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := canReadJob(tc.user, tc.job); got != tc.want {
+				t.Fatalf("canReadJob() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkLoginThrottle answers the request and returns false when the email is
+// locked out or the email or client IP still has to wait after earlier
+// failures. Without a login attempt repository logins are not throttled.
+func (h *AuthHandler) checkLoginThrottle(c *gin.Context, email string, clientIP string, now time.Time) bool {
+	if h.loginRepo == nil {
+		return true
+	}
+
+	emailThrottle, err := h.loginRepo.GetThrottle(models.LoginThrottleScopeEmail, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return false
+	}
+	ipThrottle, err := h.loginRepo.GetThrottle(models.LoginThrottleScopeIP, clientIP)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return false
+	}
+
+	if emailThrottle.Locked(now) || ipThrottle.Locked(now) {
+		h.recordLoginAttempt(c, email, nil, models.LoginOutcomeLocked, now)
+		setRetryAfter(c, maxDuration(emailThrottle.RetryAfter(now), ipThrottle.RetryAfter(now)))
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: "ACCOUNT_LOCKED", Message: "Too many failed logins. The account is temporarily locked"})
+		return false
+	}
+
+	if wait := maxDuration(emailThrottle.RetryAfter(now), ipThrottle.RetryAfter(now)); wait > 0 {
+		h.recordLoginAttempt(c, email, nil, models.LoginOutcomeThrottled, now)
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: "LOGIN_THROTTLED", Message: "Too many failed logins. Please wait before trying again"})
+		return false
+	}
+	return true
+}
+
+// registerLoginFailure counts a failed login against the email and the client
+// IP and records it. Storage errors are logged; the caller still answers 401.
+func (h *AuthHandler) registerLoginFailure(c *gin.Context, email string, clientIP string, userID *int64, now time.Time) {
+	if h.loginRepo == nil {
+		return
+	}
+
+	h.countLoginFailure(models.LoginThrottleScopeEmail, email, h.loginPolicy.EmailMaxFailures, now)
+	h.countLoginFailure(models.LoginThrottleScopeIP, clientIP, h.loginPolicy.IPMaxFailures, now)
+	h.recordLoginAttempt(c, email, userID, models.LoginOutcomeInvalidCredentials, now)
+}
+
+func (h *AuthHandler) countLoginFailure(scope string, subject string, maxFailures int, now time.Time) {
+	counted, err := h.loginRepo.CountFailure(scope, subject, h.loginPolicy.Window, now)
+	if err != nil {
+		log.Printf("[auth] counting %s login failure failed: %v", scope, err)
+		return
+	}
+	penalized := models.ApplyLoginFailurePenalty(*counted, maxFailures, h.loginPolicy, now)
+	if penalized.NextAttemptAt == nil && penalized.LockedUntil == nil {
+		return
+	}
+	if err := h.loginRepo.SavePenalty(penalized); err != nil {
+		log.Printf("[auth] saving %s login throttle failed: %v", scope, err)
+	}
+}
+
+// registerLoginSuccess forgets the failures of the email. The IP counter is
+// left alone so one valid account cannot be used to keep guessing others.
+func (h *AuthHandler) registerLoginSuccess(c *gin.Context, email string, userID int64, now time.Time) {
+	if h.loginRepo == nil {
+		return
+	}
+
+	if err := h.loginRepo.ClearThrottle(models.LoginThrottleScopeEmail, email); err != nil {
+		log.Printf("[auth] clearing login throttle failed: %v", err)
+	}
+	h.recordLoginAttempt(c, email, &userID, models.LoginOutcomeSuccess, now)
+}
+
+func (h *AuthHandler) recordLoginAttempt(c *gin.Context, email string, userID *int64, outcome string, now time.Time) {
+	if h.loginRepo == nil {
+		return
+	}
+
+	if err := h.loginRepo.RecordAttempt(models.LoginAttempt{
+		Email:     email,
+		UserID:    userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Outcome:   outcome,
+		CreatedAt: now,
+	}); err != nil {
+		log.Printf("[auth] recording login attempt failed: %v", err)
+	}
+}
+
+// UnlockManagedUser lifts a lockout or pending delay on a user's email before
+// it runs out by itself.
+func (h *AuthHandler) UnlockManagedUser(c *gin.Context) {
+	if _, ok := requireCurrentUser(c); !ok {
+		return
+	}
+
+	if h.loginRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Login throttling is not configured"})
+		return
+	}
+
+	userID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_USER_ID", Message: "User ID is invalid"})
+		return
+	}
+
+	targetUser, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "User not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(targetUser.Email))
+	if err := h.loginRepo.ClearThrottle(models.LoginThrottleScopeEmail, email); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unlocked successfully",
+		"user":    targetUser.ToProfile(),
+	})
+}
+
+// ListLoginAttempts returns the newest recorded logins, optionally for one
+// email only.
+func (h *AuthHandler) ListLoginAttempts(c *gin.Context) {
+	if _, ok := requireCurrentUser(c); !ok {
+		return
+	}
+
+	if h.loginRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Login throttling is not configured"})
+		return
+	}
+
+	filter := models.LoginAttemptFilter{Email: c.Query("email")}
+	if rawLimit := strings.TrimSpace(c.Query("limit")); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_LIMIT", Message: "limit must be a positive number"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	attempts, err := h.loginRepo.ListAttempts(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attempts": attempts})
+}
+
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		wantErr  string
+	}{
+		{name: "default minimum length", policy: PasswordPolicy{}, password: "short1", wantErr: "at least 8 characters"},
+		{name: "long enough", policy: PasswordPolicy{}, password: "longenough"},
+		{name: "length counts characters not bytes", policy: PasswordPolicy{MinLength: 4}, password: "mật1"},
+		{name: "missing digit", policy: PasswordPolicy{RequireDigit: true}, password: "matkhaumoi", wantErr: "a digit"},
+		{name: "missing mixed case and symbol", policy: PasswordPolicy{RequireMixedCase: true, RequireSymbol: true}, password: "matkhau123", wantErr: "upper and lower case letters, a symbol"},
+		{name: "all rules met", policy: PasswordPolicy{MinLength: 10, RequireMixedCase: true, RequireDigit: true, RequireSymbol: true}, password: "MatKhau@123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(*AuthHandler, *gin.Context)
+	}{
+		{name: "unlock", method: http.MethodPost, path: "/api/auth/users/3/unlock", handler: (*AuthHandler).UnlockManagedUser},
+		{name: "login attempts", method: http.MethodGet, path: "/api/auth/login-attempts", handler: (*AuthHandler).ListLoginAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tt.method, tt.path, nil)
+
+			tt.handler(&AuthHandler{}, ctx)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
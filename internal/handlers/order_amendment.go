@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CancelOrderHistoryRequest struct {
+	Reason string `json:"reason"`
+}
+
+type AmendOrderHistoryRequest struct {
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+func (h *OrderHandler) CancelOrderHistoryLine(c *gin.Context) {
+	currentUser, orderID, ok := h.authorizeOrderAmendment(c)
+	if !ok {
+		return
+	}
+
+	var req CancelOrderHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid cancel order payload"})
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "reason is required when cancelling an order"})
+		return
+	}
+
+	h.applyOrderHistoryAmendment(c, currentUser, orderID, models.OrderAmendmentActionCancel, 0, reason)
+}
+
+func (h *OrderHandler) AmendOrderHistoryLine(c *gin.Context) {
+	currentUser, orderID, ok := h.authorizeOrderAmendment(c)
+	if !ok {
+		return
+	}
+
+	var req AmendOrderHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid amend order payload"})
+		return
+	}
+
+	if req.Quantity <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "quantity must be greater than zero"})
+		return
+	}
+
+	h.applyOrderHistoryAmendment(c, currentUser, orderID, models.OrderAmendmentActionAmend, req.Quantity, strings.TrimSpace(req.Reason))
+}
+
+func (h *OrderHandler) ListOrderHistoryAmendments(c *gin.Context) {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+
+	orderID, ok := parseOrderHistoryIDParam(c)
+	if !ok {
+		return
+	}
+
+	amendments, err := h.repo.ListOrderHistoryAmendments(orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": amendments})
+}
+
+func (h *OrderHandler) authorizeOrderAmendment(c *gin.Context) (*models.UserProfile, int64, bool) {
+	currentUser, err := h.getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return nil, 0, false
+	}
+
+	orderID, ok := parseOrderHistoryIDParam(c)
+	if !ok {
+		return nil, 0, false
+	}
+	return currentUser, orderID, true
+}
+
+// applyOrderHistoryAmendment changes the line first and queues the supplier
+// email in the same transaction, so a change that loses a race is never
+// announced to the supplier.
+func (h *OrderHandler) applyOrderHistoryAmendment(c *gin.Context, currentUser *models.UserProfile, orderID int64, action string, newQty int, reason string) {
+	historyOrders, err := h.repo.GetOrderHistoryByIDs([]int64{orderID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if len(historyOrders) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Order history record not found"})
+		return
+	}
+	line := historyOrders[0]
+
+	if err := models.ValidateOrderAmendment(line, action, newQty); err != nil {
+		writeOrderAmendmentError(c, err)
+		return
+	}
+
+	supplierName := strings.TrimSpace(line.NhaThau)
+	email := h.resolveOrderRecipientEmail(line.PendingOrder)
+	if email == "" {
+		message := "missing company email"
+		if supplierName != "" {
+			message = "missing company email for " + supplierName
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "MISSING_EMAIL", Message: message})
+		return
+	}
+
+	amendment, err := h.repo.AmendOrderHistoryLine(models.OrderAmendmentInput{
+		OrderHistoryID: orderID,
+		Action:         action,
+		ExpectedQty:    line.DotGoiHang,
+		NewQty:         newQty,
+		Reason:         reason,
+		RecipientEmail: email,
+		SupplierName:   supplierName,
+		Actor: models.OrderActor{
+			ID:       currentUser.ID,
+			Username: currentUser.Username,
+			Email:    currentUser.Email,
+		},
+	})
+	if err != nil {
+		writeOrderAmendmentError(c, err)
+		return
+	}
+
+	h.emailOutbox.Notify()
+
+	status := "amended"
+	message := "Order amended and supplier email queued"
+	if action == models.OrderAmendmentActionCancel {
+		status = "cancelled"
+		message = "Order cancelled and supplier email queued"
+	}
+
+	if h.hub != nil {
+		now := time.Now().UTC()
+		h.hub.Broadcast("orders.updated", gin.H{
+			"action":    status,
+			"orderId":   orderID,
+			"count":     1,
+			"updatedBy": currentUser.Username,
+			"updatedAt": now.Format(time.RFC3339Nano),
+		})
+
+		broadcastActivityNotification(h.hub, ActivityNotificationPayload{
+			Category:   "orders",
+			Action:     "orders." + status,
+			ActorID:    currentUser.ID,
+			ActorName:  currentUser.Username,
+			ActorEmail: currentUser.Email,
+			Count:      1,
+			CreatedAt:  now.Format(time.RFC3339Nano),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": amendment})
+}
+
+func writeOrderAmendmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrOrderHistoryNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Order history record not found"})
+	case errors.Is(err, models.ErrOrderLineNotAmendable), errors.Is(err, models.ErrOrderLineChanged):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "INVALID_STATUS", Message: err.Error()})
+	case errors.Is(err, models.ErrInvalidOrderAmendment):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+	}
+}
+
+func parseOrderHistoryIDParam(c *gin.Context) (int64, bool) {
+	orderID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || orderID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return 0, false
+	}
+	return orderID, true
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOrderAmendmentEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(*OrderHandler, *gin.Context)
+	}{
+		{name: "amendments", method: http.MethodGet, path: "/api/orders/history/1/amendments", handler: (*OrderHandler).ListOrderHistoryAmendments},
+		{name: "cancel", method: http.MethodPost, path: "/api/orders/history/1/cancel", handler: (*OrderHandler).CancelOrderHistoryLine},
+		{name: "amend", method: http.MethodPost, path: "/api/orders/history/1/amend", handler: (*OrderHandler).AmendOrderHistoryLine},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tc.method, tc.path, nil)
+
+			tc.handler(&OrderHandler{}, ctx)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("%s status = %d, want %d", tc.name, recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
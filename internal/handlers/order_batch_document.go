@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOrderBatchPDF regenerates the placed-order PDF of a past batch from the
+// template and prices stored when it was placed. The :id route segment
+// carries the OrderBatchKey from the order history listing.
+func (h *OrderHandler) GetOrderBatchPDF(c *gin.Context) {
+	supplierName, documentSnapshot, lines, ok := h.loadOrderBatchDocument(c)
+	if !ok {
+		return
+	}
+
+	pdfBytes, err := h.emailTemplates.RenderPlacedOrderDocument(supplierName, documentSnapshot, lines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "EXPORT_ERROR", Message: err.Error()})
+		return
+	}
+
+	writeOrderBatchDocument(c, "application/pdf", orderBatchDocumentFileName(lines, "pdf"), pdfBytes)
+}
+
+func (h *OrderHandler) GetOrderBatchWorkbook(c *gin.Context) {
+	supplierName, documentSnapshot, lines, ok := h.loadOrderBatchDocument(c)
+	if !ok {
+		return
+	}
+
+	workbook, err := h.emailTemplates.RenderPlacedOrderWorkbook(supplierName, documentSnapshot, lines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "EXPORT_ERROR", Message: err.Error()})
+		return
+	}
+
+	writeOrderBatchDocument(c, services.PlacedOrderWorkbookContentType, orderBatchDocumentFileName(lines, "xlsx"), workbook)
+}
+
+// loadOrderBatchDocument returns the batch's supplier, the document snapshot
+// stored on its placed-order email and its lines.
+func (h *OrderHandler) loadOrderBatchDocument(c *gin.Context) (string, string, []models.OrderEmailLine, bool) {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return "", "", nil, false
+	}
+
+	batchKey := strings.TrimSpace(c.Param("id"))
+	if batchKey == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "batch key is required"})
+		return "", "", nil, false
+	}
+
+	lines, err := h.repo.GetOrderBatchLines(batchKey)
+	if err != nil {
+		if errors.Is(err, models.ErrOrderBatchNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Order batch not found"})
+			return "", "", nil, false
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return "", "", nil, false
+	}
+
+	documentSnapshot := ""
+	if outboxID := lines[0].EmailOutboxID; outboxID != nil {
+		entry, err := h.repo.GetOrderEmail(*outboxID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+			return "", "", nil, false
+		}
+		if entry != nil {
+			documentSnapshot = entry.DocumentSnapshot
+		}
+	}
+
+	return strings.TrimSpace(lines[0].NhaThau), documentSnapshot, lines, true
+}
+
+func writeOrderBatchDocument(c *gin.Context, contentType, fileName string, content []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// orderBatchDocumentFileName builds an ASCII file name from the batch's order
+// time, e.g. don-dat-hang-20260701080000.pdf.
+func orderBatchDocumentFileName(lines []models.OrderEmailLine, extension string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, lines[0].NgayDatHang)
+	if digits == "" {
+		return "don-dat-hang." + extension
+	}
+	return fmt.Sprintf("don-dat-hang-%s.%s", digits, extension)
+}
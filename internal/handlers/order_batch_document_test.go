@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOrderBatchDocumentEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name    string
+		path    string
+		handler func(*OrderHandler, *gin.Context)
+	}{
+		{name: "pdf", path: "/api/orders/history/cc-1__2026-07-01__batch-1/pdf", handler: (*OrderHandler).GetOrderBatchPDF},
+		{name: "xlsx", path: "/api/orders/history/cc-1__2026-07-01__batch-1/xlsx", handler: (*OrderHandler).GetOrderBatchWorkbook},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, tc.path, nil)
+
+			tc.handler(&OrderHandler{}, ctx)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("%s status = %d, want %d", tc.name, recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestOrderBatchDocumentFileName(t *testing.T) {
+	lines := []models.OrderEmailLine{{OrderHistoryRecord: models.OrderHistoryRecord{NgayDatHang: "2026-07-01 08:05:00"}}}
+	if got := orderBatchDocumentFileName(lines, "pdf"); got != "don-dat-hang-20260701080500.pdf" {
+		t.Fatalf("file name = %q", got)
+	}
+
+	lines[0].NgayDatHang = ""
+	if got := orderBatchDocumentFileName(lines, "xlsx"); got != "don-dat-hang.xlsx" {
+		t.Fatalf("file name = %q", got)
+	}
+}
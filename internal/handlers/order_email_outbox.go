@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *OrderHandler) ListOrderEmails(c *gin.Context) {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+	switch status {
+	case "", models.OrderEmailStatusPending, models.OrderEmailStatusSending, models.OrderEmailStatusSent, models.OrderEmailStatusFailed:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "status must be pending, sending, sent or failed"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(strings.TrimSpace(c.Query("limit")))
+	entries, err := h.repo.ListOrderEmails(models.OrderEmailOutboxFilter{Status: status, Limit: limit})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// ResendOrderEmail requeues one supplier email of an order batch; the
+// worker sends it again with a fresh retry budget. A batch placed with
+// several suppliers has one outbox row per supplier, resent separately.
+func (h *OrderHandler) ResendOrderEmail(c *gin.Context) {
+	currentUser, err := h.getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+
+	if h.emailOutbox == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Order email outbox is not configured"})
+		return
+	}
+
+	outboxID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || outboxID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return
+	}
+
+	if err := h.emailOutbox.Resend(outboxID); err != nil {
+		switch {
+		case errors.Is(err, models.ErrOrderEmailNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Order email not found"})
+		case errors.Is(err, models.ErrOrderEmailInFlight):
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "INVALID_STATUS", Message: err.Error()})
+		case errors.Is(err, models.ErrOrderEmailChanged):
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "ORDER_CHANGED", Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		}
+		return
+	}
+
+	if h.hub != nil {
+		now := time.Now().UTC()
+		h.hub.Broadcast("orders.updated", gin.H{
+			"action":        "email_requeued",
+			"emailOutboxId": outboxID,
+			"updatedBy":     currentUser.Username,
+			"updatedAt":     now.Format(time.RFC3339Nano),
+		})
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Order email queued for resend", "id": outboxID})
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+type OrderEmailTemplateRequest struct {
+	SupplierName   string   `json:"supplierName"`
+	Subject        string   `json:"subject"`
+	Body           string   `json:"body"`
+	PDFTitle       string   `json:"pdfTitle"`
+	PDFIntro       string   `json:"pdfIntro"`
+	PDFRequest     string   `json:"pdfRequest"`
+	SignatoryName  string   `json:"signatoryName"`
+	SignatoryTitle string   `json:"signatoryTitle"`
+	SignatoryDept  string   `json:"signatoryDept"`
+	SignatoryPhone string   `json:"signatoryPhone"`
+	ExtraColumns   []string `json:"extraColumns"`
+}
+
+type OrderEmailTemplatePreviewRequest struct {
+	SupplierName  string                     `json:"supplierName"`
+	EmailOutboxID int64                      `json:"emailOutboxId"`
+	Template      *OrderEmailTemplateRequest `json:"template"`
+}
+
+func (h *OrderHandler) ListOrderEmailTemplates(c *gin.Context) {
+	if _, ok := h.authorizeOrderEmailTemplateChange(c); !ok {
+		return
+	}
+
+	templates, err := h.emailTemplates.ListTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+func (h *OrderHandler) CreateOrderEmailTemplate(c *gin.Context) {
+	currentUser, ok := h.authorizeOrderEmailTemplateChange(c)
+	if !ok {
+		return
+	}
+
+	var req OrderEmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid order email template payload"})
+		return
+	}
+
+	template, err := h.emailTemplates.CreateTemplate(req.toModel(), vinmesOverrideActor(currentUser))
+	if err != nil {
+		writeOrderEmailTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": template})
+}
+
+func (h *OrderHandler) UpdateOrderEmailTemplate(c *gin.Context) {
+	currentUser, ok := h.authorizeOrderEmailTemplateChange(c)
+	if !ok {
+		return
+	}
+
+	templateID, ok := parseOrderEmailTemplateID(c)
+	if !ok {
+		return
+	}
+
+	var req OrderEmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid order email template payload"})
+		return
+	}
+
+	template := req.toModel()
+	template.ID = templateID
+	template, err := h.emailTemplates.UpdateTemplate(template, vinmesOverrideActor(currentUser))
+	if err != nil {
+		writeOrderEmailTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+func (h *OrderHandler) DeleteOrderEmailTemplate(c *gin.Context) {
+	if _, ok := h.authorizeOrderEmailTemplateChange(c); !ok {
+		return
+	}
+
+	templateID, ok := parseOrderEmailTemplateID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.emailTemplates.DeleteTemplate(templateID); err != nil {
+		writeOrderEmailTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order email template deleted"})
+}
+
+// PreviewOrderEmailTemplate renders the placed-order PDF without sending it.
+// It uses the lines of a queued email when emailOutboxId is given and lays an
+// unsaved template draft over the stored ones when template is given.
+func (h *OrderHandler) PreviewOrderEmailTemplate(c *gin.Context) {
+	if _, ok := h.authorizeOrderEmailTemplateChange(c); !ok {
+		return
+	}
+
+	var req OrderEmailTemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid order email preview payload"})
+		return
+	}
+
+	supplierName := strings.TrimSpace(req.SupplierName)
+	var lines []models.OrderEmailLine
+	if req.EmailOutboxID > 0 {
+		entry, err := h.repo.GetOrderEmail(req.EmailOutboxID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+			return
+		}
+		if entry == nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Order email not found"})
+			return
+		}
+		if supplierName == "" {
+			supplierName = entry.SupplierName
+		}
+
+		lines, err = h.repo.ListOrderEmailLines(entry.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+			return
+		}
+	}
+
+	var draft *models.OrderEmailTemplate
+	if req.Template != nil {
+		draft = req.Template.toModel()
+	}
+
+	pdfBytes, err := h.emailTemplates.RenderPlacedOrderPreview(supplierName, draft, lines)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidOrderEmailTemplate) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "PREVIEW_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="don-dat-hang-vat-tu-bv108.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+func (h *OrderHandler) authorizeOrderEmailTemplateChange(c *gin.Context) (*models.UserProfile, bool) {
+	currentUser, err := h.getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return nil, false
+	}
+	if h.emailTemplates == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Order email template service is not configured"})
+		return nil, false
+	}
+	return currentUser, true
+}
+
+func (req OrderEmailTemplateRequest) toModel() *models.OrderEmailTemplate {
+	return &models.OrderEmailTemplate{
+		SupplierName:   req.SupplierName,
+		Subject:        req.Subject,
+		Body:           req.Body,
+		PDFTitle:       req.PDFTitle,
+		PDFIntro:       req.PDFIntro,
+		PDFRequest:     req.PDFRequest,
+		SignatoryName:  req.SignatoryName,
+		SignatoryTitle: req.SignatoryTitle,
+		SignatoryDept:  req.SignatoryDept,
+		SignatoryPhone: req.SignatoryPhone,
+		ExtraColumns:   req.ExtraColumns,
+	}
+}
+
+func writeOrderEmailTemplateError(c *gin.Context, err error) {
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.Is(err, services.ErrInvalidOrderEmailTemplate):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: err.Error()})
+	case errors.Is(err, services.ErrOrderEmailTemplateNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Order email template not found"})
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == 1062:
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "DUPLICATE_TEMPLATE", Message: "An order email template already exists for this supplier"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+	}
+}
+
+func parseOrderEmailTemplateID(c *gin.Context) (int64, bool) {
+	templateID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || templateID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return 0, false
+	}
+	return templateID, true
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOrderEmailTemplateEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(*OrderHandler, *gin.Context)
+	}{
+		{name: "list", method: http.MethodGet, path: "/api/orders/email-templates", handler: (*OrderHandler).ListOrderEmailTemplates},
+		{name: "create", method: http.MethodPost, path: "/api/orders/email-templates", handler: (*OrderHandler).CreateOrderEmailTemplate},
+		{name: "update", method: http.MethodPut, path: "/api/orders/email-templates/1", handler: (*OrderHandler).UpdateOrderEmailTemplate},
+		{name: "delete", method: http.MethodDelete, path: "/api/orders/email-templates/1", handler: (*OrderHandler).DeleteOrderEmailTemplate},
+		{name: "preview", method: http.MethodPost, path: "/api/orders/email-templates/preview", handler: (*OrderHandler).PreviewOrderEmailTemplate},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tc.method, tc.path, nil)
+
+			tc.handler(&OrderHandler{}, ctx)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("%s status = %d, want %d", tc.name, recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
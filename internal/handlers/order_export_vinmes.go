@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,13 +32,14 @@ func (h *OrderHandler) GetExportToVinmes(c *gin.Context) {
 	masters := models.BuildVinmesExportMasters(sources)
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":         masters,
-		"count":        len(masters),
-		"detailCount":  len(sources),
-		"month":        filter.Month,
-		"year":         filter.Year,
-		"all":          filter.All,
-		"materialCode": filter.MaterialCode,
+		"data":            masters,
+		"count":           len(masters),
+		"detailCount":     len(sources),
+		"month":           filter.Month,
+		"year":            filter.Year,
+		"all":             filter.All,
+		"materialCode":    filter.MaterialCode,
+		"includeExported": filter.IncludeExported,
 	})
 }
 
@@ -73,25 +76,30 @@ func (h *OrderHandler) GetExportToVinmesMappingPreview(c *gin.Context) {
 		}
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"data":         mapped,
-		"count":        len(mapped),
-		"detailCount":  len(sources),
-		"invalidCount": invalidCount,
-		"month":        filter.Month,
-		"year":         filter.Year,
-		"all":          filter.All,
-		"materialCode": filter.MaterialCode,
+		"data":            mapped,
+		"count":           len(mapped),
+		"detailCount":     len(sources),
+		"invalidCount":    invalidCount,
+		"month":           filter.Month,
+		"year":            filter.Year,
+		"all":             filter.All,
+		"materialCode":    filter.MaterialCode,
+		"includeExported": filter.IncludeExported,
 	})
 }
 
-func (h *OrderHandler) RefreshVinmesCatalogs(c *gin.Context) {
+type SubmitExportToVinmesRequest struct {
+	SoPhieu []string `json:"soPhieu"`
+}
+
+func (h *OrderHandler) SubmitExportToVinmes(c *gin.Context) {
 	currentUser, err := h.getCurrentUser(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
 		return
 	}
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa, RoleNhanVienKeToan) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Admin, Chi huy khoa or Nhan vien ke toan can refresh Vinmes catalogs"})
+	if h.invoiceMatchRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Invoice reconciliation repository is not configured"})
 		return
 	}
 	if h.vinmesCatalog == nil || !h.vinmesCatalog.IsConfigured() {
@@ -99,22 +107,167 @@ func (h *OrderHandler) RefreshVinmesCatalogs(c *gin.Context) {
 		return
 	}
 
-	result, err := h.vinmesCatalog.RefreshCatalogs(c.Request.Context())
+	var req SubmitExportToVinmesRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: err.Error()})
+			return
+		}
+	}
+
+	filter, ok := parseVinmesExportFilter(c)
+	if !ok {
+		return
+	}
+	sources, err := h.invoiceMatchRepo.ListVinmesExportSources(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	masters := models.BuildVinmesExportMasters(sources)
+	if selected := uniqueNonEmptyStrings(req.SoPhieu); len(selected) > 0 {
+		wanted := make(map[string]struct{}, len(selected))
+		for _, soPhieu := range selected {
+			wanted[soPhieu] = struct{}{}
+		}
+		filtered := make([]models.VinmesExportMaster, 0, len(selected))
+		for _, master := range masters {
+			if _, ok := wanted[strings.TrimSpace(master.SoPhieu)]; ok {
+				filtered = append(filtered, master)
+			}
+		}
+		masters = filtered
+	}
+
+	mapped, err := h.vinmesCatalog.BuildMappingPreview(c.Request.Context(), masters)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "VINMES_API_ERROR", Message: err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, result)
+	results, err := h.vinmesCatalog.SubmitPurchaseOrders(c.Request.Context(), mapped, models.OrderActor{
+		ID:       currentUser.ID,
+		Username: currentUser.Username,
+		Email:    currentUser.Email,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	batchID := ""
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+		batchID = result.BatchID
+	}
+	submittedCount := counts[services.VinmesSubmissionStatusSubmitted]
+	if submittedCount > 0 {
+		broadcastActivityNotification(h.hub, ActivityNotificationPayload{
+			Category:   "invoices",
+			Action:     "invoices.vinmes_submitted",
+			ActorID:    currentUser.ID,
+			ActorName:  currentUser.Username,
+			ActorEmail: currentUser.Email,
+			Count:      submittedCount,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":                  results,
+		"batchId":               batchID,
+		"count":                 len(results),
+		"submittedCount":        submittedCount,
+		"alreadySubmittedCount": counts[services.VinmesSubmissionStatusAlreadySubmitted],
+		"invalidCount":          counts[services.VinmesSubmissionStatusInvalid],
+		"failedCount":           counts[services.VinmesSubmissionStatusFailed],
+	})
 }
 
-func (h *OrderHandler) authorizeVinmesExport(c *gin.Context) bool {
+// RefreshVinmesCatalogs starts a Vinmes catalog refresh as a background job;
+// the refresh result is stored on the job when it succeeds.
+func (h *OrderHandler) RefreshVinmesCatalogs(c *gin.Context) {
 	currentUser, err := h.getCurrentUser(c)
 	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+	if h.vinmesCatalog == nil || !h.vinmesCatalog.IsConfigured() {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "VINMES_NOT_CONFIGURED", Message: "VINMES_API_BASE_URL is not configured"})
+		return
+	}
+
+	if !requireJobRunner(c, h.jobs) {
+		return
+	}
+
+	job, err := h.jobs.Start(models.BackgroundJobKindVinmesCatalogRefresh, jobCreatorID(currentUser), func(ctx context.Context, _ services.JobProgressFunc) (interface{}, error) {
+		return h.vinmesCatalog.RefreshCatalogs(ctx)
+	})
+	respondJobStarted(c, job, err, "Vinmes catalog refresh started")
+}
+
+func (h *OrderHandler) ListVinmesCatalogRefreshRuns(c *gin.Context) {
+	if !h.authorizeVinmesCatalogRefreshLog(c) {
+		return
+	}
+
+	limit := 30
+	if rawLimit := strings.TrimSpace(c.Query("limit")); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "limit must be greater than 0"})
+			return
+		}
+		limit = parsed
+	}
+
+	runs, err := h.vinmesCatalog.ListRefreshRuns(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": runs, "count": len(runs)})
+}
+
+func (h *OrderHandler) GetVinmesCatalogRefreshRun(c *gin.Context) {
+	if !h.authorizeVinmesCatalogRefreshLog(c) {
+		return
+	}
+
+	runID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || runID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return
+	}
+
+	run, err := h.vinmesCatalog.GetRefreshRun(runID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Vinmes catalog refresh run not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": run})
+}
+
+func (h *OrderHandler) authorizeVinmesCatalogRefreshLog(c *gin.Context) bool {
+	if _, err := h.getCurrentUser(c); err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
 		return false
 	}
-	if !canViewInvoiceWorkflowRole(currentUser.Role) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Authenticated users with a valid operational role can export Vinmes reconciliation data"})
+	if h.vinmesCatalog == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "VINMES_NOT_CONFIGURED", Message: "VINMES_API_BASE_URL is not configured"})
+		return false
+	}
+	return true
+}
+
+func (h *OrderHandler) authorizeVinmesExport(c *gin.Context) bool {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
 		return false
 	}
 	if h.invoiceMatchRepo == nil {
@@ -140,6 +293,14 @@ func parseVinmesExportFilter(c *gin.Context) (models.VinmesExportFilter, bool) {
 		}
 		filter.All = parsed
 	}
+	if rawIncludeExported := strings.TrimSpace(c.Query("includeExported")); rawIncludeExported != "" {
+		parsed, err := strconv.ParseBool(rawIncludeExported)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "includeExported must be true or false"})
+			return filter, false
+		}
+		filter.IncludeExported = parsed
+	}
 	if rawMonth := strings.TrimSpace(c.Query("month")); rawMonth != "" {
 		parsed, err := strconv.Atoi(rawMonth)
 		if err != nil || parsed < 1 || parsed > 12 {
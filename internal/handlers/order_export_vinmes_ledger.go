@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *OrderHandler) ListVinmesExportLedger(c *gin.Context) {
+	if !h.authorizeVinmesExportLedger(c) {
+		return
+	}
+
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+	switch status {
+	case "", models.VinmesExportStatusQueued, models.VinmesExportStatusSent, models.VinmesExportStatusFailed:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "status must be queued, sent or failed"})
+		return
+	}
+
+	page, pageSize := parsePagination(c)
+	entries, total, err := h.vinmesLedgerRepo.List(models.VinmesExportLedgerFilter{
+		Status:   status,
+		BatchID:  strings.TrimSpace(c.Query("batchId")),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginationResponse{
+		Data:       entries,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	})
+}
+
+func (h *OrderHandler) GetVinmesExportLedgerEntry(c *gin.Context) {
+	if !h.authorizeVinmesExportLedger(c) {
+		return
+	}
+
+	entryID, ok := parseVinmesExportLedgerID(c)
+	if !ok {
+		return
+	}
+
+	entry, err := h.vinmesLedgerRepo.GetVinmesExportEntry(entryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if entry == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Vinmes export ledger entry not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entry})
+}
+
+func (h *OrderHandler) RetryVinmesExportLedgerEntry(c *gin.Context) {
+	currentUser, err := h.getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+	if h.vinmesCatalog == nil || !h.vinmesCatalog.IsConfigured() {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "VINMES_NOT_CONFIGURED", Message: "VINMES_API_BASE_URL is not configured"})
+		return
+	}
+
+	entryID, ok := parseVinmesExportLedgerID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.vinmesCatalog.RetryExportEntry(c.Request.Context(), entryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrVinmesExportEntryNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Vinmes export ledger entry not found"})
+		case errors.Is(err, services.ErrVinmesExportEntryAlreadySent):
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "ALREADY_SENT", Message: "Vinmes export ledger entry was already sent"})
+		case errors.Is(err, services.ErrVinmesExportEntryInFlight):
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "IN_FLIGHT", Message: "Vinmes export ledger entry is still being submitted"})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		}
+		return
+	}
+
+	if result.Status == services.VinmesSubmissionStatusSubmitted {
+		broadcastActivityNotification(h.hub, ActivityNotificationPayload{
+			Category:   "invoices",
+			Action:     "invoices.vinmes_submitted",
+			ActorID:    currentUser.ID,
+			ActorName:  currentUser.Username,
+			ActorEmail: currentUser.Email,
+			Count:      1,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *OrderHandler) authorizeVinmesExportLedger(c *gin.Context) bool {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return false
+	}
+	if h.vinmesLedgerRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Vinmes export ledger repository is not configured"})
+		return false
+	}
+	return true
+}
+
+func parseVinmesExportLedgerID(c *gin.Context) (int64, bool) {
+	entryID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || entryID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return 0, false
+	}
+	return entryID, true
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVinmesExportLedgerEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(*OrderHandler, *gin.Context)
+	}{
+		{name: "list", method: http.MethodGet, path: "/api/export-to-vinmes/ledger", handler: (*OrderHandler).ListVinmesExportLedger},
+		{name: "detail", method: http.MethodGet, path: "/api/export-to-vinmes/ledger/1", handler: (*OrderHandler).GetVinmesExportLedgerEntry},
+		{name: "retry", method: http.MethodPost, path: "/api/export-to-vinmes/ledger/1/retry", handler: (*OrderHandler).RetryVinmesExportLedgerEntry},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tc.method, tc.path, nil)
+
+			tc.handler(&OrderHandler{}, ctx)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("%s status = %d, want %d", tc.name, recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+type VinmesMappingOverrideRequest struct {
+	OverrideType string `json:"overrideType"`
+	MatchField   string `json:"matchField"`
+	SourceValue  string `json:"sourceValue"`
+	VinmesID     string `json:"vinmesId"`
+	Note         string `json:"note"`
+}
+
+func (h *OrderHandler) ListVinmesMappingOverrides(c *gin.Context) {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+	if h.vinmesCatalog == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Vinmes catalog service is not configured"})
+		return
+	}
+
+	overrides, err := h.vinmesCatalog.ListMappingOverrides()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": overrides})
+}
+
+func (h *OrderHandler) CreateVinmesMappingOverride(c *gin.Context) {
+	currentUser, ok := h.authorizeVinmesMappingOverrideChange(c)
+	if !ok {
+		return
+	}
+
+	var req VinmesMappingOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid mapping override payload"})
+		return
+	}
+
+	override, err := h.vinmesCatalog.CreateMappingOverride(req.toModel(), vinmesOverrideActor(currentUser))
+	if err != nil {
+		writeVinmesMappingOverrideError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": override})
+}
+
+func (h *OrderHandler) UpdateVinmesMappingOverride(c *gin.Context) {
+	currentUser, ok := h.authorizeVinmesMappingOverrideChange(c)
+	if !ok {
+		return
+	}
+
+	overrideID, ok := parseVinmesMappingOverrideID(c)
+	if !ok {
+		return
+	}
+
+	var req VinmesMappingOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid mapping override payload"})
+		return
+	}
+
+	override := req.toModel()
+	override.ID = overrideID
+	override, err := h.vinmesCatalog.UpdateMappingOverride(override, vinmesOverrideActor(currentUser))
+	if err != nil {
+		writeVinmesMappingOverrideError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": override})
+}
+
+func (h *OrderHandler) DeleteVinmesMappingOverride(c *gin.Context) {
+	if _, ok := h.authorizeVinmesMappingOverrideChange(c); !ok {
+		return
+	}
+
+	overrideID, ok := parseVinmesMappingOverrideID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.vinmesCatalog.DeleteMappingOverride(overrideID); err != nil {
+		writeVinmesMappingOverrideError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vinmes mapping override deleted"})
+}
+
+func (h *OrderHandler) authorizeVinmesMappingOverrideChange(c *gin.Context) (*models.UserProfile, bool) {
+	currentUser, err := h.getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return nil, false
+	}
+	if h.vinmesCatalog == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Vinmes catalog service is not configured"})
+		return nil, false
+	}
+	return currentUser, true
+}
+
+func (req VinmesMappingOverrideRequest) toModel() *models.VinmesMappingOverride {
+	return &models.VinmesMappingOverride{
+		OverrideType: req.OverrideType,
+		MatchField:   req.MatchField,
+		SourceValue:  req.SourceValue,
+		VinmesID:     req.VinmesID,
+		Note:         req.Note,
+	}
+}
+
+func vinmesOverrideActor(user *models.UserProfile) models.OrderActor {
+	return models.OrderActor{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+	}
+}
+
+func writeVinmesMappingOverrideError(c *gin.Context, err error) {
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.Is(err, services.ErrInvalidVinmesMappingOverride):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: err.Error()})
+	case errors.Is(err, services.ErrVinmesMappingOverrideNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Vinmes mapping override not found"})
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == 1062:
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "DUPLICATE_OVERRIDE", Message: "A mapping override already exists for this source value"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+	}
+}
+
+func parseVinmesMappingOverrideID(c *gin.Context) (int64, bool) {
+	overrideID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || overrideID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return 0, false
+	}
+	return overrideID, true
+}
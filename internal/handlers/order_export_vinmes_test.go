@@ -39,3 +39,18 @@ func TestGetExportToVinmesMappingPreviewRequiresAuthentication(t *testing.T) {
 		t.Fatalf("GetExportToVinmesMappingPreview() status = %d, want %d", recorder.Code, http.StatusUnauthorized)
 	}
 }
+
+func TestSubmitExportToVinmesRequiresAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/export-to-vinmes/submit", nil)
+
+	handler := &OrderHandler{}
+	handler.SubmitExportToVinmes(ctx)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("SubmitExportToVinmes() status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+}
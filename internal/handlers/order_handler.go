@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"sort"
 	"strconv"
@@ -14,7 +15,6 @@ import (
 	"bv108-consumables-management-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type OrderHandler struct {
@@ -22,11 +22,14 @@ type OrderHandler struct {
 	invoiceMatchRepo   *models.InvoiceReconciliationRepository
 	unreadRepo         *models.OrderUnreadRepository
 	companyContactRepo *models.CompanyContactRepository
-	userRepo           *models.UserRepository
-	jwtSecret          []byte
 	mailer             services.OrderEmailSender
 	hub                *realtime.Hub
 	vinmesCatalog      *services.VinmesCatalogService
+	vinmesLedgerRepo   *models.VinmesExportLedgerRepository
+	emailOutbox        *services.OrderEmailOutbox
+	emailTemplates     *services.OrderEmailTemplateService
+	invoiceMatcher     *services.InvoiceMatcher
+	jobs               *services.JobRunner
 }
 
 type CreateForecastOrdersRequest struct {
@@ -100,17 +103,20 @@ type UpsertInvoiceReconciliationItemRequest struct {
 	Status                  string  `json:"status"`
 }
 
-func NewOrderHandler(repo *models.OrderRepository, invoiceMatchRepo *models.InvoiceReconciliationRepository, unreadRepo *models.OrderUnreadRepository, companyContactRepo *models.CompanyContactRepository, userRepo *models.UserRepository, jwtSecret string, mailer services.OrderEmailSender, hub *realtime.Hub, vinmesCatalog *services.VinmesCatalogService) *OrderHandler {
+func NewOrderHandler(repo *models.OrderRepository, invoiceMatchRepo *models.InvoiceReconciliationRepository, unreadRepo *models.OrderUnreadRepository, companyContactRepo *models.CompanyContactRepository, mailer services.OrderEmailSender, hub *realtime.Hub, vinmesCatalog *services.VinmesCatalogService, vinmesLedgerRepo *models.VinmesExportLedgerRepository, emailOutbox *services.OrderEmailOutbox, emailTemplates *services.OrderEmailTemplateService, invoiceMatcher *services.InvoiceMatcher, jobs *services.JobRunner) *OrderHandler {
 	return &OrderHandler{
 		repo:               repo,
 		invoiceMatchRepo:   invoiceMatchRepo,
 		unreadRepo:         unreadRepo,
 		companyContactRepo: companyContactRepo,
-		userRepo:           userRepo,
-		jwtSecret:          []byte(jwtSecret),
 		mailer:             mailer,
 		hub:                hub,
 		vinmesCatalog:      vinmesCatalog,
+		vinmesLedgerRepo:   vinmesLedgerRepo,
+		emailOutbox:        emailOutbox,
+		emailTemplates:     emailTemplates,
+		invoiceMatcher:     invoiceMatcher,
+		jobs:               jobs,
 	}
 }
 
@@ -126,11 +132,6 @@ func (h *OrderHandler) UpsertInvoiceReconciliations(c *gin.Context) {
 		return
 	}
 
-	if !canEditInvoiceWorkflowRole(currentUser.Role) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Thu kho can create invoice reconciliation records"})
-		return
-	}
-
 	var req UpsertInvoiceReconciliationsBulkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid invoice reconciliation upsert payload"})
@@ -195,6 +196,12 @@ func (h *OrderHandler) UpsertInvoiceReconciliations(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
 		return
 	}
+	h.syncOrderLifecycleAfterReconciliation()
+	setAuditChange(c, AuditChange{
+		Action:     "invoices.reconciliation_upserted",
+		EntityType: "invoice_reconciliation",
+		After:      inputs,
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Invoice reconciliation records upserted", "count": len(inputs)})
 }
@@ -211,11 +218,6 @@ func (h *OrderHandler) SaveInvoiceReconciliations(c *gin.Context) {
 		return
 	}
 
-	if !canEditInvoiceWorkflowRole(currentUser.Role) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Thu kho can update invoice reconciliation"})
-		return
-	}
-
 	var req SaveInvoiceReconciliationsBulkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid invoice reconciliation payload"})
@@ -262,6 +264,19 @@ func (h *OrderHandler) SaveInvoiceReconciliations(c *gin.Context) {
 		return
 	}
 
+	editedIDs := make([]int64, 0, len(noteUpdates)+len(statusUpdates))
+	for _, item := range noteUpdates {
+		editedIDs = append(editedIDs, item.ID)
+	}
+	for _, item := range statusUpdates {
+		editedIDs = append(editedIDs, item.ID)
+	}
+	before, err := h.invoiceMatchRepo.ListNoteStatusByIDs(editedIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
 	var updatedCount int64
 	var noteUpdatedCount int64
 	var statusUpdatedCount int64
@@ -285,6 +300,20 @@ func (h *OrderHandler) SaveInvoiceReconciliations(c *gin.Context) {
 		updatedCount += count
 	}
 
+	if updatedCount > 0 {
+		h.syncOrderLifecycleAfterReconciliation()
+		after, err := h.invoiceMatchRepo.ListNoteStatusByIDs(editedIDs)
+		if err != nil {
+			log.Printf("[audit] loading saved invoice reconciliations failed: %v", err)
+		}
+		setAuditChange(c, AuditChange{
+			Action:     "invoices.reconciliation_saved",
+			EntityType: "invoice_reconciliation",
+			Before:     before,
+			After:      after,
+		})
+	}
+
 	if h.hub != nil && updatedCount > 0 {
 		now := time.Now().UTC()
 		h.hub.Broadcast("invoices.reconciliation_updated", gin.H{
@@ -329,17 +358,11 @@ func (h *OrderHandler) GetInvoiceReconciliationHistory(c *gin.Context) {
 		return
 	}
 
-	currentUser, err := h.getCurrentUser(c)
-	if err != nil {
+	if _, err := h.getCurrentUser(c); err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
 		return
 	}
 
-	if !canViewInvoiceWorkflowRole(currentUser.Role) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Authenticated users with a valid operational role can view invoice reconciliation history"})
-		return
-	}
-
 	month, err := strconv.Atoi(c.DefaultQuery("month", strconv.Itoa(int(time.Now().Month()))))
 	if err != nil || month < 1 || month > 12 {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "month must be from 1 to 12"})
@@ -367,17 +390,11 @@ func (h *OrderHandler) GetMatchedInvoiceNumbers(c *gin.Context) {
 		return
 	}
 
-	currentUser, err := h.getCurrentUser(c)
-	if err != nil {
+	if _, err := h.getCurrentUser(c); err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
 		return
 	}
 
-	if !canViewInvoiceWorkflowRole(currentUser.Role) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Authenticated users with a valid operational role can view matched invoices"})
-		return
-	}
-
 	allParam := strings.TrimSpace(c.Query("all"))
 	if allParam != "" {
 		if parsed, err := strconv.ParseBool(allParam); err == nil && parsed {
@@ -419,17 +436,11 @@ func (h *OrderHandler) GetMatchedOrderReconciliations(c *gin.Context) {
 		return
 	}
 
-	currentUser, err := h.getCurrentUser(c)
-	if err != nil {
+	if _, err := h.getCurrentUser(c); err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
 		return
 	}
 
-	if !canViewInvoiceWorkflowRole(currentUser.Role) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Authenticated users with a valid operational role can view matched order reconciliations"})
-		return
-	}
-
 	records, err := h.invoiceMatchRepo.ListAllReconciliations()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
@@ -445,17 +456,11 @@ func (h *OrderHandler) SearchCompanyContacts(c *gin.Context) {
 		return
 	}
 
-	currentUser, err := h.getCurrentUser(c)
-	if err != nil {
+	if _, err := h.getCurrentUser(c); err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
 		return
 	}
 
-	if !canCreateManualOrderRole(currentUser.Role) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Admin or Chi huy khoa can search company contacts"})
-		return
-	}
-
 	keyword := strings.TrimSpace(c.Query("keyword"))
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
@@ -512,11 +517,6 @@ func (h *OrderHandler) CreateForecastOrders(c *gin.Context) {
 		return
 	}
 
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Chi huy khoa (or Admin) can move approved forecasts to pending orders"})
-		return
-	}
-
 	var req CreateForecastOrdersRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid forecast order payload"})
@@ -689,11 +689,6 @@ func (h *OrderHandler) CreateManualOrder(c *gin.Context) {
 		return
 	}
 
-	if !canCreateManualOrderRole(currentUser.Role) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Admin or Chi huy khoa can create manual orders"})
-		return
-	}
-
 	var req CreateOrderItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid manual order payload"})
@@ -772,11 +767,6 @@ func (h *OrderHandler) PlaceOrders(c *gin.Context) {
 		return
 	}
 
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa, RoleThuKho, RoleNhanVienThau) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Admin, Chi huy khoa, Thu kho, or Nhan vien thau can place orders"})
-		return
-	}
-
 	var req PlaceOrdersRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid place order payload"})
@@ -804,8 +794,15 @@ func (h *OrderHandler) PlaceOrders(c *gin.Context) {
 		return
 	}
 
-	if err := h.sendPlacedOrderEmails(pendingOrders); err != nil {
-		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "EMAIL_SEND_ERROR", Message: err.Error()})
+	for _, order := range pendingOrders {
+		if order.ApprovalStatus != models.PendingOrderStatusApproved {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "NOT_APPROVED", Message: "Only pending orders approved by Chi huy khoa can be placed"})
+			return
+		}
+	}
+
+	if err := validatePlacedOrderRecipients(pendingOrders); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "MISSING_EMAIL", Message: err.Error()})
 		return
 	}
 
@@ -813,8 +810,13 @@ func (h *OrderHandler) PlaceOrders(c *gin.Context) {
 		ID:       currentUser.ID,
 		Username: currentUser.Username,
 		Email:    currentUser.Email,
-	})
+	}, h.emailTemplates.SnapshotPlacedOrder)
 	if err != nil {
+		if errors.Is(err, models.ErrPendingOrdersNotApproved) {
+			writePendingOrderRepositoryError(c, err)
+			return
+		}
+
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "no pending orders found" {
 			statusCode = http.StatusNotFound
@@ -824,6 +826,14 @@ func (h *OrderHandler) PlaceOrders(c *gin.Context) {
 		return
 	}
 
+	h.emailOutbox.Notify()
+	setAuditChange(c, AuditChange{
+		Action:     "orders.placed",
+		EntityType: "pending_order",
+		Before:     pendingOrders,
+		After:      gin.H{"orderIds": req.OrderIDs, "placedCount": placedCount},
+	})
+
 	if h.hub != nil && placedCount > 0 {
 		now := time.Now().UTC()
 		h.hub.Broadcast("orders.updated", gin.H{
@@ -845,7 +855,7 @@ func (h *OrderHandler) PlaceOrders(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":     "Orders placed and email sent successfully",
+		"message":     "Orders placed and supplier emails queued",
 		"placedCount": placedCount,
 	})
 }
@@ -857,11 +867,6 @@ func (h *OrderHandler) RepeatOrderHistory(c *gin.Context) {
 		return
 	}
 
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa, RoleThuKho, RoleNhanVienThau) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Only Admin, Chi huy khoa, Thu kho, or Nhan vien thau can place orders"})
-		return
-	}
-
 	var req PlaceOrdersRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid place order payload"})
@@ -894,12 +899,12 @@ func (h *OrderHandler) RepeatOrderHistory(c *gin.Context) {
 		pendingOrders = append(pendingOrders, order.PendingOrder)
 	}
 
-	if err := h.sendPlacedOrderEmails(pendingOrders); err != nil {
-		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "EMAIL_SEND_ERROR", Message: err.Error()})
+	if err := validatePlacedOrderRecipients(pendingOrders); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "MISSING_EMAIL", Message: err.Error()})
 		return
 	}
 
-	placedCount, err := h.repo.RepeatOrderHistory(historyOrders, models.OrderActor{
+	repeatedCount, err := h.repo.RepeatOrderHistory(historyOrders, models.OrderActor{
 		ID:       currentUser.ID,
 		Username: currentUser.Username,
 		Email:    currentUser.Email,
@@ -909,11 +914,17 @@ func (h *OrderHandler) RepeatOrderHistory(c *gin.Context) {
 		return
 	}
 
-	if h.hub != nil && placedCount > 0 {
+	setAuditChange(c, AuditChange{
+		Action:     "orders.repeated",
+		EntityType: "order_history",
+		After:      gin.H{"orderIds": req.OrderIDs, "draftCount": repeatedCount},
+	})
+
+	if h.hub != nil && repeatedCount > 0 {
 		now := time.Now().UTC()
 		h.hub.Broadcast("orders.updated", gin.H{
 			"action":    "repeated",
-			"count":     placedCount,
+			"count":     repeatedCount,
 			"updatedBy": currentUser.Username,
 			"updatedAt": now.Format(time.RFC3339Nano),
 		})
@@ -924,14 +935,16 @@ func (h *OrderHandler) RepeatOrderHistory(c *gin.Context) {
 			ActorID:    currentUser.ID,
 			ActorName:  currentUser.Username,
 			ActorEmail: currentUser.Email,
-			Count:      placedCount,
+			Count:      repeatedCount,
 			CreatedAt:  now.Format(time.RFC3339Nano),
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":     "Orders repeated and email sent successfully",
-		"placedCount": placedCount,
+	// The copies are drafts; they reach suppliers only after propose, approve
+	// and place.
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Orders copied to the pending list as drafts awaiting approval",
+		"draftCount": repeatedCount,
 	})
 }
 
@@ -1017,56 +1030,19 @@ func (h *OrderHandler) MarkGroupsSeen(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Groups marked as seen", "count": len(groupKeys)})
 }
 
-func (h *OrderHandler) sendPlacedOrderEmails(orders []models.PendingOrder) error {
-	if h.mailer == nil {
-		return fmt.Errorf("email sender is not configured")
-	}
-
-	type emailGroup struct {
-		supplierName string
-		email        string
-		items        []services.OrderEmailItem
-	}
-
-	groups := make(map[string]emailGroup)
+// validatePlacedOrderRecipients rejects orders the outbox could never
+// deliver, so they are not placed without a supplier email address.
+func validatePlacedOrderRecipients(orders []models.PendingOrder) error {
 	for _, order := range orders {
-		supplierName := strings.TrimSpace(order.NhaThau)
-		email := h.resolveOrderRecipientEmail(order)
-		if email == "" {
-			if supplierName == "" {
-				return fmt.Errorf("missing company email")
-			}
-			return fmt.Errorf("missing company email for %s", supplierName)
-		}
-
-		key := strings.ToLower(email) + "|" + strings.ToLower(supplierName)
-		group, exists := groups[key]
-		if !exists {
-			group = emailGroup{
-				supplierName: supplierName,
-				email:        email,
-				items:        make([]services.OrderEmailItem, 0, 4),
-			}
+		if strings.TrimSpace(order.Email) != "" {
+			continue
 		}
-
-		group.items = append(group.items, services.OrderEmailItem{
-			Index:        len(group.items) + 1,
-			TenVatTu:     strings.TrimSpace(order.TenVtytBv),
-			MaXuatHoaDon: models.PreferredMaterialCode(order.MaQuanLy, order.MaVtytCu),
-			MaHieu:       strings.TrimSpace(order.MaHieu),
-			HangNuocSX:   strings.TrimSpace(order.HangSx),
-			DonViTinh:    strings.TrimSpace(order.DonViTinh),
-			SoLuong:      order.DotGoiHang,
-		})
-		groups[key] = group
-	}
-
-	for _, group := range groups {
-		if err := h.mailer.SendPlacedOrderEmail(group.email, group.supplierName, group.items); err != nil {
-			return err
+		supplierName := strings.TrimSpace(order.NhaThau)
+		if supplierName == "" {
+			return fmt.Errorf("missing company email")
 		}
+		return fmt.Errorf("missing company email for %s", supplierName)
 	}
-
 	return nil
 }
 
@@ -1074,63 +1050,12 @@ func (h *OrderHandler) resolveOrderRecipientEmail(order models.PendingOrder) str
 	return strings.TrimSpace(order.Email)
 }
 
+// getCurrentUser returns the user AuthMiddleware.Enforce stored in the context.
 func (h *OrderHandler) getCurrentUser(c *gin.Context) (*models.UserProfile, error) {
-	userID, err := h.getUserIDFromAuthorizationHeader(c)
-	if err != nil {
-		return nil, err
-	}
-
-	user, err := loadActiveUserByID(h.userRepo, userID)
-	if err != nil {
-		return nil, err
-	}
-
-	profile := user.ToProfile()
-	return &profile, nil
-}
-
-func (h *OrderHandler) getUserIDFromAuthorizationHeader(c *gin.Context) (int64, error) {
-	authHeader := strings.TrimSpace(c.GetHeader("Authorization"))
-	if authHeader == "" {
-		return 0, fmt.Errorf("missing authorization header")
-	}
-
-	parts := strings.SplitN(authHeader, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return 0, fmt.Errorf("invalid authorization header format")
+	if profile, ok := currentUserFromContext(c); ok {
+		return profile, nil
 	}
-
-	tokenString := strings.TrimSpace(parts[1])
-	if tokenString == "" {
-		return 0, fmt.Errorf("missing bearer token")
-	}
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
-		}
-		return h.jwtSecret, nil
-	})
-	if err != nil || !token.Valid {
-		return 0, fmt.Errorf("invalid or expired token")
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return 0, fmt.Errorf("invalid token claims")
-	}
-
-	subValue, exists := claims["sub"]
-	if !exists {
-		return 0, fmt.Errorf("missing subject in token")
-	}
-
-	userID, err := convertClaimToInt64(subValue)
-	if err != nil {
-		return 0, fmt.Errorf("invalid subject in token")
-	}
-
-	return userID, nil
+	return nil, errMissingCurrentUser
 }
 
 func sanitizeText(value string) string {
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *OrderHandler) GetOutstandingOrderQuantities(c *gin.Context) {
+	if _, err := h.getCurrentUser(c); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+
+	items, err := h.repo.ListOutstandingQuantities(models.OrderOutstandingFilter{
+		NhaThau:  strings.TrimSpace(c.Query("supplier")),
+		MaQuanLy: strings.TrimSpace(c.Query("material")),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// syncOrderLifecycleAfterReconciliation refreshes delivered quantities once
+// reconciliation rows change. The reconciliation itself is already saved, so a
+// failure here is only logged and picked up again by OrderLifecycleSync.
+func (h *OrderHandler) syncOrderLifecycleAfterReconciliation() {
+	if h.repo == nil {
+		return
+	}
+	if _, err := h.repo.SyncOrderLifecycle(time.Now()); err != nil {
+		log.Printf("⚠️ Failed to sync order lifecycle after reconciliation: %v", err)
+	}
+}
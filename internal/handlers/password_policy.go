@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const defaultPasswordMinLength = 8
+
+// PasswordPolicy is checked whenever a password is set: on register, on admin
+// reset and on self-service change.
+type PasswordPolicy struct {
+	MinLength        int
+	RequireMixedCase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+}
+
+// Validate returns a message fit for the client when password breaks the
+// policy.
+func (p PasswordPolicy) Validate(password string) error {
+	minLength := p.MinLength
+	if minLength <= 0 {
+		minLength = defaultPasswordMinLength
+	}
+	if utf8.RuneCountInString(password) < minLength {
+		return fmt.Errorf("Password must be at least %d characters", minLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	missing := make([]string, 0, 3)
+	if p.RequireMixedCase && !(hasUpper && hasLower) {
+		missing = append(missing, "upper and lower case letters")
+	}
+	if p.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Password must contain %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
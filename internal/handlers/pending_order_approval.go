@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RejectPendingOrderBatchRequest struct {
+	Reason string `json:"reason"`
+}
+
+type pendingOrderTransitionError struct {
+	status  int
+	message string
+}
+
+func (e *pendingOrderTransitionError) Error() string {
+	return e.message
+}
+
+func (h *OrderHandler) ProposePendingOrders(c *gin.Context) {
+	currentUser, err := h.getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+
+	var req PlaceOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid propose order payload"})
+		return
+	}
+
+	orderIDs := uniqueOrderIDs(req.OrderIDs)
+	if len(orderIDs) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "At least one order id is required"})
+		return
+	}
+
+	pendingOrders, err := h.repo.GetPendingOrdersByIDs(orderIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if len(pendingOrders) != len(orderIDs) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Some pending orders were not found"})
+		return
+	}
+
+	for _, order := range pendingOrders {
+		if err := validatePendingOrderTransition(models.PendingOrderStatusProposed, currentUser, order.ApprovalStatus, ""); err != nil {
+			writePendingOrderTransitionError(c, err)
+			return
+		}
+	}
+
+	batchID, err := h.repo.ProposePendingOrders(orderIDs, models.OrderActor{
+		ID:       currentUser.ID,
+		Username: currentUser.Username,
+		Email:    currentUser.Email,
+	})
+	if err != nil {
+		writePendingOrderRepositoryError(c, err)
+		return
+	}
+
+	h.broadcastPendingOrderTransition(currentUser, models.PendingOrderStatusProposed, batchID, len(orderIDs))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pending orders proposed for approval",
+		"batchId": batchID,
+		"count":   len(orderIDs),
+	})
+}
+
+func (h *OrderHandler) ApprovePendingOrderBatch(c *gin.Context) {
+	h.reviewPendingOrderBatch(c, models.PendingOrderStatusApproved)
+}
+
+func (h *OrderHandler) RejectPendingOrderBatch(c *gin.Context) {
+	h.reviewPendingOrderBatch(c, models.PendingOrderStatusRejected)
+}
+
+func (h *OrderHandler) reviewPendingOrderBatch(c *gin.Context, status string) {
+	currentUser, err := h.getCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
+		return
+	}
+
+	reason := ""
+	if status == models.PendingOrderStatusRejected {
+		var req RejectPendingOrderBatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid reject payload"})
+			return
+		}
+		reason = strings.TrimSpace(req.Reason)
+	}
+
+	batchID := strings.TrimSpace(c.Param("batchId"))
+	if batchID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "batchId is required"})
+		return
+	}
+
+	statuses, err := h.repo.GetPendingOrderBatchStatuses(batchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	if len(statuses) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Pending order batch not found"})
+		return
+	}
+
+	for _, existingStatus := range statuses {
+		if err := validatePendingOrderTransition(status, currentUser, existingStatus, reason); err != nil {
+			writePendingOrderTransitionError(c, err)
+			return
+		}
+	}
+
+	count, err := h.repo.ReviewPendingOrderBatch(models.PendingOrderReview{
+		BatchID: batchID,
+		Status:  status,
+		Reason:  reason,
+		Actor: models.OrderActor{
+			ID:       currentUser.ID,
+			Username: currentUser.Username,
+			Email:    currentUser.Email,
+		},
+	})
+	if err != nil {
+		writePendingOrderRepositoryError(c, err)
+		return
+	}
+
+	h.broadcastPendingOrderTransition(currentUser, status, batchID, count)
+
+	message := "Pending order batch approved"
+	if status == models.PendingOrderStatusRejected {
+		message = "Pending order batch rejected"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"batchId": batchID,
+		"count":   count,
+	})
+}
+
+func (h *OrderHandler) broadcastPendingOrderTransition(currentUser *models.UserProfile, status, batchID string, count int) {
+	if h.hub == nil || count == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	h.hub.Broadcast("orders.updated", gin.H{
+		"action":    status,
+		"batchId":   batchID,
+		"count":     count,
+		"updatedBy": currentUser.Username,
+		"updatedAt": now.Format(time.RFC3339Nano),
+	})
+
+	broadcastActivityNotification(h.hub, ActivityNotificationPayload{
+		Category:   "orders",
+		Action:     "orders." + status,
+		ActorID:    currentUser.ID,
+		ActorName:  currentUser.Username,
+		ActorEmail: currentUser.Email,
+		Count:      count,
+		Status:     status,
+		CreatedAt:  now.Format(time.RFC3339Nano),
+	})
+}
+
+func validatePendingOrderTransition(targetStatus string, currentUser *models.UserProfile, existingStatus, reason string) error {
+	normalizedExistingStatus := strings.TrimSpace(existingStatus)
+	if normalizedExistingStatus == "" {
+		normalizedExistingStatus = models.PendingOrderStatusDraft
+	}
+
+	isAdmin := userHasAnyRole(currentUser, RoleAdmin)
+
+	switch strings.TrimSpace(targetStatus) {
+	case models.PendingOrderStatusProposed:
+		if !(userHasAnyRole(currentUser, RoleThuKho) || isAdmin) {
+			return &pendingOrderTransitionError{status: http.StatusForbidden, message: "Only Thu kho (or Admin) can propose pending orders for approval"}
+		}
+		if normalizedExistingStatus != models.PendingOrderStatusDraft && normalizedExistingStatus != models.PendingOrderStatusRejected {
+			return &pendingOrderTransitionError{status: http.StatusConflict, message: "Only draft or rejected pending orders can be proposed"}
+		}
+		return nil
+
+	case models.PendingOrderStatusApproved:
+		if !(userHasAnyRole(currentUser, RoleChiHuyKhoa) || isAdmin) {
+			return &pendingOrderTransitionError{status: http.StatusForbidden, message: "Only Chi huy khoa (or Admin) can approve pending orders"}
+		}
+		if normalizedExistingStatus != models.PendingOrderStatusProposed {
+			return &pendingOrderTransitionError{status: http.StatusConflict, message: "Only proposed pending orders can be approved"}
+		}
+		return nil
+
+	case models.PendingOrderStatusRejected:
+		if !(userHasAnyRole(currentUser, RoleChiHuyKhoa) || isAdmin) {
+			return &pendingOrderTransitionError{status: http.StatusForbidden, message: "Only Chi huy khoa (or Admin) can reject pending orders"}
+		}
+		if normalizedExistingStatus != models.PendingOrderStatusProposed {
+			return &pendingOrderTransitionError{status: http.StatusConflict, message: "Only proposed pending orders can be rejected"}
+		}
+		if strings.TrimSpace(reason) == "" {
+			return &pendingOrderTransitionError{status: http.StatusBadRequest, message: "reason is required when rejecting pending orders"}
+		}
+		return nil
+
+	default:
+		return &pendingOrderTransitionError{status: http.StatusBadRequest, message: "status is invalid"}
+	}
+}
+
+func writePendingOrderTransitionError(c *gin.Context, err error) {
+	if transitionErr, ok := err.(*pendingOrderTransitionError); ok {
+		errorCode := "INVALID_REQUEST"
+		switch transitionErr.status {
+		case http.StatusForbidden:
+			errorCode = "FORBIDDEN"
+		case http.StatusConflict:
+			errorCode = "INVALID_STATUS"
+		}
+
+		c.JSON(transitionErr.status, ErrorResponse{Error: errorCode, Message: transitionErr.message})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: err.Error()})
+}
+
+func writePendingOrderRepositoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrPendingOrderBatchNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Pending order batch not found"})
+	case errors.Is(err, models.ErrPendingOrderStatusConflict):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "INVALID_STATUS", Message: err.Error()})
+	case errors.Is(err, models.ErrPendingOrdersNotApproved):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "NOT_APPROVED", Message: "Only approved pending orders can be placed"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+	}
+}
+
+func uniqueOrderIDs(orderIDs []int64) []int64 {
+	seen := make(map[int64]struct{}, len(orderIDs))
+	result := make([]int64, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		if orderID <= 0 {
+			continue
+		}
+		if _, exists := seen[orderID]; exists {
+			continue
+		}
+		seen[orderID] = struct{}{}
+		result = append(result, orderID)
+	}
+	return result
+}
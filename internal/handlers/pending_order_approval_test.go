@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+func TestValidatePendingOrderTransition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		target     string
+		role       string
+		existing   string
+		reason     string
+		wantStatus int
+	}{
+		{name: "thu kho proposes draft", target: models.PendingOrderStatusProposed, role: RoleThuKho, existing: models.PendingOrderStatusDraft},
+		{name: "thu kho re-proposes rejected", target: models.PendingOrderStatusProposed, role: RoleThuKho, existing: models.PendingOrderStatusRejected},
+		{name: "legacy empty status is draft", target: models.PendingOrderStatusProposed, role: RoleThuKho, existing: ""},
+		{name: "chi huy khoa cannot propose", target: models.PendingOrderStatusProposed, role: RoleChiHuyKhoa, existing: models.PendingOrderStatusDraft, wantStatus: http.StatusForbidden},
+		{name: "approved cannot be proposed", target: models.PendingOrderStatusProposed, role: RoleThuKho, existing: models.PendingOrderStatusApproved, wantStatus: http.StatusConflict},
+		{name: "chi huy khoa approves proposed", target: models.PendingOrderStatusApproved, role: RoleChiHuyKhoa, existing: models.PendingOrderStatusProposed},
+		{name: "thu kho cannot approve", target: models.PendingOrderStatusApproved, role: RoleThuKho, existing: models.PendingOrderStatusProposed, wantStatus: http.StatusForbidden},
+		{name: "draft cannot be approved", target: models.PendingOrderStatusApproved, role: RoleChiHuyKhoa, existing: models.PendingOrderStatusDraft, wantStatus: http.StatusConflict},
+		{name: "admin rejects with reason", target: models.PendingOrderStatusRejected, role: RoleAdmin, existing: models.PendingOrderStatusProposed, reason: "Sai số lượng"},
+		{name: "reject requires reason", target: models.PendingOrderStatusRejected, role: RoleChiHuyKhoa, existing: models.PendingOrderStatusProposed, reason: " ", wantStatus: http.StatusBadRequest},
+		{name: "unknown target", target: "placed", role: RoleAdmin, existing: models.PendingOrderStatusApproved, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validatePendingOrderTransition(tc.target, &models.UserProfile{ID: 1, Role: tc.role}, tc.existing, tc.reason)
+			if tc.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("validatePendingOrderTransition() error = %v", err)
+				}
+				return
+			}
+
+			transitionErr, ok := err.(*pendingOrderTransitionError)
+			if !ok {
+				t.Fatalf("validatePendingOrderTransition() error = %v, want transition error", err)
+			}
+			if transitionErr.status != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", transitionErr.status, tc.wantStatus)
+			}
+		})
+	}
+}
@@ -1,312 +1,112 @@
 package handlers
 
 import (
-	"bytes"
-	"fmt"
-	"log"
+	"context"
 	"net/http"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"runtime"
 	"strings"
 	"time"
 
 	"bv108-consumables-management-backend/internal/models"
 	"bv108-consumables-management-backend/internal/realtime"
+	"bv108-consumables-management-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-const ubotAPIDirEnvKey = "UBOT_API_DIR"
-
 type RefreshHandler struct {
-	hoaDonRepo interface{ GetCount() (int, error) }
-	userRepo   *models.UserRepository
-	jwtSecret  []byte
-	hub        *realtime.Hub
+	ubotSync *services.UBotInvoiceSync
+	jobs     *services.JobRunner
+	hub      *realtime.Hub
 }
 
-func NewRefreshHandler(repo interface{ GetCount() (int, error) }, userRepo *models.UserRepository, jwtSecret string, hub *realtime.Hub) *RefreshHandler {
+func NewRefreshHandler(ubotSync *services.UBotInvoiceSync, jobs *services.JobRunner, hub *realtime.Hub) *RefreshHandler {
 	return &RefreshHandler{
-		hoaDonRepo: repo,
-		userRepo:   userRepo,
-		jwtSecret:  []byte(jwtSecret),
-		hub:        hub,
-	}
-}
-
-func parseCommandSpec(spec string) (string, []string) {
-	trimmed := strings.TrimSpace(spec)
-	if trimmed == "" {
-		return "", nil
-	}
-
-	unquoted := strings.Trim(trimmed, "\"")
-	if _, err := os.Stat(unquoted); err == nil {
-		return unquoted, nil
-	}
-
-	parts := strings.Fields(trimmed)
-	if len(parts) == 0 {
-		return "", nil
-	}
-	parts[0] = strings.Trim(parts[0], "\"")
-	return parts[0], parts[1:]
-}
-
-func commandExists(command string) bool {
-	if command == "" {
-		return false
-	}
-
-	if strings.ContainsAny(command, `/\`) {
-		_, err := os.Stat(command)
-		return err == nil
-	}
-
-	_, err := exec.LookPath(command)
-	return err == nil
-}
-
-func pythonRuntimeUsable(command string, args []string) bool {
-	probeArgs := append(append([]string{}, args...), "--version")
-	probeCmd := exec.Command(command, probeArgs...)
-
-	var outBuf, errBuf bytes.Buffer
-	probeCmd.Stdout = &outBuf
-	probeCmd.Stderr = &errBuf
-
-	if err := probeCmd.Run(); err != nil {
-		return false
+		ubotSync: ubotSync,
+		jobs:     jobs,
+		hub:      hub,
 	}
-
-	output := strings.ToLower(outBuf.String() + "\n" + errBuf.String())
-	return strings.Contains(output, "python")
 }
 
-func resolvePythonRuntime(ubotDir string) (string, []string, []string) {
-	type candidate struct {
-		label string
-		spec  string
+// RefreshInvoices starts a background UBot import and answers 202 with the
+// job; progress is streamed as jobs.progress events. fromDate and toDate are
+// optional YYYY-MM-DD bounds; without fromDate the refresh resumes from the
+// newest stored invoice.
+func (h *RefreshHandler) RefreshInvoices(c *gin.Context) {
+	currentUser, ok := h.authorizeInvoiceRefresh(c)
+	if !ok {
+		return
 	}
 
-	candidates := make([]candidate, 0, 8)
-
-	if envSpec := strings.TrimSpace(os.Getenv("PYTHON_PATH")); envSpec != "" {
-		candidates = append(candidates, candidate{
-			label: "PYTHON_PATH",
-			spec:  envSpec,
-		})
+	from, ok := parseInvoiceRefreshDate(c, "fromDate")
+	if !ok {
+		return
 	}
-
-	candidates = append(candidates,
-		candidate{label: "venv-windows", spec: filepath.Join(ubotDir, ".venv", "Scripts", "python.exe")},
-		candidate{label: "venv-linux", spec: filepath.Join(ubotDir, ".venv", "bin", "python")},
-	)
-
-	if runtime.GOOS == "windows" {
-		candidates = append(candidates,
-			candidate{label: "py -3", spec: "py -3"},
-			candidate{label: "py", spec: "py"},
-			candidate{label: "python3", spec: "python3"},
-			candidate{label: "python", spec: "python"},
-		)
-	} else {
-		candidates = append(candidates,
-			candidate{label: "python3", spec: "python3"},
-			candidate{label: "python", spec: "python"},
-			candidate{label: "py -3", spec: "py -3"},
-			candidate{label: "py", spec: "py"},
-		)
+	to, ok := parseInvoiceRefreshDate(c, "toDate")
+	if !ok {
+		return
 	}
-
-	tried := make([]string, 0, len(candidates))
-	for _, item := range candidates {
-		cmd, args := parseCommandSpec(item.spec)
-		if cmd == "" {
-			continue
-		}
-
-		tried = append(tried, fmt.Sprintf("%s => %s", item.label, item.spec))
-		if commandExists(cmd) && pythonRuntimeUsable(cmd, args) {
-			return cmd, args, tried
-		}
+	if from != nil && to != nil && from.After(*to) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "fromDate must not be after toDate"})
+		return
 	}
 
-	return "", nil, tried
-}
-
-type ubotDirCandidate struct {
-	label string
-	path  string
-}
-
-func resolveExistingDirectory(candidates []ubotDirCandidate) (string, []string, error) {
-	tried := make([]string, 0, len(candidates))
-	seen := make(map[string]struct{}, len(candidates))
-
-	for _, candidate := range candidates {
-		trimmedPath := strings.TrimSpace(candidate.path)
-		if trimmedPath == "" {
-			continue
+	job, err := h.jobs.Start(models.BackgroundJobKindInvoiceRefresh, jobCreatorID(currentUser), func(ctx context.Context, report services.JobProgressFunc) (interface{}, error) {
+		result, err := h.ubotSync.Run(ctx, from, to, report)
+		if err != nil {
+			return nil, err
 		}
-
-		cleanPath := filepath.Clean(trimmedPath)
-		if _, exists := seen[cleanPath]; exists {
-			continue
+		if h.hub != nil {
+			h.hub.Broadcast("invoices.data_refreshed", gin.H{
+				"total":       result.Total,
+				"inserted":    result.Inserted,
+				"updated":     result.Updated,
+				"refreshedAt": time.Now().UTC().Format(time.RFC3339),
+			})
 		}
-		seen[cleanPath] = struct{}{}
-		tried = append(tried, fmt.Sprintf("%s => %s", candidate.label, cleanPath))
-
-		info, err := os.Stat(cleanPath)
-		if err == nil && info.IsDir() {
-			return cleanPath, tried, nil
-		}
-	}
-
-	return "", tried, fmt.Errorf("no existing directory found")
-}
-
-func resolveUBotDir() (string, []string, error) {
-	candidates := make([]ubotDirCandidate, 0, 6)
-
-	if envPath := strings.TrimSpace(os.Getenv(ubotAPIDirEnvKey)); envPath != "" {
-		candidates = append(candidates, ubotDirCandidate{
-			label: ubotAPIDirEnvKey,
-			path:  envPath,
-		})
-	}
-
-	if cwd, err := os.Getwd(); err == nil {
-		candidates = append(candidates, ubotDirCandidate{
-			label: "cwd/ubot-api",
-			path:  filepath.Join(cwd, "ubot-api"),
-		})
-	}
-
-	if executablePath, err := os.Executable(); err == nil {
-		executableDir := filepath.Dir(executablePath)
-		candidates = append(candidates,
-			ubotDirCandidate{
-				label: "exe-dir/ubot-api",
-				path:  filepath.Join(executableDir, "ubot-api"),
-			},
-			ubotDirCandidate{
-				label: "exe-parent/ubot-api",
-				path:  filepath.Join(executableDir, "..", "ubot-api"),
-			},
-		)
-	}
-
-	if _, sourceFile, _, ok := runtime.Caller(0); ok {
-		backendRoot := filepath.Join(filepath.Dir(sourceFile), "..", "..")
-		candidates = append(candidates, ubotDirCandidate{
-			label: "source-tree/ubot-api",
-			path:  filepath.Join(backendRoot, "ubot-api"),
-		})
-	}
-
-	return resolveExistingDirectory(candidates)
+		return result, nil
+	})
+	respondJobStarted(c, job, err, "Invoice refresh started")
 }
 
-func (h *RefreshHandler) RefreshInvoices(c *gin.Context) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
-		return
-	}
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa, RoleNhanVienKeToan) {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error:   "FORBIDDEN",
-			Message: "Only Admin, Chi huy khoa or Nhan vien ke toan can refresh invoices",
-		})
+// GetInvoiceRefreshStatus returns the running or last finished refresh job so
+// a client that missed the realtime events can catch up.
+func (h *RefreshHandler) GetInvoiceRefreshStatus(c *gin.Context) {
+	if _, ok := h.authorizeInvoiceRefresh(c); !ok {
 		return
 	}
 
-	ubotDir, triedDirs, err := resolveUBotDir()
+	job, err := h.jobs.Latest(models.BackgroundJobKindInvoiceRefresh)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "UBot script directory not found",
-			"details": fmt.Sprintf("Set %s or start the backend from a directory layout that includes ubot-api", ubotAPIDirEnvKey),
-			"tried":   triedDirs,
-		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
 
-	pythonCmd, pythonBaseArgs, tried := resolvePythonRuntime(ubotDir)
-	if pythonCmd == "" {
-		hint := "Install Python 3 and set PYTHON_PATH (e.g. PYTHON_PATH=python3)."
-		if runtime.GOOS == "windows" {
-			hint = "Install Python 3 and set PYTHON_PATH=py -3 or PYTHON_PATH=<path-to-python.exe>."
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Python runtime not found",
-			"details": hint,
-			"tried":   tried,
-		})
-		return
+func (h *RefreshHandler) authorizeInvoiceRefresh(c *gin.Context) (*models.UserProfile, bool) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return nil, false
 	}
-
-	// Step 1: Export - Dùng auto_export.py không cần input
-	log.Println("[invoice-refresh] crawling invoices")
-	exportArgs := append(append([]string{}, pythonBaseArgs...), "auto_export.py")
-	exportCmd := exec.Command(pythonCmd, exportArgs...)
-	exportCmd.Dir = ubotDir
-
-	var outBuf, errBuf bytes.Buffer
-	exportCmd.Stdout = &outBuf
-	exportCmd.Stderr = &errBuf
-
-	if err := exportCmd.Run(); err != nil {
-		output := outBuf.String() + "\n" + errBuf.String()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Export script failed",
-			"details": err.Error(),
-			"output":  output,
-		})
-		return
+	if !h.ubotSync.IsConfigured() {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UBOT_NOT_CONFIGURED", Message: "UBOT_USERNAME and UBOT_PASSWORD are not configured"})
+		return nil, false
 	}
-
-	// Step 2: Import
-	log.Println("[invoice-refresh] importing invoices into database")
-	importArgs := append(append([]string{}, pythonBaseArgs...), "import_csv_to_db.py", "invoices_export.csv", "true")
-	importCmd := exec.Command(pythonCmd, importArgs...)
-	importCmd.Dir = ubotDir
-
-	var importOut, importErr bytes.Buffer
-	importCmd.Stdout = &importOut
-	importCmd.Stderr = &importErr
-
-	if err := importCmd.Run(); err != nil {
-		output := importOut.String() + "\n" + importErr.String()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Import failed",
-			"details": err.Error(),
-			"output":  output,
-		})
-		return
+	if !requireJobRunner(c, h.jobs) {
+		return nil, false
 	}
+	return currentUser, true
+}
 
-	// Get total count
-	total, err := h.hoaDonRepo.GetCount()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get count",
-		})
-		return
+func parseInvoiceRefreshDate(c *gin.Context, name string) (*time.Time, bool) {
+	raw := strings.TrimSpace(c.Query(name))
+	if raw == "" {
+		return nil, true
 	}
-
-	log.Println("[invoice-refresh] completed successfully")
-	if h.hub != nil {
-		h.hub.Broadcast("invoices.data_refreshed", gin.H{
-			"total":       total,
-			"refreshedAt": time.Now().UTC().Format(time.RFC3339),
-		})
+	value, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: name + " must use the YYYY-MM-DD format"})
+		return nil, false
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Invoices refreshed successfully",
-		"total":   total,
-	})
+	return &value, true
 }
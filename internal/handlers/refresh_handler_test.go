@@ -1,61 +1,77 @@
 package handlers
 
 import (
-	"path/filepath"
+	"net/http"
+	"net/http/httptest"
 	"testing"
-)
 
-func TestResolveExistingDirectoryReturnsFirstExistingUniquePath(t *testing.T) {
-	t.Parallel()
+	"github.com/gin-gonic/gin"
+)
 
-	firstDir := t.TempDir()
-	secondDir := t.TempDir()
+func TestInvoiceRefreshEndpointsRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
 
-	resolved, tried, err := resolveExistingDirectory([]ubotDirCandidate{
-		{label: "missing", path: filepath.Join(firstDir, "does-not-exist")},
-		{label: "first", path: firstDir},
-		{label: "duplicate-first", path: firstDir},
-		{label: "second", path: secondDir},
-	})
-	if err != nil {
-		t.Fatalf("resolveExistingDirectory returned unexpected error: %v", err)
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(*RefreshHandler, *gin.Context)
+	}{
+		{name: "start", method: http.MethodPost, path: "/api/hoa-don/refresh", handler: (*RefreshHandler).RefreshInvoices},
+		{name: "status", method: http.MethodGet, path: "/api/hoa-don/refresh/status", handler: (*RefreshHandler).GetInvoiceRefreshStatus},
 	}
 
-	if resolved != filepath.Clean(firstDir) {
-		t.Fatalf("resolveExistingDirectory resolved %q, want %q", resolved, filepath.Clean(firstDir))
-	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(tc.method, tc.path, nil)
 
-	if len(tried) != 2 {
-		t.Fatalf("resolveExistingDirectory tried %d paths, want 2 unique paths", len(tried))
-	}
-}
+			tc.handler(&RefreshHandler{}, ctx)
 
-func TestResolveExistingDirectoryReturnsErrorWhenNoCandidateExists(t *testing.T) {
-	t.Parallel()
-
-	missingDir := filepath.Join(t.TempDir(), "missing")
-	_, tried, err := resolveExistingDirectory([]ubotDirCandidate{
-		{label: "missing", path: missingDir},
-	})
-	if err == nil {
-		t.Fatal("resolveExistingDirectory returned nil error, want error")
-	}
-
-	if len(tried) != 1 {
-		t.Fatalf("resolveExistingDirectory tried %d paths, want 1", len(tried))
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("%s status = %d, want %d", tc.name, recorder.Code, http.StatusUnauthorized)
+			}
+		})
 	}
 }
 
-func TestResolveUBotDirPrefersEnvOverride(t *testing.T) {
-	customDir := t.TempDir()
-	t.Setenv(ubotAPIDirEnvKey, customDir)
+func TestParseInvoiceRefreshDate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
 
-	resolved, _, err := resolveUBotDir()
-	if err != nil {
-		t.Fatalf("resolveUBotDir returned unexpected error: %v", err)
+	testCases := []struct {
+		name     string
+		query    string
+		wantOK   bool
+		wantDate string
+	}{
+		{name: "missing", query: "", wantOK: true},
+		{name: "valid", query: "?fromDate=2026-10-01", wantOK: true, wantDate: "2026-10-01"},
+		{name: "wrong format", query: "?fromDate=01/10/2026", wantOK: false},
 	}
 
-	if resolved != filepath.Clean(customDir) {
-		t.Fatalf("resolveUBotDir resolved %q, want %q", resolved, filepath.Clean(customDir))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/hoa-don/refresh"+tc.query, nil)
+
+			value, ok := parseInvoiceRefreshDate(ctx, "fromDate")
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.wantOK)
+			}
+			if !ok {
+				if recorder.Code != http.StatusBadRequest {
+					t.Fatalf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+				}
+				return
+			}
+			if tc.wantDate == "" && value != nil {
+				t.Fatalf("value = %v, want nil", value)
+			}
+			if tc.wantDate != "" && (value == nil || value.Format("2006-01-02") != tc.wantDate) {
+				t.Fatalf("value = %v, want %s", value, tc.wantDate)
+			}
+		})
 	}
 }
@@ -3,32 +3,23 @@ package handlers
 import (
 	"net/http"
 
-	"bv108-consumables-management-backend/internal/models"
 	"bv108-consumables-management-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ReportHandler struct {
-	userRepo    *models.UserRepository
-	jwtSecret   []byte
 	geminiProxy *services.GeminiProxyService
 }
 
-func NewReportHandler(userRepo *models.UserRepository, jwtSecret string, geminiProxy *services.GeminiProxyService) *ReportHandler {
+func NewReportHandler(geminiProxy *services.GeminiProxyService) *ReportHandler {
 	return &ReportHandler{
-		userRepo:    userRepo,
-		jwtSecret:   []byte(jwtSecret),
 		geminiProxy: geminiProxy,
 	}
 }
 
 func (h *ReportHandler) requireAuthenticatedUser(c *gin.Context) bool {
-	if _, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret); err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "UNAUTHORIZED",
-			Message: err.Error(),
-		})
+	if _, ok := requireCurrentUser(c); !ok {
 		return false
 	}
 
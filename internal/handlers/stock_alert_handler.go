@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxStockAlertSnoozeHours = 24 * 30
+
+type StockAlertHandler struct {
+	repo     *models.StockAlertRepository
+	taskRepo *models.SupplyTaskRepository
+}
+
+type SnoozeStockAlertRequest struct {
+	Hours int `json:"hours"`
+}
+
+func NewStockAlertHandler(repo *models.StockAlertRepository, taskRepo *models.SupplyTaskRepository) *StockAlertHandler {
+	return &StockAlertHandler{
+		repo:     repo,
+		taskRepo: taskRepo,
+	}
+}
+
+// ListStockAlerts returns reorder-point alerts of the supplies the requester
+// can see. status takes a comma-separated list and defaults to the active
+// states.
+func (h *StockAlertHandler) ListStockAlerts(c *gin.Context) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	visibleIDX1, ok := visibleSupplyIDX1ForUser(c, h.taskRepo, currentUser)
+	if !ok {
+		return
+	}
+
+	statuses := models.ActiveStockAlertStatuses
+	if raw := strings.TrimSpace(c.Query("status")); raw != "" {
+		statuses = make([]string, 0)
+		for _, status := range strings.Split(raw, ",") {
+			status = strings.ToLower(strings.TrimSpace(status))
+			if !isStockAlertStatus(status) {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "status must be open, acknowledged, snoozed or resolved"})
+				return
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	alerts, err := h.repo.ListAlerts(models.StockAlertFilter{Statuses: statuses, VisibleIDX1: visibleIDX1})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": alerts, "total": len(alerts)})
+}
+
+func (h *StockAlertHandler) AcknowledgeStockAlert(c *gin.Context) {
+	currentUser, alert, ok := h.loadActionableAlert(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.AcknowledgeAlert(alert.ID, currentUser.ID, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	h.respondAlert(c, alert.ID)
+}
+
+func (h *StockAlertHandler) SnoozeStockAlert(c *gin.Context) {
+	currentUser, alert, ok := h.loadActionableAlert(c)
+	if !ok {
+		return
+	}
+
+	var req SnoozeStockAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "Invalid snooze payload"})
+		return
+	}
+	if req.Hours < 1 || req.Hours > maxStockAlertSnoozeHours {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "hours must be between 1 and " + strconv.Itoa(maxStockAlertSnoozeHours),
+		})
+		return
+	}
+
+	now := time.Now()
+	if err := h.repo.SnoozeAlert(alert.ID, currentUser.ID, now.Add(time.Duration(req.Hours)*time.Hour), now); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	h.respondAlert(c, alert.ID)
+}
+
+// loadActionableAlert authenticates the requester and loads the alert named
+// in the path, answering 404 for alerts of supplies hidden from them and 409
+// for alerts that are already resolved.
+func (h *StockAlertHandler) loadActionableAlert(c *gin.Context) (*models.UserProfile, *models.StockAlert, bool) {
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	alertID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || alertID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "id must be a positive integer"})
+		return nil, nil, false
+	}
+
+	visibleIDX1, ok := visibleSupplyIDX1ForUser(c, h.taskRepo, currentUser)
+	if !ok {
+		return nil, nil, false
+	}
+
+	alert, err := h.repo.GetAlert(alertID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return nil, nil, false
+	}
+	if !ensureStockAlertActionable(c, alert, visibleIDX1) {
+		return nil, nil, false
+	}
+	return currentUser, alert, true
+}
+
+// ensureStockAlertActionable writes the 404 or 409 response when alert is
+// missing, belongs to a supply outside visibleIDX1, or is already resolved.
+func ensureStockAlertActionable(c *gin.Context, alert *models.StockAlert, visibleIDX1 []int) bool {
+	if alert == nil || (visibleIDX1 != nil && !containsInt(visibleIDX1, alert.SupplyIDX1)) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Stock alert not found"})
+		return false
+	}
+	if alert.Status == models.StockAlertStatusResolved {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "ALERT_RESOLVED", Message: "Stock alert is already resolved"})
+		return false
+	}
+	return true
+}
+
+func (h *StockAlertHandler) respondAlert(c *gin.Context, alertID int64) {
+	alert, err := h.repo.GetAlert(alertID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": alert})
+}
+
+func isStockAlertStatus(status string) bool {
+	switch status {
+	case models.StockAlertStatusOpen, models.StockAlertStatusAcknowledged, models.StockAlertStatusSnoozed, models.StockAlertStatusResolved:
+		return true
+	default:
+		return false
+	}
+}
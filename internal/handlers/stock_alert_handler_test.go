@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEnsureStockAlertActionable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name        string
+		alert       *models.StockAlert
+		visibleIDX1 []int
+		wantOK      bool
+		wantStatus  int
+	}{
+		{name: "missing alert", wantStatus: http.StatusNotFound},
+		{
+			name:        "alert of a hidden supply",
+			alert:       &models.StockAlert{ID: 1, SupplyIDX1: 7, Status: models.StockAlertStatusOpen},
+			visibleIDX1: []int{3, 5},
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			name:        "resolved alert",
+			alert:       &models.StockAlert{ID: 1, SupplyIDX1: 7, Status: models.StockAlertStatusResolved},
+			visibleIDX1: []int{7},
+			wantStatus:  http.StatusConflict,
+		},
+		{
+			name:        "open alert of a visible supply",
+			alert:       &models.StockAlert{ID: 1, SupplyIDX1: 7, Status: models.StockAlertStatusOpen},
+			visibleIDX1: []int{7},
+			wantOK:      true,
+		},
+		{
+			name:   "snoozed alert without visibility limits",
+			alert:  &models.StockAlert{ID: 1, SupplyIDX1: 7, Status: models.StockAlertStatusSnoozed},
+			wantOK: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+
+			ok := ensureStockAlertActionable(ctx, tc.alert, tc.visibleIDX1)
+
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.wantOK)
+			}
+			if !ok && recorder.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestListStockAlertsRejectsUnknownStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/stock-alerts?status=open,closed", nil)
+	ctx.Set(currentUserContextKey, &models.UserProfile{ID: 1, Username: "Admin", Role: RoleAdmin})
+
+	(&StockAlertHandler{}).ListStockAlerts(ctx)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+}
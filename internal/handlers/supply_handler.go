@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"bv108-consumables-management-backend/internal/models"
+	"bv108-consumables-management-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
@@ -16,9 +18,10 @@ import (
 
 type SupplyHandler struct {
 	repo      *models.SupplyRepository
-	userRepo  *models.UserRepository
 	taskRepo  *models.SupplyTaskRepository
-	jwtSecret []byte
+	stockRepo *models.SupplyStockSnapshotRepository
+	orderRepo *models.OrderRepository
+	jobs      *services.JobRunner
 }
 
 const (
@@ -63,33 +66,37 @@ var compareSupplyExcelHeaders = []string{
 // NewSupplyHandler creates a new supply handler
 func NewSupplyHandler(
 	repo *models.SupplyRepository,
-	userRepo *models.UserRepository,
 	taskRepo *models.SupplyTaskRepository,
-	jwtSecret string,
+	stockRepo *models.SupplyStockSnapshotRepository,
+	orderRepo *models.OrderRepository,
+	jobs *services.JobRunner,
 ) *SupplyHandler {
 	return &SupplyHandler{
 		repo:      repo,
-		userRepo:  userRepo,
 		taskRepo:  taskRepo,
-		jwtSecret: []byte(jwtSecret),
+		stockRepo: stockRepo,
+		orderRepo: orderRepo,
+		jobs:      jobs,
 	}
 }
 
 func (h *SupplyHandler) getVisibleSupplyIDX1ForRequester(c *gin.Context) ([]int, bool) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "UNAUTHORIZED",
-			Message: "Yêu cầu đăng nhập hợp lệ",
-		})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return nil, false
 	}
 
+	return visibleSupplyIDX1ForUser(c, h.taskRepo, currentUser)
+}
+
+// visibleSupplyIDX1ForUser returns the supplies currentUser may see, or nil
+// when nothing is hidden from them.
+func visibleSupplyIDX1ForUser(c *gin.Context, taskRepo *models.SupplyTaskRepository, currentUser *models.UserProfile) ([]int, bool) {
 	if userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa) {
 		return nil, true
 	}
 
-	hideForOtherRoles, err := h.taskRepo.IsHideForOtherRolesEnabled()
+	hideForOtherRoles, err := taskRepo.IsHideForOtherRolesEnabled()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "DATABASE_ERROR",
@@ -106,7 +113,7 @@ func (h *SupplyHandler) getVisibleSupplyIDX1ForRequester(c *gin.Context) ([]int,
 		return nil, true
 	}
 
-	visibleIDX1, err := h.taskRepo.GetAssignedSupplyIDX1ByUserID(currentUser.ID)
+	visibleIDX1, err := taskRepo.GetAssignedSupplyIDX1ByUserID(currentUser.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "DATABASE_ERROR",
@@ -128,11 +135,7 @@ func shouldRestrictSupplyVisibilityByAssignment(role string) bool {
 }
 
 func (h *SupplyHandler) requireAuthenticatedRequester(c *gin.Context) bool {
-	if _, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret); err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "UNAUTHORIZED",
-			Message: "Yêu cầu đăng nhập hợp lệ",
-		})
+	if _, ok := requireCurrentUser(c); !ok {
 		return false
 	}
 
@@ -140,12 +143,8 @@ func (h *SupplyHandler) requireAuthenticatedRequester(c *gin.Context) bool {
 }
 
 func (h *SupplyHandler) getAuthenticatedRequester(c *gin.Context) (*models.UserProfile, bool) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "UNAUTHORIZED",
-			Message: "Yêu cầu đăng nhập hợp lệ",
-		})
+	currentUser, ok := requireCurrentUser(c)
+	if !ok {
 		return nil, false
 	}
 
@@ -554,14 +553,6 @@ func (h *SupplyHandler) ImportCompareCatalogExcel(c *gin.Context) {
 		return
 	}
 
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa) {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error:   "FORBIDDEN",
-			Message: "Chỉ Admin hoặc Chỉ huy khoa mới có quyền import thay thế dữ liệu so sánh",
-		})
-		return
-	}
-
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -768,18 +759,28 @@ func (h *SupplyHandler) ImportCompareCatalogExcel(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.ReplaceAllCompareSupplies(inputs); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "DATABASE_ERROR",
-			Message: err.Error(),
-		})
+	if !requireJobRunner(c, h.jobs) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Import dữ liệu so sánh thành công",
-		"count":   len(inputs),
+	// The workbook is validated above so template errors still come back on
+	// the request; only the table replacement runs as a job.
+	job, err := h.jobs.Start(models.BackgroundJobKindCompareCatalogImport, jobCreatorID(currentUser), func(_ context.Context, report services.JobProgressFunc) (interface{}, error) {
+		report(models.BackgroundJobProgress{Stage: "import", Total: len(inputs), Message: "Đang thay thế dữ liệu so sánh"})
+		if err := h.repo.ReplaceAllCompareSupplies(inputs); err != nil {
+			return nil, err
+		}
+		return gin.H{"count": len(inputs)}, nil
 	})
+	if err == nil {
+		setAuditChange(c, AuditChange{
+			Action:     "supplies.catalog_imported",
+			EntityType: "compare_catalog",
+			EntityID:   strconv.FormatInt(job.ID, 10),
+			After:      gin.H{"fileName": fileHeader.Filename, "rowCount": len(inputs)},
+		})
+	}
+	respondJobStarted(c, job, err, "Đã bắt đầu import dữ liệu so sánh")
 }
 
 // GetCompareCatalog returns paginated rows from so_sanh_vat_tu for selection list.
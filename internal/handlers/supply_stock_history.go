@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultStockHistoryDays = 90
+
+// GetSupplyStockHistory godoc
+// @Summary Get supply stock history
+// @Description Daily stock snapshots of one supply and its consumption rate
+// @Tags supplies
+// @Param id path int true "Supply IDX1"
+// @Param from query string false "First day (YYYY-MM-DD), default 90 days before to"
+// @Param to query string false "Last day (YYYY-MM-DD), default today"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/supplies/{id}/stock-history [get]
+func (h *SupplyHandler) GetSupplyStockHistory(c *gin.Context) {
+	visibleIDX1, ok := h.getVisibleSupplyIDX1ForRequester(c)
+	if !ok {
+		return
+	}
+	if h.stockRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "UNAVAILABLE", Message: "Supply stock history is not configured"})
+		return
+	}
+
+	idx1, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_ID",
+			Message: "Invalid supply ID",
+		})
+		return
+	}
+	// Restricted roles only see assigned supplies; history of a supply that
+	// has since left the catalog stays visible to everyone else.
+	if visibleIDX1 != nil && !containsInt(visibleIDX1, idx1) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "NOT_FOUND",
+			Message: "Supply not found",
+		})
+		return
+	}
+
+	to := time.Now()
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "to must use the YYYY-MM-DD format"})
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -defaultStockHistoryDays)
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "from must use the YYYY-MM-DD format"})
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_REQUEST", Message: "from must not be after to"})
+		return
+	}
+
+	snapshots, err := h.stockRepo.ListSnapshots(idx1, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "DATABASE_ERROR",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"idx1":        idx1,
+		"from":        from.Format("2006-01-02"),
+		"to":          to.Format("2006-01-02"),
+		"data":        snapshots,
+		"consumption": models.SummarizeSupplyConsumption(snapshots),
+	})
+}
+
+func containsInt(values []int, target int) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetSupplyStockHistoryRejectsInvalidRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		id        string
+		query     string
+		wantError string
+	}{
+		{name: "non-numeric IDX1", id: "abc", wantError: "INVALID_ID"},
+		{name: "invalid to", id: "7", query: "to=01/04/2026", wantError: "INVALID_REQUEST"},
+		{name: "invalid from", id: "7", query: "from=2026-4-1", wantError: "INVALID_REQUEST"},
+		{name: "from after to", id: "7", query: "from=2026-04-02&to=2026-04-01", wantError: "INVALID_REQUEST"},
+	}
+
+	handler := &SupplyHandler{stockRepo: &models.SupplyStockSnapshotRepository{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/supplies/"+tt.id+"/stock-history?"+tt.query, nil)
+			ctx.Params = gin.Params{{Key: "id", Value: tt.id}}
+			ctx.Set(currentUserContextKey, &models.UserProfile{ID: 1, Username: "Admin", Role: RoleAdmin})
+
+			handler.GetSupplyStockHistory(ctx)
+
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d (body = %s)", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+			}
+			var body ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || body.Error != tt.wantError {
+				t.Fatalf("error = %q (%v), want %q", body.Error, err, tt.wantError)
+			}
+		})
+	}
+}
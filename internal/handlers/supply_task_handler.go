@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -19,7 +20,6 @@ type SupplyTaskHandler struct {
 	supplyRepo *models.SupplyRepository
 	taskRepo   *models.SupplyTaskRepository
 	userRepo   *models.UserRepository
-	jwtSecret  []byte
 }
 
 type updateSupplyVisibilityRequest struct {
@@ -71,33 +71,16 @@ func NewSupplyTaskHandler(
 	supplyRepo *models.SupplyRepository,
 	taskRepo *models.SupplyTaskRepository,
 	userRepo *models.UserRepository,
-	jwtSecret string,
 ) *SupplyTaskHandler {
 	return &SupplyTaskHandler{
 		supplyRepo: supplyRepo,
 		taskRepo:   taskRepo,
 		userRepo:   userRepo,
-		jwtSecret:  []byte(jwtSecret),
 	}
 }
 
-func (h *SupplyTaskHandler) authorizeManager(c *gin.Context) (*models.UserProfile, bool) {
-	currentUser, err := getCurrentUserFromAuthorizationHeader(c, h.userRepo, h.jwtSecret)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: "Yêu cầu đăng nhập hợp lệ"})
-		return nil, false
-	}
-
-	if !userHasAnyRole(currentUser, RoleAdmin, RoleChiHuyKhoa) {
-		c.JSON(http.StatusForbidden, ErrorResponse{Error: "FORBIDDEN", Message: "Chỉ Admin hoặc Chỉ huy khoa mới có quyền truy cập tác vụ"})
-		return nil, false
-	}
-
-	return currentUser, true
-}
-
 func (h *SupplyTaskHandler) GetState(c *gin.Context) {
-	if _, ok := h.authorizeManager(c); !ok {
+	if _, ok := requireCurrentUser(c); !ok {
 		return
 	}
 
@@ -157,7 +140,7 @@ func (h *SupplyTaskHandler) GetState(c *gin.Context) {
 }
 
 func (h *SupplyTaskHandler) UpdateVisibility(c *gin.Context) {
-	currentUser, ok := h.authorizeManager(c)
+	currentUser, ok := requireCurrentUser(c)
 	if !ok {
 		return
 	}
@@ -168,16 +151,28 @@ func (h *SupplyTaskHandler) UpdateVisibility(c *gin.Context) {
 		return
 	}
 
+	previous, err := h.taskRepo.IsHideForOtherRolesEnabled()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
 	if err := h.taskRepo.SetHideForOtherRolesEnabled(req.HideForOtherRoles, currentUser.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
 		return
 	}
+	setAuditChange(c, AuditChange{
+		Action:     "supply_tasks.visibility_changed",
+		EntityType: "supply_visibility",
+		Before:     gin.H{"hideForOtherRoles": previous},
+		After:      gin.H{"hideForOtherRoles": req.HideForOtherRoles},
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Cập nhật tùy chọn hiển thị vật tư thành công"})
 }
 
 func (h *SupplyTaskHandler) GetAssignmentsByUser(c *gin.Context) {
-	if _, ok := h.authorizeManager(c); !ok {
+	if _, ok := requireCurrentUser(c); !ok {
 		return
 	}
 
@@ -189,14 +184,14 @@ func (h *SupplyTaskHandler) GetAssignmentsByUser(c *gin.Context) {
 
 	targetUser, err := loadSupplyAssignmentUser(h.userRepo, userID)
 	if err != nil {
-		switch err.Error() {
-		case "user not found":
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Người dùng không tồn tại"})
 			return
-		case "user account is disabled":
+		case errors.Is(err, models.ErrUserDisabled):
 			c.JSON(http.StatusForbidden, ErrorResponse{Error: "ACCOUNT_DISABLED", Message: "Tài khoản đã bị vô hiệu hóa"})
 			return
-		case "user is not eligible for supply assignments":
+		case errors.Is(err, errSupplyAssignmentIneligible):
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_USER", Message: "Chỉ Nhân viên thầu mới được nhận phân công vật tư"})
 			return
 		default:
@@ -220,7 +215,7 @@ func (h *SupplyTaskHandler) GetAssignmentsByUser(c *gin.Context) {
 }
 
 func (h *SupplyTaskHandler) UpdateAssignmentsByUser(c *gin.Context) {
-	currentUser, ok := h.authorizeManager(c)
+	currentUser, ok := requireCurrentUser(c)
 	if !ok {
 		return
 	}
@@ -237,14 +232,14 @@ func (h *SupplyTaskHandler) UpdateAssignmentsByUser(c *gin.Context) {
 	}
 
 	if _, err := loadSupplyAssignmentUser(h.userRepo, req.UserID); err != nil {
-		switch err.Error() {
-		case "user not found":
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "Người dùng không tồn tại"})
 			return
-		case "user account is disabled":
+		case errors.Is(err, models.ErrUserDisabled):
 			c.JSON(http.StatusForbidden, ErrorResponse{Error: "ACCOUNT_DISABLED", Message: "Tài khoản đã bị vô hiệu hóa"})
 			return
-		case "user is not eligible for supply assignments":
+		case errors.Is(err, errSupplyAssignmentIneligible):
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "INVALID_USER", Message: "Chỉ Nhân viên thầu mới được nhận phân công vật tư"})
 			return
 		default:
@@ -267,16 +262,29 @@ func (h *SupplyTaskHandler) UpdateAssignmentsByUser(c *gin.Context) {
 	}
 	sort.Ints(uniqueSupplyIDs)
 
+	previousSupplyIDs, err := h.taskRepo.GetAssignedSupplyIDX1ByUserID(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
+		return
+	}
+
 	if err := h.taskRepo.ReplaceAssignmentsForUser(req.UserID, uniqueSupplyIDs, currentUser.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
 		return
 	}
+	setAuditChange(c, AuditChange{
+		Action:     "supply_tasks.assignments_updated",
+		EntityType: "user",
+		EntityID:   strconv.FormatInt(req.UserID, 10),
+		Before:     gin.H{"supplyIdx1List": previousSupplyIDs},
+		After:      gin.H{"supplyIdx1List": uniqueSupplyIDs},
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Lưu phân công vật tư thành công"})
 }
 
 func (h *SupplyTaskHandler) ExportAssignments(c *gin.Context) {
-	if _, ok := h.authorizeManager(c); !ok {
+	if _, ok := requireCurrentUser(c); !ok {
 		return
 	}
 
@@ -353,7 +361,7 @@ func (h *SupplyTaskHandler) ExportAssignments(c *gin.Context) {
 }
 
 func (h *SupplyTaskHandler) ImportAssignments(c *gin.Context) {
-	currentUser, ok := h.authorizeManager(c)
+	currentUser, ok := requireCurrentUser(c)
 	if !ok {
 		return
 	}
@@ -536,6 +544,16 @@ func (h *SupplyTaskHandler) ImportAssignments(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "DATABASE_ERROR", Message: err.Error()})
 		return
 	}
+	setAuditChange(c, AuditChange{
+		Action:     "supply_tasks.assignments_imported",
+		EntityType: "supply_assignment",
+		After: gin.H{
+			"fileName":      fileHeader.Filename,
+			"assignments":   assignments,
+			"assignedCount": assignedCount,
+			"clearedCount":  clearedCount,
+		},
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "Import phân công vật tư thành công",
@@ -546,7 +564,7 @@ func (h *SupplyTaskHandler) ImportAssignments(c *gin.Context) {
 }
 
 func (h *SupplyTaskHandler) GetSupplyCatalog(c *gin.Context) {
-	if _, ok := h.authorizeManager(c); !ok {
+	if _, ok := requireCurrentUser(c); !ok {
 		return
 	}
 
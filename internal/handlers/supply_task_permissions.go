@@ -1,11 +1,13 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 
 	"bv108-consumables-management-backend/internal/models"
 )
 
+var errSupplyAssignmentIneligible = errors.New("user is not eligible for supply assignments")
+
 func isSupplyAssignmentEligibleRole(role string) bool {
 	return normalizeRoleForPermissions(role) == RoleNhanVienThau
 }
@@ -17,7 +19,7 @@ func loadSupplyAssignmentUser(userRepo *models.UserRepository, userID int64) (*m
 	}
 
 	if !isSupplyAssignmentEligibleRole(user.Role) {
-		return nil, fmt.Errorf("user is not eligible for supply assignments")
+		return nil, errSupplyAssignmentIneligible
 	}
 
 	return user, nil
@@ -9,7 +9,6 @@ import (
 	"bv108-consumables-management-backend/internal/realtime"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 )
 
@@ -53,13 +52,13 @@ func NewWSHandler(userRepo *models.UserRepository, jwtSecret string, hub *realti
 }
 
 func (h *WSHandler) Handle(c *gin.Context) {
-	userID, err := h.getUserIDFromRequest(c)
+	claims, err := h.getTokenClaimsFromRequest(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
 		return
 	}
 
-	if _, err := loadActiveUserByID(h.userRepo, userID); err != nil {
+	if _, err := loadActiveUserForToken(h.userRepo, claims); err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "UNAUTHORIZED", Message: err.Error()})
 		return
 	}
@@ -70,10 +69,10 @@ func (h *WSHandler) Handle(c *gin.Context) {
 		return
 	}
 
-	h.hub.Register(userID, conn)
+	h.hub.Register(claims.UserID, conn)
 }
 
-func (h *WSHandler) getUserIDFromRequest(c *gin.Context) (int64, error) {
+func (h *WSHandler) getTokenClaimsFromRequest(c *gin.Context) (accessTokenClaims, error) {
 	tokenString := ""
 	subprotocols := websocket.Subprotocols(c.Request)
 	if len(subprotocols) >= 2 && strings.EqualFold(strings.TrimSpace(subprotocols[0]), websocketAuthProtocol) {
@@ -87,36 +86,8 @@ func (h *WSHandler) getUserIDFromRequest(c *gin.Context) (int64, error) {
 		}
 	}
 	if tokenString == "" {
-		return 0, fmt.Errorf("missing bearer token")
+		return accessTokenClaims{}, fmt.Errorf("missing bearer token")
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if token.Method.Alg() != "HS256" {
-			return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
-		}
-		return h.jwtSecret, nil
-	})
-	if err != nil {
-		return 0, fmt.Errorf("invalid token: %w", err)
-	}
-	if !token.Valid {
-		return 0, fmt.Errorf("token is not valid")
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return 0, fmt.Errorf("invalid token claims")
-	}
-
-	subValue, exists := claims["sub"]
-	if !exists {
-		return 0, fmt.Errorf("missing subject in token")
-	}
-
-	userID, err := convertClaimToInt64(subValue)
-	if err != nil {
-		return 0, fmt.Errorf("invalid subject in token")
-	}
-
-	return userID, nil
+	return parseAccessToken(tokenString, h.jwtSecret)
 }
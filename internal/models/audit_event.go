@@ -0,0 +1,229 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditEvent is one state-changing request. Rows are only ever inserted; the
+// repository has no update or delete.
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	ActorID    *int64          `json:"actorId,omitempty"`
+	ActorName  string          `json:"actorName"`
+	ActorEmail string          `json:"actorEmail"`
+	ActorRole  string          `json:"actorRole"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entityType"`
+	EntityID   string          `json:"entityId"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	StatusCode int             `json:"statusCode"`
+	IPAddress  string          `json:"ipAddress"`
+	UserAgent  string          `json:"userAgent"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+type AuditEventFilter struct {
+	ActorID    *int64
+	Action     string
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PageSize   int
+}
+
+type AuditEventRepository struct {
+	DB *sql.DB
+}
+
+func NewAuditEventRepository(db *sql.DB) *AuditEventRepository {
+	return &AuditEventRepository{DB: db}
+}
+
+func (r *AuditEventRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS audit_events (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			actor_id BIGINT NULL,
+			actor_name VARCHAR(255) NOT NULL DEFAULT '',
+			actor_email VARCHAR(255) NOT NULL DEFAULT '',
+			actor_role VARCHAR(64) NOT NULL DEFAULT '',
+			action VARCHAR(128) NOT NULL,
+			entity_type VARCHAR(64) NOT NULL DEFAULT '',
+			entity_id VARCHAR(128) NOT NULL DEFAULT '',
+			before_json JSON NULL,
+			after_json JSON NULL,
+			method VARCHAR(10) NOT NULL DEFAULT '',
+			path VARCHAR(255) NOT NULL DEFAULT '',
+			status_code INT NOT NULL DEFAULT 0,
+			ip_address VARCHAR(64) NOT NULL DEFAULT '',
+			user_agent VARCHAR(500) NOT NULL DEFAULT '',
+			created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+			PRIMARY KEY (id),
+			KEY idx_audit_events_created (created_at),
+			KEY idx_audit_events_actor (actor_id, created_at),
+			KEY idx_audit_events_entity (entity_type, entity_id, created_at),
+			KEY idx_audit_events_action (action, created_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`); err != nil {
+		return fmt.Errorf("error ensuring audit event schema: %w", err)
+	}
+	return nil
+}
+
+func (r *AuditEventRepository) Record(event AuditEvent) error {
+	createdAt := event.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	if _, err := r.DB.Exec(`
+		INSERT INTO audit_events (
+			actor_id, actor_name, actor_email, actor_role, action, entity_type, entity_id,
+			before_json, after_json, method, path, status_code, ip_address, user_agent, created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		nullableInt64Value(event.ActorID),
+		truncateColumnText(event.ActorName, 255),
+		truncateColumnText(event.ActorEmail, 255),
+		truncateColumnText(event.ActorRole, 64),
+		truncateColumnText(event.Action, 128),
+		truncateColumnText(event.EntityType, 64),
+		truncateColumnText(event.EntityID, 128),
+		nullableAuditJSON(event.Before),
+		nullableAuditJSON(event.After),
+		truncateColumnText(event.Method, 10),
+		truncateColumnText(event.Path, 255),
+		event.StatusCode,
+		truncateColumnText(event.IPAddress, 64),
+		truncateColumnText(event.UserAgent, 500),
+		createdAt,
+	); err != nil {
+		return fmt.Errorf("error recording audit event: %w", err)
+	}
+	return nil
+}
+
+// List returns one page of events, newest first, and the number of events
+// matching the filter.
+func (r *AuditEventRepository) List(filter AuditEventFilter) ([]AuditEvent, int, error) {
+	where, args := auditEventWhere(filter)
+
+	page := filter.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := filter.PageSize
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	var total int
+	if err := r.DB.QueryRow(`SELECT COUNT(*) FROM audit_events`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("error counting audit events: %w", err)
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT id, actor_id, actor_name, actor_email, actor_role, action, entity_type, entity_id,
+			before_json, after_json, method, path, status_code, ip_address, user_agent, created_at
+		FROM audit_events`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing audit events: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]AuditEvent, 0)
+	for rows.Next() {
+		var event AuditEvent
+		var actorID sql.NullInt64
+		var before, after sql.NullString
+		if err := rows.Scan(
+			&event.ID,
+			&actorID,
+			&event.ActorName,
+			&event.ActorEmail,
+			&event.ActorRole,
+			&event.Action,
+			&event.EntityType,
+			&event.EntityID,
+			&before,
+			&after,
+			&event.Method,
+			&event.Path,
+			&event.StatusCode,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("error scanning audit event: %w", err)
+		}
+		if actorID.Valid {
+			value := actorID.Int64
+			event.ActorID = &value
+		}
+		if before.Valid {
+			event.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			event.After = json.RawMessage(after.String)
+		}
+		items = append(items, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating audit events: %w", err)
+	}
+
+	return items, total, nil
+}
+
+func auditEventWhere(filter AuditEventFilter) (string, []interface{}) {
+	conditions := make([]string, 0, 6)
+	args := make([]interface{}, 0, 6)
+	if filter.ActorID != nil {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, *filter.ActorID)
+	}
+	if action := strings.TrimSpace(filter.Action); action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, action)
+	}
+	if entityType := strings.TrimSpace(filter.EntityType); entityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, entityType)
+	}
+	if entityID := strings.TrimSpace(filter.EntityID); entityID != "" {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, entityID)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, *filter.To)
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func nullableAuditJSON(value json.RawMessage) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
@@ -0,0 +1,255 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	BackgroundJobStatusRunning   = "running"
+	BackgroundJobStatusSucceeded = "succeeded"
+	BackgroundJobStatusFailed    = "failed"
+	BackgroundJobStatusCancelled = "cancelled"
+
+	BackgroundJobKindInvoiceRefresh       = "invoice_refresh"
+	BackgroundJobKindInternalSupplySync   = "internal_supply_sync"
+	BackgroundJobKindVinmesCatalogRefresh = "vinmes_catalog_refresh"
+	BackgroundJobKindCompareCatalogImport = "compare_catalog_import"
+)
+
+const (
+	backgroundJobInterruptedMessage    = "Interrupted by a server restart"
+	backgroundJobStageMaxLength        = 64
+	backgroundJobProgressMaxLength     = 500
+	backgroundJobErrorMessageMaxLength = 2000
+)
+
+// BackgroundJobProgress is the last progress report of a job. Current and
+// Total are in whatever unit the stage counts (pages, rows, catalogs).
+type BackgroundJobProgress struct {
+	Stage   string `json:"stage,omitempty"`
+	Current int    `json:"current"`
+	Total   int    `json:"total"`
+	Message string `json:"message,omitempty"`
+}
+
+type BackgroundJob struct {
+	ID              int64                 `json:"id"`
+	Kind            string                `json:"kind"`
+	Status          string                `json:"status"`
+	Progress        BackgroundJobProgress `json:"progress"`
+	Result          json.RawMessage       `json:"result,omitempty"`
+	ErrorMessage    string                `json:"errorMessage,omitempty"`
+	CreatedByUserID *int64                `json:"createdByUserId,omitempty"`
+	CreatedAt       time.Time             `json:"createdAt"`
+	UpdatedAt       time.Time             `json:"updatedAt"`
+	FinishedAt      *time.Time            `json:"finishedAt,omitempty"`
+}
+
+type BackgroundJobRepository struct {
+	DB *sql.DB
+}
+
+func NewBackgroundJobRepository(db *sql.DB) *BackgroundJobRepository {
+	return &BackgroundJobRepository{DB: db}
+}
+
+func (r *BackgroundJobRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS background_jobs (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			kind VARCHAR(64) NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			progress_stage VARCHAR(64) NOT NULL DEFAULT '',
+			progress_current INT NOT NULL DEFAULT 0,
+			progress_total INT NOT NULL DEFAULT 0,
+			progress_message VARCHAR(500) NOT NULL DEFAULT '',
+			result_json LONGTEXT NULL,
+			error_message TEXT NULL,
+			created_by_user_id BIGINT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			finished_at DATETIME NULL,
+			PRIMARY KEY (id),
+			KEY idx_background_jobs_kind (kind, id),
+			KEY idx_background_jobs_status (status)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`); err != nil {
+		return fmt.Errorf("error ensuring background job schema: %w", err)
+	}
+	return nil
+}
+
+// FailInterruptedJobs closes jobs left running by a previous process. Jobs
+// only live in the process that started them, so after a restart nothing
+// will ever finish them.
+func (r *BackgroundJobRepository) FailInterruptedJobs(now time.Time) (int, error) {
+	result, err := r.DB.Exec(`
+		UPDATE background_jobs
+		SET status = ?, error_message = ?, updated_at = ?, finished_at = ?
+		WHERE status = ?
+	`, BackgroundJobStatusFailed, backgroundJobInterruptedMessage, now, now, BackgroundJobStatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("error failing interrupted background jobs: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error reading interrupted background job rows: %w", err)
+	}
+	return int(affected), nil
+}
+
+func (r *BackgroundJobRepository) CreateJob(job *BackgroundJob) error {
+	result, err := r.DB.Exec(`
+		INSERT INTO background_jobs (kind, status, created_by_user_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, job.Kind, job.Status, nullableInt64Value(job.CreatedByUserID), job.CreatedAt, job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating background job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error reading background job id: %w", err)
+	}
+	job.ID = id
+	return nil
+}
+
+func (r *BackgroundJobRepository) UpdateJobProgress(id int64, progress BackgroundJobProgress, now time.Time) error {
+	if _, err := r.DB.Exec(`
+		UPDATE background_jobs
+		SET progress_stage = ?, progress_current = ?, progress_total = ?, progress_message = ?, updated_at = ?
+		WHERE id = ? AND status = ?
+	`,
+		truncateBackgroundJobText(progress.Stage, backgroundJobStageMaxLength),
+		progress.Current,
+		progress.Total,
+		truncateBackgroundJobText(progress.Message, backgroundJobProgressMaxLength),
+		now,
+		id,
+		BackgroundJobStatusRunning,
+	); err != nil {
+		return fmt.Errorf("error updating background job progress: %w", err)
+	}
+	return nil
+}
+
+func (r *BackgroundJobRepository) FinishJob(job *BackgroundJob) error {
+	var result interface{}
+	if len(job.Result) > 0 {
+		result = string(job.Result)
+	}
+	var errorMessage interface{}
+	if strings.TrimSpace(job.ErrorMessage) != "" {
+		errorMessage = truncateBackgroundJobText(job.ErrorMessage, backgroundJobErrorMessageMaxLength)
+	}
+
+	if _, err := r.DB.Exec(`
+		UPDATE background_jobs
+		SET status = ?, progress_stage = ?, progress_current = ?, progress_total = ?, progress_message = ?,
+			result_json = ?, error_message = ?, updated_at = ?, finished_at = ?
+		WHERE id = ?
+	`,
+		job.Status,
+		truncateBackgroundJobText(job.Progress.Stage, backgroundJobStageMaxLength),
+		job.Progress.Current,
+		job.Progress.Total,
+		truncateBackgroundJobText(job.Progress.Message, backgroundJobProgressMaxLength),
+		result,
+		errorMessage,
+		job.UpdatedAt,
+		job.FinishedAt,
+		job.ID,
+	); err != nil {
+		return fmt.Errorf("error finishing background job: %w", err)
+	}
+	return nil
+}
+
+func (r *BackgroundJobRepository) GetJob(id int64) (*BackgroundJob, error) {
+	row := r.DB.QueryRow(`
+		SELECT `+backgroundJobColumns+`
+		FROM background_jobs
+		WHERE id = ?
+	`, id)
+	job, err := scanBackgroundJob(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// LatestJob returns the newest job of a kind, or nil when none has run.
+func (r *BackgroundJobRepository) LatestJob(kind string) (*BackgroundJob, error) {
+	row := r.DB.QueryRow(`
+		SELECT `+backgroundJobColumns+`
+		FROM background_jobs
+		WHERE kind = ?
+		ORDER BY id DESC
+		LIMIT 1
+	`, kind)
+	job, err := scanBackgroundJob(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+const backgroundJobColumns = `id, kind, status, progress_stage, progress_current, progress_total, progress_message,
+			result_json, error_message, created_by_user_id, created_at, updated_at, finished_at`
+
+type backgroundJobScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBackgroundJob(scanner backgroundJobScanner) (*BackgroundJob, error) {
+	var job BackgroundJob
+	var result, errorMessage sql.NullString
+	var createdByUserID sql.NullInt64
+	var finishedAt sql.NullTime
+	if err := scanner.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Status,
+		&job.Progress.Stage,
+		&job.Progress.Current,
+		&job.Progress.Total,
+		&job.Progress.Message,
+		&result,
+		&errorMessage,
+		&createdByUserID,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&finishedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error scanning background job: %w", err)
+	}
+	if result.Valid && result.String != "" {
+		job.Result = json.RawMessage(result.String)
+	}
+	job.ErrorMessage = errorMessage.String
+	if createdByUserID.Valid {
+		value := createdByUserID.Int64
+		job.CreatedByUserID = &value
+	}
+	if finishedAt.Valid {
+		value := finishedAt.Time
+		job.FinishedAt = &value
+	}
+	return &job, nil
+}
+
+func truncateBackgroundJobText(value string, maxLength int) string {
+	runes := []rune(strings.TrimSpace(value))
+	if len(runes) <= maxLength {
+		return string(runes)
+	}
+	return string(runes[:maxLength])
+}
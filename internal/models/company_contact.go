@@ -531,3 +531,42 @@ func (r *CompanyContactRepository) ReplaceAll(contacts []CompanyContact) error {
 	return nil
 }
 
+// ListAll returns every company contact, ordered by tax ID, for comparing
+// against a partner sync.
+func (r *CompanyContactRepository) ListAll() ([]CompanyContact, error) {
+	rows, err := r.DB.Query(`
+		SELECT
+			ma_so_thue,
+			ten_cong_ty,
+			COALESCE(so_hd, ''),
+			COALESCE(DATE_FORMAT(ngay_hd, '%Y-%m-%d'), ''),
+			COALESCE(dia_chi_cong_ty, ''),
+			COALESCE(so_tk_ngan_hang, ''),
+			COALESCE(ten_ngan_hang, ''),
+			COALESCE(chi_nhanh, ''),
+			COALESCE(qd, ''),
+			COALESCE(so_goi_thau, ''),
+			COALESCE(gmail, '')
+		FROM company_contacts
+		ORDER BY ma_so_thue ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing company contacts: %w", err)
+	}
+	defer rows.Close()
+
+	contacts := make([]CompanyContact, 0)
+	for rows.Next() {
+		contact, err := scanCompanyContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, *contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating company contacts: %w", err)
+	}
+
+	return contacts, nil
+}
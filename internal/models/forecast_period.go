@@ -0,0 +1,426 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ForecastPeriodActionConfigure = "configure"
+	ForecastPeriodActionLock      = "lock"
+	ForecastPeriodActionUnlock    = "unlock"
+
+	ForecastDeadlineSubmission = "submission"
+	ForecastDeadlineApproval   = "approval"
+)
+
+var ErrForecastPeriodLockState = errors.New("forecast period is already in the requested lock state")
+
+// ForecastPeriod holds the time rules of one forecast month. A month without
+// a row has no deadlines and is unlocked.
+type ForecastPeriod struct {
+	ForecastMonth            int        `json:"forecastMonth"`
+	ForecastYear             int        `json:"forecastYear"`
+	SubmissionDeadline       *time.Time `json:"submissionDeadline,omitempty"`
+	ApprovalDeadline         *time.Time `json:"approvalDeadline,omitempty"`
+	Locked                   bool       `json:"locked"`
+	LockedByUserID           *int64     `json:"lockedByUserId,omitempty"`
+	LockedAt                 *time.Time `json:"lockedAt,omitempty"`
+	SubmissionReminderSentAt *time.Time `json:"submissionReminderSentAt,omitempty"`
+	ApprovalReminderSentAt   *time.Time `json:"approvalReminderSentAt,omitempty"`
+	UpdatedByUserID          *int64     `json:"updatedByUserId,omitempty"`
+	UpdatedAt                *time.Time `json:"updatedAt,omitempty"`
+}
+
+// ForecastPeriodAuditRecord is one configuration, lock or unlock of a period.
+type ForecastPeriodAuditRecord struct {
+	ID            int64     `json:"id"`
+	ForecastMonth int       `json:"forecastMonth"`
+	ForecastYear  int       `json:"forecastYear"`
+	Action        string    `json:"action"`
+	Reason        string    `json:"reason,omitempty"`
+	Details       string    `json:"details,omitempty"`
+	ActorID       int64     `json:"actorId"`
+	ActorName     string    `json:"actorName"`
+	ActorEmail    string    `json:"actorEmail,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type ForecastPeriodDeadlinesInput struct {
+	ForecastMonth      int
+	ForecastYear       int
+	SubmissionDeadline *time.Time
+	ApprovalDeadline   *time.Time
+	Actor              OrderActor
+	Now                time.Time
+}
+
+type ForecastPeriodRepository struct {
+	DB *sql.DB
+}
+
+func NewForecastPeriodRepository(db *sql.DB) *ForecastPeriodRepository {
+	return &ForecastPeriodRepository{DB: db}
+}
+
+func (r *ForecastPeriodRepository) EnsureSchema() error {
+	statements := []string{
+		`
+		CREATE TABLE IF NOT EXISTS forecast_periods (
+			forecast_year INT NOT NULL,
+			forecast_month INT NOT NULL,
+			submission_deadline DATETIME NULL,
+			approval_deadline DATETIME NULL,
+			locked TINYINT(1) NOT NULL DEFAULT 0,
+			locked_by_user_id BIGINT NULL,
+			locked_at DATETIME NULL,
+			submission_reminder_sent_at DATETIME NULL,
+			approval_reminder_sent_at DATETIME NULL,
+			updated_by_user_id BIGINT NULL,
+			updated_at DATETIME NULL,
+			PRIMARY KEY (forecast_year, forecast_month)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS forecast_period_audit (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			forecast_year INT NOT NULL,
+			forecast_month INT NOT NULL,
+			action VARCHAR(32) NOT NULL,
+			reason TEXT NULL,
+			details TEXT NULL,
+			actor_id BIGINT NOT NULL,
+			actor_name VARCHAR(255) NOT NULL,
+			actor_email VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (id),
+			KEY idx_forecast_period_audit_period (forecast_year, forecast_month, id)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+		`,
+	}
+
+	for _, statement := range statements {
+		if _, err := r.DB.Exec(statement); err != nil {
+			return fmt.Errorf("error ensuring forecast period schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// SubmissionClosed reports whether the submission deadline has passed.
+func (p *ForecastPeriod) SubmissionClosed(now time.Time) bool {
+	return p != nil && p.SubmissionDeadline != nil && now.After(*p.SubmissionDeadline)
+}
+
+// ApprovalClosed reports whether the approval deadline has passed.
+func (p *ForecastPeriod) ApprovalClosed(now time.Time) bool {
+	return p != nil && p.ApprovalDeadline != nil && now.After(*p.ApprovalDeadline)
+}
+
+func (r *ForecastPeriodRepository) GetPeriod(month, year int) (*ForecastPeriod, error) {
+	row := r.DB.QueryRow(`
+		SELECT `+forecastPeriodColumns+`
+		FROM forecast_periods
+		WHERE forecast_year = ? AND forecast_month = ?
+	`, year, month)
+	period, err := scanForecastPeriod(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return period, err
+}
+
+// ListPeriods returns the configured periods of a year, or of every year
+// when year is 0.
+func (r *ForecastPeriodRepository) ListPeriods(year int) ([]ForecastPeriod, error) {
+	query := `
+		SELECT ` + forecastPeriodColumns + `
+		FROM forecast_periods
+	`
+	args := make([]interface{}, 0, 1)
+	if year > 0 {
+		query += " WHERE forecast_year = ?"
+		args = append(args, year)
+	}
+	query += " ORDER BY forecast_year DESC, forecast_month DESC"
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing forecast periods: %w", err)
+	}
+	defer rows.Close()
+
+	periods := make([]ForecastPeriod, 0)
+	for rows.Next() {
+		period, err := scanForecastPeriod(rows)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, *period)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating forecast periods: %w", err)
+	}
+	return periods, nil
+}
+
+// SaveDeadlines sets both deadlines of a period and records the change. A
+// deadline that moves gets its reminder sent again.
+func (r *ForecastPeriodRepository) SaveDeadlines(input ForecastPeriodDeadlinesInput) (*ForecastPeriod, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting forecast period transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`
+		INSERT INTO forecast_periods (
+			forecast_year, forecast_month, submission_deadline, approval_deadline, updated_by_user_id, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			submission_reminder_sent_at = IF(submission_deadline <=> VALUES(submission_deadline), submission_reminder_sent_at, NULL),
+			approval_reminder_sent_at = IF(approval_deadline <=> VALUES(approval_deadline), approval_reminder_sent_at, NULL),
+			submission_deadline = VALUES(submission_deadline),
+			approval_deadline = VALUES(approval_deadline),
+			updated_by_user_id = VALUES(updated_by_user_id),
+			updated_at = VALUES(updated_at)
+	`, input.ForecastYear, input.ForecastMonth, input.SubmissionDeadline, input.ApprovalDeadline, input.Actor.ID, input.Now); err != nil {
+		return nil, fmt.Errorf("error saving forecast period deadlines: %w", err)
+	}
+
+	details := fmt.Sprintf("submissionDeadline=%s; approvalDeadline=%s", formatForecastDeadline(input.SubmissionDeadline), formatForecastDeadline(input.ApprovalDeadline))
+	if err = insertForecastPeriodAudit(tx, input.ForecastMonth, input.ForecastYear, ForecastPeriodActionConfigure, "", details, input.Actor, input.Now); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing forecast period deadlines: %w", err)
+	}
+	return r.GetPeriod(input.ForecastMonth, input.ForecastYear)
+}
+
+// SetLocked locks or unlocks a period and writes the audit record in the
+// same transaction. It returns ErrForecastPeriodLockState when the period is
+// already in that state.
+func (r *ForecastPeriodRepository) SetLocked(month, year int, locked bool, reason string, actor OrderActor, now time.Time) (*ForecastPeriod, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting forecast period transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var current bool
+	scanErr := tx.QueryRow(`
+		SELECT locked
+		FROM forecast_periods
+		WHERE forecast_year = ? AND forecast_month = ?
+		FOR UPDATE
+	`, year, month).Scan(&current)
+	if scanErr != nil && scanErr != sql.ErrNoRows {
+		err = fmt.Errorf("error reading forecast period lock: %w", scanErr)
+		return nil, err
+	}
+	if current == locked {
+		err = ErrForecastPeriodLockState
+		return nil, err
+	}
+
+	action := ForecastPeriodActionUnlock
+	var lockedBy interface{}
+	var lockedAt interface{}
+	if locked {
+		action = ForecastPeriodActionLock
+		lockedBy = actor.ID
+		lockedAt = now
+	}
+	if _, err = tx.Exec(`
+		INSERT INTO forecast_periods (forecast_year, forecast_month, locked, locked_by_user_id, locked_at, updated_by_user_id, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			locked = VALUES(locked),
+			locked_by_user_id = VALUES(locked_by_user_id),
+			locked_at = VALUES(locked_at),
+			updated_by_user_id = VALUES(updated_by_user_id),
+			updated_at = VALUES(updated_at)
+	`, year, month, locked, lockedBy, lockedAt, actor.ID, now); err != nil {
+		return nil, fmt.Errorf("error updating forecast period lock: %w", err)
+	}
+
+	if err = insertForecastPeriodAudit(tx, month, year, action, reason, "", actor, now); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing forecast period lock: %w", err)
+	}
+	return r.GetPeriod(month, year)
+}
+
+func (r *ForecastPeriodRepository) ListAudit(month, year int) ([]ForecastPeriodAuditRecord, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, forecast_month, forecast_year, action, COALESCE(reason, ''), COALESCE(details, ''),
+			actor_id, actor_name, actor_email, created_at
+		FROM forecast_period_audit
+		WHERE forecast_year = ? AND forecast_month = ?
+		ORDER BY id DESC
+	`, year, month)
+	if err != nil {
+		return nil, fmt.Errorf("error listing forecast period audit: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]ForecastPeriodAuditRecord, 0)
+	for rows.Next() {
+		var record ForecastPeriodAuditRecord
+		if err := rows.Scan(
+			&record.ID,
+			&record.ForecastMonth,
+			&record.ForecastYear,
+			&record.Action,
+			&record.Reason,
+			&record.Details,
+			&record.ActorID,
+			&record.ActorName,
+			&record.ActorEmail,
+			&record.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning forecast period audit: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating forecast period audit: %w", err)
+	}
+	return records, nil
+}
+
+// ListUnremindedDeadlines returns unlocked periods with a deadline between
+// from and to whose reminder has not gone out yet.
+func (r *ForecastPeriodRepository) ListUnremindedDeadlines(from, to time.Time) ([]ForecastPeriod, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+forecastPeriodColumns+`
+		FROM forecast_periods
+		WHERE locked = 0
+			AND (
+				(submission_deadline BETWEEN ? AND ? AND submission_reminder_sent_at IS NULL)
+				OR (approval_deadline BETWEEN ? AND ? AND approval_reminder_sent_at IS NULL)
+			)
+		ORDER BY forecast_year, forecast_month
+	`, from, to, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error listing forecast deadlines: %w", err)
+	}
+	defer rows.Close()
+
+	periods := make([]ForecastPeriod, 0)
+	for rows.Next() {
+		period, err := scanForecastPeriod(rows)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, *period)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating forecast deadlines: %w", err)
+	}
+	return periods, nil
+}
+
+func (r *ForecastPeriodRepository) MarkReminderSent(month, year int, deadline string, now time.Time) error {
+	column := "submission_reminder_sent_at"
+	if deadline == ForecastDeadlineApproval {
+		column = "approval_reminder_sent_at"
+	}
+	if _, err := r.DB.Exec(`
+		UPDATE forecast_periods
+		SET `+column+` = ?
+		WHERE forecast_year = ? AND forecast_month = ?
+	`, now, year, month); err != nil {
+		return fmt.Errorf("error marking forecast deadline reminder: %w", err)
+	}
+	return nil
+}
+
+func insertForecastPeriodAudit(tx *sql.Tx, month, year int, action, reason, details string, actor OrderActor, now time.Time) error {
+	if _, err := tx.Exec(`
+		INSERT INTO forecast_period_audit (
+			forecast_year, forecast_month, action, reason, details, actor_id, actor_name, actor_email, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, year, month, action, nullIfEmpty(strings.TrimSpace(reason)), nullIfEmpty(details), actor.ID, actor.Username, actor.Email, now); err != nil {
+		return fmt.Errorf("error recording forecast period audit: %w", err)
+	}
+	return nil
+}
+
+func formatForecastDeadline(deadline *time.Time) string {
+	if deadline == nil {
+		return "none"
+	}
+	return deadline.Format(time.RFC3339)
+}
+
+const forecastPeriodColumns = `forecast_month, forecast_year, submission_deadline, approval_deadline, locked,
+			locked_by_user_id, locked_at, submission_reminder_sent_at, approval_reminder_sent_at,
+			updated_by_user_id, updated_at`
+
+type forecastPeriodScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanForecastPeriod(scanner forecastPeriodScanner) (*ForecastPeriod, error) {
+	var period ForecastPeriod
+	var submissionDeadline, approvalDeadline, lockedAt, submissionReminder, approvalReminder, updatedAt sql.NullTime
+	var lockedBy, updatedBy sql.NullInt64
+	if err := scanner.Scan(
+		&period.ForecastMonth,
+		&period.ForecastYear,
+		&submissionDeadline,
+		&approvalDeadline,
+		&period.Locked,
+		&lockedBy,
+		&lockedAt,
+		&submissionReminder,
+		&approvalReminder,
+		&updatedBy,
+		&updatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error scanning forecast period: %w", err)
+	}
+	period.SubmissionDeadline = nullTimePointer(submissionDeadline)
+	period.ApprovalDeadline = nullTimePointer(approvalDeadline)
+	period.LockedAt = nullTimePointer(lockedAt)
+	period.SubmissionReminderSentAt = nullTimePointer(submissionReminder)
+	period.ApprovalReminderSentAt = nullTimePointer(approvalReminder)
+	period.UpdatedAt = nullTimePointer(updatedAt)
+	if lockedBy.Valid {
+		value := lockedBy.Int64
+		period.LockedByUserID = &value
+	}
+	if updatedBy.Valid {
+		value := updatedBy.Int64
+		period.UpdatedByUserID = &value
+	}
+	return &period, nil
+}
+
+func nullTimePointer(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	result := value.Time
+	return &result
+}
@@ -0,0 +1,343 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	ForecastSuggestionMethodSeasonal      = "seasonal"
+	ForecastSuggestionMethodMovingAverage = "moving_average"
+	ForecastSuggestionMethodAnnualUsage   = "annual_usage"
+	ForecastSuggestionMethodNone          = "none"
+)
+
+const (
+	// forecastSuggestionAverageMonths is how many recent months feed the
+	// moving average.
+	forecastSuggestionAverageMonths = 3
+	// forecastSuggestionMinCoveredDays drops months with too few snapshots
+	// to say anything about their consumption.
+	forecastSuggestionMinCoveredDays = 7
+	// forecastSuggestionSeasonalMinMonths is how much of the previous year
+	// must be known before the same month last year is trusted as a pattern.
+	forecastSuggestionSeasonalMinMonths = 9
+)
+
+// SupplyMonthlyConsumption is the outflow of one supply in one calendar
+// month. CoveredDays counts the days the snapshots account for, so a month
+// that is only partly recorded can be scaled up to its full length.
+type SupplyMonthlyConsumption struct {
+	Month       string `json:"month"`
+	Consumed    int    `json:"consumed"`
+	CoveredDays int    `json:"coveredDays"`
+	DaysInMonth int    `json:"daysInMonth"`
+}
+
+// ForecastSuggestionInput is everything SuggestForecastQuantity needs for one
+// material. History may include months at or after the forecast month; they
+// are ignored.
+type ForecastSuggestionInput struct {
+	ForecastMonth int
+	ForecastYear  int
+	AsOf          time.Time
+	History       []SupplyMonthlyConsumption
+	AnnualUsage   *float64
+	SafetyStock   int
+	CurrentStock  int
+	OnOrder       float64
+	PackSize      int
+}
+
+// ForecastSuggestion is a suggested DuTruGoc together with the figures it was
+// derived from, so the forecast screen can show why.
+type ForecastSuggestion struct {
+	IDX1                    int                        `json:"idx1"`
+	MaterialCode            string                     `json:"materialCode"`
+	MaQuanLy                string                     `json:"maQuanLy"`
+	MaVtytCu                string                     `json:"maVtytCu"`
+	TenVtytBv               string                     `json:"tenVtytBv"`
+	DonViTinh               string                     `json:"donViTinh"`
+	QuyCachToiThieu         string                     `json:"quyCachToiThieu"`
+	ForecastMonth           int                        `json:"forecastMonth"`
+	ForecastYear            int                        `json:"forecastYear"`
+	Method                  string                     `json:"method"`
+	HistoryMonths           int                        `json:"historyMonths"`
+	DailyRate               float64                    `json:"dailyRate"`
+	SeasonalFactor          *float64                   `json:"seasonalFactor,omitempty"`
+	AnnualUsage             *float64                   `json:"annualUsage,omitempty"`
+	ExpectedConsumption     float64                    `json:"expectedConsumption"`
+	SafetyStock             int                        `json:"safetyStock"`
+	CurrentStock            int                        `json:"currentStock"`
+	ConsumptionBeforeTarget float64                    `json:"consumptionBeforeTarget"`
+	ProjectedStock          float64                    `json:"projectedStock"`
+	OnOrder                 float64                    `json:"onOrder"`
+	NetRequirement          float64                    `json:"netRequirement"`
+	PackSize                int                        `json:"packSize"`
+	SuggestedDuTruGoc       int                        `json:"suggestedDuTruGoc"`
+	History                 []SupplyMonthlyConsumption `json:"history"`
+}
+
+// SummarizeMonthlyConsumption groups the outflow between snapshots, ordered
+// oldest first, by the month of the later snapshot.
+func SummarizeMonthlyConsumption(snapshots []SupplyStockSnapshot) []SupplyMonthlyConsumption {
+	months := make([]SupplyMonthlyConsumption, 0)
+	for index := 1; index < len(snapshots); index++ {
+		previous, current := snapshots[index-1], snapshots[index]
+		previousDate, previousErr := time.Parse("2006-01-02", previous.SnapshotDate)
+		currentDate, currentErr := time.Parse("2006-01-02", current.SnapshotDate)
+		if previousErr != nil || currentErr != nil {
+			continue
+		}
+
+		month := currentDate.Format("2006-01")
+		if len(months) == 0 || months[len(months)-1].Month != month {
+			months = append(months, SupplyMonthlyConsumption{
+				Month:       month,
+				DaysInMonth: daysInMonth(currentDate.Year(), currentDate.Month()),
+			})
+		}
+		entry := &months[len(months)-1]
+		entry.Consumed += snapshotConsumed(previous, current)
+		entry.CoveredDays += int(currentDate.Sub(previousDate).Hours() / 24)
+	}
+	return months
+}
+
+// ListMonthlyConsumption returns the monthly consumption of every supply with
+// snapshots between from and to inclusive, keyed by IDX1.
+func (r *SupplyStockSnapshotRepository) ListMonthlyConsumption(from, to time.Time) (map[int][]SupplyMonthlyConsumption, error) {
+	rows, err := r.DB.Query(`
+		SELECT DATE_FORMAT(snapshot_date, '%Y-%m-%d'), idx1, xuat_trong_ky, ton_cuoi_ky
+		FROM supply_stock_snapshots
+		WHERE snapshot_date BETWEEN ? AND ?
+		ORDER BY idx1 ASC, snapshot_date ASC
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing supply monthly consumption: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int][]SupplyMonthlyConsumption)
+	var pending []SupplyStockSnapshot
+	flush := func() {
+		if len(pending) > 0 {
+			result[pending[0].IDX1] = SummarizeMonthlyConsumption(pending)
+		}
+		pending = pending[:0]
+	}
+	for rows.Next() {
+		var snapshot SupplyStockSnapshot
+		if err := rows.Scan(&snapshot.SnapshotDate, &snapshot.IDX1, &snapshot.XuatTrongKy, &snapshot.TonCuoiKy); err != nil {
+			return nil, fmt.Errorf("error scanning supply monthly consumption: %w", err)
+		}
+		if len(pending) > 0 && pending[0].IDX1 != snapshot.IDX1 {
+			flush()
+		}
+		pending = append(pending, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating supply monthly consumption: %w", err)
+	}
+	flush()
+	return result, nil
+}
+
+// ListCompareAnnualUsage returns so_luong_su_dung_12_thang from the compare
+// catalog keyed by upper-cased ma_thu_vien.
+func (r *SupplyRepository) ListCompareAnnualUsage() (map[string]float64, error) {
+	rows, err := r.DB.Query(`
+		SELECT ma_thu_vien, so_luong_su_dung_12_thang
+		FROM so_sanh_vat_tu
+		WHERE TRIM(COALESCE(ma_thu_vien, '')) <> ''
+			AND so_luong_su_dung_12_thang IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing compare annual usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]float64)
+	for rows.Next() {
+		var maThuVien string
+		var quantity sql.NullFloat64
+		if err := rows.Scan(&maThuVien, &quantity); err != nil {
+			return nil, fmt.Errorf("error scanning compare annual usage: %w", err)
+		}
+		if quantity.Valid {
+			usage[strings.ToUpper(strings.TrimSpace(maThuVien))] = quantity.Float64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating compare annual usage: %w", err)
+	}
+	return usage, nil
+}
+
+// OutstandingQuantityByMaterial totals outstanding order quantities across
+// suppliers, keyed by upper-cased material code.
+func OutstandingQuantityByMaterial(items []OrderOutstandingQuantity) map[string]float64 {
+	totals := make(map[string]float64, len(items))
+	for _, item := range items {
+		code := strings.ToUpper(PreferredMaterialCode(item.MaQuanLy, item.MaVtytCu))
+		if code == "" {
+			continue
+		}
+		totals[code] += item.OutstandingQty
+	}
+	return totals
+}
+
+// BuildForecastSuggestion suggests DuTruGoc for one supply. annualUsage and
+// onOrder are keyed by upper-cased material code; the compare catalog is
+// looked up by TYPENAME first and ID second.
+func BuildForecastSuggestion(supply Supply, input ForecastSuggestionInput, annualUsage, onOrder map[string]float64) ForecastSuggestion {
+	typeName, legacyID := NormalizeMaterialIdentifiers(supply.TypeName.String, supply.ID.String)
+	for _, code := range []string{typeName, legacyID} {
+		if code == "" {
+			continue
+		}
+		if usage, ok := annualUsage[strings.ToUpper(code)]; ok {
+			input.AnnualUsage = &usage
+			break
+		}
+	}
+
+	input.SafetyStock = int(supply.TonKhoMin.Int32)
+	input.CurrentStock = supply.TonCuoiKy
+	input.OnOrder = onOrder[strings.ToUpper(supply.MaterialCode)]
+	input.PackSize = extractPackQuantity(supply.QuyCachToiThieu.String)
+
+	suggestion := SuggestForecastQuantity(input)
+	suggestion.IDX1 = supply.IDX1
+	suggestion.MaterialCode = supply.MaterialCode
+	suggestion.MaQuanLy = typeName
+	suggestion.MaVtytCu = legacyID
+	suggestion.TenVtytBv = strings.TrimSpace(supply.Name.String)
+	suggestion.DonViTinh = strings.TrimSpace(supply.Unit.String)
+	suggestion.QuyCachToiThieu = strings.TrimSpace(supply.QuyCachToiThieu.String)
+	return suggestion
+}
+
+// SuggestForecastQuantity estimates consumption in the forecast month and
+// turns it into an order quantity:
+//
+//   - the daily rate is the moving average of the last recorded months, or
+//     the compare catalog's 12-month usage when there is no history;
+//   - with most of the previous year recorded, the rate is scaled by how the
+//     same month last year compared with that year's average;
+//   - the requirement is that consumption plus TonKhoMin, less the stock
+//     expected to be left when the month starts and what is already on order;
+//   - the result is rounded up to whole packs of QuyCachToiThieu.
+func SuggestForecastQuantity(input ForecastSuggestionInput) ForecastSuggestion {
+	suggestion := ForecastSuggestion{
+		ForecastMonth: input.ForecastMonth,
+		ForecastYear:  input.ForecastYear,
+		Method:        ForecastSuggestionMethodNone,
+		AnnualUsage:   input.AnnualUsage,
+		SafetyStock:   input.SafetyStock,
+		CurrentStock:  input.CurrentStock,
+		OnOrder:       input.OnOrder,
+		PackSize:      input.PackSize,
+		History:       make([]SupplyMonthlyConsumption, 0),
+	}
+	if suggestion.PackSize <= 0 {
+		suggestion.PackSize = 1
+	}
+
+	location := input.AsOf.Location()
+	targetStart := time.Date(input.ForecastYear, time.Month(input.ForecastMonth), 1, 0, 0, 0, 0, location)
+	targetMonth := targetStart.Format("2006-01")
+	targetDays := daysInMonth(input.ForecastYear, time.Month(input.ForecastMonth))
+
+	for _, month := range input.History {
+		if month.Month < targetMonth && month.CoveredDays >= forecastSuggestionMinCoveredDays {
+			suggestion.History = append(suggestion.History, month)
+		}
+	}
+
+	recent := suggestion.History
+	if len(recent) > forecastSuggestionAverageMonths {
+		recent = recent[len(recent)-forecastSuggestionAverageMonths:]
+	}
+	switch {
+	case len(recent) > 0:
+		suggestion.Method = ForecastSuggestionMethodMovingAverage
+		suggestion.HistoryMonths = len(recent)
+		suggestion.DailyRate = monthlyConsumptionDailyRate(recent)
+	case input.AnnualUsage != nil && *input.AnnualUsage > 0:
+		suggestion.Method = ForecastSuggestionMethodAnnualUsage
+		suggestion.DailyRate = *input.AnnualUsage / 365
+	}
+
+	expected := suggestion.DailyRate * float64(targetDays)
+	if factor, ok := seasonalFactor(suggestion.History, targetStart); ok {
+		suggestion.Method = ForecastSuggestionMethodSeasonal
+		suggestion.SeasonalFactor = &factor
+		expected *= factor
+	}
+	suggestion.ExpectedConsumption = roundForecastFigure(expected)
+
+	asOfDate := time.Date(input.AsOf.Year(), input.AsOf.Month(), input.AsOf.Day(), 0, 0, 0, 0, location)
+	if daysBefore := int(targetStart.Sub(asOfDate).Hours() / 24); daysBefore > 0 {
+		suggestion.ConsumptionBeforeTarget = roundForecastFigure(suggestion.DailyRate * float64(daysBefore))
+	}
+	suggestion.ProjectedStock = math.Max(0, float64(input.CurrentStock)-suggestion.ConsumptionBeforeTarget)
+
+	net := suggestion.ExpectedConsumption + float64(input.SafetyStock) - suggestion.ProjectedStock - input.OnOrder
+	if net > 0 {
+		suggestion.NetRequirement = roundForecastFigure(net)
+		packs := int(math.Ceil(net / float64(suggestion.PackSize)))
+		suggestion.SuggestedDuTruGoc = packs * suggestion.PackSize
+	}
+	return suggestion
+}
+
+// seasonalFactor compares the daily rate of the same month last year with
+// the daily rate over the twelve months before the forecast month.
+func seasonalFactor(history []SupplyMonthlyConsumption, targetStart time.Time) (float64, bool) {
+	yearStart := targetStart.AddDate(-1, 0, 0).Format("2006-01")
+	var sameMonth *SupplyMonthlyConsumption
+	year := make([]SupplyMonthlyConsumption, 0, 12)
+	for index := range history {
+		if history[index].Month < yearStart {
+			continue
+		}
+		if history[index].Month == yearStart {
+			sameMonth = &history[index]
+		}
+		year = append(year, history[index])
+	}
+	if sameMonth == nil || len(year) < forecastSuggestionSeasonalMinMonths {
+		return 0, false
+	}
+
+	yearRate := monthlyConsumptionDailyRate(year)
+	if yearRate <= 0 {
+		return 0, false
+	}
+	return roundForecastFigure(monthlyConsumptionDailyRate([]SupplyMonthlyConsumption{*sameMonth}) / yearRate), true
+}
+
+func monthlyConsumptionDailyRate(months []SupplyMonthlyConsumption) float64 {
+	consumed, days := 0, 0
+	for _, month := range months {
+		consumed += month.Consumed
+		days += month.CoveredDays
+	}
+	if days == 0 {
+		return 0
+	}
+	return float64(consumed) / float64(days)
+}
+
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func roundForecastFigure(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeMonthlyConsumptionSplitsByMonth(t *testing.T) {
+	t.Parallel()
+
+	months := SummarizeMonthlyConsumption([]SupplyStockSnapshot{
+		{SnapshotDate: "2026-09-29", XuatTrongKy: 10},
+		{SnapshotDate: "2026-09-30", XuatTrongKy: 15},
+		{SnapshotDate: "2026-10-01", XuatTrongKy: 3},
+		{SnapshotDate: "2026-10-03", XuatTrongKy: 9},
+	})
+
+	want := []SupplyMonthlyConsumption{
+		{Month: "2026-09", Consumed: 5, CoveredDays: 1, DaysInMonth: 30},
+		{Month: "2026-10", Consumed: 9, CoveredDays: 3, DaysInMonth: 31},
+	}
+	if len(months) != len(want) {
+		t.Fatalf("months = %+v, want %+v", months, want)
+	}
+	for index := range want {
+		if months[index] != want[index] {
+			t.Fatalf("months[%d] = %+v, want %+v", index, months[index], want[index])
+		}
+	}
+}
+
+func TestSuggestForecastQuantityUsesMovingAverage(t *testing.T) {
+	t.Parallel()
+
+	suggestion := SuggestForecastQuantity(ForecastSuggestionInput{
+		ForecastMonth: 11,
+		ForecastYear:  2026,
+		AsOf:          time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		History: []SupplyMonthlyConsumption{
+			{Month: "2026-06", Consumed: 9000, CoveredDays: 3, DaysInMonth: 30},
+			{Month: "2026-07", Consumed: 3100, CoveredDays: 31, DaysInMonth: 31},
+			{Month: "2026-08", Consumed: 310, CoveredDays: 31, DaysInMonth: 31},
+			{Month: "2026-09", Consumed: 300, CoveredDays: 30, DaysInMonth: 30},
+			{Month: "2026-10", Consumed: 160, CoveredDays: 16, DaysInMonth: 31},
+			{Month: "2026-11", Consumed: 999, CoveredDays: 30, DaysInMonth: 30},
+		},
+		SafetyStock:  50,
+		CurrentStock: 200,
+		OnOrder:      100,
+		PackSize:     25,
+	})
+
+	if suggestion.Method != ForecastSuggestionMethodMovingAverage || suggestion.HistoryMonths != 3 || suggestion.DailyRate != 10 {
+		t.Fatalf("suggestion = %+v, want a 3-month moving average of 10 per day", suggestion)
+	}
+	if suggestion.ExpectedConsumption != 300 || suggestion.ConsumptionBeforeTarget != 160 || suggestion.ProjectedStock != 40 {
+		t.Fatalf("suggestion = %+v, want 300 expected and 40 left on Nov 1", suggestion)
+	}
+	// 300 + 50 safety - 40 projected - 100 on order = 210, rounded up to 9 packs of 25.
+	if suggestion.NetRequirement != 210 || suggestion.SuggestedDuTruGoc != 225 {
+		t.Fatalf("net = %v, suggested = %d, want 210 and 225", suggestion.NetRequirement, suggestion.SuggestedDuTruGoc)
+	}
+	if len(suggestion.History) != 4 {
+		t.Fatalf("history = %+v, want the four usable months before November", suggestion.History)
+	}
+}
+
+func TestSuggestForecastQuantityFallsBackToAnnualUsage(t *testing.T) {
+	t.Parallel()
+
+	annualUsage := 3650.0
+	suggestion := SuggestForecastQuantity(ForecastSuggestionInput{
+		ForecastMonth: 10,
+		ForecastYear:  2026,
+		AsOf:          time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		AnnualUsage:   &annualUsage,
+		CurrentStock:  100,
+	})
+
+	if suggestion.Method != ForecastSuggestionMethodAnnualUsage || suggestion.DailyRate != 10 {
+		t.Fatalf("suggestion = %+v, want the annual usage rate", suggestion)
+	}
+	if suggestion.ConsumptionBeforeTarget != 0 || suggestion.SuggestedDuTruGoc != 210 || suggestion.PackSize != 1 {
+		t.Fatalf("suggestion = %+v, want 310 - 100 = 210 in single units", suggestion)
+	}
+}
+
+func TestSuggestForecastQuantityAppliesSeasonalFactor(t *testing.T) {
+	t.Parallel()
+
+	history := []SupplyMonthlyConsumption{{Month: "2025-12", Consumed: 600, CoveredDays: 30, DaysInMonth: 31}}
+	for month := 1; month <= 11; month++ {
+		history = append(history, SupplyMonthlyConsumption{
+			Month:       time.Date(2026, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01"),
+			Consumed:    300,
+			CoveredDays: 30,
+		})
+	}
+
+	suggestion := SuggestForecastQuantity(ForecastSuggestionInput{
+		ForecastMonth: 12,
+		ForecastYear:  2026,
+		AsOf:          time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC),
+		History:       history,
+	})
+
+	if suggestion.Method != ForecastSuggestionMethodSeasonal || suggestion.SeasonalFactor == nil || *suggestion.SeasonalFactor != 1.85 {
+		t.Fatalf("suggestion = %+v, want a seasonal factor of 1.85", suggestion)
+	}
+	if suggestion.ExpectedConsumption != 573.5 || suggestion.SuggestedDuTruGoc != 574 {
+		t.Fatalf("expected = %v, suggested = %d, want 573.5 and 574", suggestion.ExpectedConsumption, suggestion.SuggestedDuTruGoc)
+	}
+}
+
+func TestSuggestForecastQuantityWithoutData(t *testing.T) {
+	t.Parallel()
+
+	suggestion := SuggestForecastQuantity(ForecastSuggestionInput{
+		ForecastMonth: 11,
+		ForecastYear:  2026,
+		AsOf:          time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		SafetyStock:   30,
+		CurrentStock:  10,
+		PackSize:      12,
+	})
+
+	// Nothing is known about consumption, but stock is still below TonKhoMin.
+	if suggestion.Method != ForecastSuggestionMethodNone || suggestion.SuggestedDuTruGoc != 24 {
+		t.Fatalf("suggestion = %+v, want 20 short of TonKhoMin rounded to 24", suggestion)
+	}
+}
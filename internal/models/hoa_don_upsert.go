@@ -0,0 +1,177 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// HoaDonUpsertResult counts the invoice lines written by UpsertInvoiceRows.
+type HoaDonUpsertResult struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+}
+
+// LatestInvoiceDate returns the newest ngay_hoa_don, or nil when hoa_don is
+// empty. The UBot refresh uses it as the start of the next incremental range.
+func (r *HoaDonRepository) LatestInvoiceDate() (*time.Time, error) {
+	var latest sql.NullTime
+	if err := r.db.QueryRow("SELECT MAX(ngay_hoa_don) FROM hoa_don").Scan(&latest); err != nil {
+		return nil, fmt.Errorf("error loading latest invoice date: %w", err)
+	}
+	if !latest.Valid {
+		return nil, nil
+	}
+	value := latest.Time
+	return &value, nil
+}
+
+// UpsertInvoiceRows writes invoice lines keyed by id_hoa_don and
+// stt_dong_hang in a single transaction. Existing lines are updated in place
+// so reconciliations pointing at hoa_don.id stay valid; lines missing from
+// the batch are left untouched. Supplier references are backfilled afterwards
+// the same way the schema maintenance does.
+func (r *HoaDonRepository) UpsertInvoiceRows(rows []HoaDon) (HoaDonUpsertResult, error) {
+	result := HoaDonUpsertResult{}
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("error starting invoice upsert transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	textLengths, err := loadHoaDonTextColumnLengths(tx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, row := range rows {
+		values := []interface{}{
+			fitHoaDonText(row.TrangThaiHoaDon, "trang_thai_hoa_don", textLengths),
+			fitHoaDonText(row.LoaiHoaDon, "loai_hoa_don", textLengths),
+			fitHoaDonText(row.SoHoaDon, "so_hoa_don", textLengths),
+			fitHoaDonText(row.KyHieu, "kyhieu", textLengths),
+			row.NgayHoaDon.Format("2006-01-02"),
+			fitHoaDonText(row.MaSoThueNguoiBan, "ma_so_thue_nguoi_ban", textLengths),
+			fitHoaDonText(row.CongTy, "cong_ty", textLengths),
+			fitHoaDonText(row.DiaChi, "dia_chi", textLengths),
+			fitHoaDonText(row.LinkTraCuuHoaDon, "link_tra_cuu_hoa_don", textLengths),
+			fitHoaDonText(row.TenHangHoa, "ten_hang_hoa", textLengths),
+			fitHoaDonText(row.InvoiceContext, "invoice_context", textLengths),
+			fitHoaDonText(row.MaHangHoa, "ma_hang_hoa", textLengths),
+			fitHoaDonText(row.DonViTinh, "don_vi_tinh", textLengths),
+			row.SoLuong,
+			row.DonGiaChuaThue,
+			row.ThueSuatGTGT,
+		}
+		idHoaDon := fitHoaDonText(row.IDHoaDon, "id_hoa_don", textLengths)
+
+		var existingID int64
+		err := tx.QueryRow(
+			"SELECT id FROM hoa_don WHERE id_hoa_don = ? AND stt_dong_hang = ? ORDER BY id LIMIT 1 FOR UPDATE",
+			idHoaDon, row.STTDongHang,
+		).Scan(&existingID)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := tx.Exec(`
+				INSERT INTO hoa_don (
+					trang_thai_hoa_don, loai_hoa_don, so_hoa_don, kyhieu, ngay_hoa_don,
+					ma_so_thue_nguoi_ban, cong_ty, dia_chi, link_tra_cuu_hoa_don,
+					ten_hang_hoa, invoice_context, ma_hang_hoa,
+					don_vi_tinh, so_luong, don_gia_chua_thue, thue_suat_gtgt,
+					id_hoa_don, stt_dong_hang
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`, append(values, idHoaDon, row.STTDongHang)...); err != nil {
+				return result, fmt.Errorf("error inserting invoice %s line %d: %w", row.IDHoaDon, row.STTDongHang, err)
+			}
+			result.Inserted++
+		case err != nil:
+			return result, fmt.Errorf("error loading invoice %s line %d: %w", row.IDHoaDon, row.STTDongHang, err)
+		default:
+			if _, err := tx.Exec(`
+				UPDATE hoa_don
+				SET trang_thai_hoa_don = ?, loai_hoa_don = ?, so_hoa_don = ?, kyhieu = ?, ngay_hoa_don = ?,
+					ma_so_thue_nguoi_ban = ?, cong_ty = ?, dia_chi = ?, link_tra_cuu_hoa_don = ?,
+					ten_hang_hoa = ?, invoice_context = ?, ma_hang_hoa = ?,
+					don_vi_tinh = ?, so_luong = ?, don_gia_chua_thue = ?, thue_suat_gtgt = ?
+				WHERE id = ?
+			`, append(values, existingID)...); err != nil {
+				return result, fmt.Errorf("error updating invoice %s line %d: %w", row.IDHoaDon, row.STTDongHang, err)
+			}
+			result.Updated++
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE hoa_don h
+		INNER JOIN company_contacts c
+			ON TRIM(COALESCE(h.ma_so_thue_nguoi_ban, '')) <> ''
+			AND TRIM(COALESCE(h.ma_so_thue_nguoi_ban, '')) = TRIM(COALESCE(c.ma_so_thue, ''))
+		SET h.company_contact_id = c.ma_so_thue
+		WHERE TRIM(COALESCE(h.company_contact_id, '')) = ''
+	`); err != nil {
+		return result, fmt.Errorf("error linking invoices to company contacts by tax id: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE hoa_don h
+		INNER JOIN company_contacts c
+			ON LOWER(TRIM(COALESCE(h.cong_ty, ''))) = LOWER(TRIM(COALESCE(c.ten_cong_ty, '')))
+		SET h.company_contact_id = c.ma_so_thue
+		WHERE TRIM(COALESCE(h.company_contact_id, '')) = ''
+		  AND TRIM(COALESCE(h.cong_ty, '')) <> ''
+	`); err != nil {
+		return result, fmt.Errorf("error linking invoices to company contacts by company name: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("error committing invoice upsert: %w", err)
+	}
+	return result, nil
+}
+
+func loadHoaDonTextColumnLengths(tx *sql.Tx) (map[string]int, error) {
+	rows, err := tx.Query(`
+		SELECT column_name, character_maximum_length
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+		  AND table_name = 'hoa_don'
+		  AND data_type IN ('char', 'varchar', 'tinytext', 'text')
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error loading hoa_don column lengths: %w", err)
+	}
+	defer rows.Close()
+
+	lengths := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var maxLength sql.NullInt64
+		if err := rows.Scan(&name, &maxLength); err != nil {
+			return nil, fmt.Errorf("error scanning hoa_don column length: %w", err)
+		}
+		if maxLength.Valid && maxLength.Int64 > 0 {
+			lengths[name] = int(maxLength.Int64)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hoa_don column lengths: %w", err)
+	}
+	return lengths, nil
+}
+
+// fitHoaDonText trims a value to the column's character limit so one long
+// UBot item name cannot abort the whole refresh.
+func fitHoaDonText(value, columnName string, lengths map[string]int) string {
+	maxLength, ok := lengths[columnName]
+	if !ok {
+		return value
+	}
+	runes := []rune(value)
+	if len(runes) <= maxLength {
+		return value
+	}
+	return string(runes[:maxLength])
+}
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	InternalSupplySyncTriggerManual    = "manual"
+	InternalSupplySyncTriggerScheduled = "scheduled"
+	InternalSupplySyncTriggerStartup   = "startup"
+
+	InternalSupplySyncStatusSuccess = "success"
+	InternalSupplySyncStatusFailed  = "failed"
+	InternalSupplySyncStatusAborted = "aborted"
+)
+
+type InternalSupplySyncDiffItem struct {
+	Key     string   `json:"key"`
+	Code    string   `json:"code,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Changes []string `json:"changes,omitempty"`
+}
+
+// InternalSupplySyncDiff compares the stored rows of one table with what the
+// hospital API returned.
+type InternalSupplySyncDiff struct {
+	PreviousCount int                          `json:"previousCount"`
+	CurrentCount  int                          `json:"currentCount"`
+	Added         []InternalSupplySyncDiffItem `json:"added"`
+	Removed       []InternalSupplySyncDiffItem `json:"removed"`
+	Changed       []InternalSupplySyncDiffItem `json:"changed"`
+}
+
+type InternalSupplySyncRun struct {
+	ID                int64                   `json:"id"`
+	Trigger           string                  `json:"trigger"`
+	DryRun            bool                    `json:"dryRun"`
+	Status            string                  `json:"status"`
+	ErrorMessage      string                  `json:"errorMessage,omitempty"`
+	WarningMessage    string                  `json:"warningMessage,omitempty"`
+	SupplyCount       int                     `json:"supplyCount"`
+	SuppliesAdded     int                     `json:"suppliesAdded"`
+	SuppliesRemoved   int                     `json:"suppliesRemoved"`
+	SuppliesChanged   int                     `json:"suppliesChanged"`
+	ContactCount      int                     `json:"contactCount"`
+	ContactsAdded     int                     `json:"contactsAdded"`
+	ContactsRemoved   int                     `json:"contactsRemoved"`
+	ContactsChanged   int                     `json:"contactsChanged"`
+	Supplies          *InternalSupplySyncDiff `json:"supplies,omitempty"`
+	Contacts          *InternalSupplySyncDiff `json:"contacts,omitempty"`
+	RequestedByUserID *int64                  `json:"requestedByUserId,omitempty"`
+	StartedAt         time.Time               `json:"startedAt"`
+	FinishedAt        time.Time               `json:"finishedAt"`
+	CreatedAt         time.Time               `json:"createdAt"`
+}
+
+type internalSupplySyncRunDiffPayload struct {
+	Supplies *InternalSupplySyncDiff `json:"supplies,omitempty"`
+	Contacts *InternalSupplySyncDiff `json:"contacts,omitempty"`
+}
+
+type InternalSupplySyncRunRepository struct {
+	DB *sql.DB
+}
+
+func NewInternalSupplySyncRunRepository(db *sql.DB) *InternalSupplySyncRunRepository {
+	return &InternalSupplySyncRunRepository{DB: db}
+}
+
+func (r *InternalSupplySyncRunRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS internal_supply_sync_runs (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			trigger_source VARCHAR(20) NOT NULL,
+			dry_run TINYINT(1) NOT NULL DEFAULT 0,
+			status VARCHAR(20) NOT NULL,
+			error_message TEXT NULL,
+			warning_message TEXT NULL,
+			supply_count INT NOT NULL DEFAULT 0,
+			supplies_added INT NOT NULL DEFAULT 0,
+			supplies_removed INT NOT NULL DEFAULT 0,
+			supplies_changed INT NOT NULL DEFAULT 0,
+			contact_count INT NOT NULL DEFAULT 0,
+			contacts_added INT NOT NULL DEFAULT 0,
+			contacts_removed INT NOT NULL DEFAULT 0,
+			contacts_changed INT NOT NULL DEFAULT 0,
+			diff_payload LONGTEXT NULL,
+			requested_by_user_id BIGINT NULL,
+			started_at DATETIME NOT NULL,
+			finished_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY idx_internal_supply_sync_runs_started (started_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`); err != nil {
+		return fmt.Errorf("error ensuring internal supply sync run schema: %w", err)
+	}
+	return nil
+}
+
+func (r *InternalSupplySyncRunRepository) RecordRun(run *InternalSupplySyncRun) error {
+	if run == nil {
+		return fmt.Errorf("internal supply sync run is required")
+	}
+
+	var diffPayload any
+	if run.Supplies != nil || run.Contacts != nil {
+		encoded, err := json.Marshal(internalSupplySyncRunDiffPayload{Supplies: run.Supplies, Contacts: run.Contacts})
+		if err != nil {
+			return fmt.Errorf("error encoding internal supply sync diff: %w", err)
+		}
+		diffPayload = string(encoded)
+	}
+
+	result, err := r.DB.Exec(`
+		INSERT INTO internal_supply_sync_runs (
+			trigger_source, dry_run, status, error_message, warning_message,
+			supply_count, supplies_added, supplies_removed, supplies_changed,
+			contact_count, contacts_added, contacts_removed, contacts_changed,
+			diff_payload, requested_by_user_id, started_at, finished_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		run.Trigger,
+		run.DryRun,
+		run.Status,
+		nullableCatalogString(run.ErrorMessage),
+		nullableCatalogString(run.WarningMessage),
+		run.SupplyCount,
+		run.SuppliesAdded,
+		run.SuppliesRemoved,
+		run.SuppliesChanged,
+		run.ContactCount,
+		run.ContactsAdded,
+		run.ContactsRemoved,
+		run.ContactsChanged,
+		diffPayload,
+		nullableInt64Value(run.RequestedByUserID),
+		run.StartedAt,
+		run.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording internal supply sync run: %w", err)
+	}
+
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error reading internal supply sync run id: %w", err)
+	}
+	run.ID = runID
+	return nil
+}
+
+const internalSupplySyncRunColumns = `id, trigger_source, dry_run, status, COALESCE(error_message, ''), COALESCE(warning_message, ''),
+			supply_count, supplies_added, supplies_removed, supplies_changed,
+			contact_count, contacts_added, contacts_removed, contacts_changed,
+			requested_by_user_id, started_at, finished_at, created_at`
+
+// ListRuns returns the newest runs without their diff payload.
+func (r *InternalSupplySyncRunRepository) ListRuns(limit int) ([]InternalSupplySyncRun, error) {
+	if limit <= 0 {
+		limit = 30
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT `+internalSupplySyncRunColumns+`
+		FROM internal_supply_sync_runs
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing internal supply sync runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]InternalSupplySyncRun, 0)
+	for rows.Next() {
+		run, err := scanInternalSupplySyncRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating internal supply sync runs: %w", err)
+	}
+	return runs, nil
+}
+
+func (r *InternalSupplySyncRunRepository) GetRun(runID int64) (*InternalSupplySyncRun, error) {
+	var diffPayload sql.NullString
+	run, err := scanInternalSupplySyncRun(r.DB.QueryRow(`
+		SELECT `+internalSupplySyncRunColumns+`, diff_payload
+		FROM internal_supply_sync_runs
+		WHERE id = ?
+	`, runID), &diffPayload)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if diffPayload.Valid && diffPayload.String != "" {
+		var payload internalSupplySyncRunDiffPayload
+		if err := json.Unmarshal([]byte(diffPayload.String), &payload); err != nil {
+			return nil, fmt.Errorf("error decoding internal supply sync diff: %w", err)
+		}
+		run.Supplies = payload.Supplies
+		run.Contacts = payload.Contacts
+	}
+	return run, nil
+}
+
+type internalSupplySyncRunScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInternalSupplySyncRun(scanner internalSupplySyncRunScanner, extra ...any) (*InternalSupplySyncRun, error) {
+	var run InternalSupplySyncRun
+	var requestedBy sql.NullInt64
+	dest := []any{
+		&run.ID,
+		&run.Trigger,
+		&run.DryRun,
+		&run.Status,
+		&run.ErrorMessage,
+		&run.WarningMessage,
+		&run.SupplyCount,
+		&run.SuppliesAdded,
+		&run.SuppliesRemoved,
+		&run.SuppliesChanged,
+		&run.ContactCount,
+		&run.ContactsAdded,
+		&run.ContactsRemoved,
+		&run.ContactsChanged,
+		&requestedBy,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.CreatedAt,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error scanning internal supply sync run: %w", err)
+	}
+	if requestedBy.Valid {
+		value := requestedBy.Int64
+		run.RequestedByUserID = &value
+	}
+	return &run, nil
+}
@@ -0,0 +1,562 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	InvoiceMatchStatusProposed = "proposed"
+	InvoiceMatchStatusAccepted = "accepted"
+	InvoiceMatchStatusRejected = "rejected"
+
+	// InvoiceMatchDetailStatus marks reconciliations created by accepting a
+	// server-side match proposal.
+	InvoiceMatchDetailStatus = "auto_matched"
+)
+
+var (
+	ErrInvoiceMatchNotFound    = errors.New("invoice match proposal not found")
+	ErrInvoiceMatchNotProposed = errors.New("invoice match proposal has already been reviewed")
+)
+
+// InvoiceMatchFactor explains how many points one criterion contributed to a
+// proposal's score.
+type InvoiceMatchFactor struct {
+	Factor    string  `json:"factor"`
+	Points    float64 `json:"points"`
+	MaxPoints float64 `json:"maxPoints"`
+	Detail    string  `json:"detail"`
+}
+
+// InvoiceMatchOrderLine is an open order history line the matcher can pair
+// with invoice rows.
+type InvoiceMatchOrderLine struct {
+	ID               int64
+	CompanyContactID *string
+	NhaThau          string
+	MaQuanLy         string
+	MaVtytCu         string
+	MaHieu           string
+	TenVtytBv        string
+	OrderedQty       int
+	DeliveredQty     float64
+	NgayDatHang      string
+}
+
+type InvoiceMatchPair struct {
+	HoaDonID       int64
+	OrderHistoryID int64
+}
+
+type InvoiceMatchProposal struct {
+	ID                 int64                `json:"id"`
+	HoaDonID           int64                `json:"hoaDonId"`
+	OrderHistoryID     int64                `json:"orderHistoryId"`
+	Score              float64              `json:"score"`
+	QuantityDiff       float64              `json:"quantityDiff"`
+	Factors            []InvoiceMatchFactor `json:"factors"`
+	Status             string               `json:"status"`
+	ReconciliationID   *int64               `json:"reconciliationId,omitempty"`
+	ReviewedByUsername string               `json:"reviewedByUsername,omitempty"`
+	ReviewedAt         *time.Time           `json:"reviewedAt,omitempty"`
+	RejectReason       string               `json:"rejectReason,omitempty"`
+	CreatedAt          time.Time            `json:"createdAt"`
+	InvoiceNumber      string               `json:"invoiceNumber"`
+	InvoiceDate        *time.Time           `json:"invoiceDate,omitempty"`
+	InvoiceCompanyName string               `json:"invoiceCompanyName"`
+	InvoiceTaxCode     string               `json:"invoiceTaxCode"`
+	InvoiceItemCode    string               `json:"invoiceItemCode"`
+	InvoiceItemName    string               `json:"invoiceItemName"`
+	InvoiceQty         float64              `json:"invoiceQty"`
+	NhaThau            string               `json:"nhaThau"`
+	MaQuanLy           string               `json:"maQuanLy"`
+	MaVtytCu           string               `json:"maVtytCu"`
+	TenVtytBv          string               `json:"tenVtytBv"`
+	OrderedQty         int                  `json:"orderedQty"`
+	DeliveredQty       float64              `json:"deliveredQty"`
+	NgayDatHang        string               `json:"ngayDatHang"`
+}
+
+type InvoiceMatchFilter struct {
+	Status string
+	Limit  int
+}
+
+func (r *InvoiceReconciliationRepository) ensureInvoiceMatchSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS invoice_match_proposals (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			hoa_don_id BIGINT NOT NULL,
+			order_history_id BIGINT NOT NULL,
+			score DECIMAL(6,2) NOT NULL,
+			quantity_diff DECIMAL(18,3) NOT NULL DEFAULT 0,
+			factors TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'proposed',
+			reconciliation_id BIGINT NULL,
+			reviewed_by_id BIGINT NULL,
+			reviewed_by VARCHAR(255) NOT NULL DEFAULT '',
+			reviewed_at DATETIME NULL,
+			reject_reason VARCHAR(1000) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_invoice_match_pair (hoa_don_id, order_history_id),
+			KEY idx_invoice_match_status (status, score)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`); err != nil {
+		return fmt.Errorf("error ensuring invoice match proposal schema: %w", err)
+	}
+	return nil
+}
+
+// ListUnmatchedInvoiceRows returns invoice rows dated on or after since that
+// are neither reconciled nor waiting in, or accepted from, the review queue.
+func (r *InvoiceReconciliationRepository) ListUnmatchedInvoiceRows(since time.Time) ([]HoaDon, error) {
+	rows, err := r.DB.Query(`
+		SELECT
+			hd.id, hd.company_contact_id, hd.so_hoa_don, hd.ngay_hoa_don, hd.ma_so_thue_nguoi_ban,
+			hd.cong_ty, hd.id_hoa_don, hd.ten_hang_hoa, hd.ma_hang_hoa, hd.don_vi_tinh, hd.so_luong
+		FROM hoa_don hd
+		WHERE hd.ngay_hoa_don >= ?
+			AND NOT EXISTS (
+				SELECT 1 FROM order_invoice_reconciliation oir WHERE oir.invoice_row_id = hd.id
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM invoice_match_proposals p
+				WHERE p.hoa_don_id = hd.id AND p.status IN (?, ?)
+			)
+		ORDER BY hd.ngay_hoa_don, hd.id
+	`, since, InvoiceMatchStatusProposed, InvoiceMatchStatusAccepted)
+	if err != nil {
+		return nil, fmt.Errorf("error listing unmatched invoice rows: %w", err)
+	}
+	defer rows.Close()
+
+	invoices := make([]HoaDon, 0)
+	for rows.Next() {
+		var invoice HoaDon
+		var companyContactID sql.NullString
+		if err := rows.Scan(
+			&invoice.ID,
+			&companyContactID,
+			&invoice.SoHoaDon,
+			&invoice.NgayHoaDon,
+			&invoice.MaSoThueNguoiBan,
+			&invoice.CongTy,
+			&invoice.IDHoaDon,
+			&invoice.TenHangHoa,
+			&invoice.MaHangHoa,
+			&invoice.DonViTinh,
+			&invoice.SoLuong,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning unmatched invoice row: %w", err)
+		}
+		if companyContactID.Valid {
+			value := companyContactID.String
+			invoice.CompanyContactID = &value
+		}
+		invoices = append(invoices, invoice)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unmatched invoice rows: %w", err)
+	}
+	return invoices, nil
+}
+
+// ListOpenOrderLinesForMatching returns order lines that still expect
+// deliveries.
+func (r *InvoiceReconciliationRepository) ListOpenOrderLinesForMatching() ([]InvoiceMatchOrderLine, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, company_contact_id, nha_thau, ma_quan_ly, ma_vtyt_cu, ma_hieu, ten_vtyt_bv,
+			so_luong, delivered_qty, ngay_dat_hang
+		FROM order_history
+		WHERE lifecycle_status IN (?, ?, ?)
+		ORDER BY ngay_dat_hang, id
+	`, OrderLineStatusSent, OrderLineStatusPartiallyDelivered, OrderLineStatusOverdue)
+	if err != nil {
+		return nil, fmt.Errorf("error listing open order lines for matching: %w", err)
+	}
+	defer rows.Close()
+
+	lines := make([]InvoiceMatchOrderLine, 0)
+	for rows.Next() {
+		var line InvoiceMatchOrderLine
+		var companyContactID sql.NullString
+		if err := rows.Scan(
+			&line.ID,
+			&companyContactID,
+			&line.NhaThau,
+			&line.MaQuanLy,
+			&line.MaVtytCu,
+			&line.MaHieu,
+			&line.TenVtytBv,
+			&line.OrderedQty,
+			&line.DeliveredQty,
+			&line.NgayDatHang,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning open order line for matching: %w", err)
+		}
+		if companyContactID.Valid {
+			value := companyContactID.String
+			line.CompanyContactID = &value
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating open order lines for matching: %w", err)
+	}
+	return lines, nil
+}
+
+func (r *InvoiceReconciliationRepository) ListRejectedInvoiceMatches() ([]InvoiceMatchPair, error) {
+	rows, err := r.DB.Query(`SELECT hoa_don_id, order_history_id FROM invoice_match_proposals WHERE status = ?`, InvoiceMatchStatusRejected)
+	if err != nil {
+		return nil, fmt.Errorf("error listing rejected invoice matches: %w", err)
+	}
+	defer rows.Close()
+
+	pairs := make([]InvoiceMatchPair, 0)
+	for rows.Next() {
+		var pair InvoiceMatchPair
+		if err := rows.Scan(&pair.HoaDonID, &pair.OrderHistoryID); err != nil {
+			return nil, fmt.Errorf("error scanning rejected invoice match: %w", err)
+		}
+		pairs = append(pairs, pair)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rejected invoice matches: %w", err)
+	}
+	return pairs, nil
+}
+
+// InsertInvoiceMatchProposals queues proposals for review and skips pairs
+// that were already proposed. It returns how many rows were added.
+func (r *InvoiceReconciliationRepository) InsertInvoiceMatchProposals(proposals []InvoiceMatchProposal) (int, error) {
+	if len(proposals) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting invoice match transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserted := 0
+	for _, proposal := range proposals {
+		factors, err := json.Marshal(proposal.Factors)
+		if err != nil {
+			return 0, fmt.Errorf("error encoding invoice match factors: %w", err)
+		}
+
+		result, err := tx.Exec(`
+			INSERT IGNORE INTO invoice_match_proposals (hoa_don_id, order_history_id, score, quantity_diff, factors, status)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, proposal.HoaDonID, proposal.OrderHistoryID, proposal.Score, proposal.QuantityDiff, string(factors), InvoiceMatchStatusProposed)
+		if err != nil {
+			return 0, fmt.Errorf("error inserting invoice match proposal: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			inserted += int(affected)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing invoice match proposals: %w", err)
+	}
+	return inserted, nil
+}
+
+const invoiceMatchProposalSelect = `
+	SELECT
+		p.id, p.hoa_don_id, p.order_history_id, p.score, p.quantity_diff, p.factors, p.status,
+		p.reconciliation_id, p.reviewed_by, p.reviewed_at, p.reject_reason, p.created_at,
+		COALESCE(hd.so_hoa_don, ''), hd.ngay_hoa_don, COALESCE(hd.cong_ty, ''),
+		COALESCE(hd.ma_so_thue_nguoi_ban, ''), COALESCE(hd.ma_hang_hoa, ''),
+		COALESCE(hd.ten_hang_hoa, ''), COALESCE(hd.so_luong, 0),
+		COALESCE(oh.nha_thau, ''), COALESCE(oh.ma_quan_ly, ''), COALESCE(oh.ma_vtyt_cu, ''),
+		COALESCE(oh.ten_vtyt_bv, ''), COALESCE(oh.so_luong, 0), COALESCE(oh.delivered_qty, 0),
+		COALESCE(oh.ngay_dat_hang, '')
+	FROM invoice_match_proposals p
+	LEFT JOIN hoa_don hd ON hd.id = p.hoa_don_id
+	LEFT JOIN order_history oh ON oh.id = p.order_history_id
+`
+
+func (r *InvoiceReconciliationRepository) ListInvoiceMatchProposals(filter InvoiceMatchFilter) ([]InvoiceMatchProposal, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 200
+	}
+
+	query := invoiceMatchProposalSelect
+	args := make([]interface{}, 0, 2)
+	if status := strings.TrimSpace(filter.Status); status != "" {
+		query += ` WHERE p.status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY p.score DESC, p.id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing invoice match proposals: %w", err)
+	}
+	defer rows.Close()
+
+	proposals := make([]InvoiceMatchProposal, 0)
+	for rows.Next() {
+		proposal, err := scanInvoiceMatchProposal(rows)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, *proposal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invoice match proposals: %w", err)
+	}
+	return proposals, nil
+}
+
+func (r *InvoiceReconciliationRepository) GetInvoiceMatchProposal(id int64) (*InvoiceMatchProposal, error) {
+	proposal, err := scanInvoiceMatchProposal(r.DB.QueryRow(invoiceMatchProposalSelect+` WHERE p.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvoiceMatchNotFound
+	}
+	return proposal, err
+}
+
+// AcceptInvoiceMatchProposal turns a proposal into an order_invoice_reconciliation
+// row and records the reviewer. Other open proposals for the same invoice row
+// are rejected because an invoice row reconciles to one order line.
+func (r *InvoiceReconciliationRepository) AcceptInvoiceMatchProposal(id int64, actor OrderActor, now time.Time) (*InvoiceMatchProposal, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting invoice match accept transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	proposal, err := lockInvoiceMatchProposalTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var companyContactID, invoiceCompanyContactID sql.NullString
+	var invoiceIDHoaDon string
+	if err := tx.QueryRow(`
+		SELECT oh.company_contact_id, hd.company_contact_id, COALESCE(hd.id_hoa_don, '')
+		FROM invoice_match_proposals p
+		JOIN order_history oh ON oh.id = p.order_history_id
+		JOIN hoa_don hd ON hd.id = p.hoa_don_id
+		WHERE p.id = ?
+	`, id).Scan(&companyContactID, &invoiceCompanyContactID, &invoiceIDHoaDon); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: the invoice row or order line no longer exists", ErrInvoiceMatchNotFound)
+		}
+		return nil, fmt.Errorf("error loading invoice match sources: %w", err)
+	}
+
+	line := OrderHistoryRecord{NgayDatHang: proposal.NgayDatHang}
+	line.NhaThau = proposal.NhaThau
+	if companyContactID.Valid {
+		value := companyContactID.String
+		line.CompanyContactID = &value
+	}
+	var invoiceContact *string
+	if invoiceCompanyContactID.Valid {
+		value := invoiceCompanyContactID.String
+		invoiceContact = &value
+	}
+
+	orderTime := parseOrderTimestamp(proposal.NgayDatHang)
+	hoaDonID := proposal.HoaDonID
+	input := UpsertInvoiceReconciliationInput{
+		OrderHistoryID:          proposal.OrderHistoryID,
+		OrderBatchKey:           buildOrderBatchSignature(line) + "__batch-1",
+		CompanyContactID:        line.CompanyContactID,
+		NhaThau:                 proposal.NhaThau,
+		MaQuanLy:                proposal.MaQuanLy,
+		MaVtytCu:                proposal.MaVtytCu,
+		TenVtytBv:               proposal.TenVtytBv,
+		OrderedQty:              proposal.OrderedQty,
+		InvoiceNumber:           proposal.InvoiceNumber,
+		InvoiceIDHoaDon:         invoiceIDHoaDon,
+		InvoiceRowID:            &hoaDonID,
+		InvoiceCompanyContactID: invoiceContact,
+		InvoiceCompanyName:      proposal.InvoiceCompanyName,
+		InvoiceItemCode:         proposal.InvoiceItemCode,
+		InvoiceItemName:         proposal.InvoiceItemName,
+		InvoiceQty:              proposal.InvoiceQty,
+		InvoiceTime:             proposal.InvoiceDate,
+		HasInvoice:              true,
+		DetailStatus:            InvoiceMatchDetailStatus,
+		DetailNote:              summarizeInvoiceMatchFactors(proposal.Factors),
+		MatchScore:              proposal.Score,
+		QuantityDiff:            proposal.QuantityDiff,
+		MatchedByUserID:         int64PointerOrNil(actor.ID),
+		MatchedByUsername:       actor.Username,
+		MatchedByEmail:          actor.Email,
+		MatchedAt:               now,
+	}
+	if !orderTime.IsZero() {
+		input.OrderTime = &orderTime
+	}
+	if err := upsertInvoiceReconciliationTx(tx, input); err != nil {
+		return nil, err
+	}
+
+	var reconciliationID int64
+	if err := tx.QueryRow(`
+		SELECT id FROM order_invoice_reconciliation
+		WHERE order_history_id = ? AND order_batch_key = ? AND invoice_number = ?
+	`, input.OrderHistoryID, input.OrderBatchKey, strings.TrimSpace(input.InvoiceNumber)).Scan(&reconciliationID); err != nil {
+		return nil, fmt.Errorf("error loading accepted invoice reconciliation: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE invoice_match_proposals
+		SET status = ?, reconciliation_id = ?, reviewed_by_id = ?, reviewed_by = ?, reviewed_at = ?
+		WHERE id = ?
+	`, InvoiceMatchStatusAccepted, reconciliationID, nullableInt64Value(input.MatchedByUserID), actor.Username, now, id); err != nil {
+		return nil, fmt.Errorf("error accepting invoice match proposal: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE invoice_match_proposals
+		SET status = ?, reviewed_by_id = ?, reviewed_by = ?, reviewed_at = ?, reject_reason = ?
+		WHERE hoa_don_id = ? AND id <> ? AND status = ?
+	`, InvoiceMatchStatusRejected, nullableInt64Value(input.MatchedByUserID), actor.Username, now, "Invoice row matched to another order line", proposal.HoaDonID, id, InvoiceMatchStatusProposed); err != nil {
+		return nil, fmt.Errorf("error closing competing invoice match proposals: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing invoice match accept: %w", err)
+	}
+	return r.GetInvoiceMatchProposal(id)
+}
+
+// RejectInvoiceMatchProposal keeps the pair out of future matcher runs.
+func (r *InvoiceReconciliationRepository) RejectInvoiceMatchProposal(id int64, actor OrderActor, reason string, now time.Time) (*InvoiceMatchProposal, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting invoice match reject transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockInvoiceMatchProposalTx(tx, id); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE invoice_match_proposals
+		SET status = ?, reviewed_by_id = ?, reviewed_by = ?, reviewed_at = ?, reject_reason = ?
+		WHERE id = ?
+	`, InvoiceMatchStatusRejected, nullableInt64Value(int64PointerOrNil(actor.ID)), actor.Username, now, strings.TrimSpace(reason), id); err != nil {
+		return nil, fmt.Errorf("error rejecting invoice match proposal: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing invoice match reject: %w", err)
+	}
+	return r.GetInvoiceMatchProposal(id)
+}
+
+func lockInvoiceMatchProposalTx(tx *sql.Tx, id int64) (*InvoiceMatchProposal, error) {
+	proposal, err := scanInvoiceMatchProposal(tx.QueryRow(invoiceMatchProposalSelect+` WHERE p.id = ? FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvoiceMatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Status != InvoiceMatchStatusProposed {
+		return nil, ErrInvoiceMatchNotProposed
+	}
+	return proposal, nil
+}
+
+type invoiceMatchProposalScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInvoiceMatchProposal(scanner invoiceMatchProposalScanner) (*InvoiceMatchProposal, error) {
+	var proposal InvoiceMatchProposal
+	var factors string
+	var reconciliationID sql.NullInt64
+	var reviewedAt, invoiceDate sql.NullTime
+	if err := scanner.Scan(
+		&proposal.ID,
+		&proposal.HoaDonID,
+		&proposal.OrderHistoryID,
+		&proposal.Score,
+		&proposal.QuantityDiff,
+		&factors,
+		&proposal.Status,
+		&reconciliationID,
+		&proposal.ReviewedByUsername,
+		&reviewedAt,
+		&proposal.RejectReason,
+		&proposal.CreatedAt,
+		&proposal.InvoiceNumber,
+		&invoiceDate,
+		&proposal.InvoiceCompanyName,
+		&proposal.InvoiceTaxCode,
+		&proposal.InvoiceItemCode,
+		&proposal.InvoiceItemName,
+		&proposal.InvoiceQty,
+		&proposal.NhaThau,
+		&proposal.MaQuanLy,
+		&proposal.MaVtytCu,
+		&proposal.TenVtytBv,
+		&proposal.OrderedQty,
+		&proposal.DeliveredQty,
+		&proposal.NgayDatHang,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error scanning invoice match proposal: %w", err)
+	}
+
+	proposal.Factors = make([]InvoiceMatchFactor, 0)
+	if strings.TrimSpace(factors) != "" {
+		if err := json.Unmarshal([]byte(factors), &proposal.Factors); err != nil {
+			return nil, fmt.Errorf("error decoding invoice match factors: %w", err)
+		}
+	}
+	if reconciliationID.Valid {
+		value := reconciliationID.Int64
+		proposal.ReconciliationID = &value
+	}
+	if reviewedAt.Valid {
+		value := reviewedAt.Time
+		proposal.ReviewedAt = &value
+	}
+	if invoiceDate.Valid {
+		value := invoiceDate.Time
+		proposal.InvoiceDate = &value
+	}
+	return &proposal, nil
+}
+
+func summarizeInvoiceMatchFactors(factors []InvoiceMatchFactor) string {
+	parts := make([]string, 0, len(factors))
+	for _, factor := range factors {
+		parts = append(parts, fmt.Sprintf("%s %.0f/%.0f", factor.Factor, factor.Points, factor.MaxPoints))
+	}
+	summary := strings.Join(parts, "; ")
+	if len(summary) > 500 {
+		summary = summary[:500]
+	}
+	return summary
+}
+
+func int64PointerOrNil(value int64) *int64 {
+	if value <= 0 {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,408 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/config"
+)
+
+const defaultInvoicePriceTolerancePercent = 1.0
+
+// InvoicePriceCheckSource holds the prices compared for one reconciliation
+// row: the invoiced unit price before VAT and its VAT rate in percent, the
+// contracted price from supplies.PRICE and the tender price from
+// so_sanh_vat_tu.don_gia_trung_thau_2025.
+type InvoicePriceCheckSource struct {
+	ReconciliationID int64
+	InvoiceUnitPrice float64
+	InvoiceVATRate   float64
+	ContractPrice    *float64
+	TenderPrice      *float64
+}
+
+type InvoicePriceVariance struct {
+	ReconciliationID int64
+	InvoiceUnitPrice *float64
+	ContractPrice    *float64
+	TenderPrice      *float64
+	ReferencePrice   *float64
+	Variance         *float64
+	VariancePercent  *float64
+	Flagged          bool
+}
+
+type InvoicePriceVarianceFilter struct {
+	From        *time.Time
+	To          *time.Time
+	FlaggedOnly bool
+}
+
+type InvoicePriceVarianceLine struct {
+	ReconciliationID     int64     `json:"reconciliationId"`
+	InvoiceNumber        string    `json:"invoiceNumber"`
+	InvoiceTime          time.Time `json:"invoiceTime"`
+	MaterialCode         string    `json:"materialCode"`
+	TenVtytBv            string    `json:"tenVtytBv"`
+	InvoiceQty           float64   `json:"invoiceQty"`
+	InvoiceUnitPrice     float64   `json:"invoiceUnitPrice"`
+	ContractUnitPrice    *float64  `json:"contractUnitPrice,omitempty"`
+	TenderUnitPrice      *float64  `json:"tenderUnitPrice,omitempty"`
+	ReferenceUnitPrice   float64   `json:"referenceUnitPrice"`
+	PriceVariance        float64   `json:"priceVariance"`
+	PriceVariancePercent float64   `json:"priceVariancePercent"`
+	Flagged              bool      `json:"flagged"`
+}
+
+// InvoicePriceVarianceGroup totals one supplier's variances for one invoice
+// month. OverchargeAmount sums variance × invoiced quantity over flagged lines.
+type InvoicePriceVarianceGroup struct {
+	SupplierKey      string                     `json:"supplierKey"`
+	SupplierName     string                     `json:"supplierName"`
+	Month            string                     `json:"month"`
+	CheckedLines     int                        `json:"checkedLines"`
+	FlaggedLines     int                        `json:"flaggedLines"`
+	OverchargeAmount float64                    `json:"overchargeAmount"`
+	Lines            []InvoicePriceVarianceLine `json:"lines"`
+}
+
+func resolveInvoicePriceTolerancePercent() float64 {
+	if config.AppConfig == nil || config.AppConfig.InvoicePriceTolerancePercent < 0 {
+		return defaultInvoicePriceTolerancePercent
+	}
+	return config.AppConfig.InvoicePriceTolerancePercent
+}
+
+// EvaluateInvoicePriceVariance compares the VAT-inclusive invoice unit price
+// with the lower of the contract and tender prices, since both are quoted
+// with VAT. The line is flagged when it exceeds that reference by more than
+// tolerancePercent.
+func EvaluateInvoicePriceVariance(source InvoicePriceCheckSource, tolerancePercent float64) InvoicePriceVariance {
+	result := InvoicePriceVariance{
+		ReconciliationID: source.ReconciliationID,
+		ContractPrice:    positivePrice(source.ContractPrice),
+		TenderPrice:      positivePrice(source.TenderPrice),
+	}
+	if source.InvoiceUnitPrice <= 0 {
+		return result
+	}
+
+	invoicePrice := roundPrice(source.InvoiceUnitPrice * (1 + math.Max(source.InvoiceVATRate, 0)/100))
+	result.InvoiceUnitPrice = &invoicePrice
+
+	for _, candidate := range []*float64{result.ContractPrice, result.TenderPrice} {
+		if candidate != nil && (result.ReferencePrice == nil || *candidate < *result.ReferencePrice) {
+			value := *candidate
+			result.ReferencePrice = &value
+		}
+	}
+	if result.ReferencePrice == nil {
+		return result
+	}
+
+	variance := roundPrice(invoicePrice - *result.ReferencePrice)
+	percent := math.Round(variance / *result.ReferencePrice * 100000) / 1000
+	result.Variance = &variance
+	result.VariancePercent = &percent
+	result.Flagged = variance > 0 && percent > tolerancePercent
+	return result
+}
+
+func (r *InvoiceReconciliationRepository) ensureInvoicePriceVarianceColumns() error {
+	columns := []struct {
+		name      string
+		statement string
+	}{
+		{"invoice_unit_price", "ALTER TABLE order_invoice_reconciliation ADD COLUMN invoice_unit_price DECIMAL(18,2) NULL AFTER vinmes_submitted_at"},
+		{"contract_unit_price", "ALTER TABLE order_invoice_reconciliation ADD COLUMN contract_unit_price DECIMAL(18,2) NULL AFTER invoice_unit_price"},
+		{"tender_unit_price", "ALTER TABLE order_invoice_reconciliation ADD COLUMN tender_unit_price DECIMAL(18,2) NULL AFTER contract_unit_price"},
+		{"reference_unit_price", "ALTER TABLE order_invoice_reconciliation ADD COLUMN reference_unit_price DECIMAL(18,2) NULL AFTER tender_unit_price"},
+		{"price_variance", "ALTER TABLE order_invoice_reconciliation ADD COLUMN price_variance DECIMAL(18,2) NULL AFTER reference_unit_price"},
+		{"price_variance_percent", "ALTER TABLE order_invoice_reconciliation ADD COLUMN price_variance_percent DECIMAL(10,3) NULL AFTER price_variance"},
+		{"price_variance_flag", "ALTER TABLE order_invoice_reconciliation ADD COLUMN price_variance_flag TINYINT(1) NOT NULL DEFAULT 0 AFTER price_variance_percent"},
+		{"price_checked_at", "ALTER TABLE order_invoice_reconciliation ADD COLUMN price_checked_at DATETIME NULL AFTER price_variance_flag"},
+	}
+	for _, column := range columns {
+		if err := r.ensureColumnExists("order_invoice_reconciliation", column.name, column.statement); err != nil {
+			return err
+		}
+	}
+
+	return r.ensureIndexExists(
+		"order_invoice_reconciliation",
+		"idx_oir_price_variance",
+		"ALTER TABLE order_invoice_reconciliation ADD INDEX idx_oir_price_variance (price_variance_flag, invoice_time)",
+	)
+}
+
+// RecheckInvoicePriceVariances re-evaluates every reconciliation linked to an
+// invoice row, e.g. after supply prices or the tolerance changed.
+func (r *InvoiceReconciliationRepository) RecheckInvoicePriceVariances(now time.Time) (int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting invoice price check transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	checked, err := applyInvoicePriceVariancesTx(tx, `r.invoice_row_id IS NOT NULL`, nil, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing invoice price check: %w", err)
+	}
+	return checked, nil
+}
+
+// applyInvoicePriceVariancesTx stores the price comparison on the
+// reconciliation rows selected by condition.
+func applyInvoicePriceVariancesTx(tx *sql.Tx, condition string, args []interface{}, now time.Time) (int, error) {
+	rows, err := tx.Query(`
+		SELECT
+			r.id,
+			COALESCE(h.don_gia_chua_thue, 0),
+			COALESCE(h.thue_suat_gtgt, 0),
+			(
+				SELECT s.PRICE
+				FROM supplies s
+				WHERE (
+					TRIM(r.ma_quan_ly) <> ''
+					AND TRIM(COALESCE(s.TYPENAME, '')) = TRIM(r.ma_quan_ly)
+				) OR (
+					TRIM(r.ma_vtyt_cu) <> ''
+					AND TRIM(COALESCE(s.ID, '')) = TRIM(r.ma_vtyt_cu)
+				)
+				ORDER BY
+					CASE WHEN TRIM(COALESCE(s.TYPENAME, '')) = TRIM(r.ma_quan_ly) THEN 0 ELSE 1 END,
+					s.IDX1
+				LIMIT 1
+			),
+			(
+				SELECT ss.don_gia_trung_thau_2025
+				FROM so_sanh_vat_tu ss
+				WHERE TRIM(COALESCE(ss.ma_thu_vien, '')) <> ''
+					AND TRIM(ss.ma_thu_vien) IN (TRIM(r.ma_quan_ly), TRIM(r.ma_vtyt_cu))
+				ORDER BY
+					CASE WHEN TRIM(ss.ma_thu_vien) = TRIM(r.ma_quan_ly) THEN 0 ELSE 1 END,
+					ss.stt
+				LIMIT 1
+			)
+		FROM order_invoice_reconciliation r
+		JOIN hoa_don h ON h.id = r.invoice_row_id
+		WHERE `+condition, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error loading invoice price check sources: %w", err)
+	}
+
+	sources := make([]InvoicePriceCheckSource, 0)
+	for rows.Next() {
+		var source InvoicePriceCheckSource
+		var contractPrice, tenderPrice sql.NullFloat64
+		if err := rows.Scan(&source.ReconciliationID, &source.InvoiceUnitPrice, &source.InvoiceVATRate, &contractPrice, &tenderPrice); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error scanning invoice price check source: %w", err)
+		}
+		source.ContractPrice = nullFloat64Pointer(contractPrice)
+		source.TenderPrice = nullFloat64Pointer(tenderPrice)
+		sources = append(sources, source)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("error iterating invoice price check sources: %w", err)
+	}
+	rows.Close()
+
+	tolerance := resolveInvoicePriceTolerancePercent()
+	for _, source := range sources {
+		variance := EvaluateInvoicePriceVariance(source, tolerance)
+		if _, err := tx.Exec(`
+			UPDATE order_invoice_reconciliation
+			SET invoice_unit_price = ?, contract_unit_price = ?, tender_unit_price = ?, reference_unit_price = ?,
+				price_variance = ?, price_variance_percent = ?, price_variance_flag = ?, price_checked_at = ?
+			WHERE id = ?
+		`,
+			nullableFloat64Value(variance.InvoiceUnitPrice),
+			nullableFloat64Value(variance.ContractPrice),
+			nullableFloat64Value(variance.TenderPrice),
+			nullableFloat64Value(variance.ReferencePrice),
+			nullableFloat64Value(variance.Variance),
+			nullableFloat64Value(variance.VariancePercent),
+			boolToTinyInt(variance.Flagged),
+			now,
+			variance.ReconciliationID,
+		); err != nil {
+			return 0, fmt.Errorf("error storing invoice price variance: %w", err)
+		}
+	}
+	return len(sources), nil
+}
+
+// ListInvoicePriceVariances groups checked reconciliation lines by supplier
+// and invoice month, newest month first.
+func (r *InvoiceReconciliationRepository) ListInvoicePriceVariances(filter InvoicePriceVarianceFilter) ([]InvoicePriceVarianceGroup, error) {
+	queryBuilder := strings.Builder{}
+	queryBuilder.WriteString(`
+		SELECT
+			r.id,
+			r.invoice_number,
+			COALESCE(r.invoice_time, h.ngay_hoa_don, r.matched_at),
+			COALESCE(NULLIF(r.invoice_company_contact_id, ''), NULLIF(h.ma_so_thue_nguoi_ban, ''), NULLIF(r.company_contact_id, ''), ''),
+			COALESCE(NULLIF(r.invoice_company_name, ''), NULLIF(h.cong_ty, ''), r.nha_thau),
+			r.ma_quan_ly,
+			r.ma_vtyt_cu,
+			r.ten_vtyt_bv,
+			CASE WHEN r.invoice_qty > 0 THEN r.invoice_qty ELSE COALESCE(h.so_luong, 0) END,
+			r.invoice_unit_price,
+			r.contract_unit_price,
+			r.tender_unit_price,
+			r.reference_unit_price,
+			r.price_variance,
+			r.price_variance_percent,
+			r.price_variance_flag
+		FROM order_invoice_reconciliation r
+		LEFT JOIN hoa_don h ON h.id = r.invoice_row_id
+		WHERE r.reference_unit_price IS NOT NULL AND r.invoice_unit_price IS NOT NULL
+	`)
+	args := make([]interface{}, 0, 2)
+	if filter.From != nil {
+		queryBuilder.WriteString(` AND COALESCE(r.invoice_time, h.ngay_hoa_don, r.matched_at) >= ?`)
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		queryBuilder.WriteString(` AND COALESCE(r.invoice_time, h.ngay_hoa_don, r.matched_at) < ?`)
+		args = append(args, *filter.To)
+	}
+	if filter.FlaggedOnly {
+		queryBuilder.WriteString(` AND r.price_variance_flag = 1`)
+	}
+	queryBuilder.WriteString(` ORDER BY r.id`)
+
+	rows, err := r.DB.Query(queryBuilder.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing invoice price variances: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make(map[string]*InvoicePriceVarianceGroup)
+	for rows.Next() {
+		var line InvoicePriceVarianceLine
+		var taxCode, supplierName, maQuanLy, maVtytCu string
+		var contractPrice, tenderPrice sql.NullFloat64
+		var flagged int
+		if err := rows.Scan(
+			&line.ReconciliationID,
+			&line.InvoiceNumber,
+			&line.InvoiceTime,
+			&taxCode,
+			&supplierName,
+			&maQuanLy,
+			&maVtytCu,
+			&line.TenVtytBv,
+			&line.InvoiceQty,
+			&line.InvoiceUnitPrice,
+			&contractPrice,
+			&tenderPrice,
+			&line.ReferenceUnitPrice,
+			&line.PriceVariance,
+			&line.PriceVariancePercent,
+			&flagged,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning invoice price variance: %w", err)
+		}
+		line.MaterialCode = PreferredMaterialCode(NormalizeMaterialIdentifiers(maQuanLy, maVtytCu))
+		line.ContractUnitPrice = nullFloat64Pointer(contractPrice)
+		line.TenderUnitPrice = nullFloat64Pointer(tenderPrice)
+		line.Flagged = flagged == 1
+
+		supplierKey := strings.TrimSpace(taxCode)
+		if supplierKey == "" {
+			supplierKey = "name:" + strings.ToLower(strings.TrimSpace(supplierName))
+		}
+		month := line.InvoiceTime.Format("2006-01")
+		groupKey := supplierKey + "|" + month
+		group, ok := groups[groupKey]
+		if !ok {
+			group = &InvoicePriceVarianceGroup{
+				SupplierKey:  supplierKey,
+				SupplierName: strings.TrimSpace(supplierName),
+				Month:        month,
+				Lines:        make([]InvoicePriceVarianceLine, 0),
+			}
+			groups[groupKey] = group
+		}
+		group.CheckedLines++
+		if line.Flagged {
+			group.FlaggedLines++
+			group.OverchargeAmount = roundPrice(group.OverchargeAmount + line.PriceVariance*line.InvoiceQty)
+		}
+		group.Lines = append(group.Lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invoice price variances: %w", err)
+	}
+
+	result := make([]InvoicePriceVarianceGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Month != result[j].Month {
+			return result[i].Month > result[j].Month
+		}
+		if result[i].OverchargeAmount != result[j].OverchargeAmount {
+			return result[i].OverchargeAmount > result[j].OverchargeAmount
+		}
+		return result[i].SupplierName < result[j].SupplierName
+	})
+	return result, nil
+}
+
+// invoiceReconciliationPriceColumns scans the stored price check of a
+// reconciliation row.
+type invoiceReconciliationPriceColumns struct {
+	invoiceUnitPrice   sql.NullFloat64
+	referenceUnitPrice sql.NullFloat64
+	variance           sql.NullFloat64
+	variancePercent    sql.NullFloat64
+	flagged            int
+}
+
+func (c invoiceReconciliationPriceColumns) apply(item *InvoiceReconciliationRecord) {
+	item.InvoiceUnitPrice = nullFloat64Pointer(c.invoiceUnitPrice)
+	item.ReferenceUnitPrice = nullFloat64Pointer(c.referenceUnitPrice)
+	item.PriceVariance = nullFloat64Pointer(c.variance)
+	item.PriceVariancePercent = nullFloat64Pointer(c.variancePercent)
+	item.PriceVarianceFlagged = c.flagged == 1
+}
+
+func nullFloat64Pointer(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	result := value.Float64
+	return &result
+}
+
+func positivePrice(value *float64) *float64 {
+	if value == nil || *value <= 0 {
+		return nil
+	}
+	price := roundPrice(*value)
+	return &price
+}
+
+func roundPrice(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func nullableFloat64Value(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
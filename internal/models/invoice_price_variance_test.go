@@ -0,0 +1,48 @@
+package models
+
+import "testing"
+
+func TestEvaluateInvoicePriceVarianceUsesLowestReference(t *testing.T) {
+	t.Parallel()
+
+	contract := 110000.0
+	tender := 105000.0
+	result := EvaluateInvoicePriceVariance(InvoicePriceCheckSource{
+		ReconciliationID: 4,
+		InvoiceUnitPrice: 100000,
+		InvoiceVATRate:   8,
+		ContractPrice:    &contract,
+		TenderPrice:      &tender,
+	}, 1)
+
+	if result.InvoiceUnitPrice == nil || *result.InvoiceUnitPrice != 108000 {
+		t.Fatalf("invoice unit price = %v, want 108000 with VAT", result.InvoiceUnitPrice)
+	}
+	if result.ReferencePrice == nil || *result.ReferencePrice != tender {
+		t.Fatalf("reference price = %v, want the tender price", result.ReferencePrice)
+	}
+	if *result.Variance != 3000 || *result.VariancePercent != 2.857 || !result.Flagged {
+		t.Fatalf("variance = %v (%v%%), flagged = %v", *result.Variance, *result.VariancePercent, result.Flagged)
+	}
+}
+
+func TestEvaluateInvoicePriceVarianceTolerance(t *testing.T) {
+	t.Parallel()
+
+	contract := 100000.0
+	withinTolerance := EvaluateInvoicePriceVariance(InvoicePriceCheckSource{InvoiceUnitPrice: 100500, ContractPrice: &contract}, 1)
+	if withinTolerance.Flagged || *withinTolerance.VariancePercent != 0.5 {
+		t.Fatalf("0.5%% over a 1%% tolerance flagged = %v, percent = %v", withinTolerance.Flagged, *withinTolerance.VariancePercent)
+	}
+
+	below := EvaluateInvoicePriceVariance(InvoicePriceCheckSource{InvoiceUnitPrice: 90000, ContractPrice: &contract}, 0)
+	if below.Flagged || *below.Variance != -10000 {
+		t.Fatalf("cheaper invoice flagged = %v, variance = %v", below.Flagged, *below.Variance)
+	}
+
+	zero := 0.0
+	noReference := EvaluateInvoicePriceVariance(InvoicePriceCheckSource{InvoiceUnitPrice: 90000, ContractPrice: &zero}, 0)
+	if noReference.ReferencePrice != nil || noReference.Variance != nil || noReference.Flagged {
+		t.Fatalf("result without a positive reference price = %+v", noReference)
+	}
+}
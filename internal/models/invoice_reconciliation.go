@@ -51,6 +51,11 @@ type InvoiceReconciliationRecord struct {
 	UpdatedAt               time.Time  `json:"updatedAt"`
 	Note                    string     `json:"note,omitempty"`
 	Status                  string     `json:"status"`
+	InvoiceUnitPrice        *float64   `json:"invoiceUnitPrice,omitempty"`
+	ReferenceUnitPrice      *float64   `json:"referenceUnitPrice,omitempty"`
+	PriceVariance           *float64   `json:"priceVariance,omitempty"`
+	PriceVariancePercent    *float64   `json:"priceVariancePercent,omitempty"`
+	PriceVarianceFlagged    bool       `json:"priceVarianceFlagged"`
 }
 
 type UpsertInvoiceReconciliationInput struct {
@@ -176,9 +181,98 @@ func (r *InvoiceReconciliationRepository) EnsureSchema() error {
 		return err
 	}
 
-	return nil
+	if err := r.ensureColumnExists(
+		"order_invoice_reconciliation",
+		"vinmes_po_id",
+		"ALTER TABLE order_invoice_reconciliation ADD COLUMN vinmes_po_id BIGINT NULL AFTER status",
+	); err != nil {
+		return err
+	}
+
+	if err := r.ensureColumnExists(
+		"order_invoice_reconciliation",
+		"vinmes_submitted_at",
+		"ALTER TABLE order_invoice_reconciliation ADD COLUMN vinmes_submitted_at DATETIME NULL AFTER vinmes_po_id",
+	); err != nil {
+		return err
+	}
+
+	if err := r.ensureIndexExists(
+		"order_invoice_reconciliation",
+		"idx_oir_vinmes_po",
+		"ALTER TABLE order_invoice_reconciliation ADD INDEX idx_oir_vinmes_po (vinmes_po_id)",
+	); err != nil {
+		return err
+	}
+
+	if err := r.ensureInvoicePriceVarianceColumns(); err != nil {
+		return err
+	}
+
+	return r.ensureInvoiceMatchSchema()
 }
 
+const upsertInvoiceReconciliationStatement = `
+	INSERT INTO order_invoice_reconciliation (
+		order_history_id,
+		order_batch_key,
+		company_contact_id,
+		nha_thau,
+		ma_quan_ly,
+		ma_vtyt_cu,
+		ten_vtyt_bv,
+		ordered_qty,
+		order_time,
+		invoice_number,
+		invoice_id_hoa_don,
+		invoice_row_id,
+		invoice_company_contact_id,
+		invoice_company_name,
+		invoice_item_code,
+		invoice_item_name,
+		invoice_qty,
+		invoice_time,
+		has_invoice,
+		detail_status,
+		detail_note,
+		match_score,
+		quantity_diff,
+		matched_by_user_id,
+		matched_by_username,
+		matched_by_email,
+		matched_at,
+		note,
+		status
+	)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		company_contact_id = VALUES(company_contact_id),
+		nha_thau = VALUES(nha_thau),
+		ma_quan_ly = VALUES(ma_quan_ly),
+		ma_vtyt_cu = VALUES(ma_vtyt_cu),
+		ten_vtyt_bv = VALUES(ten_vtyt_bv),
+		ordered_qty = VALUES(ordered_qty),
+		order_time = VALUES(order_time),
+		invoice_id_hoa_don = VALUES(invoice_id_hoa_don),
+		invoice_row_id = VALUES(invoice_row_id),
+		invoice_company_contact_id = VALUES(invoice_company_contact_id),
+		invoice_company_name = VALUES(invoice_company_name),
+		invoice_item_code = VALUES(invoice_item_code),
+		invoice_item_name = VALUES(invoice_item_name),
+		invoice_qty = VALUES(invoice_qty),
+		invoice_time = VALUES(invoice_time),
+		has_invoice = VALUES(has_invoice),
+		detail_status = VALUES(detail_status),
+		detail_note = VALUES(detail_note),
+		match_score = VALUES(match_score),
+		quantity_diff = VALUES(quantity_diff),
+		matched_by_user_id = VALUES(matched_by_user_id),
+		matched_by_username = VALUES(matched_by_username),
+		matched_by_email = VALUES(matched_by_email),
+		matched_at = VALUES(matched_at),
+		updated_at = CURRENT_TIMESTAMP
+`
+
 func (r *InvoiceReconciliationRepository) UpsertBulk(inputs []UpsertInvoiceReconciliationInput) error {
 	if len(inputs) == 0 {
 		return nil
@@ -190,116 +284,12 @@ func (r *InvoiceReconciliationRepository) UpsertBulk(inputs []UpsertInvoiceRecon
 	}
 	defer tx.Rollback()
 
-	statement := `
-		INSERT INTO order_invoice_reconciliation (
-			order_history_id,
-			order_batch_key,
-			company_contact_id,
-			nha_thau,
-			ma_quan_ly,
-			ma_vtyt_cu,
-			ten_vtyt_bv,
-			ordered_qty,
-			order_time,
-			invoice_number,
-			invoice_id_hoa_don,
-			invoice_row_id,
-			invoice_company_contact_id,
-			invoice_company_name,
-			invoice_item_code,
-			invoice_item_name,
-			invoice_qty,
-			invoice_time,
-			has_invoice,
-			detail_status,
-			detail_note,
-			match_score,
-			quantity_diff,
-			matched_by_user_id,
-			matched_by_username,
-			matched_by_email,
-			matched_at,
-			note,
-			status
-		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON DUPLICATE KEY UPDATE
-			company_contact_id = VALUES(company_contact_id),
-			nha_thau = VALUES(nha_thau),
-			ma_quan_ly = VALUES(ma_quan_ly),
-			ma_vtyt_cu = VALUES(ma_vtyt_cu),
-			ten_vtyt_bv = VALUES(ten_vtyt_bv),
-			ordered_qty = VALUES(ordered_qty),
-			order_time = VALUES(order_time),
-			invoice_id_hoa_don = VALUES(invoice_id_hoa_don),
-			invoice_row_id = VALUES(invoice_row_id),
-			invoice_company_contact_id = VALUES(invoice_company_contact_id),
-			invoice_company_name = VALUES(invoice_company_name),
-			invoice_item_code = VALUES(invoice_item_code),
-			invoice_item_name = VALUES(invoice_item_name),
-			invoice_qty = VALUES(invoice_qty),
-			invoice_time = VALUES(invoice_time),
-			has_invoice = VALUES(has_invoice),
-			detail_status = VALUES(detail_status),
-			detail_note = VALUES(detail_note),
-			match_score = VALUES(match_score),
-			quantity_diff = VALUES(quantity_diff),
-			matched_by_user_id = VALUES(matched_by_user_id),
-			matched_by_username = VALUES(matched_by_username),
-			matched_by_email = VALUES(matched_by_email),
-			matched_at = VALUES(matched_at),
-			updated_at = CURRENT_TIMESTAMP
-	`
-
 	for _, input := range inputs {
 		if input.OrderHistoryID <= 0 {
 			continue
 		}
-
-		matchedAt := input.MatchedAt
-		if matchedAt.IsZero() {
-			matchedAt = time.Now().UTC()
-		}
-
-		status := normalizeInvoiceReconciliationStatus(input.Status)
-		if status == "" {
-			status = InvoiceReconciliationStatusPending
-		}
-		maQuanLy, maVtytCu := NormalizeMaterialIdentifiers(input.MaQuanLy, input.MaVtytCu)
-
-		if _, err := tx.Exec(
-			statement,
-			input.OrderHistoryID,
-			strings.TrimSpace(input.OrderBatchKey),
-			nullableStringValue(input.CompanyContactID),
-			strings.TrimSpace(input.NhaThau),
-			maQuanLy,
-			maVtytCu,
-			strings.TrimSpace(input.TenVtytBv),
-			input.OrderedQty,
-			nullableTimeValue(input.OrderTime),
-			strings.TrimSpace(input.InvoiceNumber),
-			strings.TrimSpace(input.InvoiceIDHoaDon),
-			nullableInt64Value(input.InvoiceRowID),
-			nullableStringValue(input.InvoiceCompanyContactID),
-			strings.TrimSpace(input.InvoiceCompanyName),
-			strings.TrimSpace(input.InvoiceItemCode),
-			strings.TrimSpace(input.InvoiceItemName),
-			input.InvoiceQty,
-			nullableTimeValue(input.InvoiceTime),
-			boolToTinyInt(input.HasInvoice),
-			strings.TrimSpace(input.DetailStatus),
-			strings.TrimSpace(input.DetailNote),
-			input.MatchScore,
-			input.QuantityDiff,
-			nullableInt64Value(input.MatchedByUserID),
-			strings.TrimSpace(input.MatchedByUsername),
-			strings.TrimSpace(input.MatchedByEmail),
-			matchedAt,
-			strings.TrimSpace(input.Note),
-			status,
-		); err != nil {
-			return fmt.Errorf("error upserting invoice reconciliation: %w", err)
+		if err := upsertInvoiceReconciliationTx(tx, input); err != nil {
+			return err
 		}
 	}
 
@@ -310,6 +300,66 @@ func (r *InvoiceReconciliationRepository) UpsertBulk(inputs []UpsertInvoiceRecon
 	return nil
 }
 
+func upsertInvoiceReconciliationTx(tx *sql.Tx, input UpsertInvoiceReconciliationInput) error {
+	matchedAt := input.MatchedAt
+	if matchedAt.IsZero() {
+		matchedAt = time.Now().UTC()
+	}
+
+	status := normalizeInvoiceReconciliationStatus(input.Status)
+	if status == "" {
+		status = InvoiceReconciliationStatusPending
+	}
+	maQuanLy, maVtytCu := NormalizeMaterialIdentifiers(input.MaQuanLy, input.MaVtytCu)
+
+	if _, err := tx.Exec(
+		upsertInvoiceReconciliationStatement,
+		input.OrderHistoryID,
+		strings.TrimSpace(input.OrderBatchKey),
+		nullableStringValue(input.CompanyContactID),
+		strings.TrimSpace(input.NhaThau),
+		maQuanLy,
+		maVtytCu,
+		strings.TrimSpace(input.TenVtytBv),
+		input.OrderedQty,
+		nullableTimeValue(input.OrderTime),
+		strings.TrimSpace(input.InvoiceNumber),
+		strings.TrimSpace(input.InvoiceIDHoaDon),
+		nullableInt64Value(input.InvoiceRowID),
+		nullableStringValue(input.InvoiceCompanyContactID),
+		strings.TrimSpace(input.InvoiceCompanyName),
+		strings.TrimSpace(input.InvoiceItemCode),
+		strings.TrimSpace(input.InvoiceItemName),
+		input.InvoiceQty,
+		nullableTimeValue(input.InvoiceTime),
+		boolToTinyInt(input.HasInvoice),
+		strings.TrimSpace(input.DetailStatus),
+		strings.TrimSpace(input.DetailNote),
+		input.MatchScore,
+		input.QuantityDiff,
+		nullableInt64Value(input.MatchedByUserID),
+		strings.TrimSpace(input.MatchedByUsername),
+		strings.TrimSpace(input.MatchedByEmail),
+		matchedAt,
+		strings.TrimSpace(input.Note),
+		status,
+	); err != nil {
+		return fmt.Errorf("error upserting invoice reconciliation: %w", err)
+	}
+
+	if input.InvoiceRowID != nil {
+		if _, err := applyInvoicePriceVariancesTx(
+			tx,
+			`r.order_history_id = ? AND r.order_batch_key = ? AND r.invoice_number = ?`,
+			[]interface{}{input.OrderHistoryID, strings.TrimSpace(input.OrderBatchKey), strings.TrimSpace(input.InvoiceNumber)},
+			time.Now().UTC(),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *InvoiceReconciliationRepository) ListByMonthYear(month, year int) ([]InvoiceReconciliationRecord, error) {
 	rows, err := r.DB.Query(`
 		SELECT
@@ -344,7 +394,12 @@ func (r *InvoiceReconciliationRepository) ListByMonthYear(month, year int) ([]In
 			created_at,
 			updated_at,
 			note,
-			status
+			status,
+			invoice_unit_price,
+			reference_unit_price,
+			price_variance,
+			price_variance_percent,
+			price_variance_flag
 		FROM order_invoice_reconciliation
 		WHERE has_invoice = 1 AND status IN (?, ?)
 			AND MONTH(matched_at) = ?
@@ -367,6 +422,7 @@ func (r *InvoiceReconciliationRepository) ListByMonthYear(month, year int) ([]In
 		var hasInvoice int
 		var matchedByUserID sql.NullInt64
 		var note sql.NullString
+		var prices invoiceReconciliationPriceColumns
 
 		if err := rows.Scan(
 			&item.ID,
@@ -401,6 +457,11 @@ func (r *InvoiceReconciliationRepository) ListByMonthYear(month, year int) ([]In
 			&item.UpdatedAt,
 			&note,
 			&item.Status,
+			&prices.invoiceUnitPrice,
+			&prices.referenceUnitPrice,
+			&prices.variance,
+			&prices.variancePercent,
+			&prices.flagged,
 		); err != nil {
 			return nil, fmt.Errorf("error scanning invoice reconciliation history: %w", err)
 		}
@@ -430,6 +491,7 @@ func (r *InvoiceReconciliationRepository) ListByMonthYear(month, year int) ([]In
 		if note.Valid {
 			item.Note = note.String
 		}
+		prices.apply(&item)
 		if matchedByUserID.Valid {
 			value := matchedByUserID.Int64
 			item.MatchedByUserID = &value
@@ -512,7 +574,12 @@ func (r *InvoiceReconciliationRepository) ListAllReconciliations() ([]InvoiceRec
 			created_at,
 			updated_at,
 			note,
-			status
+			status,
+			invoice_unit_price,
+			reference_unit_price,
+			price_variance,
+			price_variance_percent,
+			price_variance_flag
 		FROM order_invoice_reconciliation
 		ORDER BY updated_at DESC, matched_at DESC, id DESC
 	`)
@@ -532,6 +599,7 @@ func (r *InvoiceReconciliationRepository) ListAllReconciliations() ([]InvoiceRec
 		var hasInvoice int
 		var matchedByUserID sql.NullInt64
 		var note sql.NullString
+		var prices invoiceReconciliationPriceColumns
 
 		if err := rows.Scan(
 			&item.ID,
@@ -566,6 +634,11 @@ func (r *InvoiceReconciliationRepository) ListAllReconciliations() ([]InvoiceRec
 			&item.UpdatedAt,
 			&note,
 			&item.Status,
+			&prices.invoiceUnitPrice,
+			&prices.referenceUnitPrice,
+			&prices.variance,
+			&prices.variancePercent,
+			&prices.flagged,
 		); err != nil {
 			return nil, fmt.Errorf("error scanning invoice reconciliation: %w", err)
 		}
@@ -595,6 +668,7 @@ func (r *InvoiceReconciliationRepository) ListAllReconciliations() ([]InvoiceRec
 		if note.Valid {
 			item.Note = note.String
 		}
+		prices.apply(&item)
 		if matchedByUserID.Valid {
 			value := matchedByUserID.Int64
 			item.MatchedByUserID = &value
@@ -726,6 +800,49 @@ func (r *InvoiceReconciliationRepository) UpdateStatusesBulk(inputs []UpdateInvo
 	return updatedCount, nil
 }
 
+// InvoiceReconciliationNoteStatus is the part of a reconciliation row that
+// the bulk save edits.
+type InvoiceReconciliationNoteStatus struct {
+	ID     int64  `json:"id"`
+	Note   string `json:"note"`
+	Status string `json:"status"`
+}
+
+func (r *InvoiceReconciliationRepository) ListNoteStatusByIDs(ids []int64) ([]InvoiceReconciliationNoteStatus, error) {
+	if len(ids) == 0 {
+		return []InvoiceReconciliationNoteStatus{}, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for index, id := range ids {
+		args[index] = id
+	}
+
+	rows, err := r.DB.Query(fmt.Sprintf(`
+		SELECT id, COALESCE(note, ''), status
+		FROM order_invoice_reconciliation
+		WHERE id IN (%s)
+		ORDER BY id
+	`, makePlaceholders(len(ids))), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing invoice reconciliation notes: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]InvoiceReconciliationNoteStatus, 0, len(ids))
+	for rows.Next() {
+		var item InvoiceReconciliationNoteStatus
+		if err := rows.Scan(&item.ID, &item.Note, &item.Status); err != nil {
+			return nil, fmt.Errorf("error scanning invoice reconciliation note: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invoice reconciliation notes: %w", err)
+	}
+	return items, nil
+}
+
 func nullableInt64Value(value *int64) interface{} {
 	if value == nil {
 		return nil
@@ -0,0 +1,425 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	InvoiceExceptionMissingInvoice   = "missing_invoice"
+	InvoiceExceptionUnmatchedInvoice = "unmatched_invoice"
+	InvoiceExceptionQuantityMismatch = "quantity_mismatch"
+
+	defaultInvoiceAgingLookbackDays = 180
+)
+
+// invoiceAgingBuckets are the upper bounds, in days, of the age buckets; the
+// last bucket is open-ended.
+var invoiceAgingBuckets = []struct {
+	label  string
+	maxAge int
+}{
+	{"0-7", 7},
+	{"8-14", 14},
+	{"15-30", 30},
+	{"31-60", 60},
+	{"60+", math.MaxInt},
+}
+
+type InvoiceAgingFilter struct {
+	MinAgeDays   int
+	LookbackDays int
+}
+
+// InvoiceReconciliationException is one line Thủ kho has to follow up with a
+// supplier, with the reason spelled out.
+type InvoiceReconciliationException struct {
+	Kind                 string    `json:"kind"`
+	Reason               string    `json:"reason"`
+	AgeDays              int       `json:"ageDays"`
+	AgeBucket            string    `json:"ageBucket"`
+	Since                time.Time `json:"since"`
+	SupplierKey          string    `json:"supplierKey"`
+	SupplierName         string    `json:"supplierName"`
+	OrderHistoryID       *int64    `json:"orderHistoryId,omitempty"`
+	ReconciliationID     *int64    `json:"reconciliationId,omitempty"`
+	InvoiceRowID         *int64    `json:"invoiceRowId,omitempty"`
+	InvoiceNumber        string    `json:"invoiceNumber,omitempty"`
+	MaterialCode         string    `json:"materialCode"`
+	TenVatTu             string    `json:"tenVatTu"`
+	OrderedQty           *float64  `json:"orderedQty,omitempty"`
+	DeliveredQty         *float64  `json:"deliveredQty,omitempty"`
+	InvoiceQty           *float64  `json:"invoiceQty,omitempty"`
+	QuantityDiff         *float64  `json:"quantityDiff,omitempty"`
+	PendingMatchProposal bool      `json:"pendingMatchProposal,omitempty"`
+}
+
+type InvoiceAgingBucketCount struct {
+	Bucket           string `json:"bucket"`
+	MissingInvoice   int    `json:"missingInvoice"`
+	UnmatchedInvoice int    `json:"unmatchedInvoice"`
+	QuantityMismatch int    `json:"quantityMismatch"`
+}
+
+type InvoiceAgingSupplier struct {
+	SupplierKey      string                           `json:"supplierKey"`
+	SupplierName     string                           `json:"supplierName"`
+	MissingInvoice   int                              `json:"missingInvoice"`
+	UnmatchedInvoice int                              `json:"unmatchedInvoice"`
+	QuantityMismatch int                              `json:"quantityMismatch"`
+	OldestAgeDays    int                              `json:"oldestAgeDays"`
+	Buckets          []InvoiceAgingBucketCount        `json:"buckets"`
+	Exceptions       []InvoiceReconciliationException `json:"exceptions"`
+}
+
+type InvoiceAgingReport struct {
+	GeneratedAt  time.Time                 `json:"generatedAt"`
+	MinAgeDays   int                       `json:"minAgeDays"`
+	LookbackDays int                       `json:"lookbackDays"`
+	Totals       []InvoiceAgingBucketCount `json:"totals"`
+	Suppliers    []InvoiceAgingSupplier    `json:"suppliers"`
+}
+
+// GetInvoiceAgingReport collects order lines still without an invoice after
+// MinAgeDays, invoices from known suppliers that match no order line, and
+// open reconciliations whose invoiced quantity differs from the order.
+func (r *InvoiceReconciliationRepository) GetInvoiceAgingReport(filter InvoiceAgingFilter, now time.Time) (*InvoiceAgingReport, error) {
+	if filter.MinAgeDays < 0 {
+		filter.MinAgeDays = resolveOrderDeliveryDueDays()
+	}
+	if filter.LookbackDays <= 0 {
+		filter.LookbackDays = defaultInvoiceAgingLookbackDays
+	}
+
+	missing, err := r.listOrderLinesWithoutInvoice()
+	if err != nil {
+		return nil, err
+	}
+	unmatched, err := r.listInvoicesWithoutOrder(now.AddDate(0, 0, -filter.LookbackDays))
+	if err != nil {
+		return nil, err
+	}
+	mismatched, err := r.listOpenQuantityMismatches()
+	if err != nil {
+		return nil, err
+	}
+
+	exceptions := make([]InvoiceReconciliationException, 0, len(missing)+len(unmatched)+len(mismatched))
+	exceptions = append(exceptions, missing...)
+	exceptions = append(exceptions, unmatched...)
+	exceptions = append(exceptions, mismatched...)
+	return BuildInvoiceAgingReport(exceptions, filter, now), nil
+}
+
+// BuildInvoiceAgingReport ages the exceptions against now, drops order lines
+// younger than MinAgeDays and groups the rest per supplier, oldest first.
+func BuildInvoiceAgingReport(exceptions []InvoiceReconciliationException, filter InvoiceAgingFilter, now time.Time) *InvoiceAgingReport {
+	report := &InvoiceAgingReport{
+		GeneratedAt:  now,
+		MinAgeDays:   filter.MinAgeDays,
+		LookbackDays: filter.LookbackDays,
+		Totals:       newInvoiceAgingBucketCounts(),
+		Suppliers:    make([]InvoiceAgingSupplier, 0),
+	}
+
+	suppliers := make(map[string]*InvoiceAgingSupplier)
+	for _, exception := range exceptions {
+		exception.AgeDays = invoiceAgingDays(exception.Since, now)
+		if exception.Kind == InvoiceExceptionMissingInvoice && exception.AgeDays < filter.MinAgeDays {
+			continue
+		}
+		bucketIndex := invoiceAgingBucketIndex(exception.AgeDays)
+		exception.AgeBucket = invoiceAgingBuckets[bucketIndex].label
+		exception.Reason = describeInvoiceException(exception)
+
+		supplier, ok := suppliers[exception.SupplierKey]
+		if !ok {
+			supplier = &InvoiceAgingSupplier{
+				SupplierKey:  exception.SupplierKey,
+				SupplierName: exception.SupplierName,
+				Buckets:      newInvoiceAgingBucketCounts(),
+				Exceptions:   make([]InvoiceReconciliationException, 0),
+			}
+			suppliers[exception.SupplierKey] = supplier
+		}
+		if supplier.SupplierName == "" {
+			supplier.SupplierName = exception.SupplierName
+		}
+
+		countInvoiceException(&supplier.Buckets[bucketIndex], exception.Kind)
+		countInvoiceException(&report.Totals[bucketIndex], exception.Kind)
+		switch exception.Kind {
+		case InvoiceExceptionMissingInvoice:
+			supplier.MissingInvoice++
+		case InvoiceExceptionUnmatchedInvoice:
+			supplier.UnmatchedInvoice++
+		case InvoiceExceptionQuantityMismatch:
+			supplier.QuantityMismatch++
+		}
+		if exception.AgeDays > supplier.OldestAgeDays {
+			supplier.OldestAgeDays = exception.AgeDays
+		}
+		supplier.Exceptions = append(supplier.Exceptions, exception)
+	}
+
+	for _, supplier := range suppliers {
+		sort.SliceStable(supplier.Exceptions, func(i, j int) bool {
+			return supplier.Exceptions[i].AgeDays > supplier.Exceptions[j].AgeDays
+		})
+		report.Suppliers = append(report.Suppliers, *supplier)
+	}
+	sort.Slice(report.Suppliers, func(i, j int) bool {
+		if report.Suppliers[i].OldestAgeDays != report.Suppliers[j].OldestAgeDays {
+			return report.Suppliers[i].OldestAgeDays > report.Suppliers[j].OldestAgeDays
+		}
+		return report.Suppliers[i].SupplierName < report.Suppliers[j].SupplierName
+	})
+	return report
+}
+
+func (r *InvoiceReconciliationRepository) listOrderLinesWithoutInvoice() ([]InvoiceReconciliationException, error) {
+	rows, err := r.DB.Query(`
+		SELECT oh.id, oh.company_contact_id, oh.nha_thau, oh.ma_quan_ly, oh.ma_vtyt_cu, oh.ten_vtyt_bv,
+			oh.so_luong, oh.delivered_qty, oh.ngay_dat_hang
+		FROM order_history oh
+		WHERE oh.lifecycle_status IN (?, ?, ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM order_invoice_reconciliation r
+				WHERE r.order_history_id = oh.id AND r.has_invoice = 1
+			)
+		ORDER BY oh.id
+	`, OrderLineStatusSent, OrderLineStatusPartiallyDelivered, OrderLineStatusOverdue)
+	if err != nil {
+		return nil, fmt.Errorf("error listing order lines without invoice: %w", err)
+	}
+	defer rows.Close()
+
+	exceptions := make([]InvoiceReconciliationException, 0)
+	for rows.Next() {
+		var id int64
+		var companyContactID sql.NullString
+		var nhaThau, maQuanLy, maVtytCu, ngayDatHang string
+		var exception InvoiceReconciliationException
+		var orderedQty int
+		var deliveredQty float64
+		if err := rows.Scan(&id, &companyContactID, &nhaThau, &maQuanLy, &maVtytCu, &exception.TenVatTu, &orderedQty, &deliveredQty, &ngayDatHang); err != nil {
+			return nil, fmt.Errorf("error scanning order line without invoice: %w", err)
+		}
+
+		ordered := float64(orderedQty)
+		exception.Kind = InvoiceExceptionMissingInvoice
+		exception.Since = parseOrderTimestamp(ngayDatHang)
+		exception.SupplierKey = invoiceAgingSupplierKey(companyContactID.String, nhaThau)
+		exception.SupplierName = strings.TrimSpace(nhaThau)
+		exception.OrderHistoryID = &id
+		exception.MaterialCode = PreferredMaterialCode(NormalizeMaterialIdentifiers(maQuanLy, maVtytCu))
+		exception.OrderedQty = &ordered
+		exception.DeliveredQty = &deliveredQty
+		exceptions = append(exceptions, exception)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order lines without invoice: %w", err)
+	}
+	return exceptions, nil
+}
+
+func (r *InvoiceReconciliationRepository) listInvoicesWithoutOrder(since time.Time) ([]InvoiceReconciliationException, error) {
+	rows, err := r.DB.Query(`
+		SELECT hd.id, hd.so_hoa_don, hd.ngay_hoa_don, hd.ma_so_thue_nguoi_ban, hd.cong_ty,
+			hd.ma_hang_hoa, hd.ten_hang_hoa, hd.so_luong,
+			EXISTS (
+				SELECT 1 FROM invoice_match_proposals p
+				WHERE p.hoa_don_id = hd.id AND p.status = ?
+			)
+		FROM hoa_don hd
+		WHERE hd.ngay_hoa_don >= ?
+			AND hd.ma_so_thue_nguoi_ban IN (
+				SELECT DISTINCT company_contact_id FROM order_history WHERE company_contact_id IS NOT NULL
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM order_invoice_reconciliation r WHERE r.invoice_row_id = hd.id
+			)
+		ORDER BY hd.id
+	`, InvoiceMatchStatusProposed, since)
+	if err != nil {
+		return nil, fmt.Errorf("error listing invoices without order: %w", err)
+	}
+	defer rows.Close()
+
+	exceptions := make([]InvoiceReconciliationException, 0)
+	for rows.Next() {
+		var id int64
+		var taxCode, congTy, maHangHoa string
+		var invoiceQty float64
+		var exception InvoiceReconciliationException
+		if err := rows.Scan(&id, &exception.InvoiceNumber, &exception.Since, &taxCode, &congTy, &maHangHoa, &exception.TenVatTu, &invoiceQty, &exception.PendingMatchProposal); err != nil {
+			return nil, fmt.Errorf("error scanning invoice without order: %w", err)
+		}
+
+		exception.Kind = InvoiceExceptionUnmatchedInvoice
+		exception.SupplierKey = invoiceAgingSupplierKey(taxCode, congTy)
+		exception.SupplierName = strings.TrimSpace(congTy)
+		exception.InvoiceRowID = &id
+		exception.MaterialCode = strings.TrimSpace(maHangHoa)
+		exception.InvoiceQty = &invoiceQty
+		exceptions = append(exceptions, exception)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invoices without order: %w", err)
+	}
+	return exceptions, nil
+}
+
+func (r *InvoiceReconciliationRepository) listOpenQuantityMismatches() ([]InvoiceReconciliationException, error) {
+	rows, err := r.DB.Query(`
+		SELECT r.id, r.order_history_id, r.invoice_row_id, r.company_contact_id, r.nha_thau, r.ma_quan_ly,
+			r.ma_vtyt_cu, r.ten_vtyt_bv, r.ordered_qty, r.invoice_number, r.invoice_qty, r.quantity_diff,
+			COALESCE(r.invoice_time, r.matched_at)
+		FROM order_invoice_reconciliation r
+		WHERE r.has_invoice = 1 AND r.quantity_diff <> 0 AND r.status NOT IN (?, ?)
+		ORDER BY r.id
+	`, InvoiceReconciliationStatusDone, invoiceReconciliationLegacyStatusDone)
+	if err != nil {
+		return nil, fmt.Errorf("error listing invoice quantity mismatches: %w", err)
+	}
+	defer rows.Close()
+
+	exceptions := make([]InvoiceReconciliationException, 0)
+	for rows.Next() {
+		var id, orderHistoryID int64
+		var invoiceRowID sql.NullInt64
+		var companyContactID sql.NullString
+		var nhaThau, maQuanLy, maVtytCu string
+		var orderedQty int
+		var invoiceQty, quantityDiff float64
+		var exception InvoiceReconciliationException
+		if err := rows.Scan(&id, &orderHistoryID, &invoiceRowID, &companyContactID, &nhaThau, &maQuanLy, &maVtytCu, &exception.TenVatTu, &orderedQty, &exception.InvoiceNumber, &invoiceQty, &quantityDiff, &exception.Since); err != nil {
+			return nil, fmt.Errorf("error scanning invoice quantity mismatch: %w", err)
+		}
+
+		ordered := float64(orderedQty)
+		exception.Kind = InvoiceExceptionQuantityMismatch
+		exception.SupplierKey = invoiceAgingSupplierKey(companyContactID.String, nhaThau)
+		exception.SupplierName = strings.TrimSpace(nhaThau)
+		exception.ReconciliationID = &id
+		exception.OrderHistoryID = &orderHistoryID
+		if invoiceRowID.Valid {
+			value := invoiceRowID.Int64
+			exception.InvoiceRowID = &value
+		}
+		exception.MaterialCode = PreferredMaterialCode(NormalizeMaterialIdentifiers(maQuanLy, maVtytCu))
+		exception.OrderedQty = &ordered
+		exception.InvoiceQty = &invoiceQty
+		exception.QuantityDiff = &quantityDiff
+		exceptions = append(exceptions, exception)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invoice quantity mismatches: %w", err)
+	}
+	return exceptions, nil
+}
+
+func describeInvoiceException(exception InvoiceReconciliationException) string {
+	switch exception.Kind {
+	case InvoiceExceptionMissingInvoice:
+		return fmt.Sprintf(
+			"Đã đặt %d ngày, chưa có hóa đơn (đặt %s, đã giao %s)",
+			exception.AgeDays,
+			formatInvoiceAgingQty(exception.OrderedQty),
+			formatInvoiceAgingQty(exception.DeliveredQty),
+		)
+	case InvoiceExceptionUnmatchedInvoice:
+		reason := fmt.Sprintf("Hóa đơn %s (%d ngày) chưa khớp đơn đặt hàng nào", nonEmptyInvoiceAgingText(exception.InvoiceNumber), exception.AgeDays)
+		if exception.PendingMatchProposal {
+			reason += "; có đề xuất khớp chờ duyệt"
+		}
+		return reason
+	case InvoiceExceptionQuantityMismatch:
+		return fmt.Sprintf(
+			"Hóa đơn %s ghi %s, lệch %s so với số lượng đặt %s",
+			nonEmptyInvoiceAgingText(exception.InvoiceNumber),
+			formatInvoiceAgingQty(exception.InvoiceQty),
+			formatInvoiceAgingSignedQty(exception.QuantityDiff),
+			formatInvoiceAgingQty(exception.OrderedQty),
+		)
+	default:
+		return ""
+	}
+}
+
+func newInvoiceAgingBucketCounts() []InvoiceAgingBucketCount {
+	counts := make([]InvoiceAgingBucketCount, len(invoiceAgingBuckets))
+	for index, bucket := range invoiceAgingBuckets {
+		counts[index].Bucket = bucket.label
+	}
+	return counts
+}
+
+func countInvoiceException(count *InvoiceAgingBucketCount, kind string) {
+	switch kind {
+	case InvoiceExceptionMissingInvoice:
+		count.MissingInvoice++
+	case InvoiceExceptionUnmatchedInvoice:
+		count.UnmatchedInvoice++
+	case InvoiceExceptionQuantityMismatch:
+		count.QuantityMismatch++
+	}
+}
+
+func invoiceAgingBucketIndex(ageDays int) int {
+	for index, bucket := range invoiceAgingBuckets {
+		if ageDays <= bucket.maxAge {
+			return index
+		}
+	}
+	return len(invoiceAgingBuckets) - 1
+}
+
+// invoiceAgingDays counts whole calendar days between since and now; an
+// unknown start date is treated as today.
+func invoiceAgingDays(since, now time.Time) int {
+	if since.IsZero() {
+		return 0
+	}
+	sinceYear, sinceMonth, sinceDay := since.In(now.Location()).Date()
+	nowYear, nowMonth, nowDay := now.Date()
+	start := time.Date(sinceYear, sinceMonth, sinceDay, 0, 0, 0, 0, time.UTC)
+	end := time.Date(nowYear, nowMonth, nowDay, 0, 0, 0, 0, time.UTC)
+	days := int(end.Sub(start).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+func invoiceAgingSupplierKey(taxCode, name string) string {
+	if taxCode = strings.TrimSpace(taxCode); taxCode != "" {
+		return taxCode
+	}
+	return "name:" + strings.ToLower(strings.TrimSpace(name))
+}
+
+func formatInvoiceAgingQty(value *float64) string {
+	if value == nil {
+		return "0"
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", *value), "0"), ".")
+}
+
+func formatInvoiceAgingSignedQty(value *float64) string {
+	if value != nil && *value > 0 {
+		return "+" + formatInvoiceAgingQty(value)
+	}
+	return formatInvoiceAgingQty(value)
+}
+
+func nonEmptyInvoiceAgingText(value string) string {
+	if value = strings.TrimSpace(value); value != "" {
+		return value
+	}
+	return "(không số)"
+}
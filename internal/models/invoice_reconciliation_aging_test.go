@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildInvoiceAgingReportGroupsAndBuckets(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 8, 1, 9, 0, 0, 0, time.UTC)
+	ordered := 100.0
+	delivered := 0.0
+	invoiceQty := 90.0
+	diff := -10.0
+	exceptions := []InvoiceReconciliationException{
+		{Kind: InvoiceExceptionMissingInvoice, SupplierKey: "0101", SupplierName: "Công ty A", Since: now.AddDate(0, 0, -20), OrderedQty: &ordered, DeliveredQty: &delivered},
+		{Kind: InvoiceExceptionMissingInvoice, SupplierKey: "0101", SupplierName: "Công ty A", Since: now.AddDate(0, 0, -3), OrderedQty: &ordered, DeliveredQty: &delivered},
+		{Kind: InvoiceExceptionQuantityMismatch, SupplierKey: "0101", SupplierName: "Công ty A", Since: now.AddDate(0, 0, -70), InvoiceNumber: "0000123", OrderedQty: &ordered, InvoiceQty: &invoiceQty, QuantityDiff: &diff},
+		{Kind: InvoiceExceptionUnmatchedInvoice, SupplierKey: "0202", SupplierName: "Công ty B", Since: now.AddDate(0, 0, -2), InvoiceNumber: "0000456", PendingMatchProposal: true},
+	}
+
+	report := BuildInvoiceAgingReport(exceptions, InvoiceAgingFilter{MinAgeDays: 14, LookbackDays: 180}, now)
+
+	if len(report.Suppliers) != 2 || report.Suppliers[0].SupplierKey != "0101" {
+		t.Fatalf("suppliers = %+v", report.Suppliers)
+	}
+	supplier := report.Suppliers[0]
+	if supplier.MissingInvoice != 1 || supplier.QuantityMismatch != 1 || supplier.OldestAgeDays != 70 {
+		t.Fatalf("supplier A = %+v", supplier)
+	}
+	if supplier.Exceptions[0].AgeBucket != "60+" || supplier.Exceptions[1].AgeBucket != "15-30" {
+		t.Fatalf("buckets = %q, %q", supplier.Exceptions[0].AgeBucket, supplier.Exceptions[1].AgeBucket)
+	}
+	if reason := supplier.Exceptions[0].Reason; !strings.Contains(reason, "lệch -10") || !strings.Contains(reason, "0000123") {
+		t.Fatalf("mismatch reason = %q", reason)
+	}
+	if reason := report.Suppliers[1].Exceptions[0].Reason; !strings.Contains(reason, "chờ duyệt") {
+		t.Fatalf("unmatched reason = %q", reason)
+	}
+	if report.Totals[0].UnmatchedInvoice != 1 || report.Totals[2].MissingInvoice != 1 || report.Totals[4].QuantityMismatch != 1 {
+		t.Fatalf("totals = %+v", report.Totals)
+	}
+}
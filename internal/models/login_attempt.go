@@ -0,0 +1,287 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	LoginThrottleScopeEmail = "email"
+	LoginThrottleScopeIP    = "ip"
+	// Forgot-password requests are limited with the same counters.
+	LoginThrottleScopeResetEmail = "reset_email"
+	LoginThrottleScopeResetIP    = "reset_ip"
+
+	LoginOutcomeSuccess            = "success"
+	LoginOutcomeInvalidCredentials = "invalid_credentials"
+	LoginOutcomeDisabled           = "disabled"
+	LoginOutcomeThrottled          = "throttled"
+	LoginOutcomeLocked             = "locked"
+)
+
+// LoginThrottlePolicy controls how failed logins slow down and lock an email
+// or a client IP. Failures older than Window are forgotten.
+type LoginThrottlePolicy struct {
+	EmailMaxFailures int
+	IPMaxFailures    int
+	DelayAfter       int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	Lockout          time.Duration
+	Window           time.Duration
+}
+
+// LoginThrottle is the failure counter of one email or IP.
+type LoginThrottle struct {
+	Scope         string     `json:"scope"`
+	Subject       string     `json:"subject"`
+	FailedCount   int        `json:"failedCount"`
+	WindowStarted time.Time  `json:"windowStartedAt"`
+	LastFailedAt  time.Time  `json:"lastFailedAt"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty"`
+}
+
+// LoginAttempt is one audited login.
+type LoginAttempt struct {
+	ID        int64     `json:"id"`
+	Email     string    `json:"email"`
+	UserID    *int64    `json:"userId,omitempty"`
+	IPAddress string    `json:"ipAddress"`
+	UserAgent string    `json:"userAgent"`
+	Outcome   string    `json:"outcome"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type LoginAttemptFilter struct {
+	Email string
+	Limit int
+}
+
+// Locked reports whether the subject is locked out at now.
+func (t *LoginThrottle) Locked(now time.Time) bool {
+	return t != nil && t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// RetryAfter is how long the subject must wait before the next attempt, or
+// zero when it may try now.
+func (t *LoginThrottle) RetryAfter(now time.Time) time.Duration {
+	if t == nil {
+		return 0
+	}
+	if t.Locked(now) {
+		return t.LockedUntil.Sub(now)
+	}
+	if t.NextAttemptAt != nil && now.Before(*t.NextAttemptAt) {
+		return t.NextAttemptAt.Sub(now)
+	}
+	return 0
+}
+
+// ApplyLoginFailurePenalty sets the wait that follows the counted failures of
+// throttle. From DelayAfter failures on, every further attempt has to wait
+// twice as long as the previous one, up to MaxDelay; at maxFailures the
+// subject is locked out.
+func ApplyLoginFailurePenalty(throttle LoginThrottle, maxFailures int, policy LoginThrottlePolicy, now time.Time) LoginThrottle {
+	throttle.NextAttemptAt = nil
+	throttle.LockedUntil = nil
+
+	if maxFailures > 0 && throttle.FailedCount >= maxFailures {
+		lockedUntil := now.Add(policy.Lockout)
+		throttle.LockedUntil = &lockedUntil
+		return throttle
+	}
+
+	if policy.DelayAfter > 0 && throttle.FailedCount >= policy.DelayAfter && policy.BaseDelay > 0 {
+		delay := policy.BaseDelay << uint(throttle.FailedCount-policy.DelayAfter)
+		if policy.MaxDelay > 0 && (delay > policy.MaxDelay || delay <= 0) {
+			delay = policy.MaxDelay
+		}
+		nextAttemptAt := now.Add(delay)
+		throttle.NextAttemptAt = &nextAttemptAt
+	}
+	return throttle
+}
+
+type LoginAttemptRepository struct {
+	DB *sql.DB
+}
+
+func NewLoginAttemptRepository(db *sql.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{DB: db}
+}
+
+func (r *LoginAttemptRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS login_throttles (
+			scope VARCHAR(16) NOT NULL,
+			subject VARCHAR(255) NOT NULL,
+			failed_count INT NOT NULL DEFAULT 0,
+			window_started_at DATETIME NOT NULL,
+			last_failed_at DATETIME NOT NULL,
+			next_attempt_at DATETIME NULL,
+			locked_until DATETIME NULL,
+			PRIMARY KEY (scope, subject)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`); err != nil {
+		return fmt.Errorf("error ensuring login throttle schema: %w", err)
+	}
+
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS login_attempts (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			email VARCHAR(255) NOT NULL DEFAULT '',
+			user_id BIGINT NULL,
+			ip_address VARCHAR(64) NOT NULL DEFAULT '',
+			user_agent VARCHAR(500) NOT NULL DEFAULT '',
+			outcome VARCHAR(32) NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY idx_login_attempts_email (email, created_at),
+			KEY idx_login_attempts_created (created_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`); err != nil {
+		return fmt.Errorf("error ensuring login attempt schema: %w", err)
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) GetThrottle(scope, subject string) (*LoginThrottle, error) {
+	var throttle LoginThrottle
+	var nextAttemptAt, lockedUntil sql.NullTime
+	err := r.DB.QueryRow(`
+		SELECT scope, subject, failed_count, window_started_at, last_failed_at, next_attempt_at, locked_until
+		FROM login_throttles
+		WHERE scope = ? AND subject = ?
+	`, scope, subject).Scan(
+		&throttle.Scope,
+		&throttle.Subject,
+		&throttle.FailedCount,
+		&throttle.WindowStarted,
+		&throttle.LastFailedAt,
+		&nextAttemptAt,
+		&lockedUntil,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying login throttle: %w", err)
+	}
+	throttle.NextAttemptAt = nullTimePointer(nextAttemptAt)
+	throttle.LockedUntil = nullTimePointer(lockedUntil)
+	return &throttle, nil
+}
+
+// CountFailure adds one failure to the counter of scope/subject and returns
+// the counter afterwards. The increment is a single upsert so concurrent
+// failures are never lost. Counting starts over once window has passed since
+// the first counted failure, or once an earlier lockout has run out.
+func (r *LoginAttemptRepository) CountFailure(scope, subject string, window time.Duration, now time.Time) (*LoginThrottle, error) {
+	// MySQL applies the assignments in order, so the columns after
+	// failed_count see the new value: 1 means counting started over.
+	if _, err := r.DB.Exec(`
+		INSERT INTO login_throttles (scope, subject, failed_count, window_started_at, last_failed_at)
+		VALUES (?, ?, 1, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			failed_count = IF(
+				(? AND window_started_at <= ?) OR (locked_until IS NOT NULL AND locked_until <= ?),
+				1,
+				failed_count + 1
+			),
+			window_started_at = IF(failed_count = 1, VALUES(window_started_at), window_started_at),
+			next_attempt_at = IF(failed_count = 1, NULL, next_attempt_at),
+			locked_until = IF(failed_count = 1, NULL, locked_until),
+			last_failed_at = VALUES(last_failed_at)
+	`, scope, subject, now, now, window > 0, now.Add(-window), now); err != nil {
+		return nil, fmt.Errorf("error counting login failure: %w", err)
+	}
+
+	throttle, err := r.GetThrottle(scope, subject)
+	if err != nil {
+		return nil, err
+	}
+	if throttle == nil {
+		return nil, fmt.Errorf("error counting login failure: throttle row is missing")
+	}
+	return throttle, nil
+}
+
+// SavePenalty stores the wait computed for throttle. It only applies while the
+// counter still holds throttle.FailedCount, so a slower request cannot replace
+// the penalty of a later failure with a shorter one.
+func (r *LoginAttemptRepository) SavePenalty(throttle LoginThrottle) error {
+	if _, err := r.DB.Exec(`
+		UPDATE login_throttles
+		SET next_attempt_at = ?, locked_until = ?
+		WHERE scope = ? AND subject = ? AND failed_count = ?
+	`, throttle.NextAttemptAt, throttle.LockedUntil, throttle.Scope, throttle.Subject, throttle.FailedCount); err != nil {
+		return fmt.Errorf("error saving login throttle penalty: %w", err)
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) ClearThrottle(scope, subject string) error {
+	if _, err := r.DB.Exec(`
+		DELETE FROM login_throttles
+		WHERE scope = ? AND subject = ?
+	`, scope, subject); err != nil {
+		return fmt.Errorf("error clearing login throttle: %w", err)
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) RecordAttempt(attempt LoginAttempt) error {
+	if _, err := r.DB.Exec(`
+		INSERT INTO login_attempts (email, user_id, ip_address, user_agent, outcome, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, truncateColumnText(attempt.Email, 255), nullableInt64Value(attempt.UserID), truncateColumnText(attempt.IPAddress, 64), truncateColumnText(attempt.UserAgent, 500), attempt.Outcome, attempt.CreatedAt); err != nil {
+		return fmt.Errorf("error recording login attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) ListAttempts(filter LoginAttemptFilter) ([]LoginAttempt, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, email, user_id, ip_address, user_agent, outcome, created_at
+		FROM login_attempts
+	`
+	args := make([]interface{}, 0, 2)
+	if email := strings.ToLower(strings.TrimSpace(filter.Email)); email != "" {
+		query += " WHERE email = ?"
+		args = append(args, email)
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing login attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := make([]LoginAttempt, 0)
+	for rows.Next() {
+		var attempt LoginAttempt
+		var userID sql.NullInt64
+		if err := rows.Scan(&attempt.ID, &attempt.Email, &userID, &attempt.IPAddress, &attempt.UserAgent, &attempt.Outcome, &attempt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning login attempt: %w", err)
+		}
+		if userID.Valid {
+			value := userID.Int64
+			attempt.UserID = &value
+		}
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating login attempts: %w", err)
+	}
+	return attempts, nil
+}
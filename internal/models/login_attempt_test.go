@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyLoginFailurePenaltyDelaysThenLocks(t *testing.T) {
+	policy := LoginThrottlePolicy{
+		DelayAfter: 3,
+		BaseDelay:  2 * time.Second,
+		MaxDelay:   5 * time.Second,
+		Lockout:    15 * time.Minute,
+		Window:     15 * time.Minute,
+	}
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+
+	wantDelays := []time.Duration{0, 0, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, want := range wantDelays {
+		throttle := LoginThrottle{Scope: LoginThrottleScopeEmail, Subject: "a@bv108.vn", FailedCount: i + 1}
+		next := ApplyLoginFailurePenalty(throttle, 6, policy, now)
+		if got := next.RetryAfter(now); got != want {
+			t.Fatalf("failure %d: retry after = %s, want %s", i+1, got, want)
+		}
+		if next.Locked(now) {
+			t.Fatalf("failure %d: locked too early", i+1)
+		}
+	}
+
+	locked := ApplyLoginFailurePenalty(LoginThrottle{FailedCount: 6}, 6, policy, now)
+	if !locked.Locked(now) || locked.RetryAfter(now) != policy.Lockout {
+		t.Fatalf("expected a %s lockout, got %+v", policy.Lockout, locked)
+	}
+	if locked.NextAttemptAt != nil {
+		t.Fatalf("a lockout must not also set a delay, got %+v", locked)
+	}
+}
+
+func TestApplyLoginFailurePenaltyClearsStalePenalty(t *testing.T) {
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	restarted := LoginThrottle{FailedCount: 1, LockedUntil: &earlier, NextAttemptAt: &earlier}
+
+	next := ApplyLoginFailurePenalty(restarted, 6, LoginThrottlePolicy{DelayAfter: 3, BaseDelay: time.Second}, now)
+	if next.LockedUntil != nil || next.NextAttemptAt != nil {
+		t.Fatalf("expected no penalty after counting restarted, got %+v", next)
+	}
+}
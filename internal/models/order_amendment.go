@@ -0,0 +1,306 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	OrderAmendmentActionCancel = "cancel"
+	OrderAmendmentActionAmend  = "amend"
+)
+
+var (
+	ErrOrderHistoryNotFound  = errors.New("order history line not found")
+	ErrOrderLineNotAmendable = errors.New("order history line can no longer be changed")
+	ErrInvalidOrderAmendment = errors.New("invalid order amendment")
+	ErrOrderLineChanged      = errors.New("order history line changed before the amendment was applied")
+)
+
+type OrderHistoryAmendment struct {
+	ID             int64     `json:"id"`
+	OrderHistoryID int64     `json:"orderHistoryId"`
+	Action         string    `json:"action"`
+	OldQty         int       `json:"oldQty"`
+	NewQty         int       `json:"newQty"`
+	Reason         string    `json:"reason"`
+	ChangedByID    int64     `json:"changedById"`
+	ChangedBy      string    `json:"changedBy"`
+	ChangedByEmail string    `json:"changedByEmail,omitempty"`
+	EmailSent      bool      `json:"emailSent"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type OrderAmendmentInput struct {
+	OrderHistoryID int64
+	Action         string
+	ExpectedQty    int
+	NewQty         int
+	Reason         string
+	RecipientEmail string
+	SupplierName   string
+	Actor          OrderActor
+}
+
+// OrderAmendmentEmailLine is one amendment an outbox email reports, with the
+// order history line it changed.
+type OrderAmendmentEmailLine struct {
+	Amendment OrderHistoryAmendment
+	Line      OrderHistoryRecord
+}
+
+func (r *OrderRepository) ensureOrderAmendmentSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS order_history_amendments (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			order_history_id BIGINT NOT NULL,
+			action VARCHAR(20) NOT NULL,
+			old_qty INT NOT NULL,
+			new_qty INT NOT NULL,
+			reason VARCHAR(1000) NOT NULL DEFAULT '',
+			changed_by_id BIGINT NOT NULL,
+			changed_by VARCHAR(255) NOT NULL,
+			changed_by_email VARCHAR(255) NOT NULL DEFAULT '',
+			email_sent TINYINT(1) NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY idx_order_history_amendments_line (order_history_id, id)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`); err != nil {
+		return fmt.Errorf("error ensuring order amendment schema: %w", err)
+	}
+
+	exists, err := r.columnExists("order_history_amendments", "email_outbox_id")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if _, err := r.DB.Exec("ALTER TABLE order_history_amendments ADD COLUMN email_outbox_id BIGINT NULL AFTER email_sent, ADD KEY idx_order_history_amendments_email_outbox (email_outbox_id)"); err != nil {
+			return fmt.Errorf("error ensuring order_history_amendments.email_outbox_id: %w", err)
+		}
+	}
+	return nil
+}
+
+// ValidateOrderAmendment checks whether a placed line in its current state
+// may be cancelled or re-quantified. Amended quantities cannot drop below
+// what has already been delivered.
+func ValidateOrderAmendment(line OrderHistoryRecord, action string, newQty int) error {
+	switch strings.TrimSpace(line.LifecycleStatus) {
+	case OrderLineStatusCancelled:
+		return fmt.Errorf("%w: line is already cancelled", ErrOrderLineNotAmendable)
+	case OrderLineStatusDelivered:
+		return fmt.Errorf("%w: line is fully delivered", ErrOrderLineNotAmendable)
+	}
+
+	switch action {
+	case OrderAmendmentActionCancel:
+		return nil
+	case OrderAmendmentActionAmend:
+		if newQty <= 0 {
+			return fmt.Errorf("%w: quantity must be greater than zero", ErrInvalidOrderAmendment)
+		}
+		if newQty == line.DotGoiHang {
+			return fmt.Errorf("%w: quantity is unchanged", ErrInvalidOrderAmendment)
+		}
+		if float64(newQty) < math.Ceil(line.DeliveredQty) {
+			return fmt.Errorf("%w: quantity cannot be lower than the %.0f already delivered", ErrInvalidOrderAmendment, math.Ceil(line.DeliveredQty))
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidOrderAmendment, action)
+	}
+}
+
+// AmendOrderHistoryLine applies a cancellation or quantity change to one
+// order history line, records the old and new quantity in
+// order_history_amendments and queues the supplier email in the same
+// transaction, so the supplier only hears about changes that were committed.
+// ExpectedQty guards against a concurrent change since the caller read the
+// line.
+func (r *OrderRepository) AmendOrderHistoryLine(input OrderAmendmentInput) (*OrderHistoryAmendment, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting order amendment transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var line OrderHistoryRecord
+	if err := tx.QueryRow(`
+		SELECT id, so_luong, ngay_dat_hang, lifecycle_status, delivered_qty
+		FROM order_history
+		WHERE id = ?
+		FOR UPDATE
+	`, input.OrderHistoryID).Scan(&line.ID, &line.DotGoiHang, &line.NgayDatHang, &line.LifecycleStatus, &line.DeliveredQty); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOrderHistoryNotFound
+		}
+		return nil, fmt.Errorf("error loading order history line: %w", err)
+	}
+
+	if line.DotGoiHang != input.ExpectedQty {
+		return nil, ErrOrderLineChanged
+	}
+	if err := ValidateOrderAmendment(line, input.Action, input.NewQty); err != nil {
+		return nil, err
+	}
+
+	newQty := input.NewQty
+	status := OrderLineStatusCancelled
+	if input.Action == OrderAmendmentActionCancel {
+		newQty = 0
+		if _, err := tx.Exec(`
+			UPDATE order_history
+			SET lifecycle_status = ?, trang_thai = ?, lifecycle_updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?
+		`, status, OrderLineStatusLabel(status), line.ID); err != nil {
+			return nil, fmt.Errorf("error cancelling order history line: %w", err)
+		}
+	} else {
+		status = DeriveOrderLineStatus(line.LifecycleStatus, newQty, line.DeliveredQty, parseOrderTimestamp(line.NgayDatHang), time.Now(), resolveOrderDeliveryDueDays())
+		if _, err := tx.Exec(`
+			UPDATE order_history
+			SET so_luong = ?, lifecycle_status = ?, trang_thai = ?, lifecycle_updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?
+		`, newQty, status, OrderLineStatusLabel(status), line.ID); err != nil {
+			return nil, fmt.Errorf("error amending order history line: %w", err)
+		}
+	}
+
+	amendment := &OrderHistoryAmendment{
+		OrderHistoryID: line.ID,
+		Action:         input.Action,
+		OldQty:         line.DotGoiHang,
+		NewQty:         newQty,
+		Reason:         strings.TrimSpace(input.Reason),
+		ChangedByID:    input.Actor.ID,
+		ChangedBy:      input.Actor.Username,
+		ChangedByEmail: input.Actor.Email,
+		CreatedAt:      time.Now(),
+	}
+
+	outboxID, err := enqueueOrderEmailKindTx(tx, OrderEmailKindOrderAmendment, input.RecipientEmail, input.SupplierName, "", input.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO order_history_amendments (
+			order_history_id,
+			action,
+			old_qty,
+			new_qty,
+			reason,
+			changed_by_id,
+			changed_by,
+			changed_by_email,
+			email_sent,
+			email_outbox_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0, ?)
+	`, amendment.OrderHistoryID, amendment.Action, amendment.OldQty, amendment.NewQty, amendment.Reason, amendment.ChangedByID, amendment.ChangedBy, amendment.ChangedByEmail, outboxID)
+	if err != nil {
+		return nil, fmt.Errorf("error recording order amendment: %w", err)
+	}
+	if amendment.ID, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("error reading order amendment id: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing order amendment: %w", err)
+	}
+
+	return amendment, nil
+}
+
+func (r *OrderRepository) ListOrderHistoryAmendments(orderHistoryID int64) ([]OrderHistoryAmendment, error) {
+	return r.queryOrderHistoryAmendments(`order_history_id = ?`, orderHistoryID)
+}
+
+// ListOrderAmendmentEmailLines returns the amendments an outbox email
+// reports, oldest first, with their order history lines.
+func (r *OrderRepository) ListOrderAmendmentEmailLines(outboxID int64) ([]OrderAmendmentEmailLine, error) {
+	amendments, err := r.queryOrderHistoryAmendments(`email_outbox_id = ?`, outboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(amendments))
+	for _, amendment := range amendments {
+		ids = append(ids, amendment.OrderHistoryID)
+	}
+	records, err := r.GetOrderHistoryByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	recordsByID := make(map[int64]OrderHistoryRecord, len(records))
+	for _, record := range records {
+		recordsByID[record.ID] = record
+	}
+
+	lines := make([]OrderAmendmentEmailLine, 0, len(amendments))
+	for index := len(amendments) - 1; index >= 0; index-- {
+		record, exists := recordsByID[amendments[index].OrderHistoryID]
+		if !exists {
+			continue
+		}
+		lines = append(lines, OrderAmendmentEmailLine{Amendment: amendments[index], Line: record})
+	}
+	return lines, nil
+}
+
+func (r *OrderRepository) queryOrderHistoryAmendments(condition string, arg interface{}) ([]OrderHistoryAmendment, error) {
+	rows, err := r.DB.Query(`
+		SELECT
+			id,
+			order_history_id,
+			action,
+			old_qty,
+			new_qty,
+			reason,
+			changed_by_id,
+			changed_by,
+			changed_by_email,
+			email_sent,
+			created_at
+		FROM order_history_amendments
+		WHERE `+condition+`
+		ORDER BY id DESC
+	`, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error listing order amendments: %w", err)
+	}
+	defer rows.Close()
+
+	amendments := make([]OrderHistoryAmendment, 0)
+	for rows.Next() {
+		var item OrderHistoryAmendment
+		var emailSent int
+		if err := rows.Scan(
+			&item.ID,
+			&item.OrderHistoryID,
+			&item.Action,
+			&item.OldQty,
+			&item.NewQty,
+			&item.Reason,
+			&item.ChangedByID,
+			&item.ChangedBy,
+			&item.ChangedByEmail,
+			&emailSent,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning order amendment: %w", err)
+		}
+		item.EmailSent = emailSent == 1
+		amendments = append(amendments, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order amendments: %w", err)
+	}
+
+	return amendments, nil
+}
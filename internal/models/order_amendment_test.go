@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateOrderAmendment(t *testing.T) {
+	t.Parallel()
+
+	line := func(status string, ordered int, delivered float64) OrderHistoryRecord {
+		record := OrderHistoryRecord{LifecycleStatus: status, DeliveredQty: delivered}
+		record.DotGoiHang = ordered
+		return record
+	}
+
+	cases := []struct {
+		name    string
+		line    OrderHistoryRecord
+		action  string
+		newQty  int
+		wantErr error
+	}{
+		{name: "cancel sent line", line: line(OrderLineStatusSent, 10, 0), action: OrderAmendmentActionCancel},
+		{name: "cancel rest of partial delivery", line: line(OrderLineStatusPartiallyDelivered, 10, 4), action: OrderAmendmentActionCancel},
+		{name: "cancel twice", line: line(OrderLineStatusCancelled, 10, 0), action: OrderAmendmentActionCancel, wantErr: ErrOrderLineNotAmendable},
+		{name: "cancel delivered line", line: line(OrderLineStatusDelivered, 10, 10), action: OrderAmendmentActionCancel, wantErr: ErrOrderLineNotAmendable},
+		{name: "raise quantity", line: line(OrderLineStatusSent, 10, 0), action: OrderAmendmentActionAmend, newQty: 15},
+		{name: "lower to delivered quantity", line: line(OrderLineStatusOverdue, 10, 4), action: OrderAmendmentActionAmend, newQty: 4},
+		{name: "lower below delivered quantity", line: line(OrderLineStatusPartiallyDelivered, 10, 4), action: OrderAmendmentActionAmend, newQty: 3, wantErr: ErrInvalidOrderAmendment},
+		{name: "unchanged quantity", line: line(OrderLineStatusSent, 10, 0), action: OrderAmendmentActionAmend, newQty: 10, wantErr: ErrInvalidOrderAmendment},
+		{name: "zero quantity", line: line(OrderLineStatusSent, 10, 0), action: OrderAmendmentActionAmend, wantErr: ErrInvalidOrderAmendment},
+		{name: "unknown action", line: line(OrderLineStatusSent, 10, 0), action: "reopen", wantErr: ErrInvalidOrderAmendment},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateOrderAmendment(tc.line, tc.action, tc.newQty)
+			if tc.wantErr == nil && err != nil {
+				t.Fatalf("ValidateOrderAmendment() error = %v, want nil", err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("ValidateOrderAmendment() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
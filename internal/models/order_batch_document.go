@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrOrderBatchNotFound = errors.New("order batch not found")
+
+const orderBatchUnknownTime = "unknown-time"
+
+// ParseOrderBatchKey splits an OrderBatchKey into the company key and the
+// ngay_dat_hang value it was built from. The trailing "__batch-N" sequence
+// only keeps keys distinct within one listing, so it is ignored.
+func ParseOrderBatchKey(batchKey string) (companyKey, ngayDatHang string, err error) {
+	signature := strings.TrimSpace(batchKey)
+	if index := strings.LastIndex(signature, "__batch-"); index >= 0 {
+		signature = signature[:index]
+	}
+
+	index := strings.LastIndex(signature, "__")
+	if index <= 0 || index+2 >= len(signature) {
+		return "", "", fmt.Errorf("%w: malformed batch key", ErrOrderBatchNotFound)
+	}
+
+	companyKey = signature[:index]
+	ngayDatHang = signature[index+2:]
+	if ngayDatHang == orderBatchUnknownTime {
+		ngayDatHang = ""
+	}
+	return companyKey, ngayDatHang, nil
+}
+
+// GetOrderBatchLines returns the lines of one placed batch as they were sent
+// to the supplier: cancelled lines are kept and amended quantities are
+// restored to the quantity originally ordered.
+func (r *OrderRepository) GetOrderBatchLines(batchKey string) ([]OrderEmailLine, error) {
+	companyKey, ngayDatHang, err := ParseOrderBatchKey(batchKey)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := r.queryOrderEmailLines(`oh.ngay_dat_hang = ?`, []interface{}{ngayDatHang})
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]OrderEmailLine, 0, len(candidates))
+	for _, line := range candidates {
+		if buildOrderCompanyKey(line.OrderHistoryRecord) == companyKey {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, ErrOrderBatchNotFound
+	}
+
+	if err := r.restoreOriginalOrderQuantities(lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// restoreOriginalOrderQuantities replaces each amended line's quantity with
+// the one recorded before its first amendment.
+func (r *OrderRepository) restoreOriginalOrderQuantities(lines []OrderEmailLine) error {
+	args := make([]interface{}, len(lines))
+	for index, line := range lines {
+		args[index] = line.ID
+	}
+
+	rows, err := r.DB.Query(fmt.Sprintf(`
+		SELECT a.order_history_id, a.old_qty
+		FROM order_history_amendments a
+		WHERE a.id IN (
+			SELECT MIN(earliest.id)
+			FROM order_history_amendments earliest
+			WHERE earliest.order_history_id IN (%s)
+			GROUP BY earliest.order_history_id
+		)
+	`, makePlaceholders(len(args))), args...)
+	if err != nil {
+		return fmt.Errorf("error loading original order quantities: %w", err)
+	}
+	defer rows.Close()
+
+	originalQty := make(map[int64]int)
+	for rows.Next() {
+		var orderHistoryID int64
+		var quantity int
+		if err := rows.Scan(&orderHistoryID, &quantity); err != nil {
+			return fmt.Errorf("error scanning original order quantity: %w", err)
+		}
+		originalQty[orderHistoryID] = quantity
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating original order quantities: %w", err)
+	}
+
+	for index := range lines {
+		if quantity, ok := originalQty[lines[index].ID]; ok {
+			lines[index].DotGoiHang = quantity
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseOrderBatchKeyRoundTripsAssignedKeys(t *testing.T) {
+	t.Parallel()
+
+	contactID := "0101234567"
+	history := []OrderHistoryRecord{
+		{PendingOrder: PendingOrder{CompanyContactID: &contactID}, NgayDatHang: "2026-07-01 08:00:00"},
+		{PendingOrder: PendingOrder{NhaThau: "  Công ty   Thiết bị A "}, NgayDatHang: "2026-07-01 09:30:00"},
+		{PendingOrder: PendingOrder{NhaThau: "Công ty B"}},
+	}
+	assignOrderBatchKeys(history)
+
+	for _, item := range history {
+		companyKey, ngayDatHang, err := ParseOrderBatchKey(item.OrderBatchKey)
+		if err != nil {
+			t.Fatalf("ParseOrderBatchKey(%q) error = %v", item.OrderBatchKey, err)
+		}
+		if companyKey != buildOrderCompanyKey(item) || ngayDatHang != item.NgayDatHang {
+			t.Fatalf("ParseOrderBatchKey(%q) = (%q, %q)", item.OrderBatchKey, companyKey, ngayDatHang)
+		}
+	}
+
+	if _, _, err := ParseOrderBatchKey("not-a-batch"); !errors.Is(err, ErrOrderBatchNotFound) {
+		t.Fatalf("malformed key error = %v, want ErrOrderBatchNotFound", err)
+	}
+}
@@ -0,0 +1,559 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	OrderEmailKindPlacedOrder    = "placed_order"
+	OrderEmailKindOrderAmendment = "order_amendment"
+
+	OrderEmailStatusPending = "pending"
+	OrderEmailStatusSending = "sending"
+	OrderEmailStatusSent    = "sent"
+	OrderEmailStatusFailed  = "failed"
+)
+
+// orderEmailClaimTimeout releases rows left in "sending" by a worker that
+// stopped before recording the SMTP result.
+const orderEmailClaimTimeout = 10 * time.Minute
+
+// OrderDocumentSnapshotFunc captures, as an opaque string, the template and
+// date a supplier's placed-order document is rendered with. It runs once per
+// placed-order email while the order is being placed, so the email and any
+// later download of the batch render the same document.
+type OrderDocumentSnapshotFunc func(supplierName string) (string, error)
+
+// orderLineCatalogPriceSQL looks up the catalog unit price of the order
+// history line aliased oh.
+const orderLineCatalogPriceSQL = `
+	SELECT s.PRICE
+	FROM supplies s
+	WHERE (
+		TRIM(COALESCE(oh.ma_quan_ly, '')) <> ''
+		AND TRIM(COALESCE(s.TYPENAME, '')) = TRIM(oh.ma_quan_ly)
+	) OR (
+		TRIM(COALESCE(oh.ma_vtyt_cu, '')) <> ''
+		AND TRIM(COALESCE(s.ID, '')) = TRIM(oh.ma_vtyt_cu)
+	)
+	ORDER BY
+		CASE WHEN TRIM(COALESCE(s.TYPENAME, '')) = TRIM(COALESCE(oh.ma_quan_ly, '')) THEN 0 ELSE 1 END,
+		s.IDX1
+	LIMIT 1
+`
+
+var (
+	ErrOrderEmailNotFound = errors.New("order email not found")
+	ErrOrderEmailInFlight = errors.New("order email is being sent")
+	ErrOrderEmailChanged  = errors.New("order lines were amended or cancelled after the email was placed")
+)
+
+type OrderEmailOutboxEntry struct {
+	ID             int64      `json:"id"`
+	Kind           string     `json:"kind"`
+	RecipientEmail string     `json:"recipientEmail"`
+	SupplierName   string     `json:"supplierName"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	LastError      string     `json:"lastError,omitempty"`
+	SentAt         *time.Time `json:"sentAt,omitempty"`
+	LineCount      int        `json:"lineCount"`
+	// DocumentSnapshot is what the OrderDocumentSnapshotFunc returned when
+	// the order was placed; empty for amendments and older rows.
+	DocumentSnapshot string    `json:"-"`
+	CreatedBy        string    `json:"createdBy"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type OrderEmailOutboxFilter struct {
+	Status string
+	Limit  int
+}
+
+func (r *OrderRepository) ensureOrderEmailOutboxSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS order_email_outbox (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			kind VARCHAR(32) NOT NULL,
+			recipient_email VARCHAR(255) NOT NULL,
+			supplier_name VARCHAR(255) NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			claimed_at DATETIME NULL,
+			last_error VARCHAR(2000) NOT NULL DEFAULT '',
+			sent_at DATETIME NULL,
+			document_snapshot LONGTEXT NULL,
+			created_by_id BIGINT NULL,
+			created_by VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY idx_order_email_outbox_due (status, next_attempt_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`); err != nil {
+		return fmt.Errorf("error ensuring order email outbox schema: %w", err)
+	}
+
+	columns := []struct {
+		table     string
+		name      string
+		statement string
+	}{
+		{
+			table:     "order_history",
+			name:      "email_outbox_id",
+			statement: "ALTER TABLE order_history ADD COLUMN email_outbox_id BIGINT NULL AFTER email_sent, ADD KEY idx_order_history_email_outbox (email_outbox_id)",
+		},
+		{
+			table:     "order_history",
+			name:      "don_gia",
+			statement: "ALTER TABLE order_history ADD COLUMN don_gia DECIMAL(18,2) NULL AFTER so_luong",
+		},
+		{
+			table:     "order_email_outbox",
+			name:      "document_snapshot",
+			statement: "ALTER TABLE order_email_outbox ADD COLUMN document_snapshot LONGTEXT NULL AFTER sent_at",
+		},
+	}
+	for _, column := range columns {
+		exists, err := r.columnExists(column.table, column.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := r.DB.Exec(column.statement); err != nil {
+			return fmt.Errorf("error ensuring %s.%s: %w", column.table, column.name, err)
+		}
+	}
+
+	return nil
+}
+
+// enqueueOrderEmailTx returns the outbox row a placed line belongs to,
+// creating one per recipient and supplier inside the placing transaction so
+// an order is never committed without its email. A nil snapshot leaves the
+// row to render with the templates current at send time.
+func enqueueOrderEmailTx(tx *sql.Tx, outboxIDs map[string]int64, email, supplierName string, createdBy OrderActor, snapshot OrderDocumentSnapshotFunc) (int64, error) {
+	key := strings.ToLower(strings.TrimSpace(email)) + "|" + strings.ToLower(strings.TrimSpace(supplierName))
+	if outboxID, exists := outboxIDs[key]; exists {
+		return outboxID, nil
+	}
+
+	documentSnapshot := ""
+	if snapshot != nil {
+		var err error
+		documentSnapshot, err = snapshot(supplierName)
+		if err != nil {
+			return 0, fmt.Errorf("error capturing order document: %w", err)
+		}
+	}
+
+	outboxID, err := enqueueOrderEmailKindTx(tx, OrderEmailKindPlacedOrder, email, supplierName, documentSnapshot, createdBy)
+	if err != nil {
+		return 0, err
+	}
+
+	outboxIDs[key] = outboxID
+	return outboxID, nil
+}
+
+func enqueueOrderEmailKindTx(tx *sql.Tx, kind, email, supplierName, documentSnapshot string, createdBy OrderActor) (int64, error) {
+	var snapshotValue interface{}
+	if documentSnapshot != "" {
+		snapshotValue = documentSnapshot
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO order_email_outbox (
+			kind,
+			recipient_email,
+			supplier_name,
+			status,
+			next_attempt_at,
+			document_snapshot,
+			created_by_id,
+			created_by
+		) VALUES (?, ?, ?, ?, NOW(), ?, ?, ?)
+	`, kind, strings.TrimSpace(email), strings.TrimSpace(supplierName), OrderEmailStatusPending, snapshotValue, createdBy.ID, createdBy.Username)
+	if err != nil {
+		return 0, fmt.Errorf("error queueing order email: %w", err)
+	}
+
+	outboxID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error reading queued order email id: %w", err)
+	}
+	return outboxID, nil
+}
+
+// ClaimDueOrderEmails marks up to limit due emails as sending and returns
+// them. Rows stuck in sending past orderEmailClaimTimeout are claimed again.
+func (r *OrderRepository) ClaimDueOrderEmails(now time.Time, limit int) ([]OrderEmailOutboxEntry, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting order email claim transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id
+		FROM order_email_outbox
+		WHERE (status = ? AND next_attempt_at <= ?)
+			OR (status = ? AND claimed_at <= ?)
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+		FOR UPDATE
+	`, OrderEmailStatusPending, now, OrderEmailStatusSending, now.Add(-orderEmailClaimTimeout), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting due order emails: %w", err)
+	}
+
+	ids := make([]int64, 0, limit)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning due order email: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating due order emails: %w", err)
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return []OrderEmailOutboxEntry{}, nil
+	}
+
+	args := []interface{}{OrderEmailStatusSending, now}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`
+		UPDATE order_email_outbox
+		SET status = ?, claimed_at = ?
+		WHERE id IN (%s)
+	`, makePlaceholders(len(ids))), args...); err != nil {
+		return nil, fmt.Errorf("error claiming order emails: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing order email claim: %w", err)
+	}
+
+	entries := make([]OrderEmailOutboxEntry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := r.GetOrderEmail(id)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+// ListOrderEmailLines returns the order history lines an email covers, with
+// the unit price stored when the line was placed and the supplier's tender
+// package for optional PDF columns. Lines cancelled before the email went out are left off.
+func (r *OrderRepository) ListOrderEmailLines(outboxID int64) ([]OrderEmailLine, error) {
+	return r.queryOrderEmailLines(`oh.email_outbox_id = ? AND oh.lifecycle_status <> ?`, []interface{}{outboxID, OrderLineStatusCancelled})
+}
+
+func (r *OrderRepository) queryOrderEmailLines(condition string, args []interface{}) ([]OrderEmailLine, error) {
+	rows, err := r.DB.Query(`
+		SELECT
+			oh.id,
+			COALESCE(oh.don_gia, (`+orderLineCatalogPriceSQL+`), 0),
+			COALESCE(cc.so_goi_thau, '')
+		FROM order_history oh
+		LEFT JOIN company_contacts cc ON cc.ma_so_thue = oh.company_contact_id
+		WHERE `+condition+`
+		ORDER BY oh.id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing order email lines: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	details := make(map[int64]OrderEmailLine)
+	for rows.Next() {
+		var id int64
+		var line OrderEmailLine
+		if err := rows.Scan(&id, &line.DonGia, &line.SoGoiThau); err != nil {
+			return nil, fmt.Errorf("error scanning order email line: %w", err)
+		}
+		ids = append(ids, id)
+		details[id] = line
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order email lines: %w", err)
+	}
+
+	records, err := r.GetOrderHistoryByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	lines := make([]OrderEmailLine, 0, len(records))
+	for _, record := range records {
+		line := details[record.ID]
+		line.OrderHistoryRecord = record
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// MarkOrderEmailSent records a delivered email and flips email_sent on every
+// order history line it covered.
+func (r *OrderRepository) MarkOrderEmailSent(outboxID int64, attempts int, sentAt time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting order email transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE order_email_outbox
+		SET status = ?, attempts = ?, sent_at = ?, claimed_at = NULL, last_error = ''
+		WHERE id = ?
+	`, OrderEmailStatusSent, attempts, sentAt, outboxID); err != nil {
+		return fmt.Errorf("error marking order email sent: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE order_history
+		SET email_sent = 1,
+			trang_thai = CASE WHEN lifecycle_status = ? THEN ? ELSE trang_thai END
+		WHERE email_outbox_id = ?
+	`, OrderLineStatusSent, OrderHistoryStatusLabel(OrderLineStatusSent, OrderEmailStatusSent), outboxID); err != nil {
+		return fmt.Errorf("error marking order history email sent: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE order_history_amendments
+		SET email_sent = 1
+		WHERE email_outbox_id = ?
+	`, outboxID); err != nil {
+		return fmt.Errorf("error marking order amendment email sent: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing order email result: %w", err)
+	}
+	return nil
+}
+
+// MarkOrderEmailFailed stores a failed attempt. A nil nextAttemptAt means
+// retries are exhausted and the email stays failed until it is resent.
+func (r *OrderRepository) MarkOrderEmailFailed(outboxID int64, attempts int, nextAttemptAt *time.Time, lastError string) error {
+	lastError = truncateColumnText(lastError, 2000)
+
+	if nextAttemptAt != nil {
+		if _, err := r.DB.Exec(`
+			UPDATE order_email_outbox
+			SET status = ?, attempts = ?, next_attempt_at = ?, claimed_at = NULL, last_error = ?
+			WHERE id = ?
+		`, OrderEmailStatusPending, attempts, *nextAttemptAt, lastError, outboxID); err != nil {
+			return fmt.Errorf("error scheduling order email retry: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting order email transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE order_email_outbox
+		SET status = ?, attempts = ?, claimed_at = NULL, last_error = ?
+		WHERE id = ?
+	`, OrderEmailStatusFailed, attempts, lastError, outboxID); err != nil {
+		return fmt.Errorf("error marking order email failed: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE order_history
+		SET trang_thai = CASE WHEN lifecycle_status = ? THEN ? ELSE trang_thai END
+		WHERE email_outbox_id = ? AND email_sent = 0
+	`, OrderLineStatusSent, OrderHistoryStatusLabel(OrderLineStatusSent, OrderEmailStatusFailed), outboxID); err != nil {
+		return fmt.Errorf("error marking order history email failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing order email result: %w", err)
+	}
+	return nil
+}
+
+// RequeueOrderEmail puts one supplier email back in the queue with a fresh
+// retry budget, whether it failed or was already delivered. A placed-order
+// email is refused once any of its lines was amended or cancelled, since
+// sending it again would re-order the original quantities.
+func (r *OrderRepository) RequeueOrderEmail(outboxID int64) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting order email transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status, kind string
+	if err := tx.QueryRow(`SELECT status, kind FROM order_email_outbox WHERE id = ? FOR UPDATE`, outboxID).Scan(&status, &kind); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrOrderEmailNotFound
+		}
+		return fmt.Errorf("error loading order email: %w", err)
+	}
+	if status == OrderEmailStatusSending {
+		return ErrOrderEmailInFlight
+	}
+	if kind == OrderEmailKindPlacedOrder {
+		var changed bool
+		if err := tx.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1
+				FROM order_history oh
+				WHERE oh.email_outbox_id = ?
+				  AND (
+					oh.lifecycle_status = ?
+					OR EXISTS (SELECT 1 FROM order_history_amendments a WHERE a.order_history_id = oh.id)
+				  )
+			)
+		`, outboxID, OrderLineStatusCancelled).Scan(&changed); err != nil {
+			return fmt.Errorf("error checking order email lines: %w", err)
+		}
+		if changed {
+			return ErrOrderEmailChanged
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE order_email_outbox
+		SET status = ?, attempts = 0, next_attempt_at = NOW(), claimed_at = NULL, last_error = ''
+		WHERE id = ?
+	`, OrderEmailStatusPending, outboxID); err != nil {
+		return fmt.Errorf("error requeueing order email: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE order_history
+		SET trang_thai = CASE WHEN lifecycle_status = ? THEN ? ELSE trang_thai END
+		WHERE email_outbox_id = ? AND email_sent = 0
+	`, OrderLineStatusSent, OrderHistoryStatusLabel(OrderLineStatusSent, OrderEmailStatusPending), outboxID); err != nil {
+		return fmt.Errorf("error requeueing order history email: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing requeued order email: %w", err)
+	}
+	return nil
+}
+
+func (r *OrderRepository) GetOrderEmail(outboxID int64) (*OrderEmailOutboxEntry, error) {
+	entries, err := r.queryOrderEmails(`WHERE o.id = ?`, []interface{}{outboxID}, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+func (r *OrderRepository) ListOrderEmails(filter OrderEmailOutboxFilter) ([]OrderEmailOutboxEntry, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	if status := strings.TrimSpace(filter.Status); status != "" {
+		return r.queryOrderEmails(`WHERE o.status = ?`, []interface{}{status}, limit)
+	}
+	return r.queryOrderEmails("", nil, limit)
+}
+
+func (r *OrderRepository) queryOrderEmails(where string, args []interface{}, limit int) ([]OrderEmailOutboxEntry, error) {
+	args = append(args, limit)
+	rows, err := r.DB.Query(`
+		SELECT
+			o.id,
+			o.kind,
+			o.recipient_email,
+			o.supplier_name,
+			o.status,
+			o.attempts,
+			o.next_attempt_at,
+			o.last_error,
+			o.sent_at,
+			COALESCE(o.document_snapshot, ''),
+			(SELECT COUNT(*) FROM order_history oh WHERE oh.email_outbox_id = o.id)
+				+ (SELECT COUNT(*) FROM order_history_amendments a WHERE a.email_outbox_id = o.id),
+			o.created_by,
+			o.created_at,
+			o.updated_at
+		FROM order_email_outbox o
+		`+where+`
+		ORDER BY o.id DESC
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing order emails: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]OrderEmailOutboxEntry, 0)
+	for rows.Next() {
+		var entry OrderEmailOutboxEntry
+		var sentAt sql.NullTime
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.Kind,
+			&entry.RecipientEmail,
+			&entry.SupplierName,
+			&entry.Status,
+			&entry.Attempts,
+			&entry.NextAttemptAt,
+			&entry.LastError,
+			&sentAt,
+			&entry.DocumentSnapshot,
+			&entry.LineCount,
+			&entry.CreatedBy,
+			&entry.CreatedAt,
+			&entry.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning order email: %w", err)
+		}
+		if sentAt.Valid {
+			value := sentAt.Time
+			entry.SentAt = &value
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order emails: %w", err)
+	}
+
+	return entries, nil
+}
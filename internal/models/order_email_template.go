@@ -0,0 +1,268 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	OrderEmailColumnUnitPrice     = "unit_price"
+	OrderEmailColumnTenderPackage = "tender_package"
+)
+
+// OrderEmailTemplate customizes the placed-order email and PDF. The row with
+// an empty SupplierKey is the hospital-wide default; other rows override it
+// for one supplier. Empty text fields and a nil ExtraColumns inherit from the
+// layer below.
+type OrderEmailTemplate struct {
+	ID                int64     `json:"id"`
+	SupplierName      string    `json:"supplierName"`
+	SupplierKey       string    `json:"supplierKey"`
+	Subject           string    `json:"subject"`
+	Body              string    `json:"body"`
+	PDFTitle          string    `json:"pdfTitle"`
+	PDFIntro          string    `json:"pdfIntro"`
+	PDFRequest        string    `json:"pdfRequest"`
+	SignatoryName     string    `json:"signatoryName"`
+	SignatoryTitle    string    `json:"signatoryTitle"`
+	SignatoryDept     string    `json:"signatoryDept"`
+	SignatoryPhone    string    `json:"signatoryPhone"`
+	ExtraColumns      []string  `json:"extraColumns"`
+	UpdatedByUserID   *int64    `json:"updatedByUserId,omitempty"`
+	UpdatedByUsername string    `json:"updatedByUsername"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// OrderEmailLine is an order history line together with the unit price it
+// was placed at and the tender package that optional PDF columns print.
+type OrderEmailLine struct {
+	OrderHistoryRecord
+	DonGia    float64 `json:"donGia"`
+	SoGoiThau string  `json:"soGoiThau"`
+}
+
+type OrderEmailTemplateRepository struct {
+	DB *sql.DB
+}
+
+func NewOrderEmailTemplateRepository(db *sql.DB) *OrderEmailTemplateRepository {
+	return &OrderEmailTemplateRepository{DB: db}
+}
+
+func (r *OrderEmailTemplateRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS order_email_templates (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			supplier_name VARCHAR(255) NOT NULL DEFAULT '',
+			supplier_key VARCHAR(255) NOT NULL DEFAULT '',
+			subject VARCHAR(500) NOT NULL DEFAULT '',
+			body TEXT NOT NULL,
+			pdf_title VARCHAR(255) NOT NULL DEFAULT '',
+			pdf_intro TEXT NOT NULL,
+			pdf_request TEXT NOT NULL,
+			signatory_name VARCHAR(255) NOT NULL DEFAULT '',
+			signatory_title VARCHAR(255) NOT NULL DEFAULT '',
+			signatory_dept VARCHAR(255) NOT NULL DEFAULT '',
+			signatory_phone VARCHAR(50) NOT NULL DEFAULT '',
+			extra_columns VARCHAR(255) NULL,
+			updated_by_user_id BIGINT NULL,
+			updated_by_username VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_order_email_template_supplier (supplier_key)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`); err != nil {
+		return fmt.Errorf("error ensuring order email template schema: %w", err)
+	}
+	return nil
+}
+
+const orderEmailTemplateColumns = `
+	id, supplier_name, supplier_key, subject, body, pdf_title, pdf_intro, pdf_request,
+	signatory_name, signatory_title, signatory_dept, signatory_phone, extra_columns,
+	updated_by_user_id, updated_by_username, created_at, updated_at
+`
+
+func (r *OrderEmailTemplateRepository) ListOrderEmailTemplates() ([]OrderEmailTemplate, error) {
+	rows, err := r.DB.Query(`SELECT ` + orderEmailTemplateColumns + ` FROM order_email_templates ORDER BY supplier_key <> '', supplier_name, id`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing order email templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := make([]OrderEmailTemplate, 0)
+	for rows.Next() {
+		template, err := scanOrderEmailTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order email templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (r *OrderEmailTemplateRepository) GetOrderEmailTemplate(id int64) (*OrderEmailTemplate, error) {
+	row := r.DB.QueryRow(`SELECT `+orderEmailTemplateColumns+` FROM order_email_templates WHERE id = ?`, id)
+	template, err := scanOrderEmailTemplate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return template, err
+}
+
+// FindOrderEmailTemplate returns the template stored for a supplier key, or
+// the default template for an empty key. It returns nil when none exists.
+func (r *OrderEmailTemplateRepository) FindOrderEmailTemplate(supplierKey string) (*OrderEmailTemplate, error) {
+	row := r.DB.QueryRow(`SELECT `+orderEmailTemplateColumns+` FROM order_email_templates WHERE supplier_key = ?`, supplierKey)
+	template, err := scanOrderEmailTemplate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return template, err
+}
+
+func (r *OrderEmailTemplateRepository) CreateOrderEmailTemplate(template *OrderEmailTemplate) error {
+	result, err := r.DB.Exec(`
+		INSERT INTO order_email_templates (
+			supplier_name, supplier_key, subject, body, pdf_title, pdf_intro, pdf_request,
+			signatory_name, signatory_title, signatory_dept, signatory_phone, extra_columns,
+			updated_by_user_id, updated_by_username
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, orderEmailTemplateArgs(template)...)
+	if err != nil {
+		return fmt.Errorf("error creating order email template: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error reading order email template id: %w", err)
+	}
+	template.ID = id
+	return nil
+}
+
+func (r *OrderEmailTemplateRepository) UpdateOrderEmailTemplate(template *OrderEmailTemplate) (bool, error) {
+	args := append(orderEmailTemplateArgs(template), template.ID)
+	result, err := r.DB.Exec(`
+		UPDATE order_email_templates
+		SET supplier_name = ?, supplier_key = ?, subject = ?, body = ?, pdf_title = ?, pdf_intro = ?,
+			pdf_request = ?, signatory_name = ?, signatory_title = ?, signatory_dept = ?,
+			signatory_phone = ?, extra_columns = ?, updated_by_user_id = ?, updated_by_username = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, args...)
+	if err != nil {
+		return false, fmt.Errorf("error updating order email template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error reading updated order email template rows: %w", err)
+	}
+	if rowsAffected > 0 {
+		return true, nil
+	}
+
+	// MySQL reports zero affected rows when nothing changed, so confirm the row exists.
+	existing, err := r.GetOrderEmailTemplate(template.ID)
+	if err != nil {
+		return false, err
+	}
+	return existing != nil, nil
+}
+
+func (r *OrderEmailTemplateRepository) DeleteOrderEmailTemplate(id int64) (bool, error) {
+	result, err := r.DB.Exec("DELETE FROM order_email_templates WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting order email template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error reading deleted order email template rows: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func orderEmailTemplateArgs(template *OrderEmailTemplate) []interface{} {
+	var extraColumns interface{}
+	if template.ExtraColumns != nil {
+		extraColumns = strings.Join(template.ExtraColumns, ",")
+	}
+
+	return []interface{}{
+		strings.TrimSpace(template.SupplierName),
+		template.SupplierKey,
+		strings.TrimSpace(template.Subject),
+		strings.TrimSpace(template.Body),
+		strings.TrimSpace(template.PDFTitle),
+		strings.TrimSpace(template.PDFIntro),
+		strings.TrimSpace(template.PDFRequest),
+		strings.TrimSpace(template.SignatoryName),
+		strings.TrimSpace(template.SignatoryTitle),
+		strings.TrimSpace(template.SignatoryDept),
+		strings.TrimSpace(template.SignatoryPhone),
+		extraColumns,
+		nullableInt64Value(template.UpdatedByUserID),
+		strings.TrimSpace(template.UpdatedByUsername),
+	}
+}
+
+type orderEmailTemplateScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrderEmailTemplate(scanner orderEmailTemplateScanner) (*OrderEmailTemplate, error) {
+	var template OrderEmailTemplate
+	var extraColumns sql.NullString
+	var updatedByUserID sql.NullInt64
+	if err := scanner.Scan(
+		&template.ID,
+		&template.SupplierName,
+		&template.SupplierKey,
+		&template.Subject,
+		&template.Body,
+		&template.PDFTitle,
+		&template.PDFIntro,
+		&template.PDFRequest,
+		&template.SignatoryName,
+		&template.SignatoryTitle,
+		&template.SignatoryDept,
+		&template.SignatoryPhone,
+		&extraColumns,
+		&updatedByUserID,
+		&template.UpdatedByUsername,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error scanning order email template: %w", err)
+	}
+	if extraColumns.Valid {
+		template.ExtraColumns = splitOrderEmailColumns(extraColumns.String)
+	}
+	if updatedByUserID.Valid {
+		value := updatedByUserID.Int64
+		template.UpdatedByUserID = &value
+	}
+	return &template, nil
+}
+
+func splitOrderEmailColumns(value string) []string {
+	columns := make([]string, 0)
+	for _, column := range strings.Split(value, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
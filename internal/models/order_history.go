@@ -34,6 +34,13 @@ type PendingOrder struct {
 	Email              string  `json:"email,omitempty"`
 	Source             string  `json:"source"`
 	GroupKey           string  `json:"groupKey,omitempty"`
+	ApprovalStatus     string  `json:"approvalStatus,omitempty"`
+	ApprovalBatchID    string  `json:"approvalBatchId,omitempty"`
+	ProposedBy         string  `json:"proposedBy,omitempty"`
+	ProposedAt         string  `json:"proposedAt,omitempty"`
+	ReviewedBy         string  `json:"reviewedBy,omitempty"`
+	ReviewedAt         string  `json:"reviewedAt,omitempty"`
+	RejectionReason    string  `json:"rejectionReason,omitempty"`
 	NguoiPheDuyet      string  `json:"nguoiPheDuyet,omitempty"`
 	NguoiPheDuyetEmail string  `json:"nguoiPheDuyetEmail,omitempty"`
 	ThoiGianPheDuyet   string  `json:"thoiGianPheDuyet,omitempty"`
@@ -46,12 +53,16 @@ type PendingOrder struct {
 
 type OrderHistoryRecord struct {
 	PendingOrder
-	OrderBatchKey     string `json:"orderBatchKey,omitempty"`
-	NgayDatHang       string `json:"ngayDatHang"`
-	TrangThai         string `json:"trangThai"`
-	EmailSent         bool   `json:"emailSent"`
-	NguoiDatHang      string `json:"nguoiDatHang"`
-	NguoiDatHangEmail string `json:"nguoiDatHangEmail,omitempty"`
+	OrderBatchKey     string  `json:"orderBatchKey,omitempty"`
+	NgayDatHang       string  `json:"ngayDatHang"`
+	TrangThai         string  `json:"trangThai"`
+	LifecycleStatus   string  `json:"lifecycleStatus"`
+	DeliveredQty      float64 `json:"deliveredQty"`
+	EmailSent         bool    `json:"emailSent"`
+	EmailOutboxID     *int64  `json:"emailOutboxId,omitempty"`
+	EmailStatus       string  `json:"emailStatus,omitempty"`
+	NguoiDatHang      string  `json:"nguoiDatHang"`
+	NguoiDatHangEmail string  `json:"nguoiDatHangEmail,omitempty"`
 }
 
 type CreatePendingOrderInput struct {
@@ -169,10 +180,26 @@ func (r *OrderRepository) EnsureSchema() error {
 		return err
 	}
 
+	if err := r.ensurePendingApprovalColumns(); err != nil {
+		return err
+	}
+
 	if err := r.ensureQuantityColumn("order_history"); err != nil {
 		return err
 	}
 
+	if err := r.ensureOrderLifecycleColumns(); err != nil {
+		return err
+	}
+
+	if err := r.ensureOrderAmendmentSchema(); err != nil {
+		return err
+	}
+
+	if err := r.ensureOrderEmailOutboxSchema(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -233,6 +260,13 @@ func (r *OrderRepository) ListPendingOrders() ([]PendingOrder, error) {
 			email,
 			source,
 			group_key,
+			approval_status,
+			approval_batch_id,
+			proposed_by,
+			COALESCE(DATE_FORMAT(proposed_at, '%Y-%m-%dT%H:%i:%sZ'), '') AS proposed_at,
+			reviewed_by,
+			COALESCE(DATE_FORMAT(reviewed_at, '%Y-%m-%dT%H:%i:%sZ'), '') AS reviewed_at,
+			rejection_reason,
 			nguoi_phe_duyet,
 			nguoi_phe_duyet_email,
 			thoi_gian_phe_duyet,
@@ -268,6 +302,13 @@ func (r *OrderRepository) ListPendingOrders() ([]PendingOrder, error) {
 			&order.Email,
 			&order.Source,
 			&order.GroupKey,
+			&order.ApprovalStatus,
+			&order.ApprovalBatchID,
+			&order.ProposedBy,
+			&order.ProposedAt,
+			&order.ReviewedBy,
+			&order.ReviewedAt,
+			&order.RejectionReason,
 			&order.NguoiPheDuyet,
 			&order.NguoiPheDuyetEmail,
 			&order.ThoiGianPheDuyet,
@@ -321,6 +362,13 @@ func (r *OrderRepository) GetPendingOrdersByIDs(orderIDs []int64) ([]PendingOrde
 			email,
 			source,
 			group_key,
+			approval_status,
+			approval_batch_id,
+			proposed_by,
+			COALESCE(DATE_FORMAT(proposed_at, '%%Y-%%m-%%dT%%H:%%i:%%sZ'), '') AS proposed_at,
+			reviewed_by,
+			COALESCE(DATE_FORMAT(reviewed_at, '%%Y-%%m-%%dT%%H:%%i:%%sZ'), '') AS reviewed_at,
+			rejection_reason,
 			nguoi_phe_duyet,
 			nguoi_phe_duyet_email,
 			thoi_gian_phe_duyet,
@@ -357,6 +405,13 @@ func (r *OrderRepository) GetPendingOrdersByIDs(orderIDs []int64) ([]PendingOrde
 			&order.Email,
 			&order.Source,
 			&order.GroupKey,
+			&order.ApprovalStatus,
+			&order.ApprovalBatchID,
+			&order.ProposedBy,
+			&order.ProposedAt,
+			&order.ReviewedBy,
+			&order.ReviewedAt,
+			&order.RejectionReason,
 			&order.NguoiPheDuyet,
 			&order.NguoiPheDuyetEmail,
 			&order.ThoiGianPheDuyet,
@@ -407,7 +462,11 @@ func (r *OrderRepository) ListOrderHistory() ([]OrderHistoryRecord, error) {
 			ngay_tao,
 			ngay_dat_hang,
 			trang_thai,
+			lifecycle_status,
+			delivered_qty,
 			email_sent,
+			email_outbox_id,
+			COALESCE((SELECT status FROM order_email_outbox o WHERE o.id = order_history.email_outbox_id), ''),
 			nguoi_dat_hang,
 			nguoi_dat_hang_email
 		FROM order_history
@@ -423,6 +482,7 @@ func (r *OrderRepository) ListOrderHistory() ([]OrderHistoryRecord, error) {
 		var item OrderHistoryRecord
 		var companyContactID sql.NullString
 		var emailSent int
+		var emailOutboxID sql.NullInt64
 		if err := rows.Scan(
 			&item.ID,
 			&companyContactID,
@@ -445,7 +505,11 @@ func (r *OrderRepository) ListOrderHistory() ([]OrderHistoryRecord, error) {
 			&item.NgayTao,
 			&item.NgayDatHang,
 			&item.TrangThai,
+			&item.LifecycleStatus,
+			&item.DeliveredQty,
 			&emailSent,
+			&emailOutboxID,
+			&item.EmailStatus,
 			&item.NguoiDatHang,
 			&item.NguoiDatHangEmail,
 		); err != nil {
@@ -456,6 +520,10 @@ func (r *OrderRepository) ListOrderHistory() ([]OrderHistoryRecord, error) {
 			item.CompanyContactID = &value
 		}
 		item.EmailSent = emailSent == 1
+		if emailOutboxID.Valid {
+			value := emailOutboxID.Int64
+			item.EmailOutboxID = &value
+		}
 		normalizePendingOrderIdentifiers(&item.PendingOrder)
 		history = append(history, item)
 	}
@@ -503,7 +571,11 @@ func (r *OrderRepository) GetOrderHistoryByIDs(orderIDs []int64) ([]OrderHistory
 			ngay_tao,
 			ngay_dat_hang,
 			trang_thai,
+			lifecycle_status,
+			delivered_qty,
 			email_sent,
+			email_outbox_id,
+			COALESCE((SELECT status FROM order_email_outbox o WHERE o.id = order_history.email_outbox_id), ''),
 			nguoi_dat_hang,
 			nguoi_dat_hang_email
 		FROM order_history
@@ -520,6 +592,7 @@ func (r *OrderRepository) GetOrderHistoryByIDs(orderIDs []int64) ([]OrderHistory
 		var item OrderHistoryRecord
 		var companyContactID sql.NullString
 		var emailSent int
+		var emailOutboxID sql.NullInt64
 		if err := rows.Scan(
 			&item.ID,
 			&companyContactID,
@@ -542,7 +615,11 @@ func (r *OrderRepository) GetOrderHistoryByIDs(orderIDs []int64) ([]OrderHistory
 			&item.NgayTao,
 			&item.NgayDatHang,
 			&item.TrangThai,
+			&item.LifecycleStatus,
+			&item.DeliveredQty,
 			&emailSent,
+			&emailOutboxID,
+			&item.EmailStatus,
 			&item.NguoiDatHang,
 			&item.NguoiDatHangEmail,
 		); err != nil {
@@ -553,6 +630,10 @@ func (r *OrderRepository) GetOrderHistoryByIDs(orderIDs []int64) ([]OrderHistory
 			item.CompanyContactID = &value
 		}
 		item.EmailSent = emailSent == 1
+		if emailOutboxID.Valid {
+			value := emailOutboxID.Int64
+			item.EmailOutboxID = &value
+		}
 		normalizePendingOrderIdentifiers(&item.PendingOrder)
 		history = append(history, item)
 	}
@@ -566,7 +647,10 @@ func (r *OrderRepository) GetOrderHistoryByIDs(orderIDs []int64) ([]OrderHistory
 	return history, nil
 }
 
-func (r *OrderRepository) RepeatOrderHistory(history []OrderHistoryRecord, placedBy OrderActor) (int, error) {
+// RepeatOrderHistory copies placed lines back into pending_orders as drafts
+// created by createdBy. They go through propose and approve like any other
+// pending order before they can be placed again.
+func (r *OrderRepository) RepeatOrderHistory(history []OrderHistoryRecord, createdBy OrderActor) (int, error) {
 	if len(history) == 0 {
 		return 0, nil
 	}
@@ -577,75 +661,24 @@ func (r *OrderRepository) RepeatOrderHistory(history []OrderHistoryRecord, place
 	}
 	defer tx.Rollback()
 
-	placedAt := currentTimestamp()
+	now := currentTimestamp()
 	for _, order := range history {
-		maQuanLy, maVtytCu := NormalizeMaterialIdentifiers(order.MaQuanLy, order.MaVtytCu)
-		var companyContactID interface{}
-		if order.CompanyContactID != nil {
-			companyContactID = *order.CompanyContactID
-		}
-
-		if _, err := tx.Exec(`
-			INSERT INTO order_history (
-				pending_order_id,
-				company_contact_id,
-				nha_thau,
-				ma_quan_ly,
-				ma_vtyt_cu,
-				ten_vtyt_bv,
-				ma_hieu,
-				hang_sx,
-				don_vi_tinh,
-				quy_cach,
-				so_luong,
-				email,
-				source,
-				nguoi_phe_duyet_id,
-				nguoi_phe_duyet,
-				nguoi_phe_duyet_email,
-				thoi_gian_phe_duyet,
-				nguoi_tao_don_id,
-				nguoi_tao_don,
-				nguoi_tao_don_email,
-				ngay_tao,
-				ngay_dat_hang,
-				trang_thai,
-				email_sent,
-				nguoi_dat_hang_id,
-				nguoi_dat_hang,
-				nguoi_dat_hang_email
-			)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`,
-			nil,
-			companyContactID,
-			order.NhaThau,
-			maQuanLy,
-			maVtytCu,
-			order.TenVtytBv,
-			order.MaHieu,
-			order.HangSx,
-			order.DonViTinh,
-			order.QuyCach,
-			order.DotGoiHang,
-			order.Email,
-			order.Source,
-			nil,
-			order.NguoiPheDuyet,
-			order.NguoiPheDuyetEmail,
-			order.ThoiGianPheDuyet,
-			nil,
-			order.NguoiTaoDon,
-			order.NguoiTaoDonEmail,
-			order.NgayTao,
-			placedAt,
-			"Đã gửi email",
-			1,
-			placedBy.ID,
-			placedBy.Username,
-			placedBy.Email,
-		); err != nil {
-			return 0, fmt.Errorf("error inserting repeated order history: %w", err)
+		if err := r.insertPendingOrderTx(tx, CreatePendingOrderInput{
+			CompanyContactID: order.CompanyContactID,
+			NhaThau:          order.NhaThau,
+			MaQuanLy:         order.MaQuanLy,
+			MaVtytCu:         order.MaVtytCu,
+			TenVtytBv:        order.TenVtytBv,
+			MaHieu:           order.MaHieu,
+			HangSx:           order.HangSx,
+			DonViTinh:        order.DonViTinh,
+			QuyCach:          order.QuyCach,
+			DotGoiHang:       order.DotGoiHang,
+			Email:            order.Email,
+			Source:           OrderSourceManual,
+			CreatedBy:        createdBy,
+		}, now); err != nil {
+			return 0, err
 		}
 	}
 
@@ -740,7 +773,10 @@ func (r *OrderRepository) AddManualOrder(input CreatePendingOrderInput) error {
 	return nil
 }
 
-func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (int, error) {
+// PlaceOrders moves approved pending orders into order history and queues
+// one supplier email per recipient. Each line keeps the catalog unit price
+// it was placed at, and snapshot captures each email's document.
+func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor, snapshot OrderDocumentSnapshotFunc) (int, error) {
 	if len(orderIDs) == 0 {
 		return 0, nil
 	}
@@ -772,6 +808,7 @@ func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (in
 			so_luong,
 			email,
 			source,
+			approval_status,
 			nguoi_phe_duyet_id,
 			nguoi_phe_duyet,
 			nguoi_phe_duyet_email,
@@ -783,6 +820,7 @@ func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (in
 		FROM pending_orders
 		WHERE id IN (%s)
 		ORDER BY updated_at DESC, id DESC
+		FOR UPDATE
 	`, placeholders)
 
 	rows, err := tx.Query(query, args...)
@@ -805,6 +843,7 @@ func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (in
 		DotGoiHang         int
 		Email              string
 		Source             string
+		ApprovalStatus     string
 		NguoiPheDuyetID    sql.NullInt64
 		NguoiPheDuyet      string
 		NguoiPheDuyetEmail string
@@ -832,6 +871,7 @@ func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (in
 			&order.DotGoiHang,
 			&order.Email,
 			&order.Source,
+			&order.ApprovalStatus,
 			&order.NguoiPheDuyetID,
 			&order.NguoiPheDuyet,
 			&order.NguoiPheDuyetEmail,
@@ -843,6 +883,9 @@ func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (in
 		); err != nil {
 			return 0, fmt.Errorf("error scanning pending order before placing: %w", err)
 		}
+		if order.ApprovalStatus != PendingOrderStatusApproved {
+			return 0, ErrPendingOrdersNotApproved
+		}
 		order.MaQuanLy, order.MaVtytCu = NormalizeMaterialIdentifiers(order.MaQuanLy, order.MaVtytCu)
 		selectedOrders = append(selectedOrders, order)
 	}
@@ -856,7 +899,13 @@ func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (in
 	}
 
 	placedAt := currentTimestamp()
+	outboxIDs := make(map[string]int64)
 	for _, order := range selectedOrders {
+		outboxID, err := enqueueOrderEmailTx(tx, outboxIDs, order.Email, order.NhaThau, placedBy, snapshot)
+		if err != nil {
+			return 0, err
+		}
+
 		if _, err := tx.Exec(`
 			INSERT INTO order_history (
 				pending_order_id,
@@ -883,11 +932,12 @@ func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (in
 				ngay_dat_hang,
 				trang_thai,
 				email_sent,
+				email_outbox_id,
 				nguoi_dat_hang_id,
 				nguoi_dat_hang,
 				nguoi_dat_hang_email
 			)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`,
 			order.ID,
 			nullStringToValue(order.CompanyContactID),
@@ -911,8 +961,9 @@ func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (in
 			order.NguoiTaoDonEmail,
 			order.NgayTao,
 			placedAt,
-			"Đã gửi email",
-			1,
+			OrderHistoryStatusLabel(OrderLineStatusSent, OrderEmailStatusPending),
+			0,
+			outboxID,
 			placedBy.ID,
 			placedBy.Username,
 			placedBy.Email,
@@ -921,6 +972,18 @@ func (r *OrderRepository) PlaceOrders(orderIDs []int64, placedBy OrderActor) (in
 		}
 	}
 
+	outboxArgs := make([]interface{}, 0, len(outboxIDs))
+	for _, outboxID := range outboxIDs {
+		outboxArgs = append(outboxArgs, outboxID)
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`
+		UPDATE order_history oh
+		SET oh.don_gia = COALESCE((`+orderLineCatalogPriceSQL+`), 0)
+		WHERE oh.email_outbox_id IN (%s)
+	`, makePlaceholders(len(outboxArgs))), outboxArgs...); err != nil {
+		return 0, fmt.Errorf("error storing placed order prices: %w", err)
+	}
+
 	deleteQuery := fmt.Sprintf(`DELETE FROM pending_orders WHERE id IN (%s)`, placeholders)
 	if _, err := tx.Exec(deleteQuery, args...); err != nil {
 		return 0, fmt.Errorf("error deleting placed pending orders: %w", err)
@@ -1065,8 +1128,12 @@ func (r *OrderRepository) tableExists(tableName string) (bool, error) {
 }
 
 func (r *OrderRepository) columnExists(tableName, columnName string) (bool, error) {
+	return columnExists(r.DB, tableName, columnName)
+}
+
+func columnExists(db *sql.DB, tableName, columnName string) (bool, error) {
 	var count int
-	if err := r.DB.QueryRow(`
+	if err := db.QueryRow(`
 		SELECT COUNT(*)
 		FROM information_schema.columns
 		WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?
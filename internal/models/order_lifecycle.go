@@ -0,0 +1,373 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/config"
+)
+
+const (
+	OrderLineStatusSent               = "sent"
+	OrderLineStatusPartiallyDelivered = "partially_delivered"
+	OrderLineStatusDelivered          = "delivered"
+	OrderLineStatusCancelled          = "cancelled"
+	OrderLineStatusOverdue            = "overdue"
+)
+
+const defaultOrderDeliveryDueDays = 14
+
+// orderLineStatusLabels keeps trang_thai readable for the existing UI while
+// lifecycle_status carries the machine-readable state.
+var orderLineStatusLabels = map[string]string{
+	OrderLineStatusSent:               "Đã gửi email",
+	OrderLineStatusPartiallyDelivered: "Giao một phần",
+	OrderLineStatusDelivered:          "Đã giao đủ",
+	OrderLineStatusCancelled:          "Đã hủy",
+	OrderLineStatusOverdue:            "Quá hạn giao",
+}
+
+type OrderOutstandingFilter struct {
+	NhaThau  string
+	MaQuanLy string
+}
+
+type OrderOutstandingQuantity struct {
+	CompanyContactID *string `json:"companyContactId,omitempty"`
+	NhaThau          string  `json:"nhaThau"`
+	MaQuanLy         string  `json:"maQuanLy"`
+	MaVtytCu         string  `json:"maVtytCu"`
+	TenVtytBv        string  `json:"tenVtytBv"`
+	DonViTinh        string  `json:"donViTinh"`
+	OrderedQty       float64 `json:"orderedQty"`
+	DeliveredQty     float64 `json:"deliveredQty"`
+	OutstandingQty   float64 `json:"outstandingQty"`
+	OpenLineCount    int     `json:"openLineCount"`
+	OverdueLineCount int     `json:"overdueLineCount"`
+	OldestOrderDate  string  `json:"oldestOrderDate"`
+}
+
+// OrderHistoryStatusLabel is the trang_thai shown for a line. Until anything
+// is delivered or cancelled the supplier email state is what matters most.
+func OrderHistoryStatusLabel(lifecycleStatus, emailStatus string) string {
+	if lifecycleStatus == OrderLineStatusSent {
+		switch emailStatus {
+		case OrderEmailStatusPending, OrderEmailStatusSending:
+			return "Chờ gửi email"
+		case OrderEmailStatusFailed:
+			return "Gửi email lỗi"
+		}
+	}
+	return OrderLineStatusLabel(lifecycleStatus)
+}
+
+func OrderLineStatusLabel(status string) string {
+	if label, ok := orderLineStatusLabels[status]; ok {
+		return label
+	}
+	return orderLineStatusLabels[OrderLineStatusSent]
+}
+
+func resolveOrderDeliveryDueDays() int {
+	if config.AppConfig == nil || config.AppConfig.OrderDeliveryDueDays <= 0 {
+		return defaultOrderDeliveryDueDays
+	}
+	return config.AppConfig.OrderDeliveryDueDays
+}
+
+func (r *OrderRepository) ensureOrderLifecycleColumns() error {
+	tableName := "order_history"
+
+	type lifecycleColumn struct {
+		name      string
+		statement string
+	}
+
+	columns := []lifecycleColumn{
+		{
+			name:      "lifecycle_status",
+			statement: "ALTER TABLE order_history ADD COLUMN lifecycle_status VARCHAR(32) NOT NULL DEFAULT 'sent' AFTER trang_thai, ADD KEY idx_order_history_lifecycle (lifecycle_status)",
+		},
+		{
+			name:      "delivered_qty",
+			statement: "ALTER TABLE order_history ADD COLUMN delivered_qty DECIMAL(18,3) NOT NULL DEFAULT 0 AFTER lifecycle_status",
+		},
+		{
+			name:      "lifecycle_updated_at",
+			statement: "ALTER TABLE order_history ADD COLUMN lifecycle_updated_at DATETIME NULL AFTER delivered_qty",
+		},
+	}
+
+	for _, column := range columns {
+		exists, err := r.columnExists(tableName, column.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := r.DB.Exec(column.statement); err != nil {
+			return fmt.Errorf("error ensuring %s.%s: %w", tableName, column.name, err)
+		}
+	}
+
+	return nil
+}
+
+// DeriveOrderLineStatus computes the lifecycle state of a placed line from
+// the quantity delivered so far. Cancelled lines never change again, and a
+// line that is still short after the delivery window is overdue even when
+// part of it has arrived.
+func DeriveOrderLineStatus(currentStatus string, orderedQty int, deliveredQty float64, placedAt, now time.Time, dueDays int) string {
+	if strings.TrimSpace(currentStatus) == OrderLineStatusCancelled {
+		return OrderLineStatusCancelled
+	}
+	if orderedQty > 0 && deliveredQty >= float64(orderedQty) {
+		return OrderLineStatusDelivered
+	}
+	if !placedAt.IsZero() && dueDays > 0 && now.After(placedAt.AddDate(0, 0, dueDays)) {
+		return OrderLineStatusOverdue
+	}
+	if deliveredQty > 0 {
+		return OrderLineStatusPartiallyDelivered
+	}
+	return OrderLineStatusSent
+}
+
+// SyncOrderLifecycle recomputes delivered quantity and lifecycle status for
+// every open order history line from the matched invoice quantities in
+// order_invoice_reconciliation. It returns the number of lines that changed.
+// Each update only applies when the line still has the status, label and
+// email flag that were read, so a concurrent write from the email outbox or
+// an amendment wins and the line is picked up again on the next run.
+func (r *OrderRepository) SyncOrderLifecycle(now time.Time) (int, error) {
+	rows, err := r.DB.Query(`
+		SELECT
+			oh.id,
+			oh.so_luong,
+			oh.ngay_dat_hang,
+			oh.lifecycle_status,
+			oh.trang_thai,
+			oh.delivered_qty,
+			COALESCE(delivered.qty, 0),
+			oh.email_sent,
+			COALESCE(outbox.status, '')
+		FROM order_history oh
+		LEFT JOIN (
+			SELECT order_history_id, SUM(invoice_qty) AS qty
+			FROM order_invoice_reconciliation
+			WHERE has_invoice = 1
+			GROUP BY order_history_id
+		) delivered ON delivered.order_history_id = oh.id
+		LEFT JOIN order_email_outbox outbox ON outbox.id = oh.email_outbox_id
+		WHERE oh.lifecycle_status <> ?
+	`, OrderLineStatusCancelled)
+	if err != nil {
+		return 0, fmt.Errorf("error loading order lifecycle: %w", err)
+	}
+	defer rows.Close()
+
+	type lifecycleUpdate struct {
+		id           int64
+		status       string
+		label        string
+		deliveredQty float64
+		readStatus   string
+		readLabel    string
+		readSent     int
+	}
+
+	dueDays := resolveOrderDeliveryDueDays()
+	updates := make([]lifecycleUpdate, 0)
+	for rows.Next() {
+		var id int64
+		var orderedQty int
+		var ngayDatHang string
+		var currentStatus string
+		var currentLabel string
+		var storedQty float64
+		var deliveredQty float64
+		var emailSent int
+		var emailStatus string
+		if err := rows.Scan(&id, &orderedQty, &ngayDatHang, &currentStatus, &currentLabel, &storedQty, &deliveredQty, &emailSent, &emailStatus); err != nil {
+			return 0, fmt.Errorf("error scanning order lifecycle: %w", err)
+		}
+		if emailSent == 1 {
+			emailStatus = OrderEmailStatusSent
+		}
+
+		status := DeriveOrderLineStatus(currentStatus, orderedQty, deliveredQty, parseOrderTimestamp(ngayDatHang), now, dueDays)
+		label := OrderHistoryStatusLabel(status, emailStatus)
+		if status != currentStatus || label != currentLabel || storedQty != deliveredQty {
+			updates = append(updates, lifecycleUpdate{
+				id:           id,
+				status:       status,
+				label:        label,
+				deliveredQty: deliveredQty,
+				readStatus:   currentStatus,
+				readLabel:    currentLabel,
+				readSent:     emailSent,
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating order lifecycle: %w", err)
+	}
+
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting order lifecycle transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	changed := 0
+	for _, update := range updates {
+		result, err := tx.Exec(`
+			UPDATE order_history
+			SET lifecycle_status = ?, trang_thai = ?, delivered_qty = ?, lifecycle_updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND lifecycle_status = ? AND trang_thai = ? AND email_sent = ?
+		`, update.status, update.label, update.deliveredQty, update.id, update.readStatus, update.readLabel, update.readSent)
+		if err != nil {
+			return 0, fmt.Errorf("error updating order lifecycle: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("error reading order lifecycle update result: %w", err)
+		}
+		changed += int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing order lifecycle: %w", err)
+	}
+
+	return changed, nil
+}
+
+// ListOutstandingQuantities sums what is still owed per supplier and
+// material across lines that are neither delivered nor cancelled.
+func (r *OrderRepository) ListOutstandingQuantities(filter OrderOutstandingFilter) ([]OrderOutstandingQuantity, error) {
+	conditions := []string{"lifecycle_status IN (?, ?, ?)"}
+	args := []interface{}{OrderLineStatusSent, OrderLineStatusPartiallyDelivered, OrderLineStatusOverdue}
+	if nhaThau := strings.TrimSpace(filter.NhaThau); nhaThau != "" {
+		conditions = append(conditions, "nha_thau LIKE ?")
+		args = append(args, "%"+nhaThau+"%")
+	}
+	if maQuanLy := strings.TrimSpace(filter.MaQuanLy); maQuanLy != "" {
+		conditions = append(conditions, "(ma_quan_ly = ? OR ma_vtyt_cu = ?)")
+		args = append(args, maQuanLy, maQuanLy)
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT
+			id,
+			company_contact_id,
+			nha_thau,
+			ma_quan_ly,
+			ma_vtyt_cu,
+			ten_vtyt_bv,
+			don_vi_tinh,
+			so_luong,
+			delivered_qty,
+			lifecycle_status,
+			ngay_dat_hang
+		FROM order_history
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY nha_thau, ma_quan_ly, ngay_dat_hang, id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing outstanding order quantities: %w", err)
+	}
+	defer rows.Close()
+
+	grouped := make(map[string]*OrderOutstandingQuantity)
+	keys := make([]string, 0)
+	for rows.Next() {
+		var id int64
+		var companyContactID sql.NullString
+		var line PendingOrder
+		var orderedQty int
+		var deliveredQty float64
+		var status string
+		var ngayDatHang string
+		if err := rows.Scan(
+			&id,
+			&companyContactID,
+			&line.NhaThau,
+			&line.MaQuanLy,
+			&line.MaVtytCu,
+			&line.TenVtytBv,
+			&line.DonViTinh,
+			&orderedQty,
+			&deliveredQty,
+			&status,
+			&ngayDatHang,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning outstanding order quantity: %w", err)
+		}
+		normalizePendingOrderIdentifiers(&line)
+
+		outstanding := float64(orderedQty) - deliveredQty
+		if outstanding <= 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line.NhaThau)) + "|" + strings.ToUpper(PreferredMaterialCode(line.MaQuanLy, line.MaVtytCu))
+		item, exists := grouped[key]
+		if !exists {
+			item = &OrderOutstandingQuantity{
+				NhaThau:         line.NhaThau,
+				MaQuanLy:        line.MaQuanLy,
+				MaVtytCu:        line.MaVtytCu,
+				TenVtytBv:       line.TenVtytBv,
+				DonViTinh:       line.DonViTinh,
+				OldestOrderDate: ngayDatHang,
+			}
+			if companyContactID.Valid {
+				value := companyContactID.String
+				item.CompanyContactID = &value
+			}
+			grouped[key] = item
+			keys = append(keys, key)
+		}
+
+		item.OrderedQty += float64(orderedQty)
+		item.DeliveredQty += deliveredQty
+		item.OutstandingQty += outstanding
+		item.OpenLineCount++
+		if status == OrderLineStatusOverdue {
+			item.OverdueLineCount++
+		}
+		if parseOrderTimestamp(ngayDatHang).Before(parseOrderTimestamp(item.OldestOrderDate)) {
+			item.OldestOrderDate = ngayDatHang
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outstanding order quantities: %w", err)
+	}
+
+	sort.Strings(keys)
+	result := make([]OrderOutstandingQuantity, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *grouped[key])
+	}
+	return result, nil
+}
+
+func parseOrderTimestamp(value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeriveOrderLineStatus(t *testing.T) {
+	t.Parallel()
+
+	placedAt := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	withinWindow := placedAt.AddDate(0, 0, 3)
+	pastWindow := placedAt.AddDate(0, 0, 15)
+
+	cases := []struct {
+		name      string
+		current   string
+		ordered   int
+		delivered float64
+		now       time.Time
+		expected  string
+	}{
+		{name: "nothing delivered yet", current: OrderLineStatusSent, ordered: 10, now: withinWindow, expected: OrderLineStatusSent},
+		{name: "part delivered", current: OrderLineStatusSent, ordered: 10, delivered: 4, now: withinWindow, expected: OrderLineStatusPartiallyDelivered},
+		{name: "fully delivered", current: OrderLineStatusPartiallyDelivered, ordered: 10, delivered: 10, now: withinWindow, expected: OrderLineStatusDelivered},
+		{name: "over delivered", current: OrderLineStatusSent, ordered: 10, delivered: 12, now: pastWindow, expected: OrderLineStatusDelivered},
+		{name: "short after due date", current: OrderLineStatusPartiallyDelivered, ordered: 10, delivered: 4, now: pastWindow, expected: OrderLineStatusOverdue},
+		{name: "nothing after due date", current: OrderLineStatusSent, ordered: 10, now: pastWindow, expected: OrderLineStatusOverdue},
+		{name: "cancelled stays cancelled", current: OrderLineStatusCancelled, ordered: 10, delivered: 10, now: pastWindow, expected: OrderLineStatusCancelled},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := DeriveOrderLineStatus(tc.current, tc.ordered, tc.delivered, placedAt, tc.now, 14)
+			if got != tc.expected {
+				t.Fatalf("DeriveOrderLineStatus() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestOrderHistoryStatusLabel(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		lifecycle string
+		email     string
+		expected  string
+	}{
+		{lifecycle: OrderLineStatusSent, email: OrderEmailStatusPending, expected: "Chờ gửi email"},
+		{lifecycle: OrderLineStatusSent, email: OrderEmailStatusFailed, expected: "Gửi email lỗi"},
+		{lifecycle: OrderLineStatusSent, email: OrderEmailStatusSent, expected: "Đã gửi email"},
+		{lifecycle: OrderLineStatusSent, email: "", expected: "Đã gửi email"},
+		{lifecycle: OrderLineStatusPartiallyDelivered, email: OrderEmailStatusFailed, expected: "Giao một phần"},
+		{lifecycle: OrderLineStatusCancelled, email: OrderEmailStatusPending, expected: "Đã hủy"},
+	}
+
+	for _, tc := range cases {
+		if got := OrderHistoryStatusLabel(tc.lifecycle, tc.email); got != tc.expected {
+			t.Fatalf("OrderHistoryStatusLabel(%q, %q) = %q, want %q", tc.lifecycle, tc.email, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPasswordResetTokenInvalid is returned when a reset token is unknown,
+// expired or was already used.
+var ErrPasswordResetTokenInvalid = errors.New("password reset token is invalid or has expired")
+
+// PasswordResetToken is a single-use token mailed to a user who forgot their
+// password. Like refresh tokens, only the SHA-256 of the token is stored.
+type PasswordResetToken struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"userId"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	IPAddress string     `json:"ipAddress"`
+}
+
+type PasswordResetTokenRepository struct {
+	DB *sql.DB
+}
+
+func NewPasswordResetTokenRepository(db *sql.DB) *PasswordResetTokenRepository {
+	return &PasswordResetTokenRepository{DB: db}
+}
+
+func (r *PasswordResetTokenRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			user_id BIGINT NOT NULL,
+			token_hash CHAR(64) NOT NULL,
+			expires_at DATETIME NOT NULL,
+			used_at DATETIME NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			ip_address VARCHAR(64) NOT NULL DEFAULT '',
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_password_reset_tokens_hash (token_hash),
+			KEY idx_password_reset_tokens_user (user_id, used_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`); err != nil {
+		return fmt.Errorf("error ensuring password reset token schema: %w", err)
+	}
+	return nil
+}
+
+// NewPasswordResetTokenValue returns a random token for the email link and the
+// hash to store for it. HashRefreshToken hashes presented reset tokens too.
+func NewPasswordResetTokenValue() (string, string, error) {
+	return newHashedToken("password reset token")
+}
+
+// Create stores a new reset token and retires the user's earlier unused ones,
+// so only the most recent email works.
+func (r *PasswordResetTokenRepository) Create(token *PasswordResetToken, tokenHash string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting password reset token creation: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE password_reset_tokens
+		SET used_at = ?
+		WHERE user_id = ? AND used_at IS NULL
+	`, token.CreatedAt, token.UserID); err != nil {
+		return fmt.Errorf("error retiring password reset tokens: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at, ip_address)
+		VALUES (?, ?, ?, ?, ?)
+	`, token.UserID, tokenHash, token.ExpiresAt, token.CreatedAt, truncateColumnText(token.IPAddress, 64))
+	if err != nil {
+		return fmt.Errorf("error creating password reset token: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error getting password reset token id: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing password reset token: %w", err)
+	}
+	token.ID = id
+	return nil
+}
+
+// Consume marks the token as used and returns its user. The update only
+// matches an unused, unexpired token, so a token can be redeemed once even
+// under concurrent requests.
+func (r *PasswordResetTokenRepository) Consume(tokenHash string, now time.Time) (int64, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting password reset: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id, userID int64
+	err = tx.QueryRow(`
+		SELECT id, user_id
+		FROM password_reset_tokens
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?
+		FOR UPDATE
+	`, tokenHash, now).Scan(&id, &userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrPasswordResetTokenInvalid
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error querying password reset token: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE password_reset_tokens
+		SET used_at = ?
+		WHERE id = ?
+	`, now, id); err != nil {
+		return 0, fmt.Errorf("error using password reset token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing password reset: %w", err)
+	}
+	return userID, nil
+}
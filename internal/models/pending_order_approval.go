@@ -0,0 +1,230 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	PendingOrderStatusDraft    = "draft"
+	PendingOrderStatusProposed = "proposed"
+	PendingOrderStatusApproved = "approved"
+	PendingOrderStatusRejected = "rejected"
+)
+
+var (
+	ErrPendingOrdersNotApproved   = errors.New("pending orders are not approved")
+	ErrPendingOrderStatusConflict = errors.New("pending orders changed status before the update was applied")
+	ErrPendingOrderBatchNotFound  = errors.New("pending order approval batch not found")
+)
+
+type PendingOrderReview struct {
+	BatchID string
+	Status  string
+	Reason  string
+	Actor   OrderActor
+}
+
+func (r *OrderRepository) ensurePendingApprovalColumns() error {
+	tableName := "pending_orders"
+
+	type approvalColumn struct {
+		name      string
+		statement string
+	}
+
+	columns := []approvalColumn{
+		{
+			name:      "approval_status",
+			statement: "ALTER TABLE pending_orders ADD COLUMN approval_status VARCHAR(20) NOT NULL DEFAULT 'draft' AFTER group_key",
+		},
+		{
+			name:      "approval_batch_id",
+			statement: "ALTER TABLE pending_orders ADD COLUMN approval_batch_id VARCHAR(64) NOT NULL DEFAULT '' AFTER approval_status, ADD KEY idx_pending_orders_approval_batch (approval_batch_id)",
+		},
+		{
+			name:      "proposed_by_id",
+			statement: "ALTER TABLE pending_orders ADD COLUMN proposed_by_id BIGINT NULL AFTER approval_batch_id",
+		},
+		{
+			name:      "proposed_by",
+			statement: "ALTER TABLE pending_orders ADD COLUMN proposed_by VARCHAR(255) NOT NULL DEFAULT '' AFTER proposed_by_id",
+		},
+		{
+			name:      "proposed_at",
+			statement: "ALTER TABLE pending_orders ADD COLUMN proposed_at DATETIME NULL AFTER proposed_by",
+		},
+		{
+			name:      "reviewed_by_id",
+			statement: "ALTER TABLE pending_orders ADD COLUMN reviewed_by_id BIGINT NULL AFTER proposed_at",
+		},
+		{
+			name:      "reviewed_by",
+			statement: "ALTER TABLE pending_orders ADD COLUMN reviewed_by VARCHAR(255) NOT NULL DEFAULT '' AFTER reviewed_by_id",
+		},
+		{
+			name:      "reviewed_at",
+			statement: "ALTER TABLE pending_orders ADD COLUMN reviewed_at DATETIME NULL AFTER reviewed_by",
+		},
+		{
+			name:      "rejection_reason",
+			statement: "ALTER TABLE pending_orders ADD COLUMN rejection_reason VARCHAR(1000) NOT NULL DEFAULT '' AFTER reviewed_at",
+		},
+	}
+
+	for _, column := range columns {
+		exists, err := r.columnExists(tableName, column.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := r.DB.Exec(column.statement); err != nil {
+			return fmt.Errorf("error ensuring %s.%s: %w", tableName, column.name, err)
+		}
+	}
+
+	return nil
+}
+
+// ProposePendingOrders groups draft or rejected pending orders into a new
+// approval batch for Chi huy khoa to review. It fails with
+// ErrPendingOrderStatusConflict when any row is no longer proposable.
+func (r *OrderRepository) ProposePendingOrders(orderIDs []int64, proposedBy OrderActor) (string, error) {
+	if len(orderIDs) == 0 {
+		return "", fmt.Errorf("no pending orders found")
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return "", fmt.Errorf("error starting propose order transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	batchID := newPendingOrderBatchID(time.Now())
+	args := []interface{}{
+		PendingOrderStatusProposed,
+		batchID,
+		proposedBy.ID,
+		proposedBy.Username,
+	}
+	for _, orderID := range orderIDs {
+		args = append(args, orderID)
+	}
+	args = append(args, PendingOrderStatusDraft, PendingOrderStatusRejected)
+
+	result, err := tx.Exec(fmt.Sprintf(`
+		UPDATE pending_orders
+		SET approval_status = ?,
+			approval_batch_id = ?,
+			proposed_by_id = ?,
+			proposed_by = ?,
+			proposed_at = NOW(),
+			reviewed_by_id = NULL,
+			reviewed_by = '',
+			reviewed_at = NULL,
+			rejection_reason = ''
+		WHERE id IN (%s) AND approval_status IN (?, ?)
+	`, makePlaceholders(len(orderIDs))), args...)
+	if err != nil {
+		return "", fmt.Errorf("error proposing pending orders: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return "", fmt.Errorf("error reading proposed pending orders: %w", err)
+	}
+	if int(affected) != len(orderIDs) {
+		return "", ErrPendingOrderStatusConflict
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("error committing proposed pending orders: %w", err)
+	}
+
+	return batchID, nil
+}
+
+// ReviewPendingOrderBatch approves or rejects every proposed row of a batch.
+func (r *OrderRepository) ReviewPendingOrderBatch(review PendingOrderReview) (int, error) {
+	status := strings.TrimSpace(review.Status)
+	if status != PendingOrderStatusApproved && status != PendingOrderStatusRejected {
+		return 0, fmt.Errorf("invalid pending order review status %q", review.Status)
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting review order transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total, proposed int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(approval_status = ?), 0)
+		FROM pending_orders
+		WHERE approval_batch_id = ?
+		FOR UPDATE
+	`, PendingOrderStatusProposed, review.BatchID).Scan(&total, &proposed); err != nil {
+		return 0, fmt.Errorf("error loading pending order batch: %w", err)
+	}
+	if total == 0 {
+		return 0, ErrPendingOrderBatchNotFound
+	}
+	if proposed != total {
+		return 0, ErrPendingOrderStatusConflict
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE pending_orders
+		SET approval_status = ?,
+			reviewed_by_id = ?,
+			reviewed_by = ?,
+			reviewed_at = NOW(),
+			rejection_reason = ?
+		WHERE approval_batch_id = ?
+	`, status, review.Actor.ID, review.Actor.Username, strings.TrimSpace(review.Reason), review.BatchID); err != nil {
+		return 0, fmt.Errorf("error reviewing pending order batch: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing pending order review: %w", err)
+	}
+
+	return total, nil
+}
+
+func (r *OrderRepository) GetPendingOrderBatchStatuses(batchID string) (map[int64]string, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, approval_status
+		FROM pending_orders
+		WHERE approval_batch_id = ?
+	`, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pending order batch: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var status string
+		if err := rows.Scan(&id, &status); err != nil {
+			return nil, fmt.Errorf("error scanning pending order batch: %w", err)
+		}
+		statuses[id] = status
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending order batch: %w", err)
+	}
+
+	return statuses, nil
+}
+
+func newPendingOrderBatchID(now time.Time) string {
+	return "POB" + now.Format("20060102150405.000000")
+}
@@ -0,0 +1,239 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token that was already
+// rotated is presented again.
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+// RefreshToken is one link of a session. Every refresh replaces the presented
+// token with a new one of the same family; only the SHA-256 of the token is
+// stored.
+type RefreshToken struct {
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"userId"`
+	FamilyID     string     `json:"familyId"`
+	TokenVersion int64      `json:"tokenVersion"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	ReplacedByID *int64     `json:"replacedById,omitempty"`
+	UserAgent    string     `json:"userAgent"`
+	IPAddress    string     `json:"ipAddress"`
+}
+
+type RefreshTokenRepository struct {
+	DB *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{DB: db}
+}
+
+func (r *RefreshTokenRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			user_id BIGINT NOT NULL,
+			token_hash CHAR(64) NOT NULL,
+			family_id CHAR(32) NOT NULL,
+			token_version BIGINT NOT NULL DEFAULT 0,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			revoked_at DATETIME NULL,
+			replaced_by_id BIGINT NULL,
+			user_agent VARCHAR(500) NOT NULL DEFAULT '',
+			ip_address VARCHAR(64) NOT NULL DEFAULT '',
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_refresh_tokens_hash (token_hash),
+			KEY idx_refresh_tokens_user (user_id, revoked_at),
+			KEY idx_refresh_tokens_family (family_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`); err != nil {
+		return fmt.Errorf("error ensuring refresh token schema: %w", err)
+	}
+	return nil
+}
+
+// NewRefreshTokenValue returns a random token for the client and the hash to
+// store for it.
+func NewRefreshTokenValue() (string, string, error) {
+	return newHashedToken("refresh token")
+}
+
+func newHashedToken(kind string) (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("error generating %s: %w", kind, err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+func NewRefreshTokenFamilyID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating refresh token family: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *RefreshTokenRepository) Create(token *RefreshToken, tokenHash string) error {
+	id, err := insertRefreshToken(r.DB, token, tokenHash)
+	if err != nil {
+		return err
+	}
+	token.ID = id
+	return nil
+}
+
+func (r *RefreshTokenRepository) GetByHash(tokenHash string) (*RefreshToken, error) {
+	token, err := scanRefreshToken(r.DB.QueryRow(`
+		SELECT `+refreshTokenColumns+`
+		FROM refresh_tokens
+		WHERE token_hash = ?
+	`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// Rotate revokes current and stores next in its place. It returns
+// ErrRefreshTokenReused when current was revoked in the meantime, so two
+// concurrent refreshes with one token cannot both succeed.
+func (r *RefreshTokenRepository) Rotate(current *RefreshToken, next *RefreshToken, nextHash string, now time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting refresh token rotation: %w", err)
+	}
+	defer tx.Rollback()
+
+	nextID, err := insertRefreshToken(tx, next, nextHash)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = ?, replaced_by_id = ?
+		WHERE id = ? AND revoked_at IS NULL
+	`, now, nextID, current.ID)
+	if err != nil {
+		return fmt.Errorf("error revoking rotated refresh token: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrRefreshTokenReused
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing refresh token rotation: %w", err)
+	}
+	next.ID = nextID
+	return nil
+}
+
+// RevokeFamily ends one session, e.g. on logout or when a rotated token is
+// replayed.
+func (r *RefreshTokenRepository) RevokeFamily(familyID string, now time.Time) error {
+	if _, err := r.DB.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = ?
+		WHERE family_id = ? AND revoked_at IS NULL
+	`, now, familyID); err != nil {
+		return fmt.Errorf("error revoking refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeAllForUser(userID int64, now time.Time) error {
+	if _, err := r.DB.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = ?
+		WHERE user_id = ? AND revoked_at IS NULL
+	`, now, userID); err != nil {
+		return fmt.Errorf("error revoking user refresh tokens: %w", err)
+	}
+	return nil
+}
+
+const refreshTokenColumns = `id, user_id, family_id, token_version, expires_at, created_at, revoked_at, replaced_by_id, user_agent, ip_address`
+
+type refreshTokenExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertRefreshToken(execer refreshTokenExecer, token *RefreshToken, tokenHash string) (int64, error) {
+	result, err := execer.Exec(`
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, token_version, expires_at, created_at, user_agent, ip_address)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, token.UserID, tokenHash, token.FamilyID, token.TokenVersion, token.ExpiresAt, token.CreatedAt, truncateColumnText(token.UserAgent, 500), truncateColumnText(token.IPAddress, 64))
+	if err != nil {
+		return 0, fmt.Errorf("error creating refresh token: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error getting refresh token id: %w", err)
+	}
+	return id, nil
+}
+
+func truncateColumnText(value string, maxLength int) string {
+	runes := []rune(strings.TrimSpace(value))
+	if len(runes) <= maxLength {
+		return string(runes)
+	}
+	return string(runes[:maxLength])
+}
+
+type refreshTokenScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRefreshToken(scanner refreshTokenScanner) (*RefreshToken, error) {
+	var token RefreshToken
+	var revokedAt sql.NullTime
+	var replacedByID sql.NullInt64
+	if err := scanner.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenVersion,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&revokedAt,
+		&replacedByID,
+		&token.UserAgent,
+		&token.IPAddress,
+	); err != nil {
+		return nil, err
+	}
+	token.RevokedAt = nullTimePointer(revokedAt)
+	if replacedByID.Valid {
+		value := replacedByID.Int64
+		token.ReplacedByID = &value
+	}
+	return &token, nil
+}
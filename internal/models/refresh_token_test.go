@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestNewRefreshTokenValueReturnsMatchingHash(t *testing.T) {
+	first, firstHash, err := NewRefreshTokenValue()
+	if err != nil {
+		t.Fatalf("NewRefreshTokenValue returned error: %v", err)
+	}
+	second, _, err := NewRefreshTokenValue()
+	if err != nil {
+		t.Fatalf("NewRefreshTokenValue returned error: %v", err)
+	}
+
+	if first == second {
+		t.Fatal("expected two different refresh tokens")
+	}
+	if firstHash != HashRefreshToken(first) || len(firstHash) != 64 {
+		t.Fatalf("hash %q does not match token", firstHash)
+	}
+	if firstHash == first {
+		t.Fatal("the stored hash must differ from the token")
+	}
+}
@@ -0,0 +1,427 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	StockAlertStatusOpen         = "open"
+	StockAlertStatusAcknowledged = "acknowledged"
+	StockAlertStatusSnoozed      = "snoozed"
+	StockAlertStatusResolved     = "resolved"
+)
+
+// ActiveStockAlertStatuses are the states of an alert whose supply is still
+// below its reorder point.
+var ActiveStockAlertStatuses = []string{StockAlertStatusOpen, StockAlertStatusAcknowledged, StockAlertStatusSnoozed}
+
+// StockAlert is raised when closing stock plus quantities already pending or
+// on order falls below TonKhoMin. A supply has at most one unresolved alert;
+// resolved alerts are kept as history and a new row is raised when the
+// supply drops below its reorder point again.
+type StockAlert struct {
+	ID               int64      `json:"id"`
+	SupplyIDX1       int        `json:"supplyIdx1"`
+	MaterialCode     string     `json:"materialCode"`
+	Name             string     `json:"name"`
+	Unit             string     `json:"unit"`
+	TonCuoiKy        int        `json:"tonCuoiKy"`
+	PendingQty       float64    `json:"pendingQty"`
+	OnOrderQty       float64    `json:"onOrderQty"`
+	ProjectedStock   float64    `json:"projectedStock"`
+	TonKhoMin        int        `json:"tonKhoMin"`
+	Shortfall        float64    `json:"shortfall"`
+	Status           string     `json:"status"`
+	SnoozedUntil     *time.Time `json:"snoozedUntil,omitempty"`
+	ActionedByUserID *int64     `json:"actionedByUserId,omitempty"`
+	ActionedAt       *time.Time `json:"actionedAt,omitempty"`
+	RaisedAt         time.Time  `json:"raisedAt"`
+	LastEvaluatedAt  time.Time  `json:"lastEvaluatedAt"`
+	ResolvedAt       *time.Time `json:"resolvedAt,omitempty"`
+}
+
+type StockAlertFilter struct {
+	Statuses []string
+	// VisibleIDX1 limits the result to these supplies; nil means all.
+	VisibleIDX1 []int
+}
+
+type StockAlertRepository struct {
+	DB *sql.DB
+}
+
+func NewStockAlertRepository(db *sql.DB) *StockAlertRepository {
+	return &StockAlertRepository{DB: db}
+}
+
+func (r *StockAlertRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS stock_alerts (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			supply_idx1 INT NOT NULL,
+			material_code VARCHAR(255) NOT NULL DEFAULT '',
+			name TEXT NULL,
+			unit VARCHAR(100) NOT NULL DEFAULT '',
+			ton_cuoi_ky INT NOT NULL DEFAULT 0,
+			pending_qty DECIMAL(18,3) NOT NULL DEFAULT 0,
+			on_order_qty DECIMAL(18,3) NOT NULL DEFAULT 0,
+			projected_stock DECIMAL(18,3) NOT NULL DEFAULT 0,
+			ton_kho_min INT NOT NULL DEFAULT 0,
+			shortfall DECIMAL(18,3) NOT NULL DEFAULT 0,
+			status VARCHAR(20) NOT NULL,
+			snoozed_until DATETIME NULL,
+			actioned_by_user_id BIGINT NULL,
+			actioned_at DATETIME NULL,
+			raised_at DATETIME NOT NULL,
+			last_evaluated_at DATETIME NOT NULL,
+			resolved_at DATETIME NULL,
+			open_supply_idx1 INT AS (IF(status = 'resolved', NULL, supply_idx1)) STORED,
+			PRIMARY KEY (id),
+			UNIQUE KEY uk_stock_alerts_open_supply (open_supply_idx1),
+			KEY idx_stock_alerts_supply (supply_idx1),
+			KEY idx_stock_alerts_status (status)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`); err != nil {
+		return fmt.Errorf("error ensuring stock alert schema: %w", err)
+	}
+
+	// Earlier tables kept one row per supply, which overwrote resolved alerts.
+	var legacyIndexCount int
+	if err := r.DB.QueryRow(`
+		SELECT COUNT(*)
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE()
+			AND table_name = 'stock_alerts'
+			AND index_name = 'uk_stock_alerts_supply'
+	`).Scan(&legacyIndexCount); err != nil {
+		return fmt.Errorf("error checking legacy stock alert index: %w", err)
+	}
+	if legacyIndexCount > 0 {
+		if _, err := r.DB.Exec(`
+			ALTER TABLE stock_alerts
+				ADD COLUMN open_supply_idx1 INT AS (IF(status = 'resolved', NULL, supply_idx1)) STORED,
+				DROP INDEX uk_stock_alerts_supply,
+				ADD UNIQUE KEY uk_stock_alerts_open_supply (open_supply_idx1),
+				ADD KEY idx_stock_alerts_supply (supply_idx1)
+		`); err != nil {
+			return fmt.Errorf("error migrating stock alert supply index: %w", err)
+		}
+	}
+	return nil
+}
+
+// EvaluateReorderPoint compares what a supply will have once pending and
+// placed orders arrive with its TonKhoMin. The bool reports whether the
+// supply is below its reorder point.
+func EvaluateReorderPoint(supply Supply, pendingQty, onOrderQty float64) (StockAlert, bool) {
+	alert := StockAlert{
+		SupplyIDX1:   supply.IDX1,
+		MaterialCode: supply.MaterialCode,
+		Name:         strings.TrimSpace(supply.Name.String),
+		Unit:         strings.TrimSpace(supply.Unit.String),
+		TonCuoiKy:    supply.TonCuoiKy,
+		PendingQty:   pendingQty,
+		OnOrderQty:   onOrderQty,
+		TonKhoMin:    int(supply.TonKhoMin.Int32),
+	}
+	alert.ProjectedStock = float64(alert.TonCuoiKy) + pendingQty + onOrderQty
+	if alert.TonKhoMin <= 0 || alert.ProjectedStock >= float64(alert.TonKhoMin) {
+		return alert, false
+	}
+	alert.Shortfall = float64(alert.TonKhoMin) - alert.ProjectedStock
+	return alert, true
+}
+
+// ListReorderSupplies returns supplies that have a TonKhoMin set.
+func (r *SupplyRepository) ListReorderSupplies() ([]Supply, error) {
+	rows, err := r.DB.Query(`
+		SELECT
+			` + supplySelectColumns + `
+		FROM supplies
+		WHERE TON_KHO_MIN > 0
+		ORDER BY IDX1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying reorder supplies: %w", err)
+	}
+	defer rows.Close()
+
+	supplies := []Supply{}
+	for rows.Next() {
+		s, err := scanSupply(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning supply: %w", err)
+		}
+		supplies = append(supplies, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reorder supplies: %w", err)
+	}
+	return supplies, nil
+}
+
+// ListPendingQuantitiesByMaterial totals pending order lines that have not
+// been rejected, keyed by upper-cased material code.
+func (r *OrderRepository) ListPendingQuantitiesByMaterial() (map[string]float64, error) {
+	rows, err := r.DB.Query(`
+		SELECT ma_quan_ly, ma_vtyt_cu, so_luong
+		FROM pending_orders
+		WHERE approval_status <> ?
+	`, PendingOrderStatusRejected)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pending order quantities: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]float64)
+	for rows.Next() {
+		var maQuanLy, maVtytCu string
+		var quantity int
+		if err := rows.Scan(&maQuanLy, &maVtytCu, &quantity); err != nil {
+			return nil, fmt.Errorf("error scanning pending order quantity: %w", err)
+		}
+		code := strings.ToUpper(PreferredMaterialCode(maQuanLy, maVtytCu))
+		if code == "" || quantity <= 0 {
+			continue
+		}
+		totals[code] += float64(quantity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending order quantities: %w", err)
+	}
+	return totals, nil
+}
+
+// SaveAlert raises the alert of a short supply, or refreshes the stock figures
+// of its unresolved alert when there is one. Status, snooze and actioned
+// columns of an existing alert are never touched here, so a user acting on
+// the alert during an evaluation is not overwritten. It sets alert.ID and
+// reports whether a new alert was raised.
+func (r *StockAlertRepository) SaveAlert(alert *StockAlert) (bool, error) {
+	result, err := r.DB.Exec(`
+		INSERT INTO stock_alerts (
+			supply_idx1, material_code, name, unit, ton_cuoi_ky, pending_qty, on_order_qty, projected_stock,
+			ton_kho_min, shortfall, status, raised_at, last_evaluated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			id = LAST_INSERT_ID(id),
+			material_code = VALUES(material_code),
+			name = VALUES(name),
+			unit = VALUES(unit),
+			ton_cuoi_ky = VALUES(ton_cuoi_ky),
+			pending_qty = VALUES(pending_qty),
+			on_order_qty = VALUES(on_order_qty),
+			projected_stock = VALUES(projected_stock),
+			ton_kho_min = VALUES(ton_kho_min),
+			shortfall = VALUES(shortfall),
+			last_evaluated_at = VALUES(last_evaluated_at)
+	`,
+		alert.SupplyIDX1,
+		alert.MaterialCode,
+		nullIfEmpty(alert.Name),
+		alert.Unit,
+		alert.TonCuoiKy,
+		alert.PendingQty,
+		alert.OnOrderQty,
+		alert.ProjectedStock,
+		alert.TonKhoMin,
+		alert.Shortfall,
+		alert.Status,
+		alert.RaisedAt,
+		alert.LastEvaluatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error saving stock alert: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("error reading stock alert id: %w", err)
+	}
+	alert.ID = id
+
+	// MySQL reports 1 affected row for an insert and 2 for an update.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error reading stock alert save result: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// ReopenSnoozedAlert reopens a snoozed alert whose snooze has run out. It
+// reports false when the alert was acknowledged, re-snoozed or resolved in
+// the meantime.
+func (r *StockAlertRepository) ReopenSnoozedAlert(id int64, now time.Time) (bool, error) {
+	result, err := r.DB.Exec(`
+		UPDATE stock_alerts
+		SET status = ?, snoozed_until = NULL
+		WHERE id = ? AND status = ? AND (snoozed_until IS NULL OR snoozed_until <= ?)
+	`, StockAlertStatusOpen, id, StockAlertStatusSnoozed, now)
+	if err != nil {
+		return false, fmt.Errorf("error reopening snoozed stock alert: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error reading reopened stock alert result: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// ResolveAlerts closes alerts whose supply is back above its reorder point.
+func (r *StockAlertRepository) ResolveAlerts(ids []int64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(ids)), ",")
+	args := []interface{}{StockAlertStatusResolved, now, now}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, StockAlertStatusResolved)
+	if _, err := r.DB.Exec(`
+		UPDATE stock_alerts
+		SET status = ?, snoozed_until = NULL, resolved_at = ?, last_evaluated_at = ?
+		WHERE id IN (`+placeholders+`) AND status <> ?
+	`, args...); err != nil {
+		return fmt.Errorf("error resolving stock alerts: %w", err)
+	}
+	return nil
+}
+
+// AcknowledgeAlert marks an alert as seen; it stays acknowledged until the
+// supply recovers.
+func (r *StockAlertRepository) AcknowledgeAlert(id, userID int64, now time.Time) error {
+	if _, err := r.DB.Exec(`
+		UPDATE stock_alerts
+		SET status = ?, snoozed_until = NULL, actioned_by_user_id = ?, actioned_at = ?
+		WHERE id = ? AND status <> ?
+	`, StockAlertStatusAcknowledged, userID, now, id, StockAlertStatusResolved); err != nil {
+		return fmt.Errorf("error acknowledging stock alert: %w", err)
+	}
+	return nil
+}
+
+// SnoozeAlert hides an alert until the given time; the next evaluation after
+// that reopens it if the supply is still short.
+func (r *StockAlertRepository) SnoozeAlert(id, userID int64, until, now time.Time) error {
+	if _, err := r.DB.Exec(`
+		UPDATE stock_alerts
+		SET status = ?, snoozed_until = ?, actioned_by_user_id = ?, actioned_at = ?
+		WHERE id = ? AND status <> ?
+	`, StockAlertStatusSnoozed, until, userID, now, id, StockAlertStatusResolved); err != nil {
+		return fmt.Errorf("error snoozing stock alert: %w", err)
+	}
+	return nil
+}
+
+func (r *StockAlertRepository) GetAlert(id int64) (*StockAlert, error) {
+	row := r.DB.QueryRow(`
+		SELECT `+stockAlertColumns+`
+		FROM stock_alerts
+		WHERE id = ?
+	`, id)
+	alert, err := scanStockAlert(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return alert, err
+}
+
+// ListAlerts returns alerts by largest shortfall first.
+func (r *StockAlertRepository) ListAlerts(filter StockAlertFilter) ([]StockAlert, error) {
+	query := `
+		SELECT ` + stockAlertColumns + `
+		FROM stock_alerts
+		WHERE 1 = 1
+	`
+	args := make([]interface{}, 0)
+	if len(filter.Statuses) > 0 {
+		query += " AND status IN (" + strings.TrimRight(strings.Repeat("?,", len(filter.Statuses)), ",") + ")"
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	visibilityClause, visibilityArgs := buildSupplyVisibilityFilterClause("supply_idx1", filter.VisibleIDX1)
+	query += visibilityClause
+	args = append(args, visibilityArgs...)
+	query += " ORDER BY shortfall DESC, supply_idx1 ASC, raised_at DESC"
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing stock alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := make([]StockAlert, 0)
+	for rows.Next() {
+		alert, err := scanStockAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+const stockAlertColumns = `id, supply_idx1, material_code, COALESCE(name, ''), unit, ton_cuoi_ky, pending_qty, on_order_qty,
+			projected_stock, ton_kho_min, shortfall, status, snoozed_until, actioned_by_user_id, actioned_at,
+			raised_at, last_evaluated_at, resolved_at`
+
+type stockAlertScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanStockAlert(scanner stockAlertScanner) (*StockAlert, error) {
+	var alert StockAlert
+	var snoozedUntil, actionedAt, resolvedAt sql.NullTime
+	var actionedByUserID sql.NullInt64
+	if err := scanner.Scan(
+		&alert.ID,
+		&alert.SupplyIDX1,
+		&alert.MaterialCode,
+		&alert.Name,
+		&alert.Unit,
+		&alert.TonCuoiKy,
+		&alert.PendingQty,
+		&alert.OnOrderQty,
+		&alert.ProjectedStock,
+		&alert.TonKhoMin,
+		&alert.Shortfall,
+		&alert.Status,
+		&snoozedUntil,
+		&actionedByUserID,
+		&actionedAt,
+		&alert.RaisedAt,
+		&alert.LastEvaluatedAt,
+		&resolvedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error scanning stock alert: %w", err)
+	}
+	if snoozedUntil.Valid {
+		value := snoozedUntil.Time
+		alert.SnoozedUntil = &value
+	}
+	if actionedByUserID.Valid {
+		value := actionedByUserID.Int64
+		alert.ActionedByUserID = &value
+	}
+	if actionedAt.Valid {
+		value := actionedAt.Time
+		alert.ActionedAt = &value
+	}
+	if resolvedAt.Valid {
+		value := resolvedAt.Time
+		alert.ResolvedAt = &value
+	}
+	return &alert, nil
+}
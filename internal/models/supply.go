@@ -868,6 +868,46 @@ func (r *SupplyRepository) ReplaceAll(inputs []SupplyUpsertInput) error {
 	return nil
 }
 
+// ListSyncedSupplies returns the supplies table in the shape ReplaceAll
+// writes, so a sync can compare it with the rows it is about to store.
+func (r *SupplyRepository) ListSyncedSupplies() ([]SupplyUpsertInput, error) {
+	rows, err := r.DB.Query(`
+		SELECT
+			IDX1, COALESCE(PRODUCTID, 0), COALESCE(GROUPNAME, ''), COALESCE(ID, ''), COALESCE(IDX2, ''),
+			COALESCE(MA_HIEU, ''), COALESCE(TYPENAME, ''), COALESCE(NAME, ''), COALESCE(UNIT, ''),
+			COALESCE(QUY_CACH_DONG_GOI, ''), COALESCE(QUY_CACH_GIAO_HANG, ''), COALESCE(QUY_CACH_TOI_THIEU, ''),
+			COALESCE(THONG_TIN_THAU, ''), COALESCE(TONGTHAU, ''), COALESCE(HANGSX, ''), COALESCE(NUOC_SX, ''),
+			COALESCE(NHA_CUNG_CAP, ''), COALESCE(PRICE, 0), COALESCE(TONDAUKY, 0), COALESCE(NHAPTRONGKY, 0),
+			COALESCE(XUATTRONGKY, 0), COALESCE(TONGNHAP, 0), COALESCE(TON_KHO_MIN, 0)
+		FROM supplies
+		ORDER BY IDX1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing synced supplies: %w", err)
+	}
+	defer rows.Close()
+
+	supplies := make([]SupplyUpsertInput, 0)
+	for rows.Next() {
+		var input SupplyUpsertInput
+		if err := rows.Scan(
+			&input.IDX1, &input.ProductID, &input.GroupName, &input.ID, &input.IDX2,
+			&input.MaHieu, &input.TypeName, &input.Name, &input.Unit,
+			&input.QuyCachDongGoi, &input.QuyCachGiaoHang, &input.QuyCachToiThieu,
+			&input.ThongTinThau, &input.TongThau, &input.HangSX, &input.NuocSX,
+			&input.NhaCungCap, &input.Price, &input.TonDauKy, &input.NhapTrongKy,
+			&input.XuatTrongKy, &input.TongNhap, &input.TonKhoMin,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning synced supply: %w", err)
+		}
+		supplies = append(supplies, input)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating synced supplies: %w", err)
+	}
+	return supplies, nil
+}
+
 // SupplyMapping represents a row in the mapping2 table
 type SupplyMapping struct {
 	IDQuyetdinh     string
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const supplyStockSnapshotBatchSize = 500
+
+// SupplyStockSnapshot is the stock of one supply as the internal sync saw it
+// on one day. TonCuoiKy is derived the same way as on Supply.
+type SupplyStockSnapshot struct {
+	SnapshotDate string    `json:"snapshotDate"`
+	IDX1         int       `json:"idx1"`
+	SupplyCode   string    `json:"supplyCode,omitempty"`
+	Name         string    `json:"name,omitempty"`
+	TonDauKy     int       `json:"tonDauKy"`
+	NhapTrongKy  int       `json:"nhapTrongKy"`
+	XuatTrongKy  int       `json:"xuatTrongKy"`
+	TonCuoiKy    int       `json:"tonCuoiKy"`
+	CapturedAt   time.Time `json:"capturedAt"`
+}
+
+// SupplyConsumption summarises outflow across a run of snapshots. Each day's
+// consumption is the growth of XuatTrongKy since the previous snapshot; when
+// XuatTrongKy falls a new period has started and its value counts in full.
+type SupplyConsumption struct {
+	FromDate       string  `json:"fromDate,omitempty"`
+	ToDate         string  `json:"toDate,omitempty"`
+	Days           int     `json:"days"`
+	TotalConsumed  int     `json:"totalConsumed"`
+	DailyAverage   float64 `json:"dailyAverage"`
+	LatestStock    int     `json:"latestStock"`
+	DaysOfStock    *int    `json:"daysOfStock,omitempty"`
+	LargestDrop    int     `json:"largestDrop"`
+	LargestDropOn  string  `json:"largestDropOn,omitempty"`
+	SnapshotsCount int     `json:"snapshotsCount"`
+}
+
+type SupplyStockSnapshotRepository struct {
+	DB *sql.DB
+}
+
+func NewSupplyStockSnapshotRepository(db *sql.DB) *SupplyStockSnapshotRepository {
+	return &SupplyStockSnapshotRepository{DB: db}
+}
+
+func (r *SupplyStockSnapshotRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS supply_stock_snapshots (
+			snapshot_date DATE NOT NULL,
+			idx1 INT NOT NULL,
+			supply_code VARCHAR(255) NULL,
+			name TEXT NULL,
+			ton_dau_ky INT NOT NULL DEFAULT 0,
+			nhap_trong_ky INT NOT NULL DEFAULT 0,
+			xuat_trong_ky INT NOT NULL DEFAULT 0,
+			ton_cuoi_ky INT NOT NULL DEFAULT 0,
+			captured_at DATETIME NOT NULL,
+			PRIMARY KEY (snapshot_date, idx1),
+			KEY idx_supply_stock_snapshots_idx1 (idx1, snapshot_date)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`); err != nil {
+		return fmt.Errorf("error ensuring supply stock snapshot schema: %w", err)
+	}
+	return nil
+}
+
+// RecordSnapshots stores the stock of every synced supply for snapshotDate.
+// A later sync on the same day overwrites that day's rows.
+func (r *SupplyStockSnapshotRepository) RecordSnapshots(snapshotDate time.Time, inputs []SupplyUpsertInput, capturedAt time.Time) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting supply stock snapshot transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	date := snapshotDate.Format("2006-01-02")
+	for start := 0; start < len(inputs); start += supplyStockSnapshotBatchSize {
+		end := start + supplyStockSnapshotBatchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+
+		valueStrings := make([]string, 0, end-start)
+		valueArgs := make([]interface{}, 0, (end-start)*9)
+		for _, input := range inputs[start:end] {
+			valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+			valueArgs = append(valueArgs,
+				date,
+				input.IDX1,
+				nullIfEmpty(input.ID),
+				nullIfEmpty(input.Name),
+				input.TonDauKy,
+				input.NhapTrongKy,
+				input.XuatTrongKy,
+				supplyInputTonCuoiKy(input),
+				capturedAt,
+			)
+		}
+
+		if _, err = tx.Exec(`
+			INSERT INTO supply_stock_snapshots (
+				snapshot_date, idx1, supply_code, name, ton_dau_ky, nhap_trong_ky, xuat_trong_ky, ton_cuoi_ky, captured_at
+			) VALUES `+strings.Join(valueStrings, ",")+`
+			ON DUPLICATE KEY UPDATE
+				supply_code = VALUES(supply_code),
+				name = VALUES(name),
+				ton_dau_ky = VALUES(ton_dau_ky),
+				nhap_trong_ky = VALUES(nhap_trong_ky),
+				xuat_trong_ky = VALUES(xuat_trong_ky),
+				ton_cuoi_ky = VALUES(ton_cuoi_ky),
+				captured_at = VALUES(captured_at)
+		`, valueArgs...); err != nil {
+			return fmt.Errorf("error recording supply stock snapshots: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing supply stock snapshots: %w", err)
+	}
+	return nil
+}
+
+// ListSnapshots returns the snapshots of one supply between from and to
+// inclusive, oldest first.
+func (r *SupplyStockSnapshotRepository) ListSnapshots(idx1 int, from, to time.Time) ([]SupplyStockSnapshot, error) {
+	rows, err := r.DB.Query(`
+		SELECT DATE_FORMAT(snapshot_date, '%Y-%m-%d'), idx1, COALESCE(supply_code, ''), COALESCE(name, ''),
+			ton_dau_ky, nhap_trong_ky, xuat_trong_ky, ton_cuoi_ky, captured_at
+		FROM supply_stock_snapshots
+		WHERE idx1 = ? AND snapshot_date BETWEEN ? AND ?
+		ORDER BY snapshot_date ASC
+	`, idx1, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing supply stock snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := make([]SupplyStockSnapshot, 0)
+	for rows.Next() {
+		var snapshot SupplyStockSnapshot
+		if err := rows.Scan(
+			&snapshot.SnapshotDate,
+			&snapshot.IDX1,
+			&snapshot.SupplyCode,
+			&snapshot.Name,
+			&snapshot.TonDauKy,
+			&snapshot.NhapTrongKy,
+			&snapshot.XuatTrongKy,
+			&snapshot.TonCuoiKy,
+			&snapshot.CapturedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning supply stock snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating supply stock snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+// SummarizeSupplyConsumption derives the consumption rate from snapshots
+// ordered oldest first.
+func SummarizeSupplyConsumption(snapshots []SupplyStockSnapshot) SupplyConsumption {
+	summary := SupplyConsumption{SnapshotsCount: len(snapshots)}
+	if len(snapshots) == 0 {
+		return summary
+	}
+
+	first, last := snapshots[0], snapshots[len(snapshots)-1]
+	summary.FromDate = first.SnapshotDate
+	summary.ToDate = last.SnapshotDate
+	summary.LatestStock = last.TonCuoiKy
+
+	for index := 1; index < len(snapshots); index++ {
+		previous, current := snapshots[index-1], snapshots[index]
+		summary.TotalConsumed += snapshotConsumed(previous, current)
+
+		if drop := previous.TonCuoiKy - current.TonCuoiKy; drop > summary.LargestDrop {
+			summary.LargestDrop = drop
+			summary.LargestDropOn = current.SnapshotDate
+		}
+	}
+
+	fromDate, fromErr := time.Parse("2006-01-02", first.SnapshotDate)
+	toDate, toErr := time.Parse("2006-01-02", last.SnapshotDate)
+	if fromErr == nil && toErr == nil {
+		summary.Days = int(toDate.Sub(fromDate).Hours() / 24)
+	}
+	if summary.Days > 0 {
+		summary.DailyAverage = float64(summary.TotalConsumed) / float64(summary.Days)
+	}
+	if summary.DailyAverage > 0 && summary.LatestStock >= 0 {
+		days := int(float64(summary.LatestStock) / summary.DailyAverage)
+		summary.DaysOfStock = &days
+	}
+	return summary
+}
+
+// snapshotConsumed is the outflow between two consecutive snapshots.
+func snapshotConsumed(previous, current SupplyStockSnapshot) int {
+	consumed := current.XuatTrongKy - previous.XuatTrongKy
+	if consumed < 0 {
+		return current.XuatTrongKy
+	}
+	return consumed
+}
+
+func supplyInputTonCuoiKy(input SupplyUpsertInput) int {
+	return calculateTonCuoiKy(
+		sql.NullInt32{Int32: int32(input.TonDauKy), Valid: true},
+		sql.NullInt32{Int32: int32(input.NhapTrongKy), Valid: true},
+		sql.NullInt32{Int32: int32(input.XuatTrongKy), Valid: true},
+	)
+}
@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestSummarizeSupplyConsumptionHandlesPeriodReset(t *testing.T) {
+	t.Parallel()
+
+	snapshots := []SupplyStockSnapshot{
+		{SnapshotDate: "2026-09-28", TonDauKy: 100, XuatTrongKy: 10, TonCuoiKy: 90},
+		{SnapshotDate: "2026-09-29", TonDauKy: 100, XuatTrongKy: 16, TonCuoiKy: 84},
+		{SnapshotDate: "2026-09-30", TonDauKy: 100, NhapTrongKy: 20, XuatTrongKy: 20, TonCuoiKy: 100},
+		// A new period starts: the opening stock rolls over and outflow restarts.
+		{SnapshotDate: "2026-10-02", TonDauKy: 80, XuatTrongKy: 10, TonCuoiKy: 70},
+	}
+
+	summary := SummarizeSupplyConsumption(snapshots)
+
+	if summary.TotalConsumed != 6+4+10 || summary.Days != 4 {
+		t.Fatalf("summary = %+v, want 20 consumed over 4 days", summary)
+	}
+	if summary.DailyAverage != 5 || summary.LatestStock != 70 {
+		t.Fatalf("summary = %+v, want 5 per day and 70 in stock", summary)
+	}
+	if summary.DaysOfStock == nil || *summary.DaysOfStock != 14 {
+		t.Fatalf("days of stock = %v, want 14", summary.DaysOfStock)
+	}
+	if summary.LargestDrop != 30 || summary.LargestDropOn != "2026-10-02" {
+		t.Fatalf("largest drop = %d on %q", summary.LargestDrop, summary.LargestDropOn)
+	}
+}
+
+func TestSummarizeSupplyConsumptionWithoutHistory(t *testing.T) {
+	t.Parallel()
+
+	summary := SummarizeSupplyConsumption([]SupplyStockSnapshot{{SnapshotDate: "2026-10-01", TonCuoiKy: 5}})
+	if summary.Days != 0 || summary.DailyAverage != 0 || summary.DaysOfStock != nil || summary.LatestStock != 5 {
+		t.Fatalf("summary = %+v", summary)
+	}
+}
@@ -201,6 +201,39 @@ func (r *SupplyTaskRepository) GetAssignedSupplyDetailsByUserID(userID int64) ([
 	return items, nil
 }
 
+// ListAssignedTenderStaffBySupply returns the active Nhân viên thầu assigned
+// to each supply, keyed by IDX1.
+func (r *SupplyTaskRepository) ListAssignedTenderStaffBySupply() (map[int][]int64, error) {
+	rows, err := r.DB.Query(`
+		SELECT sua.supply_idx1, sua.user_id
+		FROM supply_user_assignments sua
+		INNER JOIN users u ON u.id = sua.user_id
+		WHERE u.is_active = 1
+		  AND LOWER(TRIM(u.role)) = 'nhan_vien_thau'
+		ORDER BY sua.supply_idx1, sua.user_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying supply tender staff: %w", err)
+	}
+	defer rows.Close()
+
+	assignees := make(map[int][]int64)
+	for rows.Next() {
+		var idx1 int
+		var userID int64
+		if err := rows.Scan(&idx1, &userID); err != nil {
+			return nil, fmt.Errorf("error scanning supply tender staff: %w", err)
+		}
+		assignees[idx1] = append(assignees[idx1], userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating supply tender staff: %w", err)
+	}
+
+	return assignees, nil
+}
+
 func (r *SupplyTaskRepository) ReplaceAssignmentsForUser(userID int64, supplyIDX1List []int, assignedByUserID int64) error {
 	oldIDs, err := r.GetAssignedSupplyIDX1ByUserID(userID)
 	if err != nil {
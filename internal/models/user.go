@@ -2,10 +2,16 @@ package models
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserDisabled = errors.New("user account is disabled")
+)
+
 type User struct {
 	ID           int64     `json:"id"`
 	Username     string    `json:"username"`
@@ -13,6 +19,7 @@ type User struct {
 	PasswordHash string    `json:"-"`
 	Role         string    `json:"role"`
 	IsActive     bool      `json:"isActive"`
+	TokenVersion int64     `json:"-"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
@@ -41,6 +48,21 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{DB: db}
 }
 
+// EnsureSchema adds users.token_version. Access and refresh tokens carry the
+// version they were issued under, so bumping it signs the user out everywhere.
+func (r *UserRepository) EnsureSchema() error {
+	exists, err := columnExists(r.DB, "users", "token_version")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if _, err := r.DB.Exec("ALTER TABLE users ADD COLUMN token_version BIGINT NOT NULL DEFAULT 0 AFTER is_active"); err != nil {
+			return fmt.Errorf("error ensuring users.token_version: %w", err)
+		}
+	}
+	return nil
+}
+
 func (r *UserRepository) CountUsers() (int64, error) {
 	query := `
 		SELECT COUNT(*)
@@ -76,7 +98,7 @@ func (r *UserRepository) Create(username, email, passwordHash, role string) (*Us
 
 func (r *UserRepository) GetByID(id int64) (*User, error) {
 	query := `
-		SELECT id, username, email, password_hash, role, is_active, created_at, updated_at
+		SELECT id, username, email, password_hash, role, is_active, token_version, created_at, updated_at
 		FROM users
 		WHERE id = ?
 	`
@@ -89,11 +111,12 @@ func (r *UserRepository) GetByID(id int64) (*User, error) {
 		&user.PasswordHash,
 		&user.Role,
 		&user.IsActive,
+		&user.TokenVersion,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error querying user: %w", err)
@@ -104,7 +127,7 @@ func (r *UserRepository) GetByID(id int64) (*User, error) {
 
 func (r *UserRepository) GetByEmail(email string) (*User, error) {
 	query := `
-		SELECT id, username, email, password_hash, role, is_active, created_at, updated_at
+		SELECT id, username, email, password_hash, role, is_active, token_version, created_at, updated_at
 		FROM users
 		WHERE email = ?
 	`
@@ -117,11 +140,12 @@ func (r *UserRepository) GetByEmail(email string) (*User, error) {
 		&user.PasswordHash,
 		&user.Role,
 		&user.IsActive,
+		&user.TokenVersion,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error querying user: %w", err)
@@ -148,7 +172,7 @@ func (r *UserRepository) UpdateProfile(userID int64, username, email string) (*U
 	}
 
 	if rowsAffected == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	return r.GetByID(userID)
@@ -218,7 +242,7 @@ func (r *UserRepository) ListActiveUsers() ([]UserProfile, error) {
 func (r *UserRepository) UpdateRole(userID int64, role string) (*User, error) {
 	query := `
 		UPDATE users
-		SET role = ?, updated_at = CURRENT_TIMESTAMP
+		SET role = ?, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ? AND is_active = 1
 	`
 
@@ -233,7 +257,7 @@ func (r *UserRepository) UpdateRole(userID int64, role string) (*User, error) {
 	}
 
 	if rowsAffected == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	return r.GetByID(userID)
@@ -242,7 +266,7 @@ func (r *UserRepository) UpdateRole(userID int64, role string) (*User, error) {
 func (r *UserRepository) UpdatePassword(userID int64, passwordHash string) (*User, error) {
 	query := `
 		UPDATE users
-		SET password_hash = ?, updated_at = CURRENT_TIMESTAMP
+		SET password_hash = ?, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
 
@@ -257,7 +281,7 @@ func (r *UserRepository) UpdatePassword(userID int64, passwordHash string) (*Use
 	}
 
 	if rowsAffected == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	return r.GetByID(userID)
@@ -266,7 +290,7 @@ func (r *UserRepository) UpdatePassword(userID int64, passwordHash string) (*Use
 func (r *UserRepository) DeactivateByID(userID int64) error {
 	query := `
 		UPDATE users
-		SET is_active = 0, updated_at = CURRENT_TIMESTAMP
+		SET is_active = 0, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ? AND is_active = 1
 	`
 
@@ -281,7 +305,30 @@ func (r *UserRepository) DeactivateByID(userID int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// BumpTokenVersion invalidates every access and refresh token of the user.
+func (r *UserRepository) BumpTokenVersion(userID int64) error {
+	result, err := r.DB.Exec(`
+		UPDATE users
+		SET token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("error bumping user token version: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
 	}
 
 	return nil
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -70,7 +71,7 @@ func (r *VinmesCatalogRepository) EnsureSchema() error {
 			return fmt.Errorf("error migrating Vinmes catalog external ID index: %w", err)
 		}
 	}
-	return nil
+	return r.ensureRefreshRunSchema()
 }
 
 func (r *VinmesCatalogRepository) ReplaceAll(items []VinmesCatalogItem, syncedAt time.Time) error {
@@ -172,3 +173,189 @@ func nullableCatalogString(value string) any {
 	}
 	return value
 }
+
+const (
+	VinmesCatalogRefreshTriggerManual    = "manual"
+	VinmesCatalogRefreshTriggerScheduled = "scheduled"
+	VinmesCatalogRefreshTriggerBootstrap = "bootstrap"
+
+	VinmesCatalogRefreshStatusSuccess = "success"
+	VinmesCatalogRefreshStatusFailed  = "failed"
+)
+
+type VinmesCatalogDiffItem struct {
+	ExternalID string   `json:"externalId"`
+	Code       string   `json:"code,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	Changes    []string `json:"changes,omitempty"`
+}
+
+type VinmesCatalogResourceDiff struct {
+	CatalogType   string                  `json:"catalogType"`
+	Resource      string                  `json:"resource"`
+	PreviousCount int                     `json:"previousCount"`
+	CurrentCount  int                     `json:"currentCount"`
+	Added         []VinmesCatalogDiffItem `json:"added"`
+	Removed       []VinmesCatalogDiffItem `json:"removed"`
+	Changed       []VinmesCatalogDiffItem `json:"changed"`
+}
+
+type VinmesCatalogRefreshRun struct {
+	ID           int64                       `json:"id"`
+	Trigger      string                      `json:"trigger"`
+	Status       string                      `json:"status"`
+	ErrorMessage string                      `json:"errorMessage,omitempty"`
+	Total        int                         `json:"total"`
+	AddedCount   int                         `json:"addedCount"`
+	RemovedCount int                         `json:"removedCount"`
+	ChangedCount int                         `json:"changedCount"`
+	Resources    []VinmesCatalogResourceDiff `json:"resources,omitempty"`
+	SyncedAt     time.Time                   `json:"syncedAt"`
+	CreatedAt    time.Time                   `json:"createdAt"`
+}
+
+func (r *VinmesCatalogRepository) ensureRefreshRunSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS vinmes_catalog_refresh_runs (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			trigger_source VARCHAR(20) NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			error_message TEXT NULL,
+			total_items INT NOT NULL DEFAULT 0,
+			added_count INT NOT NULL DEFAULT 0,
+			removed_count INT NOT NULL DEFAULT 0,
+			changed_count INT NOT NULL DEFAULT 0,
+			diff_payload LONGTEXT NULL,
+			synced_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY idx_vinmes_catalog_refresh_runs_synced (synced_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`); err != nil {
+		return fmt.Errorf("error ensuring Vinmes catalog refresh run schema: %w", err)
+	}
+	return nil
+}
+
+func (r *VinmesCatalogRepository) RecordRefreshRun(run *VinmesCatalogRefreshRun) error {
+	if run == nil {
+		return fmt.Errorf("Vinmes catalog refresh run is required")
+	}
+
+	var diffPayload any
+	if len(run.Resources) > 0 {
+		encoded, err := json.Marshal(run.Resources)
+		if err != nil {
+			return fmt.Errorf("error encoding Vinmes catalog diff: %w", err)
+		}
+		diffPayload = string(encoded)
+	}
+
+	result, err := r.DB.Exec(`
+		INSERT INTO vinmes_catalog_refresh_runs (
+			trigger_source, status, error_message, total_items,
+			added_count, removed_count, changed_count, diff_payload, synced_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		run.Trigger,
+		run.Status,
+		nullableCatalogString(run.ErrorMessage),
+		run.Total,
+		run.AddedCount,
+		run.RemovedCount,
+		run.ChangedCount,
+		diffPayload,
+		run.SyncedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording Vinmes catalog refresh run: %w", err)
+	}
+
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error reading Vinmes catalog refresh run id: %w", err)
+	}
+	run.ID = runID
+	return nil
+}
+
+func (r *VinmesCatalogRepository) ListRefreshRuns(limit int) ([]VinmesCatalogRefreshRun, error) {
+	if limit <= 0 {
+		limit = 30
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT id, trigger_source, status, COALESCE(error_message, ''), total_items,
+			added_count, removed_count, changed_count, synced_at, created_at
+		FROM vinmes_catalog_refresh_runs
+		ORDER BY synced_at DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing Vinmes catalog refresh runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]VinmesCatalogRefreshRun, 0)
+	for rows.Next() {
+		var run VinmesCatalogRefreshRun
+		if err := rows.Scan(
+			&run.ID,
+			&run.Trigger,
+			&run.Status,
+			&run.ErrorMessage,
+			&run.Total,
+			&run.AddedCount,
+			&run.RemovedCount,
+			&run.ChangedCount,
+			&run.SyncedAt,
+			&run.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning Vinmes catalog refresh run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating Vinmes catalog refresh runs: %w", err)
+	}
+	return runs, nil
+}
+
+func (r *VinmesCatalogRepository) GetRefreshRun(runID int64) (*VinmesCatalogRefreshRun, error) {
+	var run VinmesCatalogRefreshRun
+	var diffPayload sql.NullString
+	err := r.DB.QueryRow(`
+		SELECT id, trigger_source, status, COALESCE(error_message, ''), total_items,
+			added_count, removed_count, changed_count, diff_payload, synced_at, created_at
+		FROM vinmes_catalog_refresh_runs
+		WHERE id = ?
+	`, runID).Scan(
+		&run.ID,
+		&run.Trigger,
+		&run.Status,
+		&run.ErrorMessage,
+		&run.Total,
+		&run.AddedCount,
+		&run.RemovedCount,
+		&run.ChangedCount,
+		&diffPayload,
+		&run.SyncedAt,
+		&run.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting Vinmes catalog refresh run: %w", err)
+	}
+
+	if diffPayload.Valid && diffPayload.String != "" {
+		if err := json.Unmarshal([]byte(diffPayload.String), &run.Resources); err != nil {
+			return nil, fmt.Errorf("error decoding Vinmes catalog diff: %w", err)
+		}
+	}
+	return &run, nil
+}
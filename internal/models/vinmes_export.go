@@ -26,6 +26,9 @@ type VinmesExportFilter struct {
 	All          bool
 	MaterialCode string
 	Limit        int
+	// IncludeExported keeps rows that already carry a Vinmes PO or belong to a
+	// queued/sent ledger entry; by default they are left out.
+	IncludeExported bool
 }
 
 type VinmesExportSource struct {
@@ -154,6 +157,23 @@ func (r *InvoiceReconciliationRepository) ListVinmesExportSources(filter VinmesE
 		args = append(args, filter.Month, filter.Year)
 	}
 
+	if !filter.IncludeExported {
+		queryBuilder.WriteString(`
+		  AND r.vinmes_po_id IS NULL
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM vinmes_export_ledger_items li
+			INNER JOIN vinmes_export_ledger l ON l.id = li.ledger_id
+			WHERE li.reconciliation_id = r.id
+			  AND (
+				l.status = ?
+				OR (l.status = ? AND l.created_at > ?)
+			  )
+		  )
+		`)
+		args = append(args, VinmesExportStatusSent, VinmesExportStatusQueued, time.Now().Add(-VinmesExportQueuedTimeout))
+	}
+
 	materialCode := strings.ToLower(strings.TrimSpace(filter.MaterialCode))
 	if materialCode != "" {
 		queryBuilder.WriteString(`
@@ -239,6 +259,67 @@ func (r *InvoiceReconciliationRepository) ListVinmesExportSources(filter VinmesE
 	return items, nil
 }
 
+func (r *InvoiceReconciliationRepository) ListVinmesPurchaseOrderIDs(reconciliationIDs []int64) (map[int64]int64, error) {
+	result := make(map[int64]int64)
+	if len(reconciliationIDs) == 0 {
+		return result, nil
+	}
+
+	args := make([]interface{}, 0, len(reconciliationIDs))
+	for _, id := range reconciliationIDs {
+		args = append(args, id)
+	}
+
+	rows, err := r.DB.Query(fmt.Sprintf(`
+		SELECT id, vinmes_po_id
+		FROM order_invoice_reconciliation
+		WHERE vinmes_po_id IS NOT NULL
+		  AND id IN (%s)
+	`, makePlaceholders(len(args))), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing vinmes purchase order ids: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reconciliationID int64
+		var purchaseOrderID int64
+		if err := rows.Scan(&reconciliationID, &purchaseOrderID); err != nil {
+			return nil, fmt.Errorf("error scanning vinmes purchase order id: %w", err)
+		}
+		result[reconciliationID] = purchaseOrderID
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating vinmes purchase order ids: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *InvoiceReconciliationRepository) MarkVinmesSubmitted(reconciliationIDs []int64, purchaseOrderID int64, submittedAt time.Time) error {
+	if len(reconciliationIDs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(reconciliationIDs)+2)
+	args = append(args, purchaseOrderID, submittedAt)
+	for _, id := range reconciliationIDs {
+		args = append(args, id)
+	}
+
+	if _, err := r.DB.Exec(fmt.Sprintf(`
+		UPDATE order_invoice_reconciliation
+		SET vinmes_po_id = ?, vinmes_submitted_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE vinmes_po_id IS NULL
+		  AND id IN (%s)
+	`, makePlaceholders(len(reconciliationIDs))), args...); err != nil {
+		return fmt.Errorf("error marking reconciliations as submitted to vinmes: %w", err)
+	}
+
+	return nil
+}
+
 func BuildVinmesExportItem(source VinmesExportSource) VinmesExportItem {
 	invoiceDate := ""
 	if source.InvoiceDate != nil {
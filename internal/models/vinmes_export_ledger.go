@@ -0,0 +1,350 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	VinmesExportStatusQueued = "queued"
+	VinmesExportStatusSent   = "sent"
+	VinmesExportStatusFailed = "failed"
+)
+
+// VinmesExportQueuedTimeout bounds how long an entry may stay queued. An
+// entry still queued after that was abandoned mid-submit (for example by a
+// restart) and is treated like a failed one, so its rows can be exported
+// and the entry retried.
+const VinmesExportQueuedTimeout = 15 * time.Minute
+
+type VinmesExportLedgerEntry struct {
+	ID                  int64      `json:"id"`
+	BatchID             string     `json:"batchId"`
+	SoPhieu             string     `json:"soPhieu"`
+	SoHoaDon            string     `json:"soHoaDon"`
+	NhaCungCap          string     `json:"nhaCungCap"`
+	ReconciliationIDs   []int64    `json:"reconciliationIds"`
+	Status              string     `json:"status"`
+	ErrorMessage        string     `json:"errorMessage,omitempty"`
+	AttemptCount        int        `json:"attemptCount"`
+	PurchaseOrderID     *int64     `json:"purchaseOrderId,omitempty"`
+	SubmittedDetails    int        `json:"submittedDetails"`
+	Payload             string     `json:"payload,omitempty"`
+	RequestedByUserID   *int64     `json:"requestedByUserId,omitempty"`
+	RequestedByUsername string     `json:"requestedByUsername"`
+	LastAttemptAt       *time.Time `json:"lastAttemptAt,omitempty"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+// IsInFlight reports whether the entry is queued and still within
+// VinmesExportQueuedTimeout, i.e. a submit may still be working on it.
+func (e VinmesExportLedgerEntry) IsInFlight(now time.Time) bool {
+	return e.Status == VinmesExportStatusQueued && now.Sub(e.CreatedAt) < VinmesExportQueuedTimeout
+}
+
+type VinmesExportAttempt struct {
+	Status           string
+	ErrorMessage     string
+	PurchaseOrderID  *int64
+	SubmittedDetails int
+}
+
+type VinmesExportLedgerFilter struct {
+	Status   string
+	BatchID  string
+	Page     int
+	PageSize int
+}
+
+type VinmesExportLedgerRepository struct {
+	DB *sql.DB
+}
+
+func NewVinmesExportLedgerRepository(db *sql.DB) *VinmesExportLedgerRepository {
+	return &VinmesExportLedgerRepository{DB: db}
+}
+
+func (r *VinmesExportLedgerRepository) EnsureSchema() error {
+	statements := []string{
+		`
+		CREATE TABLE IF NOT EXISTS vinmes_export_ledger (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			batch_id VARCHAR(64) NOT NULL,
+			so_phieu VARCHAR(64) NOT NULL DEFAULT '',
+			so_hoa_don VARCHAR(128) NOT NULL DEFAULT '',
+			nha_cung_cap VARCHAR(255) NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL DEFAULT 'queued',
+			error_message TEXT NULL,
+			attempt_count INT NOT NULL DEFAULT 0,
+			vinmes_po_id BIGINT NULL,
+			submitted_details INT NOT NULL DEFAULT 0,
+			payload LONGTEXT NOT NULL,
+			requested_by_user_id BIGINT NULL,
+			requested_by_username VARCHAR(255) NOT NULL DEFAULT '',
+			last_attempt_at DATETIME NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY idx_vinmes_export_ledger_batch (batch_id),
+			KEY idx_vinmes_export_ledger_status (status),
+			KEY idx_vinmes_export_ledger_created (created_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS vinmes_export_ledger_items (
+			ledger_id BIGINT NOT NULL,
+			reconciliation_id BIGINT NOT NULL,
+			PRIMARY KEY (ledger_id, reconciliation_id),
+			KEY idx_vinmes_export_ledger_items_reconciliation (reconciliation_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+		`,
+	}
+
+	for _, statement := range statements {
+		if _, err := r.DB.Exec(statement); err != nil {
+			return fmt.Errorf("error ensuring vinmes export ledger schema: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *VinmesExportLedgerRepository) CreateVinmesExportEntry(entry *VinmesExportLedgerEntry) error {
+	if entry == nil {
+		return fmt.Errorf("vinmes export ledger entry is required")
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting vinmes export ledger transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO vinmes_export_ledger (
+			batch_id, so_phieu, so_hoa_don, nha_cung_cap, status, payload,
+			requested_by_user_id, requested_by_username
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		strings.TrimSpace(entry.BatchID),
+		strings.TrimSpace(entry.SoPhieu),
+		strings.TrimSpace(entry.SoHoaDon),
+		strings.TrimSpace(entry.NhaCungCap),
+		VinmesExportStatusQueued,
+		entry.Payload,
+		nullableInt64Value(entry.RequestedByUserID),
+		strings.TrimSpace(entry.RequestedByUsername),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating vinmes export ledger entry: %w", err)
+	}
+
+	entryID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error reading vinmes export ledger entry id: %w", err)
+	}
+
+	for _, reconciliationID := range entry.ReconciliationIDs {
+		if _, err := tx.Exec(`
+			INSERT IGNORE INTO vinmes_export_ledger_items (ledger_id, reconciliation_id)
+			VALUES (?, ?)
+		`, entryID, reconciliationID); err != nil {
+			return fmt.Errorf("error creating vinmes export ledger item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing vinmes export ledger transaction: %w", err)
+	}
+
+	entry.ID = entryID
+	entry.Status = VinmesExportStatusQueued
+	return nil
+}
+
+func (r *VinmesExportLedgerRepository) RecordVinmesExportAttempt(entryID int64, attempt VinmesExportAttempt) error {
+	status := strings.TrimSpace(attempt.Status)
+	if status != VinmesExportStatusSent && status != VinmesExportStatusFailed {
+		return fmt.Errorf("invalid vinmes export status %q", attempt.Status)
+	}
+
+	var errorMessage interface{}
+	if message := strings.TrimSpace(attempt.ErrorMessage); message != "" {
+		errorMessage = message
+	}
+
+	if _, err := r.DB.Exec(`
+		UPDATE vinmes_export_ledger
+		SET status = ?,
+			error_message = ?,
+			attempt_count = attempt_count + 1,
+			vinmes_po_id = COALESCE(?, vinmes_po_id),
+			submitted_details = ?,
+			last_attempt_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, status, errorMessage, nullableInt64Value(attempt.PurchaseOrderID), attempt.SubmittedDetails, entryID); err != nil {
+		return fmt.Errorf("error recording vinmes export attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r *VinmesExportLedgerRepository) GetVinmesExportEntry(entryID int64) (*VinmesExportLedgerEntry, error) {
+	entries, err := r.listEntries("WHERE l.id = ?", []interface{}{entryID}, 1, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+func (r *VinmesExportLedgerRepository) List(filter VinmesExportLedgerFilter) ([]VinmesExportLedgerEntry, int, error) {
+	conditions := make([]string, 0, 2)
+	args := make([]interface{}, 0, 2)
+	if status := strings.ToLower(strings.TrimSpace(filter.Status)); status != "" {
+		conditions = append(conditions, "l.status = ?")
+		args = append(args, status)
+	}
+	if batchID := strings.TrimSpace(filter.BatchID); batchID != "" {
+		conditions = append(conditions, "l.batch_id = ?")
+		args = append(args, batchID)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	page := filter.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := filter.PageSize
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	var total int
+	if err := r.DB.QueryRow("SELECT COUNT(*) FROM vinmes_export_ledger l "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("error counting vinmes export ledger entries: %w", err)
+	}
+
+	entries, err := r.listEntries(whereClause, args, pageSize, (page-1)*pageSize, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *VinmesExportLedgerRepository) listEntries(whereClause string, args []interface{}, limit, offset int, includePayload bool) ([]VinmesExportLedgerEntry, error) {
+	payloadColumn := "''"
+	if includePayload {
+		payloadColumn = "l.payload"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			l.id,
+			l.batch_id,
+			l.so_phieu,
+			l.so_hoa_don,
+			l.nha_cung_cap,
+			COALESCE(GROUP_CONCAT(li.reconciliation_id ORDER BY li.reconciliation_id SEPARATOR ','), ''),
+			l.status,
+			COALESCE(l.error_message, ''),
+			l.attempt_count,
+			l.vinmes_po_id,
+			l.submitted_details,
+			%s,
+			l.requested_by_user_id,
+			l.requested_by_username,
+			l.last_attempt_at,
+			l.created_at,
+			l.updated_at
+		FROM vinmes_export_ledger l
+		LEFT JOIN vinmes_export_ledger_items li ON li.ledger_id = l.id
+		%s
+		GROUP BY l.id
+		ORDER BY l.created_at DESC, l.id DESC
+		LIMIT ? OFFSET ?
+	`, payloadColumn, whereClause)
+
+	queryArgs := append(append([]interface{}{}, args...), limit, offset)
+	rows, err := r.DB.Query(query, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing vinmes export ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]VinmesExportLedgerEntry, 0)
+	for rows.Next() {
+		var entry VinmesExportLedgerEntry
+		var reconciliationIDs string
+		var purchaseOrderID sql.NullInt64
+		var requestedByUserID sql.NullInt64
+		var lastAttemptAt sql.NullTime
+
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.BatchID,
+			&entry.SoPhieu,
+			&entry.SoHoaDon,
+			&entry.NhaCungCap,
+			&reconciliationIDs,
+			&entry.Status,
+			&entry.ErrorMessage,
+			&entry.AttemptCount,
+			&purchaseOrderID,
+			&entry.SubmittedDetails,
+			&entry.Payload,
+			&requestedByUserID,
+			&entry.RequestedByUsername,
+			&lastAttemptAt,
+			&entry.CreatedAt,
+			&entry.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning vinmes export ledger entry: %w", err)
+		}
+
+		entry.ReconciliationIDs = parseInt64List(reconciliationIDs)
+		if purchaseOrderID.Valid {
+			value := purchaseOrderID.Int64
+			entry.PurchaseOrderID = &value
+		}
+		if requestedByUserID.Valid {
+			value := requestedByUserID.Int64
+			entry.RequestedByUserID = &value
+		}
+		if lastAttemptAt.Valid {
+			value := lastAttemptAt.Time
+			entry.LastAttemptAt = &value
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating vinmes export ledger entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func parseInt64List(value string) []int64 {
+	result := make([]int64, 0)
+	for _, part := range strings.Split(value, ",") {
+		parsed, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			continue
+		}
+		result = append(result, parsed)
+	}
+	return result
+}
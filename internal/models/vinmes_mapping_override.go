@@ -0,0 +1,214 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	VinmesOverrideTypePartner         = "partner"
+	VinmesOverrideTypeProduct         = "product"
+	VinmesOverrideTypeContractPackage = "contract_package"
+
+	VinmesOverrideMatchTaxCode         = "tax_code"
+	VinmesOverrideMatchSupplierName    = "supplier_name"
+	VinmesOverrideMatchMaterialCode    = "material_code"
+	VinmesOverrideMatchTenderReference = "tender_reference"
+)
+
+type VinmesMappingOverride struct {
+	ID                int64     `json:"id"`
+	OverrideType      string    `json:"overrideType"`
+	MatchField        string    `json:"matchField"`
+	SourceValue       string    `json:"sourceValue"`
+	SourceKey         string    `json:"sourceKey"`
+	VinmesID          string    `json:"vinmesId"`
+	Note              string    `json:"note,omitempty"`
+	UpdatedByUserID   *int64    `json:"updatedByUserId,omitempty"`
+	UpdatedByUsername string    `json:"updatedByUsername"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+type VinmesMappingOverrideRepository struct {
+	DB *sql.DB
+}
+
+func NewVinmesMappingOverrideRepository(db *sql.DB) *VinmesMappingOverrideRepository {
+	return &VinmesMappingOverrideRepository{DB: db}
+}
+
+func (r *VinmesMappingOverrideRepository) EnsureSchema() error {
+	if _, err := r.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS vinmes_mapping_overrides (
+			id BIGINT NOT NULL AUTO_INCREMENT,
+			override_type VARCHAR(32) NOT NULL,
+			match_field VARCHAR(32) NOT NULL,
+			source_value VARCHAR(500) NOT NULL,
+			source_key VARCHAR(255) NOT NULL,
+			vinmes_id VARCHAR(255) NOT NULL,
+			note VARCHAR(500) NOT NULL DEFAULT '',
+			updated_by_user_id BIGINT NULL,
+			updated_by_username VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_vinmes_mapping_override_source (override_type, match_field, source_key)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`); err != nil {
+		return fmt.Errorf("error ensuring Vinmes mapping override schema: %w", err)
+	}
+	return nil
+}
+
+func (r *VinmesMappingOverrideRepository) ListMappingOverrides() ([]VinmesMappingOverride, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, override_type, match_field, source_value, source_key, vinmes_id, note,
+			updated_by_user_id, updated_by_username, created_at, updated_at
+		FROM vinmes_mapping_overrides
+		ORDER BY override_type, match_field, source_value, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing Vinmes mapping overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make([]VinmesMappingOverride, 0)
+	for rows.Next() {
+		override, err := scanVinmesMappingOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, *override)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating Vinmes mapping overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+func (r *VinmesMappingOverrideRepository) GetMappingOverride(id int64) (*VinmesMappingOverride, error) {
+	row := r.DB.QueryRow(`
+		SELECT id, override_type, match_field, source_value, source_key, vinmes_id, note,
+			updated_by_user_id, updated_by_username, created_at, updated_at
+		FROM vinmes_mapping_overrides
+		WHERE id = ?
+	`, id)
+	override, err := scanVinmesMappingOverride(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return override, err
+}
+
+func (r *VinmesMappingOverrideRepository) CreateMappingOverride(override *VinmesMappingOverride) error {
+	result, err := r.DB.Exec(`
+		INSERT INTO vinmes_mapping_overrides (
+			override_type, match_field, source_value, source_key, vinmes_id, note,
+			updated_by_user_id, updated_by_username
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		override.OverrideType,
+		override.MatchField,
+		strings.TrimSpace(override.SourceValue),
+		override.SourceKey,
+		strings.TrimSpace(override.VinmesID),
+		strings.TrimSpace(override.Note),
+		nullableInt64Value(override.UpdatedByUserID),
+		strings.TrimSpace(override.UpdatedByUsername),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating Vinmes mapping override: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error reading Vinmes mapping override id: %w", err)
+	}
+	override.ID = id
+	return nil
+}
+
+func (r *VinmesMappingOverrideRepository) UpdateMappingOverride(override *VinmesMappingOverride) (bool, error) {
+	result, err := r.DB.Exec(`
+		UPDATE vinmes_mapping_overrides
+		SET override_type = ?, match_field = ?, source_value = ?, source_key = ?, vinmes_id = ?,
+			note = ?, updated_by_user_id = ?, updated_by_username = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`,
+		override.OverrideType,
+		override.MatchField,
+		strings.TrimSpace(override.SourceValue),
+		override.SourceKey,
+		strings.TrimSpace(override.VinmesID),
+		strings.TrimSpace(override.Note),
+		nullableInt64Value(override.UpdatedByUserID),
+		strings.TrimSpace(override.UpdatedByUsername),
+		override.ID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error updating Vinmes mapping override: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error reading updated Vinmes mapping override rows: %w", err)
+	}
+	if rowsAffected > 0 {
+		return true, nil
+	}
+
+	// MySQL reports zero affected rows when nothing changed, so confirm the row exists.
+	existing, err := r.GetMappingOverride(override.ID)
+	if err != nil {
+		return false, err
+	}
+	return existing != nil, nil
+}
+
+func (r *VinmesMappingOverrideRepository) DeleteMappingOverride(id int64) (bool, error) {
+	result, err := r.DB.Exec("DELETE FROM vinmes_mapping_overrides WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("error deleting Vinmes mapping override: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error reading deleted Vinmes mapping override rows: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+type vinmesMappingOverrideScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanVinmesMappingOverride(scanner vinmesMappingOverrideScanner) (*VinmesMappingOverride, error) {
+	var override VinmesMappingOverride
+	var updatedByUserID sql.NullInt64
+	if err := scanner.Scan(
+		&override.ID,
+		&override.OverrideType,
+		&override.MatchField,
+		&override.SourceValue,
+		&override.SourceKey,
+		&override.VinmesID,
+		&override.Note,
+		&updatedByUserID,
+		&override.UpdatedByUsername,
+		&override.CreatedAt,
+		&override.UpdatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error scanning Vinmes mapping override: %w", err)
+	}
+	if updatedByUserID.Valid {
+		value := updatedByUserID.Int64
+		override.UpdatedByUserID = &value
+	}
+	return &override, nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+const (
+	ForecastDeadlineReminderEventType = "forecast.deadline_reminder"
+
+	forecastDeadlineReminderCheckInterval = 15 * time.Minute
+)
+
+type ForecastDeadlineStore interface {
+	ListUnremindedDeadlines(from, to time.Time) ([]models.ForecastPeriod, error)
+	MarkReminderSent(month, year int, deadline string, now time.Time) error
+}
+
+// ForecastDeadlinePublisher is the part of realtime.Hub the reminder needs.
+type ForecastDeadlinePublisher interface {
+	Broadcast(eventType string, payload interface{})
+}
+
+type ForecastDeadlineReminderConfig struct {
+	Store     ForecastDeadlineStore
+	Publisher ForecastDeadlinePublisher
+	LeadHours int
+}
+
+type ForecastDeadlineReminderPayload struct {
+	ForecastMonth int       `json:"forecastMonth"`
+	ForecastYear  int       `json:"forecastYear"`
+	Deadline      string    `json:"deadline"`
+	DueAt         time.Time `json:"dueAt"`
+}
+
+// ForecastDeadlineReminder broadcasts forecast.deadline_reminder once per
+// deadline when a submission or approval deadline of an unlocked period is
+// less than LeadHours away.
+type ForecastDeadlineReminder struct {
+	store     ForecastDeadlineStore
+	publisher ForecastDeadlinePublisher
+	lead      time.Duration
+	now       func() time.Time
+}
+
+func NewForecastDeadlineReminder(cfg ForecastDeadlineReminderConfig) *ForecastDeadlineReminder {
+	return &ForecastDeadlineReminder{
+		store:     cfg.Store,
+		publisher: cfg.Publisher,
+		lead:      time.Duration(cfg.LeadHours) * time.Hour,
+		now:       time.Now,
+	}
+}
+
+func (r *ForecastDeadlineReminder) Start(ctx context.Context) {
+	if r == nil || r.lead <= 0 {
+		log.Println("[forecast-deadline] disabled by FORECAST_DEADLINE_REMINDER_HOURS")
+		return
+	}
+	if r.store == nil || r.publisher == nil {
+		log.Println("[forecast-deadline] skipped because store or publisher is not configured")
+		return
+	}
+
+	go r.run(ctx)
+}
+
+func (r *ForecastDeadlineReminder) run(ctx context.Context) {
+	ticker := time.NewTicker(forecastDeadlineReminderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := r.SendDue(ctx); err != nil {
+			log.Printf("[forecast-deadline] reminder check failed: %v", err)
+		} else if sent > 0 {
+			log.Printf("[forecast-deadline] %d deadline reminders sent", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue broadcasts a reminder for every deadline that falls within the lead
+// window and records it so the same deadline is not announced twice. Moving a
+// deadline clears its reminder, so it is announced again for the new time.
+func (r *ForecastDeadlineReminder) SendDue(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	now := r.now()
+	until := now.Add(r.lead)
+	periods, err := r.store.ListUnremindedDeadlines(now, until)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, period := range periods {
+		due := []struct {
+			kind     string
+			deadline *time.Time
+			sentAt   *time.Time
+		}{
+			{models.ForecastDeadlineSubmission, period.SubmissionDeadline, period.SubmissionReminderSentAt},
+			{models.ForecastDeadlineApproval, period.ApprovalDeadline, period.ApprovalReminderSentAt},
+		}
+		for _, item := range due {
+			if item.deadline == nil || item.sentAt != nil || item.deadline.Before(now) || item.deadline.After(until) {
+				continue
+			}
+			if err := r.store.MarkReminderSent(period.ForecastMonth, period.ForecastYear, item.kind, now); err != nil {
+				return sent, err
+			}
+			r.publisher.Broadcast(ForecastDeadlineReminderEventType, ForecastDeadlineReminderPayload{
+				ForecastMonth: period.ForecastMonth,
+				ForecastYear:  period.ForecastYear,
+				Deadline:      item.kind,
+				DueAt:         *item.deadline,
+			})
+			sent++
+		}
+	}
+	return sent, nil
+}
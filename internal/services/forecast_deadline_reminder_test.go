@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+type fakeForecastDeadlineStore struct {
+	periods []models.ForecastPeriod
+	marked  []string
+}
+
+func (f *fakeForecastDeadlineStore) ListUnremindedDeadlines(from, to time.Time) ([]models.ForecastPeriod, error) {
+	return f.periods, nil
+}
+
+func (f *fakeForecastDeadlineStore) MarkReminderSent(month, year int, deadline string, now time.Time) error {
+	f.marked = append(f.marked, deadline)
+	for i := range f.periods {
+		if f.periods[i].ForecastMonth != month || f.periods[i].ForecastYear != year {
+			continue
+		}
+		sentAt := now
+		if deadline == models.ForecastDeadlineApproval {
+			f.periods[i].ApprovalReminderSentAt = &sentAt
+		} else {
+			f.periods[i].SubmissionReminderSentAt = &sentAt
+		}
+	}
+	return nil
+}
+
+type fakeForecastDeadlinePublisher struct {
+	payloads []ForecastDeadlineReminderPayload
+}
+
+func (f *fakeForecastDeadlinePublisher) Broadcast(eventType string, payload interface{}) {
+	if eventType != ForecastDeadlineReminderEventType {
+		return
+	}
+	f.payloads = append(f.payloads, payload.(ForecastDeadlineReminderPayload))
+}
+
+func TestForecastDeadlineReminderSendsEachDeadlineOnce(t *testing.T) {
+	now := time.Date(2026, 5, 20, 8, 0, 0, 0, time.UTC)
+	submission := now.Add(6 * time.Hour)
+	approval := now.Add(72 * time.Hour)
+	store := &fakeForecastDeadlineStore{periods: []models.ForecastPeriod{{
+		ForecastMonth:      6,
+		ForecastYear:       2026,
+		SubmissionDeadline: &submission,
+		ApprovalDeadline:   &approval,
+	}}}
+	publisher := &fakeForecastDeadlinePublisher{}
+
+	reminder := NewForecastDeadlineReminder(ForecastDeadlineReminderConfig{Store: store, Publisher: publisher, LeadHours: 24})
+	reminder.now = func() time.Time { return now }
+
+	sent, err := reminder.SendDue(context.Background())
+	if err != nil {
+		t.Fatalf("SendDue returned error: %v", err)
+	}
+	if sent != 1 || len(publisher.payloads) != 1 {
+		t.Fatalf("expected only the submission reminder, got %d sent and %+v", sent, publisher.payloads)
+	}
+	payload := publisher.payloads[0]
+	if payload.Deadline != models.ForecastDeadlineSubmission || payload.ForecastMonth != 6 || !payload.DueAt.Equal(submission) {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+
+	sent, err = reminder.SendDue(context.Background())
+	if err != nil {
+		t.Fatalf("second SendDue returned error: %v", err)
+	}
+	if sent != 0 || len(store.marked) != 1 {
+		t.Fatalf("expected the reminder not to repeat, got %d sent and marked %v", sent, store.marked)
+	}
+}
+
+func TestForecastDeadlineReminderDisabledWithoutLead(t *testing.T) {
+	reminder := NewForecastDeadlineReminder(ForecastDeadlineReminderConfig{LeadHours: 0})
+	// Start must return without launching a loop against the nil store.
+	reminder.Start(context.Background())
+}
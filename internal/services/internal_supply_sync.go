@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -16,21 +17,39 @@ import (
 	"bv108-consumables-management-backend/internal/models"
 )
 
+// ErrInternalSupplySyncUnsafe marks a sync stopped by the removal threshold.
+var ErrInternalSupplySyncUnsafe = errors.New("internal supply sync aborted")
+
 type internalSupplySyncRepository interface {
 	ReplaceAll(inputs []models.SupplyUpsertInput) error
+	ListSyncedSupplies() ([]models.SupplyUpsertInput, error)
 	GetSupplyMappings(tableName string) (map[string]models.SupplyMapping, error)
 }
 
 type companyContactSyncRepository interface {
 	ReplaceAll(contacts []models.CompanyContact) error
+	ListAll() ([]models.CompanyContact, error)
+}
+
+type supplyStockSnapshotStore interface {
+	RecordSnapshots(snapshotDate time.Time, inputs []models.SupplyUpsertInput, capturedAt time.Time) error
+}
+
+type InternalSupplySyncRunLog interface {
+	RecordRun(run *models.InternalSupplySyncRun) error
+	ListRuns(limit int) ([]models.InternalSupplySyncRun, error)
+	GetRun(runID int64) (*models.InternalSupplySyncRun, error)
 }
 
 type InternalSupplySyncService struct {
 	config      *config.Config
 	repo        internalSupplySyncRepository
 	contactRepo companyContactSyncRepository
+	runLog      InternalSupplySyncRunLog
+	snapshots   supplyStockSnapshotStore
 	httpClient  *http.Client
 	location    *time.Location
+	now         func() time.Time
 	mu          sync.Mutex
 }
 
@@ -109,6 +128,8 @@ func NewInternalSupplySyncService(
 	cfg *config.Config,
 	repo internalSupplySyncRepository,
 	contactRepo companyContactSyncRepository,
+	runLog InternalSupplySyncRunLog,
+	snapshots supplyStockSnapshotStore,
 ) *InternalSupplySyncService {
 	location, err := time.LoadLocation(strings.TrimSpace(cfg.InternalSupplySyncTimezone))
 	if err != nil {
@@ -125,10 +146,13 @@ func NewInternalSupplySyncService(
 		config:      cfg,
 		repo:        repo,
 		contactRepo: contactRepo,
+		runLog:      runLog,
+		snapshots:   snapshots,
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
 		location: location,
+		now:      time.Now,
 	}
 }
 
@@ -145,18 +169,75 @@ func (s *InternalSupplySyncService) Start(ctx context.Context) {
 	go s.runScheduler(ctx)
 }
 
-func (s *InternalSupplySyncService) RunOnce(ctx context.Context) (int, error) {
+// InternalSupplySyncOptions describes one sync run. A dry run fetches and
+// compares but writes nothing except its run log entry.
+type InternalSupplySyncOptions struct {
+	Trigger           string
+	DryRun            bool
+	RequestedByUserID *int64
+}
+
+type InternalSupplySyncResult struct {
+	RunID    *int64                        `json:"runId,omitempty"`
+	DryRun   bool                          `json:"dryRun"`
+	Count    int                           `json:"count"`
+	Supplies models.InternalSupplySyncDiff `json:"supplies"`
+	Contacts models.InternalSupplySyncDiff `json:"contacts"`
+	// AbortReason is set when the removal threshold blocks the sync. A dry run
+	// reports it instead of failing.
+	AbortReason string `json:"abortReason,omitempty"`
+	Warning     string `json:"warning,omitempty"`
+}
+
+func (s *InternalSupplySyncService) ListRuns(limit int) ([]models.InternalSupplySyncRun, error) {
+	if s == nil || s.runLog == nil {
+		return nil, fmt.Errorf("internal supply sync run log is not configured")
+	}
+	return s.runLog.ListRuns(limit)
+}
+
+func (s *InternalSupplySyncService) GetRun(runID int64) (*models.InternalSupplySyncRun, error) {
+	if s == nil || s.runLog == nil {
+		return nil, fmt.Errorf("internal supply sync run log is not configured")
+	}
+	return s.runLog.GetRun(runID)
+}
+
+func (s *InternalSupplySyncService) Sync(ctx context.Context, opts InternalSupplySyncOptions) (*InternalSupplySyncResult, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// 1. Sync supplies from API
+	if opts.Trigger == "" {
+		opts.Trigger = models.InternalSupplySyncTriggerManual
+	}
+	run := &models.InternalSupplySyncRun{
+		Trigger:           opts.Trigger,
+		DryRun:            opts.DryRun,
+		RequestedByUserID: opts.RequestedByUserID,
+		StartedAt:         s.now().UTC(),
+	}
+
+	result, err := s.sync(ctx, opts, run)
+	if err != nil && run.Status == "" {
+		run.Status = models.InternalSupplySyncStatusFailed
+		run.ErrorMessage = err.Error()
+	}
+	s.recordRun(run)
+	if result != nil && run.ID > 0 {
+		result.RunID = int64Pointer(run.ID)
+	}
+	return result, err
+}
+
+func (s *InternalSupplySyncService) sync(ctx context.Context, opts InternalSupplySyncOptions, run *models.InternalSupplySyncRun) (*InternalSupplySyncResult, error) {
+	// 1. Fetch supplies from API
 	rows, err := s.fetchRows(ctx, "/api_trangbi_thongtinvattu?method=select")
 	if err != nil {
-		return 0, fmt.Errorf("error syncing supplies: %w", err)
+		return nil, fmt.Errorf("error syncing supplies: %w", err)
 	}
 
 	if len(rows) == 0 {
-		return 0, fmt.Errorf("internal supply API returned no product rows (ensure INTERNAL_SUPPLY_API_BODY is set correctly)")
+		return nil, fmt.Errorf("internal supply API returned no product rows (ensure INTERNAL_SUPPLY_API_BODY is set correctly)")
 	}
 
 	// Load mapping table data
@@ -165,7 +246,143 @@ func (s *InternalSupplySyncService) RunOnce(ctx context.Context) (int, error) {
 	if err != nil {
 		log.Printf("[internal-supply-sync] warning: failed to fetch supply mappings: %v", err)
 	}
+	inputs := buildInternalSupplyInputs(rows, mappings)
+
+	// 2. Fetch company contacts from partner select API
+	var contacts []models.CompanyContact
+	result := &InternalSupplySyncResult{DryRun: opts.DryRun, Count: len(inputs)}
+	if s.contactRepo != nil {
+		partners, err := s.fetchPartners(ctx)
+		if err != nil {
+			result.Warning = fmt.Sprintf("failed to fetch partners: %v", err)
+		} else if len(partners) > 0 {
+			contacts = companyContactsFromPartners(partners)
+			if len(contacts) == 0 {
+				result.Warning = "partner API returned no usable company contacts"
+			}
+		}
+	}
+
+	// 3. Compare with what is stored
+	previousSupplies, err := s.repo.ListSyncedSupplies()
+	if err != nil {
+		return nil, err
+	}
+	result.Supplies = diffInternalSupplies(previousSupplies, inputs)
+	result.Contacts = newInternalSupplySyncDiff(0, 0)
+	if len(contacts) > 0 {
+		previousContacts, err := s.contactRepo.ListAll()
+		if err != nil {
+			return nil, err
+		}
+		result.Contacts = diffCompanyContacts(previousContacts, contacts)
+	}
+	fillInternalSupplySyncRunCounts(run, result)
+
+	if maxPercent := s.config.InternalSupplySyncMaxRemovalPct; maxPercent > 0 {
+		if percent := internalSupplyRemovalPercent(result.Supplies); percent > float64(maxPercent) {
+			result.AbortReason = fmt.Sprintf(
+				"sync would remove %.1f%% of %d stored supplies, above the %d%% limit (INTERNAL_SUPPLY_SYNC_MAX_REMOVAL_PERCENT)",
+				percent, result.Supplies.PreviousCount, maxPercent,
+			)
+		}
+		// A truncated partner list must not wipe company_contacts either. The
+		// supplies can still be written, so only the contact write is skipped.
+		if percent := internalSupplyRemovalPercent(result.Contacts); len(contacts) > 0 && percent > float64(maxPercent) {
+			contacts = nil
+			result.Warning = joinInternalSupplySyncWarnings(result.Warning, fmt.Sprintf(
+				"company contacts were not updated: sync would remove %.1f%% of %d stored contacts, above the %d%% limit (INTERNAL_SUPPLY_SYNC_MAX_REMOVAL_PERCENT)",
+				percent, result.Contacts.PreviousCount, maxPercent,
+			))
+		}
+	}
+
+	if opts.DryRun {
+		run.Status = models.InternalSupplySyncStatusSuccess
+		run.WarningMessage = joinInternalSupplySyncWarnings(result.AbortReason, result.Warning)
+		return result, nil
+	}
+	if result.AbortReason != "" {
+		run.Status = models.InternalSupplySyncStatusAborted
+		run.ErrorMessage = result.AbortReason
+		run.WarningMessage = result.Warning
+		return result, fmt.Errorf("%w: %s", ErrInternalSupplySyncUnsafe, result.AbortReason)
+	}
 
+	// 4. Write
+	if err := s.repo.ReplaceAll(inputs); err != nil {
+		return nil, fmt.Errorf("error updating supplies database: %w", err)
+	}
+	log.Printf(
+		"[internal-supply-sync] synced %d supply rows successfully (%d added, %d removed, %d changed)",
+		len(inputs), len(result.Supplies.Added), len(result.Supplies.Removed), len(result.Supplies.Changed),
+	)
+
+	s.recordStockSnapshots(inputs)
+
+	if len(contacts) > 0 {
+		if err := s.contactRepo.ReplaceAll(contacts); err != nil {
+			result.Warning = joinInternalSupplySyncWarnings(result.Warning, fmt.Sprintf("failed to update company contacts: %v", err))
+		} else {
+			log.Printf("[internal-supply-sync] synced %d company contacts successfully", len(contacts))
+		}
+	}
+	if result.Warning != "" {
+		log.Printf("[internal-supply-sync] warning: %s", result.Warning)
+	}
+
+	run.Status = models.InternalSupplySyncStatusSuccess
+	run.WarningMessage = result.Warning
+	return result, nil
+}
+
+func joinInternalSupplySyncWarnings(messages ...string) string {
+	parts := make([]string, 0, len(messages))
+	for _, message := range messages {
+		if message = strings.TrimSpace(message); message != "" {
+			parts = append(parts, message)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func (s *InternalSupplySyncService) recordRun(run *models.InternalSupplySyncRun) {
+	run.FinishedAt = s.now().UTC()
+	if s.runLog == nil {
+		return
+	}
+	if err := s.runLog.RecordRun(run); err != nil {
+		log.Printf("[internal-supply-sync] warning: failed to record sync run: %v", err)
+	}
+}
+
+// recordStockSnapshots keeps the day's stock per supply, since ReplaceAll
+// overwrites the period totals. A failure here does not fail the sync.
+func (s *InternalSupplySyncService) recordStockSnapshots(inputs []models.SupplyUpsertInput) {
+	if s.snapshots == nil {
+		return
+	}
+	capturedAt := s.now()
+	if err := s.snapshots.RecordSnapshots(capturedAt.In(s.location), inputs, capturedAt.UTC()); err != nil {
+		log.Printf("[internal-supply-sync] warning: failed to record stock snapshots: %v", err)
+	}
+}
+
+func fillInternalSupplySyncRunCounts(run *models.InternalSupplySyncRun, result *InternalSupplySyncResult) {
+	supplies, contacts := result.Supplies, result.Contacts
+	run.SupplyCount = supplies.CurrentCount
+	run.SuppliesAdded = len(supplies.Added)
+	run.SuppliesRemoved = len(supplies.Removed)
+	run.SuppliesChanged = len(supplies.Changed)
+	run.ContactCount = contacts.CurrentCount
+	run.ContactsAdded = len(contacts.Added)
+	run.ContactsRemoved = len(contacts.Removed)
+	run.ContactsChanged = len(contacts.Changed)
+	run.Supplies = &supplies
+	run.Contacts = &contacts
+}
+
+func buildInternalSupplyInputs(rows []internalSupplyAPIRow, mappings map[string]models.SupplyMapping) []models.SupplyUpsertInput {
 	inputs := make([]models.SupplyUpsertInput, 0, len(rows))
 	for index, row := range rows {
 		input := mapInternalSupplyRow(row, index)
@@ -205,49 +422,32 @@ func (s *InternalSupplySyncService) RunOnce(ctx context.Context) (int, error) {
 
 		inputs = append(inputs, input)
 	}
+	return inputs
+}
 
-	if err := s.repo.ReplaceAll(inputs); err != nil {
-		return 0, fmt.Errorf("error updating supplies database: %w", err)
-	}
-
-	log.Printf("[internal-supply-sync] synced %d supply rows successfully", len(inputs))
-
-	// 2. Sync company contacts from partner select API
-	partners, err := s.fetchPartners(ctx)
-	if err != nil {
-		log.Printf("[internal-supply-sync] warning: failed to fetch partners: %v", err)
-	} else if len(partners) > 0 && s.contactRepo != nil {
-		contacts := make([]models.CompanyContact, 0, len(partners))
-		defaultEmail := models.ResolveDefaultCompanyContactEmail()
-		for _, p := range partners {
-			identity := firstNonEmpty(p.TaxCode, p.ID, p.Code)
-			name := strings.TrimSpace(p.Name)
-			if identity == "" || name == "" {
-				continue
-			}
-			contacts = append(contacts, models.CompanyContact{
-				MaSoThue:     identity,
-				TenCongTy:    name,
-				DiaChiCongTy: strings.TrimSpace(p.Address),
-				SoTKNganHang: strings.TrimSpace(p.BankAccount),
-				Gmail:        firstNonEmpty(p.ContactEmail, p.Email, defaultEmail),
-			})
-		}
-		if len(contacts) == 0 {
-			log.Println("[internal-supply-sync] warning: partner API returned no usable company contacts")
-		} else if err := s.contactRepo.ReplaceAll(contacts); err != nil {
-			log.Printf("[internal-supply-sync] warning: failed to update company contacts: %v", err)
-		} else {
-			log.Printf("[internal-supply-sync] synced %d company contacts successfully", len(contacts))
+func companyContactsFromPartners(partners []hospitalPartnerRow) []models.CompanyContact {
+	contacts := make([]models.CompanyContact, 0, len(partners))
+	defaultEmail := models.ResolveDefaultCompanyContactEmail()
+	for _, p := range partners {
+		identity := firstNonEmpty(p.TaxCode, p.ID, p.Code)
+		name := strings.TrimSpace(p.Name)
+		if identity == "" || name == "" {
+			continue
 		}
-	}
-
-	return len(inputs), nil
+		contacts = append(contacts, models.CompanyContact{
+			MaSoThue:     identity,
+			TenCongTy:    name,
+			DiaChiCongTy: strings.TrimSpace(p.Address),
+			SoTKNganHang: strings.TrimSpace(p.BankAccount),
+			Gmail:        firstNonEmpty(p.ContactEmail, p.Email, defaultEmail),
+		})
+	}
+	return contacts
 }
 
 func (s *InternalSupplySyncService) runScheduler(ctx context.Context) {
 	if s.config.InternalSupplySyncRunOnStartup {
-		if _, err := s.RunOnce(ctx); err != nil {
+		if _, err := s.Sync(ctx, InternalSupplySyncOptions{Trigger: models.InternalSupplySyncTriggerStartup}); err != nil {
 			log.Printf("[internal-supply-sync] startup sync failed: %v", err)
 		}
 	}
@@ -261,7 +461,7 @@ func (s *InternalSupplySyncService) runScheduler(ctx context.Context) {
 			timer.Stop()
 			return
 		case <-timer.C:
-			if _, err := s.RunOnce(ctx); err != nil {
+			if _, err := s.Sync(ctx, InternalSupplySyncOptions{Trigger: models.InternalSupplySyncTriggerScheduled}); err != nil {
 				log.Printf("[internal-supply-sync] scheduled sync failed: %v", err)
 			}
 		}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+// diffInternalSupplies compares stored supplies with the rows a sync would
+// write. Rows are keyed by supply code and tender decision, the same pair the
+// mapping table uses; a key may repeat, so it counts as changed when its rows
+// differ in number or content. Stock counters move every day and are left out.
+func diffInternalSupplies(previous, current []models.SupplyUpsertInput) models.InternalSupplySyncDiff {
+	before := groupInternalSupplies(previous)
+	after := groupInternalSupplies(current)
+	diff := newInternalSupplySyncDiff(len(previous), len(current))
+
+	for _, key := range sortedInternalSupplyKeys(before) {
+		if _, ok := after[key]; !ok {
+			diff.Removed = append(diff.Removed, internalSupplyDiffItem(key, before[key][0], nil))
+		}
+	}
+	for _, key := range sortedInternalSupplyKeys(after) {
+		oldRows, ok := before[key]
+		if !ok {
+			diff.Added = append(diff.Added, internalSupplyDiffItem(key, after[key][0], nil))
+			continue
+		}
+		if changes := compareInternalSupplyRows(oldRows, after[key]); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, internalSupplyDiffItem(key, after[key][0], changes))
+		}
+	}
+	return diff
+}
+
+// diffCompanyContacts compares stored contacts with the partner list by tax
+// ID. The contact sync only upserts, so Removed lists contacts the partner API
+// no longer returns; they stay in the table.
+func diffCompanyContacts(previous, current []models.CompanyContact) models.InternalSupplySyncDiff {
+	before := make(map[string]models.CompanyContact, len(previous))
+	for _, contact := range previous {
+		before[strings.TrimSpace(contact.MaSoThue)] = contact
+	}
+	after := make(map[string]models.CompanyContact, len(current))
+	for _, contact := range current {
+		after[strings.TrimSpace(contact.MaSoThue)] = contact
+	}
+	diff := newInternalSupplySyncDiff(len(previous), len(current))
+
+	for _, key := range sortedCompanyContactKeys(before) {
+		if _, ok := after[key]; !ok {
+			diff.Removed = append(diff.Removed, models.InternalSupplySyncDiffItem{Key: key, Name: before[key].TenCongTy})
+		}
+	}
+	for _, key := range sortedCompanyContactKeys(after) {
+		oldContact, ok := before[key]
+		if !ok {
+			diff.Added = append(diff.Added, models.InternalSupplySyncDiffItem{Key: key, Name: after[key].TenCongTy})
+			continue
+		}
+		if strings.TrimSpace(oldContact.TenCongTy) != strings.TrimSpace(after[key].TenCongTy) {
+			diff.Changed = append(diff.Changed, models.InternalSupplySyncDiffItem{
+				Key:     key,
+				Name:    after[key].TenCongTy,
+				Changes: []string{"tenCongTy"},
+			})
+		}
+	}
+	return diff
+}
+
+func newInternalSupplySyncDiff(previousCount, currentCount int) models.InternalSupplySyncDiff {
+	return models.InternalSupplySyncDiff{
+		PreviousCount: previousCount,
+		CurrentCount:  currentCount,
+		Added:         make([]models.InternalSupplySyncDiffItem, 0),
+		Removed:       make([]models.InternalSupplySyncDiffItem, 0),
+		Changed:       make([]models.InternalSupplySyncDiffItem, 0),
+	}
+}
+
+func internalSupplyDiffKey(input models.SupplyUpsertInput) string {
+	identifier := firstNonEmpty(input.ID, input.TypeName)
+	if identifier == "" && input.ProductID != 0 {
+		identifier = "product:" + strconv.Itoa(input.ProductID)
+	}
+	if identifier == "" {
+		identifier = "idx1:" + strconv.Itoa(input.IDX1)
+	}
+	return cleanMappingKey(identifier + "_" + strings.TrimSpace(input.ThongTinThau))
+}
+
+func groupInternalSupplies(inputs []models.SupplyUpsertInput) map[string][]models.SupplyUpsertInput {
+	grouped := make(map[string][]models.SupplyUpsertInput)
+	for _, input := range inputs {
+		key := internalSupplyDiffKey(input)
+		grouped[key] = append(grouped[key], input)
+	}
+	for key := range grouped {
+		rows := grouped[key]
+		sort.SliceStable(rows, func(i, j int) bool {
+			if rows[i].Name != rows[j].Name {
+				return rows[i].Name < rows[j].Name
+			}
+			return rows[i].IDX1 < rows[j].IDX1
+		})
+	}
+	return grouped
+}
+
+func sortedInternalSupplyKeys(rows map[string][]models.SupplyUpsertInput) []string {
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedCompanyContactKeys(contacts map[string]models.CompanyContact) []string {
+	keys := make([]string, 0, len(contacts))
+	for key := range contacts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func internalSupplyDiffItem(key string, input models.SupplyUpsertInput, changes []string) models.InternalSupplySyncDiffItem {
+	return models.InternalSupplySyncDiffItem{
+		Key:     key,
+		Code:    input.ID,
+		Name:    input.Name,
+		Changes: changes,
+	}
+}
+
+func compareInternalSupplyRows(before, after []models.SupplyUpsertInput) []string {
+	if len(before) != len(after) {
+		return []string{"count"}
+	}
+
+	changed := make(map[string]struct{})
+	for index := range before {
+		oldRow, newRow := before[index], after[index]
+		fields := []struct {
+			name     string
+			old, new string
+		}{
+			{name: "productId", old: strconv.Itoa(oldRow.ProductID), new: strconv.Itoa(newRow.ProductID)},
+			{name: "groupName", old: oldRow.GroupName, new: newRow.GroupName},
+			{name: "idx2", old: oldRow.IDX2, new: newRow.IDX2},
+			{name: "maHieu", old: oldRow.MaHieu, new: newRow.MaHieu},
+			{name: "typeName", old: oldRow.TypeName, new: newRow.TypeName},
+			{name: "name", old: oldRow.Name, new: newRow.Name},
+			{name: "unit", old: oldRow.Unit, new: newRow.Unit},
+			{name: "quyCachDongGoi", old: oldRow.QuyCachDongGoi, new: newRow.QuyCachDongGoi},
+			{name: "quyCachGiaoHang", old: oldRow.QuyCachGiaoHang, new: newRow.QuyCachGiaoHang},
+			{name: "quyCachToiThieu", old: oldRow.QuyCachToiThieu, new: newRow.QuyCachToiThieu},
+			{name: "tongThau", old: oldRow.TongThau, new: newRow.TongThau},
+			{name: "hangSx", old: oldRow.HangSX, new: newRow.HangSX},
+			{name: "nuocSx", old: oldRow.NuocSX, new: newRow.NuocSX},
+			{name: "nhaCungCap", old: oldRow.NhaCungCap, new: newRow.NhaCungCap},
+			{name: "price", old: strconv.FormatFloat(oldRow.Price, 'f', 4, 64), new: strconv.FormatFloat(newRow.Price, 'f', 4, 64)},
+			{name: "tonKhoMin", old: strconv.Itoa(oldRow.TonKhoMin), new: strconv.Itoa(newRow.TonKhoMin)},
+		}
+		for _, field := range fields {
+			if strings.TrimSpace(field.old) != strings.TrimSpace(field.new) {
+				changed[field.name] = struct{}{}
+			}
+		}
+	}
+
+	changes := make([]string, 0, len(changed))
+	for field := range changed {
+		changes = append(changes, field)
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// internalSupplyRemovalPercent is the share of stored rows (supplies or
+// company contacts) a sync would delete: the larger of the net row drop and
+// the number of keys that vanish.
+func internalSupplyRemovalPercent(diff models.InternalSupplySyncDiff) float64 {
+	if diff.PreviousCount == 0 {
+		return 0
+	}
+	removedRows := diff.PreviousCount - diff.CurrentCount
+	if removedRows < len(diff.Removed) {
+		removedRows = len(diff.Removed)
+	}
+	if removedRows <= 0 {
+		return 0
+	}
+	return float64(removedRows) * 100 / float64(diff.PreviousCount)
+}
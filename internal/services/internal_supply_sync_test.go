@@ -3,9 +3,12 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"bv108-consumables-management-backend/config"
 	"bv108-consumables-management-backend/internal/models"
@@ -69,14 +72,14 @@ func TestInternalSupplySyncUsesOnlyRemoteRowsAndMapsPartnerSchema(t *testing.T)
 		InternalSupplyAPITimeoutSeconds: 5,
 		InternalSupplySyncTimezone:      "UTC",
 		SupplyMappingTable:              "mapping2",
-	}, supplyRepo, contactRepo)
+	}, supplyRepo, contactRepo, nil, nil)
 
-	count, err := service.RunOnce(context.Background())
+	result, err := service.Sync(context.Background(), InternalSupplySyncOptions{})
 	if err != nil {
-		t.Fatalf("RunOnce() error = %v", err)
+		t.Fatalf("Sync() error = %v", err)
 	}
-	if count != 1 || len(supplyRepo.inputs) != 1 {
-		t.Fatalf("synced count = %d, rows = %d; want only the one remote row", count, len(supplyRepo.inputs))
+	if result.Count != 1 || len(supplyRepo.inputs) != 1 {
+		t.Fatalf("synced count = %d, rows = %d; want only the one remote row", result.Count, len(supplyRepo.inputs))
 	}
 	if supplyRepo.inputs[0].ID != "REMOTE001" {
 		t.Fatalf("supply ID = %q", supplyRepo.inputs[0].ID)
@@ -113,24 +116,242 @@ func TestInternalSupplyMappingPrefersTypeNameAndFallsBackToID(t *testing.T) {
 	}
 }
 
+func TestInternalSupplySyncDryRunReportsDiffWithoutWriting(t *testing.T) {
+	t.Parallel()
+
+	server := newInternalSupplyTestServer(t, []map[string]any{
+		{"ma_vtyt": "VT-001", "quyet_dinh": "QD-01", "ten_vtyt_bv": "Bơm tiêm", "don_gia": 2000},
+		{"ma_vtyt": "VT-003", "quyet_dinh": "QD-01", "ten_vtyt_bv": "Kim luồn"},
+	})
+	defer server.Close()
+
+	supplyRepo := &captureInternalSupplyRepository{stored: []models.SupplyUpsertInput{
+		{IDX1: 1, ID: "VT-001", ThongTinThau: "QD-01", Name: "Bơm tiêm", Price: 1500},
+		{IDX1: 2, ID: "VT-002", ThongTinThau: "QD-01", Name: "Găng tay"},
+	}}
+	runLog := &captureInternalSupplySyncRunLog{}
+	service := newInternalSupplyTestService(server.URL, 0, supplyRepo, runLog)
+
+	result, err := service.Sync(context.Background(), InternalSupplySyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if supplyRepo.replaced {
+		t.Fatal("dry run must not replace supplies")
+	}
+	if len(result.Supplies.Added) != 1 || result.Supplies.Added[0].Code != "VT-003" {
+		t.Fatalf("added = %+v, want VT-003", result.Supplies.Added)
+	}
+	if len(result.Supplies.Removed) != 1 || result.Supplies.Removed[0].Code != "VT-002" {
+		t.Fatalf("removed = %+v, want VT-002", result.Supplies.Removed)
+	}
+	if len(result.Supplies.Changed) != 1 || result.Supplies.Changed[0].Changes[0] != "price" {
+		t.Fatalf("changed = %+v, want VT-001 price", result.Supplies.Changed)
+	}
+	if len(runLog.runs) != 1 || !runLog.runs[0].DryRun || runLog.runs[0].Status != models.InternalSupplySyncStatusSuccess {
+		t.Fatalf("run log = %+v", runLog.runs)
+	}
+	if result.RunID == nil || *result.RunID != runLog.runs[0].ID {
+		t.Fatalf("result run id = %v", result.RunID)
+	}
+}
+
+func TestInternalSupplySyncAbortsWhenTooManyRowsDisappear(t *testing.T) {
+	t.Parallel()
+
+	server := newInternalSupplyTestServer(t, []map[string]any{
+		{"ma_vtyt": "VT-001", "quyet_dinh": "QD-01", "ten_vtyt_bv": "Bơm tiêm"},
+	})
+	defer server.Close()
+
+	stored := make([]models.SupplyUpsertInput, 0, 4)
+	for _, code := range []string{"VT-001", "VT-002", "VT-003", "VT-004"} {
+		stored = append(stored, models.SupplyUpsertInput{ID: code, ThongTinThau: "QD-01"})
+	}
+	supplyRepo := &captureInternalSupplyRepository{stored: stored}
+	runLog := &captureInternalSupplySyncRunLog{}
+	service := newInternalSupplyTestService(server.URL, 50, supplyRepo, runLog)
+
+	_, err := service.Sync(context.Background(), InternalSupplySyncOptions{Trigger: models.InternalSupplySyncTriggerScheduled})
+	if !errors.Is(err, ErrInternalSupplySyncUnsafe) {
+		t.Fatalf("Sync() error = %v, want ErrInternalSupplySyncUnsafe", err)
+	}
+	if supplyRepo.replaced {
+		t.Fatal("aborted sync must not replace supplies")
+	}
+	if len(runLog.runs) != 1 || runLog.runs[0].Status != models.InternalSupplySyncStatusAborted || runLog.runs[0].SuppliesRemoved != 3 {
+		t.Fatalf("run log = %+v", runLog.runs)
+	}
+
+	result, err := service.Sync(context.Background(), InternalSupplySyncOptions{DryRun: true})
+	if err != nil || result.AbortReason == "" {
+		t.Fatalf("dry run = %+v, %v; want the abort reason without an error", result, err)
+	}
+}
+
+func TestInternalSupplySyncKeepsContactsWhenTooManyDisappear(t *testing.T) {
+	t.Parallel()
+
+	server := newInternalSupplyTestServerWithPartners(t,
+		[]map[string]any{{"ma_vtyt": "VT-001", "quyet_dinh": "QD-01", "ten_vtyt_bv": "Bơm tiêm"}},
+		[]map[string]any{{"tax_code": "0101", "name": "Công ty A", "email": "a@ncc.vn"}},
+	)
+	defer server.Close()
+
+	contactRepo := &captureCompanyContactRepository{stored: []models.CompanyContact{
+		{MaSoThue: "0101", TenCongTy: "Công ty A"},
+		{MaSoThue: "0102", TenCongTy: "Công ty B"},
+		{MaSoThue: "0103", TenCongTy: "Công ty C"},
+		{MaSoThue: "0104", TenCongTy: "Công ty D"},
+	}}
+	supplyRepo := &captureInternalSupplyRepository{stored: []models.SupplyUpsertInput{{ID: "VT-001", ThongTinThau: "QD-01"}}}
+	service := newInternalSupplyTestService(server.URL, 50, supplyRepo, nil)
+	service.contactRepo = contactRepo
+
+	result, err := service.Sync(context.Background(), InternalSupplySyncOptions{})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if !supplyRepo.replaced {
+		t.Fatal("supplies within the limit must still be written")
+	}
+	if contactRepo.contacts != nil {
+		t.Fatalf("contacts were replaced with %+v, want them kept", contactRepo.contacts)
+	}
+	if len(result.Contacts.Removed) != 3 || !strings.Contains(result.Warning, "company contacts were not updated") {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestInternalSupplySyncRecordsStockSnapshots(t *testing.T) {
+	t.Parallel()
+
+	server := newInternalSupplyTestServer(t, []map[string]any{
+		{"IDX1": 7, "ma_vtyt": "VT-001", "quyet_dinh": "QD-01", "so_luong_ton_dau_ky": 50, "sl_nhap": 5, "sl_xuat": 12},
+	})
+	defer server.Close()
+
+	snapshots := &captureSupplyStockSnapshots{}
+	service := newInternalSupplyTestService(server.URL, 0, &captureInternalSupplyRepository{}, nil)
+	service.snapshots = snapshots
+	service.location = time.FixedZone("ICT", 7*60*60)
+	service.now = func() time.Time { return time.Date(2026, 10, 15, 20, 30, 0, 0, time.UTC) }
+
+	if _, err := service.Sync(context.Background(), InternalSupplySyncOptions{DryRun: true}); err != nil {
+		t.Fatalf("dry run error = %v", err)
+	}
+	if snapshots.calls != 0 {
+		t.Fatal("dry run must not record stock snapshots")
+	}
+
+	if _, err := service.Sync(context.Background(), InternalSupplySyncOptions{}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if snapshots.calls != 1 || snapshots.date != "2026-10-16" {
+		t.Fatalf("snapshots = %d calls on %q, want one on the sync-timezone day 2026-10-16", snapshots.calls, snapshots.date)
+	}
+	if len(snapshots.inputs) != 1 || snapshots.inputs[0].IDX1 != 7 || snapshots.inputs[0].XuatTrongKy != 12 {
+		t.Fatalf("snapshot inputs = %+v", snapshots.inputs)
+	}
+}
+
+func newInternalSupplyTestServer(t *testing.T, products []map[string]any) *httptest.Server {
+	t.Helper()
+	return newInternalSupplyTestServerWithPartners(t, products, []map[string]any{})
+}
+
+func newInternalSupplyTestServerWithPartners(t *testing.T, products []map[string]any, partners []map[string]any) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api_trangbi_thongtinvattu":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": products})
+		case "/partner_select_for_po":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": partners})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func newInternalSupplyTestService(baseURL string, maxRemovalPercent int, supplyRepo *captureInternalSupplyRepository, runLog InternalSupplySyncRunLog) *InternalSupplySyncService {
+	return NewInternalSupplySyncService(&config.Config{
+		InternalSupplyAPIURL:            baseURL,
+		InternalSupplyAPIBody:           "{}",
+		InternalSupplyAPITimeoutSeconds: 5,
+		InternalSupplySyncTimezone:      "UTC",
+		InternalSupplySyncMaxRemovalPct: maxRemovalPercent,
+		SupplyMappingTable:              "mapping2",
+	}, supplyRepo, &captureCompanyContactRepository{}, runLog, nil)
+}
+
 type captureInternalSupplyRepository struct {
-	inputs []models.SupplyUpsertInput
+	stored   []models.SupplyUpsertInput
+	inputs   []models.SupplyUpsertInput
+	replaced bool
 }
 
 func (r *captureInternalSupplyRepository) ReplaceAll(inputs []models.SupplyUpsertInput) error {
 	r.inputs = append([]models.SupplyUpsertInput(nil), inputs...)
+	r.replaced = true
 	return nil
 }
 
+func (r *captureInternalSupplyRepository) ListSyncedSupplies() ([]models.SupplyUpsertInput, error) {
+	return append([]models.SupplyUpsertInput(nil), r.stored...), nil
+}
+
 func (r *captureInternalSupplyRepository) GetSupplyMappings(string) (map[string]models.SupplyMapping, error) {
 	return nil, nil
 }
 
 type captureCompanyContactRepository struct {
+	stored   []models.CompanyContact
 	contacts []models.CompanyContact
 }
 
+func (r *captureCompanyContactRepository) ListAll() ([]models.CompanyContact, error) {
+	return append([]models.CompanyContact(nil), r.stored...), nil
+}
+
 func (r *captureCompanyContactRepository) ReplaceAll(contacts []models.CompanyContact) error {
 	r.contacts = append([]models.CompanyContact(nil), contacts...)
 	return nil
 }
+
+type captureInternalSupplySyncRunLog struct {
+	runs []models.InternalSupplySyncRun
+}
+
+func (l *captureInternalSupplySyncRunLog) RecordRun(run *models.InternalSupplySyncRun) error {
+	run.ID = int64(len(l.runs) + 1)
+	l.runs = append(l.runs, *run)
+	return nil
+}
+
+func (l *captureInternalSupplySyncRunLog) ListRuns(int) ([]models.InternalSupplySyncRun, error) {
+	return l.runs, nil
+}
+
+func (l *captureInternalSupplySyncRunLog) GetRun(runID int64) (*models.InternalSupplySyncRun, error) {
+	for index := range l.runs {
+		if l.runs[index].ID == runID {
+			return &l.runs[index], nil
+		}
+	}
+	return nil, nil
+}
+
+type captureSupplyStockSnapshots struct {
+	calls  int
+	date   string
+	inputs []models.SupplyUpsertInput
+}
+
+func (s *captureSupplyStockSnapshots) RecordSnapshots(snapshotDate time.Time, inputs []models.SupplyUpsertInput, _ time.Time) error {
+	s.calls++
+	s.date = snapshotDate.Format("2006-01-02")
+	s.inputs = append([]models.SupplyUpsertInput(nil), inputs...)
+	return nil
+}
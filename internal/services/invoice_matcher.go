@@ -0,0 +1,314 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+const (
+	defaultInvoiceMatchMinScore     = 60
+	defaultInvoiceMatchLookbackDays = 90
+	defaultInvoiceMatchDueDays      = 14
+	invoiceMatchCandidatesPerRow    = 3
+
+	invoiceMatchSupplierTaxPoints  = 35
+	invoiceMatchSupplierNamePoints = 20
+	invoiceMatchMaterialCodePoints = 30
+	invoiceMatchMaterialNamePoints = 25
+	invoiceMatchQuantityPoints     = 20
+	invoiceMatchDatePoints         = 15
+)
+
+type InvoiceMatchStore interface {
+	ListUnmatchedInvoiceRows(since time.Time) ([]models.HoaDon, error)
+	ListOpenOrderLinesForMatching() ([]models.InvoiceMatchOrderLine, error)
+	ListRejectedInvoiceMatches() ([]models.InvoiceMatchPair, error)
+	InsertInvoiceMatchProposals(proposals []models.InvoiceMatchProposal) (int, error)
+}
+
+type InvoiceMatcherConfig struct {
+	Store        InvoiceMatchStore
+	MinScore     float64
+	LookbackDays int
+	DueDays      int
+}
+
+// InvoiceMatcher pairs unreconciled hoa_don rows with open order lines and
+// queues the best-scoring pairs for review. It never writes reconciliations
+// itself; a reviewer accepts or rejects each proposal.
+type InvoiceMatcher struct {
+	store        InvoiceMatchStore
+	minScore     float64
+	lookbackDays int
+	dueDays      int
+	now          func() time.Time
+	mu           sync.Mutex
+}
+
+type InvoiceMatchRunResult struct {
+	InvoiceRows int     `json:"invoiceRows"`
+	OrderLines  int     `json:"orderLines"`
+	Proposed    int     `json:"proposed"`
+	MinScore    float64 `json:"minScore"`
+}
+
+func NewInvoiceMatcher(cfg InvoiceMatcherConfig) *InvoiceMatcher {
+	minScore := cfg.MinScore
+	if minScore <= 0 {
+		minScore = defaultInvoiceMatchMinScore
+	}
+	lookbackDays := cfg.LookbackDays
+	if lookbackDays <= 0 {
+		lookbackDays = defaultInvoiceMatchLookbackDays
+	}
+	dueDays := cfg.DueDays
+	if dueDays <= 0 {
+		dueDays = defaultInvoiceMatchDueDays
+	}
+
+	return &InvoiceMatcher{
+		store:        cfg.Store,
+		minScore:     minScore,
+		lookbackDays: lookbackDays,
+		dueDays:      dueDays,
+		now:          time.Now,
+	}
+}
+
+// Run scores every unmatched invoice row from the lookback window against
+// the open order lines and stores up to three proposals per row.
+func (m *InvoiceMatcher) Run() (InvoiceMatchRunResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := InvoiceMatchRunResult{MinScore: m.minScore}
+	if m.store == nil {
+		return result, fmt.Errorf("invoice match store is not configured")
+	}
+
+	since := m.now().AddDate(0, 0, -m.lookbackDays)
+	invoices, err := m.store.ListUnmatchedInvoiceRows(since)
+	if err != nil {
+		return result, err
+	}
+	lines, err := m.store.ListOpenOrderLinesForMatching()
+	if err != nil {
+		return result, err
+	}
+	rejected, err := m.store.ListRejectedInvoiceMatches()
+	if err != nil {
+		return result, err
+	}
+	result.InvoiceRows = len(invoices)
+	result.OrderLines = len(lines)
+
+	proposals := m.propose(invoices, lines, rejected)
+	inserted, err := m.store.InsertInvoiceMatchProposals(proposals)
+	if err != nil {
+		return result, err
+	}
+	result.Proposed = inserted
+	return result, nil
+}
+
+func (m *InvoiceMatcher) propose(invoices []models.HoaDon, lines []models.InvoiceMatchOrderLine, rejected []models.InvoiceMatchPair) []models.InvoiceMatchProposal {
+	skip := make(map[models.InvoiceMatchPair]struct{}, len(rejected))
+	for _, pair := range rejected {
+		skip[pair] = struct{}{}
+	}
+
+	proposals := make([]models.InvoiceMatchProposal, 0)
+	for _, invoice := range invoices {
+		candidates := make([]models.InvoiceMatchProposal, 0)
+		for _, line := range lines {
+			pair := models.InvoiceMatchPair{HoaDonID: int64(invoice.ID), OrderHistoryID: line.ID}
+			if _, ok := skip[pair]; ok {
+				continue
+			}
+			proposal, ok := scoreInvoiceMatch(invoice, line, m.dueDays)
+			if !ok || proposal.Score < m.minScore {
+				continue
+			}
+			candidates = append(candidates, proposal)
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].Score != candidates[j].Score {
+				return candidates[i].Score > candidates[j].Score
+			}
+			return candidates[i].OrderHistoryID < candidates[j].OrderHistoryID
+		})
+		if len(candidates) > invoiceMatchCandidatesPerRow {
+			candidates = candidates[:invoiceMatchCandidatesPerRow]
+		}
+		proposals = append(proposals, candidates...)
+	}
+	return proposals
+}
+
+// scoreInvoiceMatch rates one invoice row against one order line out of 100
+// points. A pair from different suppliers is never a candidate.
+func scoreInvoiceMatch(invoice models.HoaDon, line models.InvoiceMatchOrderLine, dueDays int) (models.InvoiceMatchProposal, bool) {
+	supplier, ok := scoreInvoiceMatchSupplier(invoice, line)
+	if !ok {
+		return models.InvoiceMatchProposal{}, false
+	}
+	material := scoreInvoiceMatchMaterial(invoice, line)
+	quantity, quantityDiff := scoreInvoiceMatchQuantity(invoice, line)
+	date := scoreInvoiceMatchDate(invoice, line, dueDays)
+
+	factors := []models.InvoiceMatchFactor{supplier, material, quantity, date}
+	score := 0.0
+	for _, factor := range factors {
+		score += factor.Points
+	}
+
+	return models.InvoiceMatchProposal{
+		HoaDonID:       int64(invoice.ID),
+		OrderHistoryID: line.ID,
+		Score:          math.Round(score*100) / 100,
+		QuantityDiff:   quantityDiff,
+		Factors:        factors,
+	}, true
+}
+
+func scoreInvoiceMatchSupplier(invoice models.HoaDon, line models.InvoiceMatchOrderLine) (models.InvoiceMatchFactor, bool) {
+	factor := models.InvoiceMatchFactor{Factor: "supplier", MaxPoints: invoiceMatchSupplierTaxPoints}
+
+	lineTaxCode := ""
+	if line.CompanyContactID != nil {
+		lineTaxCode = normalizeCode(*line.CompanyContactID)
+	}
+	invoiceTaxCodes := []string{normalizeCode(invoice.MaSoThueNguoiBan)}
+	if invoice.CompanyContactID != nil {
+		invoiceTaxCodes = append(invoiceTaxCodes, normalizeCode(*invoice.CompanyContactID))
+	}
+	for _, taxCode := range invoiceTaxCodes {
+		if lineTaxCode != "" && taxCode == lineTaxCode {
+			factor.Points = invoiceMatchSupplierTaxPoints
+			factor.Detail = fmt.Sprintf("Tax ID %s matches the order supplier", taxCode)
+			return factor, true
+		}
+	}
+
+	invoiceName := normalizeLookup(invoice.CongTy)
+	lineName := normalizeLookup(line.NhaThau)
+	if invoiceName != "" && lineName != "" && (strings.Contains(invoiceName, lineName) || strings.Contains(lineName, invoiceName)) {
+		factor.Points = invoiceMatchSupplierNamePoints
+		factor.Detail = fmt.Sprintf("Supplier name %q matches %q; tax ID not linked", invoice.CongTy, line.NhaThau)
+		return factor, true
+	}
+	return factor, false
+}
+
+func scoreInvoiceMatchMaterial(invoice models.HoaDon, line models.InvoiceMatchOrderLine) models.InvoiceMatchFactor {
+	factor := models.InvoiceMatchFactor{Factor: "material", MaxPoints: invoiceMatchMaterialCodePoints}
+
+	if code := normalizeCode(invoice.MaHangHoa); code != "" {
+		for _, candidate := range []string{line.MaQuanLy, line.MaVtytCu, line.MaHieu} {
+			if normalizeCode(candidate) == code {
+				factor.Points = invoiceMatchMaterialCodePoints
+				factor.Detail = fmt.Sprintf("Item code %s matches the order line", invoice.MaHangHoa)
+				return factor
+			}
+		}
+	}
+
+	similarity := invoiceMatchNameSimilarity(invoice.TenHangHoa, line.TenVtytBv)
+	factor.Points = math.Round(similarity*invoiceMatchMaterialNamePoints*100) / 100
+	factor.Detail = fmt.Sprintf("No item code match; name similarity %.0f%%", similarity*100)
+	return factor
+}
+
+func scoreInvoiceMatchQuantity(invoice models.HoaDon, line models.InvoiceMatchOrderLine) (models.InvoiceMatchFactor, float64) {
+	factor := models.InvoiceMatchFactor{Factor: "quantity", MaxPoints: invoiceMatchQuantityPoints}
+
+	expected := float64(line.OrderedQty) - line.DeliveredQty
+	if expected <= 0 {
+		expected = float64(line.OrderedQty)
+	}
+	quantityDiff := invoice.SoLuong - expected
+	if expected <= 0 || invoice.SoLuong <= 0 {
+		factor.Detail = "Quantity missing on the invoice or order line"
+		return factor, quantityDiff
+	}
+
+	ratio := math.Min(invoice.SoLuong, expected) / math.Max(invoice.SoLuong, expected)
+	factor.Points = math.Round(ratio*invoiceMatchQuantityPoints*100) / 100
+	factor.Detail = fmt.Sprintf("Invoiced %s against %s outstanding", formatInvoiceMatchQuantity(invoice.SoLuong), formatInvoiceMatchQuantity(expected))
+	return factor, quantityDiff
+}
+
+// scoreInvoiceMatchDate gives full points to invoices dated inside the
+// delivery window and decays linearly over a second window after it. An
+// invoice issued before the order was placed scores nothing.
+func scoreInvoiceMatchDate(invoice models.HoaDon, line models.InvoiceMatchOrderLine, dueDays int) models.InvoiceMatchFactor {
+	factor := models.InvoiceMatchFactor{Factor: "date", MaxPoints: invoiceMatchDatePoints}
+
+	orderTime, err := time.Parse(time.RFC3339, strings.TrimSpace(line.NgayDatHang))
+	if err != nil || invoice.NgayHoaDon.IsZero() {
+		factor.Detail = "Order or invoice date missing"
+		return factor
+	}
+
+	orderDay := truncateInvoiceMatchDay(orderTime)
+	invoiceDay := truncateInvoiceMatchDay(invoice.NgayHoaDon)
+	days := int(invoiceDay.Sub(orderDay).Hours() / 24)
+	switch {
+	case days < 0:
+		factor.Detail = fmt.Sprintf("Invoice dated %d day(s) before the order", -days)
+	case days <= dueDays:
+		factor.Points = invoiceMatchDatePoints
+		factor.Detail = fmt.Sprintf("Invoice dated %d day(s) after the order, within the %d-day window", days, dueDays)
+	default:
+		late := float64(days - dueDays)
+		factor.Points = math.Round(math.Max(0, 1-late/float64(dueDays))*invoiceMatchDatePoints*100) / 100
+		factor.Detail = fmt.Sprintf("Invoice dated %d day(s) after the order, %d day(s) past the window", days, days-dueDays)
+	}
+	return factor
+}
+
+// invoiceMatchNameSimilarity is the Dice coefficient of the normalized word
+// sets of two item names.
+func invoiceMatchNameSimilarity(left, right string) float64 {
+	leftTokens := strings.Fields(normalizeLookup(left))
+	rightTokens := strings.Fields(normalizeLookup(right))
+	if len(leftTokens) == 0 || len(rightTokens) == 0 {
+		return 0
+	}
+
+	leftSet := make(map[string]struct{}, len(leftTokens))
+	for _, token := range leftTokens {
+		leftSet[token] = struct{}{}
+	}
+	rightSet := make(map[string]struct{}, len(rightTokens))
+	for _, token := range rightTokens {
+		rightSet[token] = struct{}{}
+	}
+
+	shared := 0
+	for token := range leftSet {
+		if _, ok := rightSet[token]; ok {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(leftSet)+len(rightSet))
+}
+
+func truncateInvoiceMatchDay(value time.Time) time.Time {
+	year, month, day := value.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func formatInvoiceMatchQuantity(value float64) string {
+	if value == math.Trunc(value) {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.3f", value)
+}
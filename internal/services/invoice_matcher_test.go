@@ -0,0 +1,151 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+func TestScoreInvoiceMatchExactCandidate(t *testing.T) {
+	t.Parallel()
+
+	taxCode := "0101234567"
+	invoice := models.HoaDon{
+		ID:               7,
+		MaSoThueNguoiBan: "0101234567",
+		CongTy:           "Công ty Thiết bị Y tế A",
+		MaHangHoa:        "VT-001",
+		TenHangHoa:       "Bơm tiêm 5ml",
+		SoLuong:          100,
+		NgayHoaDon:       time.Date(2026, 7, 5, 0, 0, 0, 0, time.UTC),
+	}
+	line := models.InvoiceMatchOrderLine{
+		ID:               11,
+		CompanyContactID: &taxCode,
+		NhaThau:          "Công ty Thiết bị Y tế A",
+		MaQuanLy:         "vt001",
+		TenVtytBv:        "Bơm tiêm 5ml",
+		OrderedQty:       100,
+		NgayDatHang:      "2026-07-01T08:00:00+07:00",
+	}
+
+	proposal, ok := scoreInvoiceMatch(invoice, line, 14)
+	if !ok {
+		t.Fatal("scoreInvoiceMatch() rejected a same-supplier candidate")
+	}
+	if proposal.Score != 100 || proposal.QuantityDiff != 0 {
+		t.Fatalf("score = %.2f, quantityDiff = %.2f, want 100 and 0", proposal.Score, proposal.QuantityDiff)
+	}
+	if len(proposal.Factors) != 4 || proposal.Factors[0].Factor != "supplier" || proposal.Factors[0].Detail == "" {
+		t.Fatalf("factors = %+v", proposal.Factors)
+	}
+}
+
+func TestScoreInvoiceMatchPartialCandidate(t *testing.T) {
+	t.Parallel()
+
+	invoice := models.HoaDon{
+		ID:         8,
+		CongTy:     "CÔNG TY THIẾT BỊ Y TẾ A",
+		TenHangHoa: "Bơm tiêm nhựa 5ml",
+		SoLuong:    40,
+		NgayHoaDon: time.Date(2026, 7, 22, 0, 0, 0, 0, time.UTC),
+	}
+	line := models.InvoiceMatchOrderLine{
+		ID:           12,
+		NhaThau:      "Thiết bị Y tế A",
+		TenVtytBv:    "Bơm tiêm 5ml",
+		OrderedQty:   100,
+		DeliveredQty: 50,
+		NgayDatHang:  "2026-07-01T08:00:00Z",
+	}
+
+	proposal, ok := scoreInvoiceMatch(invoice, line, 14)
+	if !ok {
+		t.Fatal("scoreInvoiceMatch() rejected a supplier matched by name")
+	}
+	points := make(map[string]float64, len(proposal.Factors))
+	for _, factor := range proposal.Factors {
+		points[factor.Factor] = factor.Points
+	}
+	if points["supplier"] != invoiceMatchSupplierNamePoints {
+		t.Fatalf("supplier points = %.2f", points["supplier"])
+	}
+	if points["material"] <= 0 || points["material"] >= invoiceMatchMaterialNamePoints {
+		t.Fatalf("material points = %.2f, want partial name similarity", points["material"])
+	}
+	if points["quantity"] != 16 || proposal.QuantityDiff != -10 {
+		t.Fatalf("quantity points = %.2f, quantityDiff = %.2f", points["quantity"], proposal.QuantityDiff)
+	}
+	if points["date"] != 7.5 {
+		t.Fatalf("date points = %.2f, want 7.5 for 7 days past the window", points["date"])
+	}
+}
+
+func TestScoreInvoiceMatchRequiresSameSupplier(t *testing.T) {
+	t.Parallel()
+
+	taxCode := "0101234567"
+	invoice := models.HoaDon{MaSoThueNguoiBan: "0309999999", CongTy: "Công ty B", MaHangHoa: "VT-001"}
+	line := models.InvoiceMatchOrderLine{CompanyContactID: &taxCode, NhaThau: "Công ty A", MaQuanLy: "VT-001"}
+
+	if _, ok := scoreInvoiceMatch(invoice, line, 14); ok {
+		t.Fatal("scoreInvoiceMatch() accepted a candidate from another supplier")
+	}
+}
+
+func TestInvoiceMatcherRunSkipsRejectedPairsAndLowScores(t *testing.T) {
+	t.Parallel()
+
+	taxCode := "0101234567"
+	invoiceDate := time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC)
+	store := &memoryInvoiceMatchStore{
+		invoices: []models.HoaDon{{ID: 1, MaSoThueNguoiBan: taxCode, MaHangHoa: "VT-001", SoLuong: 10, NgayHoaDon: invoiceDate}},
+		lines: []models.InvoiceMatchOrderLine{
+			{ID: 10, CompanyContactID: &taxCode, MaQuanLy: "VT-001", OrderedQty: 10, NgayDatHang: "2026-07-01T08:00:00Z"},
+			{ID: 11, CompanyContactID: &taxCode, MaQuanLy: "VT-001", OrderedQty: 12, NgayDatHang: "2026-07-01T08:00:00Z"},
+			{ID: 12, CompanyContactID: &taxCode, MaQuanLy: "VT-999", TenVtytBv: "Găng tay", OrderedQty: 500, NgayDatHang: "2026-01-01T08:00:00Z"},
+		},
+		rejected: []models.InvoiceMatchPair{{HoaDonID: 1, OrderHistoryID: 10}},
+	}
+	matcher := NewInvoiceMatcher(InvoiceMatcherConfig{Store: store})
+	matcher.now = func() time.Time { return invoiceDate }
+
+	result, err := matcher.Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Proposed != 1 || len(store.inserted) != 1 || store.inserted[0].OrderHistoryID != 11 {
+		t.Fatalf("result = %+v, inserted = %+v", result, store.inserted)
+	}
+	if !store.since.Equal(invoiceDate.AddDate(0, 0, -defaultInvoiceMatchLookbackDays)) {
+		t.Fatalf("since = %s", store.since)
+	}
+}
+
+type memoryInvoiceMatchStore struct {
+	invoices []models.HoaDon
+	lines    []models.InvoiceMatchOrderLine
+	rejected []models.InvoiceMatchPair
+	inserted []models.InvoiceMatchProposal
+	since    time.Time
+}
+
+func (s *memoryInvoiceMatchStore) ListUnmatchedInvoiceRows(since time.Time) ([]models.HoaDon, error) {
+	s.since = since
+	return s.invoices, nil
+}
+
+func (s *memoryInvoiceMatchStore) ListOpenOrderLinesForMatching() ([]models.InvoiceMatchOrderLine, error) {
+	return s.lines, nil
+}
+
+func (s *memoryInvoiceMatchStore) ListRejectedInvoiceMatches() ([]models.InvoiceMatchPair, error) {
+	return s.rejected, nil
+}
+
+func (s *memoryInvoiceMatchStore) InsertInvoiceMatchProposals(proposals []models.InvoiceMatchProposal) (int, error) {
+	s.inserted = append(s.inserted, proposals...)
+	return len(proposals), nil
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+var (
+	ErrJobAlreadyRunning = errors.New("a job of this kind is already running")
+	ErrJobNotFound       = errors.New("job not found")
+	ErrJobNotRunning     = errors.New("job is not running")
+)
+
+type JobStore interface {
+	CreateJob(job *models.BackgroundJob) error
+	UpdateJobProgress(id int64, progress models.BackgroundJobProgress, now time.Time) error
+	FinishJob(job *models.BackgroundJob) error
+	GetJob(id int64) (*models.BackgroundJob, error)
+	LatestJob(kind string) (*models.BackgroundJob, error)
+	FailInterruptedJobs(now time.Time) (int, error)
+}
+
+// JobEventPublisher is the part of realtime.Hub the runner needs.
+type JobEventPublisher interface {
+	SendToUser(userID int64, eventType string, payload interface{})
+}
+
+// JobProgressFunc reports progress from inside a running job.
+type JobProgressFunc func(progress models.BackgroundJobProgress)
+
+// JobFunc is the body of a job. It should stop when ctx is cancelled; its
+// result is stored as JSON on success.
+type JobFunc func(ctx context.Context, report JobProgressFunc) (interface{}, error)
+
+type JobRunnerConfig struct {
+	Store  JobStore
+	Events JobEventPublisher
+}
+
+// JobRunner runs long operations outside the request that started them.
+// At most one job per kind runs at a time; every state change is persisted
+// and sent to the job's creator as jobs.progress or jobs.finished.
+type JobRunner struct {
+	store  JobStore
+	events JobEventPublisher
+	now    func() time.Time
+
+	mu      sync.Mutex
+	byKind  map[string]*runningJob
+	running map[int64]*runningJob
+}
+
+type runningJob struct {
+	job    models.BackgroundJob
+	cancel context.CancelFunc
+}
+
+func NewJobRunner(cfg JobRunnerConfig) *JobRunner {
+	return &JobRunner{
+		store:   cfg.Store,
+		events:  cfg.Events,
+		now:     time.Now,
+		byKind:  make(map[string]*runningJob),
+		running: make(map[int64]*runningJob),
+	}
+}
+
+// Start records a job and runs fn in the background. When a job of the same
+// kind is still running it returns that job with ErrJobAlreadyRunning.
+func (r *JobRunner) Start(kind string, createdByUserID *int64, fn JobFunc) (models.BackgroundJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.byKind[kind]; ok {
+		return existing.job, ErrJobAlreadyRunning
+	}
+
+	now := r.now().UTC()
+	job := models.BackgroundJob{
+		Kind:            kind,
+		Status:          models.BackgroundJobStatusRunning,
+		CreatedByUserID: createdByUserID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := r.store.CreateJob(&job); err != nil {
+		return models.BackgroundJob{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	entry := &runningJob{job: job, cancel: cancel}
+	r.byKind[kind] = entry
+	r.running[job.ID] = entry
+
+	go r.execute(ctx, entry, fn)
+	return job, nil
+}
+
+// FailInterrupted marks jobs left running by a previous process as failed.
+// Jobs only live in the process that started them, so it must run at
+// startup, before any job is started.
+func (r *JobRunner) FailInterrupted() (int, error) {
+	return r.store.FailInterruptedJobs(r.now().UTC())
+}
+
+// Get returns the live state of a running job, or the stored row otherwise.
+func (r *JobRunner) Get(id int64) (models.BackgroundJob, error) {
+	r.mu.Lock()
+	if entry, ok := r.running[id]; ok {
+		job := entry.job
+		r.mu.Unlock()
+		return job, nil
+	}
+	r.mu.Unlock()
+
+	job, err := r.store.GetJob(id)
+	if err != nil {
+		return models.BackgroundJob{}, err
+	}
+	if job == nil {
+		return models.BackgroundJob{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+// Latest returns the newest job of a kind, running or finished.
+func (r *JobRunner) Latest(kind string) (*models.BackgroundJob, error) {
+	r.mu.Lock()
+	if entry, ok := r.byKind[kind]; ok {
+		job := entry.job
+		r.mu.Unlock()
+		return &job, nil
+	}
+	r.mu.Unlock()
+
+	return r.store.LatestJob(kind)
+}
+
+// Cancel asks a running job to stop. The job finishes as cancelled once its
+// function returns.
+func (r *JobRunner) Cancel(id int64) (models.BackgroundJob, error) {
+	r.mu.Lock()
+	entry, ok := r.running[id]
+	if ok {
+		entry.cancel()
+		job := entry.job
+		r.mu.Unlock()
+		return job, nil
+	}
+	r.mu.Unlock()
+
+	job, err := r.Get(id)
+	if err != nil {
+		return models.BackgroundJob{}, err
+	}
+	return job, ErrJobNotRunning
+}
+
+func (r *JobRunner) execute(ctx context.Context, entry *runningJob, fn JobFunc) {
+	result, err := r.call(ctx, entry, fn)
+
+	r.mu.Lock()
+	now := r.now().UTC()
+	job := &entry.job
+	job.UpdatedAt = now
+	job.FinishedAt = &now
+	switch {
+	case ctx.Err() != nil:
+		job.Status = models.BackgroundJobStatusCancelled
+		job.ErrorMessage = "Cancelled"
+	case err != nil:
+		job.Status = models.BackgroundJobStatusFailed
+		job.ErrorMessage = err.Error()
+	default:
+		job.Status = models.BackgroundJobStatusSucceeded
+		if result != nil {
+			encoded, marshalErr := json.Marshal(result)
+			if marshalErr != nil {
+				log.Printf("[jobs] warning: failed to encode result of job %d: %v", job.ID, marshalErr)
+			} else {
+				job.Result = encoded
+			}
+		}
+	}
+	finished := *job
+	delete(r.byKind, job.Kind)
+	delete(r.running, job.ID)
+	entry.cancel()
+	r.mu.Unlock()
+
+	if err := r.store.FinishJob(&finished); err != nil {
+		log.Printf("[jobs] warning: failed to record end of job %d: %v", finished.ID, err)
+	}
+	if finished.Status == models.BackgroundJobStatusFailed {
+		log.Printf("[jobs] %s job %d failed: %s", finished.Kind, finished.ID, finished.ErrorMessage)
+	}
+	r.publish("jobs.finished", finished)
+}
+
+func (r *JobRunner) call(ctx context.Context, entry *runningJob, fn JobFunc) (result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	report := func(progress models.BackgroundJobProgress) {
+		r.mu.Lock()
+		now := r.now().UTC()
+		entry.job.Progress = progress
+		entry.job.UpdatedAt = now
+		snapshot := entry.job
+		r.mu.Unlock()
+
+		if err := r.store.UpdateJobProgress(snapshot.ID, progress, now); err != nil {
+			log.Printf("[jobs] warning: failed to record progress of job %d: %v", snapshot.ID, err)
+		}
+		r.publish("jobs.progress", snapshot)
+	}
+	return fn(ctx, report)
+}
+
+// publish sends a job event to the user who started the job. Jobs started
+// without a user have nobody to notify.
+func (r *JobRunner) publish(eventType string, job models.BackgroundJob) {
+	if r.events == nil || job.CreatedByUserID == nil {
+		return
+	}
+	r.events.SendToUser(*job.CreatedByUserID, eventType, job)
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+func TestJobRunnerRunsOneJobPerKind(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryJobStore()
+	events := &recordingJobEvents{finished: make(chan models.BackgroundJob, 4)}
+	runner := NewJobRunner(JobRunnerConfig{Store: store, Events: events})
+
+	release := make(chan struct{})
+	userID := int64(7)
+	job, err := runner.Start(models.BackgroundJobKindInvoiceRefresh, &userID, func(ctx context.Context, report JobProgressFunc) (interface{}, error) {
+		report(models.BackgroundJobProgress{Stage: "fetch", Current: 1, Total: 2})
+		<-release
+		return map[string]int{"count": 3}, nil
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	duplicate, err := runner.Start(models.BackgroundJobKindInvoiceRefresh, nil, func(context.Context, JobProgressFunc) (interface{}, error) {
+		t.Error("second job of the same kind must not run")
+		return nil, nil
+	})
+	if !errors.Is(err, ErrJobAlreadyRunning) || duplicate.ID != job.ID {
+		t.Fatalf("second Start() = %+v, %v; want the running job and ErrJobAlreadyRunning", duplicate, err)
+	}
+
+	otherUserID := int64(8)
+	other, err := runner.Start(models.BackgroundJobKindCompareCatalogImport, &otherUserID, func(context.Context, JobProgressFunc) (interface{}, error) {
+		return nil, errors.New("bad workbook")
+	})
+	if err != nil {
+		t.Fatalf("Start() of another kind error = %v", err)
+	}
+
+	close(release)
+	results := map[int64]models.BackgroundJob{}
+	for len(results) < 2 {
+		select {
+		case finished := <-events.finished:
+			results[finished.ID] = finished
+		case <-time.After(5 * time.Second):
+			t.Fatal("jobs did not finish")
+		}
+	}
+
+	succeeded := results[job.ID]
+	if succeeded.Status != models.BackgroundJobStatusSucceeded || string(succeeded.Result) != `{"count":3}` {
+		t.Fatalf("refresh job = %+v", succeeded)
+	}
+	if succeeded.Progress.Current != 1 || succeeded.CreatedByUserID == nil || *succeeded.CreatedByUserID != userID {
+		t.Fatalf("refresh job = %+v", succeeded)
+	}
+	if failed := results[other.ID]; failed.Status != models.BackgroundJobStatusFailed || failed.ErrorMessage != "bad workbook" {
+		t.Fatalf("import job = %+v", failed)
+	}
+	if stored, err := runner.Get(job.ID); err != nil || stored.Status != models.BackgroundJobStatusSucceeded {
+		t.Fatalf("Get() = %+v, %v", stored, err)
+	}
+	if events.count("jobs.progress") != 1 {
+		t.Fatalf("progress events = %d, want 1", events.count("jobs.progress"))
+	}
+	for _, recipient := range events.recipients() {
+		if recipient != userID && recipient != otherUserID {
+			t.Fatalf("event sent to user %d, want only the job creators", recipient)
+		}
+	}
+}
+
+func TestJobRunnerCancelStopsJob(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryJobStore()
+	events := &recordingJobEvents{finished: make(chan models.BackgroundJob, 1)}
+	runner := NewJobRunner(JobRunnerConfig{Store: store, Events: events})
+
+	started := make(chan struct{})
+	userID := int64(7)
+	job, err := runner.Start(models.BackgroundJobKindInternalSupplySync, &userID, func(ctx context.Context, _ JobProgressFunc) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	<-started
+
+	if _, err := runner.Cancel(job.ID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	select {
+	case finished := <-events.finished:
+		if finished.Status != models.BackgroundJobStatusCancelled {
+			t.Fatalf("cancelled job = %+v", finished)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled job did not finish")
+	}
+
+	if _, err := runner.Cancel(job.ID); !errors.Is(err, ErrJobNotRunning) {
+		t.Fatalf("second Cancel() error = %v, want ErrJobNotRunning", err)
+	}
+	if _, err := runner.Get(999); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("Get(999) error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestJobRunnerFailInterruptedClosesRunningJobs(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryJobStore()
+	store.jobs[1] = models.BackgroundJob{ID: 1, Kind: models.BackgroundJobKindInvoiceRefresh, Status: models.BackgroundJobStatusRunning}
+	store.jobs[2] = models.BackgroundJob{ID: 2, Kind: models.BackgroundJobKindInvoiceRefresh, Status: models.BackgroundJobStatusSucceeded}
+	runner := NewJobRunner(JobRunnerConfig{Store: store})
+
+	failed, err := runner.FailInterrupted()
+	if err != nil || failed != 1 {
+		t.Fatalf("FailInterrupted() = %d, %v; want 1", failed, err)
+	}
+	if job, _ := runner.Get(1); job.Status != models.BackgroundJobStatusFailed || job.FinishedAt == nil {
+		t.Fatalf("interrupted job = %+v", job)
+	}
+	if job, _ := runner.Get(2); job.Status != models.BackgroundJobStatusSucceeded {
+		t.Fatalf("finished job = %+v", job)
+	}
+}
+
+func TestJobRunnerSkipsEventsForJobsWithoutCreator(t *testing.T) {
+	t.Parallel()
+
+	events := &recordingJobEvents{finished: make(chan models.BackgroundJob, 1)}
+	runner := NewJobRunner(JobRunnerConfig{Store: newMemoryJobStore(), Events: events})
+
+	runner.publish("jobs.finished", models.BackgroundJob{ID: 1})
+
+	if len(events.recipients()) != 0 {
+		t.Fatalf("recipients = %v, want none", events.recipients())
+	}
+}
+
+type memoryJobStore struct {
+	mu     sync.Mutex
+	nextID int64
+	jobs   map[int64]models.BackgroundJob
+}
+
+func newMemoryJobStore() *memoryJobStore {
+	return &memoryJobStore{jobs: make(map[int64]models.BackgroundJob)}
+}
+
+func (s *memoryJobStore) CreateJob(job *models.BackgroundJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	job.ID = s.nextID
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (s *memoryJobStore) UpdateJobProgress(id int64, progress models.BackgroundJobProgress, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[id]
+	job.Progress = progress
+	job.UpdatedAt = now
+	s.jobs[id] = job
+	return nil
+}
+
+func (s *memoryJobStore) FinishJob(job *models.BackgroundJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (s *memoryJobStore) GetJob(id int64) (*models.BackgroundJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+func (s *memoryJobStore) FailInterruptedJobs(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failed := 0
+	for id, job := range s.jobs {
+		if job.Status != models.BackgroundJobStatusRunning {
+			continue
+		}
+		job.Status = models.BackgroundJobStatusFailed
+		job.FinishedAt = &now
+		s.jobs[id] = job
+		failed++
+	}
+	return failed, nil
+}
+
+func (s *memoryJobStore) LatestJob(kind string) (*models.BackgroundJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var latest *models.BackgroundJob
+	for _, job := range s.jobs {
+		if job.Kind == kind && (latest == nil || job.ID > latest.ID) {
+			value := job
+			latest = &value
+		}
+	}
+	return latest, nil
+}
+
+type recordingJobEvents struct {
+	mu       sync.Mutex
+	types    []string
+	users    []int64
+	finished chan models.BackgroundJob
+}
+
+func (e *recordingJobEvents) SendToUser(userID int64, eventType string, payload interface{}) {
+	e.mu.Lock()
+	e.types = append(e.types, eventType)
+	e.users = append(e.users, userID)
+	e.mu.Unlock()
+
+	if eventType == "jobs.finished" {
+		e.finished <- payload.(models.BackgroundJob)
+	}
+}
+
+func (e *recordingJobEvents) recipients() []int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]int64(nil), e.users...)
+}
+
+func (e *recordingJobEvents) count(eventType string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	total := 0
+	for _, recorded := range e.types {
+		if recorded == eventType {
+			total++
+		}
+	}
+	return total
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+const (
+	orderAmendmentEmailSubject   = "ĐIỀU CHỈNH ĐƠN ĐẶT HÀNG VẬT TƯ TẠI BỆNH VIỆN TWQĐ 108"
+	orderAmendmentEmailBody      = "Kính gửi công ty [Tên công ty cung cấp], Khoa Trang bị- BV TWQĐ 108 xin thông báo điều chỉnh đơn đặt hàng vật tư đã gửi trước đó theo file PDF đính kèm"
+	orderAmendmentAttachmentName = "dieu-chinh-don-dat-hang-vat-tu-bv108.pdf"
+)
+
+type OrderAmendmentEmailItem struct {
+	Index        int
+	TenVatTu     string
+	MaXuatHoaDon string
+	MaHieu       string
+	DonViTinh    string
+	NgayDatHang  string
+	SoLuongCu    int
+	SoLuongMoi   int
+	Cancelled    bool
+	LyDo         string
+}
+
+type orderAmendmentDocumentData struct {
+	placedOrderDocumentData
+	Amendments []OrderAmendmentEmailItem
+}
+
+var orderAmendmentPDFLetter = orderPDFLetter{
+	Subject: "Điều chỉnh đơn đặt hàng vật tư",
+	Title:   "THÔNG BÁO ĐIỀU CHỈNH ĐƠN ĐẶT HÀNG VẬT TƯ",
+	Intro:   "Khoa Trang bị - Bệnh viện TWQĐ 108 xin thông báo đến Quý công ty nội dung điều chỉnh đơn đặt hàng vật tư đã gửi trước đó như sau:",
+	Request: "Yêu cầu công ty xác nhận lại nội dung điều chỉnh qua gmail và zalo của cán bộ phụ trách gói thầu trong vòng 1 giờ sau khi tiếp nhận, và giao hàng theo số lượng đã điều chỉnh.",
+}
+
+var orderAmendmentPDFTableColumns = []orderPDFTableColumn{
+	{header: "STT", width: 12},
+	{header: "Tên vật tư", width: 40},
+	{header: "Mã xuất\nhóa đơn", width: 23},
+	{header: "Mã hiệu", width: 22},
+	{header: "Đơn vị\ntính", width: 18},
+	{header: "SL đã\nđặt", width: 17},
+	{header: "SL điều\nchỉnh", width: 18},
+	{header: "Ghi chú", width: 30},
+}
+
+func (m *SMTPOrderMailer) SendOrderAmendmentEmail(recipientEmail, supplierName string, items []OrderAmendmentEmailItem) error {
+	supplierName = strings.TrimSpace(supplierName)
+	recipientEmail = strings.TrimSpace(recipientEmail)
+
+	if err := m.validateOrderEmail(recipientEmail, supplierName); err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		if supplierName == "" {
+			return fmt.Errorf("missing amended order items for email")
+		}
+		return fmt.Errorf("missing amended order items for %s", supplierName)
+	}
+
+	template, err := m.templates.resolve(supplierName)
+	if err != nil {
+		return fmt.Errorf("error loading order email template: %w", err)
+	}
+
+	document := orderAmendmentDocumentData{
+		placedOrderDocumentData: template.document(supplierName, nil),
+		Amendments:              items,
+	}
+
+	pdfBytes, err := renderOrderAmendmentAttachmentPDF(document)
+	if err != nil {
+		return fmt.Errorf("error rendering order amendment PDF attachment: %w", err)
+	}
+
+	return m.sendOrderDocument(
+		recipientEmail,
+		supplierName,
+		orderAmendmentEmailSubject,
+		renderOrderAmendmentEmailBody(supplierName),
+		orderAmendmentAttachmentName,
+		pdfBytes,
+	)
+}
+
+func renderOrderAmendmentEmailBody(companyName string) string {
+	return renderOrderEmailTemplateText(orderAmendmentEmailBody, companyName)
+}
+
+func renderOrderAmendmentAttachmentPDF(data orderAmendmentDocumentData) ([]byte, error) {
+	pdf, err := newOrderPDF(orderAmendmentEmailSubject, orderAmendmentPDFLetter)
+	if err != nil {
+		return nil, err
+	}
+
+	renderPlacedOrderPDFHeader(pdf, data.placedOrderDocumentData, orderAmendmentPDFLetter)
+	renderOrderAmendmentPDFTable(pdf, data.Amendments)
+	renderPlacedOrderPDFFooter(pdf, data.placedOrderDocumentData, orderAmendmentPDFLetter)
+
+	return outputOrderPDF(pdf)
+}
+
+func renderOrderAmendmentPDFTable(pdf *gofpdf.Fpdf, items []OrderAmendmentEmailItem) {
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, orderAmendmentPDFTableValues(item))
+	}
+
+	renderOrderPDFTable(pdf, "1. Danh sách vật tư điều chỉnh:", orderAmendmentPDFTableColumns, rows)
+}
+
+func orderAmendmentPDFTableValues(item OrderAmendmentEmailItem) []string {
+	newQuantity := strconv.Itoa(item.SoLuongMoi)
+	note := "Điều chỉnh số lượng"
+	if item.Cancelled {
+		newQuantity = "0"
+		note = "Hủy đặt hàng"
+	}
+	if orderDate := strings.TrimSpace(item.NgayDatHang); orderDate != "" {
+		note += "\nĐơn ngày " + orderDate
+	}
+	if reason := strings.TrimSpace(item.LyDo); reason != "" {
+		note += "\n" + reason
+	}
+
+	return []string{
+		strconv.Itoa(item.Index),
+		nonEmptyPDFText(item.TenVatTu),
+		nonEmptyPDFText(item.MaXuatHoaDon),
+		nonEmptyPDFText(item.MaHieu),
+		nonEmptyPDFText(item.DonViTinh),
+		strconv.Itoa(item.SoLuongCu),
+		newQuantity,
+		note,
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderAmendmentPDFTableValues(t *testing.T) {
+	amended := orderAmendmentPDFTableValues(OrderAmendmentEmailItem{
+		Index:        1,
+		TenVatTu:     "Bơm kim tiêm",
+		MaXuatHoaDon: "VT001",
+		DonViTinh:    "Cái",
+		NgayDatHang:  "05/04/2026",
+		SoLuongCu:    25,
+		SoLuongMoi:   40,
+	})
+	if len(amended) != len(orderAmendmentPDFTableColumns) {
+		t.Fatalf("value count = %d, want %d", len(amended), len(orderAmendmentPDFTableColumns))
+	}
+	if amended[5] != "25" || amended[6] != "40" {
+		t.Fatalf("quantities = %q -> %q", amended[5], amended[6])
+	}
+	if amended[3] != "-" {
+		t.Fatalf("empty ma hieu = %q, want placeholder", amended[3])
+	}
+	if !strings.Contains(amended[7], "Đơn ngày 05/04/2026") {
+		t.Fatalf("note = %q", amended[7])
+	}
+
+	cancelled := orderAmendmentPDFTableValues(OrderAmendmentEmailItem{Index: 2, SoLuongCu: 10, SoLuongMoi: 10, Cancelled: true, LyDo: "Nhà thầu hết hàng"})
+	if cancelled[6] != "0" {
+		t.Fatalf("cancelled quantity = %q, want 0", cancelled[6])
+	}
+	if !strings.HasPrefix(cancelled[7], "Hủy đặt hàng") || !strings.Contains(cancelled[7], "Nhà thầu hết hàng") {
+		t.Fatalf("cancelled note = %q", cancelled[7])
+	}
+}
+
+func TestRenderOrderAmendmentAttachmentPDF(t *testing.T) {
+	if _, _, err := resolveOrderPDFFontPaths(); err != nil {
+		t.Skipf("PDF fonts unavailable in test environment: %v", err)
+	}
+
+	pdfBytes, err := renderOrderAmendmentAttachmentPDF(orderAmendmentDocumentData{
+		placedOrderDocumentData: orderPDFStressDocument(nil),
+		Amendments: []OrderAmendmentEmailItem{
+			{Index: 1, TenVatTu: "Dây truyền dịch", MaXuatHoaDon: "VT002", DonViTinh: "Bộ", SoLuongCu: 10, SoLuongMoi: 6, LyDo: "Giảm theo dự trù"},
+			{Index: 2, TenVatTu: "Bơm kim tiêm", MaXuatHoaDon: "VT001", DonViTinh: "Cái", SoLuongCu: 25, Cancelled: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("renderOrderAmendmentAttachmentPDF() error = %v", err)
+	}
+
+	assertOrderPDFStructure(t, pdfBytes, 1, 2)
+}
+
+func TestRenderOrderAmendmentEmailBody(t *testing.T) {
+	body := renderOrderAmendmentEmailBody("")
+	if !strings.HasPrefix(body, "Kính gửi công ty Quý công ty,") {
+		t.Fatalf("unexpected email body: %q", body)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	PlacedOrderWorkbookContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	placedOrderWorkbookSheet       = "don_dat_hang"
+	placedOrderWorkbookHeaderRow   = 5
+)
+
+// RenderPlacedOrderDocument regenerates the placed-order PDF for a batch
+// from the document snapshot stored when the batch was placed.
+func (s *OrderEmailTemplateService) RenderPlacedOrderDocument(supplierName, documentSnapshot string, lines []models.OrderEmailLine) ([]byte, error) {
+	supplierName = strings.TrimSpace(supplierName)
+	snapshot, err := s.placedOrder(supplierName, documentSnapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	pdfBytes, err := renderPlacedOrderAttachmentPDF(snapshot.document(supplierName, buildOrderEmailItems(lines)))
+	if err != nil {
+		return nil, fmt.Errorf("error rendering order PDF: %w", err)
+	}
+	return pdfBytes, nil
+}
+
+// RenderPlacedOrderWorkbook renders the same table as the placed-order PDF
+// into an xlsx workbook, with numeric cells kept numeric.
+func (s *OrderEmailTemplateService) RenderPlacedOrderWorkbook(supplierName, documentSnapshot string, lines []models.OrderEmailLine) ([]byte, error) {
+	supplierName = strings.TrimSpace(supplierName)
+	snapshot, err := s.placedOrder(supplierName, documentSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	template := snapshot.Template
+
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+	if err := workbook.SetSheetName(workbook.GetSheetName(0), placedOrderWorkbookSheet); err != nil {
+		return nil, fmt.Errorf("error naming order workbook sheet: %w", err)
+	}
+
+	ngayDatHang := ""
+	if len(lines) > 0 {
+		ngayDatHang = strings.TrimSpace(lines[0].NgayDatHang)
+	}
+	preamble := []string{
+		template.Letter.Title,
+		"Kính gửi công ty " + nonEmptyPDFText(supplierName),
+		"Ngày đặt hàng: " + nonEmptyPDFText(ngayDatHang),
+	}
+	for index, text := range preamble {
+		if err := workbook.SetCellValue(placedOrderWorkbookSheet, fmt.Sprintf("A%d", index+1), text); err != nil {
+			return nil, fmt.Errorf("error writing order workbook header: %w", err)
+		}
+	}
+
+	columns := placedOrderPDFTableColumns(template.ExtraColumns)
+	headers := make([]interface{}, len(columns))
+	for index, column := range columns {
+		headers[index] = strings.ReplaceAll(column.header, "\n", " ")
+	}
+	rows := [][]interface{}{headers}
+	for _, item := range buildOrderEmailItems(lines) {
+		rows = append(rows, placedOrderWorkbookValues(item, template.ExtraColumns))
+	}
+	for offset, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, placedOrderWorkbookHeaderRow+offset)
+		if err != nil {
+			return nil, fmt.Errorf("error locating order workbook row: %w", err)
+		}
+		if err := workbook.SetSheetRow(placedOrderWorkbookSheet, cell, &row); err != nil {
+			return nil, fmt.Errorf("error writing order workbook row: %w", err)
+		}
+	}
+
+	contactRow := placedOrderWorkbookHeaderRow + len(rows) + 1
+	contact := fmt.Sprintf("Thông tin liên hệ: %s - %s (SĐT liên hệ: %s)", template.ContactName, template.ContactTitle, template.ContactPhone)
+	if err := workbook.SetCellValue(placedOrderWorkbookSheet, fmt.Sprintf("A%d", contactRow), contact); err != nil {
+		return nil, fmt.Errorf("error writing order workbook contact: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	if err := workbook.Write(&buffer); err != nil {
+		return nil, fmt.Errorf("error writing order workbook: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func placedOrderWorkbookValues(item OrderEmailItem, extraColumns []string) []interface{} {
+	values := []interface{}{
+		item.Index,
+		item.TenVatTu,
+		item.MaXuatHoaDon,
+		item.MaHieu,
+		item.HangNuocSX,
+		item.DonViTinh,
+		item.SoLuong,
+	}
+	for _, name := range extraColumns {
+		switch name {
+		case models.OrderEmailColumnUnitPrice:
+			values = append(values, item.DonGia)
+		case models.OrderEmailColumnTenderPackage:
+			values = append(values, item.SoGoiThau)
+		}
+	}
+	return values
+}
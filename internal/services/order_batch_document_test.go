@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+
+	"bv108-consumables-management-backend/internal/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRenderPlacedOrderWorkbook(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryOrderEmailTemplateStore(models.OrderEmailTemplate{ExtraColumns: []string{models.OrderEmailColumnUnitPrice}})
+	lines := make([]models.OrderEmailLine, 2)
+	lines[0].NgayDatHang = "2026-07-01 08:00:00"
+	lines[0].TenVtytBv = "Bơm kim tiêm"
+	lines[0].DotGoiHang = 25
+	lines[0].DonGia = 1500
+	lines[1].TenVtytBv = "Dây truyền dịch"
+	lines[1].DotGoiHang = 10
+
+	content, err := NewOrderEmailTemplateService(store).RenderPlacedOrderWorkbook("Công ty A", "", lines)
+	if err != nil {
+		t.Fatalf("RenderPlacedOrderWorkbook() error = %v", err)
+	}
+
+	workbook, err := excelize.OpenReader(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	defer workbook.Close()
+
+	rows, err := workbook.GetRows(placedOrderWorkbookSheet)
+	if err != nil {
+		t.Fatalf("GetRows() error = %v", err)
+	}
+	header := rows[placedOrderWorkbookHeaderRow-1]
+	if len(header) != len(orderPDFTableColumns)+1 || header[len(header)-1] != "Đơn giá (VNĐ)" {
+		t.Fatalf("header = %v", header)
+	}
+	first := rows[placedOrderWorkbookHeaderRow]
+	if first[1] != "Bơm kim tiêm" || first[6] != "25" || first[7] != "1500" {
+		t.Fatalf("first row = %v", first)
+	}
+	if rows[2][0] != "Ngày đặt hàng: 2026-07-01 08:00:00" {
+		t.Fatalf("order date row = %v", rows[2])
+	}
+}
+
+func TestRenderPlacedOrderWorkbookUsesSnapshotFromPlacement(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryOrderEmailTemplateStore(models.OrderEmailTemplate{PDFTitle: "ĐƠN ĐẶT HÀNG CŨ", ExtraColumns: []string{models.OrderEmailColumnUnitPrice}})
+	service := NewOrderEmailTemplateService(store)
+	snapshot, err := service.SnapshotPlacedOrder("Công ty A")
+	if err != nil {
+		t.Fatalf("SnapshotPlacedOrder() error = %v", err)
+	}
+
+	store.templates[0].PDFTitle = "ĐƠN ĐẶT HÀNG MỚI"
+	store.templates[0].ExtraColumns = []string{}
+
+	lines := make([]models.OrderEmailLine, 1)
+	lines[0].TenVtytBv = "Bơm kim tiêm"
+	lines[0].DotGoiHang = 25
+	lines[0].DonGia = 1500
+	content, err := service.RenderPlacedOrderWorkbook("Công ty A", snapshot, lines)
+	if err != nil {
+		t.Fatalf("RenderPlacedOrderWorkbook() error = %v", err)
+	}
+
+	workbook, err := excelize.OpenReader(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	defer workbook.Close()
+
+	rows, err := workbook.GetRows(placedOrderWorkbookSheet)
+	if err != nil {
+		t.Fatalf("GetRows() error = %v", err)
+	}
+	if rows[0][0] != "ĐƠN ĐẶT HÀNG CŨ" {
+		t.Fatalf("title = %q, want the title stored at placement", rows[0][0])
+	}
+	if header := rows[placedOrderWorkbookHeaderRow-1]; len(header) != len(orderPDFTableColumns)+1 {
+		t.Fatalf("header = %v, want the unit price column stored at placement", header)
+	}
+}
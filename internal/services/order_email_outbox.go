@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+const (
+	defaultOrderEmailPollInterval = 15 * time.Second
+	defaultOrderEmailMaxAttempts  = 6
+	defaultOrderEmailRetryBase    = time.Minute
+	defaultOrderEmailRetryMax     = time.Hour
+	orderEmailClaimBatchSize      = 10
+)
+
+type OrderEmailOutboxStore interface {
+	ClaimDueOrderEmails(now time.Time, limit int) ([]models.OrderEmailOutboxEntry, error)
+	ListOrderEmailLines(outboxID int64) ([]models.OrderEmailLine, error)
+	ListOrderAmendmentEmailLines(outboxID int64) ([]models.OrderAmendmentEmailLine, error)
+	MarkOrderEmailSent(outboxID int64, attempts int, sentAt time.Time) error
+	MarkOrderEmailFailed(outboxID int64, attempts int, nextAttemptAt *time.Time, lastError string) error
+	RequeueOrderEmail(outboxID int64) error
+}
+
+type OrderEmailOutboxConfig struct {
+	Store               OrderEmailOutboxStore
+	Sender              OrderEmailSender
+	PollIntervalSeconds int
+	MaxAttempts         int
+	RetryBase           time.Duration
+	RetryMax            time.Duration
+}
+
+type OrderEmailOutboxResult struct {
+	Sent    int
+	Retried int
+	Failed  int
+}
+
+// OrderEmailOutbox delivers queued placed-order and amendment emails in the
+// background and retries SMTP failures with exponential backoff.
+type OrderEmailOutbox struct {
+	store        OrderEmailOutboxStore
+	sender       OrderEmailSender
+	pollInterval time.Duration
+	maxAttempts  int
+	retryBase    time.Duration
+	retryMax     time.Duration
+	wake         chan struct{}
+	now          func() time.Time
+}
+
+func NewOrderEmailOutbox(cfg OrderEmailOutboxConfig) *OrderEmailOutbox {
+	pollInterval := defaultOrderEmailPollInterval
+	if cfg.PollIntervalSeconds > 0 {
+		pollInterval = time.Duration(cfg.PollIntervalSeconds) * time.Second
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultOrderEmailMaxAttempts
+	}
+	retryBase := cfg.RetryBase
+	if retryBase <= 0 {
+		retryBase = defaultOrderEmailRetryBase
+	}
+	retryMax := cfg.RetryMax
+	if retryMax <= 0 {
+		retryMax = defaultOrderEmailRetryMax
+	}
+
+	return &OrderEmailOutbox{
+		store:        cfg.Store,
+		sender:       cfg.Sender,
+		pollInterval: pollInterval,
+		maxAttempts:  maxAttempts,
+		retryBase:    retryBase,
+		retryMax:     retryMax,
+		wake:         make(chan struct{}, 1),
+		now:          time.Now,
+	}
+}
+
+func (o *OrderEmailOutbox) Start(ctx context.Context) {
+	if o == nil || o.store == nil || o.sender == nil {
+		log.Println("[order-email-outbox] skipped because the store or sender is not configured")
+		return
+	}
+
+	go o.run(ctx)
+}
+
+// Notify wakes the worker so newly queued emails go out without waiting for
+// the next poll.
+func (o *OrderEmailOutbox) Notify() {
+	if o == nil {
+		return
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *OrderEmailOutbox) Resend(outboxID int64) error {
+	if o == nil || o.store == nil {
+		return fmt.Errorf("order email outbox is not configured")
+	}
+	if err := o.store.RequeueOrderEmail(outboxID); err != nil {
+		return err
+	}
+	o.Notify()
+	return nil
+}
+
+func (o *OrderEmailOutbox) run(ctx context.Context) {
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		result, err := o.ProcessDue(ctx)
+		if err != nil {
+			log.Printf("[order-email-outbox] processing failed: %v", err)
+		} else if result.Sent > 0 || result.Retried > 0 || result.Failed > 0 {
+			log.Printf("[order-email-outbox] sent %d, retrying %d, failed %d", result.Sent, result.Retried, result.Failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-o.wake:
+		}
+	}
+}
+
+// ProcessDue sends every email that is due now, one claim batch at a time.
+func (o *OrderEmailOutbox) ProcessDue(ctx context.Context) (OrderEmailOutboxResult, error) {
+	var result OrderEmailOutboxResult
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		entries, err := o.store.ClaimDueOrderEmails(o.now(), orderEmailClaimBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(entries) == 0 {
+			return result, nil
+		}
+
+		for _, entry := range entries {
+			if err := o.deliver(entry, &result); err != nil {
+				return result, err
+			}
+		}
+
+		if len(entries) < orderEmailClaimBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func (o *OrderEmailOutbox) deliver(entry models.OrderEmailOutboxEntry, result *OrderEmailOutboxResult) error {
+	attempts := entry.Attempts + 1
+
+	sendErr := o.send(entry)
+	if sendErr == nil {
+		result.Sent++
+		return o.store.MarkOrderEmailSent(entry.ID, attempts, o.now())
+	}
+
+	if attempts >= o.maxAttempts {
+		result.Failed++
+		return o.store.MarkOrderEmailFailed(entry.ID, attempts, nil, sendErr.Error())
+	}
+
+	result.Retried++
+	nextAttemptAt := o.now().Add(o.retryDelay(attempts))
+	return o.store.MarkOrderEmailFailed(entry.ID, attempts, &nextAttemptAt, sendErr.Error())
+}
+
+func (o *OrderEmailOutbox) send(entry models.OrderEmailOutboxEntry) error {
+	if entry.Kind == models.OrderEmailKindOrderAmendment {
+		lines, err := o.store.ListOrderAmendmentEmailLines(entry.ID)
+		if err != nil {
+			return err
+		}
+		return o.sender.SendOrderAmendmentEmail(entry.RecipientEmail, entry.SupplierName, buildOrderAmendmentEmailItems(lines))
+	}
+
+	lines, err := o.store.ListOrderEmailLines(entry.ID)
+	if err != nil {
+		return err
+	}
+	// Every line was cancelled before the email went out, so there is
+	// nothing left to order from the supplier.
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return o.sender.SendPlacedOrderEmail(entry.RecipientEmail, entry.SupplierName, entry.DocumentSnapshot, buildOrderEmailItems(lines))
+}
+
+// retryDelay doubles the wait after every failed attempt, capped at retryMax.
+func (o *OrderEmailOutbox) retryDelay(attempts int) time.Duration {
+	delay := o.retryBase
+	for attempt := 1; attempt < attempts; attempt++ {
+		delay *= 2
+		if delay >= o.retryMax {
+			return o.retryMax
+		}
+	}
+	return delay
+}
+
+func buildOrderEmailItems(lines []models.OrderEmailLine) []OrderEmailItem {
+	items := make([]OrderEmailItem, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, OrderEmailItem{
+			Index:        len(items) + 1,
+			TenVatTu:     strings.TrimSpace(line.TenVtytBv),
+			MaXuatHoaDon: models.PreferredMaterialCode(line.MaQuanLy, line.MaVtytCu),
+			MaHieu:       strings.TrimSpace(line.MaHieu),
+			HangNuocSX:   strings.TrimSpace(line.HangSx),
+			DonViTinh:    strings.TrimSpace(line.DonViTinh),
+			SoLuong:      line.DotGoiHang,
+			DonGia:       line.DonGia,
+			SoGoiThau:    strings.TrimSpace(line.SoGoiThau),
+		})
+	}
+	return items
+}
+
+func buildOrderAmendmentEmailItems(lines []models.OrderAmendmentEmailLine) []OrderAmendmentEmailItem {
+	items := make([]OrderAmendmentEmailItem, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, OrderAmendmentEmailItem{
+			Index:        len(items) + 1,
+			TenVatTu:     strings.TrimSpace(line.Line.TenVtytBv),
+			MaXuatHoaDon: models.PreferredMaterialCode(line.Line.MaQuanLy, line.Line.MaVtytCu),
+			MaHieu:       strings.TrimSpace(line.Line.MaHieu),
+			DonViTinh:    strings.TrimSpace(line.Line.DonViTinh),
+			NgayDatHang:  formatOrderAmendmentDate(line.Line.NgayDatHang),
+			SoLuongCu:    line.Amendment.OldQty,
+			SoLuongMoi:   line.Amendment.NewQty,
+			Cancelled:    line.Amendment.Action == models.OrderAmendmentActionCancel,
+			LyDo:         line.Amendment.Reason,
+		})
+	}
+	return items
+}
+
+func formatOrderAmendmentDate(value string) string {
+	parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	if location, err := time.LoadLocation("Asia/Ho_Chi_Minh"); err == nil {
+		parsed = parsed.In(location)
+	}
+	return parsed.Format("02/01/2006")
+}
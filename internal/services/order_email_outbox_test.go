@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+func TestOrderEmailOutboxSendsQueuedEmail(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryOrderEmailOutboxStore(models.OrderEmailOutboxEntry{ID: 1, RecipientEmail: "ncc@example.com", SupplierName: "Công ty A"})
+	sender := &fakeOrderEmailSender{}
+	outbox := newTestOrderEmailOutbox(store, sender, 3)
+
+	result, err := outbox.ProcessDue(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessDue() error = %v", err)
+	}
+	if result.Sent != 1 || result.Retried != 0 || result.Failed != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(sender.placed) != 1 || len(sender.placed[0]) != 2 || sender.placed[0][1].Index != 2 {
+		t.Fatalf("sent items = %+v", sender.placed)
+	}
+	entry := store.entries[1]
+	if entry.Status != models.OrderEmailStatusSent || entry.Attempts != 1 {
+		t.Fatalf("entry = %+v", entry)
+	}
+}
+
+func TestOrderEmailOutboxSendsQueuedAmendment(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryOrderEmailOutboxStore(models.OrderEmailOutboxEntry{ID: 2, Kind: models.OrderEmailKindOrderAmendment, RecipientEmail: "ncc@example.com", SupplierName: "Công ty A"})
+	sender := &fakeOrderEmailSender{}
+	outbox := newTestOrderEmailOutbox(store, sender, 3)
+
+	if _, err := outbox.ProcessDue(context.Background()); err != nil {
+		t.Fatalf("ProcessDue() error = %v", err)
+	}
+	if len(sender.placed) != 0 || len(sender.amendment) != 1 || len(sender.amendment[0]) != 1 {
+		t.Fatalf("placed = %+v, amendment = %+v", sender.placed, sender.amendment)
+	}
+	item := sender.amendment[0][0]
+	if !item.Cancelled || item.SoLuongCu != 12 || item.SoLuongMoi != 0 || item.NgayDatHang != "02/07/2026" {
+		t.Fatalf("amendment item = %+v", item)
+	}
+	if store.entries[2].Status != models.OrderEmailStatusSent {
+		t.Fatalf("entry = %+v", store.entries[2])
+	}
+}
+
+func TestOrderEmailOutboxSkipsEmailWhoseLinesWereCancelled(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryOrderEmailOutboxStore(models.OrderEmailOutboxEntry{ID: 3, RecipientEmail: "ncc@example.com", SupplierName: "Công ty A"})
+	store.cancelled = map[int64]bool{3: true}
+	sender := &fakeOrderEmailSender{}
+	outbox := newTestOrderEmailOutbox(store, sender, 3)
+
+	if _, err := outbox.ProcessDue(context.Background()); err != nil {
+		t.Fatalf("ProcessDue() error = %v", err)
+	}
+	if len(sender.placed) != 0 {
+		t.Fatalf("placed = %+v, want no email for cancelled lines", sender.placed)
+	}
+	if store.entries[3].Status != models.OrderEmailStatusSent {
+		t.Fatalf("entry = %+v", store.entries[3])
+	}
+}
+
+func TestOrderEmailOutboxRetriesWithBackoffThenFails(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryOrderEmailOutboxStore(models.OrderEmailOutboxEntry{ID: 7, RecipientEmail: "ncc@example.com"})
+	sender := &fakeOrderEmailSender{err: errors.New("smtp unavailable")}
+	outbox := newTestOrderEmailOutbox(store, sender, 3)
+
+	wantDelays := []time.Duration{time.Minute, 2 * time.Minute}
+	for attempt, wantDelay := range wantDelays {
+		result, err := outbox.ProcessDue(context.Background())
+		if err != nil {
+			t.Fatalf("attempt %d: ProcessDue() error = %v", attempt+1, err)
+		}
+		if result.Retried != 1 {
+			t.Fatalf("attempt %d: result = %+v", attempt+1, result)
+		}
+
+		entry := store.entries[7]
+		if entry.Status != models.OrderEmailStatusPending || entry.LastError != "smtp unavailable" {
+			t.Fatalf("attempt %d: entry = %+v", attempt+1, entry)
+		}
+		if delay := entry.NextAttemptAt.Sub(store.now); delay != wantDelay {
+			t.Fatalf("attempt %d: retry delay = %s, want %s", attempt+1, delay, wantDelay)
+		}
+
+		if result, _ := outbox.ProcessDue(context.Background()); result != (OrderEmailOutboxResult{}) {
+			t.Fatalf("attempt %d: email retried before its backoff elapsed: %+v", attempt+1, result)
+		}
+		store.now = entry.NextAttemptAt
+	}
+
+	result, err := outbox.ProcessDue(context.Background())
+	if err != nil {
+		t.Fatalf("final attempt: ProcessDue() error = %v", err)
+	}
+	if result.Failed != 1 {
+		t.Fatalf("final attempt: result = %+v", result)
+	}
+	if entry := store.entries[7]; entry.Status != models.OrderEmailStatusFailed || entry.Attempts != 3 {
+		t.Fatalf("final entry = %+v", entry)
+	}
+
+	sender.err = nil
+	if err := outbox.Resend(7); err != nil {
+		t.Fatalf("Resend() error = %v", err)
+	}
+	if result, _ := outbox.ProcessDue(context.Background()); result.Sent != 1 {
+		t.Fatalf("after resend: result = %+v", result)
+	}
+}
+
+func TestOrderEmailOutboxRetryDelayIsCapped(t *testing.T) {
+	t.Parallel()
+
+	outbox := NewOrderEmailOutbox(OrderEmailOutboxConfig{RetryBase: time.Minute, RetryMax: 10 * time.Minute})
+	if got := outbox.retryDelay(3); got != 4*time.Minute {
+		t.Fatalf("retryDelay(3) = %s", got)
+	}
+	if got := outbox.retryDelay(8); got != 10*time.Minute {
+		t.Fatalf("retryDelay(8) = %s", got)
+	}
+}
+
+func newTestOrderEmailOutbox(store *memoryOrderEmailOutboxStore, sender OrderEmailSender, maxAttempts int) *OrderEmailOutbox {
+	outbox := NewOrderEmailOutbox(OrderEmailOutboxConfig{
+		Store:       store,
+		Sender:      sender,
+		MaxAttempts: maxAttempts,
+		RetryBase:   time.Minute,
+	})
+	outbox.now = func() time.Time { return store.now }
+	return outbox
+}
+
+type fakeOrderEmailSender struct {
+	err       error
+	placed    [][]OrderEmailItem
+	amendment [][]OrderAmendmentEmailItem
+}
+
+func (s *fakeOrderEmailSender) SendPlacedOrderEmail(recipientEmail, supplierName, documentSnapshot string, items []OrderEmailItem) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.placed = append(s.placed, items)
+	return nil
+}
+
+func (s *fakeOrderEmailSender) SendOrderAmendmentEmail(recipientEmail, supplierName string, items []OrderAmendmentEmailItem) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.amendment = append(s.amendment, items)
+	return nil
+}
+
+type memoryOrderEmailOutboxStore struct {
+	now       time.Time
+	entries   map[int64]*models.OrderEmailOutboxEntry
+	cancelled map[int64]bool
+}
+
+func newMemoryOrderEmailOutboxStore(entries ...models.OrderEmailOutboxEntry) *memoryOrderEmailOutboxStore {
+	store := &memoryOrderEmailOutboxStore{
+		now:     time.Date(2026, 7, 1, 8, 0, 0, 0, time.UTC),
+		entries: make(map[int64]*models.OrderEmailOutboxEntry),
+	}
+	for _, entry := range entries {
+		entry := entry
+		entry.Status = models.OrderEmailStatusPending
+		entry.NextAttemptAt = store.now
+		store.entries[entry.ID] = &entry
+	}
+	return store
+}
+
+func (s *memoryOrderEmailOutboxStore) ClaimDueOrderEmails(now time.Time, limit int) ([]models.OrderEmailOutboxEntry, error) {
+	claimed := make([]models.OrderEmailOutboxEntry, 0)
+	for _, entry := range s.entries {
+		if entry.Status != models.OrderEmailStatusPending || entry.NextAttemptAt.After(now) || len(claimed) >= limit {
+			continue
+		}
+		entry.Status = models.OrderEmailStatusSending
+		claimed = append(claimed, *entry)
+	}
+	return claimed, nil
+}
+
+func (s *memoryOrderEmailOutboxStore) ListOrderEmailLines(outboxID int64) ([]models.OrderEmailLine, error) {
+	if s.cancelled[outboxID] {
+		return []models.OrderEmailLine{}, nil
+	}
+	lines := make([]models.OrderEmailLine, 2)
+	lines[0].TenVtytBv = "Bơm kim tiêm"
+	lines[0].DotGoiHang = 25
+	lines[1].TenVtytBv = "Dây truyền dịch"
+	lines[1].DotGoiHang = 10
+	return lines, nil
+}
+
+func (s *memoryOrderEmailOutboxStore) ListOrderAmendmentEmailLines(outboxID int64) ([]models.OrderAmendmentEmailLine, error) {
+	var line models.OrderAmendmentEmailLine
+	line.Line.TenVtytBv = "Bơm kim tiêm"
+	line.Line.NgayDatHang = "2026-07-01T20:00:00Z"
+	line.Amendment = models.OrderHistoryAmendment{Action: models.OrderAmendmentActionCancel, OldQty: 12, Reason: "Khoa hủy"}
+	return []models.OrderAmendmentEmailLine{line}, nil
+}
+
+func (s *memoryOrderEmailOutboxStore) MarkOrderEmailSent(outboxID int64, attempts int, sentAt time.Time) error {
+	entry := s.entries[outboxID]
+	entry.Status = models.OrderEmailStatusSent
+	entry.Attempts = attempts
+	entry.SentAt = &sentAt
+	entry.LastError = ""
+	return nil
+}
+
+func (s *memoryOrderEmailOutboxStore) MarkOrderEmailFailed(outboxID int64, attempts int, nextAttemptAt *time.Time, lastError string) error {
+	entry := s.entries[outboxID]
+	entry.Attempts = attempts
+	entry.LastError = lastError
+	if nextAttemptAt == nil {
+		entry.Status = models.OrderEmailStatusFailed
+		return nil
+	}
+	entry.Status = models.OrderEmailStatusPending
+	entry.NextAttemptAt = *nextAttemptAt
+	return nil
+}
+
+func (s *memoryOrderEmailOutboxStore) RequeueOrderEmail(outboxID int64) error {
+	entry, exists := s.entries[outboxID]
+	if !exists {
+		return models.ErrOrderEmailNotFound
+	}
+	if entry.Status == models.OrderEmailStatusSending {
+		return models.ErrOrderEmailInFlight
+	}
+	entry.Status = models.OrderEmailStatusPending
+	entry.Attempts = 0
+	entry.NextAttemptAt = s.now
+	entry.LastError = ""
+	return nil
+}
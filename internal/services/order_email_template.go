@@ -0,0 +1,322 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+var (
+	ErrOrderEmailTemplateNotFound = errors.New("order email template not found")
+	ErrInvalidOrderEmailTemplate  = errors.New("invalid order email template")
+)
+
+// orderEmailCompanyPlaceholder is replaced with the supplier name in the
+// email subject and body.
+const orderEmailCompanyPlaceholder = "[Tên công ty cung cấp]"
+
+type OrderEmailTemplateStore interface {
+	ListOrderEmailTemplates() ([]models.OrderEmailTemplate, error)
+	GetOrderEmailTemplate(id int64) (*models.OrderEmailTemplate, error)
+	FindOrderEmailTemplate(supplierKey string) (*models.OrderEmailTemplate, error)
+	CreateOrderEmailTemplate(template *models.OrderEmailTemplate) error
+	UpdateOrderEmailTemplate(template *models.OrderEmailTemplate) (bool, error)
+	DeleteOrderEmailTemplate(id int64) (bool, error)
+}
+
+// orderEmailTemplate is a fully resolved template: the built-in wording
+// overlaid with the stored default and then with the supplier's own row.
+type orderEmailTemplate struct {
+	Subject      string
+	Body         string
+	Letter       orderPDFLetter
+	ContactName  string
+	ContactTitle string
+	ContactDept  string
+	ContactPhone string
+	ExtraColumns []string
+}
+
+var builtinOrderEmailTemplate = orderEmailTemplate{
+	Subject:      placedOrderEmailSubject,
+	Body:         placedOrderEmailBody,
+	Letter:       placedOrderPDFLetter,
+	ContactName:  "Nguyễn Thành Trung",
+	ContactTitle: "PCNK Trang bị",
+	ContactDept:  "Khoa Trang bị",
+	ContactPhone: "0988335388",
+}
+
+func (t orderEmailTemplate) overlay(stored *models.OrderEmailTemplate) orderEmailTemplate {
+	if stored == nil {
+		return t
+	}
+
+	t.Subject = firstNonEmptyTemplateText(stored.Subject, t.Subject)
+	t.Body = firstNonEmptyTemplateText(stored.Body, t.Body)
+	t.Letter.Title = firstNonEmptyTemplateText(stored.PDFTitle, t.Letter.Title)
+	t.Letter.Intro = firstNonEmptyTemplateText(stored.PDFIntro, t.Letter.Intro)
+	t.Letter.Request = firstNonEmptyTemplateText(stored.PDFRequest, t.Letter.Request)
+	t.ContactName = firstNonEmptyTemplateText(stored.SignatoryName, t.ContactName)
+	t.ContactTitle = firstNonEmptyTemplateText(stored.SignatoryTitle, t.ContactTitle)
+	t.ContactDept = firstNonEmptyTemplateText(stored.SignatoryDept, t.ContactDept)
+	t.ContactPhone = firstNonEmptyTemplateText(stored.SignatoryPhone, t.ContactPhone)
+	if stored.ExtraColumns != nil {
+		t.ExtraColumns = slices.Clone(stored.ExtraColumns)
+	}
+	return t
+}
+
+func (t orderEmailTemplate) document(supplierName string, items []OrderEmailItem) placedOrderDocumentData {
+	return placedOrderDocumentData{
+		CompanyName:  supplierName,
+		CurrentMonth: currentOrderMonth(),
+		CurrentDate:  currentOrderDate(),
+		ContactName:  t.ContactName,
+		ContactDept:  t.ContactDept,
+		ContactTitle: t.ContactTitle,
+		ContactPhone: t.ContactPhone,
+		Letter:       t.Letter,
+		ExtraColumns: t.ExtraColumns,
+		Items:        items,
+	}
+}
+
+// placedOrderSnapshot is the resolved template and order date a placed-order
+// email is rendered with, stored on the outbox row when the order is placed.
+type placedOrderSnapshot struct {
+	Template   orderEmailTemplate `json:"template"`
+	OrderMonth string             `json:"orderMonth"`
+	OrderDate  string             `json:"orderDate"`
+}
+
+func (p placedOrderSnapshot) document(supplierName string, items []OrderEmailItem) placedOrderDocumentData {
+	data := p.Template.document(supplierName, items)
+	data.CurrentMonth = p.OrderMonth
+	data.CurrentDate = p.OrderDate
+	return data
+}
+
+func firstNonEmptyTemplateText(value, fallback string) string {
+	if value = strings.TrimSpace(value); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func renderOrderEmailTemplateText(text, companyName string) string {
+	companyName = strings.TrimSpace(companyName)
+	if companyName == "" {
+		companyName = "Quý công ty"
+	}
+	return strings.ReplaceAll(text, orderEmailCompanyPlaceholder, companyName)
+}
+
+// OrderEmailTemplateService manages the admin-edited order email templates
+// and resolves the one that applies to a supplier.
+type OrderEmailTemplateService struct {
+	store OrderEmailTemplateStore
+}
+
+func NewOrderEmailTemplateService(store OrderEmailTemplateStore) *OrderEmailTemplateService {
+	return &OrderEmailTemplateService{store: store}
+}
+
+// resolve falls back to the built-in template when no store is configured.
+func (s *OrderEmailTemplateService) resolve(supplierName string) (orderEmailTemplate, error) {
+	template := builtinOrderEmailTemplate
+	if s == nil || s.store == nil {
+		return template, nil
+	}
+
+	stored, err := s.store.FindOrderEmailTemplate("")
+	if err != nil {
+		return template, err
+	}
+	template = template.overlay(stored)
+
+	supplierKey := normalizeLookup(supplierName)
+	if supplierKey == "" {
+		return template, nil
+	}
+	stored, err = s.store.FindOrderEmailTemplate(supplierKey)
+	if err != nil {
+		return template, err
+	}
+	return template.overlay(stored), nil
+}
+
+// SnapshotPlacedOrder captures the template that applies to supplierName and
+// today's date. It matches models.OrderDocumentSnapshotFunc.
+func (s *OrderEmailTemplateService) SnapshotPlacedOrder(supplierName string) (string, error) {
+	template, err := s.resolve(strings.TrimSpace(supplierName))
+	if err != nil {
+		return "", fmt.Errorf("error loading order email template: %w", err)
+	}
+
+	encoded, err := json.Marshal(placedOrderSnapshot{Template: template, OrderMonth: currentOrderMonth(), OrderDate: currentOrderDate()})
+	if err != nil {
+		return "", fmt.Errorf("error encoding order document snapshot: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// placedOrder decodes a stored snapshot. Rows placed before snapshots were
+// stored fall back to the current template and date.
+func (s *OrderEmailTemplateService) placedOrder(supplierName, documentSnapshot string) (placedOrderSnapshot, error) {
+	if documentSnapshot = strings.TrimSpace(documentSnapshot); documentSnapshot != "" {
+		var snapshot placedOrderSnapshot
+		if err := json.Unmarshal([]byte(documentSnapshot), &snapshot); err != nil {
+			return placedOrderSnapshot{}, fmt.Errorf("error decoding order document snapshot: %w", err)
+		}
+		return snapshot, nil
+	}
+
+	template, err := s.resolve(supplierName)
+	if err != nil {
+		return placedOrderSnapshot{}, fmt.Errorf("error loading order email template: %w", err)
+	}
+	return placedOrderSnapshot{Template: template, OrderMonth: currentOrderMonth(), OrderDate: currentOrderDate()}, nil
+}
+
+// RenderPlacedOrderPreview renders the placed-order PDF a supplier would
+// receive, optionally with an unsaved draft laid over the stored templates.
+// Without lines the table shows a placeholder row.
+func (s *OrderEmailTemplateService) RenderPlacedOrderPreview(supplierName string, draft *models.OrderEmailTemplate, lines []models.OrderEmailLine) ([]byte, error) {
+	supplierName = strings.TrimSpace(supplierName)
+	if draft != nil {
+		if err := prepareOrderEmailTemplate(draft, models.OrderActor{}); err != nil {
+			return nil, err
+		}
+		if supplierName == "" {
+			supplierName = draft.SupplierName
+		}
+	}
+
+	template, err := s.resolve(supplierName)
+	if err != nil {
+		return nil, fmt.Errorf("error loading order email template: %w", err)
+	}
+	template = template.overlay(draft)
+
+	pdfBytes, err := renderPlacedOrderAttachmentPDF(template.document(supplierName, buildOrderEmailItems(lines)))
+	if err != nil {
+		return nil, fmt.Errorf("error rendering order PDF preview: %w", err)
+	}
+	return pdfBytes, nil
+}
+
+func (s *OrderEmailTemplateService) ListTemplates() ([]models.OrderEmailTemplate, error) {
+	if s == nil || s.store == nil {
+		return nil, fmt.Errorf("order email template store is not configured")
+	}
+	return s.store.ListOrderEmailTemplates()
+}
+
+func (s *OrderEmailTemplateService) CreateTemplate(template *models.OrderEmailTemplate, actor models.OrderActor) (*models.OrderEmailTemplate, error) {
+	if s == nil || s.store == nil {
+		return nil, fmt.Errorf("order email template store is not configured")
+	}
+	if err := prepareOrderEmailTemplate(template, actor); err != nil {
+		return nil, err
+	}
+	if err := s.store.CreateOrderEmailTemplate(template); err != nil {
+		return nil, err
+	}
+	return s.getTemplate(template.ID)
+}
+
+func (s *OrderEmailTemplateService) UpdateTemplate(template *models.OrderEmailTemplate, actor models.OrderActor) (*models.OrderEmailTemplate, error) {
+	if s == nil || s.store == nil {
+		return nil, fmt.Errorf("order email template store is not configured")
+	}
+	if err := prepareOrderEmailTemplate(template, actor); err != nil {
+		return nil, err
+	}
+	updated, err := s.store.UpdateOrderEmailTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrOrderEmailTemplateNotFound
+	}
+	return s.getTemplate(template.ID)
+}
+
+func (s *OrderEmailTemplateService) getTemplate(id int64) (*models.OrderEmailTemplate, error) {
+	template, err := s.store.GetOrderEmailTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrOrderEmailTemplateNotFound
+	}
+	return template, nil
+}
+
+func (s *OrderEmailTemplateService) DeleteTemplate(id int64) error {
+	if s == nil || s.store == nil {
+		return fmt.Errorf("order email template store is not configured")
+	}
+	deleted, err := s.store.DeleteOrderEmailTemplate(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrOrderEmailTemplateNotFound
+	}
+	return nil
+}
+
+func prepareOrderEmailTemplate(template *models.OrderEmailTemplate, actor models.OrderActor) error {
+	if template == nil {
+		return fmt.Errorf("%w: template is required", ErrInvalidOrderEmailTemplate)
+	}
+
+	template.SupplierName = strings.TrimSpace(template.SupplierName)
+	template.SupplierKey = normalizeLookup(template.SupplierName)
+	template.Subject = strings.TrimSpace(template.Subject)
+	template.Body = strings.TrimSpace(template.Body)
+	template.PDFTitle = strings.TrimSpace(template.PDFTitle)
+	template.PDFIntro = strings.TrimSpace(template.PDFIntro)
+	template.PDFRequest = strings.TrimSpace(template.PDFRequest)
+	template.SignatoryName = strings.TrimSpace(template.SignatoryName)
+	template.SignatoryTitle = strings.TrimSpace(template.SignatoryTitle)
+	template.SignatoryDept = strings.TrimSpace(template.SignatoryDept)
+	template.SignatoryPhone = strings.TrimSpace(template.SignatoryPhone)
+
+	if template.SupplierName != "" && template.SupplierKey == "" {
+		return fmt.Errorf("%w: supplierName must contain letters or digits", ErrInvalidOrderEmailTemplate)
+	}
+	if len([]rune(template.Subject)) > 500 {
+		return fmt.Errorf("%w: subject must be at most 500 characters", ErrInvalidOrderEmailTemplate)
+	}
+	if len(template.SignatoryPhone) > 50 {
+		return fmt.Errorf("%w: signatoryPhone must be at most 50 characters", ErrInvalidOrderEmailTemplate)
+	}
+
+	if template.ExtraColumns != nil {
+		columns := make([]string, 0, len(template.ExtraColumns))
+		for _, column := range template.ExtraColumns {
+			column = strings.ToLower(strings.TrimSpace(column))
+			if _, ok := orderPDFExtraTableColumns[column]; !ok {
+				return fmt.Errorf("%w: extraColumns may only contain %s or %s", ErrInvalidOrderEmailTemplate, models.OrderEmailColumnUnitPrice, models.OrderEmailColumnTenderPackage)
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+		template.ExtraColumns = columns
+	}
+
+	template.UpdatedByUserID = nil
+	if actor.ID > 0 {
+		template.UpdatedByUserID = int64Pointer(actor.ID)
+	}
+	template.UpdatedByUsername = actor.Username
+	return nil
+}
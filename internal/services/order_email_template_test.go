@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+func TestOrderEmailTemplateResolveLayersSupplierOverDefault(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryOrderEmailTemplateStore(
+		models.OrderEmailTemplate{SupplierKey: "", SignatoryName: "Trần Văn A", SignatoryPhone: "0911000000", ExtraColumns: []string{models.OrderEmailColumnUnitPrice}},
+		models.OrderEmailTemplate{SupplierKey: normalizeLookup("Công ty Thiết bị Y tế A"), Subject: "ĐƠN HÀNG GỬI [Tên công ty cung cấp]", ExtraColumns: []string{}},
+	)
+	service := NewOrderEmailTemplateService(store)
+
+	template, err := service.resolve("  CÔNG TY THIẾT BỊ Y TẾ A ")
+	if err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if template.ContactName != "Trần Văn A" || template.ContactPhone != "0911000000" {
+		t.Fatalf("default signatory not applied: %+v", template)
+	}
+	if template.ContactDept != builtinOrderEmailTemplate.ContactDept || template.Body != placedOrderEmailBody {
+		t.Fatalf("blank fields did not fall back to built-in text: %+v", template)
+	}
+	if got := renderOrderEmailTemplateText(template.Subject, "Công ty A"); got != "ĐƠN HÀNG GỬI Công ty A" {
+		t.Fatalf("subject = %q", got)
+	}
+	if template.ExtraColumns == nil || len(template.ExtraColumns) != 0 {
+		t.Fatalf("supplier should clear inherited extra columns, got %v", template.ExtraColumns)
+	}
+
+	other, err := service.resolve("Công ty B")
+	if err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if other.Subject != placedOrderEmailSubject || len(other.ExtraColumns) != 1 {
+		t.Fatalf("supplier without override = %+v", other)
+	}
+}
+
+func TestOrderEmailTemplateResolveWithoutStoreUsesBuiltin(t *testing.T) {
+	t.Parallel()
+
+	var service *OrderEmailTemplateService
+	template, err := service.resolve("Công ty A")
+	if err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if template.Subject != placedOrderEmailSubject || template.ContactName != "Nguyễn Thành Trung" || template.Letter != placedOrderPDFLetter {
+		t.Fatalf("template = %+v", template)
+	}
+}
+
+func TestPrepareOrderEmailTemplateValidatesExtraColumns(t *testing.T) {
+	t.Parallel()
+
+	template := &models.OrderEmailTemplate{
+		SupplierName: " Công ty A ",
+		ExtraColumns: []string{" Tender_Package ", models.OrderEmailColumnUnitPrice, models.OrderEmailColumnTenderPackage},
+	}
+	if err := prepareOrderEmailTemplate(template, models.OrderActor{ID: 3, Username: "admin"}); err != nil {
+		t.Fatalf("prepareOrderEmailTemplate() error = %v", err)
+	}
+	if template.SupplierKey != "cong ty a" {
+		t.Fatalf("supplierKey = %q", template.SupplierKey)
+	}
+	if len(template.ExtraColumns) != 2 || template.ExtraColumns[0] != models.OrderEmailColumnTenderPackage {
+		t.Fatalf("extraColumns = %v", template.ExtraColumns)
+	}
+	if template.UpdatedByUserID == nil || *template.UpdatedByUserID != 3 {
+		t.Fatalf("updatedByUserID = %v", template.UpdatedByUserID)
+	}
+
+	invalid := &models.OrderEmailTemplate{ExtraColumns: []string{"vat_rate"}}
+	if err := prepareOrderEmailTemplate(invalid, models.OrderActor{}); !errors.Is(err, ErrInvalidOrderEmailTemplate) {
+		t.Fatalf("prepareOrderEmailTemplate() error = %v, want ErrInvalidOrderEmailTemplate", err)
+	}
+}
+
+func TestPlacedOrderPDFTableColumnsWithExtras(t *testing.T) {
+	t.Parallel()
+
+	columns := placedOrderPDFTableColumns([]string{models.OrderEmailColumnUnitPrice, models.OrderEmailColumnTenderPackage})
+	if len(columns) != len(orderPDFTableColumns)+2 {
+		t.Fatalf("column count = %d", len(columns))
+	}
+	width := 0.0
+	for _, column := range columns {
+		width += column.width
+	}
+	if math.Abs(width-180) > 0.001 {
+		t.Fatalf("table width = %.3fmm, want 180mm", width)
+	}
+	if orderPDFTableColumns[1].width != 38 {
+		t.Fatal("scaling modified the base column layout")
+	}
+
+	values := placedOrderPDFTableValues(OrderEmailItem{Index: 1, SoLuong: 5, DonGia: 1250000.4, SoGoiThau: "GT-05"}, []string{models.OrderEmailColumnUnitPrice, models.OrderEmailColumnTenderPackage})
+	if got := values[len(values)-2:]; got[0] != "1.250.000" || got[1] != "GT-05" {
+		t.Fatalf("extra values = %v", got)
+	}
+	if got := formatOrderPDFAmount(0); got != "-" {
+		t.Fatalf("formatOrderPDFAmount(0) = %q", got)
+	}
+}
+
+type memoryOrderEmailTemplateStore struct {
+	templates []models.OrderEmailTemplate
+}
+
+func newMemoryOrderEmailTemplateStore(templates ...models.OrderEmailTemplate) *memoryOrderEmailTemplateStore {
+	for index := range templates {
+		templates[index].ID = int64(index + 1)
+	}
+	return &memoryOrderEmailTemplateStore{templates: templates}
+}
+
+func (s *memoryOrderEmailTemplateStore) ListOrderEmailTemplates() ([]models.OrderEmailTemplate, error) {
+	return s.templates, nil
+}
+
+func (s *memoryOrderEmailTemplateStore) GetOrderEmailTemplate(id int64) (*models.OrderEmailTemplate, error) {
+	for index := range s.templates {
+		if s.templates[index].ID == id {
+			return &s.templates[index], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryOrderEmailTemplateStore) FindOrderEmailTemplate(supplierKey string) (*models.OrderEmailTemplate, error) {
+	for index := range s.templates {
+		if s.templates[index].SupplierKey == supplierKey {
+			return &s.templates[index], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryOrderEmailTemplateStore) CreateOrderEmailTemplate(template *models.OrderEmailTemplate) error {
+	template.ID = int64(len(s.templates) + 1)
+	s.templates = append(s.templates, *template)
+	return nil
+}
+
+func (s *memoryOrderEmailTemplateStore) UpdateOrderEmailTemplate(template *models.OrderEmailTemplate) (bool, error) {
+	for index := range s.templates {
+		if s.templates[index].ID == template.ID {
+			s.templates[index] = *template
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memoryOrderEmailTemplateStore) DeleteOrderEmailTemplate(id int64) (bool, error) {
+	for index := range s.templates {
+		if s.templates[index].ID == id {
+			s.templates = append(s.templates[:index], s.templates[index+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type OrderLifecycleStore interface {
+	SyncOrderLifecycle(now time.Time) (int, error)
+}
+
+type OrderLifecycleSyncConfig struct {
+	Store           OrderLifecycleStore
+	IntervalMinutes int
+}
+
+// OrderLifecycleSync periodically recomputes delivered quantities and
+// lifecycle statuses of placed order lines, so lines turn overdue without
+// a read having to rewrite order_history.
+type OrderLifecycleSync struct {
+	store    OrderLifecycleStore
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewOrderLifecycleSync(cfg OrderLifecycleSyncConfig) *OrderLifecycleSync {
+	return &OrderLifecycleSync{
+		store:    cfg.Store,
+		interval: time.Duration(cfg.IntervalMinutes) * time.Minute,
+		now:      time.Now,
+	}
+}
+
+func (s *OrderLifecycleSync) Start(ctx context.Context) {
+	if s == nil || s.interval <= 0 {
+		log.Println("[order-lifecycle] disabled by ORDER_LIFECYCLE_SYNC_INTERVAL_MINUTES")
+		return
+	}
+	if s.store == nil {
+		log.Println("[order-lifecycle] skipped because the order repository is not configured")
+		return
+	}
+
+	go s.run(ctx)
+}
+
+func (s *OrderLifecycleSync) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if changed, err := s.store.SyncOrderLifecycle(s.now()); err != nil {
+			log.Printf("[order-lifecycle] sync failed: %v", err)
+		} else if changed > 0 {
+			log.Printf("[order-lifecycle] %d order lines updated", changed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type signalingOrderLifecycleStore struct {
+	synced chan time.Time
+}
+
+func (s *signalingOrderLifecycleStore) SyncOrderLifecycle(now time.Time) (int, error) {
+	s.synced <- now
+	return 0, nil
+}
+
+func TestOrderLifecycleSyncRunsOnStart(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	store := &signalingOrderLifecycleStore{synced: make(chan time.Time, 1)}
+	sync := NewOrderLifecycleSync(OrderLifecycleSyncConfig{Store: store, IntervalMinutes: 30})
+	sync.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sync.Start(ctx)
+
+	select {
+	case got := <-store.synced:
+		if !got.Equal(now) {
+			t.Fatalf("synced at %s, want %s", got, now)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lifecycle was not synced on start")
+	}
+}
+
+func TestOrderLifecycleSyncDisabledWithoutInterval(t *testing.T) {
+	store := &signalingOrderLifecycleStore{synced: make(chan time.Time, 1)}
+	NewOrderLifecycleSync(OrderLifecycleSyncConfig{Store: store}).Start(context.Background())
+
+	select {
+	case <-store.synced:
+		t.Fatal("lifecycle synced with a zero interval")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
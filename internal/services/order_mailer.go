@@ -16,7 +16,8 @@ import (
 )
 
 type OrderEmailSender interface {
-	SendPlacedOrderEmail(recipientEmail, supplierName string, items []OrderEmailItem) error
+	SendPlacedOrderEmail(recipientEmail, supplierName, documentSnapshot string, items []OrderEmailItem) error
+	SendOrderAmendmentEmail(recipientEmail, supplierName string, items []OrderAmendmentEmailItem) error
 }
 
 type OrderEmailItem struct {
@@ -28,6 +29,8 @@ type OrderEmailItem struct {
 	DonViTinh    string
 	SoLuong      int
 	DotGiaoHang  string
+	DonGia       float64
+	SoGoiThau    string
 }
 
 type SMTPOrderMailer struct {
@@ -37,6 +40,7 @@ type SMTPOrderMailer struct {
 	appPassword string
 	from        string
 	tlsPolicy   gomail.TLSPolicy
+	templates   *OrderEmailTemplateService
 }
 
 type SMTPOrderMailerConfig struct {
@@ -46,6 +50,7 @@ type SMTPOrderMailerConfig struct {
 	AppPassword string
 	From        string
 	TLSPolicy   string
+	Templates   *OrderEmailTemplateService
 }
 
 func NewSMTPOrderMailer(cfg SMTPOrderMailerConfig) *SMTPOrderMailer {
@@ -56,6 +61,7 @@ func NewSMTPOrderMailer(cfg SMTPOrderMailerConfig) *SMTPOrderMailer {
 		appPassword: strings.ReplaceAll(strings.TrimSpace(cfg.AppPassword), " ", ""),
 		from:        strings.TrimSpace(cfg.From),
 		tlsPolicy:   resolveSMTPTLSPolicy(cfg.TLSPolicy),
+		templates:   cfg.Templates,
 	}
 }
 
@@ -74,10 +80,39 @@ const (
 	orderPDFSectionSpacing    = 6.0
 )
 
-func (m *SMTPOrderMailer) SendPlacedOrderEmail(recipientEmail, supplierName string, items []OrderEmailItem) error {
+// SendPlacedOrderEmail renders the email and its PDF from documentSnapshot,
+// the template captured when the order was placed.
+func (m *SMTPOrderMailer) SendPlacedOrderEmail(recipientEmail, supplierName, documentSnapshot string, items []OrderEmailItem) error {
 	supplierName = strings.TrimSpace(supplierName)
 	recipientEmail = strings.TrimSpace(recipientEmail)
 
+	if err := m.validateOrderEmail(recipientEmail, supplierName); err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		if supplierName == "" {
+			return fmt.Errorf("missing order items for email")
+		}
+		return fmt.Errorf("missing order items for %s", supplierName)
+	}
+
+	snapshot, err := m.templates.placedOrder(supplierName, documentSnapshot)
+	if err != nil {
+		return err
+	}
+
+	pdfBytes, err := renderPlacedOrderAttachmentPDF(snapshot.document(supplierName, items))
+	if err != nil {
+		return fmt.Errorf("error rendering order PDF attachment: %w", err)
+	}
+
+	subject := renderOrderEmailTemplateText(snapshot.Template.Subject, supplierName)
+	body := renderOrderEmailTemplateText(snapshot.Template.Body, supplierName)
+	return m.sendOrderDocument(recipientEmail, supplierName, subject, body, placedOrderAttachmentName, pdfBytes)
+}
+
+func (m *SMTPOrderMailer) validateOrderEmail(recipientEmail, supplierName string) error {
 	if recipientEmail == "" {
 		if supplierName == "" {
 			return fmt.Errorf("missing company email")
@@ -100,34 +135,13 @@ func (m *SMTPOrderMailer) SendPlacedOrderEmail(recipientEmail, supplierName stri
 		return fmt.Errorf("invalid SMTP_FROM address: %w", err)
 	}
 
-	if len(items) == 0 {
-		if supplierName == "" {
-			return fmt.Errorf("missing order items for email")
-		}
-		return fmt.Errorf("missing order items for %s", supplierName)
-	}
-
-	port, err := strconv.Atoi(m.port)
-	if err != nil || port <= 0 {
-		return fmt.Errorf("invalid SMTP_PORT: %s", m.port)
-	}
-
-	options := []gomail.Option{
-		gomail.WithPort(port),
-		gomail.WithTLSPolicy(m.tlsPolicy),
-	}
-
-	if m.username != "" || m.appPassword != "" {
-		options = append(options,
-			gomail.WithSMTPAuth(gomail.SMTPAuthPlain),
-			gomail.WithUsername(m.username),
-			gomail.WithPassword(m.appPassword),
-		)
-	}
+	return nil
+}
 
-	client, err := gomail.NewClient(m.host, options...)
+func (m *SMTPOrderMailer) sendOrderDocument(recipientEmail, supplierName, subject, body, attachmentName string, pdfBytes []byte) error {
+	client, err := newSMTPClient(m.host, m.port, m.username, m.appPassword, m.tlsPolicy)
 	if err != nil {
-		return fmt.Errorf("error creating go-mail client: %w", err)
+		return err
 	}
 
 	message := gomail.NewMsg()
@@ -137,34 +151,13 @@ func (m *SMTPOrderMailer) SendPlacedOrderEmail(recipientEmail, supplierName stri
 	if err := message.To(recipientEmail); err != nil {
 		return fmt.Errorf("error setting TO address: %w", err)
 	}
-	message.Subject(placedOrderEmailSubject)
-
-	document := placedOrderDocumentData{
-		CompanyName:  supplierName,
-		CurrentMonth: currentOrderMonth(),
-		CurrentDate:  currentOrderDate(),
-		ContactName:  "Nguyễn Thành Trung",
-		ContactDept:  "Khoa Trang bị",
-		ContactTitle: "PCNK Trang bị",
-		ContactPhone: "0988335388",
-		Items:        items,
-	}
-
-	pdfBytes, err := renderPlacedOrderAttachmentPDF(document)
-	if err != nil {
-		return fmt.Errorf("error rendering order PDF attachment: %w", err)
-	}
-
-	body, err := renderPlacedOrderEmailBody(supplierName)
-	if err != nil {
-		return fmt.Errorf("error rendering email body: %w", err)
-	}
+	message.Subject(subject)
 	message.SetBodyString(gomail.TypeTextPlain, body)
 	if err := message.AttachReader(
-		placedOrderAttachmentName,
+		attachmentName,
 		bytes.NewReader(pdfBytes),
 		gomail.WithFileContentType(placedOrderPDFContentType),
-		gomail.WithFileName(placedOrderAttachmentName),
+		gomail.WithFileName(attachmentName),
 	); err != nil {
 		return fmt.Errorf("error attaching order PDF: %w", err)
 	}
@@ -179,6 +172,32 @@ func (m *SMTPOrderMailer) SendPlacedOrderEmail(recipientEmail, supplierName stri
 	return nil
 }
 
+func newSMTPClient(host, port, username, appPassword string, tlsPolicy gomail.TLSPolicy) (*gomail.Client, error) {
+	portNumber, err := strconv.Atoi(port)
+	if err != nil || portNumber <= 0 {
+		return nil, fmt.Errorf("invalid SMTP_PORT: %s", port)
+	}
+
+	options := []gomail.Option{
+		gomail.WithPort(portNumber),
+		gomail.WithTLSPolicy(tlsPolicy),
+	}
+
+	if username != "" || appPassword != "" {
+		options = append(options,
+			gomail.WithSMTPAuth(gomail.SMTPAuthPlain),
+			gomail.WithUsername(username),
+			gomail.WithPassword(appPassword),
+		)
+	}
+
+	client, err := gomail.NewClient(host, options...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating go-mail client: %w", err)
+	}
+	return client, nil
+}
+
 func resolveSMTPTLSPolicy(value string) gomail.TLSPolicy {
 	switch strings.ToLower(strings.TrimSpace(value)) {
 	case "opportunistic", "tlsopportunistic":
@@ -198,19 +217,46 @@ type placedOrderDocumentData struct {
 	ContactDept  string
 	ContactTitle string
 	ContactPhone string
+	Letter       orderPDFLetter
+	ExtraColumns []string
 	Items        []OrderEmailItem
 }
 
-func renderPlacedOrderEmailBody(companyName string) (string, error) {
-	companyName = strings.TrimSpace(companyName)
-	if companyName == "" {
-		companyName = "Quý công ty"
-	}
+// orderPDFLetter holds the wording that differs between the placed-order
+// letter and its variants; the surrounding layout is shared.
+type orderPDFLetter struct {
+	Subject string
+	Title   string
+	Intro   string
+	Request string
+}
 
-	return strings.Replace(placedOrderEmailBody, "[Tên công ty cung cấp]", companyName, 1), nil
+var placedOrderPDFLetter = orderPDFLetter{
+	Subject: "Đơn đặt hàng vật tư",
+	Title:   "ĐƠN ĐẶT HÀNG VẬT TƯ",
+	Intro:   "Khoa Trang bị - Bệnh viện TWQĐ 108 xin gửi đến Quý công ty đơn đặt hàng vật tư như sau:",
+	Request: "Yêu cầu công ty xác nhận lại đơn hàng qua gmail và zalo của cán bộ phụ trách gói thầu trong vòng 1 giờ sau khi tiếp nhận đơn hàng, và tiến hành giao hàng theo đúng đợt giao hàng đã nêu.",
 }
 
 func renderPlacedOrderAttachmentPDF(data placedOrderDocumentData) ([]byte, error) {
+	letter := data.Letter
+	if letter == (orderPDFLetter{}) {
+		letter = placedOrderPDFLetter
+	}
+
+	pdf, err := newOrderPDF(placedOrderEmailSubject, letter)
+	if err != nil {
+		return nil, err
+	}
+
+	renderPlacedOrderPDFHeader(pdf, data, letter)
+	renderPlacedOrderPDFTable(pdf, data.Items, data.ExtraColumns)
+	renderPlacedOrderPDFFooter(pdf, data, letter)
+
+	return outputOrderPDF(pdf)
+}
+
+func newOrderPDF(title string, letter orderPDFLetter) (*gofpdf.Fpdf, error) {
 	regularFontPath, boldFontPath, err := resolveOrderPDFFontPaths()
 	if err != nil {
 		return nil, err
@@ -227,21 +273,20 @@ func renderPlacedOrderAttachmentPDF(data placedOrderDocumentData) ([]byte, error
 	pdf := gofpdf.New("P", "mm", "A4", "")
 	pdf.SetMargins(15, 15, 15)
 	pdf.SetAutoPageBreak(true, 15)
-	pdf.SetTitle(placedOrderEmailSubject, true)
+	pdf.SetTitle(title, true)
 	pdf.SetAuthor("Khoa Trang bị Bệnh viện Quân đội TW 108", true)
 	pdf.SetCreator("BV108 Consumables Management Backend", true)
-	pdf.SetSubject("Đơn đặt hàng vật tư", true)
+	pdf.SetSubject(letter.Subject, true)
 	pdf.SetKeywords("đơn đặt hàng vật tư BV108", true)
 	pdf.SetCreationDate(time.Now())
 
 	pdf.AddUTF8FontFromBytes(orderPDFFontFamily, "", regularFontBytes)
 	pdf.AddUTF8FontFromBytes(orderPDFFontFamily, "B", boldFontBytes)
 	pdf.AddPage()
+	return pdf, nil
+}
 
-	renderPlacedOrderPDFHeader(pdf, data)
-	renderPlacedOrderPDFTable(pdf, data.Items)
-	renderPlacedOrderPDFFooter(pdf, data)
-
+func outputOrderPDF(pdf *gofpdf.Fpdf) ([]byte, error) {
 	var buffer bytes.Buffer
 	if err := pdf.Output(&buffer); err != nil {
 		return nil, err
@@ -249,7 +294,7 @@ func renderPlacedOrderAttachmentPDF(data placedOrderDocumentData) ([]byte, error
 	return buffer.Bytes(), nil
 }
 
-func renderPlacedOrderPDFHeader(pdf *gofpdf.Fpdf, data placedOrderDocumentData) {
+func renderPlacedOrderPDFHeader(pdf *gofpdf.Fpdf, data placedOrderDocumentData, letter orderPDFLetter) {
 	pageWidth, _ := pdf.GetPageSize()
 	leftMargin, _, rightMargin, _ := pdf.GetMargins()
 	contentWidth := pageWidth - leftMargin - rightMargin
@@ -271,7 +316,7 @@ func renderPlacedOrderPDFHeader(pdf *gofpdf.Fpdf, data placedOrderDocumentData)
 	pdf.Ln(orderPDFSectionSpacing + 2)
 
 	pdf.SetFont(orderPDFFontFamily, "B", orderPDFBodyFontSize)
-	pdf.CellFormat(0, 8, "ĐƠN ĐẶT HÀNG VẬT TƯ", "", 1, "C", false, 0, "")
+	pdf.CellFormat(0, 8, letter.Title, "", 1, "C", false, 0, "")
 	pdf.Ln(orderPDFParagraphSpacing)
 
 	pdf.SetFont(orderPDFFontFamily, "", orderPDFBodyFontSize)
@@ -287,11 +332,11 @@ func renderPlacedOrderPDFHeader(pdf *gofpdf.Fpdf, data placedOrderDocumentData)
 		pdf.Ln(orderPDFBodyLineHeight)
 	}
 	pdf.Ln(orderPDFParagraphSpacing)
-	pdf.MultiCell(0, orderPDFBodyLineHeight, "Khoa Trang bị - Bệnh viện TWQĐ 108 xin gửi đến Quý công ty đơn đặt hàng vật tư như sau:", "", "L", false)
+	pdf.MultiCell(0, orderPDFBodyLineHeight, letter.Intro, "", "L", false)
 	pdf.Ln(orderPDFParagraphSpacing)
 }
 
-func renderPlacedOrderPDFFooter(pdf *gofpdf.Fpdf, data placedOrderDocumentData) {
+func renderPlacedOrderPDFFooter(pdf *gofpdf.Fpdf, data placedOrderDocumentData, letter orderPDFLetter) {
 	ensureOrderPDFVerticalSpace(pdf, 62)
 
 	pdf.SetFont(orderPDFFontFamily, "B", orderPDFBodyFontSize)
@@ -302,11 +347,7 @@ func renderPlacedOrderPDFFooter(pdf *gofpdf.Fpdf, data placedOrderDocumentData)
 	pdf.Ln(orderPDFParagraphSpacing)
 
 	pdf.SetFont(orderPDFFontFamily, "", orderPDFBodyFontSize)
-	writeOrderPDFIndentedParagraph(
-		pdf,
-		"Yêu cầu công ty xác nhận lại đơn hàng qua gmail và zalo của cán bộ phụ trách gói thầu trong vòng 1 giờ sau khi tiếp nhận đơn hàng, và tiến hành giao hàng theo đúng đợt giao hàng đã nêu.",
-		8.0,
-	)
+	writeOrderPDFIndentedParagraph(pdf, letter.Request, 8.0)
 	pdf.Ln(orderPDFSectionSpacing)
 	writeOrderPDFIndentedParagraph(pdf, "Trân trọng cảm ơn sự hợp tác của công ty!", 8.0)
 	pdf.Ln(orderPDFParagraphSpacing)
@@ -6,18 +6,64 @@ import (
 	"path/filepath"
 	"testing"
 
+	"bv108-consumables-management-backend/internal/models"
+
 	gomail "github.com/wneessen/go-mail"
 )
 
-func TestRenderPlacedOrderEmailBody(t *testing.T) {
-	body, err := renderPlacedOrderEmailBody("Công ty ABC")
-	if err != nil {
-		t.Fatalf("renderPlacedOrderEmailBody() error = %v", err)
+func TestPlacedOrderEmailBodyComesFromResolvedTemplate(t *testing.T) {
+	store := newMemoryOrderEmailTemplateStore(
+		models.OrderEmailTemplate{SupplierKey: normalizeLookup("Công ty XYZ"), Body: "Gửi [Tên công ty cung cấp]: đơn hàng đính kèm"},
+	)
+	templates := NewOrderEmailTemplateService(store)
+
+	testCases := []struct {
+		name     string
+		supplier string
+		snapshot func(t *testing.T) string
+		want     string
+	}{
+		{
+			name:     "built-in body",
+			supplier: "Công ty ABC",
+			want:     "Kính gửi công ty Công ty ABC, Khoa Trang bị- BV TWQĐ 108 xin gửi đến Quý công ty đơn đặt hàng vật tư theo file PDF đính kèm",
+		},
+		{
+			name:     "supplier template body",
+			supplier: "Công ty XYZ",
+			want:     "Gửi Công ty XYZ: đơn hàng đính kèm",
+		},
+		{
+			name:     "snapshot taken at placement",
+			supplier: "Công ty XYZ",
+			snapshot: func(t *testing.T) string {
+				snapshot, err := templates.SnapshotPlacedOrder("Công ty XYZ")
+				if err != nil {
+					t.Fatalf("SnapshotPlacedOrder() error = %v", err)
+				}
+				store.templates[0].Body = "Nội dung đã sửa sau khi đặt hàng"
+				t.Cleanup(func() { store.templates[0].Body = "Gửi [Tên công ty cung cấp]: đơn hàng đính kèm" })
+				return snapshot
+			},
+			want: "Gửi Công ty XYZ: đơn hàng đính kèm",
+		},
 	}
 
-	expected := "Kính gửi công ty Công ty ABC, Khoa Trang bị- BV TWQĐ 108 xin gửi đến Quý công ty đơn đặt hàng vật tư theo file PDF đính kèm"
-	if body != expected {
-		t.Fatalf("unexpected email body: %q", body)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			documentSnapshot := ""
+			if tc.snapshot != nil {
+				documentSnapshot = tc.snapshot(t)
+			}
+
+			snapshot, err := templates.placedOrder(tc.supplier, documentSnapshot)
+			if err != nil {
+				t.Fatalf("placedOrder() error = %v", err)
+			}
+			if body := renderOrderEmailTemplateText(snapshot.Template.Body, tc.supplier); body != tc.want {
+				t.Fatalf("email body = %q, want %q", body, tc.want)
+			}
+		})
 	}
 }
 
@@ -2,7 +2,11 @@ package services
 
 import (
 	"math"
+	"slices"
 	"strconv"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
 
 	"github.com/phpdave11/gofpdf"
 )
@@ -40,34 +44,117 @@ var orderPDFTableColumns = []orderPDFTableColumn{
 	{header: "Số\nlượng", width: 18},
 }
 
-func renderPlacedOrderPDFTable(pdf *gofpdf.Fpdf, items []OrderEmailItem) {
+// orderPDFExtraTableColumns are the optional columns a template can append
+// to the placed-order table.
+var orderPDFExtraTableColumns = map[string]orderPDFTableColumn{
+	models.OrderEmailColumnUnitPrice:     {header: "Đơn giá\n(VNĐ)", width: 24},
+	models.OrderEmailColumnTenderPackage: {header: "Gói thầu", width: 26},
+}
+
+func renderPlacedOrderPDFTable(pdf *gofpdf.Fpdf, items []OrderEmailItem, extraColumns []string) {
+	columns := placedOrderPDFTableColumns(extraColumns)
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, placedOrderPDFTableValues(item, extraColumns))
+	}
+	if len(rows) == 0 {
+		placeholder := make([]string, len(columns))
+		for index := range placeholder {
+			placeholder[index] = "..."
+		}
+		placeholder[0] = "1"
+		rows = append(rows, placeholder)
+	}
+
+	renderOrderPDFTable(pdf, "1. Danh sách vật tư đặt hàng:", columns, rows)
+}
+
+// placedOrderPDFTableColumns appends the requested extra columns and scales
+// every width so the table still spans the page.
+func placedOrderPDFTableColumns(extraColumns []string) []orderPDFTableColumn {
+	if len(extraColumns) == 0 {
+		return orderPDFTableColumns
+	}
+
+	columns := slices.Clone(orderPDFTableColumns)
+	tableWidth, totalWidth := 0.0, 0.0
+	for _, column := range columns {
+		tableWidth += column.width
+	}
+	for _, name := range extraColumns {
+		if column, ok := orderPDFExtraTableColumns[name]; ok {
+			columns = append(columns, column)
+		}
+	}
+	for _, column := range columns {
+		totalWidth += column.width
+	}
+
+	scale := tableWidth / totalWidth
+	for index := range columns {
+		columns[index].width *= scale
+	}
+	return columns
+}
+
+func placedOrderPDFTableValues(item OrderEmailItem, extraColumns []string) []string {
+	values := orderPDFTableValues(item)
+	for _, name := range extraColumns {
+		switch name {
+		case models.OrderEmailColumnUnitPrice:
+			values = append(values, formatOrderPDFAmount(item.DonGia))
+		case models.OrderEmailColumnTenderPackage:
+			values = append(values, nonEmptyPDFText(item.SoGoiThau))
+		}
+	}
+	return values
+}
+
+// formatOrderPDFAmount prints a VND amount with dot thousand separators.
+func formatOrderPDFAmount(value float64) string {
+	if value <= 0 {
+		return "-"
+	}
+
+	digits := strconv.FormatInt(int64(math.Round(value)), 10)
+	var builder strings.Builder
+	for index, digit := range digits {
+		if index > 0 && (len(digits)-index)%3 == 0 {
+			builder.WriteByte('.')
+		}
+		builder.WriteRune(digit)
+	}
+	return builder.String()
+}
+
+func renderOrderPDFTable(pdf *gofpdf.Fpdf, title string, columns []orderPDFTableColumn, rows [][]string) {
 	pdf.SetFont(orderPDFFontFamily, "B", orderPDFBodyFontSize)
-	pdf.MultiCell(0, orderPDFBodyLineHeight, "1. Danh sách vật tư đặt hàng:", "", "L", false)
+	pdf.MultiCell(0, orderPDFBodyLineHeight, title, "", "L", false)
 	pdf.Ln(orderPDFParagraphSpacing - 1)
 
 	drawHeader := func() {
-		drawPlacedOrderPDFTableHeader(pdf)
+		drawOrderPDFTableHeader(pdf, columns)
 	}
 
 	drawHeader()
-	if len(items) == 0 {
-		drawPlacedOrderPDFTableRow(pdf, []string{"1", "...", "...", "...", "...", "...", "..."}, drawHeader)
-	} else {
-		for _, item := range items {
-			drawPlacedOrderPDFTableRow(pdf, orderPDFTableValues(item), drawHeader)
-		}
+	for _, values := range rows {
+		drawOrderPDFTableRow(pdf, columns, values, drawHeader)
 	}
 
 	pdf.Ln(orderPDFSectionSpacing - 1)
 }
 
 func drawPlacedOrderPDFTableHeader(pdf *gofpdf.Fpdf) {
+	drawOrderPDFTableHeader(pdf, orderPDFTableColumns)
+}
+
+func drawOrderPDFTableHeader(pdf *gofpdf.Fpdf, columns []orderPDFTableColumn) {
 	pdf.SetFont(orderPDFFontFamily, "B", orderPDFTableFontSize)
-	headerValues := make([]string, len(orderPDFTableColumns))
-	for index, column := range orderPDFTableColumns {
+	headerValues := make([]string, len(columns))
+	for index, column := range columns {
 		headerValues[index] = column.header
 	}
-	header := layoutOrderPDFTableRow(pdf, headerValues, orderPDFTableHeaderLineHeight, orderPDFTableHeaderMinHeight)
+	header := layoutOrderPDFTableRow(pdf, columns, headerValues, orderPDFTableHeaderLineHeight, orderPDFTableHeaderMinHeight)
 	drawCenteredOrderPDFTableRow(pdf, header, orderPDFTableHeaderLineHeight)
 }
 
@@ -83,9 +170,9 @@ func orderPDFTableValues(item OrderEmailItem) []string {
 	}
 }
 
-func drawPlacedOrderPDFTableRow(pdf *gofpdf.Fpdf, values []string, drawHeader func()) {
+func drawOrderPDFTableRow(pdf *gofpdf.Fpdf, columns []orderPDFTableColumn, values []string, drawHeader func()) {
 	pdf.SetFont(orderPDFFontFamily, "", orderPDFTableFontSize)
-	row := layoutOrderPDFTableRow(pdf, values, orderPDFTableLineHeight, orderPDFTableMinimumRowHeight)
+	row := layoutOrderPDFTableRow(pdf, columns, values, orderPDFTableLineHeight, orderPDFTableMinimumRowHeight)
 
 	freshPageCapacity := orderPDFTableFreshPageCapacity(pdf)
 	if row.height <= freshPageCapacity {
@@ -125,10 +212,10 @@ func drawSplitOrderPDFTableRow(pdf *gofpdf.Fpdf, row orderPDFTableRowLayout, dra
 	}
 }
 
-func layoutOrderPDFTableRow(pdf *gofpdf.Fpdf, values []string, lineHeight, minimumHeight float64) orderPDFTableRowLayout {
-	cells := make([]orderPDFTableCellLayout, len(orderPDFTableColumns))
+func layoutOrderPDFTableRow(pdf *gofpdf.Fpdf, columns []orderPDFTableColumn, values []string, lineHeight, minimumHeight float64) orderPDFTableRowLayout {
+	cells := make([]orderPDFTableCellLayout, len(columns))
 	maxLineCount := 1
-	for index, column := range orderPDFTableColumns {
+	for index, column := range columns {
 		value := "-"
 		if index < len(values) {
 			value = values[index]
@@ -152,7 +239,7 @@ func orderPDFTableHeight(lineCount int, lineHeight, minimumHeight float64) float
 }
 
 func drawCenteredOrderPDFTableRow(pdf *gofpdf.Fpdf, row orderPDFTableRowLayout, lineHeight float64) {
-	x := orderPDFTableStartX(pdf)
+	x := orderPDFTableStartX(pdf, row.cells)
 	y := pdf.GetY()
 
 	for _, cell := range row.cells {
@@ -169,16 +256,16 @@ func drawCenteredOrderPDFTableRow(pdf *gofpdf.Fpdf, row orderPDFTableRowLayout,
 		x += cell.width
 	}
 
-	pdf.SetXY(orderPDFTableStartX(pdf), y+row.height)
+	pdf.SetXY(orderPDFTableStartX(pdf, row.cells), y+row.height)
 }
 
-func orderPDFTableStartX(pdf *gofpdf.Fpdf) float64 {
+func orderPDFTableStartX(pdf *gofpdf.Fpdf, cells []orderPDFTableCellLayout) float64 {
 	pageWidth, _ := pdf.GetPageSize()
 	leftMargin, _, rightMargin, _ := pdf.GetMargins()
 	contentWidth := pageWidth - leftMargin - rightMargin
 	tableWidth := 0.0
-	for _, column := range orderPDFTableColumns {
-		tableWidth += column.width
+	for _, cell := range cells {
+		tableWidth += cell.width
 	}
 	return leftMargin + math.Max(0, (contentWidth-tableWidth)/2)
 }
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+const (
+	defaultPasswordResetTokenMinutes  = 30
+	defaultPasswordResetWindowMinutes = 60
+	passwordResetQueueSize            = 100
+)
+
+type PasswordResetStore interface {
+	Create(token *models.PasswordResetToken, tokenHash string) error
+	Consume(tokenHash string, now time.Time) (int64, error)
+}
+
+type PasswordResetUsers interface {
+	GetByEmail(email string) (*models.User, error)
+}
+
+// PasswordResetThrottle counts forgot-password requests; the login throttle
+// repository implements it.
+type PasswordResetThrottle interface {
+	GetThrottle(scope, subject string) (*models.LoginThrottle, error)
+	CountFailure(scope, subject string, window time.Duration, now time.Time) (*models.LoginThrottle, error)
+	SavePenalty(throttle models.LoginThrottle) error
+}
+
+type PasswordResetConfig struct {
+	Store                PasswordResetStore
+	Users                PasswordResetUsers
+	Sender               PasswordResetEmailSender
+	Throttle             PasswordResetThrottle
+	LinkURL              string
+	TokenMinutes         int
+	MaxRequestsPerEmail  int
+	MaxRequestsPerIP     int
+	RequestWindowMinutes int
+}
+
+type passwordResetRequest struct {
+	email     string
+	ipAddress string
+}
+
+// PasswordResetService runs the forgot-password flow: it mails a single-use
+// link to active users and redeems the token when the new password is set.
+// Requests are handled by a background worker so the response time does not
+// depend on whether the email exists.
+type PasswordResetService struct {
+	store              PasswordResetStore
+	users              PasswordResetUsers
+	sender             PasswordResetEmailSender
+	throttle           PasswordResetThrottle
+	linkURL            string
+	ttl                time.Duration
+	maxRequestsByEmail int
+	maxRequestsByIP    int
+	requestWindow      time.Duration
+	queue              chan passwordResetRequest
+	now                func() time.Time
+}
+
+func NewPasswordResetService(cfg PasswordResetConfig) *PasswordResetService {
+	minutes := cfg.TokenMinutes
+	if minutes <= 0 {
+		minutes = defaultPasswordResetTokenMinutes
+	}
+	windowMinutes := cfg.RequestWindowMinutes
+	if windowMinutes <= 0 {
+		windowMinutes = defaultPasswordResetWindowMinutes
+	}
+	return &PasswordResetService{
+		store:              cfg.Store,
+		users:              cfg.Users,
+		sender:             cfg.Sender,
+		throttle:           cfg.Throttle,
+		linkURL:            strings.TrimSpace(cfg.LinkURL),
+		ttl:                time.Duration(minutes) * time.Minute,
+		maxRequestsByEmail: cfg.MaxRequestsPerEmail,
+		maxRequestsByIP:    cfg.MaxRequestsPerIP,
+		requestWindow:      time.Duration(windowMinutes) * time.Minute,
+		queue:              make(chan passwordResetRequest, passwordResetQueueSize),
+		now:                time.Now,
+	}
+}
+
+func (s *PasswordResetService) Start(ctx context.Context) {
+	if s == nil || s.store == nil || s.sender == nil {
+		log.Println("[password-reset] skipped because the store or sender is not configured")
+		return
+	}
+
+	go s.run(ctx)
+}
+
+// Enqueue hands a forgot-password request to the worker and returns at once.
+// It reports false when the queue is full and the request was dropped.
+func (s *PasswordResetService) Enqueue(email, ipAddress string) bool {
+	select {
+	case s.queue <- passwordResetRequest{email: email, ipAddress: ipAddress}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *PasswordResetService) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case request := <-s.queue:
+			if err := s.RequestReset(request.email, request.ipAddress); err != nil {
+				log.Printf("[password-reset] request for %s failed: %v", request.email, err)
+			}
+		}
+	}
+}
+
+// CheckRequestLimit counts a forgot-password request against the email and
+// the client IP and returns how long the caller has to wait when either is
+// over its limit. Unknown emails count as well, so the limit reveals nothing
+// about which accounts exist.
+func (s *PasswordResetService) CheckRequestLimit(email, ipAddress string) (time.Duration, error) {
+	if s.throttle == nil {
+		return 0, nil
+	}
+
+	limits := []struct {
+		scope       string
+		subject     string
+		maxRequests int
+	}{
+		{scope: models.LoginThrottleScopeResetEmail, subject: email, maxRequests: s.maxRequestsByEmail},
+		{scope: models.LoginThrottleScopeResetIP, subject: ipAddress, maxRequests: s.maxRequestsByIP},
+	}
+
+	now := s.now()
+	var wait time.Duration
+	for _, limit := range limits {
+		if limit.subject == "" || limit.maxRequests <= 0 {
+			continue
+		}
+		current, err := s.throttle.GetThrottle(limit.scope, limit.subject)
+		if err != nil {
+			return 0, err
+		}
+		if retryAfter := current.RetryAfter(now); retryAfter > wait {
+			wait = retryAfter
+		}
+	}
+	if wait > 0 {
+		return wait, nil
+	}
+
+	// The request that reaches the limit still goes through; the ones after
+	// it wait until the window has passed.
+	policy := models.LoginThrottlePolicy{Lockout: s.requestWindow, Window: s.requestWindow}
+	for _, limit := range limits {
+		if limit.subject == "" || limit.maxRequests <= 0 {
+			continue
+		}
+		counted, err := s.throttle.CountFailure(limit.scope, limit.subject, s.requestWindow, now)
+		if err != nil {
+			return 0, err
+		}
+		penalized := models.ApplyLoginFailurePenalty(*counted, limit.maxRequests, policy, now)
+		if penalized.LockedUntil == nil {
+			continue
+		}
+		if err := s.throttle.SavePenalty(penalized); err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
+// RequestReset mails a reset link when email belongs to an active user.
+// Unknown and disabled accounts return nil as well, so callers cannot use the
+// endpoint to find out which emails exist.
+func (s *PasswordResetService) RequestReset(email, ipAddress string) error {
+	user, err := s.users.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	value, hash, err := models.NewPasswordResetTokenValue()
+	if err != nil {
+		return err
+	}
+	link, err := s.resetLink(value)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+		IPAddress: ipAddress,
+	}
+	if err := s.store.Create(token, hash); err != nil {
+		return err
+	}
+
+	return s.sender.SendPasswordResetEmail(user.Email, user.Username, link, token.ExpiresAt)
+}
+
+// Redeem uses up a reset token and returns the user it was issued to, or
+// models.ErrPasswordResetTokenInvalid.
+func (s *PasswordResetService) Redeem(token string) (int64, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return 0, models.ErrPasswordResetTokenInvalid
+	}
+	return s.store.Consume(models.HashRefreshToken(token), s.now())
+}
+
+func (s *PasswordResetService) resetLink(token string) (string, error) {
+	link, err := url.Parse(s.linkURL)
+	if err != nil || link.Scheme == "" || link.Host == "" {
+		return "", fmt.Errorf("invalid PASSWORD_RESET_URL: %s", s.linkURL)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	stdmail "net/mail"
+	"strings"
+	"time"
+
+	gomail "github.com/wneessen/go-mail"
+)
+
+const passwordResetEmailSubject = "ĐẶT LẠI MẬT KHẨU - HỆ THỐNG QUẢN LÝ VẬT TƯ BV TWQĐ 108"
+
+// PasswordResetEmailSender delivers the forgot-password link. Handlers depend
+// on this interface so tests can record the link instead of sending mail.
+type PasswordResetEmailSender interface {
+	SendPasswordResetEmail(recipientEmail, username, resetLink string, expiresAt time.Time) error
+}
+
+type SMTPPasswordResetMailer struct {
+	host        string
+	port        string
+	username    string
+	appPassword string
+	from        string
+	tlsPolicy   gomail.TLSPolicy
+}
+
+// SMTPPasswordResetMailerConfig takes the same SMTP settings as the order
+// mailer.
+type SMTPPasswordResetMailerConfig struct {
+	Host        string
+	Port        string
+	Username    string
+	AppPassword string
+	From        string
+	TLSPolicy   string
+}
+
+func NewSMTPPasswordResetMailer(cfg SMTPPasswordResetMailerConfig) *SMTPPasswordResetMailer {
+	return &SMTPPasswordResetMailer{
+		host:        strings.TrimSpace(cfg.Host),
+		port:        strings.TrimSpace(cfg.Port),
+		username:    strings.TrimSpace(cfg.Username),
+		appPassword: strings.ReplaceAll(strings.TrimSpace(cfg.AppPassword), " ", ""),
+		from:        strings.TrimSpace(cfg.From),
+		tlsPolicy:   resolveSMTPTLSPolicy(cfg.TLSPolicy),
+	}
+}
+
+func (m *SMTPPasswordResetMailer) SendPasswordResetEmail(recipientEmail, username, resetLink string, expiresAt time.Time) error {
+	recipientEmail = strings.TrimSpace(recipientEmail)
+	if _, err := stdmail.ParseAddress(recipientEmail); err != nil {
+		return fmt.Errorf("invalid recipient email: %s", recipientEmail)
+	}
+	if m.host == "" || m.port == "" || m.from == "" {
+		return fmt.Errorf("smtp is not configured. Set SMTP_HOST, SMTP_PORT, and SMTP_FROM")
+	}
+
+	client, err := newSMTPClient(m.host, m.port, m.username, m.appPassword, m.tlsPolicy)
+	if err != nil {
+		return err
+	}
+
+	message := gomail.NewMsg()
+	if err := message.From(m.from); err != nil {
+		return fmt.Errorf("error setting FROM address: %w", err)
+	}
+	if err := message.To(recipientEmail); err != nil {
+		return fmt.Errorf("error setting TO address: %w", err)
+	}
+	message.Subject(passwordResetEmailSubject)
+	message.SetBodyString(gomail.TypeTextPlain, renderPasswordResetEmailBody(username, resetLink, expiresAt))
+
+	if err := client.DialAndSend(message); err != nil {
+		return fmt.Errorf("error sending password reset email to %s: %w", recipientEmail, err)
+	}
+	return nil
+}
+
+func renderPasswordResetEmailBody(username, resetLink string, expiresAt time.Time) string {
+	greeting := "Xin chào,"
+	if name := strings.TrimSpace(username); name != "" {
+		greeting = fmt.Sprintf("Xin chào %s,", name)
+	}
+
+	if location, err := time.LoadLocation("Asia/Ho_Chi_Minh"); err == nil {
+		expiresAt = expiresAt.In(location)
+	}
+
+	return strings.Join([]string{
+		greeting,
+		"",
+		"Hệ thống đã nhận được yêu cầu đặt lại mật khẩu cho tài khoản của bạn.",
+		"Mở liên kết sau để đặt mật khẩu mới. Liên kết chỉ dùng được một lần và hết hạn lúc " + expiresAt.Format("15:04 02/01/2006") + ":",
+		"",
+		resetLink,
+		"",
+		"Nếu bạn không yêu cầu đặt lại mật khẩu, hãy bỏ qua email này; mật khẩu hiện tại vẫn giữ nguyên.",
+		"",
+		"Khoa Trang bị - Bệnh viện TWQĐ 108",
+	}, "\n")
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+type fakePasswordResetStore struct {
+	tokens map[string]*models.PasswordResetToken
+}
+
+func (f *fakePasswordResetStore) Create(token *models.PasswordResetToken, tokenHash string) error {
+	for _, existing := range f.tokens {
+		if existing.UserID == token.UserID && existing.UsedAt == nil {
+			retiredAt := token.CreatedAt
+			existing.UsedAt = &retiredAt
+		}
+	}
+	f.tokens[tokenHash] = token
+	return nil
+}
+
+func (f *fakePasswordResetStore) Consume(tokenHash string, now time.Time) (int64, error) {
+	token, ok := f.tokens[tokenHash]
+	if !ok || token.UsedAt != nil || !now.Before(token.ExpiresAt) {
+		return 0, models.ErrPasswordResetTokenInvalid
+	}
+	token.UsedAt = &now
+	return token.UserID, nil
+}
+
+type fakePasswordResetUsers map[string]*models.User
+
+func (f fakePasswordResetUsers) GetByEmail(email string) (*models.User, error) {
+	user, ok := f[email]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+	return user, nil
+}
+
+type sentPasswordResetEmail struct {
+	recipient string
+	link      string
+	expiresAt time.Time
+}
+
+type fakePasswordResetSender struct {
+	sent []sentPasswordResetEmail
+}
+
+func (f *fakePasswordResetSender) SendPasswordResetEmail(recipientEmail, username, resetLink string, expiresAt time.Time) error {
+	f.sent = append(f.sent, sentPasswordResetEmail{recipient: recipientEmail, link: resetLink, expiresAt: expiresAt})
+	return nil
+}
+
+type fakePasswordResetThrottle struct {
+	counters map[string]*models.LoginThrottle
+}
+
+func (f *fakePasswordResetThrottle) GetThrottle(scope, subject string) (*models.LoginThrottle, error) {
+	return f.counters[scope+"|"+subject], nil
+}
+
+func (f *fakePasswordResetThrottle) CountFailure(scope, subject string, window time.Duration, now time.Time) (*models.LoginThrottle, error) {
+	current := f.counters[scope+"|"+subject]
+	if current == nil || !now.Before(current.WindowStarted.Add(window)) || (current.LockedUntil != nil && !now.Before(*current.LockedUntil)) {
+		current = &models.LoginThrottle{Scope: scope, Subject: subject, WindowStarted: now}
+		f.counters[scope+"|"+subject] = current
+	}
+	current.FailedCount++
+	current.LastFailedAt = now
+	counted := *current
+	return &counted, nil
+}
+
+func (f *fakePasswordResetThrottle) SavePenalty(throttle models.LoginThrottle) error {
+	current := f.counters[throttle.Scope+"|"+throttle.Subject]
+	if current != nil && current.FailedCount == throttle.FailedCount {
+		current.NextAttemptAt = throttle.NextAttemptAt
+		current.LockedUntil = throttle.LockedUntil
+	}
+	return nil
+}
+
+type signalingPasswordResetSender struct {
+	recipients chan string
+}
+
+func (s signalingPasswordResetSender) SendPasswordResetEmail(recipientEmail, username, resetLink string, expiresAt time.Time) error {
+	s.recipients <- recipientEmail
+	return nil
+}
+
+func newTestPasswordResetService(now *time.Time) (*PasswordResetService, *fakePasswordResetSender) {
+	sender := &fakePasswordResetSender{}
+	service := NewPasswordResetService(PasswordResetConfig{
+		Store: &fakePasswordResetStore{tokens: make(map[string]*models.PasswordResetToken)},
+		Users: fakePasswordResetUsers{
+			"kho@bv108.vn": {ID: 7, Email: "kho@bv108.vn", Username: "Thu Kho", IsActive: true},
+			"cu@bv108.vn":  {ID: 8, Email: "cu@bv108.vn", IsActive: false},
+		},
+		Sender:       sender,
+		LinkURL:      "https://vattu.bv108.vn/reset-password?lang=vi",
+		TokenMinutes: 30,
+	})
+	service.now = func() time.Time { return *now }
+	return service, sender
+}
+
+func resetTokenFromLink(t *testing.T, link string) string {
+	t.Helper()
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("parse reset link: %v", err)
+	}
+	if parsed.Query().Get("lang") != "vi" {
+		t.Fatalf("reset link dropped the configured query: %s", link)
+	}
+	return parsed.Query().Get("token")
+}
+
+func TestPasswordResetMailsOnlyActiveUsers(t *testing.T) {
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	service, sender := newTestPasswordResetService(&now)
+
+	for _, email := range []string{"kho@bv108.vn", "cu@bv108.vn", "khong-co@bv108.vn"} {
+		if err := service.RequestReset(email, "10.0.0.5"); err != nil {
+			t.Fatalf("RequestReset(%s) returned error: %v", email, err)
+		}
+	}
+
+	if len(sender.sent) != 1 || sender.sent[0].recipient != "kho@bv108.vn" {
+		t.Fatalf("expected exactly one email to the active user, got %+v", sender.sent)
+	}
+	if want := now.Add(30 * time.Minute); !sender.sent[0].expiresAt.Equal(want) {
+		t.Fatalf("expiresAt = %s, want %s", sender.sent[0].expiresAt, want)
+	}
+}
+
+func TestPasswordResetTokenIsSingleUseAndExpires(t *testing.T) {
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	service, sender := newTestPasswordResetService(&now)
+
+	if err := service.RequestReset("kho@bv108.vn", ""); err != nil {
+		t.Fatalf("RequestReset returned error: %v", err)
+	}
+	token := resetTokenFromLink(t, sender.sent[0].link)
+
+	userID, err := service.Redeem(token)
+	if err != nil || userID != 7 {
+		t.Fatalf("Redeem = %d, %v; want 7, nil", userID, err)
+	}
+	if _, err := service.Redeem(token); !errors.Is(err, models.ErrPasswordResetTokenInvalid) {
+		t.Fatalf("second Redeem error = %v, want ErrPasswordResetTokenInvalid", err)
+	}
+
+	if err := service.RequestReset("kho@bv108.vn", ""); err != nil {
+		t.Fatalf("RequestReset returned error: %v", err)
+	}
+	now = now.Add(31 * time.Minute)
+	if _, err := service.Redeem(resetTokenFromLink(t, sender.sent[1].link)); !errors.Is(err, models.ErrPasswordResetTokenInvalid) {
+		t.Fatalf("expired Redeem error = %v, want ErrPasswordResetTokenInvalid", err)
+	}
+}
+
+func TestPasswordResetNewRequestRetiresEarlierLink(t *testing.T) {
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	service, sender := newTestPasswordResetService(&now)
+
+	for i := 0; i < 2; i++ {
+		if err := service.RequestReset("kho@bv108.vn", ""); err != nil {
+			t.Fatalf("RequestReset returned error: %v", err)
+		}
+	}
+
+	if _, err := service.Redeem(resetTokenFromLink(t, sender.sent[0].link)); !errors.Is(err, models.ErrPasswordResetTokenInvalid) {
+		t.Fatalf("earlier link error = %v, want ErrPasswordResetTokenInvalid", err)
+	}
+	if _, err := service.Redeem(resetTokenFromLink(t, sender.sent[1].link)); err != nil {
+		t.Fatalf("latest link returned error: %v", err)
+	}
+}
+
+func TestRenderPasswordResetEmailBodyIncludesLink(t *testing.T) {
+	body := renderPasswordResetEmailBody("Thu Kho", "https://vattu.bv108.vn/reset-password?token=abc", time.Date(2026, 4, 1, 2, 30, 0, 0, time.UTC))
+
+	for _, want := range []string{"Xin chào Thu Kho,", "https://vattu.bv108.vn/reset-password?token=abc"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("body does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestPasswordResetRequestLimitPerEmailAndIP(t *testing.T) {
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	service := NewPasswordResetService(PasswordResetConfig{
+		Throttle:             &fakePasswordResetThrottle{counters: make(map[string]*models.LoginThrottle)},
+		MaxRequestsPerEmail:  2,
+		MaxRequestsPerIP:     3,
+		RequestWindowMinutes: 60,
+	})
+	service.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if wait, err := service.CheckRequestLimit("kho@bv108.vn", "10.0.0.5"); err != nil || wait != 0 {
+			t.Fatalf("request %d: wait = %s, err = %v; want it allowed", i+1, wait, err)
+		}
+	}
+	if wait, _ := service.CheckRequestLimit("kho@bv108.vn", "10.0.0.6"); wait != time.Hour {
+		t.Fatalf("third request for the email: wait = %s, want 1h", wait)
+	}
+
+	if wait, _ := service.CheckRequestLimit("khong-co@bv108.vn", "10.0.0.5"); wait != 0 {
+		t.Fatalf("third request from the IP: wait = %s, want it allowed", wait)
+	}
+	if wait, _ := service.CheckRequestLimit("khac@bv108.vn", "10.0.0.5"); wait != time.Hour {
+		t.Fatalf("fourth request from the IP: wait = %s, want 1h", wait)
+	}
+
+	now = now.Add(time.Hour)
+	if wait, _ := service.CheckRequestLimit("kho@bv108.vn", "10.0.0.7"); wait != 0 {
+		t.Fatalf("after the window: wait = %s, want it allowed", wait)
+	}
+}
+
+func TestPasswordResetEnqueueSendsInBackground(t *testing.T) {
+	sender := signalingPasswordResetSender{recipients: make(chan string, 1)}
+	service := NewPasswordResetService(PasswordResetConfig{
+		Store:   &fakePasswordResetStore{tokens: make(map[string]*models.PasswordResetToken)},
+		Users:   fakePasswordResetUsers{"kho@bv108.vn": {ID: 7, Email: "kho@bv108.vn", IsActive: true}},
+		Sender:  sender,
+		LinkURL: "https://vattu.bv108.vn/reset-password",
+	})
+
+	if !service.Enqueue("kho@bv108.vn", "10.0.0.5") {
+		t.Fatal("Enqueue dropped the request")
+	}
+	select {
+	case recipient := <-sender.recipients:
+		t.Fatalf("email to %s was sent before the worker started", recipient)
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.Start(ctx)
+
+	select {
+	case recipient := <-sender.recipients:
+		if recipient != "kho@bv108.vn" {
+			t.Fatalf("recipient = %s, want kho@bv108.vn", recipient)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued reset email was not sent")
+	}
+}
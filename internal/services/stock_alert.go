@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+const StockAlertEventType = "stock.alert"
+
+type StockAlertSupplySource interface {
+	ListReorderSupplies() ([]models.Supply, error)
+}
+
+type StockAlertOrderSource interface {
+	ListPendingQuantitiesByMaterial() (map[string]float64, error)
+	ListOutstandingQuantities(filter models.OrderOutstandingFilter) ([]models.OrderOutstandingQuantity, error)
+}
+
+type StockAlertStore interface {
+	ListAlerts(filter models.StockAlertFilter) ([]models.StockAlert, error)
+	SaveAlert(alert *models.StockAlert) (bool, error)
+	ReopenSnoozedAlert(id int64, now time.Time) (bool, error)
+	ResolveAlerts(ids []int64, now time.Time) error
+}
+
+type StockAlertAssignees interface {
+	ListAssignedTenderStaffBySupply() (map[int][]int64, error)
+}
+
+// StockAlertNotifier is the part of realtime.Hub the evaluator needs.
+type StockAlertNotifier interface {
+	SendToUser(userID int64, eventType string, payload interface{})
+}
+
+type StockAlertEvaluatorConfig struct {
+	Supplies        StockAlertSupplySource
+	Orders          StockAlertOrderSource
+	Store           StockAlertStore
+	Assignees       StockAlertAssignees
+	Notifier        StockAlertNotifier
+	IntervalMinutes int
+}
+
+type StockAlertEvaluation struct {
+	Evaluated int `json:"evaluated"`
+	Raised    int `json:"raised"`
+	Updated   int `json:"updated"`
+	Resolved  int `json:"resolved"`
+	Notified  int `json:"notified"`
+}
+
+// StockAlertEvaluator periodically compares closing stock plus pending and
+// placed order quantities with TonKhoMin, keeps one alert per short supply
+// and pushes stock.alert to the tender staff assigned to it.
+type StockAlertEvaluator struct {
+	supplies  StockAlertSupplySource
+	orders    StockAlertOrderSource
+	store     StockAlertStore
+	assignees StockAlertAssignees
+	notifier  StockAlertNotifier
+	interval  time.Duration
+	now       func() time.Time
+}
+
+func NewStockAlertEvaluator(cfg StockAlertEvaluatorConfig) *StockAlertEvaluator {
+	return &StockAlertEvaluator{
+		supplies:  cfg.Supplies,
+		orders:    cfg.Orders,
+		store:     cfg.Store,
+		assignees: cfg.Assignees,
+		notifier:  cfg.Notifier,
+		interval:  time.Duration(cfg.IntervalMinutes) * time.Minute,
+		now:       time.Now,
+	}
+}
+
+func (e *StockAlertEvaluator) Start(ctx context.Context) {
+	if e == nil || e.interval <= 0 {
+		log.Println("[stock-alert] disabled by STOCK_ALERT_INTERVAL_MINUTES")
+		return
+	}
+	if e.supplies == nil || e.orders == nil || e.store == nil {
+		log.Println("[stock-alert] skipped because a data source is not configured")
+		return
+	}
+
+	go e.run(ctx)
+}
+
+func (e *StockAlertEvaluator) run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := e.Evaluate(ctx)
+		if err != nil {
+			log.Printf("[stock-alert] evaluation failed: %v", err)
+		} else if result.Raised > 0 || result.Resolved > 0 {
+			log.Printf("[stock-alert] %d supplies checked, %d raised, %d resolved", result.Evaluated, result.Raised, result.Resolved)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate checks every supply with a TonKhoMin once. New alerts and snoozed
+// alerts whose snooze has expired are pushed to the assigned tender staff;
+// alerts of supplies that recovered are resolved.
+func (e *StockAlertEvaluator) Evaluate(ctx context.Context) (StockAlertEvaluation, error) {
+	var result StockAlertEvaluation
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	supplies, err := e.supplies.ListReorderSupplies()
+	if err != nil {
+		return result, err
+	}
+	pending, err := e.orders.ListPendingQuantitiesByMaterial()
+	if err != nil {
+		return result, err
+	}
+	outstanding, err := e.orders.ListOutstandingQuantities(models.OrderOutstandingFilter{})
+	if err != nil {
+		return result, err
+	}
+	onOrder := models.OutstandingQuantityByMaterial(outstanding)
+
+	active, err := e.store.ListAlerts(models.StockAlertFilter{Statuses: models.ActiveStockAlertStatuses})
+	if err != nil {
+		return result, err
+	}
+	existing := make(map[int]models.StockAlert, len(active))
+	for _, alert := range active {
+		existing[alert.SupplyIDX1] = alert
+	}
+
+	now := e.now()
+	toNotify := make([]models.StockAlert, 0)
+	for _, supply := range supplies {
+		result.Evaluated++
+		code := strings.ToUpper(supply.MaterialCode)
+		alert, below := models.EvaluateReorderPoint(supply, pending[code], onOrder[code])
+		previous, hadAlert := existing[supply.IDX1]
+		if !below {
+			continue
+		}
+		delete(existing, supply.IDX1)
+
+		// The store only refreshes the figures of an unresolved alert, so
+		// the status read above may be stale; it only picks snooze candidates.
+		alert.Status = models.StockAlertStatusOpen
+		alert.RaisedAt = now
+		alert.LastEvaluatedAt = now
+		raised, err := e.store.SaveAlert(&alert)
+		if err != nil {
+			return result, err
+		}
+		if raised {
+			result.Raised++
+			toNotify = append(toNotify, alert)
+			continue
+		}
+		result.Updated++
+
+		if !hadAlert || previous.Status != models.StockAlertStatusSnoozed ||
+			(previous.SnoozedUntil != nil && previous.SnoozedUntil.After(now)) {
+			continue
+		}
+		reopened, err := e.store.ReopenSnoozedAlert(alert.ID, now)
+		if err != nil {
+			return result, err
+		}
+		if reopened {
+			alert.RaisedAt = previous.RaisedAt
+			toNotify = append(toNotify, alert)
+		}
+	}
+
+	// Whatever is left recovered or no longer has a TonKhoMin.
+	resolved := make([]int64, 0, len(existing))
+	for _, alert := range existing {
+		resolved = append(resolved, alert.ID)
+	}
+	if err := e.store.ResolveAlerts(resolved, now); err != nil {
+		return result, err
+	}
+	result.Resolved = len(resolved)
+
+	notified, err := e.notify(toNotify)
+	if err != nil {
+		log.Printf("[stock-alert] notifying tender staff failed: %v", err)
+	}
+	result.Notified = notified
+	return result, nil
+}
+
+func (e *StockAlertEvaluator) notify(alerts []models.StockAlert) (int, error) {
+	if len(alerts) == 0 || e.notifier == nil || e.assignees == nil {
+		return 0, nil
+	}
+
+	assignees, err := e.assignees.ListAssignedTenderStaffBySupply()
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, alert := range alerts {
+		for _, userID := range assignees[alert.SupplyIDX1] {
+			e.notifier.SendToUser(userID, StockAlertEventType, alert)
+			notified++
+		}
+	}
+	return notified, nil
+}
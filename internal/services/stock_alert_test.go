@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+type fakeStockAlertSupplies struct {
+	supplies []models.Supply
+}
+
+func (f *fakeStockAlertSupplies) ListReorderSupplies() ([]models.Supply, error) {
+	return f.supplies, nil
+}
+
+type fakeStockAlertOrders struct {
+	pending     map[string]float64
+	outstanding []models.OrderOutstandingQuantity
+}
+
+func (f *fakeStockAlertOrders) ListPendingQuantitiesByMaterial() (map[string]float64, error) {
+	return f.pending, nil
+}
+
+func (f *fakeStockAlertOrders) ListOutstandingQuantities(models.OrderOutstandingFilter) ([]models.OrderOutstandingQuantity, error) {
+	return f.outstanding, nil
+}
+
+// fakeStockAlertStore keeps the latest alert of each supply in alerts and
+// the resolved alerts a new one replaced in history.
+type fakeStockAlertStore struct {
+	alerts   map[int]models.StockAlert
+	history  []models.StockAlert
+	nextID   int64
+	resolved []int64
+	// listed, when set, is what ListAlerts returns instead of alerts, to
+	// simulate a user acting on an alert during an evaluation.
+	listed []models.StockAlert
+}
+
+func (f *fakeStockAlertStore) ListAlerts(filter models.StockAlertFilter) ([]models.StockAlert, error) {
+	source := f.listed
+	if source == nil {
+		for _, alert := range f.alerts {
+			source = append(source, alert)
+		}
+	}
+	alerts := make([]models.StockAlert, 0)
+	for _, alert := range source {
+		for _, status := range filter.Statuses {
+			if alert.Status == status {
+				alerts = append(alerts, alert)
+			}
+		}
+	}
+	return alerts, nil
+}
+
+func (f *fakeStockAlertStore) SaveAlert(alert *models.StockAlert) (bool, error) {
+	if current, ok := f.alerts[alert.SupplyIDX1]; ok && current.Status != models.StockAlertStatusResolved {
+		current.ProjectedStock = alert.ProjectedStock
+		current.Shortfall = alert.Shortfall
+		current.LastEvaluatedAt = alert.LastEvaluatedAt
+		f.alerts[alert.SupplyIDX1] = current
+		alert.ID = current.ID
+		return false, nil
+	} else if ok {
+		f.history = append(f.history, current)
+	}
+	f.nextID++
+	alert.ID = f.nextID
+	f.alerts[alert.SupplyIDX1] = *alert
+	return true, nil
+}
+
+func (f *fakeStockAlertStore) ReopenSnoozedAlert(id int64, now time.Time) (bool, error) {
+	for idx1, alert := range f.alerts {
+		if alert.ID != id || alert.Status != models.StockAlertStatusSnoozed || (alert.SnoozedUntil != nil && alert.SnoozedUntil.After(now)) {
+			continue
+		}
+		alert.Status = models.StockAlertStatusOpen
+		alert.SnoozedUntil = nil
+		f.alerts[idx1] = alert
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *fakeStockAlertStore) ResolveAlerts(ids []int64, now time.Time) error {
+	f.resolved = append(f.resolved, ids...)
+	for idx1, alert := range f.alerts {
+		for _, id := range ids {
+			if alert.ID == id {
+				alert.Status = models.StockAlertStatusResolved
+				f.alerts[idx1] = alert
+			}
+		}
+	}
+	return nil
+}
+
+type fakeStockAlertAssignees map[int][]int64
+
+func (f fakeStockAlertAssignees) ListAssignedTenderStaffBySupply() (map[int][]int64, error) {
+	return f, nil
+}
+
+type sentStockAlert struct {
+	userID    int64
+	eventType string
+	idx1      int
+}
+
+type fakeStockAlertNotifier struct {
+	sent []sentStockAlert
+}
+
+func (f *fakeStockAlertNotifier) SendToUser(userID int64, eventType string, payload interface{}) {
+	f.sent = append(f.sent, sentStockAlert{userID: userID, eventType: eventType, idx1: payload.(models.StockAlert).SupplyIDX1})
+}
+
+func reorderSupply(idx1 int, typeName string, tonDauKy, tonKhoMin int32) models.Supply {
+	return models.Supply{
+		IDX1:         idx1,
+		TypeName:     sql.NullString{String: typeName, Valid: true},
+		MaterialCode: typeName,
+		TonKhoMin:    sql.NullInt32{Int32: tonKhoMin, Valid: true},
+		TonCuoiKy:    int(tonDauKy),
+	}
+}
+
+func TestStockAlertEvaluatorRaisesAndNotifies(t *testing.T) {
+	store := &fakeStockAlertStore{alerts: map[int]models.StockAlert{}}
+	notifier := &fakeStockAlertNotifier{}
+	evaluator := NewStockAlertEvaluator(StockAlertEvaluatorConfig{
+		Supplies: &fakeStockAlertSupplies{supplies: []models.Supply{
+			reorderSupply(1, "VT-1", 5, 20),
+			reorderSupply(2, "VT-2", 5, 20),
+			reorderSupply(3, "VT-3", 50, 20),
+		}},
+		Orders: &fakeStockAlertOrders{
+			pending:     map[string]float64{"VT-2": 10},
+			outstanding: []models.OrderOutstandingQuantity{{MaQuanLy: "vt-2", OutstandingQty: 10}},
+		},
+		Store:     store,
+		Assignees: fakeStockAlertAssignees{1: {7, 8}, 3: {9}},
+		Notifier:  notifier,
+	})
+
+	result, err := evaluator.Evaluate(context.Background())
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	// VT-2 has 5 in stock but 20 pending or on order, so only VT-1 is short.
+	if result.Evaluated != 3 || result.Raised != 1 || result.Notified != 2 {
+		t.Fatalf("result = %+v, want one alert pushed to two users", result)
+	}
+	alert := store.alerts[1]
+	if alert.Status != models.StockAlertStatusOpen || alert.Shortfall != 15 || alert.ProjectedStock != 5 {
+		t.Fatalf("alert = %+v, want an open alert short by 15", alert)
+	}
+	for _, sent := range notifier.sent {
+		if sent.eventType != StockAlertEventType || sent.idx1 != 1 {
+			t.Fatalf("sent = %+v, want stock.alert for supply 1", notifier.sent)
+		}
+	}
+
+	// A second pass only refreshes the figures and does not push again.
+	notifier.sent = nil
+	result, err = evaluator.Evaluate(context.Background())
+	if err != nil {
+		t.Fatalf("second Evaluate() error = %v", err)
+	}
+	if result.Raised != 0 || result.Updated != 1 || len(notifier.sent) != 0 {
+		t.Fatalf("second result = %+v, sent = %+v", result, notifier.sent)
+	}
+}
+
+func TestStockAlertEvaluatorReopensExpiredSnoozeAndResolvesRecovered(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+	store := &fakeStockAlertStore{nextID: 10, alerts: map[int]models.StockAlert{
+		1: {ID: 1, SupplyIDX1: 1, Status: models.StockAlertStatusSnoozed, SnoozedUntil: &expired, RaisedAt: now.AddDate(0, 0, -2)},
+		2: {ID: 2, SupplyIDX1: 2, Status: models.StockAlertStatusSnoozed, SnoozedUntil: &later},
+		3: {ID: 3, SupplyIDX1: 3, Status: models.StockAlertStatusAcknowledged},
+	}}
+	notifier := &fakeStockAlertNotifier{}
+	evaluator := NewStockAlertEvaluator(StockAlertEvaluatorConfig{
+		Supplies: &fakeStockAlertSupplies{supplies: []models.Supply{
+			reorderSupply(1, "VT-1", 0, 10),
+			reorderSupply(2, "VT-2", 0, 10),
+			reorderSupply(3, "VT-3", 30, 10),
+		}},
+		Orders:    &fakeStockAlertOrders{},
+		Store:     store,
+		Assignees: fakeStockAlertAssignees{1: {7}, 2: {7}},
+		Notifier:  notifier,
+	})
+	evaluator.now = func() time.Time { return now }
+
+	result, err := evaluator.Evaluate(context.Background())
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	if result.Updated != 2 || result.Resolved != 1 || len(store.resolved) != 1 || store.resolved[0] != 3 {
+		t.Fatalf("result = %+v, resolved = %v, want supply 3 resolved", result, store.resolved)
+	}
+	if reopened := store.alerts[1]; reopened.Status != models.StockAlertStatusOpen || reopened.SnoozedUntil != nil || !reopened.RaisedAt.Equal(now.AddDate(0, 0, -2)) {
+		t.Fatalf("alert 1 = %+v, want it reopened with its original raise time", reopened)
+	}
+	if stillSnoozed := store.alerts[2]; stillSnoozed.Status != models.StockAlertStatusSnoozed {
+		t.Fatalf("alert 2 = %+v, want it still snoozed", stillSnoozed)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].idx1 != 1 {
+		t.Fatalf("sent = %+v, want only the reopened alert", notifier.sent)
+	}
+}
+
+func TestStockAlertEvaluatorKeepsActionTakenDuringEvaluation(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+	userID := int64(7)
+	store := &fakeStockAlertStore{
+		nextID: 10,
+		alerts: map[int]models.StockAlert{
+			1: {ID: 1, SupplyIDX1: 1, Status: models.StockAlertStatusAcknowledged, ActionedByUserID: &userID},
+		},
+		listed: []models.StockAlert{{ID: 1, SupplyIDX1: 1, Status: models.StockAlertStatusSnoozed, SnoozedUntil: &expired}},
+	}
+	notifier := &fakeStockAlertNotifier{}
+	evaluator := NewStockAlertEvaluator(StockAlertEvaluatorConfig{
+		Supplies:  &fakeStockAlertSupplies{supplies: []models.Supply{reorderSupply(1, "VT-1", 2, 10)}},
+		Orders:    &fakeStockAlertOrders{},
+		Store:     store,
+		Assignees: fakeStockAlertAssignees{1: {7}},
+		Notifier:  notifier,
+	})
+	evaluator.now = func() time.Time { return now }
+
+	if _, err := evaluator.Evaluate(context.Background()); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	alert := store.alerts[1]
+	if alert.Status != models.StockAlertStatusAcknowledged || alert.ActionedByUserID == nil || alert.Shortfall != 8 {
+		t.Fatalf("alert = %+v, want the acknowledgement kept and the figures refreshed", alert)
+	}
+	if len(notifier.sent) != 0 {
+		t.Fatalf("sent = %+v, want no push for an acknowledged alert", notifier.sent)
+	}
+}
+
+func TestStockAlertEvaluatorRaisesNewAlertAfterResolve(t *testing.T) {
+	resolvedAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	store := &fakeStockAlertStore{nextID: 10, alerts: map[int]models.StockAlert{
+		1: {ID: 1, SupplyIDX1: 1, Status: models.StockAlertStatusResolved, ResolvedAt: &resolvedAt},
+	}}
+	notifier := &fakeStockAlertNotifier{}
+	evaluator := NewStockAlertEvaluator(StockAlertEvaluatorConfig{
+		Supplies:  &fakeStockAlertSupplies{supplies: []models.Supply{reorderSupply(1, "VT-1", 2, 10)}},
+		Orders:    &fakeStockAlertOrders{},
+		Store:     store,
+		Assignees: fakeStockAlertAssignees{1: {7}},
+		Notifier:  notifier,
+	})
+
+	result, err := evaluator.Evaluate(context.Background())
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	if result.Raised != 1 || len(notifier.sent) != 1 {
+		t.Fatalf("result = %+v, sent = %+v, want a new alert pushed", result, notifier.sent)
+	}
+	if alert := store.alerts[1]; alert.ID != 11 || alert.Status != models.StockAlertStatusOpen {
+		t.Fatalf("alert = %+v, want a new open alert", alert)
+	}
+	if len(store.history) != 1 || store.history[0].ID != 1 || store.history[0].ResolvedAt == nil {
+		t.Fatalf("history = %+v, want the resolved alert kept", store.history)
+	}
+}
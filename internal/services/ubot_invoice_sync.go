@@ -0,0 +1,469 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+const (
+	defaultUBotAPIBaseURL   = "https://portal.ubot.vn/api"
+	defaultUBotTimeout      = 60 * time.Second
+	ubotInvoicePageSize     = 100
+	ubotInvoiceOverlapDays  = 3
+	ubotDateLayout          = "02/01/2006"
+	ubotInvoiceType         = "INPUT_ELECTRONIC_INVOICE"
+	ubotInvoiceStatusValid  = "VALID"
+	ubotInvoiceSyncDateForm = "2006-01-02"
+)
+
+const (
+	UBotInvoiceSyncStageLogin  = "login"
+	UBotInvoiceSyncStageFetch  = "fetch"
+	UBotInvoiceSyncStageImport = "import"
+)
+
+var (
+	ubotTenderCodePattern = regexp.MustCompile(`\b(?:9528|9530|9532|9534)\b`)
+	ubotTenderHintPattern = regexp.MustCompile(`(?i)(?:q\s*[đd]|quy[\s;_-]*[ếe]?t[\s;_-]*đ?[\s;_-]*[ií]?nh|h[\s;_-]*đ|h[\s;_-]*d|h[ợo][\s;_-]*p[\s;_-]*đ[ồo]ng)`)
+	ubotItemCodePatterns  = []*regexp.Regexp{
+		regexp.MustCompile(`^\[([^\]]+)\]`),
+		regexp.MustCompile(`^\(([^)]+)\)`),
+	}
+)
+
+type UBotInvoiceStore interface {
+	LatestInvoiceDate() (*time.Time, error)
+	UpsertInvoiceRows(rows []models.HoaDon) (models.HoaDonUpsertResult, error)
+	GetCount() (int, error)
+}
+
+type UBotInvoiceSyncConfig struct {
+	APIBaseURL     string
+	Username       string
+	Password       string
+	TimeoutSeconds int
+	Store          UBotInvoiceStore
+}
+
+// UBotInvoiceSyncResult is stored as the result of an invoice refresh job.
+type UBotInvoiceSyncResult struct {
+	FromDate string `json:"fromDate"`
+	ToDate   string `json:"toDate"`
+	Invoices int    `json:"invoices"`
+	Rows     int    `json:"rows"`
+	Inserted int    `json:"inserted"`
+	Updated  int    `json:"updated"`
+	Total    int    `json:"total"`
+}
+
+// UBotInvoiceSync pulls input e-invoices from the UBot portal and upserts
+// their lines into hoa_don. Callers run it through JobRunner, which keeps
+// refreshes single-flight.
+type UBotInvoiceSync struct {
+	apiBaseURL string
+	username   string
+	password   string
+	httpClient *http.Client
+	store      UBotInvoiceStore
+	now        func() time.Time
+}
+
+type ubotString string
+
+func (value *ubotString) UnmarshalJSON(data []byte) error {
+	var stringValue string
+	if err := json.Unmarshal(data, &stringValue); err == nil {
+		*value = ubotString(stringValue)
+		return nil
+	}
+
+	var numberValue json.Number
+	if err := json.Unmarshal(data, &numberValue); err != nil {
+		return fmt.Errorf("expected string or number: %w", err)
+	}
+	*value = ubotString(numberValue.String())
+	return nil
+}
+
+// ubotNumber accepts numbers, numeric strings ("8", "8%") and null. Values
+// that do not parse count as zero, as the old CSV import did.
+type ubotNumber float64
+
+func (value *ubotNumber) UnmarshalJSON(data []byte) error {
+	var numberValue float64
+	if err := json.Unmarshal(data, &numberValue); err == nil {
+		*value = ubotNumber(numberValue)
+		return nil
+	}
+
+	var stringValue string
+	if err := json.Unmarshal(data, &stringValue); err != nil {
+		*value = 0
+		return nil
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stringValue), "%")), 64)
+	if err != nil {
+		parsed = 0
+	}
+	*value = ubotNumber(parsed)
+	return nil
+}
+
+type ubotInvoice struct {
+	InvoiceID          ubotString        `json:"invoiceId"`
+	Status             string            `json:"status"`
+	ReleaseStatus      string            `json:"releaseStatus"`
+	InvoiceNo          ubotString        `json:"invoiceNo"`
+	ModelNo            ubotString        `json:"modelNo"`
+	Serial             string            `json:"serial"`
+	InvoiceReleaseDate string            `json:"invoiceReleaseDate"`
+	SellerTaxNo        string            `json:"sellerTaxNo"`
+	SellerName         string            `json:"sellerName"`
+	SellerAddress      string            `json:"sellerAddress"`
+	InvoiceItems       []ubotInvoiceItem `json:"invoiceItems"`
+}
+
+type ubotInvoiceItem struct {
+	ItemOrderNo  ubotNumber `json:"itemOrderNo"`
+	ItemName     string     `json:"itemName"`
+	ItemUnit     string     `json:"itemUnit"`
+	ItemQuantity ubotNumber `json:"itemQuantity"`
+	ItemPrice    ubotNumber `json:"itemPrice"`
+	ItemTax      ubotNumber `json:"itemTax"`
+}
+
+type ubotInvoicePage struct {
+	Metadata struct {
+		Total int `json:"total"`
+	} `json:"metadata"`
+	Data []ubotInvoice `json:"data"`
+}
+
+func NewUBotInvoiceSync(cfg UBotInvoiceSyncConfig) *UBotInvoiceSync {
+	timeout := defaultUBotTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.APIBaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultUBotAPIBaseURL
+	}
+
+	return &UBotInvoiceSync{
+		apiBaseURL: baseURL,
+		username:   strings.TrimSpace(cfg.Username),
+		password:   cfg.Password,
+		httpClient: &http.Client{Timeout: timeout},
+		store:      cfg.Store,
+		now:        time.Now,
+	}
+}
+
+func (s *UBotInvoiceSync) IsConfigured() bool {
+	return s != nil && s.store != nil && s.username != "" && s.password != ""
+}
+
+// Run refreshes invoices released in [from, to]. A nil from resumes a few
+// days before the newest stored invoice, or at the start of the month when
+// hoa_don is empty; a nil to means today. Pages are fetched first and
+// written in one transaction at the end, so a cancelled run changes nothing.
+func (s *UBotInvoiceSync) Run(ctx context.Context, from, to *time.Time, report JobProgressFunc) (UBotInvoiceSyncResult, error) {
+	if report == nil {
+		report = func(models.BackgroundJobProgress) {}
+	}
+
+	rangeFrom, rangeTo, err := s.resolveRange(from, to)
+	if err != nil {
+		return UBotInvoiceSyncResult{}, err
+	}
+	result := UBotInvoiceSyncResult{
+		FromDate: rangeFrom.Format(ubotInvoiceSyncDateForm),
+		ToDate:   rangeTo.Format(ubotInvoiceSyncDateForm),
+	}
+
+	report(models.BackgroundJobProgress{Stage: UBotInvoiceSyncStageLogin, Message: "Đang đăng nhập UBot"})
+	token, err := s.login(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	rows := make([]models.HoaDon, 0)
+	for page := 0; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		invoicePage, err := s.listInvoices(ctx, token, page, rangeFrom, rangeTo)
+		if err != nil {
+			return result, err
+		}
+		for _, invoice := range invoicePage.Data {
+			rows = append(rows, s.invoiceRows(invoice)...)
+		}
+		result.Invoices += len(invoicePage.Data)
+		result.Rows = len(rows)
+
+		report(models.BackgroundJobProgress{
+			Stage:   UBotInvoiceSyncStageFetch,
+			Current: page + 1,
+			Total:   (invoicePage.Metadata.Total + ubotInvoicePageSize - 1) / ubotInvoicePageSize,
+			Message: fmt.Sprintf("Đã tải %d hóa đơn (%d dòng hàng)", result.Invoices, result.Rows),
+		})
+
+		if len(invoicePage.Data) == 0 || (page+1)*ubotInvoicePageSize >= invoicePage.Metadata.Total {
+			break
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	report(models.BackgroundJobProgress{Stage: UBotInvoiceSyncStageImport, Current: 0, Total: len(rows), Message: "Đang ghi hóa đơn vào cơ sở dữ liệu"})
+	upserted, err := s.store.UpsertInvoiceRows(rows)
+	if err != nil {
+		return result, err
+	}
+	result.Inserted = upserted.Inserted
+	result.Updated = upserted.Updated
+
+	total, err := s.store.GetCount()
+	if err != nil {
+		return result, fmt.Errorf("error counting invoices: %w", err)
+	}
+	result.Total = total
+	report(models.BackgroundJobProgress{Stage: UBotInvoiceSyncStageImport, Current: len(rows), Total: len(rows), Message: "Đã cập nhật hóa đơn"})
+
+	log.Printf("[ubot-invoice-sync] imported %d rows (%d inserted, %d updated)", len(rows), upserted.Inserted, upserted.Updated)
+	return result, nil
+}
+
+func (s *UBotInvoiceSync) resolveRange(from, to *time.Time) (time.Time, time.Time, error) {
+	today := s.now()
+	rangeTo := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	if to != nil {
+		rangeTo = *to
+	}
+
+	var rangeFrom time.Time
+	switch {
+	case from != nil:
+		rangeFrom = *from
+	default:
+		latest, err := s.store.LatestInvoiceDate()
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if latest != nil {
+			rangeFrom = latest.AddDate(0, 0, -ubotInvoiceOverlapDays)
+		} else {
+			rangeFrom = time.Date(rangeTo.Year(), rangeTo.Month(), 1, 0, 0, 0, 0, rangeTo.Location())
+		}
+	}
+
+	if rangeFrom.After(rangeTo) {
+		return time.Time{}, time.Time{}, fmt.Errorf("fromDate %s is after toDate %s", rangeFrom.Format(ubotInvoiceSyncDateForm), rangeTo.Format(ubotInvoiceSyncDateForm))
+	}
+	return rangeFrom, rangeTo, nil
+}
+
+func (s *UBotInvoiceSync) login(ctx context.Context) (string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"username":   s.username,
+		"password":   s.password,
+		"rememberMe": false,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		IDToken string `json:"id_token"`
+		Token   string `json:"token"`
+	}
+	if err := s.postJSON(ctx, s.apiBaseURL+"/authenticate", "", payload, &response); err != nil {
+		return "", fmt.Errorf("UBot login failed: %w", err)
+	}
+
+	token := strings.TrimSpace(response.IDToken)
+	if token == "" {
+		token = strings.TrimSpace(response.Token)
+	}
+	if token == "" {
+		return "", fmt.Errorf("UBot login failed: response has no token")
+	}
+	return token, nil
+}
+
+func (s *UBotInvoiceSync) listInvoices(ctx context.Context, token string, page int, from, to time.Time) (ubotInvoicePage, error) {
+	var result ubotInvoicePage
+	payload, err := json.Marshal(map[string]interface{}{
+		"invoiceTypes":     []string{ubotInvoiceType},
+		"invoiceStatus":    ubotInvoiceStatusValid,
+		"releasedDateFrom": from.Format(ubotDateLayout),
+		"releasedDateTo":   to.Format(ubotDateLayout),
+		"getMatchingData":  false,
+		"getAttachments":   true,
+		"getTaxes":         false,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("size", strconv.Itoa(ubotInvoicePageSize))
+	query.Set("sort", "id,desc")
+	endpoint := s.apiBaseURL + "/third-party/invoices?" + query.Encode()
+
+	if err := s.postJSON(ctx, endpoint, token, payload, &result); err != nil {
+		return result, fmt.Errorf("error fetching UBot invoices page %d: %w", page, err)
+	}
+	return result, nil
+}
+
+func (s *UBotInvoiceSync) postJSON(ctx context.Context, endpoint, token string, payload []byte, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("UBot API returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("invalid UBot response: %w", err)
+	}
+	return nil
+}
+
+// invoiceRows flattens one UBot invoice into hoa_don lines. Note-only lines
+// (no quantity and no price) are dropped but feed the invoice context used
+// to infer the tender package.
+func (s *UBotInvoiceSync) invoiceRows(invoice ubotInvoice) []models.HoaDon {
+	invoiceDate, ok := parseUBotInvoiceDate(invoice.InvoiceReleaseDate)
+	if !ok {
+		return nil
+	}
+
+	invoiceID := strings.TrimSpace(string(invoice.InvoiceID))
+	link := ""
+	if invoiceID != "" {
+		link = s.apiBaseURL + "/invoices/" + invoiceID + "/pdf/blob"
+	}
+	base := models.HoaDon{
+		TrangThaiHoaDon:  cleanUBotText(invoice.Status),
+		LoaiHoaDon:       cleanUBotText(invoice.ReleaseStatus),
+		SoHoaDon:         cleanUBotText(string(invoice.InvoiceNo)),
+		KyHieu:           cleanUBotText(string(invoice.ModelNo) + invoice.Serial),
+		NgayHoaDon:       invoiceDate,
+		MaSoThueNguoiBan: cleanUBotText(invoice.SellerTaxNo),
+		CongTy:           cleanUBotText(invoice.SellerName),
+		DiaChi:           cleanUBotText(invoice.SellerAddress),
+		LinkTraCuuHoaDon: link,
+		IDHoaDon:         invoiceID,
+		InvoiceContext:   cleanUBotText(buildUBotInvoiceContext(invoice.InvoiceItems)),
+	}
+
+	rows := make([]models.HoaDon, 0, len(invoice.InvoiceItems))
+	for _, item := range invoice.InvoiceItems {
+		if item.ItemQuantity == 0 && item.ItemPrice == 0 {
+			continue
+		}
+		row := base
+		row.STTDongHang = int(item.ItemOrderNo)
+		row.TenHangHoa = cleanUBotText(item.ItemName)
+		row.MaHangHoa = cleanUBotText(extractUBotItemCode(item.ItemName))
+		row.DonViTinh = cleanUBotText(item.ItemUnit)
+		row.SoLuong = float64(item.ItemQuantity)
+		row.DonGiaChuaThue = float64(item.ItemPrice)
+		row.ThueSuatGTGT = float64(item.ItemTax)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func parseUBotInvoiceDate(value string) (time.Time, bool) {
+	trimmed := strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", ubotInvoiceSyncDateForm} {
+		if parsed, err := time.Parse(layout, trimmed); err == nil {
+			return time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// buildUBotInvoiceContext joins the note lines of an invoice, plus any line
+// quoting a tender decision or contract, in item order.
+func buildUBotInvoiceContext(items []ubotInvoiceItem) string {
+	sorted := append([]ubotInvoiceItem(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ItemOrderNo < sorted[j].ItemOrderNo })
+
+	parts := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, item := range sorted {
+		name := strings.Join(strings.Fields(item.ItemName), " ")
+		if name == "" {
+			continue
+		}
+		isNote := item.ItemQuantity == 0 && item.ItemPrice == 0
+		if !isNote && !containsUBotTenderReference(name) {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func containsUBotTenderReference(value string) bool {
+	return ubotTenderCodePattern.MatchString(value) && ubotTenderHintPattern.MatchString(value)
+}
+
+// extractUBotItemCode reads the item code suppliers put in brackets at the
+// start of the name, e.g. "[A33201] Bóng nong" or "(C02141) Dao cắt".
+func extractUBotItemCode(itemName string) string {
+	for _, pattern := range ubotItemCodePatterns {
+		match := pattern.FindStringSubmatch(itemName)
+		if match == nil {
+			continue
+		}
+		code := strings.TrimSpace(match[1])
+		if !strings.Contains(strings.ToLower(code), "theo") {
+			return code
+		}
+	}
+	return ""
+}
+
+// cleanUBotText flattens multi-line UBot values the way the CSV export did.
+func cleanUBotText(value string) string {
+	replacer := strings.NewReplacer("\n", "; ", "\r", "", "\t", " ")
+	return strings.Join(strings.Fields(replacer.Replace(value)), " ")
+}
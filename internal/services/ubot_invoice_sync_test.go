@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+func TestUBotInvoiceSyncImportsPagedInvoices(t *testing.T) {
+	t.Parallel()
+
+	server := newUBotTestServer(t)
+	defer server.Close()
+
+	latest := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	store := &memoryUBotInvoiceStore{latest: &latest, count: 42}
+	service := NewUBotInvoiceSync(UBotInvoiceSyncConfig{
+		APIBaseURL: server.URL,
+		Username:   "kho@benhvien108.vn",
+		Password:   "secret",
+		Store:      store,
+	})
+	service.now = func() time.Time { return time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC) }
+
+	progress := make([]models.BackgroundJobProgress, 0)
+	result, err := service.Run(context.Background(), nil, nil, func(update models.BackgroundJobProgress) {
+		progress = append(progress, update)
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.FromDate != "2026-10-07" || result.ToDate != "2026-10-16" {
+		t.Fatalf("range = %s..%s, want 2026-10-07..2026-10-16", result.FromDate, result.ToDate)
+	}
+	if result.Invoices != 101 || result.Rows != 102 || result.Inserted != 102 || result.Total != 42 {
+		t.Fatalf("result = %+v", result)
+	}
+	if got := server.requestedRanges(); len(got) != 2 || got[0] != "07/10/2026-16/10/2026" {
+		t.Fatalf("requested ranges = %v", got)
+	}
+	if len(progress) != 5 || progress[2].Stage != UBotInvoiceSyncStageFetch || progress[2].Current != 2 || progress[2].Total != 2 {
+		t.Fatalf("progress = %+v", progress)
+	}
+
+	first := store.rows[0]
+	if first.IDHoaDon != "INV-1" || first.STTDongHang != 1 || first.MaHangHoa != "A33201" || first.KyHieu != "1C26TAA" {
+		t.Fatalf("first row = %+v", first)
+	}
+	if first.TenHangHoa != "[A33201] Bóng nong; mạch vành" || first.ThueSuatGTGT != 5 || first.SoLuong != 10 {
+		t.Fatalf("first row = %+v", first)
+	}
+	if first.InvoiceContext != "Theo QĐ số 9528/QĐ-BV" {
+		t.Fatalf("invoice context = %q", first.InvoiceContext)
+	}
+	if !first.NgayHoaDon.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("invoice date = %s", first.NgayHoaDon)
+	}
+	if first.LinkTraCuuHoaDon != server.URL+"/invoices/INV-1/pdf/blob" {
+		t.Fatalf("link = %q", first.LinkTraCuuHoaDon)
+	}
+}
+
+func TestUBotInvoiceSyncReportsLoginFailure(t *testing.T) {
+	t.Parallel()
+
+	server := newUBotTestServer(t)
+	defer server.Close()
+
+	store := &memoryUBotInvoiceStore{}
+	service := NewUBotInvoiceSync(UBotInvoiceSyncConfig{
+		APIBaseURL: server.URL,
+		Username:   "kho@benhvien108.vn",
+		Password:   "wrong",
+		Store:      store,
+	})
+
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := service.Run(context.Background(), &from, &to, nil); err == nil {
+		t.Fatal("Run() succeeded with a rejected login")
+	}
+	if store.upserts != 0 {
+		t.Fatalf("upserts = %d, want none after a failed login", store.upserts)
+	}
+}
+
+func TestUBotInvoiceSyncStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	server := newUBotTestServer(t)
+	defer server.Close()
+
+	store := &memoryUBotInvoiceStore{}
+	service := NewUBotInvoiceSync(UBotInvoiceSyncConfig{
+		APIBaseURL: server.URL,
+		Username:   "kho@benhvien108.vn",
+		Password:   "secret",
+		Store:      store,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	_, err := service.Run(ctx, &from, nil, func(update models.BackgroundJobProgress) {
+		if update.Stage == UBotInvoiceSyncStageFetch {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if store.upserts != 0 {
+		t.Fatalf("upserts = %d, want none after cancellation", store.upserts)
+	}
+}
+
+func TestUBotInvoiceSyncRejectsInvertedRange(t *testing.T) {
+	t.Parallel()
+
+	service := NewUBotInvoiceSync(UBotInvoiceSyncConfig{Username: "u", Password: "p", Store: &memoryUBotInvoiceStore{}})
+	from := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := service.Run(context.Background(), &from, &to, nil); err == nil {
+		t.Fatal("Run() accepted fromDate after toDate")
+	}
+}
+
+func TestExtractUBotItemCode(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"[A33201] Bóng nong":          "A33201",
+		"(C02141) Dao cắt":            "C02141",
+		"[Theo hợp đồng 12] Kim luồn": "",
+		"Găng tay [G01]":              "",
+	}
+	for name, want := range testCases {
+		if got := extractUBotItemCode(name); got != want {
+			t.Errorf("extractUBotItemCode(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+type ubotTestServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (s *ubotTestServer) requestedRanges() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ranges...)
+}
+
+// newUBotTestServer stands in for the UBot portal: one login, then 101
+// invoices split over two pages of 100.
+func newUBotTestServer(t *testing.T) *ubotTestServer {
+	t.Helper()
+
+	server := &ubotTestServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authenticate", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Password string `json:"password"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Password != "secret" {
+			http.Error(w, `{"title":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": "test-token"})
+	})
+	mux.HandleFunc("/third-party/invoices", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			http.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			ReleasedDateFrom string `json:"releasedDateFrom"`
+			ReleasedDateTo   string `json:"releasedDateTo"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		server.mu.Lock()
+		server.ranges = append(server.ranges, body.ReleasedDateFrom+"-"+body.ReleasedDateTo)
+		server.mu.Unlock()
+
+		invoices := make([]map[string]interface{}, 0, 100)
+		if r.URL.Query().Get("page") == "0" {
+			invoices = append(invoices, map[string]interface{}{
+				"invoiceId":          "INV-1",
+				"status":             "VALID",
+				"releaseStatus":      "VALID",
+				"invoiceNo":          1234,
+				"modelNo":            "1",
+				"serial":             "C26TAA",
+				"invoiceReleaseDate": "2026-10-12T07:00:00Z",
+				"sellerTaxNo":        "0101234567",
+				"sellerName":         "Công ty Thiết bị Y tế A",
+				"invoiceItems": []map[string]interface{}{
+					{"itemOrderNo": 1, "itemName": "[A33201] Bóng nong\nmạch vành", "itemUnit": "Cái", "itemQuantity": 10, "itemPrice": 1500000, "itemTax": 5},
+					{"itemOrderNo": 2, "itemName": "Bóng nong dự phòng", "itemUnit": "Cái", "itemQuantity": 1, "itemPrice": 1500000, "itemTax": "5%"},
+					{"itemOrderNo": 3, "itemName": "Theo QĐ số 9528/QĐ-BV", "itemQuantity": 0, "itemPrice": 0},
+				},
+			})
+			for index := 2; index <= 99; index++ {
+				invoices = append(invoices, ubotTestInvoice(index))
+			}
+		} else {
+			invoices = append(invoices, ubotTestInvoice(100), ubotTestInvoice(101))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"metadata": map[string]interface{}{"total": 101},
+			"data":     invoices,
+		})
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
+
+func ubotTestInvoice(index int) map[string]interface{} {
+	return map[string]interface{}{
+		"invoiceId":          index,
+		"invoiceNo":          index,
+		"invoiceReleaseDate": "2026-10-13T07:00:00Z",
+		"sellerName":         "Công ty B",
+		"invoiceItems": []map[string]interface{}{
+			{"itemOrderNo": 1, "itemName": "Găng tay", "itemQuantity": 5, "itemPrice": 1000},
+		},
+	}
+}
+
+type memoryUBotInvoiceStore struct {
+	mu      sync.Mutex
+	latest  *time.Time
+	count   int
+	rows    []models.HoaDon
+	upserts int
+}
+
+func (s *memoryUBotInvoiceStore) LatestInvoiceDate() (*time.Time, error) {
+	return s.latest, nil
+}
+
+func (s *memoryUBotInvoiceStore) UpsertInvoiceRows(rows []models.HoaDon) (models.HoaDonUpsertResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.upserts++
+	s.rows = append(s.rows, rows...)
+	return models.HoaDonUpsertResult{Inserted: len(rows)}, nil
+}
+
+func (s *memoryUBotInvoiceStore) GetCount() (int, error) {
+	return s.count, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -26,10 +27,20 @@ const (
 var vinmesTenderCodePattern = regexp.MustCompile(`\b(2233|4418|7313|9528|9530|9532|9534)\b`)
 
 type VinmesCatalogConfig struct {
-	APIBaseURL     string
-	APIToken       string
-	TimeoutSeconds int
-	CatalogStore   VinmesCatalogStore
+	APIBaseURL      string
+	APIToken        string
+	TimeoutSeconds  int
+	CatalogStore    VinmesCatalogStore
+	SubmissionStore VinmesSubmissionStore
+	ExportLedger    VinmesExportLedgerStore
+	RefreshLog      VinmesCatalogRefreshLog
+	OverrideStore   VinmesMappingOverrideStore
+	// Nightly refresh of the stored catalogs; disabled unless ScheduleEnabled.
+	ScheduleEnabled      bool
+	ScheduleHour         int
+	ScheduleMinute       int
+	ScheduleTimezone     string
+	ScheduleRunOnStartup bool
 }
 
 type VinmesCatalogStore interface {
@@ -38,10 +49,17 @@ type VinmesCatalogStore interface {
 }
 
 type VinmesCatalogService struct {
-	apiBaseURL   string
-	apiToken     string
-	httpClient   *http.Client
-	catalogStore VinmesCatalogStore
+	apiBaseURL      string
+	apiToken        string
+	httpClient      *http.Client
+	catalogStore    VinmesCatalogStore
+	submissionStore VinmesSubmissionStore
+	exportLedger    VinmesExportLedgerStore
+	refreshLog      VinmesCatalogRefreshLog
+	overrideStore   VinmesMappingOverrideStore
+	schedule        vinmesCatalogSchedule
+	submitMu        sync.Mutex
+	refreshMu       sync.Mutex
 }
 
 type vinmesStorage struct {
@@ -117,9 +135,14 @@ type vinmesCatalogs struct {
 }
 
 type VinmesCatalogRefreshResult struct {
-	Total    int            `json:"total"`
-	Counts   map[string]int `json:"counts"`
-	SyncedAt time.Time      `json:"syncedAt"`
+	RunID        *int64                             `json:"runId,omitempty"`
+	Total        int                                `json:"total"`
+	Counts       map[string]int                     `json:"counts"`
+	AddedCount   int                                `json:"addedCount"`
+	RemovedCount int                                `json:"removedCount"`
+	ChangedCount int                                `json:"changedCount"`
+	Diff         []models.VinmesCatalogResourceDiff `json:"diff"`
+	SyncedAt     time.Time                          `json:"syncedAt"`
 }
 
 type VinmesC10Options struct {
@@ -190,11 +213,32 @@ func NewVinmesCatalogService(cfg VinmesCatalogConfig) *VinmesCatalogService {
 		timeout = defaultVinmesCatalogTimeout
 	}
 
+	location := time.Local
+	if timezone := strings.TrimSpace(cfg.ScheduleTimezone); timezone != "" {
+		loaded, err := time.LoadLocation(timezone)
+		if err != nil {
+			log.Printf("[vinmes-catalog-sync] invalid timezone %q, fallback to local: %v", timezone, err)
+		} else {
+			location = loaded
+		}
+	}
+
 	return &VinmesCatalogService{
-		apiBaseURL:   strings.TrimRight(strings.TrimSpace(cfg.APIBaseURL), "/"),
-		apiToken:     strings.TrimSpace(cfg.APIToken),
-		httpClient:   &http.Client{Timeout: timeout},
-		catalogStore: cfg.CatalogStore,
+		apiBaseURL:      strings.TrimRight(strings.TrimSpace(cfg.APIBaseURL), "/"),
+		apiToken:        strings.TrimSpace(cfg.APIToken),
+		httpClient:      &http.Client{Timeout: timeout},
+		catalogStore:    cfg.CatalogStore,
+		submissionStore: cfg.SubmissionStore,
+		exportLedger:    cfg.ExportLedger,
+		refreshLog:      cfg.RefreshLog,
+		overrideStore:   cfg.OverrideStore,
+		schedule: vinmesCatalogSchedule{
+			enabled:      cfg.ScheduleEnabled,
+			hour:         cfg.ScheduleHour,
+			minute:       cfg.ScheduleMinute,
+			location:     location,
+			runOnStartup: cfg.ScheduleRunOnStartup,
+		},
 	}
 }
 
@@ -211,10 +255,14 @@ func (s *VinmesCatalogService) BuildMappingPreview(ctx context.Context, masters
 	if err != nil {
 		return nil, err
 	}
+	overrides, err := s.loadMappingOverrides()
+	if err != nil {
+		return nil, err
+	}
 
 	result := make([]VinmesMappedPurchaseOrder, 0, len(masters))
 	for _, master := range masters {
-		result = append(result, mapVinmesMaster(master, catalogs))
+		result = append(result, mapVinmesMaster(master, catalogs, overrides))
 	}
 	return result, nil
 }
@@ -228,7 +276,7 @@ func (s *VinmesCatalogService) loadCatalogs(ctx context.Context) (*vinmesCatalog
 		if len(items) > 0 {
 			return catalogsFromStoredItems(items)
 		}
-		_, catalogs, err := s.refreshCatalogs(ctx)
+		_, catalogs, err := s.refreshCatalogs(ctx, models.VinmesCatalogRefreshTriggerBootstrap)
 		return catalogs, err
 	}
 	return s.loadRemoteCatalogs(ctx)
@@ -238,26 +286,64 @@ func (s *VinmesCatalogService) RefreshCatalogs(ctx context.Context) (*VinmesCata
 	if !s.IsConfigured() {
 		return nil, fmt.Errorf("Vinmes catalog API is not configured")
 	}
-	result, _, err := s.refreshCatalogs(ctx)
+	result, _, err := s.refreshCatalogs(ctx, models.VinmesCatalogRefreshTriggerManual)
 	return result, err
 }
 
-func (s *VinmesCatalogService) refreshCatalogs(ctx context.Context) (*VinmesCatalogRefreshResult, *vinmesCatalogs, error) {
+func (s *VinmesCatalogService) refreshCatalogs(ctx context.Context, trigger string) (*VinmesCatalogRefreshResult, *vinmesCatalogs, error) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	syncedAt := time.Now()
 	catalogs, err := s.loadRemoteCatalogs(ctx)
 	if err != nil {
+		s.recordRefreshFailure(trigger, syncedAt, err)
 		return nil, nil, err
 	}
-	syncedAt := time.Now()
 	items, counts, err := storedItemsFromCatalogs(catalogs, syncedAt)
 	if err != nil {
+		s.recordRefreshFailure(trigger, syncedAt, err)
 		return nil, nil, err
 	}
+
+	var diff []models.VinmesCatalogResourceDiff
 	if s.catalogStore != nil {
+		previous, err := s.catalogStore.ListAll()
+		if err != nil {
+			s.recordRefreshFailure(trigger, syncedAt, err)
+			return nil, nil, err
+		}
+		diff = diffVinmesCatalogItems(previous, items)
 		if err := s.catalogStore.ReplaceAll(items, syncedAt); err != nil {
+			s.recordRefreshFailure(trigger, syncedAt, err)
 			return nil, nil, err
 		}
 	}
-	return &VinmesCatalogRefreshResult{Total: len(items), Counts: counts, SyncedAt: syncedAt}, catalogs, nil
+
+	result := &VinmesCatalogRefreshResult{Total: len(items), Counts: counts, SyncedAt: syncedAt, Diff: diff}
+	for _, resource := range diff {
+		result.AddedCount += len(resource.Added)
+		result.RemovedCount += len(resource.Removed)
+		result.ChangedCount += len(resource.Changed)
+	}
+	if s.refreshLog != nil {
+		run := &models.VinmesCatalogRefreshRun{
+			Trigger:      trigger,
+			Status:       models.VinmesCatalogRefreshStatusSuccess,
+			Total:        result.Total,
+			AddedCount:   result.AddedCount,
+			RemovedCount: result.RemovedCount,
+			ChangedCount: result.ChangedCount,
+			Resources:    diff,
+			SyncedAt:     syncedAt,
+		}
+		if err := s.refreshLog.RecordRefreshRun(run); err != nil {
+			log.Printf("[vinmes-catalog-sync] warning: failed to record refresh run: %v", err)
+		} else {
+			result.RunID = int64Pointer(run.ID)
+		}
+	}
+	return result, catalogs, nil
 }
 
 func (s *VinmesCatalogService) loadRemoteCatalogs(ctx context.Context) (*vinmesCatalogs, error) {
@@ -492,7 +578,9 @@ func bearerAuthorization(token string) string {
 	return "Bearer " + token
 }
 
-func mapVinmesMaster(master models.VinmesExportMaster, catalogs *vinmesCatalogs) VinmesMappedPurchaseOrder {
+// mapVinmesMaster consults the manual overrides for partner, contract package
+// and product before falling back to catalog matching.
+func mapVinmesMaster(master models.VinmesExportMaster, catalogs *vinmesCatalogs, overrides vinmesMappingOverrides) VinmesMappedPurchaseOrder {
 	mapped := VinmesMappedPurchaseOrder{
 		Master: VinmesC10MasterRequest{
 			Options: VinmesC10Options{DML: true},
@@ -520,9 +608,13 @@ func mapVinmesMaster(master models.VinmesExportMaster, catalogs *vinmesCatalogs)
 
 	mapStorage(master, catalogs, &mapped)
 	mapResource(master, catalogs, &mapped)
-	mapPartner(master, catalogs, &mapped)
+	if !applyPartnerOverride(master, catalogs, overrides, &mapped) {
+		mapPartner(master, catalogs, &mapped)
+	}
 	mapTax(master, catalogs, &mapped)
-	mapContractPackage(master, catalogs, &mapped)
+	if !applyContractPackageOverride(master, catalogs, overrides, &mapped) {
+		mapContractPackage(master, catalogs, &mapped)
+	}
 	validateMasterFields(master, &mapped)
 
 	productsByCode := make(map[string][]vinmesProduct, len(catalogs.Products))
@@ -542,10 +634,11 @@ func mapVinmesMaster(master models.VinmesExportMaster, catalogs *vinmesCatalogs)
 			},
 		}
 		matches := productsByCode[normalizeCode(detail.MaHang)]
-		switch len(matches) {
-		case 1:
+		switch {
+		case applyProductOverride(index, detail, catalogs, overrides, &mapped, &request):
+		case len(matches) == 1:
 			request.Binds.ProductID = int64Pointer(matches[0].ID)
-		case 0:
+		case len(matches) == 0:
 			mapped.addError(fmt.Sprintf("details[%d].p_product_id", index), detail.MaHang, "Không tìm thấy mã hàng trong danh mục Vinmes")
 		default:
 			mapped.addError(fmt.Sprintf("details[%d].p_product_id", index), detail.MaHang, "Mã hàng khớp nhiều sản phẩm Vinmes")
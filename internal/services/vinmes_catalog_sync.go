@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+type VinmesCatalogRefreshLog interface {
+	RecordRefreshRun(run *models.VinmesCatalogRefreshRun) error
+	ListRefreshRuns(limit int) ([]models.VinmesCatalogRefreshRun, error)
+	GetRefreshRun(runID int64) (*models.VinmesCatalogRefreshRun, error)
+}
+
+type vinmesCatalogSchedule struct {
+	enabled      bool
+	hour         int
+	minute       int
+	location     *time.Location
+	runOnStartup bool
+}
+
+// vinmesCatalogResources lists the stored catalog types in the order the diff
+// report presents them, together with the Vinmes resource they come from.
+var vinmesCatalogResources = []struct {
+	catalogType string
+	resource    string
+}{
+	{catalogType: "storage", resource: "storage_select_for_po"},
+	{catalogType: "partner", resource: "partner_select_for_po"},
+	{catalogType: "resource", resource: "resource_select_for_po"},
+	{catalogType: "tax", resource: "tax_select_for_po"},
+	{catalogType: "contract_package", resource: "contractpkg_select_for_po"},
+	{catalogType: "contract", resource: "contract_select_for_po"},
+	{catalogType: "product", resource: "product_select_for_po"},
+}
+
+func (s *VinmesCatalogService) Start(ctx context.Context) {
+	if !s.schedule.enabled {
+		log.Println("[vinmes-catalog-sync] disabled by VINMES_CATALOG_SYNC_ENABLED")
+		return
+	}
+	if !s.IsConfigured() {
+		log.Println("[vinmes-catalog-sync] skipped because VINMES_API_BASE_URL is empty")
+		return
+	}
+
+	go s.runScheduler(ctx)
+}
+
+func (s *VinmesCatalogService) ListRefreshRuns(limit int) ([]models.VinmesCatalogRefreshRun, error) {
+	if s == nil || s.refreshLog == nil {
+		return nil, fmt.Errorf("Vinmes catalog refresh log is not configured")
+	}
+	return s.refreshLog.ListRefreshRuns(limit)
+}
+
+func (s *VinmesCatalogService) GetRefreshRun(runID int64) (*models.VinmesCatalogRefreshRun, error) {
+	if s == nil || s.refreshLog == nil {
+		return nil, fmt.Errorf("Vinmes catalog refresh log is not configured")
+	}
+	return s.refreshLog.GetRefreshRun(runID)
+}
+
+func (s *VinmesCatalogService) runScheduler(ctx context.Context) {
+	if s.schedule.runOnStartup {
+		if _, _, err := s.refreshCatalogs(ctx, models.VinmesCatalogRefreshTriggerScheduled); err != nil {
+			log.Printf("[vinmes-catalog-sync] startup refresh failed: %v", err)
+		}
+	}
+
+	for {
+		waitDuration := s.durationUntilNextRun(time.Now())
+		timer := time.NewTimer(waitDuration)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			result, _, err := s.refreshCatalogs(ctx, models.VinmesCatalogRefreshTriggerScheduled)
+			if err != nil {
+				log.Printf("[vinmes-catalog-sync] scheduled refresh failed: %v", err)
+				continue
+			}
+			log.Printf(
+				"[vinmes-catalog-sync] refreshed %d catalog items (%d added, %d removed, %d changed)",
+				result.Total, result.AddedCount, result.RemovedCount, result.ChangedCount,
+			)
+		}
+	}
+}
+
+func (s *VinmesCatalogService) durationUntilNextRun(now time.Time) time.Duration {
+	location := s.schedule.location
+	if location == nil {
+		location = time.Local
+	}
+
+	current := now.In(location)
+	nextRun := time.Date(
+		current.Year(),
+		current.Month(),
+		current.Day(),
+		s.schedule.hour,
+		s.schedule.minute,
+		0,
+		0,
+		location,
+	)
+
+	if !nextRun.After(current) {
+		nextRun = nextRun.Add(24 * time.Hour)
+	}
+
+	return nextRun.Sub(current)
+}
+
+func (s *VinmesCatalogService) recordRefreshFailure(trigger string, syncedAt time.Time, cause error) {
+	if s.refreshLog == nil {
+		return
+	}
+	run := &models.VinmesCatalogRefreshRun{
+		Trigger:      trigger,
+		Status:       models.VinmesCatalogRefreshStatusFailed,
+		ErrorMessage: cause.Error(),
+		SyncedAt:     syncedAt,
+	}
+	if err := s.refreshLog.RecordRefreshRun(run); err != nil {
+		log.Printf("[vinmes-catalog-sync] warning: failed to record refresh failure: %v", err)
+	}
+}
+
+// diffVinmesCatalogItems compares two catalog snapshots per catalog type,
+// keyed by external ID. Vinmes may repeat an external ID, so a key counts as
+// changed when its rows differ in number or content.
+func diffVinmesCatalogItems(previous, current []models.VinmesCatalogItem) []models.VinmesCatalogResourceDiff {
+	previousByType := groupVinmesCatalogItems(previous)
+	currentByType := groupVinmesCatalogItems(current)
+
+	result := make([]models.VinmesCatalogResourceDiff, 0, len(vinmesCatalogResources))
+	for _, resource := range vinmesCatalogResources {
+		before := previousByType[resource.catalogType]
+		after := currentByType[resource.catalogType]
+		diff := models.VinmesCatalogResourceDiff{
+			CatalogType: resource.catalogType,
+			Resource:    resource.resource,
+			Added:       make([]models.VinmesCatalogDiffItem, 0),
+			Removed:     make([]models.VinmesCatalogDiffItem, 0),
+			Changed:     make([]models.VinmesCatalogDiffItem, 0),
+		}
+
+		for _, key := range sortedVinmesCatalogKeys(before) {
+			diff.PreviousCount += len(before[key])
+			if _, ok := after[key]; !ok {
+				diff.Removed = append(diff.Removed, vinmesCatalogDiffItem(before[key][0], nil))
+			}
+		}
+		for _, key := range sortedVinmesCatalogKeys(after) {
+			diff.CurrentCount += len(after[key])
+			oldRows, ok := before[key]
+			if !ok {
+				diff.Added = append(diff.Added, vinmesCatalogDiffItem(after[key][0], nil))
+				continue
+			}
+			if changes := compareVinmesCatalogRows(oldRows, after[key]); len(changes) > 0 {
+				diff.Changed = append(diff.Changed, vinmesCatalogDiffItem(after[key][0], changes))
+			}
+		}
+
+		result = append(result, diff)
+	}
+	return result
+}
+
+func groupVinmesCatalogItems(items []models.VinmesCatalogItem) map[string]map[string][]models.VinmesCatalogItem {
+	grouped := make(map[string]map[string][]models.VinmesCatalogItem)
+	for _, item := range items {
+		byID, ok := grouped[item.CatalogType]
+		if !ok {
+			byID = make(map[string][]models.VinmesCatalogItem)
+			grouped[item.CatalogType] = byID
+		}
+		byID[item.ExternalID] = append(byID[item.ExternalID], item)
+	}
+	for _, byID := range grouped {
+		for key := range byID {
+			rows := byID[key]
+			sort.SliceStable(rows, func(i, j int) bool { return rows[i].RawPayload < rows[j].RawPayload })
+		}
+	}
+	return grouped
+}
+
+func sortedVinmesCatalogKeys(rows map[string][]models.VinmesCatalogItem) []string {
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compareVinmesCatalogRows(before, after []models.VinmesCatalogItem) []string {
+	if len(before) != len(after) {
+		return []string{"count"}
+	}
+
+	changed := make(map[string]struct{})
+	for index := range before {
+		oldRow, newRow := before[index], after[index]
+		if oldRow.Code != newRow.Code {
+			changed["code"] = struct{}{}
+		}
+		if oldRow.Name != newRow.Name {
+			changed["name"] = struct{}{}
+		}
+		if oldRow.TaxCode != newRow.TaxCode {
+			changed["taxCode"] = struct{}{}
+		}
+		if oldRow.BankAccount != newRow.BankAccount {
+			changed["bankAccount"] = struct{}{}
+		}
+		if formatOptionalRate(oldRow.TaxRate) != formatOptionalRate(newRow.TaxRate) {
+			changed["taxRate"] = struct{}{}
+		}
+		if oldRow.RawPayload != newRow.RawPayload {
+			changed["payload"] = struct{}{}
+		}
+	}
+
+	changes := make([]string, 0, len(changed))
+	for field := range changed {
+		changes = append(changes, field)
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func vinmesCatalogDiffItem(item models.VinmesCatalogItem, changes []string) models.VinmesCatalogDiffItem {
+	return models.VinmesCatalogDiffItem{
+		ExternalID: item.ExternalID,
+		Code:       item.Code,
+		Name:       item.Name,
+		Changes:    changes,
+	}
+}
+
+func formatOptionalRate(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+func TestDiffVinmesCatalogItemsReportsAddedRemovedAndChanged(t *testing.T) {
+	t.Parallel()
+
+	previous := []models.VinmesCatalogItem{
+		{CatalogType: "product", ExternalID: "1", Code: "A", Name: "Nẹp", RawPayload: `{"id":1,"code":"A"}`},
+		{CatalogType: "product", ExternalID: "2", Code: "B", Name: "Vít", RawPayload: `{"id":2,"code":"B"}`},
+		{CatalogType: "contract", ExternalID: "HD-1", Code: "HD-1", RawPayload: `{"adc_contract_id":"HD-1"}`},
+	}
+	current := []models.VinmesCatalogItem{
+		{CatalogType: "product", ExternalID: "1", Code: "A", Name: "Nẹp 2.0mm", RawPayload: `{"id":1,"code":"A","name":"Nẹp 2.0mm"}`},
+		{CatalogType: "product", ExternalID: "3", Code: "C", Name: "Kim", RawPayload: `{"id":3,"code":"C"}`},
+		{CatalogType: "contract", ExternalID: "HD-1", Code: "HD-1", RawPayload: `{"adc_contract_id":"HD-1"}`},
+	}
+
+	diff := diffVinmesCatalogItems(previous, current)
+	if len(diff) != len(vinmesCatalogResources) {
+		t.Fatalf("diff resources = %d, want %d", len(diff), len(vinmesCatalogResources))
+	}
+
+	byType := make(map[string]models.VinmesCatalogResourceDiff, len(diff))
+	for _, resource := range diff {
+		byType[resource.CatalogType] = resource
+	}
+
+	products := byType["product"]
+	if products.Resource != "product_select_for_po" || products.PreviousCount != 2 || products.CurrentCount != 2 {
+		t.Fatalf("product diff = %+v", products)
+	}
+	if len(products.Added) != 1 || products.Added[0].ExternalID != "3" {
+		t.Fatalf("added products = %+v", products.Added)
+	}
+	if len(products.Removed) != 1 || products.Removed[0].ExternalID != "2" {
+		t.Fatalf("removed products = %+v", products.Removed)
+	}
+	if len(products.Changed) != 1 || products.Changed[0].ExternalID != "1" {
+		t.Fatalf("changed products = %+v", products.Changed)
+	}
+	if got := products.Changed[0].Changes; len(got) != 2 || got[0] != "name" || got[1] != "payload" {
+		t.Fatalf("changed fields = %v", got)
+	}
+
+	contracts := byType["contract"]
+	if len(contracts.Added)+len(contracts.Removed)+len(contracts.Changed) != 0 {
+		t.Fatalf("contract diff = %+v", contracts)
+	}
+}
+
+func TestVinmesCatalogRefreshRecordsDiffRun(t *testing.T) {
+	t.Parallel()
+
+	server := newVinmesCatalogTestServer(t)
+	defer server.Close()
+	store := &memoryVinmesCatalogStore{items: []models.VinmesCatalogItem{
+		{CatalogType: "product", ExternalID: "999", Code: "OLD", Name: "Đã ngừng", RawPayload: `{"id":999}`},
+	}}
+	refreshLog := &memoryVinmesCatalogRefreshLog{}
+	service := NewVinmesCatalogService(VinmesCatalogConfig{
+		APIBaseURL:   server.URL,
+		APIToken:     "test-token",
+		CatalogStore: store,
+		RefreshLog:   refreshLog,
+	})
+
+	result, err := service.RefreshCatalogs(context.Background())
+	if err != nil {
+		t.Fatalf("RefreshCatalogs() error = %v", err)
+	}
+	if result.RemovedCount != 1 || result.AddedCount != 8 {
+		t.Fatalf("refresh counts = added %d, removed %d", result.AddedCount, result.RemovedCount)
+	}
+	if result.RunID == nil || len(refreshLog.runs) != 1 {
+		t.Fatalf("recorded runs = %d, run ID = %v", len(refreshLog.runs), result.RunID)
+	}
+	run := refreshLog.runs[0]
+	if run.Trigger != models.VinmesCatalogRefreshTriggerManual || run.Status != models.VinmesCatalogRefreshStatusSuccess {
+		t.Fatalf("recorded run = %+v", run)
+	}
+
+	server.Close()
+	if _, err := service.RefreshCatalogs(context.Background()); err == nil {
+		t.Fatal("expected refresh to fail once the Vinmes server is gone")
+	}
+	if len(refreshLog.runs) != 2 || refreshLog.runs[1].Status != models.VinmesCatalogRefreshStatusFailed {
+		t.Fatalf("failed refresh was not recorded: %+v", refreshLog.runs)
+	}
+}
+
+func TestVinmesCatalogDurationUntilNextRun(t *testing.T) {
+	t.Parallel()
+
+	location := time.FixedZone("ICT", 7*60*60)
+	service := NewVinmesCatalogService(VinmesCatalogConfig{APIBaseURL: "http://vinmes.internal"})
+	service.schedule = vinmesCatalogSchedule{enabled: true, hour: 2, minute: 30, location: location}
+
+	beforeRun := time.Date(2026, 7, 7, 1, 0, 0, 0, location)
+	if got := service.durationUntilNextRun(beforeRun); got != 90*time.Minute {
+		t.Fatalf("durationUntilNextRun(before) = %v, want 1h30m", got)
+	}
+
+	afterRun := time.Date(2026, 7, 7, 2, 30, 0, 0, location)
+	if got := service.durationUntilNextRun(afterRun); got != 24*time.Hour {
+		t.Fatalf("durationUntilNextRun(at run time) = %v, want 24h", got)
+	}
+}
+
+type memoryVinmesCatalogRefreshLog struct {
+	runs []models.VinmesCatalogRefreshRun
+}
+
+func (l *memoryVinmesCatalogRefreshLog) RecordRefreshRun(run *models.VinmesCatalogRefreshRun) error {
+	run.ID = int64(len(l.runs) + 1)
+	l.runs = append(l.runs, *run)
+	return nil
+}
+
+func (l *memoryVinmesCatalogRefreshLog) ListRefreshRuns(int) ([]models.VinmesCatalogRefreshRun, error) {
+	return append([]models.VinmesCatalogRefreshRun(nil), l.runs...), nil
+}
+
+func (l *memoryVinmesCatalogRefreshLog) GetRefreshRun(runID int64) (*models.VinmesCatalogRefreshRun, error) {
+	for index := range l.runs {
+		if l.runs[index].ID == runID {
+			run := l.runs[index]
+			return &run, nil
+		}
+	}
+	return nil, nil
+}
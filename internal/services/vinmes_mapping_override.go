@@ -0,0 +1,249 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+var (
+	ErrVinmesMappingOverrideNotFound = errors.New("vinmes mapping override not found")
+	ErrInvalidVinmesMappingOverride  = errors.New("invalid vinmes mapping override")
+)
+
+type VinmesMappingOverrideStore interface {
+	ListMappingOverrides() ([]models.VinmesMappingOverride, error)
+	GetMappingOverride(id int64) (*models.VinmesMappingOverride, error)
+	CreateMappingOverride(override *models.VinmesMappingOverride) error
+	UpdateMappingOverride(override *models.VinmesMappingOverride) (bool, error)
+	DeleteMappingOverride(id int64) (bool, error)
+}
+
+// vinmesOverrideMatchFields lists which source fields each override type can
+// be keyed on.
+var vinmesOverrideMatchFields = map[string][]string{
+	models.VinmesOverrideTypePartner:         {models.VinmesOverrideMatchTaxCode, models.VinmesOverrideMatchSupplierName},
+	models.VinmesOverrideTypeProduct:         {models.VinmesOverrideMatchMaterialCode},
+	models.VinmesOverrideTypeContractPackage: {models.VinmesOverrideMatchTenderReference},
+}
+
+// vinmesMappingOverrides indexes overrides by type, match field and
+// normalized source value.
+type vinmesMappingOverrides map[string]models.VinmesMappingOverride
+
+func newVinmesMappingOverrides(items []models.VinmesMappingOverride) vinmesMappingOverrides {
+	overrides := make(vinmesMappingOverrides, len(items))
+	for _, item := range items {
+		overrides[vinmesMappingOverrideIndexKey(item.OverrideType, item.MatchField, item.SourceKey)] = item
+	}
+	return overrides
+}
+
+func (o vinmesMappingOverrides) find(overrideType, matchField, sourceValue string) (models.VinmesMappingOverride, bool) {
+	key := vinmesMappingOverrideSourceKey(matchField, sourceValue)
+	if len(o) == 0 || key == "" {
+		return models.VinmesMappingOverride{}, false
+	}
+	override, ok := o[vinmesMappingOverrideIndexKey(overrideType, matchField, key)]
+	return override, ok
+}
+
+func vinmesMappingOverrideIndexKey(overrideType, matchField, sourceKey string) string {
+	return overrideType + "|" + matchField + "|" + sourceKey
+}
+
+// vinmesMappingOverrideSourceKey normalizes a source value the same way the
+// mapper normalizes it for matching, so overrides survive formatting noise.
+func vinmesMappingOverrideSourceKey(matchField, value string) string {
+	switch matchField {
+	case models.VinmesOverrideMatchTaxCode, models.VinmesOverrideMatchMaterialCode:
+		return normalizeCode(value)
+	default:
+		return normalizeLookup(value)
+	}
+}
+
+func (s *VinmesCatalogService) loadMappingOverrides() (vinmesMappingOverrides, error) {
+	if s.overrideStore == nil {
+		return nil, nil
+	}
+	items, err := s.overrideStore.ListMappingOverrides()
+	if err != nil {
+		return nil, err
+	}
+	return newVinmesMappingOverrides(items), nil
+}
+
+func (s *VinmesCatalogService) ListMappingOverrides() ([]models.VinmesMappingOverride, error) {
+	if s == nil || s.overrideStore == nil {
+		return nil, fmt.Errorf("Vinmes mapping override store is not configured")
+	}
+	return s.overrideStore.ListMappingOverrides()
+}
+
+func (s *VinmesCatalogService) CreateMappingOverride(override *models.VinmesMappingOverride, actor models.OrderActor) (*models.VinmesMappingOverride, error) {
+	if s == nil || s.overrideStore == nil {
+		return nil, fmt.Errorf("Vinmes mapping override store is not configured")
+	}
+	if err := prepareVinmesMappingOverride(override, actor); err != nil {
+		return nil, err
+	}
+	if err := s.overrideStore.CreateMappingOverride(override); err != nil {
+		return nil, err
+	}
+	return s.getMappingOverride(override.ID)
+}
+
+func (s *VinmesCatalogService) UpdateMappingOverride(override *models.VinmesMappingOverride, actor models.OrderActor) (*models.VinmesMappingOverride, error) {
+	if s == nil || s.overrideStore == nil {
+		return nil, fmt.Errorf("Vinmes mapping override store is not configured")
+	}
+	if err := prepareVinmesMappingOverride(override, actor); err != nil {
+		return nil, err
+	}
+	updated, err := s.overrideStore.UpdateMappingOverride(override)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrVinmesMappingOverrideNotFound
+	}
+	return s.getMappingOverride(override.ID)
+}
+
+func (s *VinmesCatalogService) getMappingOverride(id int64) (*models.VinmesMappingOverride, error) {
+	override, err := s.overrideStore.GetMappingOverride(id)
+	if err != nil {
+		return nil, err
+	}
+	if override == nil {
+		return nil, ErrVinmesMappingOverrideNotFound
+	}
+	return override, nil
+}
+
+func (s *VinmesCatalogService) DeleteMappingOverride(id int64) error {
+	if s == nil || s.overrideStore == nil {
+		return fmt.Errorf("Vinmes mapping override store is not configured")
+	}
+	deleted, err := s.overrideStore.DeleteMappingOverride(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrVinmesMappingOverrideNotFound
+	}
+	return nil
+}
+
+func prepareVinmesMappingOverride(override *models.VinmesMappingOverride, actor models.OrderActor) error {
+	if override == nil {
+		return fmt.Errorf("%w: override is required", ErrInvalidVinmesMappingOverride)
+	}
+
+	override.OverrideType = strings.ToLower(strings.TrimSpace(override.OverrideType))
+	override.MatchField = strings.ToLower(strings.TrimSpace(override.MatchField))
+	override.SourceValue = strings.TrimSpace(override.SourceValue)
+	override.VinmesID = strings.TrimSpace(override.VinmesID)
+	override.Note = strings.TrimSpace(override.Note)
+
+	fields, ok := vinmesOverrideMatchFields[override.OverrideType]
+	if !ok {
+		return fmt.Errorf("%w: overrideType must be partner, product or contract_package", ErrInvalidVinmesMappingOverride)
+	}
+	if override.MatchField == "" && len(fields) == 1 {
+		override.MatchField = fields[0]
+	}
+	if !slices.Contains(fields, override.MatchField) {
+		return fmt.Errorf("%w: matchField must be one of %s for %s", ErrInvalidVinmesMappingOverride, strings.Join(fields, ", "), override.OverrideType)
+	}
+
+	override.SourceKey = vinmesMappingOverrideSourceKey(override.MatchField, override.SourceValue)
+	if override.SourceKey == "" {
+		return fmt.Errorf("%w: sourceValue is required", ErrInvalidVinmesMappingOverride)
+	}
+	if override.VinmesID == "" {
+		return fmt.Errorf("%w: vinmesId is required", ErrInvalidVinmesMappingOverride)
+	}
+	if override.OverrideType != models.VinmesOverrideTypePartner {
+		if _, err := strconv.ParseInt(override.VinmesID, 10, 64); err != nil {
+			return fmt.Errorf("%w: vinmesId must be numeric for %s", ErrInvalidVinmesMappingOverride, override.OverrideType)
+		}
+	}
+
+	override.UpdatedByUserID = nil
+	if actor.ID > 0 {
+		override.UpdatedByUserID = int64Pointer(actor.ID)
+	}
+	override.UpdatedByUsername = actor.Username
+	return nil
+}
+
+// applyPartnerOverride resolves the partner from a manual override keyed on
+// tax code first, then on supplier name. It reports whether an override was
+// found, even when its target is missing from the catalog.
+func applyPartnerOverride(master models.VinmesExportMaster, catalogs *vinmesCatalogs, overrides vinmesMappingOverrides, mapped *VinmesMappedPurchaseOrder) bool {
+	override, ok := overrides.find(models.VinmesOverrideTypePartner, models.VinmesOverrideMatchTaxCode, master.MaSoThueNhaCungCap)
+	sourceValue := master.MaSoThueNhaCungCap
+	if !ok {
+		override, ok = overrides.find(models.VinmesOverrideTypePartner, models.VinmesOverrideMatchSupplierName, master.NhaCungCap)
+		sourceValue = master.NhaCungCap
+	}
+	if !ok {
+		return false
+	}
+
+	for _, partner := range catalogs.Partners {
+		if strings.TrimSpace(partner.ID) == override.VinmesID {
+			mapped.Master.Binds.PartnerID = stringPointer(partner.ID)
+			mapped.PartnerMatchMethod = "override"
+			return true
+		}
+	}
+	mapped.addError("p_partner_id", sourceValue, fmt.Sprintf("Mapping thủ công trỏ tới nhà cung cấp Vinmes %s không có trong danh mục", override.VinmesID))
+	return true
+}
+
+func applyContractPackageOverride(master models.VinmesExportMaster, catalogs *vinmesCatalogs, overrides vinmesMappingOverrides, mapped *VinmesMappedPurchaseOrder) bool {
+	override, ok := overrides.find(models.VinmesOverrideTypeContractPackage, models.VinmesOverrideMatchTenderReference, master.GoiThau)
+	if !ok {
+		return false
+	}
+
+	for _, item := range catalogs.ContractPackages {
+		if strings.TrimSpace(item.ID) != override.VinmesID {
+			continue
+		}
+		packageID, err := strconv.ParseInt(override.VinmesID, 10, 64)
+		if err != nil {
+			break
+		}
+		mapped.Master.Binds.ContractPackageID = int64Pointer(packageID)
+		return true
+	}
+	mapped.addError("p_contractpkg_id", master.GoiThau, fmt.Sprintf("Mapping thủ công trỏ tới gói thầu Vinmes %s không có trong danh mục", override.VinmesID))
+	return true
+}
+
+func applyProductOverride(index int, detail models.VinmesExportDetail, catalogs *vinmesCatalogs, overrides vinmesMappingOverrides, mapped *VinmesMappedPurchaseOrder, request *VinmesC10DetailRequest) bool {
+	override, ok := overrides.find(models.VinmesOverrideTypeProduct, models.VinmesOverrideMatchMaterialCode, detail.MaHang)
+	if !ok {
+		return false
+	}
+
+	productID, err := strconv.ParseInt(override.VinmesID, 10, 64)
+	if err == nil {
+		for _, product := range catalogs.Products {
+			if product.ID == productID {
+				request.Binds.ProductID = int64Pointer(productID)
+				return true
+			}
+		}
+	}
+	mapped.addError(fmt.Sprintf("details[%d].p_product_id", index), detail.MaHang, fmt.Sprintf("Mapping thủ công trỏ tới sản phẩm Vinmes %s không có trong danh mục", override.VinmesID))
+	return true
+}
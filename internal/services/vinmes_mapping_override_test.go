@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+func TestVinmesMappingPreviewPrefersManualOverrides(t *testing.T) {
+	t.Parallel()
+
+	server := newVinmesCatalogTestServer(t)
+	defer server.Close()
+
+	store := &memoryVinmesMappingOverrideStore{}
+	service := NewVinmesCatalogService(VinmesCatalogConfig{
+		APIBaseURL:    server.URL,
+		APIToken:      "test-token",
+		OverrideStore: store,
+	})
+
+	actor := models.OrderActor{ID: 1, Username: "admin"}
+	for _, override := range []models.VinmesMappingOverride{
+		{OverrideType: models.VinmesOverrideTypePartner, MatchField: models.VinmesOverrideMatchTaxCode, SourceValue: "0101-234-567", VinmesID: "TB.TRGTIEN"},
+		{OverrideType: models.VinmesOverrideTypeProduct, SourceValue: "vt-local-01", VinmesID: "12345"},
+		{OverrideType: models.VinmesOverrideTypeContractPackage, SourceValue: "Gói thầu số 5", VinmesID: "123"},
+	} {
+		if _, err := service.CreateMappingOverride(&override, actor); err != nil {
+			t.Fatalf("CreateMappingOverride(%s) error = %v", override.OverrideType, err)
+		}
+	}
+
+	preview, err := service.BuildMappingPreview(context.Background(), []models.VinmesExportMaster{
+		{
+			UserID:             "trangbi",
+			GoiThau:            "GÓI THẦU SỐ 5",
+			KhoHang:            "Kho vật tư tiêu hao",
+			Nguon:              "Mua",
+			NhaCungCap:         "Nhà cung cấp theo thuế",
+			MaSoThueNhaCungCap: "0101234567",
+			SoPhieu:            "PN20260707000089",
+			KyHieu:             "1C26TKK",
+			SoHoaDon:           "00000316",
+			NgayYeuCau:         "07/07/2026",
+			NgayHoaDon:         "06/07/2026",
+			Thue:               "0%",
+			Details: []models.VinmesExportDetail{
+				{MaHang: "VT-LOCAL-01", SoLuong: 2, ReconciliationID: 89},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildMappingPreview() error = %v", err)
+	}
+
+	item := preview[0]
+	if item.PartnerMatchMethod != "override" {
+		t.Fatalf("partnerMatchMethod = %q", item.PartnerMatchMethod)
+	}
+	if item.Master.Binds.PartnerID == nil || *item.Master.Binds.PartnerID != "TB.TRGTIEN" {
+		t.Fatalf("partner ID = %v", item.Master.Binds.PartnerID)
+	}
+	if item.Master.Binds.ContractPackageID == nil || *item.Master.Binds.ContractPackageID != 123 {
+		t.Fatalf("contract package ID = %v", item.Master.Binds.ContractPackageID)
+	}
+	if len(item.Details) != 1 || item.Details[0].Binds.ProductID == nil || *item.Details[0].Binds.ProductID != 12345 {
+		t.Fatalf("product mapping = %+v", item.Details)
+	}
+	if len(item.ValidationErrors) != 0 {
+		t.Fatalf("unexpected validation errors: %+v", item.ValidationErrors)
+	}
+}
+
+func TestVinmesMappingOverrideRejectsTargetMissingFromCatalog(t *testing.T) {
+	t.Parallel()
+
+	overrides := newVinmesMappingOverrides([]models.VinmesMappingOverride{
+		{OverrideType: models.VinmesOverrideTypePartner, MatchField: models.VinmesOverrideMatchSupplierName, SourceKey: "cong ty a", VinmesID: "GONE"},
+	})
+	mapped := VinmesMappedPurchaseOrder{}
+	applied := applyPartnerOverride(
+		models.VinmesExportMaster{NhaCungCap: "Công ty A"},
+		&vinmesCatalogs{Partners: []vinmesPartner{{ID: "OTHER", Name: "Công ty A"}}},
+		overrides,
+		&mapped,
+	)
+	if !applied {
+		t.Fatal("expected override to be applied")
+	}
+	if mapped.Master.Binds.PartnerID != nil {
+		t.Fatalf("partner ID = %v, want nil", mapped.Master.Binds.PartnerID)
+	}
+	if len(mapped.ValidationErrors) != 1 || mapped.ValidationErrors[0].Field != "p_partner_id" {
+		t.Fatalf("validation errors = %+v", mapped.ValidationErrors)
+	}
+}
+
+func TestPrepareVinmesMappingOverrideValidatesInput(t *testing.T) {
+	t.Parallel()
+
+	testCases := []models.VinmesMappingOverride{
+		{OverrideType: "storage", SourceValue: "Kho", VinmesID: "5"},
+		{OverrideType: models.VinmesOverrideTypePartner, MatchField: models.VinmesOverrideMatchMaterialCode, SourceValue: "X", VinmesID: "P"},
+		{OverrideType: models.VinmesOverrideTypeProduct, SourceValue: "VT01", VinmesID: "ABC"},
+		{OverrideType: models.VinmesOverrideTypeContractPackage, SourceValue: " ", VinmesID: "123"},
+	}
+	for _, tc := range testCases {
+		override := tc
+		if err := prepareVinmesMappingOverride(&override, models.OrderActor{}); !errors.Is(err, ErrInvalidVinmesMappingOverride) {
+			t.Fatalf("prepareVinmesMappingOverride(%+v) error = %v", tc, err)
+		}
+	}
+}
+
+type memoryVinmesMappingOverrideStore struct {
+	items []models.VinmesMappingOverride
+}
+
+func (s *memoryVinmesMappingOverrideStore) ListMappingOverrides() ([]models.VinmesMappingOverride, error) {
+	return append([]models.VinmesMappingOverride(nil), s.items...), nil
+}
+
+func (s *memoryVinmesMappingOverrideStore) GetMappingOverride(id int64) (*models.VinmesMappingOverride, error) {
+	for index := range s.items {
+		if s.items[index].ID == id {
+			item := s.items[index]
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryVinmesMappingOverrideStore) CreateMappingOverride(override *models.VinmesMappingOverride) error {
+	override.ID = int64(len(s.items) + 1)
+	s.items = append(s.items, *override)
+	return nil
+}
+
+func (s *memoryVinmesMappingOverrideStore) UpdateMappingOverride(override *models.VinmesMappingOverride) (bool, error) {
+	for index := range s.items {
+		if s.items[index].ID == override.ID {
+			s.items[index] = *override
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memoryVinmesMappingOverrideStore) DeleteMappingOverride(id int64) (bool, error) {
+	for index := range s.items {
+		if s.items[index].ID == id {
+			s.items = append(s.items[:index], s.items[index+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
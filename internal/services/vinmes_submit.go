@@ -0,0 +1,383 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+const (
+	vinmesC10MasterResource = "po_c10_master"
+	vinmesC10DetailResource = "po_c10_detail"
+)
+
+const (
+	VinmesSubmissionStatusSubmitted        = "submitted"
+	VinmesSubmissionStatusAlreadySubmitted = "already_submitted"
+	VinmesSubmissionStatusInvalid          = "invalid"
+	VinmesSubmissionStatusFailed           = "failed"
+)
+
+var (
+	ErrVinmesExportEntryNotFound    = errors.New("vinmes export ledger entry not found")
+	ErrVinmesExportEntryAlreadySent = errors.New("vinmes export ledger entry was already sent")
+	ErrVinmesExportEntryInFlight    = errors.New("vinmes export ledger entry is still being submitted")
+)
+
+type VinmesSubmissionStore interface {
+	ListVinmesPurchaseOrderIDs(reconciliationIDs []int64) (map[int64]int64, error)
+	MarkVinmesSubmitted(reconciliationIDs []int64, purchaseOrderID int64, submittedAt time.Time) error
+}
+
+type VinmesExportLedgerStore interface {
+	CreateVinmesExportEntry(entry *models.VinmesExportLedgerEntry) error
+	RecordVinmesExportAttempt(entryID int64, attempt models.VinmesExportAttempt) error
+	GetVinmesExportEntry(entryID int64) (*models.VinmesExportLedgerEntry, error)
+}
+
+type VinmesSubmissionResult struct {
+	BatchID           string                         `json:"batchId,omitempty"`
+	LedgerEntryID     *int64                         `json:"ledgerEntryId,omitempty"`
+	SoPhieu           string                         `json:"soPhieu"`
+	SoHoaDon          string                         `json:"soHoaDon"`
+	NhaCungCap        string                         `json:"nhaCungCap"`
+	ReconciliationIDs []int64                        `json:"reconciliationIds"`
+	Status            string                         `json:"status"`
+	PurchaseOrderID   *int64                         `json:"purchaseOrderId,omitempty"`
+	SubmittedDetails  int                            `json:"submittedDetails"`
+	ValidationErrors  []VinmesMappingValidationError `json:"validationErrors,omitempty"`
+	Message           string                         `json:"message,omitempty"`
+}
+
+// SubmitPurchaseOrders pushes each mapped order to Vinmes as a C10 master
+// followed by its details. Orders with validation errors or with any
+// reconciliation row that already carries a Vinmes PO are never sent. Every
+// order that is sent gets a ledger entry under one batch ID.
+func (s *VinmesCatalogService) SubmitPurchaseOrders(ctx context.Context, orders []VinmesMappedPurchaseOrder, actor models.OrderActor) ([]VinmesSubmissionResult, error) {
+	if !s.IsConfigured() {
+		return nil, fmt.Errorf("Vinmes catalog API is not configured")
+	}
+	if s.submissionStore == nil {
+		return nil, fmt.Errorf("Vinmes submission store is not configured")
+	}
+
+	s.submitMu.Lock()
+	defer s.submitMu.Unlock()
+
+	allIDs := make([]int64, 0)
+	for _, order := range orders {
+		allIDs = append(allIDs, order.Source.ReconciliationIDs...)
+	}
+	submitted, err := s.submissionStore.ListVinmesPurchaseOrderIDs(allIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	batchID := newVinmesExportBatchID(time.Now())
+	results := make([]VinmesSubmissionResult, 0, len(orders))
+	for _, order := range orders {
+		result := VinmesSubmissionResult{
+			BatchID:           batchID,
+			SoPhieu:           order.Source.SoPhieu,
+			SoHoaDon:          order.Source.SoHoaDon,
+			NhaCungCap:        order.Source.NhaCungCap,
+			ReconciliationIDs: order.Source.ReconciliationIDs,
+		}
+
+		if existingID, ok := firstSubmittedPurchaseOrder(order.Source.ReconciliationIDs, submitted); ok {
+			result.Status = VinmesSubmissionStatusAlreadySubmitted
+			result.PurchaseOrderID = int64Pointer(existingID)
+			result.Message = "Phiếu đã được gửi sang Vinmes trước đó"
+			results = append(results, result)
+			continue
+		}
+		if len(order.ValidationErrors) > 0 {
+			result.Status = VinmesSubmissionStatusInvalid
+			result.ValidationErrors = order.ValidationErrors
+			result.Message = "Phiếu còn lỗi ánh xạ danh mục Vinmes"
+			results = append(results, result)
+			continue
+		}
+
+		var entry *models.VinmesExportLedgerEntry
+		if s.exportLedger != nil {
+			entry, err = newVinmesExportLedgerEntry(batchID, order, actor)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.exportLedger.CreateVinmesExportEntry(entry); err != nil {
+				return nil, err
+			}
+			result.LedgerEntryID = int64Pointer(entry.ID)
+		}
+
+		s.submitPurchaseOrder(ctx, order, nil, 0, &result)
+		s.recordExportAttempt(entry, &result)
+		if result.PurchaseOrderID != nil {
+			for _, id := range order.Source.ReconciliationIDs {
+				submitted[id] = *result.PurchaseOrderID
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// RetryExportEntry resends a failed ledger entry, or one left queued past
+// models.VinmesExportQueuedTimeout, from its stored payload. When the master
+// already reached Vinmes, only the details that were not acknowledged are
+// sent again.
+func (s *VinmesCatalogService) RetryExportEntry(ctx context.Context, entryID int64) (*VinmesSubmissionResult, error) {
+	if !s.IsConfigured() {
+		return nil, fmt.Errorf("Vinmes catalog API is not configured")
+	}
+	if s.submissionStore == nil || s.exportLedger == nil {
+		return nil, fmt.Errorf("Vinmes export ledger is not configured")
+	}
+
+	s.submitMu.Lock()
+	defer s.submitMu.Unlock()
+
+	entry, err := s.exportLedger.GetVinmesExportEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrVinmesExportEntryNotFound
+	}
+	if entry.Status == models.VinmesExportStatusSent {
+		return nil, ErrVinmesExportEntryAlreadySent
+	}
+	if entry.IsInFlight(time.Now()) {
+		return nil, ErrVinmesExportEntryInFlight
+	}
+
+	var order VinmesMappedPurchaseOrder
+	if err := json.Unmarshal([]byte(entry.Payload), &order); err != nil {
+		return nil, fmt.Errorf("decode Vinmes export ledger payload: %w", err)
+	}
+
+	result := VinmesSubmissionResult{
+		BatchID:           entry.BatchID,
+		LedgerEntryID:     int64Pointer(entry.ID),
+		SoPhieu:           entry.SoPhieu,
+		SoHoaDon:          entry.SoHoaDon,
+		NhaCungCap:        entry.NhaCungCap,
+		ReconciliationIDs: order.Source.ReconciliationIDs,
+	}
+
+	if entry.PurchaseOrderID == nil {
+		submitted, err := s.submissionStore.ListVinmesPurchaseOrderIDs(order.Source.ReconciliationIDs)
+		if err != nil {
+			return nil, err
+		}
+		if existingID, ok := firstSubmittedPurchaseOrder(order.Source.ReconciliationIDs, submitted); ok {
+			result.Status = VinmesSubmissionStatusAlreadySubmitted
+			result.PurchaseOrderID = int64Pointer(existingID)
+			result.Message = "Phiếu đã được gửi sang Vinmes trước đó"
+			return &result, nil
+		}
+	}
+
+	s.submitPurchaseOrder(ctx, order, entry.PurchaseOrderID, entry.SubmittedDetails, &result)
+	s.recordExportAttempt(entry, &result)
+	return &result, nil
+}
+
+// submitPurchaseOrder sends the master unless purchaseOrderID is already
+// known, then sends the details starting at firstDetail.
+func (s *VinmesCatalogService) submitPurchaseOrder(ctx context.Context, order VinmesMappedPurchaseOrder, purchaseOrderID *int64, firstDetail int, result *VinmesSubmissionResult) {
+	if purchaseOrderID == nil {
+		createdID, err := s.postC10Master(ctx, order.Master)
+		if err != nil {
+			result.Status = VinmesSubmissionStatusFailed
+			result.Message = err.Error()
+			return
+		}
+		purchaseOrderID = int64Pointer(createdID)
+
+		// The master already exists in Vinmes at this point, so the PO is
+		// recorded before the details go out to keep a failed detail from
+		// causing a duplicate master on the next attempt.
+		if err := s.submissionStore.MarkVinmesSubmitted(order.Source.ReconciliationIDs, createdID, time.Now()); err != nil {
+			result.Status = VinmesSubmissionStatusFailed
+			result.Message = err.Error()
+			return
+		}
+	}
+	result.PurchaseOrderID = purchaseOrderID
+	result.SubmittedDetails = firstDetail
+
+	for index := firstDetail; index < len(order.Details); index++ {
+		detail := order.Details[index]
+		detail.Binds.PurchaseOrderID = int64Pointer(*purchaseOrderID)
+		if _, err := s.executeVinmesDML(ctx, vinmesC10DetailResource, detail); err != nil {
+			result.Status = VinmesSubmissionStatusFailed
+			result.Message = fmt.Sprintf("details[%d]: %v", index, err)
+			return
+		}
+		result.SubmittedDetails++
+	}
+
+	result.Status = VinmesSubmissionStatusSubmitted
+}
+
+func (s *VinmesCatalogService) recordExportAttempt(entry *models.VinmesExportLedgerEntry, result *VinmesSubmissionResult) {
+	if s.exportLedger == nil || entry == nil {
+		return
+	}
+
+	attempt := models.VinmesExportAttempt{
+		Status:           models.VinmesExportStatusFailed,
+		ErrorMessage:     result.Message,
+		PurchaseOrderID:  result.PurchaseOrderID,
+		SubmittedDetails: result.SubmittedDetails,
+	}
+	if result.Status == VinmesSubmissionStatusSubmitted {
+		attempt.Status = models.VinmesExportStatusSent
+	}
+	if err := s.exportLedger.RecordVinmesExportAttempt(entry.ID, attempt); err != nil {
+		log.Printf("[vinmes-export] warning: failed to record attempt for ledger entry %d: %v", entry.ID, err)
+	}
+}
+
+func newVinmesExportLedgerEntry(batchID string, order VinmesMappedPurchaseOrder, actor models.OrderActor) (*models.VinmesExportLedgerEntry, error) {
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return nil, fmt.Errorf("encode Vinmes export ledger payload: %w", err)
+	}
+
+	entry := &models.VinmesExportLedgerEntry{
+		BatchID:             batchID,
+		SoPhieu:             order.Source.SoPhieu,
+		SoHoaDon:            order.Source.SoHoaDon,
+		NhaCungCap:          order.Source.NhaCungCap,
+		ReconciliationIDs:   order.Source.ReconciliationIDs,
+		Payload:             string(payload),
+		RequestedByUsername: actor.Username,
+	}
+	if actor.ID > 0 {
+		entry.RequestedByUserID = int64Pointer(actor.ID)
+	}
+	return entry, nil
+}
+
+func newVinmesExportBatchID(now time.Time) string {
+	return "VMX" + now.Format("20060102150405.000000")
+}
+
+func (s *VinmesCatalogService) postC10Master(ctx context.Context, master VinmesC10MasterRequest) (int64, error) {
+	data, err := s.executeVinmesDML(ctx, vinmesC10MasterResource, master)
+	if err != nil {
+		return 0, err
+	}
+	purchaseOrderID, err := parseVinmesPurchaseOrderID(data)
+	if err != nil {
+		return 0, fmt.Errorf("decode Vinmes %s response: %w", vinmesC10MasterResource, err)
+	}
+	return purchaseOrderID, nil
+}
+
+func (s *VinmesCatalogService) executeVinmesDML(ctx context.Context, resource string, payload any) (json.RawMessage, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode Vinmes %s request: %w", resource, err)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s?method=execute", s.apiBaseURL, url.PathEscape(resource))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create Vinmes %s request: %w", resource, err)
+	}
+	if s.apiToken != "" {
+		req.Header.Set("Authorization", bearerAuthorization(s.apiToken))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request Vinmes %s: %w", resource, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return nil, fmt.Errorf("Vinmes %s returned HTTP %d: %s", resource, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("decode Vinmes %s response: %w", resource, err)
+	}
+	return envelope.Data, nil
+}
+
+// parseVinmesPurchaseOrderID accepts the PO ID either as a plain object, as
+// the first row of an array, or nested under outBinds.
+func parseVinmesPurchaseOrderID(data json.RawMessage) (int64, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return 0, fmt.Errorf("response has no data field")
+	}
+
+	if trimmed[0] == '[' {
+		var rows []json.RawMessage
+		if err := json.Unmarshal(trimmed, &rows); err != nil {
+			return 0, err
+		}
+		if len(rows) == 0 {
+			return 0, fmt.Errorf("response data is empty")
+		}
+		return parseVinmesPurchaseOrderID(rows[0])
+	}
+
+	var payload struct {
+		POID       *vinmesStringID `json:"p_po_id"`
+		PurchaseID *vinmesStringID `json:"po_id"`
+		OutBinds   json.RawMessage `json:"outBinds"`
+	}
+	if err := json.Unmarshal(trimmed, &payload); err != nil {
+		return 0, err
+	}
+
+	var rawID *vinmesStringID
+	switch {
+	case payload.POID != nil:
+		rawID = payload.POID
+	case payload.PurchaseID != nil:
+		rawID = payload.PurchaseID
+	case len(payload.OutBinds) > 0:
+		return parseVinmesPurchaseOrderID(payload.OutBinds)
+	default:
+		return 0, fmt.Errorf("response has no p_po_id")
+	}
+
+	purchaseOrderID, err := strconv.ParseInt(strings.TrimSpace(string(*rawID)), 10, 64)
+	if err != nil || purchaseOrderID <= 0 {
+		return 0, fmt.Errorf("invalid p_po_id %q", string(*rawID))
+	}
+	return purchaseOrderID, nil
+}
+
+func firstSubmittedPurchaseOrder(reconciliationIDs []int64, submitted map[int64]int64) (int64, bool) {
+	for _, id := range reconciliationIDs {
+		if purchaseOrderID, ok := submitted[id]; ok {
+			return purchaseOrderID, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bv108-consumables-management-backend/internal/models"
+)
+
+func TestSubmitPurchaseOrdersSendsMasterThenDetailsAndStoresPO(t *testing.T) {
+	t.Parallel()
+
+	vinmes := newVinmesSubmitTestServer(t, http.StatusOK)
+	defer vinmes.server.Close()
+	store := newMemoryVinmesSubmissionStore()
+	service := NewVinmesCatalogService(VinmesCatalogConfig{
+		APIBaseURL:      vinmes.server.URL,
+		APIToken:        "test-token",
+		SubmissionStore: store,
+	})
+
+	results, err := service.SubmitPurchaseOrders(context.Background(), []VinmesMappedPurchaseOrder{
+		newSubmittableVinmesOrder("PN20260707000088", 88, 89),
+	}, models.OrderActor{ID: 7, Username: "ketoan"})
+	if err != nil {
+		t.Fatalf("SubmitPurchaseOrders() error = %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("results = %d, want 1", len(results))
+	}
+	result := results[0]
+	if result.Status != VinmesSubmissionStatusSubmitted {
+		t.Fatalf("status = %q, message = %q", result.Status, result.Message)
+	}
+	if result.PurchaseOrderID == nil || *result.PurchaseOrderID != 9001 {
+		t.Fatalf("purchase order ID = %v", result.PurchaseOrderID)
+	}
+	if result.SubmittedDetails != 2 {
+		t.Fatalf("submitted details = %d, want 2", result.SubmittedDetails)
+	}
+	if store.purchaseOrders[88] != 9001 || store.purchaseOrders[89] != 9001 {
+		t.Fatalf("stored purchase orders = %v", store.purchaseOrders)
+	}
+
+	calls := vinmes.snapshot()
+	if len(calls) != 3 || calls[0] != vinmesC10MasterResource || calls[1] != vinmesC10DetailResource || calls[2] != vinmesC10DetailResource {
+		t.Fatalf("Vinmes calls = %v", calls)
+	}
+	for _, poID := range vinmes.detailPurchaseOrderIDs {
+		if poID != 9001 {
+			t.Fatalf("detail p_po_id = %d, want 9001", poID)
+		}
+	}
+}
+
+func TestSubmitPurchaseOrdersSkipsInvalidAndAlreadySubmittedOrders(t *testing.T) {
+	t.Parallel()
+
+	vinmes := newVinmesSubmitTestServer(t, http.StatusOK)
+	defer vinmes.server.Close()
+	store := newMemoryVinmesSubmissionStore()
+	store.purchaseOrders[90] = 7000
+	service := NewVinmesCatalogService(VinmesCatalogConfig{
+		APIBaseURL:      vinmes.server.URL,
+		APIToken:        "test-token",
+		SubmissionStore: store,
+	})
+
+	invalid := newSubmittableVinmesOrder("PN20260707000091", 91)
+	invalid.addError("p_partner_id", "ABC", "Không tìm thấy nhà cung cấp trong danh mục Vinmes")
+
+	results, err := service.SubmitPurchaseOrders(context.Background(), []VinmesMappedPurchaseOrder{
+		newSubmittableVinmesOrder("PN20260707000090", 90),
+		invalid,
+	}, models.OrderActor{ID: 7, Username: "ketoan"})
+	if err != nil {
+		t.Fatalf("SubmitPurchaseOrders() error = %v", err)
+	}
+	if results[0].Status != VinmesSubmissionStatusAlreadySubmitted || results[0].PurchaseOrderID == nil || *results[0].PurchaseOrderID != 7000 {
+		t.Fatalf("already submitted result = %+v", results[0])
+	}
+	if results[1].Status != VinmesSubmissionStatusInvalid || len(results[1].ValidationErrors) != 1 {
+		t.Fatalf("invalid result = %+v", results[1])
+	}
+	if calls := vinmes.snapshot(); len(calls) != 0 {
+		t.Fatalf("expected no Vinmes calls, got %v", calls)
+	}
+	if _, ok := store.purchaseOrders[91]; ok {
+		t.Fatal("invalid order must not be recorded as submitted")
+	}
+}
+
+func TestSubmitPurchaseOrdersReportsMasterFailureWithoutStoringPO(t *testing.T) {
+	t.Parallel()
+
+	vinmes := newVinmesSubmitTestServer(t, http.StatusInternalServerError)
+	defer vinmes.server.Close()
+	store := newMemoryVinmesSubmissionStore()
+	service := NewVinmesCatalogService(VinmesCatalogConfig{
+		APIBaseURL:      vinmes.server.URL,
+		APIToken:        "test-token",
+		SubmissionStore: store,
+	})
+
+	results, err := service.SubmitPurchaseOrders(context.Background(), []VinmesMappedPurchaseOrder{
+		newSubmittableVinmesOrder("PN20260707000092", 92),
+	}, models.OrderActor{ID: 7, Username: "ketoan"})
+	if err != nil {
+		t.Fatalf("SubmitPurchaseOrders() error = %v", err)
+	}
+	if results[0].Status != VinmesSubmissionStatusFailed || !strings.Contains(results[0].Message, "HTTP 500") {
+		t.Fatalf("failed result = %+v", results[0])
+	}
+	if len(store.purchaseOrders) != 0 {
+		t.Fatalf("stored purchase orders = %v", store.purchaseOrders)
+	}
+}
+
+func TestParseVinmesPurchaseOrderIDAcceptsKnownShapes(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{name: "object number", data: `{"p_po_id": 42}`},
+		{name: "object string", data: `{"p_po_id": "42"}`},
+		{name: "array row", data: `[{"po_id": 42}]`},
+		{name: "out binds", data: `{"outBinds": {"p_po_id": 42}}`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseVinmesPurchaseOrderID(json.RawMessage(tc.data))
+			if err != nil {
+				t.Fatalf("parseVinmesPurchaseOrderID(%s) error = %v", tc.data, err)
+			}
+			if got != 42 {
+				t.Fatalf("parseVinmesPurchaseOrderID(%s) = %d, want 42", tc.data, got)
+			}
+		})
+	}
+
+	if _, err := parseVinmesPurchaseOrderID(json.RawMessage(`{}`)); err == nil {
+		t.Fatal("expected error when p_po_id is missing")
+	}
+}
+
+func TestSubmitPurchaseOrdersWritesLedgerAndRetryResumesDetails(t *testing.T) {
+	t.Parallel()
+
+	vinmes := newVinmesSubmitTestServer(t, http.StatusOK)
+	defer vinmes.server.Close()
+	vinmes.failDetailCalls = 1
+	store := newMemoryVinmesSubmissionStore()
+	ledger := newMemoryVinmesExportLedger()
+	service := NewVinmesCatalogService(VinmesCatalogConfig{
+		APIBaseURL:      vinmes.server.URL,
+		APIToken:        "test-token",
+		SubmissionStore: store,
+		ExportLedger:    ledger,
+	})
+
+	results, err := service.SubmitPurchaseOrders(context.Background(), []VinmesMappedPurchaseOrder{
+		newSubmittableVinmesOrder("PN20260707000093", 93, 94),
+	}, models.OrderActor{ID: 7, Username: "ketoan"})
+	if err != nil {
+		t.Fatalf("SubmitPurchaseOrders() error = %v", err)
+	}
+	result := results[0]
+	if result.Status != VinmesSubmissionStatusFailed || result.LedgerEntryID == nil || result.BatchID == "" {
+		t.Fatalf("first attempt result = %+v", result)
+	}
+	entry := ledger.entries[*result.LedgerEntryID]
+	if entry.Status != models.VinmesExportStatusFailed || entry.AttemptCount != 1 || entry.SubmittedDetails != 0 {
+		t.Fatalf("ledger after failure = %+v", entry)
+	}
+	if entry.PurchaseOrderID == nil || *entry.PurchaseOrderID != 9001 || entry.RequestedByUsername != "ketoan" {
+		t.Fatalf("ledger after failure = %+v", entry)
+	}
+
+	retried, err := service.RetryExportEntry(context.Background(), entry.ID)
+	if err != nil {
+		t.Fatalf("RetryExportEntry() error = %v", err)
+	}
+	if retried.Status != VinmesSubmissionStatusSubmitted || retried.SubmittedDetails != 2 {
+		t.Fatalf("retry result = %+v", retried)
+	}
+	entry = ledger.entries[entry.ID]
+	if entry.Status != models.VinmesExportStatusSent || entry.AttemptCount != 2 {
+		t.Fatalf("ledger after retry = %+v", entry)
+	}
+
+	calls := vinmes.snapshot()
+	masterCalls := 0
+	for _, call := range calls {
+		if call == vinmesC10MasterResource {
+			masterCalls++
+		}
+	}
+	if masterCalls != 1 {
+		t.Fatalf("master calls = %d, want 1 (calls = %v)", masterCalls, calls)
+	}
+
+	if _, err := service.RetryExportEntry(context.Background(), entry.ID); err != ErrVinmesExportEntryAlreadySent {
+		t.Fatalf("RetryExportEntry() on sent entry error = %v", err)
+	}
+}
+
+func TestRetryExportEntryRetriesOnlyStaleQueuedEntries(t *testing.T) {
+	t.Parallel()
+
+	vinmes := newVinmesSubmitTestServer(t, http.StatusOK)
+	defer vinmes.server.Close()
+	ledger := newMemoryVinmesExportLedger()
+	service := NewVinmesCatalogService(VinmesCatalogConfig{
+		APIBaseURL:      vinmes.server.URL,
+		APIToken:        "test-token",
+		SubmissionStore: newMemoryVinmesSubmissionStore(),
+		ExportLedger:    ledger,
+	})
+
+	payload, err := json.Marshal(newSubmittableVinmesOrder("PN20260707000095", 95))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	ledger.entries[1] = &models.VinmesExportLedgerEntry{ID: 1, Status: models.VinmesExportStatusQueued, Payload: string(payload), CreatedAt: time.Now()}
+	ledger.entries[2] = &models.VinmesExportLedgerEntry{ID: 2, Status: models.VinmesExportStatusQueued, Payload: string(payload), CreatedAt: time.Now().Add(-models.VinmesExportQueuedTimeout - time.Minute)}
+
+	if _, err := service.RetryExportEntry(context.Background(), 1); err != ErrVinmesExportEntryInFlight {
+		t.Fatalf("RetryExportEntry() on fresh queued entry error = %v", err)
+	}
+	if len(vinmes.snapshot()) != 0 {
+		t.Fatalf("calls = %v, want none for an in-flight entry", vinmes.snapshot())
+	}
+
+	result, err := service.RetryExportEntry(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("RetryExportEntry() on stale queued entry error = %v", err)
+	}
+	if result.Status != VinmesSubmissionStatusSubmitted || ledger.entries[2].Status != models.VinmesExportStatusSent {
+		t.Fatalf("retry result = %+v, ledger = %+v", result, ledger.entries[2])
+	}
+}
+
+func newSubmittableVinmesOrder(soPhieu string, reconciliationIDs ...int64) VinmesMappedPurchaseOrder {
+	order := VinmesMappedPurchaseOrder{
+		Master: VinmesC10MasterRequest{
+			Options: VinmesC10Options{DML: true},
+			Binds: VinmesC10MasterBinds{
+				UserID:      "trangbi",
+				StorageID:   int64Pointer(5),
+				ResourceID:  int64Pointer(1),
+				PartnerID:   stringPointer("TB.TRGTIEN"),
+				InvoiceType: "P",
+				InvoiceNo:   "00000315",
+				Description: "Số phiếu BV108: " + soPhieu,
+			},
+		},
+		Details:          make([]VinmesC10DetailRequest, 0, len(reconciliationIDs)),
+		ValidationErrors: make([]VinmesMappingValidationError, 0),
+		Source: VinmesMappingSource{
+			SoPhieu:           soPhieu,
+			SoHoaDon:          "00000315",
+			ReconciliationIDs: reconciliationIDs,
+		},
+	}
+	for range reconciliationIDs {
+		order.Details = append(order.Details, VinmesC10DetailRequest{
+			Options: VinmesC10Options{DML: true},
+			Binds: VinmesC10DetailBinds{
+				UserID:    "trangbi",
+				ProductID: int64Pointer(12345),
+				Quantity:  15,
+			},
+		})
+	}
+	return order
+}
+
+type memoryVinmesSubmissionStore struct {
+	purchaseOrders map[int64]int64
+}
+
+func newMemoryVinmesSubmissionStore() *memoryVinmesSubmissionStore {
+	return &memoryVinmesSubmissionStore{purchaseOrders: make(map[int64]int64)}
+}
+
+func (s *memoryVinmesSubmissionStore) ListVinmesPurchaseOrderIDs(reconciliationIDs []int64) (map[int64]int64, error) {
+	result := make(map[int64]int64)
+	for _, id := range reconciliationIDs {
+		if purchaseOrderID, ok := s.purchaseOrders[id]; ok {
+			result[id] = purchaseOrderID
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryVinmesSubmissionStore) MarkVinmesSubmitted(reconciliationIDs []int64, purchaseOrderID int64, _ time.Time) error {
+	for _, id := range reconciliationIDs {
+		if _, ok := s.purchaseOrders[id]; !ok {
+			s.purchaseOrders[id] = purchaseOrderID
+		}
+	}
+	return nil
+}
+
+type memoryVinmesExportLedger struct {
+	nextID  int64
+	entries map[int64]*models.VinmesExportLedgerEntry
+}
+
+func newMemoryVinmesExportLedger() *memoryVinmesExportLedger {
+	return &memoryVinmesExportLedger{entries: make(map[int64]*models.VinmesExportLedgerEntry)}
+}
+
+func (l *memoryVinmesExportLedger) CreateVinmesExportEntry(entry *models.VinmesExportLedgerEntry) error {
+	l.nextID++
+	entry.ID = l.nextID
+	entry.Status = models.VinmesExportStatusQueued
+	stored := *entry
+	l.entries[entry.ID] = &stored
+	return nil
+}
+
+func (l *memoryVinmesExportLedger) RecordVinmesExportAttempt(entryID int64, attempt models.VinmesExportAttempt) error {
+	entry := l.entries[entryID]
+	entry.Status = attempt.Status
+	entry.ErrorMessage = attempt.ErrorMessage
+	entry.AttemptCount++
+	if attempt.PurchaseOrderID != nil {
+		entry.PurchaseOrderID = int64Pointer(*attempt.PurchaseOrderID)
+	}
+	entry.SubmittedDetails = attempt.SubmittedDetails
+	return nil
+}
+
+func (l *memoryVinmesExportLedger) GetVinmesExportEntry(entryID int64) (*models.VinmesExportLedgerEntry, error) {
+	entry, ok := l.entries[entryID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+type vinmesSubmitTestServer struct {
+	server                 *httptest.Server
+	mu                     sync.Mutex
+	calls                  []string
+	detailPurchaseOrderIDs []int64
+	failDetailCalls        int
+}
+
+func (s *vinmesSubmitTestServer) snapshot() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func newVinmesSubmitTestServer(t *testing.T, masterStatus int) *vinmesSubmitTestServer {
+	t.Helper()
+
+	stand := &vinmesSubmitTestServer{}
+	stand.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s", r.Method)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Authorization header missing")
+		}
+		if r.URL.Query().Get("method") != "execute" {
+			t.Errorf("method query = %q", r.URL.Query().Get("method"))
+		}
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		resource := parts[len(parts)-1]
+
+		stand.mu.Lock()
+		stand.calls = append(stand.calls, resource)
+		stand.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch resource {
+		case vinmesC10MasterResource:
+			if masterStatus != http.StatusOK {
+				http.Error(w, "master rejected", masterStatus)
+				return
+			}
+			var request VinmesC10MasterRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Errorf("decode master request: %v", err)
+			}
+			if !request.Options.DML || request.Binds.PartnerID == nil {
+				t.Errorf("unexpected master request: %+v", request)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"p_po_id": 9001}})
+		case vinmesC10DetailResource:
+			stand.mu.Lock()
+			failDetail := stand.failDetailCalls > 0
+			if failDetail {
+				stand.failDetailCalls--
+			}
+			stand.mu.Unlock()
+			if failDetail {
+				http.Error(w, "detail rejected", http.StatusBadGateway)
+				return
+			}
+			var request VinmesC10DetailRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Errorf("decode detail request: %v", err)
+			}
+			if request.Binds.PurchaseOrderID == nil {
+				t.Errorf("detail request missing p_po_id")
+			} else {
+				stand.mu.Lock()
+				stand.detailPurchaseOrderIDs = append(stand.detailPurchaseOrderIDs, *request.Binds.PurchaseOrderID)
+				stand.mu.Unlock()
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"rows_affected": 1}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return stand
+}
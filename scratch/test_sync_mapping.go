@@ -30,8 +30,8 @@ func main() {
 	config.AppConfig.SupplyMappingTable = "mapping"
 	os.Setenv("SUPPLY_MAPPING_TABLE", "mapping")
 
-	syncService1 := services.NewInternalSupplySyncService(config.AppConfig, supplyRepo, companyContactRepo)
-	_, err := syncService1.RunOnce(context.Background())
+	syncService1 := services.NewInternalSupplySyncService(config.AppConfig, supplyRepo, companyContactRepo, nil, nil)
+	_, err := syncService1.Sync(context.Background(), services.InternalSupplySyncOptions{})
 	if err != nil {
 		log.Fatalf("sync mode mapping error: %v", err)
 	}
@@ -43,8 +43,8 @@ func main() {
 	config.AppConfig.SupplyMappingTable = "mapping2"
 	os.Setenv("SUPPLY_MAPPING_TABLE", "mapping2")
 
-	syncService2 := services.NewInternalSupplySyncService(config.AppConfig, supplyRepo, companyContactRepo)
-	_, err = syncService2.RunOnce(context.Background())
+	syncService2 := services.NewInternalSupplySyncService(config.AppConfig, supplyRepo, companyContactRepo, nil, nil)
+	_, err = syncService2.Sync(context.Background(), services.InternalSupplySyncOptions{})
 	if err != nil {
 		log.Fatalf("sync mode mapping2 error: %v", err)
 	}